@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+)
+
+// ErrClientAborted is returned by the response writers when the request context is done,
+// typically because the client disconnected, before the response has been fully written.
+var ErrClientAborted = errors.New("client aborted the request")
+
+// StatusClientClosedRequest is the non-standard status (introduced by nginx) under which requests
+// cancelled by the client are logged and measured, to tell them apart from server errors.
+const StatusClientClosedRequest = 499
+
+// responseChunkSize is the size of the chunks in which WriteResponseBody writes,
+// checking the request context between chunks.
+const responseChunkSize = 32 << 10
+
+// clientAborts counts responses abandoned because the client disconnected.
+var clientAborts atomic.Int64
+
+// ClientAborts returns the number of responses whose encoding or writing was abandoned
+// because the client disconnected, since the process started.
+func ClientAborts() int64 {
+	return clientAborts.Load()
+}
+
+// CheckAborted returns ErrClientAborted if ctx is done, so that handlers and response writers
+// can stop producing a response nobody will read. Cancellations (client disconnects) are
+// counted by ClientAborts; expired deadlines are not.
+func CheckAborted(ctx context.Context) error {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) {
+		clientAborts.Add(1)
+	}
+	return ErrClientAborted
+}
+
+// WriteResponseBody writes data to w in chunks, stopping with ErrClientAborted
+// as soon as ctx is done. It is used by Context.JSON implementations.
+func WriteResponseBody(ctx context.Context, w io.Writer, data []byte) error {
+	for len(data) > 0 {
+		if err := CheckAborted(ctx); err != nil {
+			return err
+		}
+		n := min(len(data), responseChunkSize)
+		if _, err := w.Write(data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// EffectiveStatus returns StatusClientClosedRequest if the client cancelled the request of ctx,
+// and status otherwise. The logging and metrics middleware use it, so that responses nobody
+// received are not reported as server errors.
+func EffectiveStatus(ctx context.Context, status int) int {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return StatusClientClosedRequest
+	}
+	return status
+}
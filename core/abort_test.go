@@ -0,0 +1,94 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteResponseBody(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 3*responseChunkSize)
+
+	var buf bytes.Buffer
+	if err := WriteResponseBody(context.Background(), &buf, data); err != nil {
+		t.Fatalf("WriteResponseBody() error = %v", err)
+	}
+	if buf.Len() != len(data) {
+		t.Errorf("wrote %d bytes, want %d", buf.Len(), len(data))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	before := ClientAborts()
+	buf.Reset()
+	if err := WriteResponseBody(ctx, &buf, data); !errors.Is(err, ErrClientAborted) {
+		t.Fatalf("WriteResponseBody() error = %v, want ErrClientAborted", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("wrote %d bytes after cancellation, want 0", buf.Len())
+	}
+	if got := ClientAborts() - before; got != 1 {
+		t.Errorf("ClientAborts() increased by %d, want 1", got)
+	}
+}
+
+func TestCheckAbortedDeadline(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	before := ClientAborts()
+	if err := CheckAborted(ctx); !errors.Is(err, ErrClientAborted) {
+		t.Fatalf("CheckAborted() error = %v, want ErrClientAborted", err)
+	}
+	if ClientAborts() != before {
+		t.Error("expired deadlines should not be counted as client aborts")
+	}
+}
+
+func TestWriteJSONStreamAborted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	seq := func(yield func(interface{}) bool) {
+		for i := 0; i < 100; i++ {
+			if i == 2 {
+				// The client disconnects while the response is being streamed
+				cancel()
+			}
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	if err := WriteJSONStream(ctx, rec, http.StatusOK, seq); !errors.Is(err, ErrClientAborted) {
+		t.Fatalf("WriteJSONStream() error = %v, want ErrClientAborted", err)
+	}
+	if got := rec.Body.String(); !strings.HasPrefix(got, "[0,1") || strings.Contains(got, "2") {
+		t.Errorf("body = %q, want the elements produced before the disconnect", got)
+	}
+}
+
+func TestEffectiveStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if status := EffectiveStatus(ctx, http.StatusInternalServerError); status != http.StatusInternalServerError {
+		t.Errorf("EffectiveStatus() = %d before cancellation, want 500", status)
+	}
+	cancel()
+	if status := EffectiveStatus(ctx, http.StatusInternalServerError); status != StatusClientClosedRequest {
+		t.Errorf("EffectiveStatus() = %d after cancellation, want 499", status)
+	}
+
+	// Expired deadlines are not client cancellations
+	deadline, cancelDeadline := context.WithTimeout(context.Background(), -time.Second)
+	defer cancelDeadline()
+	if status := EffectiveStatus(deadline, http.StatusGatewayTimeout); status != http.StatusGatewayTimeout {
+		t.Errorf("EffectiveStatus() = %d after deadline, want 504", status)
+	}
+}
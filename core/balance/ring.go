@@ -0,0 +1,256 @@
+// Package balance selects backends for requests proxied to stateful services. A Ring maps keys,
+// such as a user or session ID, to backends by consistent hashing, so that requests for the same
+// entity land on the same backend, and adding or removing a backend only moves the keys of that
+// backend. Backends marked unhealthy are skipped: their keys move to the next healthy backend on
+// the ring and return once the backend is healthy again.
+//
+// Use Ring.Rewrite with httputil.ReverseProxy to route requests:
+//
+//	ring := balance.NewRing(0, "http://cart-1:8080", "http://cart-2:8080")
+//	go ring.MonitorHealth(ctx, 5*time.Second, balance.HTTPHealthCheck("/healthz", nil))
+//	proxy := &httputil.ReverseProxy{Rewrite: ring.Rewrite(balance.KeyByHeader("X-Session-ID"))}
+//	s.GET("/carts/:id", func(c server.Context) {
+//		proxy.ServeHTTP(c.Writer(), c.Request())
+//	})
+package balance
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultReplicas is the number of points each backend has on the ring when NewRing is called
+// with zero replicas. More points spread keys more evenly across backends.
+const DefaultReplicas = 100
+
+// Ring is a consistent hash ring of backends. It is safe for concurrent use.
+type Ring struct {
+	replicas int
+
+	mu       sync.RWMutex
+	points   []uint64          // Sorted hashes of the points of all backends
+	owners   map[uint64]string // Backend of each point
+	backends map[string]bool   // Backends and whether they are healthy
+}
+
+// NewRing returns a ring of the given backends, which are all healthy. Each backend has
+// replicas points on the ring; if replicas is zero or less, DefaultReplicas is used.
+func NewRing(replicas int, backends ...string) *Ring {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	r := &Ring{
+		replicas: replicas,
+		owners:   make(map[uint64]string),
+		backends: make(map[string]bool),
+	}
+	r.Add(backends...)
+	return r
+}
+
+// hash returns the position of s on the ring.
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	// FNV spreads similar strings poorly over the high bits; mix them, as in SplitMix64
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// Add adds healthy backends to the ring. Backends already on the ring are left as they are.
+func (r *Ring) Add(backends ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, backend := range backends {
+		if _, ok := r.backends[backend]; ok {
+			continue
+		}
+		r.backends[backend] = true
+		for i := 0; i < r.replicas; i++ {
+			point := hash(backend + "#" + strconv.Itoa(i))
+			if _, taken := r.owners[point]; taken {
+				continue // A collision; the first backend keeps the point
+			}
+			r.owners[point] = backend
+			r.points = append(r.points, point)
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+}
+
+// Remove removes backends from the ring.
+func (r *Ring) Remove(backends ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, backend := range backends {
+		delete(r.backends, backend)
+	}
+	points := r.points[:0]
+	for _, point := range r.points {
+		if _, ok := r.backends[r.owners[point]]; ok {
+			points = append(points, point)
+		} else {
+			delete(r.owners, point)
+		}
+	}
+	r.points = points
+}
+
+// SetHealthy marks a backend healthy or unhealthy. Keys of an unhealthy backend are picked
+// for the next healthy backend on the ring until it is healthy again.
+func (r *Ring) SetHealthy(backend string, healthy bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.backends[backend]; ok {
+		r.backends[backend] = healthy
+	}
+}
+
+// Healthy returns whether backend is on the ring and healthy.
+func (r *Ring) Healthy(backend string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.backends[backend]
+}
+
+// Backends returns the backends on the ring in sorted order.
+func (r *Ring) Backends() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	backends := make([]string, 0, len(r.backends))
+	for backend := range r.backends {
+		backends = append(backends, backend)
+	}
+	sort.Strings(backends)
+	return backends
+}
+
+// Pick returns the backend of key: the owner of the first point at or after the hash of key
+// whose backend is healthy. It returns false if no backend is healthy.
+func (r *Ring) Pick(key string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.points) == 0 {
+		return "", false
+	}
+
+	h := hash(key)
+	start := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	for i := 0; i < len(r.points); i++ {
+		backend := r.owners[r.points[(start+i)%len(r.points)]]
+		if r.backends[backend] {
+			return backend, true
+		}
+	}
+	return "", false
+}
+
+// KeyByHeader returns a key function reading the request header name, e.g. "X-Session-ID".
+func KeyByHeader(name string) func(*http.Request) string {
+	return func(req *http.Request) string {
+		return req.Header.Get(name)
+	}
+}
+
+// KeyByCookie returns a key function reading the cookie name, e.g. a session cookie.
+func KeyByCookie(name string) func(*http.Request) string {
+	return func(req *http.Request) string {
+		if cookie, err := req.Cookie(name); err == nil {
+			return cookie.Value
+		}
+		return ""
+	}
+}
+
+// Rewrite returns a function for httputil.ReverseProxy.Rewrite that routes each request to the
+// backend picked for its key, sets the X-Forwarded headers and keeps the inbound Host header out.
+// Backends must be URLs such as "http://cart-1:8080". Requests with an empty key are routed by
+// their client address, so that they still stick to a backend. If no backend is healthy, or the
+// backend URL is invalid, the outbound URL is left empty and the proxy responds with 502 Bad Gateway.
+func (r *Ring) Rewrite(key func(*http.Request) string) func(*httputil.ProxyRequest) {
+	return func(pr *httputil.ProxyRequest) {
+		k := key(pr.In)
+		if k == "" {
+			k = pr.In.RemoteAddr
+		}
+		pr.SetXForwarded()
+
+		backend, ok := r.Pick(k)
+		if !ok {
+			pr.Out.URL = &url.URL{}
+			return
+		}
+		target, err := url.Parse(backend)
+		if err != nil {
+			pr.Out.URL = &url.URL{}
+			return
+		}
+		pr.SetURL(target)
+	}
+}
+
+// HealthCheck checks whether a backend is healthy.
+type HealthCheck func(ctx context.Context, backend string) error
+
+// HTTPHealthCheck returns a HealthCheck requesting path on the backend, which is healthy if it
+// responds with a 2xx status. If client is nil, a client with a 2 second timeout is used.
+func HTTPHealthCheck(path string, client *http.Client) HealthCheck {
+	if client == nil {
+		client = &http.Client{Timeout: 2 * time.Second}
+	}
+	return func(ctx context.Context, backend string) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, backend+path, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("health check of %s returned status %d", backend, resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// CheckHealth checks every backend once, concurrently, and marks it healthy or unhealthy.
+func (r *Ring) CheckHealth(ctx context.Context, check HealthCheck) {
+	var wg sync.WaitGroup
+	for _, backend := range r.Backends() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.SetHealthy(backend, check(ctx, backend) == nil)
+		}()
+	}
+	wg.Wait()
+}
+
+// MonitorHealth checks every backend immediately and then every interval until ctx is done.
+func (r *Ring) MonitorHealth(ctx context.Context, interval time.Duration, check HealthCheck) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.CheckHealth(ctx, check)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package balance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"testing"
+)
+
+// pickAll returns the backend picked for each of n keys.
+func pickAll(t *testing.T, r *Ring, n int) map[string]string {
+	t.Helper()
+	picks := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		backend, ok := r.Pick(key)
+		if !ok {
+			t.Fatalf("Pick(%q) found no backend", key)
+		}
+		picks[key] = backend
+	}
+	return picks
+}
+
+func TestRingDistribution(t *testing.T) {
+	r := NewRing(0, "a", "b", "c")
+	counts := make(map[string]int)
+	for _, backend := range pickAll(t, r, 3000) {
+		counts[backend]++
+	}
+	for _, backend := range r.Backends() {
+		if counts[backend] < 700 || counts[backend] > 1300 {
+			t.Errorf("backend %s got %d of 3000 keys, want about 1000", backend, counts[backend])
+		}
+	}
+}
+
+func TestRingMovesOnlyKeysOfChangedBackends(t *testing.T) {
+	r := NewRing(0, "a", "b", "c")
+	before := pickAll(t, r, 1000)
+
+	// Only keys of an unhealthy backend move, and they return once it is healthy again
+	r.SetHealthy("b", false)
+	for key, backend := range pickAll(t, r, 1000) {
+		if backend == "b" {
+			t.Fatalf("key %s picked unhealthy backend b", key)
+		}
+		if before[key] != "b" && backend != before[key] {
+			t.Errorf("key %s moved from %s to %s", key, before[key], backend)
+		}
+	}
+	r.SetHealthy("b", true)
+	for key, backend := range pickAll(t, r, 1000) {
+		if backend != before[key] {
+			t.Errorf("key %s picked %s after b recovered, want %s", key, backend, before[key])
+		}
+	}
+
+	// Adding a backend only moves keys to it
+	r.Add("d")
+	for key, backend := range pickAll(t, r, 1000) {
+		if backend != before[key] && backend != "d" {
+			t.Errorf("key %s moved from %s to %s after adding d", key, before[key], backend)
+		}
+	}
+
+	r.Remove("d")
+	for key, backend := range pickAll(t, r, 1000) {
+		if backend != before[key] {
+			t.Errorf("key %s picked %s after removing d, want %s", key, backend, before[key])
+		}
+	}
+}
+
+func TestRingWithoutHealthyBackends(t *testing.T) {
+	r := NewRing(10, "a")
+	r.SetHealthy("a", false)
+	if backend, ok := r.Pick("user-1"); ok {
+		t.Errorf("Pick returned %s, want no backend", backend)
+	}
+	if _, ok := NewRing(10).Pick("user-1"); ok {
+		t.Error("Pick on an empty ring returned a backend")
+	}
+}
+
+func TestRingCheckHealth(t *testing.T) {
+	r := NewRing(10, "a", "b")
+	r.CheckHealth(context.Background(), func(ctx context.Context, backend string) error {
+		if backend == "b" {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	if !r.Healthy("a") || r.Healthy("b") {
+		t.Errorf("health = a:%v b:%v, want a healthy and b unhealthy", r.Healthy("a"), r.Healthy("b"))
+	}
+}
+
+func TestRingRewrite(t *testing.T) {
+	var backends []string
+	for _, name := range []string{"one", "two"} {
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/healthz" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			w.Write([]byte(name))
+		}))
+		defer backend.Close()
+		backends = append(backends, backend.URL)
+	}
+
+	r := NewRing(0, backends...)
+	r.CheckHealth(context.Background(), HTTPHealthCheck("/healthz", nil))
+	proxy := &httputil.ReverseProxy{Rewrite: r.Rewrite(KeyByHeader("X-Session-ID"))}
+
+	serve := func(session string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/cart", nil)
+		req.Header.Set("X-Session-ID", session)
+		rec := httptest.NewRecorder()
+		proxy.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	// Requests of a session stick to one backend
+	_, first := serve("session-1")
+	for i := 0; i < 5; i++ {
+		if _, body := serve("session-1"); body != first {
+			t.Fatalf("session-1 was routed to %s, then %s", first, body)
+		}
+	}
+
+	for _, backend := range backends {
+		r.SetHealthy(backend, false)
+	}
+	if code, _ := serve("session-1"); code != http.StatusBadGateway {
+		t.Errorf("status without healthy backends = %d, want 502", code)
+	}
+}
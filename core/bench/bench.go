@@ -0,0 +1,178 @@
+// Package bench drives an http.Handler in-memory with synthetic traffic and reports
+// throughput and latency percentiles per route.
+// It is used by core.Server.SelfBench to compare framework adapters and middleware cost
+// before deployment.
+package bench
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Route describes a request sent during a benchmark.
+type Route struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// RouteStats holds the results for a single route.
+type RouteStats struct {
+	Method     string
+	Path       string
+	Requests   int           // Number of requests sent
+	Errors     int           // Number of responses with a status code >= 400
+	Throughput float64       // Requests per second
+	P50        time.Duration // Median latency
+	P90        time.Duration // 90th percentile latency
+	P99        time.Duration // 99th percentile latency
+	Max        time.Duration // Maximum latency
+}
+
+// Report holds the results of a benchmark run.
+type Report struct {
+	Concurrency int
+	Duration    time.Duration // Actual duration of the run
+	Routes      []RouteStats  // Results per route, in the order the routes were given
+	Total       RouteStats    // Results across all routes
+}
+
+// String renders the report as a table.
+func (r *Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "concurrency: %d, duration: %v\n", r.Concurrency, r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(&sb, "%-8s %-30s %10s %8s %12s %10s %10s %10s %10s\n",
+		"METHOD", "PATH", "REQUESTS", "ERRORS", "REQ/S", "P50", "P90", "P99", "MAX")
+	// Copy the routes, so that appending the total never writes to the backing array of r.Routes
+	rows := make([]RouteStats, 0, len(r.Routes)+1)
+	rows = append(append(rows, r.Routes...), r.Total)
+	for _, stats := range rows {
+		fmt.Fprintf(&sb, "%-8s %-30s %10d %8d %12.1f %10v %10v %10v %10v\n",
+			stats.Method, stats.Path, stats.Requests, stats.Errors, stats.Throughput,
+			stats.P50, stats.P90, stats.P99, stats.Max)
+	}
+	return sb.String()
+}
+
+// sample is the result of a single request.
+type sample struct {
+	latency time.Duration
+	failed  bool
+}
+
+// Run sends requests to the handler from the given number of workers until the duration elapses.
+// Each worker cycles through the routes in order.
+func Run(handler http.Handler, routes []Route, concurrency int, duration time.Duration) (*Report, error) {
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("bench: at least one route is required")
+	}
+	if concurrency <= 0 {
+		return nil, fmt.Errorf("bench: concurrency must be positive, got %d", concurrency)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("bench: duration must be positive, got %v", duration)
+	}
+
+	// Each worker records samples per route, so no locking is needed while running
+	results := make([][][]sample, concurrency)
+
+	start := time.Now()
+	deadline := start.Add(duration)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		results[w] = make([][]sample, len(routes))
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := worker; time.Now().Before(deadline); i++ {
+				index := i % len(routes)
+				results[worker][index] = append(results[worker][index], send(handler, routes[index]))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	report := &Report{
+		Concurrency: concurrency,
+		Duration:    elapsed,
+		Routes:      make([]RouteStats, len(routes)),
+	}
+
+	var all []sample
+	for index, route := range routes {
+		var samples []sample
+		for w := range results {
+			samples = append(samples, results[w][index]...)
+		}
+		all = append(all, samples...)
+		report.Routes[index] = summarize(route.Method, route.Path, samples, elapsed)
+	}
+	report.Total = summarize("ALL", "", all, elapsed)
+
+	return report, nil
+}
+
+// send serves a single request and measures its latency.
+func send(handler http.Handler, route Route) sample {
+	req := httptest.NewRequest(route.Method, route.Path, bytes.NewReader(route.Body))
+	for key, values := range route.Header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+
+	start := time.Now()
+	handler.ServeHTTP(rec, req)
+	return sample{
+		latency: time.Since(start),
+		failed:  rec.Code >= http.StatusBadRequest,
+	}
+}
+
+// summarize computes statistics for a set of samples.
+func summarize(method, path string, samples []sample, elapsed time.Duration) RouteStats {
+	stats := RouteStats{
+		Method:   method,
+		Path:     path,
+		Requests: len(samples),
+	}
+	if len(samples) == 0 {
+		return stats
+	}
+
+	latencies := make([]time.Duration, len(samples))
+	for i, s := range samples {
+		latencies[i] = s.latency
+		if s.failed {
+			stats.Errors++
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	stats.Throughput = float64(len(samples)) / elapsed.Seconds()
+	stats.P50 = percentile(latencies, 0.50)
+	stats.P90 = percentile(latencies, 0.90)
+	stats.P99 = percentile(latencies, 0.99)
+	stats.Max = latencies[len(latencies)-1]
+	return stats
+}
+
+// percentile returns the p-th percentile of sorted latencies using the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted)) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,66 @@
+package bench
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	report, err := Run(mux, []Route{
+		{Method: http.MethodGet, Path: "/ok"},
+		{Method: http.MethodGet, Path: "/missing"},
+	}, 2, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+
+	ok, missing := report.Routes[0], report.Routes[1]
+	if ok.Requests == 0 || ok.Errors != 0 {
+		t.Errorf("/ok stats = %+v, want requests and no errors", ok)
+	}
+	if missing.Requests == 0 || missing.Errors != missing.Requests {
+		t.Errorf("/missing stats = %+v, want every request to fail", missing)
+	}
+	if report.Total.Requests != ok.Requests+missing.Requests {
+		t.Errorf("total requests = %d, want %d", report.Total.Requests, ok.Requests+missing.Requests)
+	}
+}
+
+func TestReportStringKeepsRoutes(t *testing.T) {
+	routes := make([]RouteStats, 1, 2)
+	routes[0] = RouteStats{Method: http.MethodGet, Path: "/a"}
+	spare := routes[:2]
+	spare[1] = RouteStats{Method: http.MethodGet, Path: "/b"}
+	report := &Report{Routes: routes, Total: RouteStats{Method: "ALL"}}
+
+	_ = report.String()
+	if spare[1].Path != "/b" {
+		t.Errorf("String() overwrote the spare capacity of Routes with %+v", spare[1])
+	}
+}
+
+func TestRunInvalidArguments(t *testing.T) {
+	handler := http.NotFoundHandler()
+	if _, err := Run(handler, nil, 1, time.Millisecond); err == nil {
+		t.Error("Run() without routes did not return error")
+	}
+	if _, err := Run(handler, []Route{{Method: "GET", Path: "/"}}, 0, time.Millisecond); err == nil {
+		t.Error("Run() with zero concurrency did not return error")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	if got := percentile(sorted, 0.5); got != 5 {
+		t.Errorf("percentile(0.5) = %v, want 5", got)
+	}
+	if got := percentile(sorted, 0.99); got != 10 {
+		t.Errorf("percentile(0.99) = %v, want 10", got)
+	}
+}
@@ -0,0 +1,235 @@
+package core
+
+import (
+	"encoding"
+	"fmt"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// BindForm sets the fields of the struct pointed to by obj from form values and uploaded files,
+// following Gin's form binding: fields are matched by their form tag, or their name if it has none,
+// and `form:"-"` skips a field. `form:"name,default=value"` sets a value for missing fields, and
+// time.Time fields are parsed with the layout of their time_format tag (RFC 3339 by default).
+// Slices take every value of a field; fields of type *multipart.FileHeader or []*multipart.FileHeader
+// take the uploaded files. Nested structs are bound from the same values. Fields without a value
+// are left unchanged. files may be nil.
+func BindForm(values url.Values, files map[string][]*multipart.FileHeader, obj interface{}) error {
+	value := reflect.ValueOf(obj)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form binding requires a pointer to a struct, got %T", obj)
+	}
+	return bindFormStruct(value.Elem(), values, files)
+}
+
+// bindFormStruct binds the fields of the struct v.
+func bindFormStruct(v reflect.Value, values url.Values, files map[string][]*multipart.FileHeader) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// Embedded structs are bound even if unexported, since their fields are promoted
+		if !field.IsExported() && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		tag := field.Tag.Get("form")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		fv := v.Field(i)
+
+		// Uploaded files
+		switch field.Type {
+		case fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs[0]))
+			}
+			continue
+		case reflect.SliceOf(fileHeaderType):
+			if fhs := files[name]; len(fhs) > 0 {
+				fv.Set(reflect.ValueOf(fhs))
+			}
+			continue
+		}
+
+		// Nested structs are bound from the same values, as in Gin
+		if field.Type.Kind() == reflect.Struct && field.Type != timeType && !reflect.PointerTo(field.Type).Implements(textUnmarshalerType) {
+			if err := bindFormStruct(fv, values, files); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fieldValues, ok := values[name]
+		if !ok || len(fieldValues) == 0 {
+			defaultValue, hasDefault := strings.CutPrefix(options, "default=")
+			if !hasDefault {
+				continue
+			}
+			fieldValues = []string{defaultValue}
+		}
+		if err := setFormField(fv, field, fieldValues); err != nil {
+			return fmt.Errorf("form field %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setFormField sets the field fv from its form values.
+func setFormField(fv reflect.Value, field reflect.StructField, values []string) error {
+	switch fv.Kind() {
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() == reflect.Uint8 {
+			break // []byte takes the value as a string
+		}
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setFormValue(slice.Index(i), field, value); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	case reflect.Array:
+		if len(values) != fv.Len() {
+			return fmt.Errorf("got %d values for an array of length %d", len(values), fv.Len())
+		}
+		for i, value := range values {
+			if err := setFormValue(fv.Index(i), field, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return setFormValue(fv, field, values[0])
+}
+
+// setFormValue parses value into v.
+func setFormValue(v reflect.Value, field reflect.StructField, value string) error {
+	if v.Kind() == reflect.Pointer {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setFormValue(ptr.Elem(), field, value); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) && v.Type() != timeType {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch v.Type() {
+	case timeType:
+		return setFormTime(v, field, value)
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		if value == "" {
+			value = "false"
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value == "" {
+			value = "0"
+		}
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value == "" {
+			value = "0"
+		}
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if value == "" {
+			value = "0"
+		}
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		v.SetBytes([]byte(value))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// setFormTime parses value with the time_format, time_utc and time_location tags of field.
+// time_format "unix" and "unixnano" parse Unix timestamps.
+func setFormTime(v reflect.Value, field reflect.StructField, value string) error {
+	if value == "" {
+		v.Set(reflect.ValueOf(time.Time{}))
+		return nil
+	}
+
+	layout := field.Tag.Get("time_format")
+	switch layout {
+	case "unix", "unixnano":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		t := time.Unix(n, 0)
+		if layout == "unixnano" {
+			t = time.Unix(0, n)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case "":
+		layout = time.RFC3339
+	}
+
+	location := time.Local
+	if utc, _ := strconv.ParseBool(field.Tag.Get("time_utc")); utc {
+		location = time.UTC
+	}
+	if name := field.Tag.Get("time_location"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return err
+		}
+		location = loc
+	}
+	t, err := time.ParseInLocation(layout, value, location)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(t))
+	return nil
+}
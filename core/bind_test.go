@@ -0,0 +1,57 @@
+package core
+
+import (
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBindForm(t *testing.T) {
+	type paging struct {
+		Limit uint `form:"limit,default=20"`
+	}
+	type filter struct {
+		paging
+		Name    *string       `form:"name"`
+		Score   float64       `form:"score"`
+		Timeout time.Duration `form:"timeout"`
+		Active  bool          `form:"active"`
+		Point   [2]int        `form:"point"`
+		Client  netip.Addr    `form:"client"`
+		Created time.Time     `form:"created" time_format:"unix"`
+		Untaken string
+	}
+
+	var f filter
+	err := BindForm(url.Values{
+		"name":    {"alice"},
+		"score":   {"4.5"},
+		"timeout": {"1m30s"},
+		"active":  {"true"},
+		"point":   {"3", "4"},
+		"client":  {"192.0.2.1"},
+		"created": {"1700000000"},
+		"Untaken": {"by field name"},
+	}, nil, &f)
+	if err != nil {
+		t.Fatalf("BindForm() error = %v", err)
+	}
+	if f.Name == nil || *f.Name != "alice" || f.Score != 4.5 || f.Timeout != 90*time.Second || !f.Active {
+		t.Errorf("BindForm() = %+v", f)
+	}
+	if f.Point != [2]int{3, 4} || f.Client.String() != "192.0.2.1" || f.Created.Unix() != 1700000000 {
+		t.Errorf("BindForm() = %+v", f)
+	}
+	if f.Limit != 20 || f.Untaken != "by field name" {
+		t.Errorf("Limit = %d, Untaken = %q, want the default and the value of the field name", f.Limit, f.Untaken)
+	}
+
+	if err := BindForm(url.Values{"score": {"high"}}, nil, &f); err == nil || !strings.Contains(err.Error(), `form field "score"`) {
+		t.Errorf("BindForm() with an invalid float error = %v, want an error naming the field", err)
+	}
+	if err := BindForm(url.Values{}, nil, f); err == nil {
+		t.Error("BindForm() into a struct value did not fail")
+	}
+}
@@ -0,0 +1,45 @@
+package core
+
+import "reflect"
+
+// boundKey returns the context key under which a bound value of type T is stored.
+func boundKey[T any]() string {
+	t := reflect.TypeFor[T]()
+	return "bound:" + t.PkgPath() + "." + t.String()
+}
+
+// BindOnce binds the JSON request body into a new T the first time it is called for a request,
+// and returns the same value on later calls instead of decoding the body again.
+// This lets middleware (validation, duplicate detection, ...) bind the request DTO and the
+// handler retrieve it with Bound or BindOnce without a second decode.
+//
+// Example usage:
+//
+//	// In middleware
+//	req, err := core.BindOnce[CreateUserRequest](c)
+//
+//	// In the handler
+//	req, ok := core.Bound[CreateUserRequest](c)
+func BindOnce[T any](c Context) (*T, error) {
+	if value, ok := Bound[T](c); ok {
+		return value, nil
+	}
+
+	value := new(T)
+	if err := c.ShouldBindJSON(value); err != nil {
+		return nil, err
+	}
+	c.Set(boundKey[T](), value)
+	return value, nil
+}
+
+// Bound returns the value of type T previously bound by BindOnce for this request,
+// and whether there is one.
+func Bound[T any](c Context) (*T, bool) {
+	value, exists := c.Get(boundKey[T]())
+	if !exists {
+		return nil, false
+	}
+	bound, ok := value.(*T)
+	return bound, ok
+}
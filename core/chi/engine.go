@@ -0,0 +1,59 @@
+package chi
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// SetEngineOptions implements core.Server.SetEngineOptions for Server.
+func (s *Server) SetEngineOptions(options *core.EngineOptions) {
+	if options == nil {
+		options = &core.EngineOptions{}
+	}
+	s.config.Options = *options
+}
+
+// routePath returns the path of r used for routing, and whether it differs from the path chi
+// routes on by itself. chi prefers the escaped path (url.URL.RawPath) when there is one, so the
+// unescaped path is selected unless EngineOptions.UseRawPath is set.
+func (s *Server) routePath(r *http.Request) (string, bool) {
+	chiPath := r.URL.Path
+	if r.URL.RawPath != "" {
+		chiPath = r.URL.RawPath
+	}
+
+	p := r.URL.Path
+	if s.config.Options.UseRawPath && r.URL.RawPath != "" {
+		p = r.URL.RawPath
+	}
+	if s.config.Options.RemoveExtraSlash {
+		p = cleanPath(p)
+	}
+	return p, p != chiPath
+}
+
+// cleanPath removes repeated slashes and "." and ".." segments from p, keeping a trailing slash.
+// Paths that are already clean are returned without allocating.
+func cleanPath(p string) string {
+	if !strings.Contains(p, "//") && !strings.Contains(p, "/.") {
+		return p
+	}
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// SetTrustedProxies implements core.Server.SetTrustedProxies for Server.
+func (s *Server) SetTrustedProxies(proxies []string) error {
+	trusted, err := core.ParseTrustedProxies(proxies...)
+	if err != nil {
+		return err
+	}
+	s.config.TrustedProxies = trusted
+	return nil
+}
@@ -0,0 +1,15 @@
+package chi
+
+import (
+	"github.com/mythofleader/go-http-server/core/internal/nethttp"
+	"github.com/mythofleader/go-http-server/core/middleware"
+)
+
+// ErrorHandlerMiddleware is a chi implementation of middleware.IErrorHandlerMiddleware.
+// It is the error handler middleware of the std adapter, as chi serves net/http requests.
+type ErrorHandlerMiddleware = nethttp.ErrorHandlerMiddleware
+
+// NewErrorHandlerMiddleware creates a new ErrorHandlerMiddleware.
+func NewErrorHandlerMiddleware() middleware.IErrorHandlerMiddleware {
+	return &ErrorHandlerMiddleware{}
+}
@@ -0,0 +1,15 @@
+package chi
+
+import (
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/internal/nethttp"
+)
+
+// LoggingMiddleware is a chi implementation of core.ILoggingMiddleware.
+// It is the logging middleware of the std adapter, as chi serves net/http requests.
+type LoggingMiddleware = nethttp.LoggingMiddleware
+
+// NewLoggingMiddleware creates a new LoggingMiddleware.
+func NewLoggingMiddleware() core.ILoggingMiddleware {
+	return &LoggingMiddleware{}
+}
@@ -0,0 +1,215 @@
+package chi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// route is a route registered with the chi router.
+type route struct {
+	method   string
+	path     string             // Path as registered, e.g. "/users/:id"
+	key      string             // Path with unnamed parameters, for conflict checks
+	wildcard string             // Name of the trailing "*name" wildcard, if any
+	handlers []core.HandlerFunc // Route handlers, preceded by the DisableRoute guard
+	chain    []core.HandlerFunc // Middleware and route handlers, built by Freeze
+}
+
+// chiPattern converts a route path to chi's syntax: ":name" parameters become "{name}", and a
+// trailing "*name" wildcard becomes "*", whose name is returned.
+func chiPattern(path string) (pattern, wildcard string) {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			segments[i] = "{" + segment[1:] + "}"
+		case strings.HasPrefix(segment, "*"):
+			segments[i] = "*"
+			wildcard = segment[1:]
+		}
+	}
+	return strings.Join(segments, "/"), wildcard
+}
+
+// routeKey returns path with the names of its parameters removed. Routes with the same key
+// match the same requests.
+func routeKey(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = segment[:1]
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// handle registers handlers for method and path with the chi router.
+// It panics with an error wrapping core.ErrRouteConflict if a route matching the same requests
+// is already registered, since chi would silently replace it.
+func (s *Server) handle(method, path string, handlers []core.HandlerFunc) {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	s.checkNotFrozen(method + " " + path)
+
+	key := routeKey(path)
+	for _, registered := range s.routes {
+		if registered.method != method || registered.key != key {
+			continue
+		}
+		if registered.path == path {
+			panic(fmt.Errorf("%w: %s %s is already registered", core.ErrRouteConflict, method, path))
+		}
+		panic(fmt.Errorf("%w: %s %s matches the same requests as %s %s", core.ErrRouteConflict, method, path, method, registered.path))
+	}
+
+	pattern, wildcard := chiPattern(path)
+	// The guard counts the in-flight requests of the route for DisableRoute
+	guarded := make([]core.HandlerFunc, 0, len(handlers)+1)
+	guarded = append(guarded, s.drainer.Guard(method, path))
+	rt := &route{
+		method:   method,
+		path:     path,
+		key:      key,
+		wildcard: wildcard,
+		handlers: append(guarded, handlers...),
+	}
+	s.routes = append(s.routes, rt)
+	s.mux.Method(method, pattern, s.routeHandler(rt))
+}
+
+// combineHandlers returns the middleware followed by the route handlers.
+func combineHandlers(middleware, handlers []core.HandlerFunc) []core.HandlerFunc {
+	combined := make([]core.HandlerFunc, 0, len(middleware)+len(handlers))
+	combined = append(combined, middleware...)
+	return append(combined, handlers...)
+}
+
+// params looks up the path parameters of a request routed by chi.
+type params struct {
+	route    *route       // Matched route, nil for NoRoute, NoMethod and dynamic requests
+	rctx     *chi.Context // Routing context holding the path parameters of the matched route
+	unescape bool         // Whether parameter values were matched against the escaped path
+}
+
+// Param implements nethttp.ParamLookup.Param
+// The trailing wildcard of a route, e.g. "*filepath", is read from chi's "*" parameter and
+// starts with a slash, as in Gin.
+func (p *params) Param(key string) string {
+	if p.rctx == nil {
+		return ""
+	}
+	var value string
+	if p.route != nil && p.route.wildcard != "" && p.route.wildcard == key {
+		value = "/" + p.rctx.URLParam("*")
+	} else {
+		value = p.rctx.URLParam(key)
+	}
+	if p.unescape && strings.Contains(value, "%") {
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+	}
+	return value
+}
+
+// request is the Context of a request routed by chi, allocated together with its parameters.
+type request struct {
+	ctx    Context
+	params params
+}
+
+// newContext returns the Context of a request matched by chi against rt, nil if no route matched.
+func (s *Server) newContext(w http.ResponseWriter, r *http.Request, rt *route) *Context {
+	req := &request{params: params{
+		route:    rt,
+		rctx:     chi.RouteContext(r.Context()),
+		unescape: s.config.Options.UseRawPath && r.URL.RawPath != "",
+	}}
+	req.ctx.Init(&s.config, w, r)
+	req.ctx.SetParamLookup(&req.params)
+	return &req.ctx
+}
+
+// ServeHTTP implements http.Handler for Server
+// Requests are routed by chi on the path selected by the engine options. If the path matches a
+// route of another method, the NoMethod handlers run; if it matches no route, dynamic routes and
+// the NoRoute handlers are tried.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if path, ok := s.routePath(r); ok {
+		// A routing context set before chi's own makes chi route on its RoutePath
+		rctx := chi.NewRouteContext()
+		rctx.RoutePath = path
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// routeHandler returns the chi handler running the middleware chain followed by the handlers of rt.
+func (s *Server) routeHandler(rt *route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handlers := rt.chain
+		if handlers == nil {
+			handlers = combineHandlers(s.middleware, rt.handlers)
+		}
+		s.newContext(w, r, rt).Serve(handlers)
+	}
+}
+
+// serveNotFound runs the middleware chain followed by the matching dynamic route or the NoRoute
+// handlers. Without either, it responds with 404 Not Found.
+func (s *Server) serveNotFound(w http.ResponseWriter, r *http.Request) {
+	if s.dynamic == nil && len(s.noRouteHandlers) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	handlers := make([]core.HandlerFunc, 0, len(s.middleware)+len(s.noRouteHandlers)+1)
+	handlers = append(handlers, s.middleware...)
+	if s.dynamic != nil {
+		handlers = append(handlers, s.serveDynamic)
+	}
+	if len(s.noRouteHandlers) > 0 {
+		handlers = append(handlers, s.noRouteHandlers...)
+	} else {
+		handlers = append(handlers, func(c core.Context) {
+			http.NotFound(c.Writer(), c.Request())
+		})
+	}
+	s.newContext(w, r, nil).Serve(handlers)
+}
+
+// serveDynamic serves the request with a dynamic route and skips the NoRoute handlers if one matched.
+func (s *Server) serveDynamic(c core.Context) {
+	if s.dynamic.Serve(c) {
+		c.Abort()
+	}
+}
+
+// serveMethodNotAllowed runs the NoMethod handlers for a request whose path matches a route
+// of a different method.
+func (s *Server) serveMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	ctx := s.newContext(w, r, nil)
+
+	// Special handling for OPTIONS requests to support CORS preflight
+	if r.Method == http.MethodOptions {
+		// Run middleware only for OPTIONS requests
+		ctx.Serve(combineHandlers(s.middleware, nil))
+		return
+	}
+
+	if len(s.noMethodHandlers) == 0 {
+		// Use default error response
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Use custom NoMethod handlers
+	ctx.Serve(combineHandlers(s.middleware, s.noMethodHandlers))
+}
@@ -0,0 +1,71 @@
+package chi
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/servertest"
+)
+
+func TestChiPattern(t *testing.T) {
+	tests := []struct {
+		path, pattern, wildcard string
+	}{
+		{"/", "/", ""},
+		{"/users/:id", "/users/{id}", ""},
+		{"/orgs/:org/repos/:repo", "/orgs/{org}/repos/{repo}", ""},
+		{"/static/*filepath", "/static/*", "filepath"},
+	}
+	for _, tt := range tests {
+		pattern, wildcard := chiPattern(tt.path)
+		if pattern != tt.pattern || wildcard != tt.wildcard {
+			t.Errorf("chiPattern(%q) = %q, %q, want %q, %q", tt.path, pattern, wildcard, tt.pattern, tt.wildcard)
+		}
+	}
+}
+
+func TestRouteConflicts(t *testing.T) {
+	tests := []struct {
+		first, second string
+		conflict      bool
+	}{
+		{"/users", "/users", true},
+		{"/users/:id", "/users/:uid", true},
+		{"/users/:id", "/users/new", false},
+		{"/users/:id", "/users/:name/posts", false},
+	}
+	for _, tt := range tests {
+		s := NewServer("8080", false)
+		handler := func(c core.Context) {}
+		s.GET(tt.first, handler)
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if conflict := errors.Is(err, core.ErrRouteConflict); conflict != tt.conflict {
+					t.Errorf("registering %s after %s panicked with %v, want conflict: %v", tt.second, tt.first, err, tt.conflict)
+				}
+			}()
+			s.GET(tt.second, handler)
+		}()
+	}
+}
+
+func TestNoRouteRunsMiddleware(t *testing.T) {
+	s := NewServer("8080", false)
+	s.Use(func(c core.Context) {
+		c.SetHeader("X-Middleware", "ran")
+		c.Next()
+	})
+	s.GET("/users/:id", func(c core.Context) { c.String(http.StatusOK, c.Param("id")) })
+	s.NoRoute(func(c core.Context) { c.String(http.StatusNotFound, "no route for %s", c.Request().URL.Path) })
+
+	client := servertest.NewClient(s)
+	client.GET("/users/42").Expect(t).Status(http.StatusOK).Body("42")
+	client.GET("/orders").Expect(t).
+		Status(http.StatusNotFound).
+		Header("X-Middleware", "ran").
+		Body("no route for /orders")
+	client.Request(http.MethodDelete, "/users/42").Expect(t).Status(http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,527 @@
+// Package chi provides a chi implementation of the HTTP server abstraction.
+package chi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/bench"
+	"github.com/mythofleader/go-http-server/core/dynamic"
+	"github.com/mythofleader/go-http-server/core/internal/nethttp"
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// Context is an implementation of core.Context using the chi router.
+// It is the Context of the std adapter; chi only supplies the path parameters of the matched route.
+type Context = nethttp.Context
+
+// Server is an implementation of core.Server using the chi router.
+// Routes are registered with chi, while the middleware chain runs as a core handler chain inside
+// each route, so Next and Abort behave as with the other adapters.
+type Server struct {
+	mux              *chi.Mux
+	server           *http.Server
+	serverMu         sync.Mutex // Guards server and closed
+	closed           bool       // Set by Stop and Shutdown; Run and RunTLS then return http.ErrServerClosed
+	routes           []*route   // Registered routes, in registration order
+	middleware       []core.HandlerFunc
+	port             string
+	middlewareLog    []core.NamedHandler    // Track middleware for logging
+	middlewareChain  core.MiddlewareLog     // Describes the middleware of the server and its groups
+	noRouteHandlers  []core.HandlerFunc     // Handlers for 404 Not Found errors
+	noMethodHandlers []core.HandlerFunc     // Handlers for 405 Method Not Allowed errors
+	showLogs         bool                   // Controls whether framework logs are shown
+	frozen           atomic.Bool            // Set once the route table is sealed
+	freezeOnce       sync.Once              // Builds the handler chains when the server is frozen
+	dynamic          core.DynamicRouter     // Dynamic router, nil unless dynamic routing is enabled
+	lifecycle        core.Lifecycle         // Start and stop hooks
+	httpConfig       *core.HTTPServerConfig // Settings of the http.Server, nil for the defaults
+	fastPath         *core.FastPathConfig   // Health check paths answered before the middleware chain
+	config           nethttp.Config         // Router and request parsing settings, and trusted proxies
+	drainer          core.RouteDrainer      // In-flight requests of each route, for DisableRoute
+}
+
+// GetLoggingMiddleware returns a chi-specific logging middleware.
+func (s *Server) GetLoggingMiddleware() core.ILoggingMiddleware {
+	return NewLoggingMiddleware()
+}
+
+// GetErrorHandlerMiddleware returns a chi-specific error handler middleware.
+func (s *Server) GetErrorHandlerMiddleware() core.IErrorHandlerMiddleware {
+	return NewErrorHandlerMiddleware()
+}
+
+// GET implements core.Server.GET for Server
+func (s *Server) GET(path string, handlers ...core.HandlerFunc) {
+	s.handle(http.MethodGet, path, handlers)
+}
+
+// POST implements core.Server.POST for Server
+func (s *Server) POST(path string, handlers ...core.HandlerFunc) {
+	s.handle(http.MethodPost, path, handlers)
+}
+
+// PUT implements core.Server.PUT for Server
+func (s *Server) PUT(path string, handlers ...core.HandlerFunc) {
+	s.handle(http.MethodPut, path, handlers)
+}
+
+// DELETE implements core.Server.DELETE for Server
+func (s *Server) DELETE(path string, handlers ...core.HandlerFunc) {
+	s.handle(http.MethodDelete, path, handlers)
+}
+
+// PATCH implements core.Server.PATCH for Server
+func (s *Server) PATCH(path string, handlers ...core.HandlerFunc) {
+	s.handle(http.MethodPatch, path, handlers)
+}
+
+// Group implements core.Server.Group for Server
+func (s *Server) Group(path string) core.RouterGroup {
+	return &RouterGroup{
+		server: s,
+		prefix: path,
+	}
+}
+
+// Use implements core.Server.Use for Server
+func (s *Server) Use(middleware ...core.HandlerFunc) {
+	for _, m := range middleware {
+		s.UseNamed("", m)
+	}
+}
+
+// UseNamed implements core.Server.UseNamed for Server
+// If name is empty, the function name is resolved only when framework logs are shown.
+func (s *Server) UseNamed(name string, middleware core.HandlerFunc) {
+	s.UseDescribed(core.MiddlewareDescription{Name: name}, middleware)
+}
+
+// UseDescribed implements core.Server.UseDescribed for Server
+func (s *Server) UseDescribed(description core.MiddlewareDescription, middleware core.HandlerFunc) {
+	s.checkNotFrozen("middleware")
+	named := core.NamedHandler{Name: description.Name, Handler: middleware}
+	s.middlewareLog = append(s.middlewareLog, named)
+	s.middlewareChain.Add(description, middleware)
+
+	// Log middleware addition if showLogs is true
+	if s.showLogs {
+		log.Printf("[CHI] Adding middleware: %s", named.DisplayName())
+	}
+
+	s.middleware = append(s.middleware, middleware)
+}
+
+// DescribeMiddleware implements core.Server.DescribeMiddleware for Server
+func (s *Server) DescribeMiddleware() core.MiddlewareChain {
+	return s.middlewareChain.Chain()
+}
+
+// RegisterRouter implements core.Server.RegisterRouter
+func (s *Server) RegisterRouter(controllers ...core.Controller) {
+	for _, controller := range controllers {
+		// Get HTTP method, path, and handlers from the controller
+		method := controller.GetHttpMethod()
+		path := controller.GetPath()
+		handlers := core.ControllerHandlers(controller)
+
+		// Register the route based on the HTTP method
+		switch method {
+		case core.GET:
+			s.GET(path, handlers...)
+		case core.POST:
+			s.POST(path, handlers...)
+		case core.PUT:
+			s.PUT(path, handlers...)
+		case core.DELETE:
+			s.DELETE(path, handlers...)
+		case core.PATCH:
+			s.PATCH(path, handlers...)
+		}
+
+		// Log controller registration if showLogs is true
+		if s.showLogs {
+			log.Printf("[CHI] Registered controller with method: %s, path: %s, skip logging: %t, skip auth check: %t",
+				method, path, controller.SkipLogging(), controller.SkipAuthCheck())
+		}
+	}
+}
+
+// DisableRoute implements core.Server.DisableRoute for Server
+func (s *Server) DisableRoute(method core.HttpMethod, path string, status ...int) (*core.RouteDrain, error) {
+	code := 0
+	if len(status) > 0 {
+		code = status[0]
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return s.drainer.Disable(string(method), path, code)
+}
+
+// NoRoute implements core.Server.NoRoute
+func (s *Server) NoRoute(handlers ...core.HandlerFunc) {
+	s.checkNotFrozen("NoRoute handlers")
+	// If no handlers are provided, use default handler
+	if len(handlers) == 0 {
+		// Default handler returns a 404 Not Found error
+		handlers = []core.HandlerFunc{
+			func(c core.Context) {
+				path := c.Request().URL.Path
+				err := fmt.Errorf("route not found: %s", path)
+				_ = c.Error(httperrors.NewNotFoundHttpError(err))
+			},
+		}
+		if s.showLogs {
+			log.Printf("[CHI] Using default NoRoute handler")
+		}
+	}
+
+	s.noRouteHandlers = handlers
+	if s.showLogs {
+		log.Printf("[CHI] Registered NoRoute handler")
+	}
+}
+
+// NoMethod implements core.Server.NoMethod
+func (s *Server) NoMethod(handlers ...core.HandlerFunc) {
+	s.checkNotFrozen("NoMethod handlers")
+	// If no handlers are provided, use default handler
+	if len(handlers) == 0 {
+		// Default handler returns a 405 Method Not Allowed error
+		handlers = []core.HandlerFunc{
+			func(c core.Context) {
+				method := c.Request().Method
+				path := c.Request().URL.Path
+				err := fmt.Errorf("method %s not allowed for path %s", method, path)
+				_ = c.Error(httperrors.NewMethodNotAllowedHttpError(err))
+			},
+		}
+		if s.showLogs {
+			log.Printf("[CHI] Using default NoMethod handler")
+		}
+	}
+
+	s.noMethodHandlers = handlers
+	if s.showLogs {
+		log.Printf("[CHI] Registered NoMethod handler")
+	}
+}
+
+// Run implements core.Server.Run for Server
+func (s *Server) Run() error {
+	s.Freeze()
+	if err := s.lifecycle.Start(context.Background()); err != nil {
+		return err
+	}
+
+	addr := ":" + s.port
+
+	// Log server information if showLogs is true
+	if s.showLogs {
+		log.Printf("[CHI] Server starting on %s", addr)
+		log.Printf("[CHI] Using chi router")
+
+		// Log middleware information
+		if len(s.middlewareLog) > 0 {
+			log.Println("[CHI] Middleware registered:")
+			for i, middleware := range s.middlewareLog {
+				log.Printf("[CHI]   %d. %s", i+1, middleware.DisplayName())
+			}
+		} else {
+			log.Println("[CHI] No middleware registered")
+		}
+
+		// Log routes information
+		if len(s.routes) > 0 {
+			log.Println("[CHI] Routes registered:")
+			for i, rt := range s.routes {
+				log.Printf("[CHI]   %d. %s %s", i+1, rt.method, rt.path)
+			}
+		} else {
+			log.Println("[CHI] No routes registered")
+		}
+
+		log.Printf("[CHI] Server is ready to handle requests")
+	}
+
+	srv, err := s.newHTTPServer(addr)
+	if err != nil {
+		return err
+	}
+	return srv.ListenAndServe()
+}
+
+// RunTLS implements core.Server.RunTLS for Server
+func (s *Server) RunTLS(addr, certFile, keyFile string) error {
+	s.Freeze()
+	if err := s.lifecycle.Start(context.Background()); err != nil {
+		return err
+	}
+
+	srv, err := s.newHTTPServer(addr)
+	if err != nil {
+		return err
+	}
+	return srv.ListenAndServeTLS(certFile, keyFile)
+}
+
+// newHTTPServer creates the http.Server of Run and RunTLS. It returns http.ErrServerClosed
+// if the server has been stopped before it started, e.g. by a shutdown signal during start hooks.
+func (s *Server) newHTTPServer(addr string) (*http.Server, error) {
+	s.serverMu.Lock()
+	defer s.serverMu.Unlock()
+	if s.closed {
+		return nil, http.ErrServerClosed
+	}
+	s.server = core.NewHTTPServer(addr, s.Handler(), s.httpConfig)
+	return s.server, nil
+}
+
+// closeHTTPServer marks the server as stopped and returns its http.Server, nil if it has not started.
+func (s *Server) closeHTTPServer() *http.Server {
+	s.serverMu.Lock()
+	defer s.serverMu.Unlock()
+	s.closed = true
+	return s.server
+}
+
+// Stop implements core.Server.Stop for Server
+func (s *Server) Stop() error {
+	var err error
+	if srv := s.closeHTTPServer(); srv != nil {
+		err = srv.Close()
+	}
+	if hookErr := s.lifecycle.Stop(context.Background()); err == nil {
+		err = hookErr
+	}
+	return err
+}
+
+// RunWithGracefulShutdown implements core.Server.RunWithGracefulShutdown for Server
+func (s *Server) RunWithGracefulShutdown(ctx context.Context, timeout time.Duration) error {
+	return core.RunWithGracefulShutdown(ctx, timeout, s.Run, s.Shutdown)
+}
+
+// Shutdown implements core.Server.Shutdown for Server
+func (s *Server) Shutdown(ctx context.Context) error {
+	var err error
+	if srv := s.closeHTTPServer(); srv != nil {
+		err = srv.Shutdown(ctx)
+	}
+	if hookErr := s.lifecycle.Stop(ctx); err == nil {
+		err = hookErr
+	}
+	return err
+}
+
+// GetPort implements core.Server.GetPort for Server
+func (s *Server) GetPort() string {
+	return s.port
+}
+
+// Freeze implements core.Server.Freeze for Server
+// Since the middleware and routes no longer change, the handler chain of every route is built once here.
+func (s *Server) Freeze() {
+	s.freezeOnce.Do(func() {
+		s.frozen.Store(true)
+		for _, rt := range s.routes {
+			rt.chain = combineHandlers(s.middleware, rt.handlers)
+		}
+	})
+}
+
+// Frozen implements core.Server.Frozen for Server
+func (s *Server) Frozen() bool {
+	return s.frozen.Load()
+}
+
+// OnStart implements core.Server.OnStart for Server
+func (s *Server) OnStart(hook core.LifecycleHook) {
+	s.lifecycle.OnStart(hook)
+}
+
+// OnStop implements core.Server.OnStop for Server
+func (s *Server) OnStop(hook core.LifecycleHook) {
+	s.lifecycle.OnStop(hook)
+}
+
+// SetHTTPServerConfig implements core.Server.SetHTTPServerConfig for Server
+func (s *Server) SetHTTPServerConfig(config *core.HTTPServerConfig) {
+	s.httpConfig = config
+}
+
+// SetFastPath implements core.Server.SetFastPath for Server
+func (s *Server) SetFastPath(config *core.FastPathConfig) {
+	s.fastPath = config
+}
+
+// checkNotFrozen panics with an error wrapping core.ErrServerFrozen if the server has been frozen.
+func (s *Server) checkNotFrozen(what string) {
+	if s.frozen.Load() {
+		panic(fmt.Errorf("%w: cannot register %s", core.ErrServerFrozen, what))
+	}
+}
+
+// Handler implements core.Server.Handler for Server
+func (s *Server) Handler() http.Handler {
+	return core.FastPathHandler(s, s.fastPath)
+}
+
+// Dynamic implements core.Server.Dynamic for Server
+func (s *Server) Dynamic() core.DynamicRouter {
+	if s.dynamic == nil {
+		s.checkNotFrozen("dynamic router")
+		s.dynamic = dynamic.NewRouter()
+		if s.showLogs {
+			log.Printf("[CHI] Dynamic routing enabled")
+		}
+	}
+	return s.dynamic
+}
+
+// SelfBench implements core.Server.SelfBench for Server
+func (s *Server) SelfBench(routes []bench.Route, concurrency int, duration time.Duration) (*bench.Report, error) {
+	return bench.Run(s.Handler(), routes, concurrency, duration)
+}
+
+// Unwrap implements core.Server.Unwrap, returning the *chi.Mux of the server
+func (s *Server) Unwrap() interface{} {
+	return s.mux
+}
+
+// Mux returns the chi router of the server, e.g. to mount chi middleware or sub-routers.
+func (s *Server) Mux() *chi.Mux {
+	return s.mux
+}
+
+// StartLambda implements core.Server.StartLambda for Server
+// Lambda is not supported by the chi adapter.
+func (s *Server) StartLambda() error {
+	return errors.New("Lambda is only supported with the Gin and standard HTTP frameworks")
+}
+
+// SetLambdaConfig implements core.Server.SetLambdaConfig for Server
+// The configuration is ignored, since Lambda is not supported by the chi adapter.
+func (s *Server) SetLambdaConfig(config *core.LambdaConfig) {}
+
+// RouterGroup is an implementation of core.RouterGroup using the chi router.
+type RouterGroup struct {
+	server     *Server
+	prefix     string
+	middleware []core.HandlerFunc
+}
+
+// GET implements core.RouterGroup.GET for RouterGroup
+func (g *RouterGroup) GET(path string, handlers ...core.HandlerFunc) {
+	g.server.GET(g.prefix+path, g.withMiddleware(handlers)...)
+}
+
+// POST implements core.RouterGroup.POST for RouterGroup
+func (g *RouterGroup) POST(path string, handlers ...core.HandlerFunc) {
+	g.server.POST(g.prefix+path, g.withMiddleware(handlers)...)
+}
+
+// PUT implements core.RouterGroup.PUT for RouterGroup
+func (g *RouterGroup) PUT(path string, handlers ...core.HandlerFunc) {
+	g.server.PUT(g.prefix+path, g.withMiddleware(handlers)...)
+}
+
+// DELETE implements core.RouterGroup.DELETE for RouterGroup
+func (g *RouterGroup) DELETE(path string, handlers ...core.HandlerFunc) {
+	g.server.DELETE(g.prefix+path, g.withMiddleware(handlers)...)
+}
+
+// PATCH implements core.RouterGroup.PATCH for RouterGroup
+func (g *RouterGroup) PATCH(path string, handlers ...core.HandlerFunc) {
+	g.server.PATCH(g.prefix+path, g.withMiddleware(handlers)...)
+}
+
+// Group implements core.RouterGroup.Group for RouterGroup
+func (g *RouterGroup) Group(path string) core.RouterGroup {
+	return &RouterGroup{
+		server:     g.server,
+		prefix:     g.prefix + path,
+		middleware: g.middleware,
+	}
+}
+
+// Use implements core.RouterGroup.Use for RouterGroup
+func (g *RouterGroup) Use(middleware ...core.HandlerFunc) {
+	g.server.checkNotFrozen("group middleware")
+	for _, m := range middleware {
+		g.server.middlewareChain.Add(core.MiddlewareDescription{AppliesTo: g.prefix + "/*"}, m)
+	}
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// RegisterRouter implements core.RouterGroup.RegisterRouter
+func (g *RouterGroup) RegisterRouter(controllers ...core.Controller) {
+	for _, controller := range controllers {
+		// Get HTTP method, path, and handlers from the controller
+		method := controller.GetHttpMethod()
+		path := controller.GetPath()
+		handlers := core.ControllerHandlers(controller)
+
+		// Register the route based on the HTTP method
+		switch method {
+		case core.GET:
+			g.GET(path, handlers...)
+		case core.POST:
+			g.POST(path, handlers...)
+		case core.PUT:
+			g.PUT(path, handlers...)
+		case core.DELETE:
+			g.DELETE(path, handlers...)
+		case core.PATCH:
+			g.PATCH(path, handlers...)
+		}
+
+		// Log controller registration if showLogs is true
+		if g.server.showLogs {
+			log.Printf("[CHI] Registered controller with method: %s, path: %s, skip logging: %t, skip auth check: %t",
+				method, path, controller.SkipLogging(), controller.SkipAuthCheck())
+		}
+	}
+}
+
+// withMiddleware returns the route handlers prefixed with the group middleware
+func (g *RouterGroup) withMiddleware(handlers []core.HandlerFunc) []core.HandlerFunc {
+	// Group middleware runs as part of the request's handler chain, so Next and Abort
+	// behave as they do for server middleware. As in Gin, middleware added with Use
+	// only applies to routes registered afterwards.
+	chain := make([]core.HandlerFunc, 0, len(g.middleware)+len(handlers))
+	chain = append(chain, g.middleware...)
+	return append(chain, handlers...)
+}
+
+// NewServer creates a new Server instance using the chi router.
+// If showLogs is true, logs about the framework, middleware, and routes will be printed to the console.
+// If showLogs is false, these logs will be suppressed.
+func NewServer(port string, showLogs bool) *Server {
+	// Only log if showLogs is true
+	if showLogs {
+		log.Printf("[CHI] Creating new chi server on port %s", port)
+	}
+
+	s := &Server{
+		mux:              chi.NewRouter(),
+		port:             port,
+		middlewareLog:    make([]core.NamedHandler, 0),
+		noRouteHandlers:  make([]core.HandlerFunc, 0),
+		noMethodHandlers: make([]core.HandlerFunc, 0),
+		showLogs:         showLogs,
+	}
+	s.mux.NotFound(s.serveNotFound)
+	s.mux.MethodNotAllowed(s.serveMethodNotAllowed)
+	return s
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies is the set of proxies, such as load balancers, whose forwarding headers are
+// trusted when resolving the client IP of a request. The zero value and nil trust no proxy.
+type TrustedProxies struct {
+	prefixes []netip.Prefix
+}
+
+// ParseTrustedProxies parses IPs ("10.0.0.5") and CIDR ranges ("10.0.0.0/8", "fd00::/8") of trusted proxies.
+func ParseTrustedProxies(proxies ...string) (*TrustedProxies, error) {
+	trusted := &TrustedProxies{}
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+			}
+			trusted.prefixes = append(trusted.prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		addr = addr.Unmap()
+		trusted.prefixes = append(trusted.prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return trusted, nil
+}
+
+// Contains returns whether ip is a trusted proxy. Invalid IPs are never trusted.
+func (p *TrustedProxies) Contains(ip string) bool {
+	if p == nil || len(p.prefixes) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns the IP of the remote address of req, without the port.
+func remoteIP(req *http.Request) string {
+	if host, _, err := net.SplitHostPort(strings.TrimSpace(req.RemoteAddr)); err == nil {
+		return host
+	}
+	return strings.TrimSpace(req.RemoteAddr)
+}
+
+// ResolveClientIP returns the client IP of req. It is used by Context.ClientIP implementations.
+//   - If platform is set, e.g. PlatformCloudflare, the IP in that header is used, as the hosting
+//     platform sets it for every request.
+//   - If the request comes from a trusted proxy, X-Forwarded-For is read from right to left,
+//     skipping trusted proxies, and the first other IP is the client's; X-Real-IP is used if
+//     there is no X-Forwarded-For header.
+//   - Otherwise the remote address is used, so clients cannot forge their IP with these headers.
+func ResolveClientIP(req *http.Request, proxies *TrustedProxies, platform string) string {
+	if platform != "" {
+		if ip := strings.TrimSpace(req.Header.Get(platform)); ip != "" {
+			return ip
+		}
+	}
+
+	remote := remoteIP(req)
+	if !proxies.Contains(remote) {
+		return remote
+	}
+
+	if values := req.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		hops := strings.Split(strings.Join(values, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if _, err := netip.ParseAddr(hop); err != nil {
+				// A malformed entry cannot be attributed; stop at the last valid hop
+				break
+			}
+			if i == 0 || !proxies.Contains(hop) {
+				return hop
+			}
+			remote = hop
+		}
+		return remote
+	}
+
+	if ip := strings.TrimSpace(req.Header.Get("X-Real-IP")); ip != "" {
+		if _, err := netip.ParseAddr(ip); err == nil {
+			return ip
+		}
+	}
+	return remote
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8", "192.0.2.1", "fd00::/8")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() returned error: %v", err)
+	}
+	for ip, want := range map[string]bool{
+		"10.1.2.3":          true,
+		"::ffff:10.1.2.3":   true,
+		"192.0.2.1":         true,
+		"192.0.2.2":         false,
+		"fd00::1":           true,
+		"2001:db8::1":       false,
+		"not an ip address": false,
+	} {
+		if got := proxies.Contains(ip); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", ip, got, want)
+		}
+	}
+
+	if _, err := ParseTrustedProxies("10.0.0.0/33"); err == nil {
+		t.Error("ParseTrustedProxies(10.0.0.0/33) returned no error")
+	}
+	if _, err := ParseTrustedProxies("proxy.internal"); err == nil {
+		t.Error("ParseTrustedProxies(proxy.internal) returned no error")
+	}
+	var none *TrustedProxies
+	if none.Contains("10.1.2.3") {
+		t.Error("nil TrustedProxies contains 10.1.2.3")
+	}
+}
+
+func TestResolveClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("192.0.2.1", "10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		remote   string
+		headers  map[string]string
+		platform string
+		want     string
+	}{
+		{"untrusted remote ignores headers", "203.0.113.9:1234", map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.2"}, "", "203.0.113.9"},
+		{"trusted remote without headers", "192.0.2.1:1234", nil, "", "192.0.2.1"},
+		{"first untrusted hop from the right", "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.7, 203.0.113.5, 10.0.0.2"}, "", "203.0.113.5"},
+		{"all hops trusted", "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "", "10.0.0.3"},
+		{"malformed hop", "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.7, garbage, 10.0.0.2"}, "", "10.0.0.2"},
+		{"real ip header", "192.0.2.1:1234", map[string]string{"X-Real-IP": "198.51.100.2"}, "", "198.51.100.2"},
+		{"platform header", "203.0.113.9:1234", map[string]string{PlatformCloudflare: "198.51.100.3"}, PlatformCloudflare, "198.51.100.3"},
+		{"missing platform header", "203.0.113.9:1234", nil, PlatformCloudflare, "203.0.113.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			if got := ResolveClientIP(req, proxies, tt.platform); got != tt.want {
+				t.Errorf("ResolveClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
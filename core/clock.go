@@ -0,0 +1,170 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Clock provides the current time. Middleware reads the time from the clock of the
+// request (see ClockFromContext), so tests can substitute a fake clock for time.Now.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+}
+
+// ClockFunc is an adapter to allow the use of ordinary functions as clocks.
+type ClockFunc func() time.Time
+
+// Now calls f().
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the clock backed by time.Now.
+var SystemClock Clock = systemClock{}
+
+// systemClock is the Clock backed by time.Now.
+type systemClock struct{}
+
+// Now returns time.Now().
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// IDGenerator generates unique identifiers, e.g. request IDs.
+type IDGenerator interface {
+	// NewID returns a new unique identifier
+	NewID() string
+}
+
+// IDGeneratorFunc is an adapter to allow the use of ordinary functions as ID generators.
+type IDGeneratorFunc func() string
+
+// NewID calls f().
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// DefaultIDGenerator generates request IDs from the current time in nanoseconds,
+// as the logging middleware always has.
+var DefaultIDGenerator IDGenerator = nanoIDGenerator{}
+
+// nanoIDGenerator generates IDs from the current time in nanoseconds.
+type nanoIDGenerator struct{}
+
+// NewID returns the current Unix time in nanoseconds as a decimal string.
+func (nanoIDGenerator) NewID() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+// crockfordBase32 is the alphabet used to encode ULIDs.
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULIDGenerator returns an ID generator producing ULIDs: 26-character, lexicographically
+// sortable identifiers made of a millisecond timestamp read from clock and 80 random bits.
+// If clock is nil, SystemClock is used.
+func NewULIDGenerator(clock Clock) IDGenerator {
+	if clock == nil {
+		clock = SystemClock
+	}
+	return IDGeneratorFunc(func() string {
+		var id [16]byte
+		ms := uint64(clock.Now().UnixMilli())
+		for i := 5; i >= 0; i-- {
+			id[i] = byte(ms)
+			ms >>= 8
+		}
+		if _, err := rand.Read(id[6:]); err != nil {
+			panic(fmt.Sprintf("failed to generate ULID: %v", err))
+		}
+		return encodeULID(id)
+	})
+}
+
+// NewUUIDGenerator returns an ID generator producing random (version 4) UUIDs in their canonical
+// 36-character form, e.g. "f47ac10b-58cc-4372-a567-0e02b2c3d479".
+func NewUUIDGenerator() IDGenerator {
+	return IDGeneratorFunc(func() string {
+		var id [16]byte
+		if _, err := rand.Read(id[:]); err != nil {
+			panic(fmt.Sprintf("failed to generate UUID: %v", err))
+		}
+		id[6] = id[6]&0x0f | 0x40 // Version 4
+		id[8] = id[8]&0x3f | 0x80 // RFC 4122 variant
+		return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+	})
+}
+
+// encodeULID encodes 128 bits as 26 Crockford base32 characters, most significant bits first.
+func encodeULID(id [16]byte) string {
+	var out [26]byte
+	// 26 characters hold 130 bits; the two leading bits are zero
+	var acc uint32
+	bits := 2
+	pos := 0
+	for _, b := range id {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out[pos] = crockfordBase32[(acc>>uint(bits))&0x1f]
+			pos++
+		}
+	}
+	return string(out[:])
+}
+
+// clockContextKey and idGeneratorContextKey are the request context keys of the clock and ID generator.
+type (
+	clockContextKey       struct{}
+	idGeneratorContextKey struct{}
+)
+
+// ContextWithClock returns a copy of ctx carrying the clock.
+func ContextWithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockContextKey{}, clock)
+}
+
+// ClockFromContext returns the clock of ctx, or SystemClock if none has been set.
+func ClockFromContext(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockContextKey{}).(Clock); ok {
+		return clock
+	}
+	return SystemClock
+}
+
+// ContextWithIDGenerator returns a copy of ctx carrying the ID generator.
+func ContextWithIDGenerator(ctx context.Context, ids IDGenerator) context.Context {
+	return context.WithValue(ctx, idGeneratorContextKey{}, ids)
+}
+
+// IDGeneratorFromContext returns the ID generator of ctx, or DefaultIDGenerator if none has been set.
+func IDGeneratorFromContext(ctx context.Context) IDGenerator {
+	if ids, ok := ctx.Value(idGeneratorContextKey{}).(IDGenerator); ok {
+		return ids
+	}
+	return DefaultIDGenerator
+}
+
+// ClockMiddleware returns a middleware function that stores the clock and ID generator in the
+// request context, where the logging, authentication and other middleware read them, and records
+// the start time of the request with the clock (see Context.StartTime).
+// Nil arguments leave the defaults in place. It must be registered before the middleware using them.
+func ClockMiddleware(clock Clock, ids IDGenerator) HandlerFunc {
+	return func(c Context) {
+		ctx := c.Request().Context()
+		if clock != nil {
+			ctx = ContextWithClock(ctx, clock)
+		}
+		if ids != nil {
+			ctx = ContextWithIDGenerator(ctx, ids)
+		}
+		c.SetRequest(c.Request().WithContext(ctx))
+		// As the first middleware, record the start time of the request with the clock
+		RequestStartTime(c)
+		c.Next()
+	}
+}
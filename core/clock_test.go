@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestULIDGenerator(t *testing.T) {
+	now := time.UnixMilli(1469918176385)
+	ids := NewULIDGenerator(ClockFunc(func() time.Time { return now }))
+
+	id := ids.NewID()
+	if len(id) != 26 {
+		t.Fatalf("len(NewID()) = %d, want 26", len(id))
+	}
+	// The first 10 characters encode the timestamp
+	if got := id[:10]; got != "01ARYZ6S41" {
+		t.Errorf("timestamp part = %q, want 01ARYZ6S41", got)
+	}
+	for _, r := range id {
+		if !strings.ContainsRune(crockfordBase32, r) {
+			t.Fatalf("NewID() = %q contains invalid character %q", id, r)
+		}
+	}
+	if other := ids.NewID(); other == id {
+		t.Errorf("NewID() returned %q twice", id)
+	}
+
+	now = now.Add(time.Millisecond)
+	if later := ids.NewID(); later <= id {
+		t.Errorf("NewID() = %q is not sorted after %q", later, id)
+	}
+}
+
+func TestUUIDGenerator(t *testing.T) {
+	ids := NewUUIDGenerator()
+
+	id := ids.NewID()
+	if len(id) != 36 {
+		t.Fatalf("len(NewID()) = %d, want 36", len(id))
+	}
+	for i, r := range id {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				t.Fatalf("NewID() = %q, want a hyphen at %d", id, i)
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdef", r) {
+				t.Fatalf("NewID() = %q contains invalid character %q", id, r)
+			}
+		}
+	}
+	if id[14] != '4' {
+		t.Errorf("version of %q = %c, want 4", id, id[14])
+	}
+	if !strings.ContainsRune("89ab", rune(id[19])) {
+		t.Errorf("variant of %q = %c, want one of 89ab", id, id[19])
+	}
+	if other := ids.NewID(); other == id {
+		t.Errorf("NewID() returned %q twice", id)
+	}
+}
+
+func TestClockFromContext(t *testing.T) {
+	if ClockFromContext(context.Background()) != SystemClock {
+		t.Error("ClockFromContext() without a clock should return SystemClock")
+	}
+	if IDGeneratorFromContext(context.Background()) != DefaultIDGenerator {
+		t.Error("IDGeneratorFromContext() without a generator should return DefaultIDGenerator")
+	}
+
+	fixed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := ContextWithClock(context.Background(), ClockFunc(func() time.Time { return fixed }))
+	ctx = ContextWithIDGenerator(ctx, IDGeneratorFunc(func() string { return "id" }))
+	if got := ClockFromContext(ctx).Now(); !got.Equal(fixed) {
+		t.Errorf("ClockFromContext().Now() = %v, want %v", got, fixed)
+	}
+	if got := IDGeneratorFromContext(ctx).NewID(); got != "id" {
+		t.Errorf("IDGeneratorFromContext().NewID() = %q, want id", got)
+	}
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// Media types of the request bodies bound by Context.Bind, as returned by BindingMediaType.
+const (
+	MIMEJSON          = "application/json"
+	MIMEXML           = "application/xml"
+	MIMEForm          = "application/x-www-form-urlencoded"
+	MIMEMultipartForm = "multipart/form-data"
+)
+
+// BindingMediaType returns the media type of a request body from its Content-Type header,
+// ignoring parameters and case, e.g. MIMEJSON for "Application/JSON; charset=utf-8".
+// Structured syntax suffixes are mapped to their base type: "application/vnd.api+json" is MIMEJSON
+// and "application/atom+xml" is MIMEXML; "text/xml" is MIMEXML as well. Other media types are
+// returned as they are, or "" if the header is empty.
+//
+// A charset parameter other than UTF-8 (or its subset US-ASCII) fails with
+// *errors.UnsupportedMediaTypeHttpError, since bodies are decoded as UTF-8. If strict is true,
+// a missing Content-Type or one that is not one of the MIME constants above fails as well.
+func BindingMediaType(contentType string, strict bool) (string, error) {
+	if contentType == "" {
+		if strict {
+			return "", httperrors.NewUnsupportedMediaTypeHttpError(fmt.Errorf("missing Content-Type"))
+		}
+		return "", nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil && err != mime.ErrInvalidMediaParameter {
+		if strict {
+			return "", httperrors.NewUnsupportedMediaTypeHttpError(fmt.Errorf("invalid Content-Type %q", contentType))
+		}
+		return "", nil
+	}
+
+	switch {
+	case mediaType == MIMEJSON || strings.HasSuffix(mediaType, "+json"):
+		mediaType = MIMEJSON
+	case mediaType == MIMEXML || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		mediaType = MIMEXML
+	case mediaType == MIMEForm || mediaType == MIMEMultipartForm:
+	default:
+		if strict {
+			return "", httperrors.NewUnsupportedMediaTypeHttpError(fmt.Errorf("unsupported Content-Type %q", mediaType))
+		}
+	}
+
+	if charset, ok := params["charset"]; ok && mediaType != MIMEMultipartForm && !isUTF8Charset(charset) {
+		return "", httperrors.NewUnsupportedMediaTypeHttpError(fmt.Errorf("unsupported charset %q", charset))
+	}
+	return mediaType, nil
+}
+
+// CheckJSONContentType checks the Content-Type header of a JSON request body for BindJSON.
+// A charset other than UTF-8 always fails; if strict is true, a media type other than JSON fails
+// as well. Errors are *errors.UnsupportedMediaTypeHttpError.
+func CheckJSONContentType(contentType string, strict bool) error {
+	mediaType, err := BindingMediaType(contentType, false)
+	if err != nil {
+		return err
+	}
+	if strict && mediaType != MIMEJSON {
+		if mediaType == "" {
+			return httperrors.NewUnsupportedMediaTypeHttpError(fmt.Errorf("missing Content-Type, want %s", MIMEJSON))
+		}
+		return httperrors.NewUnsupportedMediaTypeHttpError(fmt.Errorf("unsupported Content-Type %q, want %s", mediaType, MIMEJSON))
+	}
+	return nil
+}
+
+// isUTF8Charset reports whether bodies in charset can be decoded as UTF-8.
+func isUTF8Charset(charset string) bool {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+func TestBindingMediaType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		strict      bool
+		want        string
+		wantErr     bool
+	}{
+		{contentType: "application/json", want: MIMEJSON},
+		{contentType: "Application/JSON; charset=UTF-8", want: MIMEJSON},
+		{contentType: "application/vnd.api+json", want: MIMEJSON},
+		{contentType: "text/xml; charset=us-ascii", want: MIMEXML},
+		{contentType: "application/atom+xml", want: MIMEXML},
+		{contentType: "multipart/form-data; boundary=x", want: MIMEMultipartForm},
+		{contentType: "application/x-www-form-urlencoded", strict: true, want: MIMEForm},
+		{contentType: "application/json; charset=iso-8859-1", wantErr: true},
+		{contentType: "text/plain", want: "text/plain"},
+		{contentType: "text/plain", strict: true, wantErr: true},
+		{contentType: "", want: ""},
+		{contentType: "", strict: true, wantErr: true},
+		{contentType: "not a media type", strict: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := BindingMediaType(tt.contentType, tt.strict)
+		if tt.wantErr {
+			var unsupported *httperrors.UnsupportedMediaTypeHttpError
+			if !errors.As(err, &unsupported) || unsupported.StatusCode() != http.StatusUnsupportedMediaType {
+				t.Errorf("BindingMediaType(%q, %t) error = %v, want an UnsupportedMediaTypeHttpError", tt.contentType, tt.strict, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("BindingMediaType(%q, %t) = %q, %v, want %q", tt.contentType, tt.strict, got, err, tt.want)
+		}
+	}
+}
+
+func TestCheckJSONContentType(t *testing.T) {
+	if err := CheckJSONContentType("text/plain", false); err != nil {
+		t.Errorf("CheckJSONContentType() without strictness error = %v", err)
+	}
+	if err := CheckJSONContentType("application/problem+json; charset=utf-8", true); err != nil {
+		t.Errorf("CheckJSONContentType() error = %v", err)
+	}
+	for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded"} {
+		if err := CheckJSONContentType(contentType, true); err == nil {
+			t.Errorf("CheckJSONContentType(%q) did not fail in strict mode", contentType)
+		}
+	}
+	if err := CheckJSONContentType("application/json; charset=utf-16", false); err == nil {
+		t.Error("CheckJSONContentType() with a UTF-16 charset did not fail")
+	}
+}
@@ -3,7 +3,15 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"iter"
+	"mime/multipart"
 	"net/http"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core/bench"
 )
 
 // FrameworkType represents the type of HTTP framework to use.
@@ -14,6 +22,10 @@ const (
 	FrameworkGin FrameworkType = "gin"
 	// FrameworkStdHTTP represents the standard net/http package.
 	FrameworkStdHTTP FrameworkType = "std"
+	// FrameworkChi represents the chi router.
+	FrameworkChi FrameworkType = "chi"
+	// FrameworkFiber represents the fiber framework, which serves requests with fasthttp.
+	FrameworkFiber FrameworkType = "fiber"
 )
 
 // HttpMethod represents an HTTP method.
@@ -40,24 +52,56 @@ type HandlerFunc func(c Context)
 type Context interface {
 	// Request returns the underlying HTTP request.
 	Request() *http.Request
-	// Writer returns the underlying ResponseWriter.
-	Writer() http.ResponseWriter
+	// SetRequest replaces the request seen by the rest of the chain,
+	// e.g. with a copy carrying a derived context (r.WithContext).
+	SetRequest(r *http.Request)
+	// Writer returns the ResponseWriter of the response, which records its status and size.
+	// Middleware reads the status with c.Writer().Status() after Next returns.
+	Writer() ResponseWriter
+	// SetWriter replaces the ResponseWriter used by the rest of the chain, e.g. with a ResponseBuffer.
+	// Middleware that replaces the writer must restore the previous one (from Writer) after Next returns.
+	SetWriter(w http.ResponseWriter)
 	// Param returns the value of the URL param.
 	Param(key string) string
+	// ParamInt returns the URL param as an int.
+	// It returns a 400 Bad Request error if the param is missing or not an integer.
+	ParamInt(key string) (int, error)
+	// ParamUUID returns the URL param after checking that it is a UUID.
+	// It returns a 400 Bad Request error if the param is missing or not a UUID.
+	ParamUUID(key string) (string, error)
 	// Query returns the value of the URL query parameter.
 	Query(key string) string
 	// DefaultQuery returns the value of the URL query parameter or the default value.
 	DefaultQuery(key, defaultValue string) string
-	// GetHeader returns the value of the request header.
+	// GetQuery returns the value of the URL query parameter and whether it is present.
+	GetQuery(key string) (string, bool)
+	// QueryArray returns all values of the URL query parameter.
+	QueryArray(key string) []string
+	// QueryMap returns the URL query parameters of the form prefix[key]=value as a map.
+	QueryMap(prefix string) map[string]string
+	// PostForm returns the value of the urlencoded or multipart form field.
+	PostForm(key string) string
+	// DefaultPostForm returns the value of the form field, or defaultValue if the field is not present.
+	DefaultPostForm(key, defaultValue string) string
+	// GetHeader returns the first value of the request header. Keys are case-insensitive.
 	GetHeader(key string) string
-	// SetHeader sets a response header.
+	// GetHeaders returns all values of the request header, one per header line received, in order.
+	// Comma-separated values within a line are not split. The returned slice must not be modified.
+	GetHeaders(key string) []string
+	// SetHeader sets a response header, replacing its values. An empty value removes the header.
 	SetHeader(key, value string)
+	// AddHeader adds a value to a response header, keeping its existing values, e.g. for Vary.
+	AddHeader(key, value string)
 	// SetStatus sets the HTTP response status code.
 	SetStatus(code int)
 	// JSON serializes the given struct as JSON into the response body.
 	JSON(code int, obj interface{})
 	// String writes the given string into the response body.
 	String(code int, format string, values ...interface{})
+	// HTML renders the template name with data using the template engine of the request (see
+	// TemplateMiddleware) and writes it as a text/html response. If rendering fails, nothing is
+	// written and the error is added with Error, so that the error handler middleware responds.
+	HTML(code int, name string, data interface{})
 	// Bind binds the request body into the given struct.
 	Bind(obj interface{}) error
 	// BindJSON binds the JSON request body into the given struct.
@@ -65,6 +109,40 @@ type Context interface {
 	// ShouldBindJSON binds the JSON request body into the given struct.
 	// If there is an error, it returns the error without aborting the request.
 	ShouldBindJSON(obj interface{}) error
+	// JSONStream writes the values produced by seq as a JSON array, one element at a time.
+	// The response is chunked and flushed after every element; use SeqFromChan to stream from a channel.
+	JSONStream(code int, seq iter.Seq[interface{}]) error
+	// NDJSON writes the values produced by seq as newline-delimited JSON, flushing after every line.
+	NDJSON(code int, seq iter.Seq[interface{}]) error
+	// CSV writes a CSV response with the given header row followed by the rows produced by rows,
+	// flushing after every row.
+	CSV(code int, headers []string, rows iter.Seq[[]string]) error
+	// Stream calls step with the response writer until it returns false, flushing after every call,
+	// e.g. to send chunks as they are produced. Set the status and headers before calling it.
+	// It stops with the first write error, or with ErrClientAborted when the client goes away.
+	Stream(step func(w io.Writer) bool) error
+	// DataFromReader copies reader to the response body with status code and content type, without
+	// buffering it in memory. contentLength is the size of the body, or -1 if it is unknown, in which
+	// case the response is chunked. Response digests are added if the response digest middleware is
+	// registered. It returns the error of reading or writing; the status may already have been sent.
+	DataFromReader(code int, contentLength int64, contentType string, reader io.Reader) error
+	// BindJSONStream reads a JSON array request body and calls fn with each element
+	// without loading the whole body into memory. It stops at the first error returned by fn.
+	BindJSONStream(fn func(element json.RawMessage) error) error
+	// GetRawData returns the request body, reading it on first use and caching it for the rest of the request.
+	// The request body is rewound on every call, so middleware and binding can all read it.
+	// Bodies larger than the configured maximum (see DefaultMaxRawDataSize) are not cached
+	// and ErrRawDataTooLarge is returned.
+	GetRawData() ([]byte, error)
+	// FormFile returns the first file of the multipart form field name, parsing the form on first use.
+	// It returns http.ErrMissingFile if there is none. See MultipartForm for the memory used.
+	FormFile(name string) (*multipart.FileHeader, error)
+	// MultipartForm returns the parsed multipart form, including the uploaded files. Up to
+	// EngineOptions.MaxMultipartMemory bytes of files, or the memory set with MultipartMemory,
+	// are kept in memory, the rest in temporary files. Limit the body size with the body limit middleware.
+	MultipartForm() (*multipart.Form, error)
+	// SaveUploadedFile copies an uploaded file to dst, creating its directory if needed.
+	SaveUploadedFile(file *multipart.FileHeader, dst string) error
 	// File serves a file.
 	File(filepath string)
 	// Redirect redirects the request to the given URL.
@@ -87,32 +165,80 @@ type Context interface {
 	// Set stores a value in the context for the given key.
 	// This is used to store values in the context.
 	Set(key string, value interface{})
+	// DetachedContext returns a context for work that must outlive the request, such as
+	// fire-and-forget goroutines or errgroup tasks. It carries the request context values
+	// (e.g. the authenticated user) and a snapshot of the values stored with Set
+	// (e.g. request ID, tenant, trace data), but is never canceled and has no deadline.
+	DetachedContext() context.Context
+	// CSPNonce returns a random nonce for the Content-Security-Policy of the request, generated on first use.
+	// Use it in the nonce attribute of inline <script> and <style> elements; the security headers
+	// middleware adds it to the policy it sends.
+	CSPNonce() string
+	// ClientIP returns the IP of the client, honoring X-Forwarded-For and X-Real-IP only for requests
+	// from the trusted proxies of the server (see Server.SetTrustedProxies) and the header of
+	// EngineOptions.TrustedPlatform. All middleware use it, so they agree on the client of a request.
+	ClientIP() string
+	// StartTime returns the start time of the request, read from the clock of the request by the first
+	// middleware asking for it, so that all middleware measuring the request share one reading.
+	StartTime() time.Time
+	// Elapsed returns the time elapsed since StartTime, measured with the clock of the request.
+	Elapsed() time.Duration
 }
 
 // ILoggingMiddleware is an interface for logging middleware implementations.
-// Each framework (Gin, StdHTTP) provides its own implementation of this interface:
+// Each framework provides its own implementation of this interface:
 // - Gin implementation: github.com/mythofleader/go-http-server/core/gin.LoggingMiddleware
 // - Standard HTTP implementation: github.com/mythofleader/go-http-server/core/std.LoggingMiddleware
+// - chi implementation: github.com/mythofleader/go-http-server/core/chi.LoggingMiddleware
+// - Fiber (fasthttp) implementation: github.com/mythofleader/go-http-server/core/fiber.LoggingMiddleware
 type ILoggingMiddleware interface {
 	// Middleware returns a middleware function that logs API requests.
 	Middleware(config *LoggingConfig) HandlerFunc
 }
 
 // IErrorHandlerMiddleware is an interface for error handler middleware implementations.
-// Each framework (Gin, StdHTTP) provides its own implementation of this interface:
+// Each framework provides its own implementation of this interface:
 // - Gin implementation: github.com/mythofleader/go-http-server/core/gin.ErrorHandlerMiddleware
 // - Standard HTTP implementation: github.com/mythofleader/go-http-server/core/std.ErrorHandlerMiddleware
+// - chi implementation: github.com/mythofleader/go-http-server/core/chi.ErrorHandlerMiddleware
+// - Fiber (fasthttp) implementation: github.com/mythofleader/go-http-server/core/fiber.ErrorHandlerMiddleware
 type IErrorHandlerMiddleware interface {
 	// Middleware returns a middleware function that handles errors.
 	Middleware(config *ErrorHandlerConfig) HandlerFunc
 }
 
+// ErrorFormat is the body format of the responses written by the error handler middleware.
+type ErrorFormat string
+
+const (
+	// ErrorFormatDefault writes {"error": {"code": ..., "message": ...}} application/json bodies.
+	ErrorFormatDefault ErrorFormat = ""
+	// ErrorFormatProblemJSON writes RFC 7807 application/problem+json bodies with the type, title,
+	// status, detail and instance members.
+	ErrorFormatProblemJSON ErrorFormat = "problem+json"
+)
+
 // ErrorHandlerConfig holds configuration for the error handler middleware.
 type ErrorHandlerConfig struct {
 	// DefaultErrorMessage is the message to use for non-HTTP errors.
 	DefaultErrorMessage string
 	// DefaultStatusCode is the status code to use for non-HTTP errors.
 	DefaultStatusCode int
+	// IncludeRequestID adds the request ID to error responses (error.request_id),
+	// so user-reported errors can be correlated with the logs.
+	IncludeRequestID bool
+	// IncludeTraceID adds the OpenTelemetry trace ID to error responses (error.trace_id).
+	IncludeTraceID bool
+	// AggregateErrors responds to requests with several errors attached via Context.Error with
+	// the highest-severity status and every error in error.details. If false, only the first
+	// error is used, as before.
+	AggregateErrors bool
+	// Format is the body format of error responses. If empty, ErrorFormatDefault is used.
+	Format ErrorFormat
+	// ProblemTypeBaseURI is the base of the type member of problem details: with
+	// "https://example.com/problems", a 404 has the type "https://example.com/problems/404".
+	// If empty, the type is "about:blank". Only used with ErrorFormatProblemJSON.
+	ProblemTypeBaseURI string
 }
 
 // LoggingConfig holds configuration for the logging middleware.
@@ -122,6 +248,81 @@ type LoggingConfig struct {
 	LoggingToConsole bool     // Whether to log to console
 	LoggingToRemote  bool     // Whether to log to remote
 	SkipPaths        []string // List of paths to ignore for logging
+
+	// Remote endpoint options, used when LoggingToRemote is true
+	RemoteGzip           bool          // Whether to gzip the payloads sent to RemoteURL
+	RemoteBasicAuthUser  string        // Username for HTTP basic authentication, disabled if empty
+	RemoteBasicAuthPass  string        // Password for HTTP basic authentication
+	RemoteAPIKey         string        // API key sent with every request, disabled if empty
+	RemoteAPIKeyHeader   string        // Header carrying RemoteAPIKey, defaults to "x-api-key"
+	RemoteRequestTimeout time.Duration // Timeout of each request to RemoteURL, defaults to 5 seconds
+
+	// Sinks receive every log entry in addition to the console and RemoteURL,
+	// e.g. Kafka or NATS sinks from the middleware package
+	Sinks []LogSink
+
+	// Logger receives the console log entries as structured fields instead of JSON printed to
+	// stdout, e.g. middleware.NewSlogLogger(slog.Default()). It is used when LoggingToConsole is true.
+	// The Authorization header is masked in entries written to it.
+	Logger Logger
+
+	// ProgressInterval makes requests that are still open after each interval, such as WebSocket
+	// connections, server-sent event streams and streamed downloads, log a progress entry with the
+	// bytes sent so far (phase "progress"). Their final entry is then marked as the summary (phase
+	// "summary"). Disabled if zero.
+	ProgressInterval time.Duration
+}
+
+// LogLevel is the level of a request log written to a Logger.
+type LogLevel int
+
+const (
+	// LogLevelInfo is used for successful requests
+	LogLevelInfo LogLevel = iota
+	// LogLevelWarn is used for requests answered with a 4xx status
+	LogLevelWarn
+	// LogLevelError is used for requests answered with a 5xx status
+	LogLevelError
+)
+
+// String returns the lower-case name of the level.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// Logger writes request logs to a structured logging library.
+// The middleware package has adapters for log/slog, zap and logrus.
+type Logger interface {
+	// Log writes msg at level with structured fields, given as alternating keys and values.
+	Log(level LogLevel, msg string, keysAndValues ...interface{})
+}
+
+// LogRecord is a log entry delivered to a LogSink.
+type LogRecord struct {
+	// RequestID is the request ID of the logged request, e.g. for use as a partition key
+	RequestID string
+	// CustomFields are the custom fields of the logging configuration (e.g. tenant)
+	CustomFields map[string]string
+	// TraceID and SpanID are the hex-encoded IDs of the server span of the logged request,
+	// empty if tracing is not active
+	TraceID string
+	SpanID  string
+	// Data is the JSON-encoded log entry
+	Data []byte
+}
+
+// LogSink receives log entries from the logging middleware.
+// WriteLog is called on the request path and must not block; implementations
+// should queue records and deliver them asynchronously.
+type LogSink interface {
+	WriteLog(record LogRecord)
 }
 
 // Controller is an interface for defining routes.
@@ -155,8 +356,25 @@ type Server interface {
 	Group(path string) RouterGroup
 	// Use adds middleware to the server
 	Use(middleware ...HandlerFunc)
+	// UseNamed adds a middleware to the server with the name shown in framework logs
+	UseNamed(name string, middleware HandlerFunc)
+	// UseDescribed adds a middleware to the server with the description returned by DescribeMiddleware
+	UseDescribed(description MiddlewareDescription, middleware HandlerFunc)
+	// DescribeMiddleware returns the middleware of the server and its router groups in the order they run
+	DescribeMiddleware() MiddlewareChain
 	// RegisterRouter registers routes from Controller objects
 	RegisterRouter(controllers ...Controller)
+	// Static serves the files of the directory dir below prefix for GET and HEAD requests
+	Static(prefix, dir string)
+	// StaticFS serves the files of fsys, e.g. an embed.FS, below prefix for GET and HEAD requests
+	StaticFS(prefix string, fsys fs.FS)
+	// StaticFile serves the single file at path for GET and HEAD requests
+	StaticFile(path, file string)
+	// DisableRoute stops accepting new requests on the route registered for method and path while
+	// its in-flight requests finish, e.g. to decommission an endpoint in stages. New requests are
+	// answered with status, 503 Service Unavailable if not provided; use 410 Gone for removed endpoints.
+	// The returned drain reports when the in-flight requests have finished. It can be called while serving.
+	DisableRoute(method HttpMethod, path string, status ...int) (*RouteDrain, error)
 	// NoRoute registers handlers for 404 Not Found errors
 	NoRoute(handlers ...HandlerFunc)
 	// NoMethod registers handlers for 405 Method Not Allowed errors
@@ -169,17 +387,121 @@ type Server interface {
 	RunTLS(addr, certFile, keyFile string) error
 	// Shutdown gracefully shuts down the server
 	Shutdown(ctx context.Context) error
+	// RunWithGracefulShutdown starts the server like Run and shuts it down gracefully once ctx is
+	// done or the process receives SIGINT or SIGTERM, waiting up to timeout for in-flight requests.
+	// It returns nil after a graceful shutdown.
+	RunWithGracefulShutdown(ctx context.Context, timeout time.Duration) error
 	// GetLoggingMiddleware returns a framework-specific logging middleware
 	GetLoggingMiddleware() ILoggingMiddleware
 	// GetErrorHandlerMiddleware returns a framework-specific error handler middleware
 	GetErrorHandlerMiddleware() IErrorHandlerMiddleware
-	// StartLambda starts the server in AWS Lambda mode.
+	// StartLambda starts the server in AWS Lambda mode, serving ALB, API Gateway and function URL events.
 	// This method should be called instead of Run or RunTLS when running in AWS Lambda.
 	// It returns an error if the framework does not support Lambda.
 	StartLambda() error
+	// SetLambdaConfig sets the event type StartLambda expects. If config is nil, the event types
+	// are detected. It must be called before StartLambda.
+	SetLambdaConfig(config *LambdaConfig)
 	// GetPort returns the port the server is configured to run on.
 	// This is useful when using random ports.
 	GetPort() string
+	// Freeze seals the route table. Registering routes or middleware afterwards panics
+	// with an error wrapping ErrServerFrozen. Run, RunTLS and StartLambda freeze the server automatically.
+	Freeze()
+	// Frozen returns whether the server has been frozen.
+	Frozen() bool
+	// OnStart registers a hook that is run by Run, RunTLS and StartLambda before serving requests.
+	// If a hook returns an error, the server does not start and the error is returned, after the
+	// stop hooks registered before the failed hook have run.
+	OnStart(hook LifecycleHook)
+	// OnStop registers a hook that is run by Stop and Shutdown, in reverse registration order.
+	OnStop(hook LifecycleHook)
+	// SetHTTPServerConfig sets the timeouts, header limits and TLS settings of the http.Server
+	// created by Run and RunTLS. It must be called before the server starts.
+	SetHTTPServerConfig(config *HTTPServerConfig)
+	// SetFastPath answers the health check paths of config before the middleware chain in
+	// Handler, Run and RunTLS. It must be called before the server starts.
+	SetFastPath(config *FastPathConfig)
+	// SetTrustedProxies sets the IPs and CIDR ranges of the proxies, e.g. load balancers, whose
+	// X-Forwarded-For and X-Real-IP headers are trusted by Context.ClientIP. No proxy is trusted by
+	// default, so the remote address is the client IP. It must be called before the server starts.
+	SetTrustedProxies(proxies []string) error
+	// SetEngineOptions maps options to the adapter's native router and request parsing settings.
+	// If options is nil, the defaults are restored. It must be called before the server starts.
+	SetEngineOptions(options *EngineOptions)
+	// Dynamic returns the server's dynamic router, enabling dynamic routing mode on the first call.
+	// Dynamic routing must be enabled before the server is frozen; routes can then be added
+	// and removed at any time, including while serving.
+	Dynamic() DynamicRouter
+	// Handler returns the http.Handler that serves the registered routes.
+	// This is useful for serving requests in-memory, e.g. with httptest.
+	Handler() http.Handler
+	// SelfBench drives the handler in-memory with synthetic traffic for the given duration
+	// and reports throughput and latency percentiles per route.
+	SelfBench(routes []bench.Route, concurrency int, duration time.Duration) (*bench.Report, error)
+	// Unwrap returns the native engine of the adapter, for backend-specific settings the abstraction
+	// does not cover, such as trusted proxies or custom renderers: the *gin.Engine of the Gin adapter,
+	// the *chi.Mux of the chi adapter and the *fiber.App of the Fiber adapter. The std adapter routes
+	// requests itself and returns its *std.Server. Routes and middleware added to the engine directly
+	// bypass the adapter, e.g. its middleware chain, route conflict checks and route listing.
+	Unwrap() interface{}
+}
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	Method HttpMethod
+	Path   string
+}
+
+// DynamicRouter holds routes that can be added and removed while the server is serving.
+// Dynamic routes are matched only when no static route matches the request.
+// The default implementation is github.com/mythofleader/go-http-server/core/dynamic.Router.
+type DynamicRouter interface {
+	// Add registers a route. Paths may contain ":name" parameters and a trailing "*name" wildcard.
+	Add(method HttpMethod, path string, handlers ...HandlerFunc) error
+	// Remove deregisters a route and returns whether it existed
+	Remove(method HttpMethod, path string) bool
+	// Routes returns the registered routes
+	Routes() []RouteInfo
+	// Serve runs the handlers of the route matching the request and returns whether one matched
+	Serve(c Context) bool
+}
+
+// RouteServer is implemented by the contexts of the adapters. It lets routers that match a route
+// once the middleware chain has started, such as the dynamic router, serve it like a static route.
+type RouteServer interface {
+	// ServeRoute makes params available through Param and runs handlers as the rest of the
+	// handler chain, so that Next and Abort behave as they do on static routes.
+	ServeRoute(params map[string]string, handlers []HandlerFunc)
+}
+
+// RunHandlers runs handlers as a chain of their own from within a handler of c: each handler
+// runs after the previous one returns or calls Next, and Abort skips the remaining ones as well as
+// the rest of the chain of c. Adapters whose chain cannot be extended use it for ServeRoute.
+func RunHandlers(c Context, handlers []HandlerFunc) {
+	(&handlerChain{Context: c, handlers: handlers, index: -1}).Next()
+}
+
+// handlerChain is a Context running its own chain of handlers.
+type handlerChain struct {
+	Context
+	handlers []HandlerFunc
+	index    int
+}
+
+// Next runs the pending handlers of the chain.
+func (c *handlerChain) Next() {
+	c.index++
+	for c.index < len(c.handlers) {
+		c.handlers[c.index](c)
+		c.index++
+	}
+}
+
+// Abort skips the pending handlers of the chain and of the underlying context.
+func (c *handlerChain) Abort() {
+	c.index = len(c.handlers)
+	c.Context.Abort()
 }
 
 // RouterGroup is a group of routes.
@@ -200,4 +522,10 @@ type RouterGroup interface {
 	Use(middleware ...HandlerFunc)
 	// RegisterRouter registers routes from Controller objects
 	RegisterRouter(controllers ...Controller)
+	// Static serves the files of the directory dir below prefix for GET and HEAD requests
+	Static(prefix, dir string)
+	// StaticFS serves the files of fsys, e.g. an embed.FS, below prefix for GET and HEAD requests
+	StaticFS(prefix string, fsys fs.FS)
+	// StaticFile serves the single file at path for GET and HEAD requests
+	StaticFile(path, file string)
 }
@@ -0,0 +1,28 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// ContextKeyCSPNonce is the context key under which the CSP nonce of the request is stored.
+const ContextKeyCSPNonce = "csp_nonce"
+
+// CSPNonce returns the Content-Security-Policy nonce of the request, generating it on first use.
+// The nonce is 128 random bits, base64-encoded, and stays the same for the rest of the request,
+// so the security headers middleware and the handler rendering inline scripts agree on it.
+// It is used by Context.CSPNonce implementations.
+func CSPNonce(c Context) string {
+	if nonce, ok := c.Get(ContextKeyCSPNonce); ok {
+		return nonce.(string)
+	}
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to generate CSP nonce: %v", err))
+	}
+	nonce := base64.StdEncoding.EncodeToString(b[:])
+	c.Set(ContextKeyCSPNonce, nonce)
+	return nonce
+}
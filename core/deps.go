@@ -0,0 +1,92 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Deps gives controller factories access to shared dependencies such as database handles and
+// API clients, so that controllers receive them through their constructor instead of globals.
+// Use Resolve or MustResolve to look up a dependency by type.
+type Deps interface {
+	// Lookup returns the dependency of type t. If t is an interface, the first dependency
+	// implementing it is returned unless one has exactly that type.
+	Lookup(t reflect.Type) (interface{}, bool)
+}
+
+// ControllerFactory constructs a controller from the dependencies of the server.
+type ControllerFactory func(deps Deps) Controller
+
+// Container is the Deps implementation of the server builder. It is safe for concurrent use.
+type Container struct {
+	mu     sync.RWMutex
+	values []interface{} // In the order they were provided
+}
+
+// NewContainer returns a Container holding values.
+func NewContainer(values ...interface{}) *Container {
+	c := &Container{}
+	c.Provide(values...)
+	return c
+}
+
+// Provide adds dependencies to the container. Nil values are ignored.
+func (c *Container) Provide(values ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, value := range values {
+		if value != nil {
+			c.values = append(c.values, value)
+		}
+	}
+}
+
+// Lookup implements Deps.Lookup.
+func (c *Container) Lookup(t reflect.Type) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, value := range c.values {
+		if reflect.TypeOf(value) == t {
+			return value, true
+		}
+	}
+	if t.Kind() == reflect.Interface {
+		for _, value := range c.values {
+			if reflect.TypeOf(value).Implements(t) {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Resolve returns the dependency of type T. It returns an error wrapping ErrDependencyNotFound
+// if there is none.
+//
+// Example usage:
+//
+//	db, err := core.Resolve[*sql.DB](deps)
+func Resolve[T any](deps Deps) (T, error) {
+	var zero T
+	t := reflect.TypeFor[T]()
+	if deps != nil {
+		if value, ok := deps.Lookup(t); ok {
+			if typed, ok := value.(T); ok {
+				return typed, nil
+			}
+		}
+	}
+	return zero, fmt.Errorf("%w: %s", ErrDependencyNotFound, t)
+}
+
+// MustResolve is like Resolve but panics if there is no dependency of type T.
+// The server builder reports the panic of a controller factory as an error of Build.
+func MustResolve[T any](deps Deps) T {
+	value, err := Resolve[T](deps)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
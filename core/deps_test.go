@@ -0,0 +1,38 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type greeter interface {
+	Greet() string
+}
+
+type englishGreeter struct{}
+
+func (englishGreeter) Greet() string { return "hello" }
+
+func TestContainerResolve(t *testing.T) {
+	counter := new(int)
+	deps := NewContainer(englishGreeter{}, counter, nil)
+
+	if got, err := Resolve[*int](deps); err != nil || got != counter {
+		t.Errorf("Resolve[*int]() = %v, %v, want the provided pointer", got, err)
+	}
+	if got, err := Resolve[greeter](deps); err != nil || got.Greet() != "hello" {
+		t.Errorf("Resolve[greeter]() = %v, %v, want the greeter implementation", got, err)
+	}
+	if _, err := Resolve[fmt.Stringer](deps); !errors.Is(err, ErrDependencyNotFound) {
+		t.Errorf("Resolve[fmt.Stringer]() error = %v, want ErrDependencyNotFound", err)
+	}
+
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrDependencyNotFound) {
+			t.Errorf("MustResolve panicked with %v, want ErrDependencyNotFound", err)
+		}
+	}()
+	MustResolve[string](deps)
+}
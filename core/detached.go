@@ -0,0 +1,41 @@
+package core
+
+import "context"
+
+// ContextKeyRequestID is the context key under which the request ID and logging middleware store the request ID.
+const ContextKeyRequestID = "request_id"
+
+// detachedContext is a context that is never canceled and additionally resolves
+// string keys from a snapshot of the request's key-value store.
+type detachedContext struct {
+	context.Context
+	keys map[string]interface{}
+}
+
+// Value returns the value for key from the key snapshot, falling back to the parent context.
+func (c *detachedContext) Value(key interface{}) interface{} {
+	if k, ok := key.(string); ok {
+		if value, exists := c.keys[k]; exists {
+			return value
+		}
+	}
+	return c.Context.Value(key)
+}
+
+// NewDetachedContext returns a context that carries the values of parent and of keys,
+// but not parent's cancellation or deadline.
+// It is used by Context.DetachedContext implementations; keys must not be modified afterwards.
+func NewDetachedContext(parent context.Context, keys map[string]interface{}) context.Context {
+	return &detachedContext{
+		Context: context.WithoutCancel(parent),
+		keys:    keys,
+	}
+}
+
+// RequestIDFromContext returns the request ID stored by the request ID or logging middleware,
+// or an empty string if there is none.
+// It works with both the request context values and contexts returned by Context.DetachedContext.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(ContextKeyRequestID).(string)
+	return requestID
+}
@@ -0,0 +1,29 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type parentKey struct{}
+
+func TestNewDetachedContext(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.WithValue(context.Background(), parentKey{}, "user"), time.Minute)
+	cancel()
+
+	ctx := NewDetachedContext(parent, map[string]interface{}{ContextKeyRequestID: "req-1"})
+
+	if err := ctx.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil for a detached context", err)
+	}
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Deadline() reported a deadline for a detached context")
+	}
+	if got := ctx.Value(parentKey{}); got != "user" {
+		t.Errorf("Value(parentKey) = %v, want user", got)
+	}
+	if got := RequestIDFromContext(ctx); got != "req-1" {
+		t.Errorf("RequestIDFromContext() = %q, want req-1", got)
+	}
+}
@@ -0,0 +1,29 @@
+package core
+
+// RouteDoc documents a route for the generated OpenAPI document.
+type RouteDoc struct {
+	// Summary is a short summary of what the route does
+	Summary string
+	// Description explains the route in detail
+	Description string
+	// Tags group the route with related routes, e.g. "users"
+	Tags []string
+	// OperationID uniquely identifies the route, e.g. "createUser"
+	OperationID string
+	// Request is a value of the type of the request body, e.g. CreateUserRequest{}, or nil for
+	// requests without a body. Its schema is derived from the type and its json tags.
+	Request interface{}
+	// Responses maps status codes to a value of the type of the response body, or nil for
+	// responses without a body. If empty, the status codes of the examples are documented.
+	Responses map[int]interface{}
+	// Deprecated marks the route as deprecated
+	Deprecated bool
+}
+
+// DocumentedController is an optional interface for controllers that document their route.
+// The documentation is served as an OpenAPI document (see ServerBuilder.WithOpenAPI), along with
+// the examples of controllers that also implement ExampleProvider.
+type DocumentedController interface {
+	// Documentation returns the documentation of the route
+	Documentation() RouteDoc
+}
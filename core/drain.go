@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// ErrRouteNotFound is wrapped by the errors of DisableRoute when no route is registered for the method and path.
+var ErrRouteNotFound = errors.New("route not found")
+
+// RouteDrainer counts the in-flight requests of each route so that routes can be disabled while
+// their in-flight requests finish. Framework-specific Server implementations use it to implement
+// DisableRoute, running the handler returned by Guard before the handlers of each route.
+type RouteDrainer struct {
+	mu     sync.Mutex
+	routes map[string]*routeGate // Keyed by "METHOD path"
+}
+
+// routeGate tracks the in-flight requests of a route and whether it has been disabled.
+type routeGate struct {
+	inFlight atomic.Int64
+	status   atomic.Int64 // Status of rejected requests, zero while the route is enabled
+	drain    *RouteDrain  // Set when the route is disabled
+}
+
+// RouteDrain reports when the requests that were in flight on a disabled route have finished.
+type RouteDrain struct {
+	gate *routeGate
+	done chan struct{}
+	once sync.Once
+}
+
+// Guard returns the handler counting the in-flight requests of the route registered for method
+// and path. Once the route is disabled, it answers new requests with the status of DisableRoute.
+func (d *RouteDrainer) Guard(method, path string) HandlerFunc {
+	d.mu.Lock()
+	if d.routes == nil {
+		d.routes = make(map[string]*routeGate)
+	}
+	gate := &routeGate{}
+	d.routes[method+" "+path] = gate
+	d.mu.Unlock()
+
+	return func(c Context) {
+		// Count the request before checking the status, so that DisableRoute either sees the
+		// request in flight or the request sees the route disabled
+		gate.inFlight.Add(1)
+		defer gate.release()
+		if status := int(gate.status.Load()); status != 0 {
+			c.JSON(status, httperrors.NewErrorResponse(status, "This endpoint is no longer available"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// release ends a request on the route and completes the drain once no requests are in flight.
+func (g *routeGate) release() {
+	if g.inFlight.Add(-1) == 0 && g.status.Load() != 0 {
+		g.drain.complete()
+	}
+}
+
+// Disable stops accepting new requests on the route registered for method and path, answering them
+// with status, and returns the drain of the requests in flight. If status is zero,
+// 503 Service Unavailable is used. Disabling a disabled route changes its status and returns the same drain.
+func (d *RouteDrainer) Disable(method, path string, status int) (*RouteDrain, error) {
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	gate, ok := d.routes[method+" "+path]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrRouteNotFound, method, path)
+	}
+	if gate.drain == nil {
+		gate.drain = &RouteDrain{gate: gate, done: make(chan struct{})}
+	}
+	gate.status.Store(int64(status))
+	if gate.inFlight.Load() == 0 {
+		gate.drain.complete()
+	}
+	return gate.drain, nil
+}
+
+// complete closes the done channel of the drain.
+func (r *RouteDrain) complete() {
+	r.once.Do(func() { close(r.done) })
+}
+
+// Done returns a channel that is closed once the requests in flight when the route was disabled have finished.
+func (r *RouteDrain) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait waits until the requests in flight when the route was disabled have finished, or ctx is done.
+func (r *RouteDrain) Wait(ctx context.Context) error {
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// InFlight returns the number of requests on the route that are currently being served.
+func (r *RouteDrain) InFlight() int {
+	return int(r.gate.inFlight.Load())
+}
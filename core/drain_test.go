@@ -0,0 +1,35 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRouteDrainer(t *testing.T) {
+	var d RouteDrainer
+	d.Guard("GET", "/reports/:id")
+
+	drain, err := d.Disable("GET", "/reports/:id", 0)
+	if err != nil {
+		t.Fatalf("Disable() error = %v", err)
+	}
+	// Without requests in flight, the drain completes immediately
+	select {
+	case <-drain.Done():
+	default:
+		t.Fatal("drain of an idle route did not complete")
+	}
+	if status := d.routes["GET /reports/:id"].status.Load(); status != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503 by default", status)
+	}
+
+	again, _ := d.Disable("GET", "/reports/:id", http.StatusGone)
+	if again != drain || d.routes["GET /reports/:id"].status.Load() != http.StatusGone {
+		t.Error("disabling a disabled route did not return its drain with the new status")
+	}
+
+	if _, err := d.Disable("POST", "/reports/:id", 0); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("Disable() of a missing route error = %v, want ErrRouteNotFound", err)
+	}
+}
@@ -0,0 +1,188 @@
+// Package dynamic provides a router whose routes can be added and removed while the server is serving.
+// It is used by core.Server.Dynamic for plugin-style applications that load endpoints at runtime.
+//
+// Dynamic routes are consulted only when no static route matches the request, so they cannot
+// shadow routes registered with GET, POST, etc. The route table is immutable and swapped
+// atomically on every change, so lookups never block on writers.
+package dynamic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// paramsKey is the context key under which matched path parameters are stored.
+const paramsKey = "dynamic.params"
+
+// route is a registered dynamic route.
+type route struct {
+	method   core.HttpMethod
+	path     string
+	segments []string
+	handlers []core.HandlerFunc
+}
+
+// Router is the default implementation of core.DynamicRouter.
+// It is safe for concurrent use.
+type Router struct {
+	mu     sync.Mutex               // Serializes writers
+	routes atomic.Pointer[[]*route] // Current immutable route table
+}
+
+// NewRouter creates a new, empty Router.
+func NewRouter() *Router {
+	r := &Router{}
+	r.routes.Store(&[]*route{})
+	return r
+}
+
+// Add registers a route. Paths may contain ":name" parameters and a trailing "*name" wildcard.
+// It returns an error if a route with the same method and path already exists.
+func (r *Router) Add(method core.HttpMethod, path string, handlers ...core.HandlerFunc) error {
+	if len(handlers) == 0 {
+		return fmt.Errorf("dynamic: route %s %s has no handlers", method, path)
+	}
+	for _, handler := range handlers {
+		if handler == nil {
+			return fmt.Errorf("dynamic: route %s %s has a nil handler", method, path)
+		}
+	}
+	segments := splitPath(path)
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "*") && i != len(segments)-1 {
+			return fmt.Errorf("dynamic: wildcard must be the last segment in %s", path)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := *r.routes.Load()
+	for _, existing := range current {
+		if existing.method == method && existing.path == path {
+			return fmt.Errorf("dynamic: route %s %s already exists", method, path)
+		}
+	}
+
+	next := make([]*route, len(current), len(current)+1)
+	copy(next, current)
+	next = append(next, &route{
+		method:   method,
+		path:     path,
+		segments: segments,
+		handlers: handlers,
+	})
+	r.routes.Store(&next)
+	return nil
+}
+
+// Remove deregisters a route. It returns false if the route does not exist.
+// Requests already being served by the route are not interrupted.
+func (r *Router) Remove(method core.HttpMethod, path string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current := *r.routes.Load()
+	next := make([]*route, 0, len(current))
+	removed := false
+	for _, existing := range current {
+		if existing.method == method && existing.path == path {
+			removed = true
+			continue
+		}
+		next = append(next, existing)
+	}
+	if removed {
+		r.routes.Store(&next)
+	}
+	return removed
+}
+
+// Routes returns the registered routes sorted by path and method.
+func (r *Router) Routes() []core.RouteInfo {
+	current := *r.routes.Load()
+	infos := make([]core.RouteInfo, len(current))
+	for i, rt := range current {
+		infos[i] = core.RouteInfo{Method: rt.method, Path: rt.path}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Path != infos[j].Path {
+			return infos[i].Path < infos[j].Path
+		}
+		return infos[i].Method < infos[j].Method
+	})
+	return infos
+}
+
+// Serve runs the handlers of the route matching the request, if any, as the rest of the handler
+// chain of c, with the path parameters available through c.Param. It returns false if no dynamic
+// route matches.
+func (r *Router) Serve(c core.Context) bool {
+	req := c.Request()
+	segments := splitPath(req.URL.Path)
+
+	for _, rt := range *r.routes.Load() {
+		if string(rt.method) != req.Method {
+			continue
+		}
+		params, ok := match(rt.segments, segments)
+		if !ok {
+			continue
+		}
+
+		c.Set(paramsKey, params)
+		if server, ok := c.(core.RouteServer); ok {
+			server.ServeRoute(params, rt.handlers)
+		} else {
+			core.RunHandlers(c, rt.handlers)
+		}
+		return true
+	}
+	return false
+}
+
+// Param returns the value of a path parameter matched by a dynamic route.
+// If the request was not served by a dynamic route, it falls back to c.Param.
+// The contexts of the adapters also return dynamic route parameters from c.Param.
+func Param(c core.Context, key string) string {
+	if value, exists := c.Get(paramsKey); exists {
+		if params, ok := value.(map[string]string); ok {
+			if param, found := params[key]; found {
+				return param
+			}
+		}
+	}
+	return c.Param(key)
+}
+
+// match matches path segments against a route pattern and returns the extracted parameters.
+func match(pattern, segments []string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for i, part := range pattern {
+		if strings.HasPrefix(part, "*") {
+			params[part[1:]] = "/" + strings.Join(segments[i:], "/")
+			return params, true
+		}
+		if i >= len(segments) {
+			return nil, false
+		}
+		if strings.HasPrefix(part, ":") {
+			params[part[1:]] = segments[i]
+			continue
+		}
+		if part != segments[i] {
+			return nil, false
+		}
+	}
+	return params, len(pattern) == len(segments)
+}
+
+// splitPath splits a path into its non-empty segments.
+func splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+}
@@ -0,0 +1,62 @@
+package dynamic
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		path     string
+		expected map[string]string
+		ok       bool
+	}{
+		{"static", "/plugins/list", "/plugins/list", map[string]string{}, true},
+		{"param", "/plugins/:id", "/plugins/42", map[string]string{"id": "42"}, true},
+		{"wildcard", "/files/*path", "/files/a/b", map[string]string{"path": "/a/b"}, true},
+		{"too short", "/plugins/:id", "/plugins", nil, false},
+		{"too long", "/plugins/:id", "/plugins/1/2", nil, false},
+		{"static mismatch", "/plugins/list", "/plugins/other", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, ok := match(splitPath(tt.pattern), splitPath(tt.path))
+			if ok != tt.ok {
+				t.Fatalf("match(%q, %q) ok = %v, want %v", tt.pattern, tt.path, ok, tt.ok)
+			}
+			if ok && !reflect.DeepEqual(params, tt.expected) {
+				t.Errorf("match(%q, %q) = %v, want %v", tt.pattern, tt.path, params, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRouterAddRemove(t *testing.T) {
+	r := NewRouter()
+	if err := r.Add("GET", "/a", nil); err == nil {
+		t.Error("Add() without handlers did not return error")
+	}
+	if err := r.Add("GET", "/*rest/x", func(c core.Context) {}); err == nil {
+		t.Error("Add() with a non-trailing wildcard did not return error")
+	}
+	if err := r.Add("GET", "/a", func(c core.Context) {}); err != nil {
+		t.Fatalf("Add() returned error: %v", err)
+	}
+	if err := r.Add("GET", "/a", func(c core.Context) {}); err == nil {
+		t.Error("Add() with a duplicate route did not return error")
+	}
+	if got := r.Routes(); len(got) != 1 || got[0].Path != "/a" {
+		t.Errorf("Routes() = %v, want [GET /a]", got)
+	}
+	if !r.Remove("GET", "/a") {
+		t.Error("Remove() of an existing route returned false")
+	}
+	if r.Remove("GET", "/a") {
+		t.Error("Remove() of a missing route returned true")
+	}
+}
@@ -0,0 +1,37 @@
+package core
+
+// Headers set by hosting platforms with the client IP, for EngineOptions.TrustedPlatform.
+const (
+	// PlatformGoogleAppEngine is the client IP header of Google App Engine.
+	PlatformGoogleAppEngine = "X-Appengine-Remote-Addr"
+	// PlatformCloudflare is the client IP header of Cloudflare's CDN.
+	PlatformCloudflare = "CF-Connecting-IP"
+	// PlatformFlyIO is the client IP header of Fly.io.
+	PlatformFlyIO = "Fly-Client-IP"
+)
+
+// DefaultMaxMultipartMemory is the memory used to parse multipart forms when
+// EngineOptions.MaxMultipartMemory is zero, matching Gin's default.
+const DefaultMaxMultipartMemory = 32 << 20 // 32 MB
+
+// EngineOptions holds router and request parsing settings that each adapter maps to its
+// native settings. Zero values keep the defaults of the adapters.
+type EngineOptions struct {
+	// TrustedPlatform is the header set by the hosting platform with the client IP, such as
+	// PlatformCloudflare. It is trusted over X-Forwarded-For by Context.ClientIP.
+	TrustedPlatform string
+	// MaxMultipartMemory is the memory used to parse multipart forms; larger files are stored
+	// in temporary files. If zero, DefaultMaxMultipartMemory is used.
+	MaxMultipartMemory int64
+	// RemoveExtraSlash cleans the request path before routing, so that "/users//42"
+	// and "/users/./42" match "/users/:id".
+	RemoveExtraSlash bool
+	// UseRawPath routes on the escaped path (url.URL.RawPath), so that an encoded slash ("%2F")
+	// can be part of a path parameter. Parameter values are unescaped.
+	UseRawPath bool
+	// StrictContentType makes Bind reject bodies without a JSON, XML, form or multipart
+	// Content-Type, and BindJSON bodies without a JSON one, with 415 Unsupported Media Type.
+	// If false, Bind falls back to form values and BindJSON decodes any body as JSON, as Gin does.
+	// Content-Type parameters are ignored either way, except that non-UTF-8 charsets are rejected.
+	StrictContentType bool
+}
@@ -0,0 +1,16 @@
+package core
+
+import "errors"
+
+// ErrServerFrozen is the panic value (wrapped) raised when routes or middleware are registered
+// after the server has been frozen by Run, RunTLS, StartLambda or an explicit Freeze call.
+// Routes that need to change while serving should use the dynamic routing mode instead.
+var ErrServerFrozen = errors.New("server is frozen: routes and middleware cannot be registered after Run or Freeze")
+
+// ErrRouteConflict is the panic value (wrapped) raised when a route is registered that conflicts
+// with an existing route, e.g. the same method and path, or different parameter names at the same position.
+var ErrRouteConflict = errors.New("route conflict")
+
+// ErrDependencyNotFound is wrapped by the errors of Resolve, and by the panic value of MustResolve,
+// when no dependency of the requested type has been provided.
+var ErrDependencyNotFound = errors.New("dependency not found")
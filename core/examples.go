@@ -0,0 +1,55 @@
+package core
+
+// DefaultExamplesPath is the path the examples endpoint is served on by default.
+const DefaultExamplesPath = "/docs/examples"
+
+// Example is an example request and response for a route.
+type Example struct {
+	// Name identifies the example, e.g. "success" or "missing name"
+	Name string `json:"name"`
+	// Description explains what the example demonstrates
+	Description string `json:"description,omitempty"`
+	// Request is the example request body, or nil for requests without a body
+	Request interface{} `json:"request,omitempty"`
+	// StatusCode is the status code of the example response
+	StatusCode int `json:"statusCode"`
+	// Response is the example response body
+	Response interface{} `json:"response,omitempty"`
+}
+
+// ExampleProvider is an optional interface for controllers that provide example
+// requests and responses for their route. The examples are served by the examples
+// endpoint (see ServerBuilder.WithExamplesEndpoint) for developer portals.
+type ExampleProvider interface {
+	// Examples returns the example requests and responses for the route
+	Examples() []Example
+}
+
+// RouteExamples holds the examples of a single route.
+type RouteExamples struct {
+	Method   HttpMethod `json:"method"`
+	Path     string     `json:"path"`
+	Examples []Example  `json:"examples"`
+}
+
+// CollectExamples returns the examples of all controllers that implement ExampleProvider,
+// in registration order.
+func CollectExamples(controllers []Controller) []RouteExamples {
+	routes := make([]RouteExamples, 0)
+	for _, controller := range controllers {
+		provider, ok := controller.(ExampleProvider)
+		if !ok {
+			continue
+		}
+		examples := provider.Examples()
+		if len(examples) == 0 {
+			continue
+		}
+		routes = append(routes, RouteExamples{
+			Method:   controller.GetHttpMethod(),
+			Path:     controller.GetPath(),
+			Examples: examples,
+		})
+	}
+	return routes
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"net/http"
+	"slices"
+)
+
+// DefaultFastPathPaths are the paths answered by the fast path when FastPathConfig.Paths is empty.
+var DefaultFastPathPaths = []string{"/health", "/ping"}
+
+// FastPathConfig configures health check paths that are answered before the middleware chain,
+// so that frequent load balancer probes skip logging, authentication and body handling.
+type FastPathConfig struct {
+	// Paths are the exact request paths answered by the fast path.
+	// If empty, DefaultFastPathPaths is used.
+	Paths []string
+	// Handler answers fast path requests. It must be cheap and must not depend on middleware.
+	// If nil, requests are answered with 200 OK and the body "ok".
+	Handler http.Handler
+}
+
+// FastPathHandler returns a handler that answers GET and HEAD requests for the fast path
+// paths of config itself and passes all other requests to next.
+// If config is nil, next is returned unchanged.
+// It is used by the Handler, Run and RunTLS implementations.
+func FastPathHandler(next http.Handler, config *FastPathConfig) http.Handler {
+	if config == nil {
+		return next
+	}
+
+	paths := config.Paths
+	if len(paths) == 0 {
+		paths = DefaultFastPathPaths
+	}
+	match := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		match[path] = true
+	}
+
+	handler := config.Handler
+	if handler == nil {
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusOK)
+			if r.Method != http.MethodHead {
+				w.Write([]byte("ok"))
+			}
+		})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method == http.MethodGet || r.Method == http.MethodHead) && match[r.URL.Path] {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Matches returns whether a request with method and path is answered by the fast path of config.
+// It is used by adapters that do not serve requests with FastPathHandler.
+func (config *FastPathConfig) Matches(method, path string) bool {
+	if config == nil || (method != http.MethodGet && method != http.MethodHead) {
+		return false
+	}
+	paths := config.Paths
+	if len(paths) == 0 {
+		paths = DefaultFastPathPaths
+	}
+	return slices.Contains(paths, path)
+}
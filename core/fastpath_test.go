@@ -0,0 +1,42 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFastPathHandler(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	custom := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		config *FastPathConfig
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"disabled", nil, http.MethodGet, "/health", http.StatusTeapot, ""},
+		{"default path", &FastPathConfig{}, http.MethodGet, "/health", http.StatusOK, "ok"},
+		{"head", &FastPathConfig{}, http.MethodHead, "/ping", http.StatusOK, ""},
+		{"other method", &FastPathConfig{}, http.MethodPost, "/health", http.StatusTeapot, ""},
+		{"other path", &FastPathConfig{}, http.MethodGet, "/health/db", http.StatusTeapot, ""},
+		{"custom path", &FastPathConfig{Paths: []string{"/livez"}}, http.MethodGet, "/health", http.StatusTeapot, ""},
+		{"custom handler", &FastPathConfig{Paths: []string{"/livez"}, Handler: custom}, http.MethodGet, "/livez", http.StatusNoContent, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			FastPathHandler(next, tt.config).ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.status || rec.Body.String() != tt.body {
+				t.Errorf("got %d %q, want %d %q", rec.Code, rec.Body.String(), tt.status, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package fiber
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/valyala/fasthttp"
+)
+
+// SetEngineOptions implements core.Server.SetEngineOptions for Server
+// Since fiber reads its configuration when the app is created, the app is recreated with the registered routes.
+func (s *Server) SetEngineOptions(options *core.EngineOptions) {
+	if options == nil {
+		options = &core.EngineOptions{}
+	}
+	s.config.Options = *options
+	s.app = s.newApp()
+	for _, rt := range s.routes {
+		s.addRoute(rt)
+	}
+}
+
+// newApp returns a fiber app configured like the routers of the other adapters:
+// routing is case-sensitive and strict about trailing slashes, and on the unescaped path unless
+// EngineOptions.UseRawPath is set.
+func (s *Server) newApp() *fiber.App {
+	return fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		CaseSensitive:         true,
+		StrictRouting:         true,
+		UnescapePath:          !s.config.Options.UseRawPath,
+		// Parameter values stay valid after the request, as with the other adapters
+		Immutable: true,
+		// The Content-Type of responses without one is detected from the body, as with net/http
+		DisableDefaultContentType: true,
+		ErrorHandler:              s.handleError,
+	})
+}
+
+// setRoutePath sets the path fiber routes fctx on. With EngineOptions.RemoveExtraSlash, it is
+// cleaned; the request seen by the handlers keeps the path as received.
+func (s *Server) setRoutePath(fctx *fasthttp.RequestCtx) {
+	if !s.config.Options.RemoveExtraSlash {
+		return
+	}
+	p := fctx.URI().PathOriginal()
+	if !bytes.Contains(p, []byte("//")) && !bytes.Contains(p, []byte("/.")) {
+		return
+	}
+	fctx.URI().SetPath(cleanPath(string(p)))
+}
+
+// cleanPath removes repeated slashes and "." and ".." segments from p, keeping a trailing slash.
+// Paths that are already clean are returned without allocating.
+func cleanPath(p string) string {
+	if !strings.Contains(p, "//") && !strings.Contains(p, "/.") {
+		return p
+	}
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// SetTrustedProxies implements core.Server.SetTrustedProxies for Server.
+func (s *Server) SetTrustedProxies(proxies []string) error {
+	trusted, err := core.ParseTrustedProxies(proxies...)
+	if err != nil {
+		return err
+	}
+	s.config.TrustedProxies = trusted
+	return nil
+}
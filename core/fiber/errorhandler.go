@@ -0,0 +1,16 @@
+package fiber
+
+import (
+	"github.com/mythofleader/go-http-server/core/internal/nethttp"
+	"github.com/mythofleader/go-http-server/core/middleware"
+)
+
+// ErrorHandlerMiddleware is a fiber implementation of middleware.IErrorHandlerMiddleware.
+// It is the error handler middleware of the std adapter: responses of requests served by fasthttp
+// go through the adapter's net/http writer, so errors are captured from the status and body.
+type ErrorHandlerMiddleware = nethttp.ErrorHandlerMiddleware
+
+// NewErrorHandlerMiddleware creates a new ErrorHandlerMiddleware.
+func NewErrorHandlerMiddleware() middleware.IErrorHandlerMiddleware {
+	return &ErrorHandlerMiddleware{}
+}
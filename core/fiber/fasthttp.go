@@ -0,0 +1,151 @@
+package fiber
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/valyala/fasthttp"
+)
+
+// newRequest returns the net/http request of a request served by fasthttp, with a context that is
+// canceled by the returned function. Unlike fasthttpadaptor.ConvertRequest, the URI and headers are
+// copied and repeated headers are kept, so middleware may keep them after the request.
+// The body is read from the fasthttp request and must be consumed while the handlers run.
+func newRequest(fctx *fasthttp.RequestCtx) (*http.Request, context.CancelFunc, error) {
+	// The header keeps the request URI as received, even if the routing path was cleaned
+	requestURI := string(fctx.Request.Header.RequestURI())
+	u, err := url.ParseRequestURI(requestURI)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body := fctx.PostBody()
+	r := &http.Request{
+		Method:        string(fctx.Method()),
+		URL:           u,
+		Proto:         string(fctx.Request.Header.Protocol()),
+		Header:        make(http.Header),
+		ContentLength: int64(len(body)),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		Host:          string(fctx.Host()),
+		RemoteAddr:    fctx.RemoteAddr().String(),
+		RequestURI:    requestURI,
+		TLS:           fctx.TLSConnectionState(),
+	}
+	var ok bool
+	if r.ProtoMajor, r.ProtoMinor, ok = http.ParseHTTPVersion(r.Proto); !ok {
+		r.ProtoMajor, r.ProtoMinor = 1, 1
+	}
+	fctx.Request.Header.VisitAll(func(key, value []byte) {
+		switch k := string(key); k {
+		case "Host":
+			// net/http keeps the host in Request.Host only
+		case "Transfer-Encoding":
+			r.TransferEncoding = append(r.TransferEncoding, string(value))
+		default:
+			r.Header.Add(k, string(value))
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return r.WithContext(ctx), cancel, nil
+}
+
+// responseWriter is the http.ResponseWriter of requests served by fasthttp.
+// The header is copied to the fasthttp response when it is written, and the body is buffered by
+// fasthttp and sent once the handlers return, so streamed responses arrive in one piece.
+type responseWriter struct {
+	fctx        *fasthttp.RequestCtx
+	header      http.Header
+	wroteHeader bool
+}
+
+// reset makes the writer write the response of fctx, as if nothing had been written yet.
+func (w *responseWriter) reset(fctx *fasthttp.RequestCtx) {
+	w.fctx = fctx
+	w.header = make(http.Header)
+	w.wroteHeader = false
+}
+
+// Header implements http.ResponseWriter.Header.
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader copies the header and status code to the fasthttp response.
+// As with net/http, changes to the header afterwards have no effect.
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.fctx.SetStatusCode(code)
+	for key, values := range w.header {
+		for _, value := range values {
+			w.fctx.Response.Header.Add(key, value)
+		}
+	}
+}
+
+// Write appends data to the body of the fasthttp response, writing a 200 OK header first if none has been written.
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.fctx.Write(data)
+}
+
+// Flush implements http.Flusher. It has no effect, since fasthttp sends the response once the handlers return.
+func (w *responseWriter) Flush() {}
+
+// finish writes the header if the handlers did not, and detects the Content-Type of the body
+// if none was set, as net/http does.
+func (w *responseWriter) finish() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if _, ok := w.header["Content-Type"]; ok {
+		return
+	}
+	if body := w.fctx.Response.Body(); len(body) > 0 {
+		w.fctx.Response.Header.SetContentType(http.DetectContentType(body[:min(len(body), 512)]))
+	}
+}
+
+// bridge carries a request received by the net/http handler of the server through fiber's router.
+// The handlers read r and write to w directly, so request context values, streaming and hijacking
+// work as with the std adapter.
+type bridge struct {
+	fctx   fasthttp.RequestCtx // Request routed by fiber, with the method and path of r
+	w      http.ResponseWriter
+	r      *http.Request
+	served bool // Whether a Context has been created for r
+}
+
+// bridgeKey is the fasthttp user value key of the bridge of a request.
+type bridgeKey struct{}
+
+// reset prepares b for routing r.
+func (b *bridge) reset(w http.ResponseWriter, r *http.Request) {
+	b.fctx.Request.Reset()
+	b.fctx.Response.Reset()
+	b.fctx.ResetUserValues()
+	b.fctx.Request.Header.SetMethod(r.Method)
+	b.fctx.Request.SetRequestURI(r.URL.EscapedPath())
+	b.fctx.SetUserValue(bridgeKey{}, b)
+	b.w = w
+	b.r = r
+	b.served = false
+}
+
+// writeResponse copies a response written by fiber itself, e.g. for an unknown method, to w.
+func (b *bridge) writeResponse() {
+	b.fctx.Response.Header.VisitAll(func(key, value []byte) {
+		b.w.Header().Add(string(key), string(value))
+	})
+	b.w.WriteHeader(b.fctx.Response.StatusCode())
+	_, _ = b.w.Write(b.fctx.Response.Body())
+}
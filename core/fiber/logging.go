@@ -0,0 +1,17 @@
+package fiber
+
+import (
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/internal/nethttp"
+)
+
+// LoggingMiddleware is a fiber implementation of core.ILoggingMiddleware.
+// It is the logging middleware of the std adapter: for requests served by fasthttp, it reads the
+// net/http request converted by the adapter and the status recorded by its writer, so log entries
+// match those of the other adapters.
+type LoggingMiddleware = nethttp.LoggingMiddleware
+
+// NewLoggingMiddleware creates a new LoggingMiddleware.
+func NewLoggingMiddleware() core.ILoggingMiddleware {
+	return &LoggingMiddleware{}
+}
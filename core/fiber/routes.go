@@ -0,0 +1,323 @@
+package fiber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// route is a route registered with the fiber router.
+type route struct {
+	method   string
+	path     string             // Path as registered, e.g. "/users/:id"
+	pattern  string             // Path in fiber's syntax, e.g. "/static/*" for "/static/*filepath"
+	key      string             // Path with unnamed parameters, for conflict checks
+	wildcard string             // Name of the trailing "*name" wildcard, if any
+	handlers []core.HandlerFunc // Route handlers, preceded by the DisableRoute guard
+	chain    []core.HandlerFunc // Middleware and route handlers, built by Freeze
+}
+
+// fiberPattern converts a route path to fiber's syntax: ":name" parameters are kept, and a
+// trailing "*name" wildcard becomes "*", whose name is returned.
+func fiberPattern(path string) (pattern, wildcard string) {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "*") {
+			segments[i] = "*"
+			wildcard = segment[1:]
+		}
+	}
+	return strings.Join(segments, "/"), wildcard
+}
+
+// routeKey returns path with the names of its parameters removed. Routes with the same key
+// match the same requests.
+func routeKey(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = segment[:1]
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// handle registers handlers for method and path with the fiber router.
+// It panics with an error wrapping core.ErrRouteConflict if a route matching the same requests
+// is already registered, since fiber would silently run the first one only.
+func (s *Server) handle(method, path string, handlers []core.HandlerFunc) {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	s.checkNotFrozen(method + " " + path)
+
+	key := routeKey(path)
+	for _, registered := range s.routes {
+		if registered.method != method || registered.key != key {
+			continue
+		}
+		if registered.path == path {
+			panic(fmt.Errorf("%w: %s %s is already registered", core.ErrRouteConflict, method, path))
+		}
+		panic(fmt.Errorf("%w: %s %s matches the same requests as %s %s", core.ErrRouteConflict, method, path, method, registered.path))
+	}
+
+	pattern, wildcard := fiberPattern(path)
+	// The guard counts the in-flight requests of the route for DisableRoute
+	guarded := make([]core.HandlerFunc, 0, len(handlers)+1)
+	guarded = append(guarded, s.drainer.Guard(method, path))
+	rt := &route{
+		method:   method,
+		path:     path,
+		pattern:  pattern,
+		key:      key,
+		wildcard: wildcard,
+		handlers: append(guarded, handlers...),
+	}
+	s.routes = append(s.routes, rt)
+	s.addRoute(rt)
+}
+
+// addRoute adds rt to the fiber app. Unlike app.Get, app.Add does not register HEAD routes.
+func (s *Server) addRoute(rt *route) {
+	s.app.Add(rt.method, rt.pattern, s.routeHandler(rt))
+}
+
+// combineHandlers returns the middleware followed by the route handlers.
+func combineHandlers(middleware, handlers []core.HandlerFunc) []core.HandlerFunc {
+	combined := make([]core.HandlerFunc, 0, len(middleware)+len(handlers))
+	combined = append(combined, middleware...)
+	return append(combined, handlers...)
+}
+
+// params looks up the path parameters of a request routed by fiber.
+type params struct {
+	fiber    *fiber.Ctx // fiber context holding the path parameters of the matched route
+	route    *route     // Matched route, nil for NoRoute, NoMethod and dynamic requests
+	unescape bool       // Whether parameter values were matched against the escaped path
+}
+
+// Param implements nethttp.ParamLookup.Param
+// The trailing wildcard of a route, e.g. "*filepath", is read from fiber's "*" parameter and
+// starts with a slash, as in Gin.
+func (p *params) Param(key string) string {
+	if p.route == nil {
+		return ""
+	}
+	var value string
+	if p.route.wildcard != "" && p.route.wildcard == key {
+		value = "/" + p.fiber.Params("*")
+	} else {
+		value = p.fiber.Params(key)
+	}
+	if p.unescape && strings.Contains(value, "%") {
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+	}
+	return value
+}
+
+// request is the Context of a request routed by fiber, allocated together with its parameters
+// and, for requests served by fasthttp, its writer.
+type request struct {
+	ctx    Context
+	params params
+	resp   responseWriter     // Writer of requests served by fasthttp, embedded to save an allocation
+	cancel context.CancelFunc // Cancels the context of requests served by fasthttp, nil otherwise
+}
+
+// newContext returns the request of fc matched by fiber against rt, nil if no route matched.
+// Requests received by ServeHTTP keep their net/http request and writer; requests served by
+// fasthttp are converted with newRequest and written with a responseWriter.
+func (s *Server) newContext(fc *fiber.Ctx, rt *route) (*request, error) {
+	req := &request{params: params{fiber: fc, route: rt, unescape: s.config.Options.UseRawPath}}
+	if b, ok := fc.Context().UserValue(bridgeKey{}).(*bridge); ok {
+		b.served = true
+		req.ctx.Init(&s.config, b.w, b.r)
+	} else {
+		r, cancel, err := newRequest(fc.Context())
+		if err != nil {
+			return nil, err
+		}
+		req.cancel = cancel
+		req.resp.reset(fc.Context())
+		req.ctx.Init(&s.config, &req.resp, r)
+	}
+	req.ctx.SetParamLookup(&req.params)
+	return req, nil
+}
+
+// serve runs handlers as the handler chain of the request of fc.
+func (s *Server) serve(fc *fiber.Ctx, rt *route, handlers []core.HandlerFunc) error {
+	req, err := s.newContext(fc, rt)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	defer req.release()
+
+	req.ctx.Serve(handlers)
+	return nil
+}
+
+// release completes the fasthttp response and cancels the request context once the handlers returned.
+func (r *request) release() {
+	if r.cancel == nil {
+		return
+	}
+	r.resp.finish()
+	r.cancel()
+}
+
+// routeHandler returns the fiber handler running the middleware chain followed by the handlers of rt.
+func (s *Server) routeHandler(rt *route) fiber.Handler {
+	return func(fc *fiber.Ctx) error {
+		handlers := rt.chain
+		if handlers == nil {
+			handlers = combineHandlers(s.middleware, rt.handlers)
+		}
+		return s.serve(fc, rt, handlers)
+	}
+}
+
+// bridgePool holds the bridges of ServeHTTP, whose fasthttp request contexts are large.
+var bridgePool = sync.Pool{
+	New: func() interface{} {
+		b := &bridge{}
+		b.fctx.Init(&fasthttp.Request{}, nil, nil)
+		return b
+	},
+}
+
+// ServeHTTP implements http.Handler for Server
+// fiber routes a fasthttp request carrying the method and path of r, while the handlers read r and
+// write to w directly. Run and RunTLS serve requests with fasthttp instead.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b := bridgePool.Get().(*bridge)
+	defer bridgePool.Put(b)
+
+	b.reset(w, r)
+	s.setRoutePath(&b.fctx)
+	s.appHandler()(&b.fctx)
+	if !b.served {
+		b.writeResponse()
+	}
+	// Do not keep the request and writer alive in the pool
+	b.w, b.r = nil, nil
+}
+
+// appHandler returns the fasthttp handler of the fiber app. Until the server is frozen, the
+// handler is fetched for every request, so that fiber rebuilds its routing tree after new routes.
+func (s *Server) appHandler() fasthttp.RequestHandler {
+	if s.Frozen() {
+		return s.handler
+	}
+	return s.app.Handler()
+}
+
+// fastHTTPHandler returns the fasthttp handler of Run and RunTLS. Fast path requests are
+// answered by the fast path handler through fasthttpadaptor.
+func (s *Server) fastHTTPHandler() fasthttp.RequestHandler {
+	if s.fastPath == nil {
+		return s.serveFastHTTP
+	}
+	fastPath := fasthttpadaptor.NewFastHTTPHandler(core.FastPathHandler(http.NotFoundHandler(), s.fastPath))
+	return func(fctx *fasthttp.RequestCtx) {
+		if s.fastPath.Matches(utils.UnsafeString(fctx.Method()), utils.UnsafeString(fctx.Path())) {
+			fastPath(fctx)
+			return
+		}
+		s.serveFastHTTP(fctx)
+	}
+}
+
+// serveFastHTTP serves a request received by fasthttp.
+// As with net/http, a panic is logged and does not stop the server; the client gets a 500 response.
+func (s *Server) serveFastHTTP(fctx *fasthttp.RequestCtx) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("[FIBER] panic serving %s: %v\n%s", fctx.RemoteAddr(), err, debug.Stack())
+			fctx.Error(http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}()
+
+	s.setRoutePath(fctx)
+	s.handler(fctx)
+}
+
+// handleError is the error handler of the fiber app. fiber reports requests without a matching route
+// as 404 and 405 errors, which run the NoRoute and NoMethod handlers after the middleware chain.
+func (s *Server) handleError(fc *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		switch fiberErr.Code {
+		case fiber.StatusNotFound:
+			return s.serveNotFound(fc)
+		case fiber.StatusMethodNotAllowed:
+			return s.serveMethodNotAllowed(fc)
+		}
+	}
+	return fiber.DefaultErrorHandler(fc, err)
+}
+
+// serveNotFound runs the middleware chain followed by the matching dynamic route or the NoRoute
+// handlers. Without either, it responds with 404 Not Found.
+func (s *Server) serveNotFound(fc *fiber.Ctx) error {
+	notFound := func(c core.Context) {
+		http.NotFound(c.Writer(), c.Request())
+	}
+	if s.dynamic == nil && len(s.noRouteHandlers) == 0 {
+		return s.serve(fc, nil, []core.HandlerFunc{notFound})
+	}
+
+	handlers := make([]core.HandlerFunc, 0, len(s.middleware)+len(s.noRouteHandlers)+1)
+	handlers = append(handlers, s.middleware...)
+	if s.dynamic != nil {
+		handlers = append(handlers, s.serveDynamic)
+	}
+	if len(s.noRouteHandlers) > 0 {
+		handlers = append(handlers, s.noRouteHandlers...)
+	} else {
+		handlers = append(handlers, notFound)
+	}
+	return s.serve(fc, nil, handlers)
+}
+
+// serveDynamic serves the request with a dynamic route and skips the NoRoute handlers if one matched.
+func (s *Server) serveDynamic(c core.Context) {
+	if s.dynamic.Serve(c) {
+		c.Abort()
+	}
+}
+
+// serveMethodNotAllowed runs the NoMethod handlers for a request whose path matches a route
+// of a different method.
+func (s *Server) serveMethodNotAllowed(fc *fiber.Ctx) error {
+	// Special handling for OPTIONS requests to support CORS preflight
+	if fc.Method() == http.MethodOptions {
+		// Run middleware only for OPTIONS requests
+		return s.serve(fc, nil, combineHandlers(s.middleware, nil))
+	}
+
+	if len(s.noMethodHandlers) == 0 {
+		// Use default error response
+		return s.serve(fc, nil, []core.HandlerFunc{func(c core.Context) {
+			http.Error(c.Writer(), "Method not allowed", http.StatusMethodNotAllowed)
+		}})
+	}
+
+	// Use custom NoMethod handlers
+	return s.serve(fc, nil, combineHandlers(s.middleware, s.noMethodHandlers))
+}
@@ -0,0 +1,157 @@
+package fiber
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/valyala/fasthttp"
+)
+
+func TestFiberPattern(t *testing.T) {
+	tests := []struct {
+		path, pattern, wildcard string
+	}{
+		{"/", "/", ""},
+		{"/users/:id", "/users/:id", ""},
+		{"/orgs/:org/repos/:repo", "/orgs/:org/repos/:repo", ""},
+		{"/static/*filepath", "/static/*", "filepath"},
+	}
+	for _, tt := range tests {
+		pattern, wildcard := fiberPattern(tt.path)
+		if pattern != tt.pattern || wildcard != tt.wildcard {
+			t.Errorf("fiberPattern(%q) = %q, %q, want %q, %q", tt.path, pattern, wildcard, tt.pattern, tt.wildcard)
+		}
+	}
+}
+
+func TestRouteConflicts(t *testing.T) {
+	tests := []struct {
+		first, second string
+		conflict      bool
+	}{
+		{"/users", "/users", true},
+		{"/users/:id", "/users/:uid", true},
+		{"/users/:id", "/users/new", false},
+		{"/users/:id", "/users/:name/posts", false},
+	}
+	for _, tt := range tests {
+		s := NewServer("8080", false)
+		handler := func(c core.Context) {}
+		s.GET(tt.first, handler)
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if conflict := errors.Is(err, core.ErrRouteConflict); conflict != tt.conflict {
+					t.Errorf("registering %s after %s panicked with %v, want conflict: %v", tt.second, tt.first, err, tt.conflict)
+				}
+			}()
+			s.GET(tt.second, handler)
+		}()
+	}
+}
+
+func TestNoRouteRunsMiddleware(t *testing.T) {
+	s := NewServer("8080", false)
+	s.Use(func(c core.Context) {
+		c.SetHeader("X-Middleware", "ran")
+		c.Next()
+	})
+	s.GET("/users/:id", func(c core.Context) { c.String(http.StatusOK, c.Param("id")) })
+	s.NoRoute(func(c core.Context) { c.String(http.StatusNotFound, "no route for %s", c.Request().URL.Path) })
+
+	client := servertest.NewClient(s)
+	client.GET("/users/42").Expect(t).Status(http.StatusOK).Body("42")
+	client.GET("/orders").Expect(t).
+		Status(http.StatusNotFound).
+		Header("X-Middleware", "ran").
+		Body("no route for /orders")
+	client.Request(http.MethodDelete, "/users/42").Expect(t).Status(http.StatusMethodNotAllowed)
+}
+
+// serveFastHTTP serves a request with the fasthttp handler of Run and returns the response.
+func serveFastHTTP(s *Server, method, uri, contentType, body string) *fasthttp.Response {
+	var req fasthttp.Request
+	req.Header.SetMethod(method)
+	req.SetRequestURI(uri)
+	req.Header.SetHost("example.com")
+	req.Header.Add("X-Tag", "a")
+	req.Header.Add("X-Tag", "b")
+	if contentType != "" {
+		req.Header.SetContentType(contentType)
+		req.SetBodyString(body)
+	}
+
+	var fctx fasthttp.RequestCtx
+	fctx.Init(&req, nil, nil)
+	s.Freeze()
+	s.fastHTTPHandler()(&fctx)
+	return &fctx.Response
+}
+
+func TestServeFastHTTP(t *testing.T) {
+	s := NewServer("8080", false)
+	s.SetEngineOptions(&core.EngineOptions{RemoveExtraSlash: true})
+	s.SetFastPath(&core.FastPathConfig{})
+	s.Use(func(c core.Context) {
+		c.SetHeader("X-Middleware", "ran")
+		c.Next()
+	})
+	s.GET("/users/:id", func(c core.Context) {
+		c.JSON(http.StatusOK, map[string]interface{}{
+			"id":   c.Param("id"),
+			"q":    c.Query("q"),
+			"host": c.Request().Host,
+			"tags": c.Request().Header.Values("X-Tag"),
+		})
+	})
+	s.GET("/static/*filepath", func(c core.Context) { c.String(http.StatusOK, "%s", c.Param("filepath")) })
+	s.POST("/users", func(c core.Context) {
+		var user struct {
+			Name string `json:"name"`
+		}
+		if err := c.BindJSON(&user); err != nil {
+			c.String(http.StatusBadRequest, "%v", err)
+			return
+		}
+		c.SetStatus(http.StatusCreated)
+		_, _ = c.Writer().Write([]byte("<p>" + user.Name + "</p>"))
+	})
+	s.GET("/panic", func(c core.Context) { panic("boom") })
+
+	tests := []struct {
+		name, method, uri, contentType, body string
+		status                               int
+		wantContentType, wantBody            string
+	}{
+		{"params and headers", http.MethodGet, "/users/42?q=go", "", "", http.StatusOK, "application/json",
+			`{"host":"example.com","id":"42","q":"go","tags":["a","b"]}` + "\n"},
+		{"extra slashes", http.MethodGet, "//users//7", "", "", http.StatusOK, "application/json",
+			`{"host":"example.com","id":"7","q":"","tags":["a","b"]}` + "\n"},
+		{"wildcard", http.MethodGet, "/static/css/site.css", "", "", http.StatusOK, "text/plain", "/css/site.css"},
+		{"detected content type", http.MethodPost, "/users", "application/json", `{"name":"Ann"}`, http.StatusCreated,
+			"text/html; charset=utf-8", "<p>Ann</p>"},
+		{"not found", http.MethodGet, "/orders", "", "", http.StatusNotFound, "text/plain; charset=utf-8", "404 page not found\n"},
+		{"method not allowed", http.MethodDelete, "/users/42", "", "", http.StatusMethodNotAllowed, "text/plain; charset=utf-8",
+			"Method not allowed\n"},
+		{"panic", http.MethodGet, "/panic", "", "", http.StatusInternalServerError, "text/plain; charset=utf-8",
+			"Internal Server Error"},
+		{"fast path", http.MethodGet, "/health", "", "", http.StatusOK, "text/plain; charset=utf-8", "ok"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := serveFastHTTP(s, tt.method, tt.uri, tt.contentType, tt.body)
+			if resp.StatusCode() != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode(), tt.status)
+			}
+			if contentType := string(resp.Header.ContentType()); contentType != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", contentType, tt.wantContentType)
+			}
+			if body := string(resp.Body()); body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
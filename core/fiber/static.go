@@ -0,0 +1,62 @@
+package fiber
+
+import (
+	"io/fs"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// Static implements core.Server.Static for Server
+func (s *Server) Static(prefix, dir string) {
+	s.StaticFS(prefix, os.DirFS(dir))
+}
+
+// StaticFS implements core.Server.StaticFS for Server
+func (s *Server) StaticFS(prefix string, fsys fs.FS) {
+	s.handleStatic(core.StaticRoute(prefix), []core.HandlerFunc{serveFiles(prefix, fsys)})
+}
+
+// StaticFile implements core.Server.StaticFile for Server
+func (s *Server) StaticFile(path, file string) {
+	s.handleStatic(path, []core.HandlerFunc{serveFile(file)})
+}
+
+// handleStatic registers handlers for GET and HEAD requests of path.
+func (s *Server) handleStatic(path string, handlers []core.HandlerFunc) {
+	s.handle(http.MethodGet, path, handlers)
+	s.handle(http.MethodHead, path, handlers)
+}
+
+// Static implements core.RouterGroup.Static for RouterGroup
+func (g *RouterGroup) Static(prefix, dir string) {
+	g.StaticFS(prefix, os.DirFS(dir))
+}
+
+// StaticFS implements core.RouterGroup.StaticFS for RouterGroup
+func (g *RouterGroup) StaticFS(prefix string, fsys fs.FS) {
+	g.server.handleStatic(core.StaticRoute(g.prefix+prefix), g.withMiddleware([]core.HandlerFunc{serveFiles(g.prefix+prefix, fsys)}))
+}
+
+// StaticFile implements core.RouterGroup.StaticFile for RouterGroup
+func (g *RouterGroup) StaticFile(path, file string) {
+	g.server.handleStatic(g.prefix+path, g.withMiddleware([]core.HandlerFunc{serveFile(file)}))
+}
+
+// serveFiles returns a handler serving the files of fsys for requests below prefix.
+// Missing files and directories without an index.html are answered with 404 Not Found.
+func serveFiles(prefix string, fsys fs.FS) core.HandlerFunc {
+	fileServer := http.StripPrefix(strings.TrimSuffix(prefix, "/"), http.FileServer(core.NewStaticFileSystem(fsys)))
+	return func(c core.Context) {
+		fileServer.ServeHTTP(c.Writer(), c.Request())
+	}
+}
+
+// serveFile returns a handler serving the file at file.
+func serveFile(file string) core.HandlerFunc {
+	return func(c core.Context) {
+		http.ServeFile(c.Writer(), c.Request(), file)
+	}
+}
@@ -0,0 +1,148 @@
+// Package fuzz provides a harness for fuzzing servers built with this package.
+// Inputs are served in-memory through the server's http.Handler, and each response is
+// checked for panics and well-formed error bodies.
+//
+// Example usage:
+//
+//	func FuzzRoutes(f *testing.F) {
+//		s := newServer()
+//		fuzz.Seed(f, "/users", "/users/1")
+//		f.Fuzz(fuzz.Target(s))
+//	}
+package fuzz
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// seedMethods are the methods used for seed corpus entries.
+var seedMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodDelete,
+	http.MethodPatch,
+	http.MethodOptions,
+}
+
+// Seed adds a seed corpus entry for every combination of the given paths and common HTTP methods.
+// The corpus entries match the arguments of the function returned by Target.
+func Seed(f *testing.F, paths ...string) {
+	for _, path := range paths {
+		for _, method := range seedMethods {
+			f.Add(method, path, "Content-Type: application/json", []byte(`{}`))
+		}
+	}
+}
+
+// Target returns a fuzz function for f.Fuzz that sends each input to the server.
+// Headers are given as "Key: value" lines separated by newlines.
+func Target(srv core.Server) func(t *testing.T, method, path, headers string, body []byte) {
+	handler := srv.Handler()
+	return func(t *testing.T, method, path, headers string, body []byte) {
+		Check(t, handler, method, path, headers, body)
+	}
+}
+
+// Check sends a single request to the handler and reports a failure if the handler panics
+// or returns a malformed response. Inputs that cannot form a valid HTTP request are ignored.
+func Check(t testing.TB, handler http.Handler, method, path, headers string, body []byte) {
+	t.Helper()
+
+	req, ok := buildRequest(method, path, headers, body)
+	if !ok {
+		return
+	}
+
+	rec := httptest.NewRecorder()
+	if stack := serve(handler, rec, req); stack != "" {
+		t.Fatalf("handler panicked for %s %q: %s", req.Method, req.URL.RequestURI(), stack)
+	}
+
+	if err := CheckResponse(rec); err != nil {
+		t.Errorf("malformed response for %s %q: %v", req.Method, req.URL.RequestURI(), err)
+	}
+}
+
+// CheckResponse validates a recorded response.
+// The status code must be valid, and JSON error responses must use the ErrorResponse
+// format with a code that matches the status code.
+func CheckResponse(rec *httptest.ResponseRecorder) error {
+	if rec.Code < 100 || rec.Code > 599 {
+		return fmt.Errorf("invalid status code %d", rec.Code)
+	}
+
+	if rec.Code < http.StatusBadRequest || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		return nil
+	}
+
+	var resp errors.ErrorResponse
+	decoder := json.NewDecoder(bytes.NewReader(rec.Body.Bytes()))
+	if err := decoder.Decode(&resp); err != nil {
+		return fmt.Errorf("error body is not valid JSON: %v", err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("error body contains more than one JSON value: %s", rec.Body.String())
+	}
+	if resp.Error.Code != rec.Code {
+		return fmt.Errorf("error code %d does not match status code %d", resp.Error.Code, rec.Code)
+	}
+	return nil
+}
+
+// serve calls the handler and returns the panic value and stack trace if it panics.
+func serve(handler http.Handler, w http.ResponseWriter, req *http.Request) (stack string) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack = fmt.Sprintf("%v\n%s", r, debug.Stack())
+		}
+	}()
+	handler.ServeHTTP(w, req)
+	return ""
+}
+
+// buildRequest creates a request from fuzz inputs.
+// It returns false if the inputs cannot form a valid HTTP request.
+func buildRequest(method, path, headers string, body []byte) (*http.Request, bool) {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	u, err := url.ParseRequestURI(path)
+	if err != nil {
+		return nil, false
+	}
+
+	req, err := http.NewRequest(method, "http://example.com", bytes.NewReader(body))
+	if err != nil {
+		return nil, false
+	}
+	req.URL.Path = u.Path
+	req.URL.RawPath = u.RawPath
+	req.URL.RawQuery = u.RawQuery
+	req.RequestURI = u.RequestURI()
+	req.RemoteAddr = "192.0.2.1:1234"
+
+	scanner := bufio.NewScanner(strings.NewReader(headers))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t\r") {
+			continue
+		}
+		req.Header.Add(key, strings.TrimSpace(value))
+	}
+
+	return req, true
+}
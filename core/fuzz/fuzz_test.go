@@ -0,0 +1,51 @@
+package fuzz
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+type echoRequest struct {
+	Name string `json:"name"`
+}
+
+func newTestServer() core.Server {
+	s := std.NewServer("8080", false)
+	s.POST("/echo", func(c core.Context) {
+		var req echoRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, map[string]interface{}{
+				"error": map[string]interface{}{"code": http.StatusBadRequest, "message": err.Error()},
+			})
+			return
+		}
+		c.JSON(http.StatusOK, req)
+	})
+	return s
+}
+
+func FuzzServer(f *testing.F) {
+	Seed(f, "/echo", "/missing", "/echo?x=1")
+	f.Add("POST", "/echo", "Content-Type: application/json\nX-Request-ID: 1", []byte(`{"name":`))
+	f.Fuzz(Target(newTestServer()))
+}
+
+func TestBuildRequest(t *testing.T) {
+	if _, ok := buildRequest("BAD METHOD", "/", "", nil); ok {
+		t.Error("buildRequest() accepted an invalid method")
+	}
+
+	req, ok := buildRequest("GET", "users?id=1", "X-Test: a\ninvalid\n: empty", nil)
+	if !ok {
+		t.Fatal("buildRequest() rejected a valid request")
+	}
+	if req.URL.Path != "/users" || req.URL.RawQuery != "id=1" {
+		t.Errorf("buildRequest() URL = %s, want /users?id=1", req.URL)
+	}
+	if got := req.Header.Get("X-Test"); got != "a" || len(req.Header) != 1 {
+		t.Errorf("buildRequest() headers = %v, want only X-Test: a", req.Header)
+	}
+}
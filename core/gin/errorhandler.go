@@ -2,13 +2,9 @@
 package gin
 
 import (
-	"errors"
-	"fmt"
-
 	"github.com/gin-gonic/gin"
 	"github.com/mythofleader/go-http-server/core"
 	"github.com/mythofleader/go-http-server/core/middleware"
-	tErrors "github.com/mythofleader/go-http-server/core/middleware/errors"
 )
 
 // ErrorHandlerMiddleware is a Gin-specific implementation of middleware.IErrorHandlerMiddleware.
@@ -31,18 +27,8 @@ func (m *ErrorHandlerMiddleware) Middleware(config *core.ErrorHandlerConfig) cor
 			// Create a recovery function to catch panics
 			defer func() {
 				if r := recover(); r != nil {
-					// Handle panic
-					var err error
-					switch e := r.(type) {
-					case string:
-						err = tErrors.NewInternalServerHttpError(fmt.Errorf("%s", e))
-					case error:
-						err = tErrors.NewInternalServerHttpError(e)
-					default:
-						err = tErrors.NewInternalServerHttpError(fmt.Errorf("unknown error: %v", e))
-					}
-
-					handleError(c, err, config)
+					// Handle panic; HTTP errors keep their status
+					handleError(c, middleware.PanicError(r), config)
 				}
 			}()
 
@@ -51,7 +37,7 @@ func (m *ErrorHandlerMiddleware) Middleware(config *core.ErrorHandlerConfig) cor
 
 			// Check if there are any errors
 			if errs := c.Errors(); len(errs) > 0 {
-				handleError(c, errs[0], config)
+				middleware.WriteErrorResponse(c, errs, config)
 			}
 			return
 		}
@@ -62,18 +48,8 @@ func (m *ErrorHandlerMiddleware) Middleware(config *core.ErrorHandlerConfig) cor
 		// Create a recovery function to catch panics
 		defer func() {
 			if r := recover(); r != nil {
-				// Handle panic
-				var err error
-				switch e := r.(type) {
-				case string:
-					err = tErrors.NewInternalServerHttpError(fmt.Errorf("%s", e))
-				case error:
-					err = tErrors.NewInternalServerHttpError(e)
-				default:
-					err = tErrors.NewInternalServerHttpError(fmt.Errorf("unknown error: %v", e))
-				}
-
-				handleError(c, err, config)
+				// Handle panic; HTTP errors keep their status
+				handleError(c, middleware.PanicError(r), config)
 
 				// Abort the request
 				gc.Abort()
@@ -85,8 +61,11 @@ func (m *ErrorHandlerMiddleware) Middleware(config *core.ErrorHandlerConfig) cor
 
 		// Check if there are any errors
 		if len(gc.Errors) > 0 {
-			// Get the first error
-			handleError(c, gc.Errors[0].Err, config)
+			errs := make([]error, 0, len(gc.Errors))
+			for _, ginErr := range gc.Errors {
+				errs = append(errs, ginErr.Err)
+			}
+			middleware.WriteErrorResponse(c, errs, config)
 			// Abort the request
 			gc.Abort()
 		}
@@ -94,12 +73,7 @@ func (m *ErrorHandlerMiddleware) Middleware(config *core.ErrorHandlerConfig) cor
 }
 
 func handleError(c core.Context, err error, config *core.ErrorHandlerConfig) {
-	var httpErr tErrors.HTTPError
-	if errors.As(err, &httpErr) {
-		c.JSON(httpErr.StatusCode(), tErrors.NewErrorResponse(httpErr.StatusCode(), httpErr.Error()))
-		return
-	}
-	c.JSON(config.DefaultStatusCode, tErrors.NewErrorResponse(config.DefaultStatusCode, config.DefaultErrorMessage))
+	middleware.WriteErrorResponse(c, []error{err}, config)
 }
 
 // NewErrorHandlerMiddleware creates a new ErrorHandlerMiddleware.
@@ -2,9 +2,6 @@
 package gin
 
 import (
-	"fmt"
-	"time"
-
 	"github.com/gin-gonic/gin"
 	"github.com/mythofleader/go-http-server/core"
 	"github.com/mythofleader/go-http-server/core/middleware"
@@ -26,88 +23,29 @@ func (m *LoggingMiddleware) Middleware(config *core.LoggingConfig) core.HandlerF
 		config = middleware.DefaultLoggingConfig()
 	}
 
-	return func(c core.Context) {
-		// Get the Gin context
-		ginContext, ok := c.(*Context)
-		if !ok {
-			// Handle the case when it's not a Gin context
-			// Get request path
-			path := c.Request().URL.Path
-
-			// Check if the path is in the skip paths list
-			if util.IsSkipPaths(path, config.SkipPaths) {
-				c.Next()
-				return
-			}
-
-			// Start timer
-			start := time.Now()
-
-			// Get request details before processing
-			req := c.Request()
-			requestID := req.Header.Get("X-Request-ID")
+	skipPaths := util.CompilePaths(config.SkipPaths)
 
-			// Ensure request ID is in response
-			if requestID == "" {
-				requestID = fmt.Sprintf("%d", time.Now().UnixNano())
-				c.SetHeader("X-Request-ID", requestID)
-			} else {
-				c.SetHeader("X-Request-ID", requestID)
-			}
-
-			// Continue with the next handler
+	return func(c core.Context) {
+		// Check if the path is in the skip paths list
+		if skipPaths.Match(c.Request().URL.Path) {
 			c.Next()
-
-			// Calculate latency
-			latency := time.Since(start).Milliseconds()
-
-			// Create log entry
-			logEntry := m.BaseLoggingMiddleware.CreateLogEntry(req, 200, latency, requestID, config)
-
-			// Process the log
-			m.BaseLoggingMiddleware.ProcessLog(logEntry, config)
 			return
 		}
 
-		// Start timer
-		start := time.Now()
-
-		// Get request details before processing
-		req := c.Request()
-		requestID := req.Header.Get("X-Request-ID")
-
-		// Ensure request ID is in response
-		if requestID == "" {
-			requestID = fmt.Sprintf("%d", time.Now().UnixNano())
-			c.SetHeader("X-Request-ID", requestID)
-		} else {
-			c.SetHeader("X-Request-ID", requestID)
-		}
-
-		// Get the underlying gin.Context
-		gc := ginContext.ginContext
-
-		// Use Gin's built-in middleware to capture the status code
-		gc.Next()
-
-		// Calculate latency
-		latency := time.Since(start).Milliseconds()
-
-		// Get the status code from the Gin context
-		statusCode := gc.Writer.Status()
-
-		// Get error information if available
-		var errorMsg string
-		if len(gc.Errors) > 0 {
-			errorMsg = gc.Errors.String()
+		// Log entries of Gin contexts carry the errors recorded by Gin
+		var errorMessage func(statusCode int) string
+		if ginContext, ok := c.(*Context); ok {
+			errorMessage = func(statusCode int) string {
+				if gc := ginContext.ginContext; len(gc.Errors) > 0 {
+					return gc.Errors.String()
+				}
+				if statusCode == core.StatusClientClosedRequest {
+					return "Client closed request"
+				}
+				return ""
+			}
 		}
-
-		// Create log entry with the actual status code
-		logEntry := m.BaseLoggingMiddleware.CreateLogEntry(req, statusCode, latency, requestID, config)
-		logEntry.Error = errorMsg
-
-		// Process the log
-		m.BaseLoggingMiddleware.ProcessLog(logEntry, config)
+		m.BaseLoggingMiddleware.LogRequest(c, config, errorMessage)
 	}
 }
 
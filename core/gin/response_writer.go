@@ -0,0 +1,103 @@
+package gin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// responseWriter adapts a plain http.ResponseWriter installed with Context.SetWriter to gin.ResponseWriter.
+// Writes go to the replacement writer; connection-level operations (Hijack, CloseNotify, Pusher)
+// go to the original gin writer.
+type responseWriter struct {
+	gin.ResponseWriter
+	writer http.ResponseWriter
+	status int
+	size   int
+}
+
+// newResponseWriter returns a gin.ResponseWriter that writes to w.
+func newResponseWriter(original gin.ResponseWriter, w http.ResponseWriter) *responseWriter {
+	return &responseWriter{
+		ResponseWriter: original,
+		writer:         w,
+		status:         http.StatusOK,
+		size:           -1,
+	}
+}
+
+// Unwrap returns the replacement writer so that http.ResponseController can reach it.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.writer
+}
+
+// Header returns the header map of the replacement writer.
+func (w *responseWriter) Header() http.Header {
+	return w.writer.Header()
+}
+
+// WriteHeader records the status code; it is sent with the first write, as gin does.
+func (w *responseWriter) WriteHeader(code int) {
+	if code > 0 && !w.Written() {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow sends the status code if it has not been sent yet.
+func (w *responseWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.size = 0
+		w.writer.WriteHeader(w.status)
+	}
+}
+
+// Write writes data to the replacement writer.
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.writer.Write(data)
+	w.size += n
+	return n, err
+}
+
+// WriteString writes s to the replacement writer.
+func (w *responseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Status returns the response status code.
+func (w *responseWriter) Status() int {
+	if rw, ok := w.responded(); ok {
+		return rw.Status()
+	}
+	return w.status
+}
+
+// Size returns the number of body bytes written.
+func (w *responseWriter) Size() int {
+	if rw, ok := w.responded(); ok {
+		return rw.Size()
+	}
+	return w.size
+}
+
+// Written returns whether the status code has been sent.
+func (w *responseWriter) Written() bool {
+	return w.Size() != -1
+}
+
+// responded returns the replacement writer if it has sent a response of its own while nothing
+// was written through this writer, e.g. the timeout response of the timeout middleware.
+func (w *responseWriter) responded() (core.ResponseWriter, bool) {
+	if w.size != -1 {
+		return nil, false
+	}
+	rw, ok := w.writer.(core.ResponseWriter)
+	return rw, ok && rw.Written()
+}
+
+// Flush flushes the replacement writer if it supports flushing.
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	_ = http.NewResponseController(w.writer).Flush()
+}
@@ -3,23 +3,33 @@ package gin
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"iter"
 	"log"
+	"mime/multipart"
 	"net/http"
-	"reflect"
-	"runtime"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/awslabs/aws-lambda-go-api-proxy/gin"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/bench"
+	"github.com/mythofleader/go-http-server/core/dynamic"
+	"github.com/mythofleader/go-http-server/core/middleware"
 	"github.com/mythofleader/go-http-server/core/middleware/errors"
 )
 
 // Context is an implementation of core.Context using the Gin framework.
 type Context struct {
 	ginContext *gin.Context
+	server     *Server
 }
 
 // Request implements core.Context.Request
@@ -27,16 +37,40 @@ func (c *Context) Request() *http.Request {
 	return c.ginContext.Request
 }
 
+// SetRequest implements core.Context.SetRequest
+func (c *Context) SetRequest(r *http.Request) {
+	c.ginContext.Request = r
+}
+
 // Writer implements core.Context.Writer
-func (c *Context) Writer() http.ResponseWriter {
+func (c *Context) Writer() core.ResponseWriter {
 	return c.ginContext.Writer
 }
 
+// SetWriter implements core.Context.SetWriter
+func (c *Context) SetWriter(w http.ResponseWriter) {
+	if gw, ok := w.(gin.ResponseWriter); ok {
+		c.ginContext.Writer = gw
+		return
+	}
+	c.ginContext.Writer = newResponseWriter(c.ginContext.Writer, w)
+}
+
 // Param implements core.Context.Param
 func (c *Context) Param(key string) string {
 	return c.ginContext.Param(key)
 }
 
+// ParamInt implements core.Context.ParamInt
+func (c *Context) ParamInt(key string) (int, error) {
+	return core.ParseParamInt(c, key)
+}
+
+// ParamUUID implements core.Context.ParamUUID
+func (c *Context) ParamUUID(key string) (string, error) {
+	return core.ParseParamUUID(c, key)
+}
+
 // Query implements core.Context.Query
 func (c *Context) Query(key string) string {
 	return c.ginContext.Query(key)
@@ -47,16 +81,51 @@ func (c *Context) DefaultQuery(key, defaultValue string) string {
 	return c.ginContext.DefaultQuery(key, defaultValue)
 }
 
+// GetQuery implements core.Context.GetQuery
+func (c *Context) GetQuery(key string) (string, bool) {
+	return c.ginContext.GetQuery(key)
+}
+
+// QueryArray implements core.Context.QueryArray
+func (c *Context) QueryArray(key string) []string {
+	return c.ginContext.QueryArray(key)
+}
+
+// QueryMap implements core.Context.QueryMap
+func (c *Context) QueryMap(prefix string) map[string]string {
+	return c.ginContext.QueryMap(prefix)
+}
+
+// PostForm implements core.Context.PostForm
+func (c *Context) PostForm(key string) string {
+	return c.ginContext.PostForm(key)
+}
+
+// DefaultPostForm implements core.Context.DefaultPostForm
+func (c *Context) DefaultPostForm(key, defaultValue string) string {
+	return c.ginContext.DefaultPostForm(key, defaultValue)
+}
+
 // GetHeader implements core.Context.GetHeader
 func (c *Context) GetHeader(key string) string {
 	return c.ginContext.GetHeader(key)
 }
 
+// GetHeaders implements core.Context.GetHeaders
+func (c *Context) GetHeaders(key string) []string {
+	return c.ginContext.Request.Header.Values(key)
+}
+
 // SetHeader implements core.Context.SetHeader
 func (c *Context) SetHeader(key, value string) {
 	c.ginContext.Header(key, value)
 }
 
+// AddHeader implements core.Context.AddHeader
+func (c *Context) AddHeader(key, value string) {
+	c.ginContext.Writer.Header().Add(key, value)
+}
+
 // SetStatus implements core.Context.SetStatus
 func (c *Context) SetStatus(code int) {
 	c.ginContext.Status(code)
@@ -64,7 +133,21 @@ func (c *Context) SetStatus(code int) {
 
 // JSON implements core.Context.JSON
 func (c *Context) JSON(code int, obj interface{}) {
-	c.ginContext.JSON(code, obj)
+	// Skip encoding altogether if the client is already gone
+	ctx := c.ginContext.Request.Context()
+	if core.CheckAborted(ctx) != nil {
+		return
+	}
+
+	data, err := core.MarshalJSON(obj)
+	if err != nil {
+		// Let gin report the encoding error as it always has
+		c.ginContext.JSON(code, obj)
+		return
+	}
+	c.ginContext.Header("Content-Type", "application/json; charset=utf-8")
+	c.ginContext.Status(code)
+	_ = core.WriteResponseBody(ctx, c.ginContext.Writer, data)
 }
 
 // String implements core.Context.String
@@ -72,26 +155,162 @@ func (c *Context) String(code int, format string, values ...interface{}) {
 	c.ginContext.String(code, format, values...)
 }
 
+// HTML implements core.Context.HTML
+func (c *Context) HTML(code int, name string, data interface{}) {
+	if err := core.WriteHTML(c.ginContext.Request.Context(), c.ginContext.Writer, code, name, data); err != nil {
+		_ = c.Error(err)
+	}
+}
+
 // Bind implements core.Context.Bind
+// Like Gin's Bind, it aborts the request with 400 Bad Request if binding fails. The bound value
+// is checked with core.Validate, and failed binding tags are reported as a *errors.ValidationError.
+// The binding is selected with core.BindingMediaType, so Content-Type parameters and structured
+// syntax suffixes such as "+json" are understood; unsupported content types abort the request
+// with 415 Unsupported Media Type.
 func (c *Context) Bind(obj interface{}) error {
-	return c.ginContext.Bind(obj)
+	err := c.bind(obj)
+	if err == nil {
+		err = core.Validate(obj)
+	} else if validationErr, ok := errors.AsValidationError(err); ok {
+		err = validationErr
+	}
+	if err != nil {
+		c.abortBind(err)
+		return err
+	}
+	return nil
+}
+
+// bind decodes the request into obj with the Gin binding selected by method and Content-Type.
+func (c *Context) bind(obj interface{}) error {
+	if c.ginContext.Request.Method == http.MethodGet {
+		return c.ginContext.ShouldBindWith(obj, binding.Form)
+	}
+
+	mediaType, err := core.BindingMediaType(c.ginContext.GetHeader("Content-Type"), c.strictContentType())
+	if err != nil {
+		return err
+	}
+	switch mediaType {
+	case core.MIMEJSON:
+		return c.ginContext.ShouldBindWith(obj, binding.JSON)
+	case core.MIMEXML:
+		return c.ginContext.ShouldBindWith(obj, binding.XML)
+	case core.MIMEMultipartForm:
+		return c.ginContext.ShouldBindWith(obj, binding.FormMultipart)
+	default:
+		// Other content types keep Gin's bindings, such as YAML, TOML and protobuf
+		return c.ginContext.ShouldBind(obj)
+	}
+}
+
+// abortBind aborts the request with the status of a binding error: the status of HTTPErrors,
+// 400 Bad Request for other errors.
+func (c *Context) abortBind(err error) {
+	status := http.StatusBadRequest
+	if httpErr, ok := err.(errors.HTTPError); ok {
+		status = httpErr.StatusCode()
+	}
+	_ = c.ginContext.AbortWithError(status, err).SetType(gin.ErrorTypeBind)
+}
+
+// strictContentType returns whether Bind and BindJSON reject unexpected content types.
+func (c *Context) strictContentType() bool {
+	return c.server != nil && c.server.strictContentType
 }
 
 // BindJSON implements core.Context.BindJSON
+// Like Gin's BindJSON, it aborts the request with 400 Bad Request if binding fails.
 func (c *Context) BindJSON(obj interface{}) error {
-	return c.ginContext.BindJSON(obj)
+	if err := c.ShouldBindJSON(obj); err != nil {
+		c.abortBind(err)
+		return err
+	}
+	return nil
 }
 
 // ShouldBindJSON implements core.Context.ShouldBindJSON
+// The Content-Type is checked with core.CheckJSONContentType, and the decoded value with
+// core.Validate. Failed binding tags are reported as a *errors.ValidationError as well.
 func (c *Context) ShouldBindJSON(obj interface{}) error {
-	return c.ginContext.ShouldBindJSON(obj)
+	if err := core.CheckJSONContentType(c.ginContext.GetHeader("Content-Type"), c.strictContentType()); err != nil {
+		return err
+	}
+	if err := c.ginContext.ShouldBindJSON(obj); err != nil {
+		if validationErr, ok := errors.AsValidationError(err); ok {
+			return validationErr
+		}
+		return err
+	}
+	return core.Validate(obj)
+}
+
+// JSONStream implements core.Context.JSONStream
+func (c *Context) JSONStream(code int, seq iter.Seq[interface{}]) error {
+	return core.WriteJSONStream(c.ginContext.Request.Context(), c.ginContext.Writer, code, seq)
 }
 
-// File implements core.Context.File
+// NDJSON implements core.Context.NDJSON
+func (c *Context) NDJSON(code int, seq iter.Seq[interface{}]) error {
+	return core.WriteNDJSON(c.ginContext.Request.Context(), c.ginContext.Writer, code, seq)
+}
+
+// CSV implements core.Context.CSV
+func (c *Context) CSV(code int, headers []string, rows iter.Seq[[]string]) error {
+	return core.WriteCSV(c.ginContext.Request.Context(), c.ginContext.Writer, code, headers, rows)
+}
+
+// Stream implements core.Context.Stream
+func (c *Context) Stream(step func(w io.Writer) bool) error {
+	return core.WriteStream(c.ginContext.Request.Context(), c.ginContext.Writer, step)
+}
+
+// DataFromReader implements core.Context.DataFromReader
+func (c *Context) DataFromReader(code int, contentLength int64, contentType string, reader io.Reader) error {
+	return middleware.DataFromReader(c, code, contentLength, contentType, reader)
+}
+
+// BindJSONStream implements core.Context.BindJSONStream
+func (c *Context) BindJSONStream(fn func(element json.RawMessage) error) error {
+	return core.DecodeJSONStream(c.ginContext.Request.Body, fn)
+}
+
+// GetRawData implements core.Context.GetRawData
+func (c *Context) GetRawData() ([]byte, error) {
+	return core.ReadRawData(c)
+}
+
+// File implements core.Context.File, adding the digest headers of the response digest middleware
 func (c *Context) File(filepath string) {
+	middleware.SetFileDigestHeaders(c, filepath)
 	c.ginContext.File(filepath)
 }
 
+// multipartMemory returns the memory used to parse multipart forms, as set by core.MultipartMemory
+// for the request or by the engine options.
+func (c *Context) multipartMemory() int64 {
+	if c.server == nil {
+		return core.MultipartMemoryFromContext(c.ginContext.Request.Context(), core.DefaultMaxMultipartMemory)
+	}
+	return core.MultipartMemoryFromContext(c.ginContext.Request.Context(), c.server.engine.MaxMultipartMemory)
+}
+
+// FormFile implements core.Context.FormFile
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	return core.FormFile(c.ginContext.Request, c.multipartMemory(), name)
+}
+
+// MultipartForm implements core.Context.MultipartForm
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	return core.MultipartForm(c.ginContext.Request, c.multipartMemory())
+}
+
+// SaveUploadedFile implements core.Context.SaveUploadedFile
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	return core.SaveUploadedFile(file, dst)
+}
+
 // Redirect implements core.Context.Redirect
 func (c *Context) Redirect(code int, location string) {
 	c.ginContext.Redirect(code, location)
@@ -125,6 +344,15 @@ func (c *Context) Abort() {
 	c.ginContext.Abort()
 }
 
+// ServeRoute implements core.RouteServer.ServeRoute
+// Gin's handler chain cannot be extended, so the handlers of the route run as a chain of their own.
+func (c *Context) ServeRoute(params map[string]string, handlers []core.HandlerFunc) {
+	for key, value := range params {
+		c.ginContext.Params = append(c.ginContext.Params, gin.Param{Key: key, Value: value})
+	}
+	core.RunHandlers(c, handlers)
+}
+
 // Get implements core.Context.Get
 func (c *Context) Get(key string) (interface{}, bool) {
 	value, exists := c.ginContext.Get(key)
@@ -136,13 +364,57 @@ func (c *Context) Set(key string, value interface{}) {
 	c.ginContext.Set(key, value)
 }
 
+// DetachedContext implements core.Context.DetachedContext
+func (c *Context) DetachedContext() context.Context {
+	// Copy takes a consistent snapshot of the keys
+	return core.NewDetachedContext(c.ginContext.Request.Context(), c.ginContext.Copy().Keys)
+}
+
+// CSPNonce implements core.Context.CSPNonce
+func (c *Context) CSPNonce() string {
+	return core.CSPNonce(c)
+}
+
+// ClientIP implements core.Context.ClientIP
+func (c *Context) ClientIP() string {
+	if c.server == nil {
+		return core.ResolveClientIP(c.ginContext.Request, nil, "")
+	}
+	return core.ResolveClientIP(c.ginContext.Request, c.server.trustedProxies, c.server.engine.TrustedPlatform)
+}
+
+// StartTime implements core.Context.StartTime
+func (c *Context) StartTime() time.Time {
+	return core.RequestStartTime(c)
+}
+
+// Elapsed implements core.Context.Elapsed
+func (c *Context) Elapsed() time.Duration {
+	return core.RequestElapsed(c)
+}
+
 // Server is an implementation of core.Server using the Gin framework.
 type Server struct {
 	engine      *gin.Engine
 	server      *http.Server
+	serverMu    sync.Mutex // Guards server and closed
+	closed      bool       // Set by Stop and Shutdown; Run and RunTLS then return http.ErrServerClosed
 	port        string
-	middlewares []string // Track middleware names
-	showLogs    bool     // Controls whether framework logs are shown
+	middlewares []core.NamedHandler // Track middleware for logging
+	chain       core.MiddlewareLog  // Describes the middleware of the server and its groups
+	showLogs    bool                // Controls whether framework logs are shown
+	frozen      atomic.Bool         // Set once the route table is sealed
+
+	noRouteHandlers []core.HandlerFunc     // Handlers for 404 Not Found errors
+	dynamic         core.DynamicRouter     // Dynamic router, nil unless dynamic routing is enabled
+	lifecycle       core.Lifecycle         // Start and stop hooks
+	httpConfig      *core.HTTPServerConfig // Settings of the http.Server, nil for the defaults
+	fastPath        *core.FastPathConfig   // Health check paths answered before the middleware chain
+	trustedProxies  *core.TrustedProxies   // Proxies whose forwarding headers are trusted, nil for none
+	drainer         core.RouteDrainer      // In-flight requests of each route, for DisableRoute
+
+	strictContentType bool              // Set by EngineOptions.StrictContentType
+	lambdaConfig      core.LambdaConfig // Event type of StartLambda
 }
 
 // GetLoggingMiddleware returns a Gin-specific logging middleware.
@@ -157,76 +429,88 @@ func (s *Server) GetErrorHandlerMiddleware() core.IErrorHandlerMiddleware {
 
 // RouterGroup is an implementation of core.RouterGroup using the Gin framework.
 type RouterGroup struct {
-	group *gin.RouterGroup
+	group  *gin.RouterGroup
+	server *Server
 }
 
 // GET implements core.Server.GET
 func (s *Server) GET(path string, handlers ...core.HandlerFunc) {
-	ginHandlers := make([]gin.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		ginHandlers[i] = wrapHandler(handler)
-	}
-	s.engine.GET(path, ginHandlers...)
+	s.checkNotFrozen("GET " + path)
+	s.engine.GET(path, s.routeHandlers("GET", path, handlers)...)
 }
 
 // POST implements core.Server.POST
 func (s *Server) POST(path string, handlers ...core.HandlerFunc) {
-	ginHandlers := make([]gin.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		ginHandlers[i] = wrapHandler(handler)
-	}
-	s.engine.POST(path, ginHandlers...)
+	s.checkNotFrozen("POST " + path)
+	s.engine.POST(path, s.routeHandlers("POST", path, handlers)...)
 }
 
 // PUT implements core.Server.PUT
 func (s *Server) PUT(path string, handlers ...core.HandlerFunc) {
-	ginHandlers := make([]gin.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		ginHandlers[i] = wrapHandler(handler)
-	}
-	s.engine.PUT(path, ginHandlers...)
+	s.checkNotFrozen("PUT " + path)
+	s.engine.PUT(path, s.routeHandlers("PUT", path, handlers)...)
 }
 
 // DELETE implements core.Server.DELETE
 func (s *Server) DELETE(path string, handlers ...core.HandlerFunc) {
-	ginHandlers := make([]gin.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		ginHandlers[i] = wrapHandler(handler)
-	}
-	s.engine.DELETE(path, ginHandlers...)
+	s.checkNotFrozen("DELETE " + path)
+	s.engine.DELETE(path, s.routeHandlers("DELETE", path, handlers)...)
 }
 
 // PATCH implements core.Server.PATCH
 func (s *Server) PATCH(path string, handlers ...core.HandlerFunc) {
-	ginHandlers := make([]gin.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		ginHandlers[i] = wrapHandler(handler)
+	s.checkNotFrozen("PATCH " + path)
+	s.engine.PATCH(path, s.routeHandlers("PATCH", path, handlers)...)
+}
+
+// DisableRoute implements core.Server.DisableRoute
+func (s *Server) DisableRoute(method core.HttpMethod, path string, status ...int) (*core.RouteDrain, error) {
+	code := 0
+	if len(status) > 0 {
+		code = status[0]
 	}
-	s.engine.PATCH(path, ginHandlers...)
+	return s.drainer.Disable(string(method), path, code)
 }
 
 // Group implements core.Server.Group
 func (s *Server) Group(path string) core.RouterGroup {
 	return &RouterGroup{
-		group: s.engine.Group(path),
+		group:  s.engine.Group(path),
+		server: s,
 	}
 }
 
 // Use implements core.Server.Use
 func (s *Server) Use(middleware ...core.HandlerFunc) {
 	for _, m := range middleware {
-		// Get the function name for logging
-		funcValue := reflect.ValueOf(m)
-		middlewareName := runtime.FuncForPC(funcValue.Pointer()).Name()
-		s.middlewares = append(s.middlewares, middlewareName)
+		s.UseNamed("", m)
+	}
+}
 
-		// Log middleware addition if showLogs is true
-		if s.showLogs {
-			log.Printf("[GIN] Adding middleware: %s", middlewareName)
-		}
+// UseNamed implements core.Server.UseNamed
+// If name is empty, the function name is resolved only when framework logs are shown.
+func (s *Server) UseNamed(name string, middleware core.HandlerFunc) {
+	s.UseDescribed(core.MiddlewareDescription{Name: name}, middleware)
+}
 
-		s.engine.Use(wrapHandler(m))
+// UseDescribed implements core.Server.UseDescribed
+func (s *Server) UseDescribed(description core.MiddlewareDescription, middleware core.HandlerFunc) {
+	s.checkNotFrozen("middleware")
+	named := core.NamedHandler{Name: description.Name, Handler: middleware}
+	s.middlewares = append(s.middlewares, named)
+	s.chain.Add(description, middleware)
+
+	// Log middleware addition if showLogs is true
+	if s.showLogs {
+		log.Printf("[GIN] Adding middleware: %s", named.DisplayName())
 	}
+
+	s.engine.Use(s.wrapHandler(middleware))
+}
+
+// DescribeMiddleware implements core.Server.DescribeMiddleware
+func (s *Server) DescribeMiddleware() core.MiddlewareChain {
+	return s.chain.Chain()
 }
 
 // RegisterRouter implements core.Server.RegisterRouter
@@ -235,7 +519,7 @@ func (s *Server) RegisterRouter(controllers ...core.Controller) {
 		// Get HTTP method, path, and handlers from the controller
 		method := controller.GetHttpMethod()
 		path := controller.GetPath()
-		handlers := controller.Handler()
+		handlers := core.ControllerHandlers(controller)
 
 		// Register the route based on the HTTP method
 		switch method {
@@ -261,6 +545,7 @@ func (s *Server) RegisterRouter(controllers ...core.Controller) {
 
 // NoRoute implements core.Server.NoRoute
 func (s *Server) NoRoute(handlers ...core.HandlerFunc) {
+	s.checkNotFrozen("NoRoute handlers")
 	// If no handlers are provided, use default handler
 	if len(handlers) == 0 {
 		// Default handler returns a 404 Not Found error
@@ -276,18 +561,51 @@ func (s *Server) NoRoute(handlers ...core.HandlerFunc) {
 		}
 	}
 
+	s.noRouteHandlers = handlers
+	s.installNoRoute()
+	if s.showLogs {
+		log.Printf("[GIN] Registered NoRoute handler")
+	}
+}
+
+// installNoRoute registers the NoRoute handlers with the engine.
+// If dynamic routing is enabled, dynamic routes are tried before the NoRoute handlers.
+func (s *Server) installNoRoute() {
+	handlers := s.noRouteHandlers
+	if s.dynamic != nil {
+		handlers = append([]core.HandlerFunc{s.serveDynamic}, handlers...)
+	}
+
 	ginHandlers := make([]gin.HandlerFunc, len(handlers))
 	for i, handler := range handlers {
-		ginHandlers[i] = wrapHandler(handler)
+		ginHandlers[i] = s.wrapHandler(handler)
 	}
 	s.engine.NoRoute(ginHandlers...)
-	if s.showLogs {
-		log.Printf("[GIN] Registered NoRoute handler")
+}
+
+// serveDynamic serves the request with a dynamic route and skips the NoRoute handlers if one matched.
+func (s *Server) serveDynamic(c core.Context) {
+	if s.dynamic.Serve(c) {
+		c.Abort()
 	}
 }
 
+// Dynamic implements core.Server.Dynamic
+func (s *Server) Dynamic() core.DynamicRouter {
+	if s.dynamic == nil {
+		s.checkNotFrozen("dynamic router")
+		s.dynamic = dynamic.NewRouter()
+		s.installNoRoute()
+		if s.showLogs {
+			log.Printf("[GIN] Dynamic routing enabled")
+		}
+	}
+	return s.dynamic
+}
+
 // NoMethod implements core.Server.NoMethod
 func (s *Server) NoMethod(handlers ...core.HandlerFunc) {
+	s.checkNotFrozen("NoMethod handlers")
 	// If no handlers are provided, use default handler
 	if len(handlers) == 0 {
 		// Default handler returns a 405 Method Not Allowed error
@@ -306,7 +624,7 @@ func (s *Server) NoMethod(handlers ...core.HandlerFunc) {
 
 	ginHandlers := make([]gin.HandlerFunc, len(handlers))
 	for i, handler := range handlers {
-		ginHandlers[i] = wrapHandler(handler)
+		ginHandlers[i] = s.wrapHandler(handler)
 	}
 	s.engine.NoMethod(ginHandlers...)
 	if s.showLogs {
@@ -316,6 +634,11 @@ func (s *Server) NoMethod(handlers ...core.HandlerFunc) {
 
 // Run implements core.Server.Run
 func (s *Server) Run() error {
+	s.Freeze()
+	if err := s.lifecycle.Start(context.Background()); err != nil {
+		return err
+	}
+
 	addr := ":" + s.port
 
 	// Log server information if showLogs is true
@@ -327,17 +650,14 @@ func (s *Server) Run() error {
 		if len(s.middlewares) > 0 {
 			log.Println("[GIN] Middleware registered:")
 			for i, middleware := range s.middlewares {
-				log.Printf("[GIN]   %d. %s", i+1, middleware)
+				log.Printf("[GIN]   %d. %s", i+1, middleware.DisplayName())
 			}
 		} else {
 			log.Println("[GIN] No middleware registered")
 		}
 	}
 
-	s.server = &http.Server{
-		Addr:    addr,
-		Handler: s.engine,
-	}
+	s.server = core.NewHTTPServer(addr, s.Handler(), s.httpConfig)
 
 	// Log routes information if showLogs is true
 	if s.showLogs {
@@ -354,32 +674,70 @@ func (s *Server) Run() error {
 		log.Printf("[GIN] Server is ready to handle requests")
 	}
 
-	return s.engine.Run(addr)
+	return s.server.ListenAndServe()
 }
 
 // RunTLS implements core.Server.RunTLS
 func (s *Server) RunTLS(addr, certFile, keyFile string) error {
-	s.server = &http.Server{
-		Addr:    addr,
-		Handler: s.engine,
+	s.Freeze()
+	if err := s.lifecycle.Start(context.Background()); err != nil {
+		return err
+	}
+
+	srv, err := s.newHTTPServer(addr)
+	if err != nil {
+		return err
 	}
-	return s.server.ListenAndServeTLS(certFile, keyFile)
+	return srv.ListenAndServeTLS(certFile, keyFile)
+}
+
+// newHTTPServer creates the http.Server of Run and RunTLS. It returns http.ErrServerClosed
+// if the server has been stopped before it started, e.g. by a shutdown signal during start hooks.
+func (s *Server) newHTTPServer(addr string) (*http.Server, error) {
+	s.serverMu.Lock()
+	defer s.serverMu.Unlock()
+	if s.closed {
+		return nil, http.ErrServerClosed
+	}
+	s.server = core.NewHTTPServer(addr, s.Handler(), s.httpConfig)
+	return s.server, nil
+}
+
+// closeHTTPServer marks the server as stopped and returns its http.Server, nil if it has not started.
+func (s *Server) closeHTTPServer() *http.Server {
+	s.serverMu.Lock()
+	defer s.serverMu.Unlock()
+	s.closed = true
+	return s.server
 }
 
 // Stop implements core.Server.Stop
 func (s *Server) Stop() error {
-	if s.server == nil {
-		return nil
+	var err error
+	if srv := s.closeHTTPServer(); srv != nil {
+		err = srv.Close()
 	}
-	return s.server.Close()
+	if hookErr := s.lifecycle.Stop(context.Background()); err == nil {
+		err = hookErr
+	}
+	return err
+}
+
+// RunWithGracefulShutdown implements core.Server.RunWithGracefulShutdown
+func (s *Server) RunWithGracefulShutdown(ctx context.Context, timeout time.Duration) error {
+	return core.RunWithGracefulShutdown(ctx, timeout, s.Run, s.Shutdown)
 }
 
 // Shutdown implements core.Server.Shutdown
 func (s *Server) Shutdown(ctx context.Context) error {
-	if s.server == nil {
-		return nil
+	var err error
+	if srv := s.closeHTTPServer(); srv != nil {
+		err = srv.Shutdown(ctx)
+	}
+	if hookErr := s.lifecycle.Stop(ctx); err == nil {
+		err = hookErr
 	}
-	return s.server.Shutdown(ctx)
+	return err
 }
 
 // GetPort implements core.Server.GetPort
@@ -387,9 +745,97 @@ func (s *Server) GetPort() string {
 	return s.port
 }
 
+// Freeze implements core.Server.Freeze
+func (s *Server) Freeze() {
+	s.frozen.Store(true)
+}
+
+// Frozen implements core.Server.Frozen
+func (s *Server) Frozen() bool {
+	return s.frozen.Load()
+}
+
+// OnStart implements core.Server.OnStart
+func (s *Server) OnStart(hook core.LifecycleHook) {
+	s.lifecycle.OnStart(hook)
+}
+
+// OnStop implements core.Server.OnStop
+func (s *Server) OnStop(hook core.LifecycleHook) {
+	s.lifecycle.OnStop(hook)
+}
+
+// SetHTTPServerConfig implements core.Server.SetHTTPServerConfig
+func (s *Server) SetHTTPServerConfig(config *core.HTTPServerConfig) {
+	s.httpConfig = config
+}
+
+// SetFastPath implements core.Server.SetFastPath
+func (s *Server) SetFastPath(config *core.FastPathConfig) {
+	s.fastPath = config
+}
+
+// SetEngineOptions implements core.Server.SetEngineOptions
+func (s *Server) SetEngineOptions(options *core.EngineOptions) {
+	if options == nil {
+		options = &core.EngineOptions{}
+	}
+	s.engine.TrustedPlatform = options.TrustedPlatform
+	s.engine.MaxMultipartMemory = core.DefaultMaxMultipartMemory
+	if options.MaxMultipartMemory > 0 {
+		s.engine.MaxMultipartMemory = options.MaxMultipartMemory
+	}
+	s.engine.RemoveExtraSlash = options.RemoveExtraSlash
+	s.engine.UseRawPath = options.UseRawPath
+	s.strictContentType = options.StrictContentType
+}
+
+// SetTrustedProxies implements core.Server.SetTrustedProxies
+// The proxies are also set on the Gin engine, for handlers using the native gin.Context.ClientIP.
+func (s *Server) SetTrustedProxies(proxies []string) error {
+	trusted, err := core.ParseTrustedProxies(proxies...)
+	if err != nil {
+		return err
+	}
+	if err := s.engine.SetTrustedProxies(proxies); err != nil {
+		return err
+	}
+	s.trustedProxies = trusted
+	return nil
+}
+
+// checkNotFrozen panics with an error wrapping core.ErrServerFrozen if the server has been frozen.
+func (s *Server) checkNotFrozen(what string) {
+	if s.frozen.Load() {
+		panic(fmt.Errorf("%w: cannot register %s", core.ErrServerFrozen, what))
+	}
+}
+
+// Handler implements core.Server.Handler
+func (s *Server) Handler() http.Handler {
+	return core.FastPathHandler(s.engine, s.fastPath)
+}
+
+// SelfBench implements core.Server.SelfBench
+func (s *Server) SelfBench(routes []bench.Route, concurrency int, duration time.Duration) (*bench.Report, error) {
+	return bench.Run(s.Handler(), routes, concurrency, duration)
+}
+
+// Unwrap implements core.Server.Unwrap, returning the *gin.Engine of the server
+func (s *Server) Unwrap() interface{} {
+	return s.engine
+}
+
+// Engine returns the gin engine of the server, e.g. to set trusted proxies or HTML templates.
+// Settings changed by SetEngineOptions are overwritten when it is called.
+func (s *Server) Engine() *gin.Engine {
+	return s.engine
+}
+
 // StartLambda starts the server in AWS Lambda mode.
 // This method should be called instead of Run or RunTLS when running in AWS Lambda.
-// This method uses the ginadapter library to convert the Gin engine to a Lambda handler.
+// ALB, API Gateway and function URL events are converted to requests of Handler, so they go
+// through the same fast path as requests of Run; see SetLambdaConfig.
 //
 // Example usage:
 //
@@ -405,75 +851,70 @@ func (s *Server) GetPort() string {
 //	    }
 //	}
 func (s *Server) StartLambda() error {
-	// Create a new ALB adapter for the Gin engine
-	ginLambda := ginadapter.NewALB(s.engine)
+	s.Freeze()
+	if err := s.lifecycle.Start(context.Background()); err != nil {
+		return err
+	}
 
 	// Start the Lambda handler
-	lambda.Start(func(ctx context.Context, req events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
-		// Process the request
-		return ginLambda.ProxyWithContext(ctx, req)
-	})
+	lambda.Start(core.NewLambdaHandler(s.Handler(), s.lambdaConfig.EventType))
 
 	// This line is never reached because lambda.Start() doesn't return
 	return nil
 }
 
+// SetLambdaConfig implements core.Server.SetLambdaConfig
+func (s *Server) SetLambdaConfig(config *core.LambdaConfig) {
+	if config == nil {
+		config = &core.LambdaConfig{}
+	}
+	s.lambdaConfig = *config
+}
+
 // GET implements core.RouterGroup.GET
 func (g *RouterGroup) GET(path string, handlers ...core.HandlerFunc) {
-	ginHandlers := make([]gin.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		ginHandlers[i] = wrapHandler(handler)
-	}
-	g.group.GET(path, ginHandlers...)
+	g.server.checkNotFrozen("GET " + g.group.BasePath() + path)
+	g.group.GET(path, g.server.routeHandlers("GET", joinPaths(g.group.BasePath(), path), handlers)...)
 }
 
 // POST implements core.RouterGroup.POST
 func (g *RouterGroup) POST(path string, handlers ...core.HandlerFunc) {
-	ginHandlers := make([]gin.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		ginHandlers[i] = wrapHandler(handler)
-	}
-	g.group.POST(path, ginHandlers...)
+	g.server.checkNotFrozen("POST " + g.group.BasePath() + path)
+	g.group.POST(path, g.server.routeHandlers("POST", joinPaths(g.group.BasePath(), path), handlers)...)
 }
 
 // PUT implements core.RouterGroup.PUT
 func (g *RouterGroup) PUT(path string, handlers ...core.HandlerFunc) {
-	ginHandlers := make([]gin.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		ginHandlers[i] = wrapHandler(handler)
-	}
-	g.group.PUT(path, ginHandlers...)
+	g.server.checkNotFrozen("PUT " + g.group.BasePath() + path)
+	g.group.PUT(path, g.server.routeHandlers("PUT", joinPaths(g.group.BasePath(), path), handlers)...)
 }
 
 // DELETE implements core.RouterGroup.DELETE
 func (g *RouterGroup) DELETE(path string, handlers ...core.HandlerFunc) {
-	ginHandlers := make([]gin.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		ginHandlers[i] = wrapHandler(handler)
-	}
-	g.group.DELETE(path, ginHandlers...)
+	g.server.checkNotFrozen("DELETE " + g.group.BasePath() + path)
+	g.group.DELETE(path, g.server.routeHandlers("DELETE", joinPaths(g.group.BasePath(), path), handlers)...)
 }
 
 // PATCH implements core.RouterGroup.PATCH
 func (g *RouterGroup) PATCH(path string, handlers ...core.HandlerFunc) {
-	ginHandlers := make([]gin.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		ginHandlers[i] = wrapHandler(handler)
-	}
-	g.group.PATCH(path, ginHandlers...)
+	g.server.checkNotFrozen("PATCH " + g.group.BasePath() + path)
+	g.group.PATCH(path, g.server.routeHandlers("PATCH", joinPaths(g.group.BasePath(), path), handlers)...)
 }
 
 // Group implements core.RouterGroup.Group
 func (g *RouterGroup) Group(path string) core.RouterGroup {
 	return &RouterGroup{
-		group: g.group.Group(path),
+		group:  g.group.Group(path),
+		server: g.server,
 	}
 }
 
 // Use implements core.RouterGroup.Use
 func (g *RouterGroup) Use(middleware ...core.HandlerFunc) {
+	g.server.checkNotFrozen("group middleware")
 	for _, m := range middleware {
-		g.group.Use(wrapHandler(m))
+		g.server.chain.Add(core.MiddlewareDescription{AppliesTo: g.group.BasePath() + "/*"}, m)
+		g.group.Use(g.server.wrapHandler(m))
 	}
 }
 
@@ -483,7 +924,7 @@ func (g *RouterGroup) RegisterRouter(controllers ...core.Controller) {
 		// Get HTTP method, path, and handlers from the controller
 		method := controller.GetHttpMethod()
 		path := controller.GetPath()
-		handlers := controller.Handler()
+		handlers := core.ControllerHandlers(controller)
 
 		// Register the route based on the HTTP method
 		switch method {
@@ -505,10 +946,33 @@ func (g *RouterGroup) RegisterRouter(controllers ...core.Controller) {
 	}
 }
 
+// routeHandlers returns the handlers of the route registered for method and path, preceded by the
+// guard counting its in-flight requests for DisableRoute.
+func (s *Server) routeHandlers(method, path string, handlers []core.HandlerFunc) []gin.HandlerFunc {
+	ginHandlers := make([]gin.HandlerFunc, 0, len(handlers)+1)
+	ginHandlers = append(ginHandlers, s.wrapHandler(s.drainer.Guard(method, path)))
+	for _, handler := range handlers {
+		ginHandlers = append(ginHandlers, s.wrapHandler(handler))
+	}
+	return ginHandlers
+}
+
+// joinPaths joins the base path of a group and a relative route path as Gin does.
+func joinPaths(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+	joined := path.Join(base, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(joined, "/") {
+		return joined + "/"
+	}
+	return joined
+}
+
 // wrapHandler wraps a core.HandlerFunc to a gin.HandlerFunc
-func wrapHandler(handler core.HandlerFunc) gin.HandlerFunc {
+func (s *Server) wrapHandler(handler core.HandlerFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		handler(&Context{ginContext: c})
+		handler(&Context{ginContext: c, server: s})
 	}
 }
 
@@ -526,7 +990,7 @@ func NewServer(port string, showLogs bool) *Server {
 	return &Server{
 		engine:      gin.New(),
 		port:        port,
-		middlewares: make([]string, 0),
+		middlewares: make([]core.NamedHandler, 0),
 		showLogs:    showLogs,
 	}
 }
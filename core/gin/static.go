@@ -0,0 +1,43 @@
+package gin
+
+import (
+	"io/fs"
+	"os"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// Static implements core.Server.Static
+func (s *Server) Static(prefix, dir string) {
+	s.StaticFS(prefix, os.DirFS(dir))
+}
+
+// StaticFS implements core.Server.StaticFS
+// Missing files are answered by the NoRoute handlers, as for unknown routes.
+func (s *Server) StaticFS(prefix string, fsys fs.FS) {
+	s.checkNotFrozen("static files " + prefix)
+	s.engine.StaticFS(prefix, core.NewStaticFileSystem(fsys))
+}
+
+// StaticFile implements core.Server.StaticFile
+func (s *Server) StaticFile(path, file string) {
+	s.checkNotFrozen("static file " + path)
+	s.engine.StaticFile(path, file)
+}
+
+// Static implements core.RouterGroup.Static
+func (g *RouterGroup) Static(prefix, dir string) {
+	g.StaticFS(prefix, os.DirFS(dir))
+}
+
+// StaticFS implements core.RouterGroup.StaticFS
+func (g *RouterGroup) StaticFS(prefix string, fsys fs.FS) {
+	g.server.checkNotFrozen("static files " + g.group.BasePath() + prefix)
+	g.group.StaticFS(prefix, core.NewStaticFileSystem(fsys))
+}
+
+// StaticFile implements core.RouterGroup.StaticFile
+func (g *RouterGroup) StaticFile(path, file string) {
+	g.server.checkNotFrozen("static file " + g.group.BasePath() + path)
+	g.group.StaticFile(path, file)
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// ErrResponseSent is returned by writers that reject writes because the response has already been
+// sent, e.g. by a handler goroutine still writing after the timeout middleware responded.
+var ErrResponseSent = errors.New("response already sent")
+
+// GuardedWriter is an http.ResponseWriter that serializes writes and rejects late ones.
+// Once closed, or once another writer has responded through Respond, writes fail with
+// ErrResponseSent and are logged as a warning instead of corrupting the response, and
+// superfluous WriteHeader calls are ignored with a warning.
+//
+// Example usage:
+//
+//	original := c.Writer()
+//	guard := core.NewGuardedWriter(original)
+//	c.SetWriter(guard)
+//	c.Next()
+//	guard.Close() // Writes from goroutines the handler left behind are rejected
+//	c.SetWriter(original)
+type GuardedWriter struct {
+	writer http.ResponseWriter
+
+	mu          sync.Mutex
+	wroteHeader bool
+	closed      bool
+	warned      bool
+}
+
+// NewGuardedWriter returns a GuardedWriter writing to w.
+func NewGuardedWriter(w http.ResponseWriter) *GuardedWriter {
+	return &GuardedWriter{writer: w}
+}
+
+// Header returns the header map of the underlying writer, or a detached map once
+// the writer is closed, so that late header changes have no effect.
+func (g *GuardedWriter) Header() http.Header {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return make(http.Header)
+	}
+	return g.writer.Header()
+}
+
+// WriteHeader sends the status code, unless a status has already been sent or the writer is closed.
+func (g *GuardedWriter) WriteHeader(code int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		g.warn("ignoring WriteHeader(%d) after the response was sent", code)
+		return
+	}
+	if g.wroteHeader {
+		g.warn("ignoring superfluous WriteHeader(%d)", code)
+		return
+	}
+	g.wroteHeader = true
+	g.writer.WriteHeader(code)
+}
+
+// Write writes data to the underlying writer, or returns ErrResponseSent if the writer is closed.
+func (g *GuardedWriter) Write(data []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		g.warn("ignoring write of %d bytes after the response was sent", len(data))
+		return 0, ErrResponseSent
+	}
+	g.wroteHeader = true
+	return g.writer.Write(data)
+}
+
+// Flush flushes the underlying writer, unless the writer is closed.
+func (g *GuardedWriter) Flush() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return
+	}
+	_ = http.NewResponseController(g.writer).Flush()
+}
+
+// Respond calls fn with the underlying writer and closes the guard, if no status has been sent
+// and the guard is still open. It returns whether fn was called. Middleware uses it to respond
+// in place of a handler that is still running, e.g. on timeout.
+func (g *GuardedWriter) Respond(fn func(w http.ResponseWriter)) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed || g.wroteHeader {
+		return false
+	}
+	fn(g.writer)
+	g.wroteHeader = true
+	g.closed = true
+	return true
+}
+
+// Close rejects all further writes. It returns false if the guard was already closed,
+// e.g. because Respond was called.
+func (g *GuardedWriter) Close() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	wasOpen := !g.closed
+	g.closed = true
+	return wasOpen
+}
+
+// warn logs a rejected write, once per writer. The caller must hold g.mu.
+func (g *GuardedWriter) warn(format string, args ...interface{}) {
+	if g.warned {
+		return
+	}
+	g.warned = true
+	log.Printf("[WARNING] "+format+"; a handler or middleware is writing after its response was completed", args...)
+}
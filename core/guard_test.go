@@ -0,0 +1,71 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGuardedWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	guard := NewGuardedWriter(rec)
+
+	guard.WriteHeader(http.StatusCreated)
+	guard.WriteHeader(http.StatusInternalServerError) // Superfluous, ignored
+	if _, err := guard.Write([]byte("created")); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	if guard.Respond(func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) }) {
+		t.Error("Respond() succeeded after the handler started responding")
+	}
+
+	if !guard.Close() {
+		t.Error("Close() = false for an open guard")
+	}
+	if _, err := guard.Write([]byte(" late")); !errors.Is(err, ErrResponseSent) {
+		t.Errorf("Write() after Close returned %v, want ErrResponseSent", err)
+	}
+	guard.Header().Set("X-Late", "1")
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "created" || rec.Header().Get("X-Late") != "" {
+		t.Errorf("response = %d %q %v, want 201 \"created\" without late header", rec.Code, rec.Body.String(), rec.Header())
+	}
+}
+
+func TestGuardedWriterRespond(t *testing.T) {
+	rec := httptest.NewRecorder()
+	guard := NewGuardedWriter(rec)
+
+	if !guard.Respond(func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("timeout"))
+	}) {
+		t.Fatal("Respond() = false for an open guard")
+	}
+	if _, err := guard.Write([]byte("handler")); !errors.Is(err, ErrResponseSent) {
+		t.Errorf("Write() after Respond returned %v, want ErrResponseSent", err)
+	}
+	if guard.Close() {
+		t.Error("Close() = true after Respond")
+	}
+	if rec.Code != http.StatusServiceUnavailable || rec.Body.String() != "timeout" {
+		t.Errorf("response = %d %q, want 503 \"timeout\"", rec.Code, rec.Body.String())
+	}
+}
+
+func TestResponseBufferRejectsWritesAfterCommit(t *testing.T) {
+	rec := httptest.NewRecorder()
+	buffer := NewResponseBuffer(rec)
+	buffer.Write([]byte("body"))
+	if err := buffer.Commit(); err != nil {
+		t.Fatalf("Commit() returned error: %v", err)
+	}
+
+	if _, err := buffer.Write([]byte(" late")); !errors.Is(err, ErrResponseSent) {
+		t.Errorf("Write() after Commit returned %v, want ErrResponseSent", err)
+	}
+	if rec.Body.String() != "body" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "body")
+	}
+}
@@ -0,0 +1,35 @@
+package core
+
+import (
+	"reflect"
+	"runtime"
+)
+
+// NamedHandler pairs a handler with the name shown in framework logs.
+type NamedHandler struct {
+	// Name is the display name. If empty, the function name of Handler is used.
+	Name    string
+	Handler HandlerFunc
+}
+
+// DisplayName returns the name of the handler.
+// The function name is only resolved through reflection when no explicit name is set,
+// so the lookup cost is paid only when the name is actually logged.
+func (h NamedHandler) DisplayName() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	return HandlerName(h.Handler)
+}
+
+// HandlerName returns the function name of the handler using runtime symbol lookup.
+func HandlerName(handler HandlerFunc) string {
+	if handler == nil {
+		return "<nil>"
+	}
+	fn := runtime.FuncForPC(reflect.ValueOf(handler).Pointer())
+	if fn == nil {
+		return "<unknown>"
+	}
+	return fn.Name()
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+func TestNewHTTPServer(t *testing.T) {
+	handler := http.NotFoundHandler()
+
+	srv := NewHTTPServer(":8080", handler, nil)
+	if srv.Addr != ":8080" || srv.ReadHeaderTimeout != 0 || srv.TLSConfig != nil {
+		t.Errorf("NewHTTPServer with nil config = %+v, want net/http defaults", srv)
+	}
+
+	config := HardenedHTTPServerConfig()
+	srv = NewHTTPServer(":8443", handler, config)
+	if srv.ReadHeaderTimeout != config.ReadHeaderTimeout || srv.ReadTimeout != config.ReadTimeout ||
+		srv.WriteTimeout != config.WriteTimeout || srv.IdleTimeout != config.IdleTimeout ||
+		srv.MaxHeaderBytes != config.MaxHeaderBytes {
+		t.Errorf("NewHTTPServer did not apply config: %+v", srv)
+	}
+	if srv.TLSConfig == nil || srv.TLSConfig.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("TLSConfig = %+v, want MinVersion TLS 1.2", srv.TLSConfig)
+	}
+	if srv.TLSConfig == config.TLSConfig {
+		t.Error("TLSConfig should be cloned, not shared")
+	}
+}
@@ -0,0 +1,557 @@
+// Package nethttp provides the core.Context shared by the adapters serving requests with net/http:
+// std, chi and fiber, whose requests served by fasthttp are converted to net/http requests.
+// The adapters only supply their router and the path parameters of the matched route.
+package nethttp
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// Config holds the settings of a server read by the contexts of its requests.
+type Config struct {
+	Options        core.EngineOptions   // Router and request parsing settings
+	TrustedProxies *core.TrustedProxies // Proxies whose forwarding headers are trusted, nil for none
+}
+
+// MultipartMemory returns the memory used to parse multipart forms.
+func (c *Config) MultipartMemory() int64 {
+	if c != nil && c.Options.MaxMultipartMemory > 0 {
+		return c.Options.MaxMultipartMemory
+	}
+	return core.DefaultMaxMultipartMemory
+}
+
+// Param is a path parameter of the matched route.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// ParamLookup looks up the path parameters kept by a router.
+type ParamLookup interface {
+	// Param returns the value of the path parameter, or an empty string if there is none.
+	Param(key string) string
+}
+
+// Context is an implementation of core.Context for requests served with net/http.
+// The adapters set it up with Init and the path parameters of the matched route, and run the
+// handler chain with Serve.
+type Context struct {
+	config     *Config
+	req        *http.Request
+	writer     core.ResponseWriter
+	rw         core.StatusWriter // Writer of the response, embedded to save an allocation
+	params     []Param           // Path parameters of the matched route
+	paramBuf   [4]Param          // Backing array of params for routes with few parameters
+	lookup     ParamLookup       // Parameters kept by the router, nil if they are all in params
+	queryCache map[string]string
+	errs       []error                // Errors that occurred during request processing
+	keys       map[string]interface{} // Key-value store for context data
+	mu         sync.RWMutex           // Mutex to protect concurrent access to keys
+
+	// Fields for middleware flow control
+	handlers     []core.HandlerFunc // All handlers (middleware + route handlers)
+	index        int                // Current handler index
+	handlerCount int                // Total number of handlers
+}
+
+// Init prepares a new Context to serve r, writing the response to w. config holds the settings
+// of the server; it may be nil, e.g. in tests.
+func (c *Context) Init(config *Config, w http.ResponseWriter, r *http.Request) {
+	c.config = config
+	c.req = r
+	c.rw.Reset(w)
+	c.writer = &c.rw
+	c.index = -1
+}
+
+// ParamBuffer returns an empty slice backed by the context, to collect the parameters of
+// routes with few parameters without allocating.
+func (c *Context) ParamBuffer() []Param {
+	return c.paramBuf[:0]
+}
+
+// SetParams sets the path parameters of the matched route.
+func (c *Context) SetParams(params []Param) {
+	c.params = params
+}
+
+// SetParamLookup sets the lookup of the path parameters kept by the router, e.g. in its own
+// routing context. Parameters set with SetParams take precedence.
+func (c *Context) SetParamLookup(lookup ParamLookup) {
+	c.lookup = lookup
+}
+
+// Serve runs handlers as the handler chain of the request.
+func (c *Context) Serve(handlers []core.HandlerFunc) {
+	c.handlers = handlers
+	c.handlerCount = len(handlers)
+	c.Next()
+}
+
+// Request implements core.Context.Request
+func (c *Context) Request() *http.Request {
+	return c.req
+}
+
+// SetRequest implements core.Context.SetRequest
+func (c *Context) SetRequest(r *http.Request) {
+	c.req = r
+}
+
+// Writer implements core.Context.Writer
+func (c *Context) Writer() core.ResponseWriter {
+	return c.writer
+}
+
+// SetWriter implements core.Context.SetWriter
+func (c *Context) SetWriter(w http.ResponseWriter) {
+	c.writer = core.NewResponseWriter(w)
+}
+
+// Param implements core.Context.Param
+func (c *Context) Param(key string) string {
+	for _, param := range c.params {
+		if param.Key == key {
+			return param.Value
+		}
+	}
+	if c.lookup != nil {
+		return c.lookup.Param(key)
+	}
+	return ""
+}
+
+// ParamInt implements core.Context.ParamInt
+func (c *Context) ParamInt(key string) (int, error) {
+	return core.ParseParamInt(c, key)
+}
+
+// ParamUUID implements core.Context.ParamUUID
+func (c *Context) ParamUUID(key string) (string, error) {
+	return core.ParseParamUUID(c, key)
+}
+
+// Query implements core.Context.Query
+func (c *Context) Query(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.queryCache == nil {
+		c.queryCache = make(map[string]string)
+	}
+	if val, ok := c.queryCache[key]; ok {
+		return val
+	}
+	val := c.req.URL.Query().Get(key)
+	c.queryCache[key] = val
+	return val
+}
+
+// DefaultQuery implements core.Context.DefaultQuery
+func (c *Context) DefaultQuery(key, defaultValue string) string {
+	val := c.Query(key)
+	if val == "" {
+		return defaultValue
+	}
+	return val
+}
+
+// GetQuery implements core.Context.GetQuery
+func (c *Context) GetQuery(key string) (string, bool) {
+	values, ok := c.req.URL.Query()[key]
+	if !ok || len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+
+// QueryArray implements core.Context.QueryArray
+func (c *Context) QueryArray(key string) []string {
+	values := c.req.URL.Query()[key]
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// QueryMap implements core.Context.QueryMap
+// It collects parameters of the form prefix[key]=value, e.g. ids[a]=1&ids[b]=2.
+func (c *Context) QueryMap(prefix string) map[string]string {
+	return formMap(c.req.URL.Query(), prefix)
+}
+
+// PostForm implements core.Context.PostForm
+func (c *Context) PostForm(key string) string {
+	value, _ := c.getPostForm(key)
+	return value
+}
+
+// DefaultPostForm implements core.Context.DefaultPostForm
+func (c *Context) DefaultPostForm(key, defaultValue string) string {
+	if value, ok := c.getPostForm(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// multipartMemory returns the memory used to parse multipart forms, as set by core.MultipartMemory
+// for the request or by the engine options.
+func (c *Context) multipartMemory() int64 {
+	return core.MultipartMemoryFromContext(c.req.Context(), c.config.MultipartMemory())
+}
+
+// strictContentType returns whether Bind and BindJSON reject unexpected content types.
+func (c *Context) strictContentType() bool {
+	return c.config != nil && c.config.Options.StrictContentType
+}
+
+// getPostForm returns the first value of the form field and whether it is present.
+// It parses urlencoded and multipart bodies on first use.
+func (c *Context) getPostForm(key string) (string, bool) {
+	if c.req.PostForm == nil {
+		if err := c.req.ParseMultipartForm(c.multipartMemory()); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return "", false
+		}
+	}
+	if values := c.req.PostForm[key]; len(values) > 0 {
+		return values[0], true
+	}
+	return "", false
+}
+
+// formMap collects the values of keys of the form prefix[key] into a map.
+func formMap(values url.Values, prefix string) map[string]string {
+	result := make(map[string]string)
+	for key, value := range values {
+		if len(value) == 0 || !strings.HasPrefix(key, prefix+"[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		result[key[len(prefix)+1:len(key)-1]] = value[0]
+	}
+	return result
+}
+
+// GetHeader implements core.Context.GetHeader
+func (c *Context) GetHeader(key string) string {
+	return c.req.Header.Get(key)
+}
+
+// GetHeaders implements core.Context.GetHeaders
+func (c *Context) GetHeaders(key string) []string {
+	return c.req.Header.Values(key)
+}
+
+// SetHeader implements core.Context.SetHeader
+// As with Gin, an empty value removes the header.
+func (c *Context) SetHeader(key, value string) {
+	if value == "" {
+		c.writer.Header().Del(key)
+		return
+	}
+	c.writer.Header().Set(key, value)
+}
+
+// AddHeader implements core.Context.AddHeader
+func (c *Context) AddHeader(key, value string) {
+	c.writer.Header().Add(key, value)
+}
+
+// SetStatus implements core.Context.SetStatus
+func (c *Context) SetStatus(code int) {
+	c.writer.WriteHeader(code)
+}
+
+// JSON implements core.Context.JSON
+func (c *Context) JSON(code int, obj interface{}) {
+	// Skip encoding altogether if the client is already gone
+	ctx := c.req.Context()
+	if core.CheckAborted(ctx) != nil {
+		return
+	}
+
+	c.SetHeader("Content-Type", "application/json")
+	c.SetStatus(code)
+	// Encode with the configured codec; the body ends with a newline, as with json.Encoder
+	data, err := core.MarshalJSON(obj)
+	if err != nil {
+		http.Error(c.writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = core.WriteResponseBody(ctx, c.writer, append(data, '\n'))
+}
+
+// String implements core.Context.String
+func (c *Context) String(code int, format string, values ...interface{}) {
+	c.SetHeader("Content-Type", "text/plain")
+	c.SetStatus(code)
+	fmt.Fprintf(c.writer, format, values...)
+}
+
+// HTML implements core.Context.HTML
+func (c *Context) HTML(code int, name string, data interface{}) {
+	if err := core.WriteHTML(c.req.Context(), c.writer, code, name, data); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// Bind implements core.Context.Bind
+// Like Gin's Bind, it selects the binding by method and Content-Type: GET requests and form bodies
+// bind the query and form values with core.BindForm, multipart bodies the uploaded files as well,
+// and JSON and XML bodies are decoded. The bound value is checked with core.Validate.
+// Unsupported content types are returned as *errors.UnsupportedMediaTypeHttpError (see
+// core.BindingMediaType), and other errors except validation errors as *errors.BadRequestHttpError.
+func (c *Context) Bind(obj interface{}) error {
+	if err := c.bind(obj); err != nil {
+		var httpErr httperrors.HTTPError
+		if _, ok := httperrors.AsValidationError(err); ok || errors.As(err, &httpErr) {
+			return err
+		}
+		return httperrors.NewBadRequestHttpError(err)
+	}
+	return core.Validate(obj)
+}
+
+// bind decodes the request into obj with the binding selected by method and Content-Type.
+func (c *Context) bind(obj interface{}) error {
+	if c.req.Method == http.MethodGet {
+		if err := c.req.ParseForm(); err != nil {
+			return err
+		}
+		return core.BindForm(c.req.Form, nil, obj)
+	}
+
+	mediaType, err := core.BindingMediaType(c.GetHeader("Content-Type"), c.strictContentType())
+	if err != nil {
+		return err
+	}
+	switch mediaType {
+	case core.MIMEJSON:
+		return json.NewDecoder(c.req.Body).Decode(obj)
+	case core.MIMEXML:
+		return xml.NewDecoder(c.req.Body).Decode(obj)
+	case core.MIMEMultipartForm:
+		if err := c.req.ParseMultipartForm(c.multipartMemory()); err != nil {
+			return err
+		}
+		return core.BindForm(c.req.Form, c.req.MultipartForm.File, obj)
+	default:
+		// Gin binds form values for any other content type
+		if err := c.req.ParseForm(); err != nil {
+			return err
+		}
+		return core.BindForm(c.req.Form, nil, obj)
+	}
+}
+
+// BindJSON implements core.Context.BindJSON
+// The decoded value is checked with core.Validate.
+func (c *Context) BindJSON(obj interface{}) error {
+	return c.ShouldBindJSON(obj)
+}
+
+// ShouldBindJSON implements core.Context.ShouldBindJSON
+// The Content-Type is checked with core.CheckJSONContentType, and the decoded value with core.Validate.
+func (c *Context) ShouldBindJSON(obj interface{}) error {
+	if err := core.CheckJSONContentType(c.GetHeader("Content-Type"), c.strictContentType()); err != nil {
+		return err
+	}
+	if err := json.NewDecoder(c.req.Body).Decode(obj); err != nil {
+		return err
+	}
+	return core.Validate(obj)
+}
+
+// JSONStream implements core.Context.JSONStream
+func (c *Context) JSONStream(code int, seq iter.Seq[interface{}]) error {
+	return core.WriteJSONStream(c.req.Context(), c.writer, code, seq)
+}
+
+// NDJSON implements core.Context.NDJSON
+func (c *Context) NDJSON(code int, seq iter.Seq[interface{}]) error {
+	return core.WriteNDJSON(c.req.Context(), c.writer, code, seq)
+}
+
+// CSV implements core.Context.CSV
+func (c *Context) CSV(code int, headers []string, rows iter.Seq[[]string]) error {
+	return core.WriteCSV(c.req.Context(), c.writer, code, headers, rows)
+}
+
+// Stream implements core.Context.Stream
+func (c *Context) Stream(step func(w io.Writer) bool) error {
+	return core.WriteStream(c.req.Context(), c.writer, step)
+}
+
+// DataFromReader implements core.Context.DataFromReader
+func (c *Context) DataFromReader(code int, contentLength int64, contentType string, reader io.Reader) error {
+	return middleware.DataFromReader(c, code, contentLength, contentType, reader)
+}
+
+// BindJSONStream implements core.Context.BindJSONStream
+func (c *Context) BindJSONStream(fn func(element json.RawMessage) error) error {
+	return core.DecodeJSONStream(c.req.Body, fn)
+}
+
+// GetRawData implements core.Context.GetRawData
+func (c *Context) GetRawData() ([]byte, error) {
+	return core.ReadRawData(c)
+}
+
+// File implements core.Context.File, adding the digest headers of the response digest middleware
+func (c *Context) File(filepath string) {
+	middleware.SetFileDigestHeaders(c, filepath)
+	http.ServeFile(c.writer, c.req, filepath)
+}
+
+// FormFile implements core.Context.FormFile
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	return core.FormFile(c.req, c.multipartMemory(), name)
+}
+
+// MultipartForm implements core.Context.MultipartForm
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	return core.MultipartForm(c.req, c.multipartMemory())
+}
+
+// SaveUploadedFile implements core.Context.SaveUploadedFile
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	return core.SaveUploadedFile(file, dst)
+}
+
+// Redirect implements core.Context.Redirect
+func (c *Context) Redirect(code int, location string) {
+	http.Redirect(c.writer, c.req, location, code)
+}
+
+// Error implements core.Context.Error
+// Since net/http doesn't have a built-in error handling mechanism,
+// this implementation stores the error in the context and returns it.
+func (c *Context) Error(err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Initialize the errs slice if it's nil
+	if c.errs == nil {
+		c.errs = make([]error, 0)
+	}
+
+	// Add the error to the errs slice
+	c.errs = append(c.errs, err)
+
+	// Return the error
+	return err
+}
+
+// Errors implements core.Context.Errors
+// It returns all errors added to the context.
+func (c *Context) Errors() []error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.errs
+}
+
+// Next implements core.Context.Next
+// It calls the next handler in the chain.
+func (c *Context) Next() {
+	c.index++
+	for c.index < c.handlerCount {
+		c.handlers[c.index](c)
+		c.index++
+	}
+}
+
+// Abort implements core.Context.Abort
+// It prevents pending handlers in the chain from being called.
+func (c *Context) Abort() {
+	c.index = c.handlerCount
+}
+
+// ServeRoute implements core.RouteServer.ServeRoute
+// It replaces the pending handlers of the chain with those of the route.
+func (c *Context) ServeRoute(params map[string]string, handlers []core.HandlerFunc) {
+	for key, value := range params {
+		c.params = append(c.params, Param{Key: key, Value: value})
+	}
+	c.handlers = handlers
+	c.handlerCount = len(handlers)
+	c.index = -1
+	c.Next()
+}
+
+// Get implements core.Context.Get
+// It returns the value for the given key and a boolean indicating whether the key exists.
+func (c *Context) Get(key string) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.keys == nil {
+		return nil, false
+	}
+	value, exists := c.keys[key]
+	return value, exists
+}
+
+// Set implements core.Context.Set
+// It stores a value in the context for the given key.
+func (c *Context) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.keys == nil {
+		c.keys = make(map[string]interface{})
+	}
+	c.keys[key] = value
+}
+
+// DetachedContext implements core.Context.DetachedContext
+// It carries the request context values and a snapshot of the key-value store.
+func (c *Context) DetachedContext() context.Context {
+	c.mu.RLock()
+	keys := make(map[string]interface{}, len(c.keys))
+	for key, value := range c.keys {
+		keys[key] = value
+	}
+	c.mu.RUnlock()
+
+	return core.NewDetachedContext(c.req.Context(), keys)
+}
+
+// CSPNonce implements core.Context.CSPNonce
+func (c *Context) CSPNonce() string {
+	return core.CSPNonce(c)
+}
+
+// ClientIP implements core.Context.ClientIP
+func (c *Context) ClientIP() string {
+	if c.config == nil {
+		return core.ResolveClientIP(c.req, nil, "")
+	}
+	return core.ResolveClientIP(c.req, c.config.TrustedProxies, c.config.Options.TrustedPlatform)
+}
+
+// StartTime implements core.Context.StartTime
+func (c *Context) StartTime() time.Time {
+	return core.RequestStartTime(c)
+}
+
+// Elapsed implements core.Context.Elapsed
+func (c *Context) Elapsed() time.Duration {
+	return core.RequestElapsed(c)
+}
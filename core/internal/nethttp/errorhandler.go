@@ -0,0 +1,125 @@
+package nethttp
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	tErrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// ErrorHandlerMiddleware is a net/http implementation of middleware.IErrorHandlerMiddleware.
+type ErrorHandlerMiddleware struct{}
+
+// Middleware returns a middleware function that handles errors for net/http.
+func (m *ErrorHandlerMiddleware) Middleware(config *core.ErrorHandlerConfig) core.HandlerFunc {
+	if config == nil {
+		config = middleware.DefaultErrorHandlerConfig()
+	}
+
+	return func(c core.Context) {
+		// Get the net/http context
+		httpContext, ok := c.(*Context)
+		if !ok {
+			// Handle the case when it's not a net/http context
+			// Create a recovery function to catch panics
+			defer func() {
+				if r := recover(); r != nil {
+					// Handle panic; HTTP errors keep their status
+					handleError(c, middleware.PanicError(r), config)
+				}
+			}()
+
+			// Continue with the next handler
+			c.Next()
+
+			// Check if there are any errors
+			if errs := c.Errors(); len(errs) > 0 {
+				middleware.WriteErrorResponse(c, errs, config)
+			}
+			return
+		}
+
+		// Create a recovery function to catch panics
+		defer func() {
+			if r := recover(); r != nil {
+				// Handle panic; HTTP errors keep their status
+				handleError(c, middleware.PanicError(r), config)
+			}
+		}()
+
+		// Create a wrapper for the response writer to capture errors
+		errorWriter := &errorCaptureWriter{ResponseWriter: httpContext.writer}
+
+		// Replace the original writer with the wrapped one
+		httpContext.writer = errorWriter
+
+		// Continue with the next middleware/handler in the chain
+		c.Next()
+
+		// With aggregation, errors attached via c.Error are reported together with the captured error
+		if config.AggregateErrors {
+			errs := append([]error(nil), c.Errors()...)
+			if errorWriter.err != nil {
+				errs = append(errs, errorWriter.err)
+			}
+			middleware.WriteErrorResponse(c, errs, config)
+			return
+		}
+
+		// Check if an error was captured
+		if errorWriter.err != nil {
+			// Handle the error based on its type
+			handleError(c, errorWriter.err, config)
+			return
+		}
+
+		// Errors attached via c.Error, e.g. binding errors, are reported as with Gin
+		if errs := c.Errors(); len(errs) > 0 {
+			middleware.WriteErrorResponse(c, errs, config)
+		}
+	}
+}
+
+// handleError processes an error and returns an appropriate HTTP response.
+func handleError(c core.Context, err error, config *core.ErrorHandlerConfig) {
+	middleware.WriteErrorResponse(c, []error{err}, config)
+}
+
+// errorCaptureWriter is a wrapper for core.ResponseWriter that captures errors.
+// The status code is recorded by the wrapped writer.
+type errorCaptureWriter struct {
+	core.ResponseWriter
+	err error
+}
+
+// Write captures errors based on the status code and calls the underlying ResponseWriter's Write.
+func (w *errorCaptureWriter) Write(b []byte) (int, error) {
+	// If the status code indicates an error, capture it
+	if status := w.Status(); status >= 400 {
+		switch status {
+		case http.StatusBadRequest:
+			w.err = tErrors.NewBadRequestHttpError(fmt.Errorf("%s", string(b)))
+		case http.StatusUnauthorized:
+			w.err = tErrors.NewUnauthorizedHttpError(fmt.Errorf("%s", string(b)))
+		case http.StatusForbidden:
+			w.err = tErrors.NewForbiddenHttpError(fmt.Errorf("%s", string(b)))
+		case http.StatusInternalServerError:
+			w.err = tErrors.NewInternalServerHttpError(fmt.Errorf("%s", string(b)))
+		default:
+			w.err = fmt.Errorf("HTTP error: %d - %s", status, string(b))
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter so that http.ResponseController can reach it.
+func (w *errorCaptureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// SetError sets an error on the writer.
+func (w *errorCaptureWriter) SetError(err error) {
+	w.err = err
+}
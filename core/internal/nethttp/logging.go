@@ -0,0 +1,50 @@
+package nethttp
+
+import (
+	"fmt"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/middleware/util"
+)
+
+// LoggingMiddleware is a net/http implementation of core.ILoggingMiddleware.
+type LoggingMiddleware struct {
+	middleware.BaseLoggingMiddleware
+}
+
+// Middleware returns a middleware function that logs API requests for net/http.
+// This implementation can capture the actual status code set by the handler.
+func (m *LoggingMiddleware) Middleware(config *core.LoggingConfig) core.HandlerFunc {
+	if config == nil {
+		config = middleware.DefaultLoggingConfig()
+	}
+
+	skipPaths := util.CompilePaths(config.SkipPaths)
+
+	return func(c core.Context) {
+		// Check if the path is in the skip paths list
+		if skipPaths.Match(c.Request().URL.Path) {
+			c.Next()
+			return
+		}
+
+		// Log entries of net/http contexts carry an error for error statuses
+		var errorMessage func(statusCode int) string
+		if _, ok := c.(*Context); ok {
+			errorMessage = statusErrorMessage
+		}
+		m.BaseLoggingMiddleware.LogRequest(c, config, errorMessage)
+	}
+}
+
+// statusErrorMessage returns the error of the log entry of a response with statusCode.
+func statusErrorMessage(statusCode int) string {
+	if statusCode == core.StatusClientClosedRequest {
+		return "Client closed request"
+	} else if statusCode >= 400 {
+		// For 4xx and 5xx status codes, set an error message
+		return fmt.Sprintf("HTTP error: %d", statusCode)
+	}
+	return ""
+}
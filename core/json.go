@@ -0,0 +1,456 @@
+package core
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// JSONCodec encodes the bodies written by Context.JSON, Context.JSONStream and Context.NDJSON.
+type JSONCodec interface {
+	// Marshal returns the JSON encoding of v
+	Marshal(v interface{}) ([]byte, error)
+}
+
+// JSONTimeFormat is the encoding of time.Time values under a JSONPolicy.
+type JSONTimeFormat string
+
+const (
+	// JSONTimeRFC3339 encodes times as RFC 3339 strings with second precision, e.g. "2024-05-01T12:00:00Z"
+	JSONTimeRFC3339 JSONTimeFormat = "rfc3339"
+	// JSONTimeRFC3339Nano encodes times as RFC 3339 strings with nanosecond precision, as encoding/json does
+	JSONTimeRFC3339Nano JSONTimeFormat = "rfc3339nano"
+	// JSONTimeUnix encodes times as Unix timestamps in seconds
+	JSONTimeUnix JSONTimeFormat = "unix"
+	// JSONTimeUnixMilli encodes times as Unix timestamps in milliseconds
+	JSONTimeUnixMilli JSONTimeFormat = "unixmilli"
+)
+
+// JSONPolicy holds serialization policies applied to every JSON response,
+// so that all services emit numbers and times the same way.
+type JSONPolicy struct {
+	// TimeFormat is the encoding of time.Time values. If empty, times are encoded by encoding/json.
+	TimeFormat JSONTimeFormat
+	// DecimalAsString encodes floating-point numbers and json.Number values as strings,
+	// e.g. "12.5", so that clients do not lose precision when parsing them as doubles.
+	DecimalAsString bool
+	// OmitZero omits struct fields with zero values, as if every field were tagged omitempty.
+	// Unlike omitempty, it also omits zero times and zero-valued structs.
+	OmitZero bool
+}
+
+// NewJSONCodec returns a JSONCodec applying policy on top of encoding/json.
+// Struct fields follow the encoding/json tags; values implementing json.Marshaler or
+// encoding.TextMarshaler are encoded by their own methods, except for time.Time when
+// policy.TimeFormat is set.
+func NewJSONCodec(policy JSONPolicy) JSONCodec {
+	if policy == (JSONPolicy{}) {
+		return stdJSONCodec{}
+	}
+	return &policyJSONCodec{policy: policy}
+}
+
+// jsonCodec is the codec used by MarshalJSON.
+var jsonCodec atomic.Value // JSONCodec
+
+// SetJSONCodec sets the codec used for all JSON responses of all servers. If codec is nil,
+// encoding/json is used. It should be called before the servers start, e.g. by the server builder.
+func SetJSONCodec(codec JSONCodec) {
+	if codec == nil {
+		codec = stdJSONCodec{}
+	}
+	jsonCodec.Store(&codec)
+}
+
+// MarshalJSON encodes v with the codec set by SetJSONCodec.
+// It is used by the Context.JSON implementations.
+func MarshalJSON(v interface{}) ([]byte, error) {
+	if codec, ok := jsonCodec.Load().(*JSONCodec); ok {
+		return (*codec).Marshal(v)
+	}
+	return json.Marshal(v)
+}
+
+// stdJSONCodec is the JSONCodec using encoding/json as it is.
+type stdJSONCodec struct{}
+
+// Marshal implements JSONCodec.Marshal.
+func (stdJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// maxJSONDepth limits the nesting of values, to fail on cyclic data instead of overflowing the stack.
+const maxJSONDepth = 1000
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonNumberType    = reflect.TypeOf(json.Number(""))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// policyJSONCodec applies a JSONPolicy by converting values to a tree of plain values,
+// which is then encoded by encoding/json.
+type policyJSONCodec struct {
+	policy JSONPolicy
+	fields sync.Map // reflect.Type -> []jsonField
+}
+
+// Marshal implements JSONCodec.Marshal.
+func (c *policyJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	converted, err := c.convert(reflect.ValueOf(v), 0)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(converted)
+}
+
+// convert returns the value to encode for v.
+func (c *policyJSONCodec) convert(v reflect.Value, depth int) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if depth > maxJSONDepth {
+		return nil, errors.New("json: value nested too deeply, possibly cyclic")
+	}
+
+	// Pointers are followed first; the pointed-to value is addressable, so methods with
+	// pointer receivers are still found below
+	if v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		return c.convert(v.Elem(), depth+1)
+	}
+
+	t := v.Type()
+	switch {
+	case t == timeType && c.policy.TimeFormat != "":
+		return c.convertTime(v.Interface().(time.Time)), nil
+	case t == jsonNumberType:
+		if c.policy.DecimalAsString {
+			return v.String(), nil
+		}
+		return v.Interface(), nil
+	case t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType):
+		return v.Interface(), nil
+	case v.CanAddr() && (reflect.PointerTo(t).Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)):
+		return v.Addr().Interface(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if c.policy.DecimalAsString {
+			return strconv.FormatFloat(v.Float(), 'f', -1, t.Bits()), nil
+		}
+		return v.Interface(), nil
+	case reflect.Struct:
+		return c.convertStruct(v, depth)
+	case reflect.Map:
+		return c.convertMap(v, depth)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded as base64 strings
+			return v.Interface(), nil
+		}
+		fallthrough
+	case reflect.Array:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			value, err := c.convert(v.Index(i), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	default:
+		return v.Interface(), nil
+	}
+}
+
+// convertTime returns the encoding of t under the time format of the policy.
+func (c *policyJSONCodec) convertTime(t time.Time) interface{} {
+	switch c.policy.TimeFormat {
+	case JSONTimeUnix:
+		return t.Unix()
+	case JSONTimeUnixMilli:
+		return t.UnixMilli()
+	case JSONTimeRFC3339Nano:
+		return t.Format(time.RFC3339Nano)
+	default:
+		return t.Format(time.RFC3339)
+	}
+}
+
+// convertStruct returns the fields of v as a jsonObject, in declaration order.
+func (c *policyJSONCodec) convertStruct(v reflect.Value, depth int) (interface{}, error) {
+	fields := c.structFields(v.Type())
+	object := &jsonObject{keys: make([]string, 0, len(fields)), values: make([]interface{}, 0, len(fields))}
+	for _, field := range fields {
+		fv, err := v.FieldByIndexErr(field.index)
+		if err != nil {
+			// The field is promoted through a nil embedded pointer
+			continue
+		}
+		if (field.omitEmpty && isEmptyJSONValue(fv)) || (c.policy.OmitZero && fv.IsZero()) {
+			continue
+		}
+
+		var value interface{}
+		if field.quoted && isQuotableKind(fv.Kind()) {
+			data, err := json.Marshal(fv.Interface())
+			if err != nil {
+				return nil, err
+			}
+			value = string(data)
+		} else if value, err = c.convert(fv, depth+1); err != nil {
+			return nil, err
+		}
+		object.keys = append(object.keys, field.name)
+		object.values = append(object.values, value)
+	}
+	return object, nil
+}
+
+// convertMap returns v as a map with string keys, converted as encoding/json converts them.
+func (c *policyJSONCodec) convertMap(v reflect.Value, depth int) (interface{}, error) {
+	if v.IsNil() {
+		return nil, nil
+	}
+	values := make(map[string]interface{}, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := jsonMapKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		value, err := c.convert(iter.Value(), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// jsonMapKey returns the JSON object key of a map key.
+func jsonMapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", nil
+		}
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", &json.UnsupportedTypeError{Type: k.Type()}
+}
+
+// jsonField is an encoded field of a struct type.
+type jsonField struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+	quoted    bool
+}
+
+// structFields returns the encoded fields of t in declaration order, following the encoding/json
+// rules: fields of embedded structs without a tag name are promoted, a field at a shallower depth
+// hides promoted fields with the same name, and of several fields at the same depth only a
+// tagged one is kept; otherwise they are all dropped.
+func (c *policyJSONCodec) structFields(t reflect.Type) []jsonField {
+	if cached, ok := c.fields.Load(t); ok {
+		return cached.([]jsonField)
+	}
+
+	var all []jsonField
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+	level := []embedded{{t: t}}
+	visited := map[reflect.Type]bool{t: true}
+	for len(level) > 0 {
+		var next []embedded
+		for _, e := range level {
+			for j := 0; j < e.t.NumField(); j++ {
+				sf := e.t.Field(j)
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(append([]int(nil), e.index...), j)
+
+				if sf.Anonymous && name == "" {
+					ft := sf.Type
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if ft.Kind() == reflect.Struct {
+						if !visited[ft] {
+							visited[ft] = true
+							next = append(next, embedded{t: ft, index: index})
+						}
+						continue
+					}
+				}
+				if !sf.IsExported() {
+					continue
+				}
+				field := jsonField{
+					name:      name,
+					index:     index,
+					tagged:    name != "",
+					omitEmpty: hasTagOption(opts, "omitempty"),
+					quoted:    hasTagOption(opts, "string"),
+				}
+				if field.name == "" {
+					field.name = sf.Name
+				}
+				all = append(all, field)
+			}
+		}
+		level = next
+	}
+
+	// Keep the dominant field of every name
+	byName := make(map[string][]jsonField)
+	for _, field := range all {
+		byName[field.name] = append(byName[field.name], field)
+	}
+	var fields []jsonField
+	for _, candidates := range byName {
+		if field, ok := dominantField(candidates); ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	c.fields.Store(t, fields)
+	return fields
+}
+
+// dominantField returns the field of candidates with the same name that is encoded:
+// the only one at the shallowest depth, or the only tagged one at that depth.
+func dominantField(candidates []jsonField) (jsonField, bool) {
+	depth := len(candidates[0].index)
+	for _, field := range candidates[1:] {
+		if len(field.index) < depth {
+			depth = len(field.index)
+		}
+	}
+	var shallowest, tagged []jsonField
+	for _, field := range candidates {
+		if len(field.index) == depth {
+			shallowest = append(shallowest, field)
+			if field.tagged {
+				tagged = append(tagged, field)
+			}
+		}
+	}
+	if len(shallowest) == 1 {
+		return shallowest[0], true
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return jsonField{}, false
+}
+
+// hasTagOption returns whether the comma-separated tag options contain option.
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmptyJSONValue returns whether v is empty in the sense of the omitempty tag option.
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// isQuotableKind returns whether values of kind k are encoded as strings by the string tag option.
+func isQuotableKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// jsonObject is a JSON object whose keys are encoded in order.
+type jsonObject struct {
+	keys   []string
+	values []interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		data, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte(':')
+		if data, err = json.Marshal(o.values[i]); err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+)
+
+type jsonBase struct {
+	ID        int       `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type jsonAudit struct {
+	By string `json:"by"`
+}
+
+type jsonOrder struct {
+	jsonBase
+	*jsonAudit
+	Name     string            `json:"name"`
+	Note     string            `json:"note,omitempty"`
+	Secret   string            `json:"-"`
+	Count    int64             `json:"count,string"`
+	Price    float64           `json:"price"`
+	Amount   json.Number       `json:"amount"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels"`
+	Sizes    map[int]float64   `json:"sizes"`
+	IP       net.IP            `json:"ip"`
+	Raw      json.RawMessage   `json:"raw"`
+	Data     []byte            `json:"data"`
+	Parent   *jsonOrder        `json:"parent"`
+	Any      interface{}       `json:"any"`
+	Untagged bool
+	internal int
+}
+
+func newJSONOrder() jsonOrder {
+	return jsonOrder{
+		jsonBase: jsonBase{ID: 7, CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)},
+		Name:     "book",
+		Count:    3,
+		Price:    12.5,
+		Amount:   "100.10",
+		Tags:     []string{"a", "<b>"},
+		Labels:   map[string]string{"z": "1", "a": "2"},
+		Sizes:    map[int]float64{2: 0.25},
+		IP:       net.IPv4(127, 0, 0, 1),
+		Raw:      json.RawMessage(`{"x":1}`),
+		Data:     []byte("hi"),
+		Any:      []interface{}{1.5, "x"},
+		internal: 1,
+	}
+}
+
+func TestJSONCodecMatchesEncodingJSON(t *testing.T) {
+	order := newJSONOrder()
+	order.Parent = &jsonOrder{Name: "parent", jsonAudit: &jsonAudit{By: "admin"}}
+
+	want, err := json.Marshal(order)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %v", err)
+	}
+	// RFC3339Nano is the time encoding of encoding/json, so the output must be identical
+	got, err := NewJSONCodec(JSONPolicy{TimeFormat: JSONTimeRFC3339Nano}).Marshal(order)
+	if err != nil {
+		t.Fatalf("Marshal() returned error: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJSONCodecPolicies(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+	type event struct {
+		At      time.Time  `json:"at"`
+		Ended   *time.Time `json:"ended"`
+		Price   float64    `json:"price"`
+		Rate    float32    `json:"rate"`
+		Amount  json.Number
+		Count   int       `json:"count"`
+		Skipped time.Time `json:"skipped"`
+	}
+	value := event{At: at, Ended: &at, Price: 0.1, Rate: 2.5, Amount: "1e3"}
+
+	tests := []struct {
+		name   string
+		policy JSONPolicy
+		want   string
+	}{
+		{"rfc3339", JSONPolicy{TimeFormat: JSONTimeRFC3339},
+			`{"at":"2024-05-01T12:00:00Z","ended":"2024-05-01T12:00:00Z","price":0.1,"rate":2.5,"Amount":1e3,"count":0,"skipped":"0001-01-01T00:00:00Z"}`},
+		{"unix", JSONPolicy{TimeFormat: JSONTimeUnix},
+			`{"at":1714564800,"ended":1714564800,"price":0.1,"rate":2.5,"Amount":1e3,"count":0,"skipped":-62135596800}`},
+		{"unix milli and omit zero", JSONPolicy{TimeFormat: JSONTimeUnixMilli, OmitZero: true},
+			`{"at":1714564800000,"ended":1714564800000,"price":0.1,"rate":2.5,"Amount":1e3}`},
+		{"decimal as string", JSONPolicy{DecimalAsString: true, OmitZero: true},
+			`{"at":"2024-05-01T12:00:00.0000005Z","ended":"2024-05-01T12:00:00.0000005Z","price":"0.1","rate":"2.5","Amount":"1e3"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewJSONCodec(tt.policy).Marshal(value)
+			if err != nil {
+				t.Fatalf("Marshal() returned error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONCodecCycle(t *testing.T) {
+	type node struct {
+		Next *node
+	}
+	n := &node{}
+	n.Next = n
+	if _, err := NewJSONCodec(JSONPolicy{OmitZero: true}).Marshal(n); err == nil {
+		t.Error("Marshal() of a cyclic value returned no error")
+	}
+}
+
+func TestSetJSONCodec(t *testing.T) {
+	t.Cleanup(func() { SetJSONCodec(nil) })
+
+	SetJSONCodec(NewJSONCodec(JSONPolicy{DecimalAsString: true}))
+	if got, _ := MarshalJSON(map[string]float64{"price": 1.5}); string(got) != `{"price":"1.5"}` {
+		t.Errorf("MarshalJSON() = %s, want {\"price\":\"1.5\"}", got)
+	}
+	SetJSONCodec(nil)
+	if got, _ := MarshalJSON(map[string]float64{"price": 1.5}); string(got) != `{"price":1.5}` {
+		t.Errorf("MarshalJSON() after reset = %s, want {\"price\":1.5}", got)
+	}
+}
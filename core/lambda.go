@@ -0,0 +1,115 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+)
+
+// LambdaEventType is the type of the events a Lambda function receives from its trigger.
+type LambdaEventType string
+
+const (
+	// LambdaEventAuto detects the type of each event from its payload.
+	LambdaEventAuto LambdaEventType = ""
+	// LambdaEventALB is an Application Load Balancer target group event.
+	LambdaEventALB LambdaEventType = "alb"
+	// LambdaEventAPIGatewayV1 is an API Gateway REST API event (payload format 1.0).
+	LambdaEventAPIGatewayV1 LambdaEventType = "apigateway-v1"
+	// LambdaEventAPIGatewayV2 is an API Gateway HTTP API event (payload format 2.0).
+	LambdaEventAPIGatewayV2 LambdaEventType = "apigateway-v2"
+	// LambdaEventFunctionURL is a Lambda function URL event, which uses the payload format 2.0.
+	LambdaEventFunctionURL LambdaEventType = "function-url"
+)
+
+// ErrUnknownLambdaEvent is returned for Lambda events that are not HTTP requests of a supported trigger.
+var ErrUnknownLambdaEvent = errors.New("unknown Lambda event type")
+
+// LambdaConfig holds the settings of StartLambda.
+type LambdaConfig struct {
+	// EventType is the type of the events the function receives. With the default,
+	// LambdaEventAuto, the type of each event is detected, so the same function can be
+	// invoked by a load balancer, API Gateway and its function URL.
+	EventType LambdaEventType
+}
+
+// lambdaEventShape holds the fields telling the Lambda event types apart.
+type lambdaEventShape struct {
+	Version        string `json:"version"`
+	HTTPMethod     string `json:"httpMethod"`
+	RequestContext struct {
+		ELB        json.RawMessage `json:"elb"`
+		HTTP       json.RawMessage `json:"http"`
+		DomainName string          `json:"domainName"`
+	} `json:"requestContext"`
+}
+
+// DetectLambdaEventType returns the type of the Lambda event payload, or an error wrapping
+// ErrUnknownLambdaEvent if it is not an HTTP request of a supported trigger.
+// Function URL events are told apart from API Gateway HTTP API events by their domain name.
+func DetectLambdaEventType(payload []byte) (LambdaEventType, error) {
+	var shape lambdaEventShape
+	if err := json.Unmarshal(payload, &shape); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnknownLambdaEvent, err)
+	}
+	switch {
+	case shape.RequestContext.ELB != nil:
+		return LambdaEventALB, nil
+	case shape.Version == "2.0" || shape.RequestContext.HTTP != nil:
+		if strings.Contains(shape.RequestContext.DomainName, ".lambda-url.") {
+			return LambdaEventFunctionURL, nil
+		}
+		return LambdaEventAPIGatewayV2, nil
+	case shape.HTTPMethod != "":
+		return LambdaEventAPIGatewayV1, nil
+	}
+	return "", ErrUnknownLambdaEvent
+}
+
+// NewLambdaHandler returns a Lambda handler serving the events of eventType with handler, the
+// Handler of the server. Each event is converted by the httpadapter adapter of its type, detected
+// from the payload with LambdaEventAuto.
+func NewLambdaHandler(handler http.Handler, eventType LambdaEventType) func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+	alb := httpadapter.NewALB(handler)
+	v1 := httpadapter.New(handler)
+	v2 := httpadapter.NewV2(handler)
+
+	return func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		eventType := eventType
+		if eventType == LambdaEventAuto {
+			var err error
+			if eventType, err = DetectLambdaEventType(payload); err != nil {
+				return nil, err
+			}
+		}
+
+		switch eventType {
+		case LambdaEventALB:
+			var req events.ALBTargetGroupRequest
+			if err := json.Unmarshal(payload, &req); err != nil {
+				return nil, err
+			}
+			return alb.ProxyWithContext(ctx, req)
+		case LambdaEventAPIGatewayV1:
+			var req events.APIGatewayProxyRequest
+			if err := json.Unmarshal(payload, &req); err != nil {
+				return nil, err
+			}
+			return v1.ProxyWithContext(ctx, req)
+		case LambdaEventAPIGatewayV2, LambdaEventFunctionURL:
+			// Function URLs use the payload format 2.0 of API Gateway HTTP APIs
+			var req events.APIGatewayV2HTTPRequest
+			if err := json.Unmarshal(payload, &req); err != nil {
+				return nil, err
+			}
+			return v2.ProxyWithContext(ctx, req)
+		}
+		return nil, fmt.Errorf("%w: %q", ErrUnknownLambdaEvent, eventType)
+	}
+}
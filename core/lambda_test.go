@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestDetectLambdaEventType(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    LambdaEventType
+	}{
+		{"ALB", `{"requestContext":{"elb":{"targetGroupArn":"arn"}},"httpMethod":"GET","path":"/"}`, LambdaEventALB},
+		{"REST API", `{"resource":"/{proxy+}","httpMethod":"GET","path":"/","requestContext":{"stage":"prod"}}`, LambdaEventAPIGatewayV1},
+		{"HTTP API", `{"version":"2.0","rawPath":"/","requestContext":{"domainName":"id.execute-api.eu-west-1.amazonaws.com","http":{"method":"GET"}}}`, LambdaEventAPIGatewayV2},
+		{"function URL", `{"version":"2.0","rawPath":"/","requestContext":{"domainName":"id.lambda-url.eu-west-1.on.aws","http":{"method":"GET"}}}`, LambdaEventFunctionURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectLambdaEventType([]byte(tt.payload))
+			if err != nil || got != tt.want {
+				t.Errorf("DetectLambdaEventType() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	for _, payload := range []string{`{"Records":[]}`, `not json`} {
+		if _, err := DetectLambdaEventType([]byte(payload)); !errors.Is(err, ErrUnknownLambdaEvent) {
+			t.Errorf("DetectLambdaEventType(%s) error = %v, want ErrUnknownLambdaEvent", payload, err)
+		}
+	}
+}
+
+func TestNewLambdaHandler(t *testing.T) {
+	handler := NewLambdaHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery))
+	}), LambdaEventAuto)
+
+	tests := []struct {
+		name, payload string
+	}{
+		{"ALB", `{"requestContext":{"elb":{"targetGroupArn":"arn"}},"httpMethod":"GET","path":"/users/1","queryStringParameters":{"q":"go"}}`},
+		{"REST API", `{"resource":"/{proxy+}","httpMethod":"GET","path":"/users/1","queryStringParameters":{"q":"go"},"requestContext":{"stage":"prod"}}`},
+		{"function URL", `{"version":"2.0","rawPath":"/users/1","rawQueryString":"q=go","requestContext":{"domainName":"id.lambda-url.eu-west-1.on.aws","http":{"method":"GET","path":"/users/1"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := handler(context.Background(), json.RawMessage(tt.payload))
+			if err != nil {
+				t.Fatalf("handler returned error: %v", err)
+			}
+			data, _ := json.Marshal(resp)
+			var decoded struct {
+				StatusCode int    `json:"statusCode"`
+				Body       string `json:"body"`
+			}
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("decoding %s: %v", data, err)
+			}
+			if decoded.StatusCode != http.StatusOK || decoded.Body != "GET /users/1?q=go" {
+				t.Errorf("response = %d %q, want 200 %q", decoded.StatusCode, decoded.Body, "GET /users/1?q=go")
+			}
+		})
+	}
+
+	if _, err := handler(context.Background(), json.RawMessage(`{"Records":[]}`)); !errors.Is(err, ErrUnknownLambdaEvent) {
+		t.Errorf("handler error = %v, want ErrUnknownLambdaEvent", err)
+	}
+}
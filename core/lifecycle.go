@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// LifecycleHook is a function called when a server starts or stops.
+type LifecycleHook func(ctx context.Context) error
+
+// Lifecycle runs start and stop hooks for a server.
+// Framework-specific Server implementations use it to implement OnStart and OnStop.
+type Lifecycle struct {
+	mu         sync.Mutex
+	startHooks []LifecycleHook
+	stopHooks  []stopHook
+	started    bool // Whether the start hooks have run
+}
+
+// stopHook is a stop hook with the number of start hooks registered before it.
+type stopHook struct {
+	hook  LifecycleHook
+	after int
+}
+
+// OnStart registers a hook that is run before the server starts accepting requests.
+func (l *Lifecycle) OnStart(hook LifecycleHook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.startHooks = append(l.startHooks, hook)
+}
+
+// OnStop registers a hook that is run when the server is stopped or shut down.
+func (l *Lifecycle) OnStop(hook LifecycleHook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stopHooks = append(l.stopHooks, stopHook{hook: hook, after: len(l.startHooks)})
+}
+
+// Start runs the start hooks in registration order and stops at the first error.
+// If a start hook fails, the stop hooks registered before it run in reverse registration order,
+// undoing the start hooks that succeeded, and a later Stop does nothing. The returned error
+// joins the error of the start hook with those of the stop hooks.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := l.startHooks
+	l.started = true
+	l.mu.Unlock()
+
+	for i, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			l.mu.Lock()
+			l.started = false
+			stopHooks := l.stopHooks
+			l.mu.Unlock()
+			return errors.Join(err, runStopHooks(ctx, stopHooks, i))
+		}
+	}
+	return nil
+}
+
+// Stop runs the stop hooks in reverse registration order and returns all errors joined.
+// The hooks run at most once, and only if Start has been called and succeeded.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	if !l.started {
+		l.mu.Unlock()
+		return nil
+	}
+	l.started = false
+	hooks, started := l.stopHooks, len(l.startHooks)
+	l.mu.Unlock()
+
+	return runStopHooks(ctx, hooks, started)
+}
+
+// runStopHooks runs, in reverse registration order, the hooks registered when at most started
+// start hooks were, and returns all errors joined.
+func runStopHooks(ctx context.Context, hooks []stopHook, started int) error {
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if hooks[i].after > started {
+			continue
+		}
+		if err := hooks[i].hook(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
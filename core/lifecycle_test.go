@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLifecycleStartFailure(t *testing.T) {
+	var events []string
+	hook := func(event string, err error) LifecycleHook {
+		return func(ctx context.Context) error {
+			events = append(events, event)
+			return err
+		}
+	}
+
+	var l Lifecycle
+	l.OnStop(hook("stop flush", nil))
+	l.OnStart(hook("start db", nil))
+	l.OnStop(hook("stop db", nil))
+	l.OnStart(hook("start cache", errors.New("connection refused")))
+	l.OnStop(hook("stop cache", nil))
+
+	if err := l.Start(context.Background()); err == nil {
+		t.Fatal("Start() did not return the error of the failed hook")
+	}
+	want := []string{"start db", "start cache", "stop db", "stop flush"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+
+	// The hooks that succeeded have been undone already
+	events = nil
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() returned error: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Stop() after a failed Start ran %v", events)
+	}
+}
+
+func TestLifecycleStop(t *testing.T) {
+	var events []string
+	var l Lifecycle
+	l.OnStart(func(ctx context.Context) error { events = append(events, "start"); return nil })
+	l.OnStop(func(ctx context.Context) error { events = append(events, "stop a"); return nil })
+	l.OnStop(func(ctx context.Context) error { events = append(events, "stop b"); return nil })
+
+	if err := l.Stop(context.Background()); err != nil || len(events) != 0 {
+		t.Fatalf("Stop() before Start = %v, ran %v", err, events)
+	}
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatalf("Start() returned error: %v", err)
+	}
+	_ = l.Stop(context.Background())
+	_ = l.Stop(context.Background())
+	want := []string{"start", "stop b", "stop a"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
@@ -0,0 +1,231 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// AffinityHeader is set to the target color on requests proxied to a peer deployment by the
+// affinity middleware. The peer serves such requests itself instead of proxying them again.
+const AffinityHeader = "X-Deployment-Color"
+
+// AffinityConfig holds configuration for the deployment affinity middleware.
+type AffinityConfig struct {
+	// Color is the deployment color of this server build, e.g. "blue" or "green".
+	Color string
+
+	// Peers maps the colors of the other deployments to their base URL,
+	// e.g. {"green": "http://app-green:8080"}. Requests of clients pinned to a peer are proxied to it.
+	Peers map[string]string
+
+	// Weights sets the relative share of new clients pinned to each color, e.g. {"blue": 90, "green": 10}
+	// while traffic is moved to green. Colors other than Color must be peers.
+	// If empty, new clients are pinned to Color.
+	Weights map[string]int
+
+	// CookieName is the name of the cookie holding the color a client is pinned to.
+	CookieName string
+
+	// MaxAge is how long clients stay pinned. If zero, the cookie lasts for the browser session.
+	MaxAge time.Duration
+
+	// Secure restricts the cookie to HTTPS requests.
+	Secure bool
+}
+
+// DefaultAffinityConfig returns a default deployment affinity configuration.
+func DefaultAffinityConfig() *AffinityConfig {
+	return &AffinityConfig{
+		Color:      "", // Empty by default, must be provided
+		Peers:      make(map[string]string),
+		Weights:    make(map[string]int),
+		CookieName: "deployment_color",
+	}
+}
+
+// Validate checks that the configuration has a color and that the peers and weights are usable,
+// returning a *ConfigError if not.
+func (config *AffinityConfig) Validate() error {
+	if config.Color == "" {
+		return &ConfigError{
+			Middleware: "AffinityMiddleware",
+			Field:      "Color",
+			Problem:    "must not be empty",
+			Remedy:     `set Color to the deployment color of this build, e.g. "blue"`,
+		}
+	}
+	for color, base := range config.Peers {
+		if u, err := url.Parse(base); err != nil || u.Scheme == "" || u.Host == "" {
+			return &ConfigError{
+				Middleware: "AffinityMiddleware",
+				Field:      "Peers",
+				Problem:    fmt.Sprintf("has an invalid URL %q for %q", base, color),
+				Remedy:     `use absolute URLs, e.g. "http://app-green:8080"`,
+			}
+		}
+	}
+	total := 0
+	for color, weight := range config.Weights {
+		if weight < 0 {
+			return &ConfigError{
+				Middleware: "AffinityMiddleware",
+				Field:      "Weights",
+				Problem:    fmt.Sprintf("has a negative weight for %q", color),
+			}
+		}
+		if _, ok := config.Peers[color]; !ok && color != config.Color {
+			return &ConfigError{
+				Middleware: "AffinityMiddleware",
+				Field:      "Weights",
+				Problem:    fmt.Sprintf("has a weight for %q, which is not a peer", color),
+				Remedy:     "add the base URL of its deployment to Peers",
+			}
+		}
+		total += weight
+	}
+	if len(config.Weights) > 0 && total == 0 {
+		return &ConfigError{
+			Middleware: "AffinityMiddleware",
+			Field:      "Weights",
+			Problem:    "are all zero",
+			Remedy:     "give at least one color a positive weight, or leave Weights empty",
+		}
+	}
+	return nil
+}
+
+// affinity pins clients to deployment colors.
+type affinity struct {
+	config  *AffinityConfig
+	colors  []string // Colors with a positive weight, sorted
+	weights []int    // Weights of colors
+	total   int
+	proxies map[string]*httputil.ReverseProxy
+}
+
+// assign returns the color of a new client, drawn according to the weights.
+func (a *affinity) assign() string {
+	if a.total == 0 {
+		return a.config.Color
+	}
+	n := rand.IntN(a.total)
+	for i, weight := range a.weights {
+		if n < weight {
+			return a.colors[i]
+		}
+		n -= weight
+	}
+	return a.config.Color
+}
+
+// cookie returns the cookie pinning a client to color.
+func (a *affinity) cookie(color string) *http.Cookie {
+	return &http.Cookie{
+		Name:     a.config.CookieName,
+		Value:    color,
+		Path:     "/",
+		MaxAge:   int(a.config.MaxAge.Seconds()),
+		Secure:   a.config.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// newProxy returns a reverse proxy to the deployment of color at target. The request keeps its
+// Host header and is marked with AffinityHeader.
+func newProxy(color string, target *url.URL) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			pr.Out.Host = pr.In.Host
+			pr.Out.Header.Set(AffinityHeader, color)
+		},
+	}
+}
+
+// AffinityMiddleware returns a middleware function that pins clients to a deployment color with a
+// cookie, so that traffic can be moved gradually between two server builds ("blue" and "green")
+// behind a load balancer that spreads requests over both.
+// Clients without a valid cookie are pinned according to Weights. Requests of clients pinned to
+// this build are served; those of clients pinned to a peer are proxied to it, and the peer's
+// response is returned as is (502 Bad Gateway if it cannot be reached). A cookie naming a color
+// that is neither this build nor a peer, e.g. of a retired deployment, is replaced.
+// It panics with a *ConfigError if the configuration is invalid; use TryAffinityMiddleware to get the error instead.
+// Example usage:
+//
+//	// On the blue build, while 10% of the new clients are moved to green
+//	config := middleware.DefaultAffinityConfig()
+//	config.Color = "blue"
+//	config.Peers = map[string]string{"green": "http://app-green:8080"}
+//	config.Weights = map[string]int{"blue": 90, "green": 10}
+//	s.Use(middleware.AffinityMiddleware(config))
+func AffinityMiddleware(config *AffinityConfig) core.HandlerFunc {
+	handler, err := TryAffinityMiddleware(config)
+	if err != nil {
+		panic(err)
+	}
+	return handler
+}
+
+// TryAffinityMiddleware is like AffinityMiddleware, but returns a *ConfigError instead of panicking
+// if the configuration is invalid.
+func TryAffinityMiddleware(config *AffinityConfig) (core.HandlerFunc, error) {
+	if config == nil {
+		config = DefaultAffinityConfig()
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.CookieName == "" {
+		config.CookieName = DefaultAffinityConfig().CookieName
+	}
+
+	a := &affinity{config: config, proxies: make(map[string]*httputil.ReverseProxy, len(config.Peers))}
+	for color, base := range config.Peers {
+		target, _ := url.Parse(base) // Checked by Validate
+		a.proxies[color] = newProxy(color, target)
+	}
+	for color, weight := range config.Weights {
+		if weight > 0 {
+			a.colors = append(a.colors, color)
+		}
+	}
+	sort.Strings(a.colors)
+	for _, color := range a.colors {
+		a.weights = append(a.weights, config.Weights[color])
+		a.total += config.Weights[color]
+	}
+
+	return func(c core.Context) {
+		req := c.Request()
+		// Requests proxied by a peer are served here
+		if req.Header.Get(AffinityHeader) == config.Color {
+			return
+		}
+
+		color := ""
+		if cookie, err := req.Cookie(config.CookieName); err == nil {
+			color = cookie.Value
+		}
+		if _, ok := a.proxies[color]; !ok && color != config.Color {
+			color = a.assign()
+			http.SetCookie(c.Writer(), a.cookie(color))
+		}
+		if color == config.Color {
+			// Continue with the next middleware/handler in the chain
+			return
+		}
+
+		a.proxies[color].ServeHTTP(c.Writer(), req)
+		c.Abort()
+	}, nil
+}
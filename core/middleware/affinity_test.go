@@ -0,0 +1,82 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+// newColorServer returns a server answering with its color behind the affinity middleware.
+func newColorServer(config *middleware.AffinityConfig) core.Server {
+	s := std.NewServer("8080", false)
+	s.Use(middleware.AffinityMiddleware(config))
+	s.GET("/color", func(c core.Context) {
+		c.String(http.StatusOK, "%s %s", config.Color, c.Request().Host)
+	})
+	return s
+}
+
+func TestAffinityMiddleware(t *testing.T) {
+	green := middleware.DefaultAffinityConfig()
+	green.Color = "green"
+	// The peer of green is never used, since proxied requests are served
+	green.Peers = map[string]string{"blue": "http://blue.invalid"}
+	greenServer := httptest.NewServer(newColorServer(green).Handler())
+	defer greenServer.Close()
+
+	blue := middleware.DefaultAffinityConfig()
+	blue.Color = "blue"
+	blue.Peers = map[string]string{"green": greenServer.URL}
+	blue.Weights = map[string]int{"blue": 0, "green": 1}
+	client := servertest.NewClient(newColorServer(blue))
+
+	tests := []struct {
+		name, cookie, body, setCookie string
+	}{
+		{"new client", "", "green example.com", "deployment_color=green"},
+		{"pinned to blue", "blue", "blue example.com", ""},
+		{"pinned to green", "green", "green example.com", ""},
+		{"retired color", "red", "green example.com", "deployment_color=green"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := client.GET("/color")
+			if tt.cookie != "" {
+				req.WithHeader("Cookie", "deployment_color="+tt.cookie)
+			}
+			rec := req.Expect(t).Status(http.StatusOK).Body(tt.body).Recorder()
+			if setCookie := rec.Header().Get("Set-Cookie"); !strings.HasPrefix(setCookie, tt.setCookie) || (tt.setCookie == "") != (setCookie == "") {
+				t.Errorf("Set-Cookie = %q, want %q", setCookie, tt.setCookie)
+			}
+		})
+	}
+}
+
+func TestAffinityConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config middleware.AffinityConfig
+		field  string
+	}{
+		{"no color", middleware.AffinityConfig{}, "Color"},
+		{"relative peer URL", middleware.AffinityConfig{Color: "blue", Peers: map[string]string{"green": "app-green"}}, "Peers"},
+		{"weight without peer", middleware.AffinityConfig{Color: "blue", Weights: map[string]int{"green": 1}}, "Weights"},
+		{"zero weights", middleware.AffinityConfig{Color: "blue", Weights: map[string]int{"blue": 0}}, "Weights"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := middleware.TryAffinityMiddleware(&tt.config)
+			var configErr *middleware.ConfigError
+			if !errors.As(err, &configErr) || configErr.Field != tt.field {
+				t.Errorf("TryAffinityMiddleware() error = %v, want a ConfigError for %s", err, tt.field)
+			}
+		})
+	}
+}
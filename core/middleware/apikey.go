@@ -50,16 +50,40 @@ func NewDefaultAPIKeyMiddleware(apiKey string) core.HandlerFunc {
 	return APIKeyMiddleware(config)
 }
 
+// Validate checks that the configuration has an API key, returning a *ConfigError if not.
+func (config *APIKeyConfig) Validate() error {
+	if config.APIKey == "" {
+		return &ConfigError{
+			Middleware: "APIKeyMiddleware",
+			Field:      "APIKey",
+			Problem:    "must not be empty",
+			Remedy:     "set APIKey to the key clients send in the x-api-key header",
+		}
+	}
+	return nil
+}
+
 // APIKeyMiddleware returns a middleware function that checks for a valid API key in the x-api-key header.
 // If the API key is missing or invalid, it returns a 401 Unauthorized response.
+// It panics with a *ConfigError if the configuration is invalid; use TryAPIKeyMiddleware to get the error instead.
 func APIKeyMiddleware(config *APIKeyConfig) core.HandlerFunc {
+	handler, err := TryAPIKeyMiddleware(config)
+	if err != nil {
+		panic(err)
+	}
+	return handler
+}
+
+// TryAPIKeyMiddleware is like APIKeyMiddleware, but returns a *ConfigError instead of panicking
+// if the configuration is invalid.
+func TryAPIKeyMiddleware(config *APIKeyConfig) (core.HandlerFunc, error) {
 	if config == nil {
 		config = DefaultAPIKeyConfig()
 	}
 
 	// Ensure API key is provided
-	if config.APIKey == "" {
-		panic("APIKeyMiddleware requires a non-empty APIKey in the configuration")
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
 	return func(c core.Context) {
@@ -79,5 +103,5 @@ func APIKeyMiddleware(config *APIKeyConfig) core.HandlerFunc {
 		}
 
 		// API key is valid, continue with the next middleware/handler in the chain
-	}
+	}, nil
 }
@@ -3,13 +3,17 @@ package middleware
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
 	"crypto/hmac"
+	"crypto/rsa"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -68,10 +72,33 @@ type AuthConfig struct {
 	// If not specified, it defaults to jwt
 	AuthType AuthType
 
-	// JWTSecret is the secret key used to validate JWT tokens
-	// Required when AuthType is AuthTypeJWT
+	// JWTSecret is the secret key used to validate HS256 JWT tokens
+	// Required when AuthType is AuthTypeJWT, unless JWTPublicKey or JWKSURL is set
 	JWTSecret string
 
+	// JWTPublicKey is an *rsa.PublicKey or *ecdsa.PublicKey used to validate RS256 or ES256
+	// (and RS384, RS512, ES384, ES512) JWT tokens, e.g. parsed with x509.ParsePKIXPublicKey
+	JWTPublicKey crypto.PublicKey
+
+	// JWKSURL is the URL of the JSON Web Key Set of the identity provider, e.g.
+	// "https://tenant.auth0.com/.well-known/jwks.json". Tokens are validated with the key
+	// named by their "kid" header; keys are cached and refetched when a token names an unknown key.
+	// It takes precedence over JWTPublicKey.
+	JWKSURL string
+
+	// JWKSRefreshInterval is how long keys are cached before the JWKS is refetched.
+	// If zero, DefaultJWKSRefreshInterval (one hour) is used.
+	JWKSRefreshInterval time.Duration
+
+	// JWKSClient fetches the JWKS. If nil, a client with a 5 second timeout is used.
+	JWKSClient *http.Client
+
+	// JWTIssuer, if set, must equal the "iss" claim of tokens
+	JWTIssuer string
+
+	// JWTAudience, if set, must contain one of the "aud" claims of tokens
+	JWTAudience []string
+
 	// Optional: custom error messages
 	UnauthorizedMessage string
 	ForbiddenMessage    string
@@ -134,39 +161,105 @@ func NewDefaultBasicAuthMiddleware(basicAuthLookup BasicAuthUserLookup) core.Han
 	return AuthMiddleware(config)
 }
 
-// AuthMiddleware returns a middleware function that checks authorization
-// It supports either Basic HTTP authentication or Bearer JWT tokens based on the configuration
-func AuthMiddleware(config *AuthConfig) core.HandlerFunc {
-	if config == nil {
-		config = DefaultAuthConfig()
-	}
-
-	// Validate the configuration based on the authentication type
+// Validate checks the configuration for the authentication type, returning a *ConfigError
+// that explains how to fix the first problem found.
+func (config *AuthConfig) Validate() error {
 	switch config.AuthType {
 	case AuthTypeBasic:
 		// For Basic authentication, we need either UserLookup or BasicAuthLookup
 		if config.UserLookup == nil && config.BasicAuthLookup == nil {
-			panic("AuthMiddleware with AuthTypeBasic requires either UserLookup or BasicAuthLookup implementation")
+			return &ConfigError{
+				Middleware: "AuthMiddleware",
+				Field:      "BasicAuthLookup",
+				Problem:    "is required with AuthTypeBasic",
+				Remedy:     "set BasicAuthLookup (or UserLookup) to a BasicAuthUserLookup implementation",
+			}
 		}
 	case AuthTypeJWT:
 		// For JWT authentication, we need either UserLookup or JWTLookup
 		if config.UserLookup == nil && config.JWTLookup == nil {
-			panic("AuthMiddleware with AuthTypeJWT requires either UserLookup or JWTLookup implementation")
+			return &ConfigError{
+				Middleware: "AuthMiddleware",
+				Field:      "JWTLookup",
+				Problem:    "is required with AuthTypeJWT",
+				Remedy:     "set JWTLookup (or UserLookup) to a JWTUserLookup implementation",
+			}
 		}
-		// Also check for JWTSecret
-		if config.JWTSecret == "" {
-			panic("JWTSecret is required when using JWT authentication")
+		// Also check for the keys tokens are validated with
+		if config.JWTSecret == "" && config.JWTPublicKey == nil && config.JWKSURL == "" {
+			return &ConfigError{
+				Middleware: "AuthMiddleware",
+				Field:      "JWTSecret",
+				Problem:    "is required with AuthTypeJWT",
+				Remedy:     "set JWTSecret to the HMAC key the tokens are signed with, or JWTPublicKey or JWKSURL for RS256/ES256 tokens",
+			}
+		}
+		switch config.JWTPublicKey.(type) {
+		case nil, *rsa.PublicKey, *ecdsa.PublicKey:
+		default:
+			return &ConfigError{
+				Middleware: "AuthMiddleware",
+				Field:      "JWTPublicKey",
+				Problem:    fmt.Sprintf("has unsupported type %T", config.JWTPublicKey),
+				Remedy:     "use an *rsa.PublicKey or *ecdsa.PublicKey, e.g. parsed with x509.ParsePKIXPublicKey",
+			}
+		}
+		if config.JWKSURL != "" {
+			if u, err := url.Parse(config.JWKSURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return &ConfigError{
+					Middleware: "AuthMiddleware",
+					Field:      "JWKSURL",
+					Problem:    fmt.Sprintf("%q is not an absolute HTTP URL", config.JWKSURL),
+					Remedy:     "set JWKSURL to the JWKS URL of the identity provider, e.g. https://tenant.auth0.com/.well-known/jwks.json",
+				}
+			}
 		}
 	default:
-		panic("Invalid AuthType specified")
+		return &ConfigError{
+			Middleware: "AuthMiddleware",
+			Field:      "AuthType",
+			Problem:    fmt.Sprintf("%q is not supported", config.AuthType),
+			Remedy:     "set AuthType to AuthTypeJWT or AuthTypeBasic, or start from DefaultAuthConfig()",
+		}
 	}
+	return nil
+}
+
+// AuthMiddleware returns a middleware function that checks authorization
+// It supports either Basic HTTP authentication or Bearer JWT tokens based on the configuration
+// It panics with a *ConfigError if the configuration is invalid; use TryAuthMiddleware to get the error instead.
+func AuthMiddleware(config *AuthConfig) core.HandlerFunc {
+	handler, err := TryAuthMiddleware(config)
+	if err != nil {
+		panic(err)
+	}
+	return handler
+}
+
+// TryAuthMiddleware is like AuthMiddleware, but returns a *ConfigError instead of panicking
+// if the configuration is invalid.
+func TryAuthMiddleware(config *AuthConfig) (core.HandlerFunc, error) {
+	if config == nil {
+		config = DefaultAuthConfig()
+	}
+
+	// Validate the configuration based on the authentication type
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	var verifier *jwtVerifier
+	if config.AuthType == AuthTypeJWT {
+		verifier = newJWTVerifier(config)
+	}
+
+	skipPaths := util.CompilePaths(config.SkipPaths)
 
 	return func(c core.Context) {
 		// Get request path
 		path := c.Request().URL.Path
 
 		// Check if the path is in the skip paths list
-		if util.IsSkipPaths(path, config.SkipPaths) {
+		if skipPaths.Match(path) {
 			return
 		}
 
@@ -229,7 +322,7 @@ func AuthMiddleware(config *AuthConfig) core.HandlerFunc {
 				jwtLookup = config.UserLookup
 			}
 
-			user, err = handleBearerToken(credentials, config.JWTSecret, jwtLookup)
+			user, err = handleBearerToken(c.Request().Context(), credentials, verifier, jwtLookup)
 		default:
 			c.SetStatus(http.StatusInternalServerError)
 			c.JSON(http.StatusInternalServerError, httperrors.NewInternalServerErrorResponse("Invalid authentication configuration"))
@@ -263,7 +356,7 @@ func AuthMiddleware(config *AuthConfig) core.HandlerFunc {
 
 		// Update the request in the context
 		*req = *newReq
-	}
+	}, nil
 }
 
 // UserContextKey is the key used to store the user in the request context
@@ -300,9 +393,9 @@ func handleBasicAuth(credentials string, lookup BasicAuthUserLookup) (interface{
 }
 
 // handleBearerToken processes JWT Bearer tokens
-func handleBearerToken(tokenString string, secret string, lookup JWTUserLookup) (interface{}, error) {
+func handleBearerToken(ctx context.Context, tokenString string, verifier *jwtVerifier, lookup JWTUserLookup) (interface{}, error) {
 	// Parse and validate the JWT token
-	claims, err := parseJWT(tokenString, secret)
+	claims, err := parseJWT(ctx, tokenString, verifier)
 	if err != nil {
 		return nil, fmt.Errorf("invalid token: %w", err)
 	}
@@ -316,8 +409,8 @@ func handleBearerToken(tokenString string, secret string, lookup JWTUserLookup)
 	return user, nil
 }
 
-// parseJWT parses and validates a JWT token
-func parseJWT(tokenString string, secret string) (MapClaims, error) {
+// parseJWT parses and validates a JWT token, checking its expiry against the clock of ctx
+func parseJWT(ctx context.Context, tokenString string, verifier *jwtVerifier) (MapClaims, error) {
 	// Split the token into parts
 	parts := strings.Split(tokenString, ".")
 	if len(parts) != 3 {
@@ -338,9 +431,10 @@ func parseJWT(tokenString string, secret string) (MapClaims, error) {
 
 	// Check the algorithm
 	alg, ok := header["alg"].(string)
-	if !ok || alg != "HS256" {
+	if !ok {
 		return nil, errors.New("unsupported signing method")
 	}
+	kid, _ := header["kid"].(string)
 
 	// Decode the payload
 	payloadJSON, err := base64URLDecode(parts[1])
@@ -360,22 +454,27 @@ func parseJWT(tokenString string, secret string) (MapClaims, error) {
 		return nil, fmt.Errorf("invalid token signature: %w", err)
 	}
 
-	// Create the signature
-	signatureString := parts[0] + "." + parts[1]
-	expectedSignature := createHmacSignature(signatureString, secret)
-
-	// Compare the signatures
-	if !hmac.Equal(signatureBytes, expectedSignature) {
-		return nil, errors.New("invalid token signature")
+	// Verify the signature with the key of the algorithm
+	if err := verifier.verify(ctx, alg, kid, parts[0]+"."+parts[1], signatureBytes); err != nil {
+		return nil, err
 	}
 
-	// Check expiration
+	// Check expiration and the time the token becomes valid
+	now := core.ClockFromContext(ctx).Now().Unix()
 	if exp, ok := claims["exp"].(float64); ok {
-		if time.Now().Unix() > int64(exp) {
+		if now > int64(exp) {
 			return nil, errors.New("token expired")
 		}
 	}
+	if nbf, ok := claims["nbf"].(float64); ok {
+		if now < int64(nbf) {
+			return nil, errors.New("token not valid yet")
+		}
+	}
 
+	if err := verifier.verifyClaims(claims); err != nil {
+		return nil, err
+	}
 	return claims, nil
 }
 
@@ -391,6 +490,25 @@ func base64URLDecode(s string) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(s)
 }
 
+// SignJWT creates an HS256 signed JWT token from the given claims.
+// The resulting token can be validated by AuthMiddleware with the same secret.
+func SignJWT(claims MapClaims, secret string) (string, error) {
+	headerJSON, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token header: %w", err)
+	}
+	payloadJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token payload: %w", err)
+	}
+
+	signingString := base64.RawURLEncoding.EncodeToString(headerJSON) + "." +
+		base64.RawURLEncoding.EncodeToString(payloadJSON)
+	signature := createHmacSignature(signingString, secret)
+
+	return signingString + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
 // createHmacSignature creates an HMAC signature for a JWT token
 func createHmacSignature(data, secret string) []byte {
 	h := hmac.New(sha256.New, []byte(secret))
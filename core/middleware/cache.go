@@ -0,0 +1,147 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/util"
+)
+
+// CachePolicy sets the caching headers of the responses of the routes matching Route.
+type CachePolicy struct {
+	// Route is a path pattern ("/products/:id", "/static/*") optionally prefixed with a method
+	// ("GET /products"), as in ConcurrencyLimitConfig.Limits.
+	Route string
+
+	// MaxAge is how long the response may be cached, sent as max-age and as the Expires header.
+	MaxAge time.Duration
+
+	// Private allows only the client's own cache to store the response, not shared caches such as CDNs.
+	Private bool
+
+	// NoStore forbids caching the response at all; MaxAge and Private are then ignored.
+	NoStore bool
+}
+
+// CacheControl returns the Cache-Control header value of the policy, e.g. "public, max-age=300".
+func (p *CachePolicy) CacheControl() string {
+	if p.NoStore {
+		return "no-store"
+	}
+	visibility := "public"
+	if p.Private {
+		visibility = "private"
+	}
+	return visibility + ", max-age=" + strconv.Itoa(int(p.MaxAge.Seconds()))
+}
+
+// cacheRule is a CachePolicy with its parsed route template.
+type cacheRule struct {
+	method string // Empty for any method
+	path   *util.PathMatcher
+	policy *CachePolicy
+}
+
+// CachePolicyMiddleware returns a middleware function that sets the Cache-Control and Expires headers
+// of responses from the first policy whose route matches the request, so handlers don't have to.
+// Policies apply to responses with a status below 400; error responses of the matched routes are
+// marked "no-store", so that a transient error is not cached for the lifetime of the policy.
+// Responses whose handler set Cache-Control itself, and requests that match no policy, are left alone.
+//
+// Example usage:
+//
+//	s.Use(middleware.CachePolicyMiddleware(
+//		middleware.CachePolicy{Route: "GET /products/:id", MaxAge: 5 * time.Minute},
+//		middleware.CachePolicy{Route: "/account/*", Private: true, MaxAge: time.Minute},
+//		middleware.CachePolicy{Route: "/checkout/*", NoStore: true},
+//	))
+func CachePolicyMiddleware(policies ...CachePolicy) core.HandlerFunc {
+	rules := make([]cacheRule, 0, len(policies))
+	for i := range policies {
+		method, path := parseRouteTemplate(policies[i].Route)
+		rules = append(rules, cacheRule{method: method, path: util.CompilePaths([]string{path}), policy: &policies[i]})
+	}
+
+	return func(c core.Context) {
+		req := c.Request()
+		var policy *CachePolicy
+		for _, rule := range rules {
+			if rule.method != "" && rule.method != req.Method {
+				continue
+			}
+			if rule.path.Match(req.URL.Path) {
+				policy = rule.policy
+				break
+			}
+		}
+		if policy == nil {
+			return
+		}
+
+		originalWriter := c.Writer()
+		c.SetWriter(&cacheHeaderWriter{
+			ResponseWriter: originalWriter,
+			policy:         policy,
+			clock:          core.ClockFromContext(req.Context()),
+		})
+		c.Next()
+		c.SetWriter(originalWriter)
+	}
+}
+
+// cacheHeaderWriter sets the caching headers of a policy when the response header is written.
+type cacheHeaderWriter struct {
+	http.ResponseWriter
+	policy      *CachePolicy
+	clock       core.Clock
+	wroteHeader bool
+}
+
+// WriteHeader sets the caching headers for the status code and forwards it.
+func (w *cacheHeaderWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.setHeaders(code)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes a 200 OK header first if none has been written.
+func (w *cacheHeaderWriter) Write(data []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush sends any buffered data to the client.
+func (w *cacheHeaderWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter so that http.ResponseController can reach it.
+func (w *cacheHeaderWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// setHeaders sets the caching headers of a response with status code, unless the handler set Cache-Control.
+func (w *cacheHeaderWriter) setHeaders(code int) {
+	header := w.Header()
+	if header.Get("Cache-Control") != "" {
+		return
+	}
+	if code >= http.StatusBadRequest {
+		header.Set("Cache-Control", "no-store")
+		return
+	}
+	header.Set("Cache-Control", w.policy.CacheControl())
+	if !w.policy.NoStore && w.policy.MaxAge > 0 {
+		header.Set("Expires", w.clock.Now().Add(w.policy.MaxAge).UTC().Format(http.TimeFormat))
+	}
+}
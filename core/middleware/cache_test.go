@@ -0,0 +1,89 @@
+package middleware_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestCachePolicyCacheControl(t *testing.T) {
+	tests := []struct {
+		policy middleware.CachePolicy
+		want   string
+	}{
+		{middleware.CachePolicy{MaxAge: 5 * time.Minute}, "public, max-age=300"},
+		{middleware.CachePolicy{MaxAge: time.Minute, Private: true}, "private, max-age=60"},
+		{middleware.CachePolicy{MaxAge: time.Minute, NoStore: true}, "no-store"},
+		{middleware.CachePolicy{}, "public, max-age=0"},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.CacheControl(); got != tt.want {
+			t.Errorf("CacheControl() of %+v = %q, want %q", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestCachePolicyMiddleware(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			s.Use(middleware.CachePolicyMiddleware(
+				middleware.CachePolicy{Route: "GET /products/:id", MaxAge: time.Minute},
+				middleware.CachePolicy{Route: "/products/*/reviews", MaxAge: time.Hour, Private: true},
+				middleware.CachePolicy{Route: "/checkout", NoStore: true},
+			))
+			s.GET("/products/:id", func(c core.Context) {
+				if c.Param("id") == "missing" {
+					c.String(http.StatusNotFound, "not found")
+					return
+				}
+				c.String(http.StatusOK, "product")
+			})
+			s.GET("/products/:id/reviews", func(c core.Context) {
+				c.String(http.StatusOK, "reviews")
+			})
+			s.POST("/products/:id", func(c core.Context) {
+				c.SetHeader("Cache-Control", "no-cache")
+				c.String(http.StatusOK, "updated")
+			})
+			s.GET("/checkout", func(c core.Context) {
+				c.String(http.StatusOK, "checkout")
+			})
+			s.GET("/other", func(c core.Context) {
+				c.String(http.StatusOK, "other")
+			})
+			client := servertest.NewClient(s)
+
+			rec := client.GET("/products/1").Expect(t).
+				Status(http.StatusOK).
+				Header("Cache-Control", "public, max-age=60").
+				Recorder()
+			expires, err := http.ParseTime(rec.Header().Get("Expires"))
+			if err != nil {
+				t.Fatalf("Expires = %q: %v", rec.Header().Get("Expires"), err)
+			}
+			if d := time.Until(expires); d < 50*time.Second || d > 70*time.Second {
+				t.Errorf("Expires is %v from now, want about a minute", d)
+			}
+
+			client.GET("/products/missing").Expect(t).
+				Status(http.StatusNotFound).
+				Header("Cache-Control", "no-store").
+				Header("Expires", "")
+			client.GET("/products/1/reviews").Expect(t).
+				Header("Cache-Control", "private, max-age=3600")
+			client.POST("/products/1").Expect(t).
+				Header("Cache-Control", "no-cache")
+			client.GET("/checkout").Expect(t).
+				Header("Cache-Control", "no-store").
+				Header("Expires", "")
+			client.GET("/other").Expect(t).
+				Header("Cache-Control", "")
+		})
+	}
+}
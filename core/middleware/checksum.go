@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// ErrChecksumMismatch is returned when reading a request body whose content does not match
+// its Content-MD5, Digest or Content-Digest header. It is a 400 Bad Request HTTPError.
+var ErrChecksumMismatch = &errors.BadRequestHttpError{Message: "Request body checksum mismatch"}
+
+// ChecksumConfig holds configuration for the checksum middleware.
+type ChecksumConfig struct {
+	// Required rejects requests without a supported checksum header with 400 Bad Request.
+	// If false, such requests are passed through unverified.
+	Required bool
+}
+
+// DefaultChecksumConfig returns a default checksum configuration, which verifies
+// checksums when the client sends them.
+func DefaultChecksumConfig() *ChecksumConfig {
+	return &ChecksumConfig{
+		Required: false,
+	}
+}
+
+// ChecksumMiddleware returns a middleware function that verifies request bodies against their
+// Content-MD5 (RFC 1864), Digest (RFC 3230; MD5, SHA, SHA-256 and SHA-512) and Content-Digest
+// (RFC 9530; sha-256 and sha-512) headers. Unsupported algorithms are ignored.
+//
+// The body is hashed while the handler reads it, so it is never buffered. Once the whole body is
+// read, a mismatch makes the read fail with ErrChecksumMismatch, which the handler reports like any
+// other bind error, e.g. with c.Error(err). Malformed checksum headers are rejected with 400 Bad Request
+// before the handler runs.
+//
+// Example usage:
+//
+//	s.POST("/ingest", middleware.ChecksumMiddleware(&middleware.ChecksumConfig{Required: true}), ingestHandler)
+func ChecksumMiddleware(config *ChecksumConfig) core.HandlerFunc {
+	if config == nil {
+		config = DefaultChecksumConfig()
+	}
+
+	return func(c core.Context) {
+		req := c.Request()
+		digests, ok := parseChecksumHeaders(req.Header)
+		if !ok {
+			c.JSON(http.StatusBadRequest, errors.NewErrorResponse(http.StatusBadRequest, "Malformed body checksum header"))
+			c.Abort()
+			return
+		}
+		if len(digests) == 0 {
+			if config.Required {
+				c.JSON(http.StatusBadRequest, errors.NewErrorResponse(http.StatusBadRequest, "Missing body checksum header"))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		body := req.Body
+		if body == nil {
+			body = http.NoBody
+		}
+		req.Body = &checksumReader{body: body, digests: digests, remaining: req.ContentLength}
+
+		c.Next()
+	}
+}
+
+// expectedDigest is a checksum sent by the client and the hash computing it over the body.
+type expectedDigest struct {
+	hash hash.Hash
+	sum  []byte
+}
+
+// newDigestHash returns the hash of the Digest or Content-Digest algorithm name, or nil if it is not supported.
+func newDigestHash(name string, contentDigest bool) hash.Hash {
+	switch strings.ToLower(name) {
+	case "sha-256":
+		return sha256.New()
+	case "sha-512":
+		return sha512.New()
+	case "md5":
+		if !contentDigest {
+			return md5.New()
+		}
+	case "sha":
+		if !contentDigest {
+			return sha1.New()
+		}
+	}
+	return nil
+}
+
+// parseChecksumHeaders returns the supported checksums of header.
+// It returns false if a header is malformed.
+func parseChecksumHeaders(header http.Header) ([]*expectedDigest, bool) {
+	var digests []*expectedDigest
+
+	if value := header.Get("Content-MD5"); value != "" {
+		sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil || len(sum) != md5.Size {
+			return nil, false
+		}
+		digests = append(digests, &expectedDigest{hash: md5.New(), sum: sum})
+	}
+
+	for _, contentDigest := range []bool{false, true} {
+		name := "Digest"
+		if contentDigest {
+			name = "Content-Digest"
+		}
+		for _, value := range header.Values(name) {
+			for _, item := range strings.Split(value, ",") {
+				algorithm, encoded, found := strings.Cut(strings.TrimSpace(item), "=")
+				if !found {
+					return nil, false
+				}
+				h := newDigestHash(algorithm, contentDigest)
+				if h == nil {
+					continue
+				}
+				if contentDigest {
+					// Content-Digest values are structured field byte sequences, e.g. :base64:
+					if len(encoded) < 2 || encoded[0] != ':' || encoded[len(encoded)-1] != ':' {
+						return nil, false
+					}
+					encoded = encoded[1 : len(encoded)-1]
+				}
+				sum, err := base64.StdEncoding.DecodeString(encoded)
+				if err != nil || len(sum) != h.Size() {
+					return nil, false
+				}
+				digests = append(digests, &expectedDigest{hash: h, sum: sum})
+			}
+		}
+	}
+
+	return digests, true
+}
+
+// checksumReader hashes a request body while it is read and verifies the checksums
+// once the whole body has been read.
+type checksumReader struct {
+	body      io.ReadCloser
+	digests   []*expectedDigest
+	remaining int64 // Bytes left according to Content-Length, or -1 if unknown
+	verified  bool
+	err       error
+}
+
+// Read implements io.Reader.
+func (r *checksumReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	n, err := r.body.Read(p)
+	for _, digest := range r.digests {
+		digest.hash.Write(p[:n])
+	}
+	if r.remaining >= 0 {
+		r.remaining -= int64(n)
+	}
+
+	// Verify as soon as Content-Length bytes are read, since decoders may not read until io.EOF
+	if !r.verified && (err == io.EOF || r.remaining == 0) {
+		r.verified = true
+		for _, digest := range r.digests {
+			if !bytes.Equal(digest.hash.Sum(nil), digest.sum) {
+				// Withhold the last chunk so that the reader cannot complete without seeing the error
+				r.err = ErrChecksumMismatch
+				return 0, r.err
+			}
+		}
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (r *checksumReader) Close() error {
+	return r.body.Close()
+}
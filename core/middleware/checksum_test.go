@@ -0,0 +1,105 @@
+package middleware_test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestChecksumMiddleware(t *testing.T) {
+	body := []byte(`{"name":"john"}`)
+	md5Sum := md5.Sum(body)
+	sha256Sum := sha256.Sum256(body)
+	contentMD5 := base64.StdEncoding.EncodeToString(md5Sum[:])
+	sha256Digest := base64.StdEncoding.EncodeToString(sha256Sum[:])
+	wrongSum := sha256.Sum256([]byte("other"))
+	wrongDigest := base64.StdEncoding.EncodeToString(wrongSum[:])
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+	}{
+		{"Content-MD5", "Content-MD5", contentMD5, http.StatusOK},
+		{"Digest", "Digest", "SHA-256=" + sha256Digest + ", unixsum=30637", http.StatusOK},
+		{"Content-Digest", "Content-Digest", "sha-256=:" + sha256Digest + ":", http.StatusOK},
+		{"mismatch", "Digest", "sha-256=" + wrongDigest, http.StatusBadRequest},
+		{"malformed", "Content-MD5", "not base64", http.StatusBadRequest},
+		{"missing", "", "", http.StatusBadRequest},
+	}
+
+	for name, newServer := range map[string]func() core.Server{
+		"gin": func() core.Server { return gin.NewServer("8080", false) },
+		"std": func() core.Server { return std.NewServer("8080", false) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := newServer()
+			s.Use(middleware.ChecksumMiddleware(&middleware.ChecksumConfig{Required: true}))
+			s.POST("/ingest", func(c core.Context) {
+				var payload struct {
+					Name string `json:"name"`
+				}
+				if err := c.BindJSON(&payload); err != nil {
+					if !errors.Is(err, middleware.ErrChecksumMismatch) {
+						t.Errorf("BindJSON() error = %v, want ErrChecksumMismatch", err)
+					}
+					c.String(http.StatusBadRequest, err.Error())
+					return
+				}
+				c.String(http.StatusOK, payload.Name)
+			})
+			client := servertest.NewClient(s)
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					req := client.POST("/ingest").WithBody("application/json", body)
+					if tt.header != "" {
+						req = req.WithHeader(tt.header, tt.value)
+					}
+					req.Expect(t).Status(tt.status)
+				})
+			}
+		})
+	}
+}
+
+func TestChecksumMiddlewareStreamedBody(t *testing.T) {
+	s := std.NewServer("8080", false)
+	s.Use(middleware.ChecksumMiddleware(nil))
+	s.POST("/ingest", func(c core.Context) {
+		if _, err := io.ReadAll(c.Request().Body); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+	client := servertest.NewClient(s)
+
+	// Without Content-Length, the checksum is verified at io.EOF
+	req, err := client.POST("/ingest").WithBody("text/plain", []byte("data")).WithHeader("Content-MD5", "AAAAAAAAAAAAAAAAAAAAAA==").Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || rec.Body.String() != middleware.ErrChecksumMismatch.Error() {
+		t.Errorf("got %d %q, want 400 %q", rec.Code, rec.Body.String(), middleware.ErrChecksumMismatch.Error())
+	}
+
+	// Requests without checksum pass through when checksums are optional
+	client.POST("/ingest").WithBody("text/plain", []byte("data")).Expect(t).
+		Status(http.StatusOK)
+}
@@ -0,0 +1,195 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/util"
+)
+
+// ConcurrencyLimitConfig holds configuration for the concurrency limit middleware.
+type ConcurrencyLimitConfig struct {
+	// Limits maps route templates to the maximum number of concurrent executions.
+	// A template is a path pattern ("/reports/:id", "/exports/*") optionally prefixed
+	// with a method ("POST /reports"). Requests that match no template are not limited.
+	Limits map[string]int
+
+	// QueueTimeout is how long a request waits for a free slot before it is rejected.
+	// If zero, requests are rejected immediately when the limit is reached.
+	QueueTimeout time.Duration
+
+	// Optional: custom error message
+	TooManyRequestsMessage string
+}
+
+// DefaultConcurrencyLimitConfig returns a default concurrency limit configuration.
+func DefaultConcurrencyLimitConfig() *ConcurrencyLimitConfig {
+	return &ConcurrencyLimitConfig{
+		Limits:                 make(map[string]int),
+		QueueTimeout:           0, // Reject immediately by default
+		TooManyRequestsMessage: "Too many concurrent requests",
+	}
+}
+
+// routeLimit is a concurrency limit for a single route template.
+type routeLimit struct {
+	template string
+	method   string // Empty for any method
+	path     string
+	match    *util.PathMatcher
+	slots    chan struct{}
+}
+
+// NewConcurrencyLimit returns a middleware function that limits the number of concurrent
+// executions of the route it is registered on.
+// Example usage:
+//
+//	// Only 2 report generations at a time, waiting up to 5 seconds for a slot
+//	s.POST("/reports", middleware.NewConcurrencyLimit(2, 5*time.Second), generateReport)
+func NewConcurrencyLimit(limit int, queueTimeout time.Duration) core.HandlerFunc {
+	if limit <= 0 {
+		panic("NewConcurrencyLimit requires a positive limit")
+	}
+
+	config := DefaultConcurrencyLimitConfig()
+	config.QueueTimeout = queueTimeout
+	slots := make(chan struct{}, limit)
+
+	return func(c core.Context) {
+		acquireAndServe(c, slots, config)
+	}
+}
+
+// ConcurrencyLimitMiddleware returns a middleware function that limits the number of concurrent
+// executions per route template. A request is limited by the most specific template it matches:
+// static segments win over ':param' segments, which win over wildcards, then longer templates
+// over shorter ones. When a limit is reached, requests wait up to QueueTimeout for
+// a free slot and are otherwise rejected with a 429 Too Many Requests response.
+// Example usage:
+//
+//	config := middleware.DefaultConcurrencyLimitConfig()
+//	config.Limits = map[string]int{
+//		"POST /reports": 2,
+//		"/exports/:id":  5,
+//	}
+//	config.QueueTimeout = 3 * time.Second
+//	s.Use(middleware.ConcurrencyLimitMiddleware(config))
+func ConcurrencyLimitMiddleware(config *ConcurrencyLimitConfig) core.HandlerFunc {
+	if config == nil {
+		config = DefaultConcurrencyLimitConfig()
+	}
+
+	limits := make([]*routeLimit, 0, len(config.Limits))
+	for template, limit := range config.Limits {
+		if limit <= 0 {
+			panic("ConcurrencyLimitMiddleware requires positive limits, got " + template)
+		}
+		method, path := parseRouteTemplate(template)
+		limits = append(limits, &routeLimit{
+			template: template,
+			method:   method,
+			path:     path,
+			match:    util.CompilePaths([]string{path}),
+			slots:    make(chan struct{}, limit),
+		})
+	}
+	sort.Slice(limits, func(i, j int) bool {
+		a, b := limits[i], limits[j]
+		if c := compareRouteSpecificity(a.path, b.path); c != 0 {
+			return c > 0
+		}
+		if (a.method != "") != (b.method != "") {
+			return a.method != ""
+		}
+		return a.template < b.template
+	})
+
+	return func(c core.Context) {
+		req := c.Request()
+		for _, limit := range limits {
+			if limit.method != "" && limit.method != req.Method {
+				continue
+			}
+			if limit.match.Match(req.URL.Path) {
+				acquireAndServe(c, limit.slots, config)
+				return
+			}
+		}
+	}
+}
+
+// acquireAndServe waits for a free slot, runs the rest of the chain and releases the slot.
+// If no slot becomes available in time, it responds with 429 Too Many Requests.
+func acquireAndServe(c core.Context, slots chan struct{}, config *ConcurrencyLimitConfig) {
+	select {
+	case slots <- struct{}{}:
+	default:
+		if !waitForSlot(c, slots, config.QueueTimeout) {
+			rejectTooManyRequests(c, config.TooManyRequestsMessage, 0)
+			return
+		}
+	}
+	defer func() { <-slots }()
+
+	// Continue with the next middleware/handler in the chain
+	c.Next()
+}
+
+// waitForSlot waits up to timeout for a free slot. It returns false if the timeout
+// elapses or the request is canceled first.
+func waitForSlot(c core.Context, slots chan struct{}, timeout time.Duration) bool {
+	if timeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-c.Request().Context().Done():
+		return false
+	}
+}
+
+// compareRouteSpecificity returns a positive number if path a is more specific than path b,
+// a negative number if it is less specific and zero otherwise. Paths are compared segment by
+// segment, static segments being more specific than ':param' segments and those more specific
+// than wildcards; if no segment decides, the longer path is the more specific.
+func compareRouteSpecificity(a, b string) int {
+	as, bs := strings.Split(strings.Trim(a, "/"), "/"), strings.Split(strings.Trim(b, "/"), "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := segmentRank(bs[i]) - segmentRank(as[i]); c != 0 {
+			return c
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// segmentRank ranks a path segment by how generic it is: 0 for static segments, 1 for ':param'
+// segments and 2 for wildcards.
+func segmentRank(segment string) int {
+	switch {
+	case strings.ContainsAny(segment, "*?["):
+		return 2
+	case strings.HasPrefix(segment, ":"):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// parseRouteTemplate splits a route template such as "POST /reports" into its method,
+// empty if the template has none, and path.
+func parseRouteTemplate(template string) (method, path string) {
+	if parts := strings.SplitN(template, " ", 2); len(parts) == 2 {
+		return strings.ToUpper(parts[0]), strings.TrimSpace(parts[1])
+	}
+	return "", template
+}
@@ -0,0 +1,144 @@
+package middleware_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestConcurrencyLimitMiddleware(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	config := middleware.DefaultConcurrencyLimitConfig()
+	config.Limits = map[string]int{"POST /reports/:id": 1}
+
+	s := std.NewServer("8080", false)
+	s.Use(middleware.ConcurrencyLimitMiddleware(config))
+	s.POST("/reports/1", func(c core.Context) {
+		started <- struct{}{}
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+	s.GET("/reports/1/status", func(c core.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	client := servertest.NewClient(s)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		client.POST("/reports/1").Expect(t).Status(http.StatusOK)
+	}()
+	<-started
+
+	client.POST("/reports/1").Expect(t).Status(http.StatusTooManyRequests)
+	client.GET("/reports/1/status").Expect(t).Status(http.StatusOK)
+
+	close(release)
+	wg.Wait()
+}
+
+func TestConcurrencyLimitMiddlewareMostSpecificRoute(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	config := middleware.DefaultConcurrencyLimitConfig()
+	config.Limits = map[string]int{
+		"/reports/*":       1,
+		"/reports/:id":     1,
+		"/reports/summary": 2,
+	}
+
+	s := std.NewServer("8080", false)
+	s.Use(middleware.ConcurrencyLimitMiddleware(config))
+	s.GET("/reports/:id", func(c core.Context) {
+		if c.GetHeader("X-Hold") != "" {
+			started <- struct{}{}
+			<-release
+		}
+		c.String(http.StatusOK, "done")
+	})
+	client := servertest.NewClient(s)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		client.GET("/reports/summary").WithHeader("X-Hold", "1").Expect(t).Status(http.StatusOK)
+	}()
+	<-started
+
+	// The summary has its own limit of 2, whatever the order of the map
+	client.GET("/reports/summary").Expect(t).Status(http.StatusOK)
+	client.GET("/reports/1").Expect(t).Status(http.StatusOK)
+
+	close(release)
+	wg.Wait()
+}
+
+func TestIPConcurrencyMiddleware(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	config := middleware.DefaultIPConcurrencyConfig()
+	config.MaxConcurrentRequests = 1
+	config.Allowlist = []string{"10.0.0.0/8"}
+
+	s := std.NewServer("8080", false)
+	// Requests of the test client come from 192.0.2.1; trust it to forward the client IP
+	if err := s.SetTrustedProxies([]string{"192.0.2.1"}); err != nil {
+		t.Fatalf("SetTrustedProxies() returned error: %v", err)
+	}
+	s.Use(middleware.IPConcurrencyMiddleware(config))
+	s.GET("/slow", func(c core.Context) {
+		started <- struct{}{}
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+	s.GET("/fast", func(c core.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	client := servertest.NewClient(s)
+
+	var wg sync.WaitGroup
+	for _, ip := range []string{"203.0.113.7", "10.1.2.3"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.GET("/slow").WithHeader("X-Forwarded-For", ip).Expect(t).Status(http.StatusOK)
+		}()
+		<-started
+	}
+
+	// The client already has a request in flight
+	client.GET("/fast").WithHeader("X-Forwarded-For", "203.0.113.7").Expect(t).
+		Status(http.StatusTooManyRequests)
+	// Other clients and allowlisted load balancers are not affected
+	client.GET("/fast").WithHeader("X-Forwarded-For", "198.51.100.1").Expect(t).Status(http.StatusOK)
+	client.GET("/fast").WithHeader("X-Forwarded-For", "10.1.2.3").Expect(t).Status(http.StatusOK)
+
+	close(release)
+	wg.Wait()
+
+	// The slot is released when the request completes
+	client.GET("/fast").WithHeader("X-Forwarded-For", "203.0.113.7").Expect(t).Status(http.StatusOK)
+}
+
+func TestIPConcurrencyMiddlewareInvalidAllowlist(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an invalid allowlist entry")
+		}
+	}()
+
+	config := middleware.DefaultIPConcurrencyConfig()
+	config.Allowlist = []string{"not-an-ip"}
+	middleware.IPConcurrencyMiddleware(config)
+}
@@ -0,0 +1,35 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import "errors"
+
+// ErrInvalidConfig is wrapped by every ConfigError, for errors.Is checks.
+var ErrInvalidConfig = errors.New("invalid middleware configuration")
+
+// ConfigError reports an invalid middleware configuration, detected when the middleware is
+// created rather than when the first request arrives. The Try variants of the middleware
+// constructors, such as TryAuthMiddleware, return it; the other constructors panic with it.
+type ConfigError struct {
+	// Middleware is the name of the middleware constructor, e.g. "AuthMiddleware"
+	Middleware string
+	// Field is the configuration field at fault, e.g. "JWTSecret"
+	Field string
+	// Problem describes what is wrong with the field
+	Problem string
+	// Remedy describes how to fix the configuration
+	Remedy string
+}
+
+// Error implements the error interface.
+func (e *ConfigError) Error() string {
+	msg := e.Middleware + ": " + e.Field + " " + e.Problem
+	if e.Remedy != "" {
+		msg += "; " + e.Remedy
+	}
+	return msg
+}
+
+// Unwrap returns ErrInvalidConfig.
+func (e *ConfigError) Unwrap() error {
+	return ErrInvalidConfig
+}
@@ -0,0 +1,65 @@
+package middleware_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core/middleware"
+)
+
+type testJWTLookup struct{}
+
+func (testJWTLookup) LookupUserByJWT(claims middleware.MapClaims) (interface{}, error) {
+	return claims["sub"], nil
+}
+
+func TestTryAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *middleware.AuthConfig
+		field  string
+	}{
+		{"missing JWT lookup", &middleware.AuthConfig{AuthType: middleware.AuthTypeJWT, JWTSecret: "secret"}, "JWTLookup"},
+		{"missing JWT secret", &middleware.AuthConfig{AuthType: middleware.AuthTypeJWT, JWTLookup: testJWTLookup{}}, "JWTSecret"},
+		{"missing basic lookup", &middleware.AuthConfig{AuthType: middleware.AuthTypeBasic}, "BasicAuthLookup"},
+		{"unknown auth type", &middleware.AuthConfig{AuthType: "digest"}, "AuthType"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, err := middleware.TryAuthMiddleware(tt.config)
+			if handler != nil {
+				t.Error("TryAuthMiddleware returned a handler for an invalid configuration")
+			}
+			var configErr *middleware.ConfigError
+			if !errors.As(err, &configErr) || !errors.Is(err, middleware.ErrInvalidConfig) {
+				t.Fatalf("TryAuthMiddleware returned %v, want a *ConfigError", err)
+			}
+			if configErr.Field != tt.field || configErr.Remedy == "" {
+				t.Errorf("ConfigError = %+v, want field %s with a remedy", configErr, tt.field)
+			}
+		})
+	}
+
+	handler, err := middleware.TryAuthMiddleware(&middleware.AuthConfig{
+		AuthType:  middleware.AuthTypeJWT,
+		JWTLookup: testJWTLookup{},
+		JWTSecret: "secret",
+	})
+	if handler == nil || err != nil {
+		t.Errorf("TryAuthMiddleware returned %v for a valid configuration", err)
+	}
+}
+
+func TestAPIKeyMiddlewarePanicsWithConfigError(t *testing.T) {
+	if _, err := middleware.TryAPIKeyMiddleware(middleware.DefaultAPIKeyConfig()); !errors.Is(err, middleware.ErrInvalidConfig) {
+		t.Errorf("TryAPIKeyMiddleware returned %v, want ErrInvalidConfig", err)
+	}
+
+	defer func() {
+		err, ok := recover().(*middleware.ConfigError)
+		if !ok || err.Field != "APIKey" {
+			t.Errorf("APIKeyMiddleware panicked with %v, want a *ConfigError for APIKey", err)
+		}
+	}()
+	middleware.APIKeyMiddleware(middleware.DefaultAPIKeyConfig())
+}
@@ -3,9 +3,12 @@ package middleware
 
 import (
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/util"
 )
 
 // CORSConfig holds configuration for the CORS middleware.
@@ -29,6 +32,17 @@ type CORSConfig struct {
 	// MaxAge indicates how long (in seconds) the results of a preflight request can be cached.
 	// Default: 86400 (24 hours)
 	MaxAge int
+
+	// Optional: records every decision, e.g. to serve on a debug endpoint with Stats.Handler
+	Stats *CORSStats
+
+	// Routes overrides the policy for route templates, e.g. different allowed origins for
+	// "/public/*" and "/partner/*". A template is a path pattern ("/partner/:id", "/partner/*")
+	// optionally prefixed with a method ("POST /partner/orders"); "*" matches a single path segment.
+	// When several templates match, the most specific one wins: templates without wildcards,
+	// then longer paths, then fewer params, then method-qualified templates. Requests that match
+	// no template use this configuration. The Stats and Routes fields of route policies are ignored.
+	Routes map[string]*CORSConfig
 }
 
 // DefaultCORSConfig returns a default CORS configuration.
@@ -60,10 +74,12 @@ func NewDefaultCORSMiddleware() core.HandlerFunc {
 // CORSMiddleware returns a middleware function that handles CORS (Cross-Origin Resource Sharing).
 // If AllowedDomains is empty, all domains are allowed.
 // If AllowedDomains contains specific domains, only those domains are allowed.
+// Requests matching a route template of Routes use that policy instead; see CORSConfig.Routes.
 func CORSMiddleware(config *CORSConfig) core.HandlerFunc {
 	if config == nil {
 		config = DefaultCORSConfig()
 	}
+	policies := newCORSRoutePolicies(config.Routes)
 
 	return func(c core.Context) {
 		origin := c.GetHeader("Origin")
@@ -72,12 +88,22 @@ func CORSMiddleware(config *CORSConfig) core.HandlerFunc {
 			return
 		}
 
+		policy, policyName := config, ""
+		req := c.Request()
+		for _, routePolicy := range policies {
+			if routePolicy.matches(req) {
+				policy, policyName = routePolicy.config, routePolicy.template
+				break
+			}
+		}
+		preflight := req.Method == "OPTIONS"
+
 		// Check if the origin is allowed
 		allowOrigin := "*" // Default to allow all
-		if len(config.AllowedDomains) > 0 {
+		if len(policy.AllowedDomains) > 0 {
 			// Check if the origin is in the allowed domains list
 			allowed := false
-			for _, domain := range config.AllowedDomains {
+			for _, domain := range policy.AllowedDomains {
 				if domain == origin {
 					allowed = true
 					allowOrigin = origin // Set the specific origin
@@ -86,24 +112,30 @@ func CORSMiddleware(config *CORSConfig) core.HandlerFunc {
 			}
 
 			if !allowed {
+				recordCORSDecision(c, config.Stats, origin, preflight, policyName, "")
 				// Origin not allowed, continue without setting CORS headers
 				return
 			}
 		}
+		recordCORSDecision(c, config.Stats, origin, preflight, policyName, allowOrigin)
 
 		// Set CORS headers
 		c.SetHeader("Access-Control-Allow-Origin", allowOrigin)
-		c.SetHeader("Access-Control-Allow-Methods", config.AllowedMethods)
-		c.SetHeader("Access-Control-Allow-Headers", config.AllowedHeaders)
+		if allowOrigin != "*" {
+			// The response depends on the origin, so caches must not share it across origins
+			c.AddHeader("Vary", "Origin")
+		}
+		c.SetHeader("Access-Control-Allow-Methods", policy.AllowedMethods)
+		c.SetHeader("Access-Control-Allow-Headers", policy.AllowedHeaders)
 
-		if config.AllowCredentials {
+		if policy.AllowCredentials {
 			c.SetHeader("Access-Control-Allow-Credentials", "true")
 		}
 
-		c.SetHeader("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
+		c.SetHeader("Access-Control-Max-Age", strconv.Itoa(policy.MaxAge))
 
 		// Handle preflight requests
-		if c.Request().Method == "OPTIONS" {
+		if preflight {
 			c.SetStatus(http.StatusOK)
 			c.Abort()
 			return
@@ -112,3 +144,84 @@ func CORSMiddleware(config *CORSConfig) core.HandlerFunc {
 		// Continue with the next middleware/handler in the chain
 	}
 }
+
+// corsRoutePolicy is the CORS policy of a route template.
+type corsRoutePolicy struct {
+	template string
+	method   string // Empty for any method
+	path     string
+	matcher  *util.PathMatcher
+	config   *CORSConfig
+}
+
+// matches reports whether the policy applies to req. Preflight requests are matched
+// by the method they announce in Access-Control-Request-Method.
+func (p *corsRoutePolicy) matches(req *http.Request) bool {
+	if p.method != "" {
+		method := req.Method
+		if method == "OPTIONS" && req.Header.Get("Access-Control-Request-Method") != "" {
+			method = strings.ToUpper(req.Header.Get("Access-Control-Request-Method"))
+		}
+		if method != p.method {
+			return false
+		}
+	}
+	return p.matcher.Match(req.URL.Path)
+}
+
+// newCORSRoutePolicies returns the policies of routes, most specific first: templates without
+// wildcards before those with wildcards, then longer paths, fewer params and method-qualified
+// templates first.
+func newCORSRoutePolicies(routes map[string]*CORSConfig) []*corsRoutePolicy {
+	policies := make([]*corsRoutePolicy, 0, len(routes))
+	for template, config := range routes {
+		if config == nil {
+			panic("CORSMiddleware requires a CORSConfig for every route, got nil for " + template)
+		}
+		method, path := parseRouteTemplate(template)
+		policies = append(policies, &corsRoutePolicy{
+			template: template,
+			method:   method,
+			path:     path,
+			matcher:  util.CompilePaths([]string{path}),
+			config:   config,
+		})
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		a, b := policies[i], policies[j]
+		if aw, bw := strings.ContainsAny(a.path, "*?["), strings.ContainsAny(b.path, "*?["); aw != bw {
+			return !aw
+		}
+		if as, bs := strings.Count(a.path, "/"), strings.Count(b.path, "/"); as != bs {
+			return as > bs
+		}
+		if ap, bp := strings.Count(a.path, ":"), strings.Count(b.path, ":"); ap != bp {
+			return ap < bp
+		}
+		if (a.method != "") != (b.method != "") {
+			return a.method != ""
+		}
+		return a.template < b.template
+	})
+	return policies
+}
+
+// recordCORSDecision records a decision in stats, if set. policy is the route template of the policy
+// applied, empty for the global policy. An empty matchedRule means the origin was blocked.
+func recordCORSDecision(c core.Context, stats *CORSStats, origin string, preflight bool, policy, matchedRule string) {
+	if stats == nil {
+		return
+	}
+	req := c.Request()
+	stats.Record(CORSDecision{
+		Time:        core.ClockFromContext(req.Context()).Now(),
+		Origin:      origin,
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		Preflight:   preflight,
+		Policy:      policy,
+		MatchedRule: matchedRule,
+		Allowed:     matchedRule != "",
+	})
+}
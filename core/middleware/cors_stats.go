@@ -0,0 +1,121 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// DefaultCORSDebugPath is the default path of the CORS debug endpoint.
+const DefaultCORSDebugPath = "/debug/cors"
+
+// DefaultCORSStatsCapacity is the default number of recent decisions kept by CORSStats.
+const DefaultCORSStatsCapacity = 100
+
+// CORSDecision describes how the CORS middleware handled a cross-origin request.
+type CORSDecision struct {
+	Time      time.Time `json:"time"`
+	Origin    string    `json:"origin"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Preflight bool      `json:"preflight"`
+	// Policy is the route template of the CORSConfig.Routes policy applied, empty for the global policy
+	Policy string `json:"policy,omitempty"`
+	// MatchedRule is the AllowedDomains entry that matched the origin, or "*" if all domains
+	// are allowed. It is empty for blocked requests.
+	MatchedRule string `json:"matched_rule,omitempty"`
+	Allowed     bool   `json:"allowed"`
+}
+
+// CORSStatsSnapshot is a point-in-time view of CORSStats.
+type CORSStatsSnapshot struct {
+	Allowed    int64 `json:"allowed"`
+	Blocked    int64 `json:"blocked"`
+	Preflights int64 `json:"preflights"`
+	// Recent holds the most recent decisions, newest first
+	Recent []CORSDecision `json:"recent"`
+}
+
+// CORSStats records the decisions of a CORS middleware, to troubleshoot blocked origins
+// without packet captures. It keeps counters and a fixed number of recent decisions.
+// Set it as CORSConfig.Stats and serve it with Handler.
+type CORSStats struct {
+	mu         sync.Mutex
+	recent     []CORSDecision // Ring buffer of recent decisions
+	next       int            // Index of the next decision to write in recent
+	full       bool           // Whether recent has wrapped around
+	allowed    int64
+	blocked    int64
+	preflights int64
+}
+
+// NewCORSStats returns a CORSStats keeping the given number of recent decisions.
+// If capacity is not positive, DefaultCORSStatsCapacity is used.
+func NewCORSStats(capacity int) *CORSStats {
+	if capacity <= 0 {
+		capacity = DefaultCORSStatsCapacity
+	}
+	return &CORSStats{recent: make([]CORSDecision, capacity)}
+}
+
+// Record adds a decision to the statistics. It is called by the CORS middleware.
+func (s *CORSStats) Record(decision CORSDecision) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if decision.Allowed {
+		s.allowed++
+	} else {
+		s.blocked++
+	}
+	if decision.Preflight {
+		s.preflights++
+	}
+
+	s.recent[s.next] = decision
+	s.next = (s.next + 1) % len(s.recent)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// Snapshot returns the current counters and recent decisions.
+func (s *CORSStats) Snapshot() CORSStatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := s.next
+	if s.full {
+		count = len(s.recent)
+	}
+	recent := make([]CORSDecision, 0, count)
+	for i := 1; i <= count; i++ {
+		recent = append(recent, s.recent[(s.next-i+len(s.recent))%len(s.recent)])
+	}
+
+	return CORSStatsSnapshot{
+		Allowed:    s.allowed,
+		Blocked:    s.blocked,
+		Preflights: s.preflights,
+		Recent:     recent,
+	}
+}
+
+// Handler returns a handler serving the snapshot as JSON. The decisions reveal the origins
+// and paths of recent requests, so protect the endpoint or only enable it while debugging.
+//
+// Example usage:
+//
+//	stats := middleware.NewCORSStats(0)
+//	config := middleware.DefaultCORSConfig()
+//	config.Stats = stats
+//	s.Use(middleware.CORSMiddleware(config))
+//	s.GET(middleware.DefaultCORSDebugPath, stats.Handler())
+func (s *CORSStats) Handler() core.HandlerFunc {
+	return func(c core.Context) {
+		c.JSON(http.StatusOK, s.Snapshot())
+	}
+}
@@ -0,0 +1,132 @@
+package middleware_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestCORSStats(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			stats := middleware.NewCORSStats(2)
+			config := middleware.DefaultCORSConfig()
+			config.AllowedDomains = []string{"https://app.example.com"}
+			config.Stats = stats
+			s.Use(middleware.CORSMiddleware(config))
+			s.GET("/items", func(c core.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+			s.GET(middleware.DefaultCORSDebugPath, stats.Handler())
+			client := servertest.NewClient(s)
+
+			client.GET("/items").WithHeader("Origin", "https://app.example.com").Expect(t).
+				Header("Access-Control-Allow-Origin", "https://app.example.com")
+			client.GET("/items").WithHeader("Origin", "https://evil.example.com").Expect(t).
+				Header("Access-Control-Allow-Origin", "")
+			client.Request(http.MethodOptions, "/items").WithHeader("Origin", "https://app.example.com").Expect(t).
+				Status(http.StatusOK)
+			// Same-origin requests are not CORS decisions
+			client.GET("/items").Expect(t).Status(http.StatusOK)
+
+			snapshot := stats.Snapshot()
+			if snapshot.Allowed != 2 || snapshot.Blocked != 1 || snapshot.Preflights != 1 {
+				t.Errorf("counters = %d allowed, %d blocked, %d preflights, want 2, 1, 1",
+					snapshot.Allowed, snapshot.Blocked, snapshot.Preflights)
+			}
+			// Only the two most recent decisions are kept, newest first
+			if len(snapshot.Recent) != 2 {
+				t.Fatalf("kept %d decisions, want 2", len(snapshot.Recent))
+			}
+			if !snapshot.Recent[0].Preflight || snapshot.Recent[0].MatchedRule != "https://app.example.com" {
+				t.Errorf("Recent[0] = %+v, want the allowed preflight", snapshot.Recent[0])
+			}
+			if snapshot.Recent[1].Allowed || snapshot.Recent[1].Origin != "https://evil.example.com" || snapshot.Recent[1].Path != "/items" {
+				t.Errorf("Recent[1] = %+v, want the blocked request", snapshot.Recent[1])
+			}
+
+			client.GET(middleware.DefaultCORSDebugPath).Expect(t).
+				Status(http.StatusOK).
+				JSONPath("$.blocked", 1).
+				JSONPath("$.recent[1].origin", "https://evil.example.com").
+				JSONPath("$.recent[1].allowed", false)
+		})
+	}
+}
+
+func TestCORSRoutePolicies(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			stats := middleware.NewCORSStats(0)
+			config := middleware.DefaultCORSConfig()
+			config.AllowedDomains = []string{"https://app.example.com"}
+			config.Stats = stats
+			partner := middleware.DefaultCORSConfig()
+			partner.AllowedDomains = []string{"https://partner.example.com"}
+			partnerAdmin := middleware.DefaultCORSConfig()
+			partnerAdmin.AllowedDomains = []string{"https://admin.partner.example.com"}
+			partnerOrders := middleware.DefaultCORSConfig()
+			partnerOrders.AllowedDomains = []string{"https://orders.partner.example.com"}
+			config.Routes = map[string]*middleware.CORSConfig{
+				"/public/*":            middleware.DefaultCORSConfig(), // All origins
+				"/partner/*":           partner,
+				"/partner/admin":       partnerAdmin,
+				"POST /partner/orders": partnerOrders,
+			}
+			s.Use(middleware.CORSMiddleware(config))
+			for _, path := range []string{"/items", "/public/docs", "/partner/items", "/partner/admin"} {
+				s.GET(path, func(c core.Context) {
+					c.String(http.StatusOK, "ok")
+				})
+			}
+			s.POST("/partner/orders", func(c core.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+			client := servertest.NewClient(s)
+
+			tests := []struct {
+				method string
+				path   string
+				origin string
+				want   string
+			}{
+				{http.MethodGet, "/items", "https://app.example.com", "https://app.example.com"},
+				{http.MethodGet, "/items", "https://partner.example.com", ""},
+				{http.MethodGet, "/public/docs", "https://anyone.example.com", "*"},
+				{http.MethodGet, "/partner/items", "https://partner.example.com", "https://partner.example.com"},
+				{http.MethodGet, "/partner/items", "https://app.example.com", ""},
+				// Static templates win over wildcards
+				{http.MethodGet, "/partner/admin", "https://admin.partner.example.com", "https://admin.partner.example.com"},
+				{http.MethodGet, "/partner/admin", "https://partner.example.com", ""},
+				// Method-qualified templates win over templates for any method
+				{http.MethodPost, "/partner/orders", "https://orders.partner.example.com", "https://orders.partner.example.com"},
+			}
+			for _, tt := range tests {
+				client.Request(tt.method, tt.path).WithHeader("Origin", tt.origin).Expect(t).
+					Header("Access-Control-Allow-Origin", tt.want)
+			}
+
+			// Preflights are matched by the method they announce
+			client.Request(http.MethodOptions, "/partner/orders").
+				WithHeader("Origin", "https://orders.partner.example.com").
+				WithHeader("Access-Control-Request-Method", "POST").
+				Expect(t).
+				Status(http.StatusOK).
+				Header("Access-Control-Allow-Origin", "https://orders.partner.example.com")
+
+			if recent := stats.Snapshot().Recent[0]; recent.Policy != "POST /partner/orders" {
+				t.Errorf("Policy = %q, want POST /partner/orders", recent.Policy)
+			}
+			client.Request(http.MethodOptions, "/partner/orders").
+				WithHeader("Origin", "https://partner.example.com").
+				WithHeader("Access-Control-Request-Method", "GET").
+				Expect(t).
+				Header("Access-Control-Allow-Origin", "https://partner.example.com")
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package middleware
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// DigestAlgorithm is a hash algorithm of the response digest middleware.
+type DigestAlgorithm string
+
+const (
+	// DigestMD5 sends the MD5 of the body in the Content-MD5 (RFC 1864) and Digest headers.
+	DigestMD5 DigestAlgorithm = "md5"
+	// DigestSHA256 sends the SHA-256 of the body in the Digest (RFC 3230) and Content-Digest (RFC 9530) headers.
+	DigestSHA256 DigestAlgorithm = "sha-256"
+	// DigestSHA512 sends the SHA-512 of the body in the Digest and Content-Digest headers.
+	DigestSHA512 DigestAlgorithm = "sha-512"
+)
+
+// responseDigestKey is the context key of the response digest configuration of a request.
+const responseDigestKey = "response_digest"
+
+// maxCachedFileDigests limits the number of files whose digests are cached by a response digest middleware.
+const maxCachedFileDigests = 1024
+
+// newHash returns the hash of the algorithm, or nil if it is not supported.
+func (a DigestAlgorithm) newHash() hash.Hash {
+	switch a {
+	case DigestMD5:
+		return md5.New()
+	case DigestSHA256:
+		return sha256.New()
+	case DigestSHA512:
+		return sha512.New()
+	}
+	return nil
+}
+
+// ResponseDigestConfig holds configuration for the response digest middleware.
+type ResponseDigestConfig struct {
+	// Algorithms are the hash algorithms whose digests are sent, in order.
+	Algorithms []DigestAlgorithm
+}
+
+// DefaultResponseDigestConfig returns a default response digest configuration, which sends SHA-256 digests.
+func DefaultResponseDigestConfig() *ResponseDigestConfig {
+	return &ResponseDigestConfig{
+		Algorithms: []DigestAlgorithm{DigestSHA256},
+	}
+}
+
+// Validate checks that the configuration has supported algorithms, returning a *ConfigError if not.
+func (config *ResponseDigestConfig) Validate() error {
+	if len(config.Algorithms) == 0 {
+		return &ConfigError{
+			Middleware: "ResponseDigest",
+			Field:      "Algorithms",
+			Problem:    "must not be empty",
+			Remedy:     "use DefaultResponseDigestConfig, or set Algorithms to e.g. DigestSHA256",
+		}
+	}
+	for _, algorithm := range config.Algorithms {
+		if algorithm.newHash() == nil {
+			return &ConfigError{
+				Middleware: "ResponseDigest",
+				Field:      "Algorithms",
+				Problem:    fmt.Sprintf("%q is not supported", algorithm),
+				Remedy:     "use DigestMD5, DigestSHA256 or DigestSHA512",
+			}
+		}
+	}
+	return nil
+}
+
+// responseDigest computes the digests of the responses of a response digest middleware.
+type responseDigest struct {
+	algorithms []DigestAlgorithm
+
+	mu    sync.Mutex
+	files map[string]fileDigest
+}
+
+// fileDigest is the cached digest of a file, valid as long as its size and modification time don't change.
+type fileDigest struct {
+	size    int64
+	modTime time.Time
+	sums    [][]byte
+}
+
+// ResponseDigestMiddleware returns a middleware function that adds digests of file and byte responses,
+// so clients can verify the integrity of downloads:
+//   - c.File hashes the file before serving it, streaming it through the hashes without loading it into
+//     memory. Digests are cached until the size or modification time of the file changes.
+//     Range requests are served without digests, since the body is only part of the file.
+//   - Data hashes the bytes before writing them.
+//   - DataFromReader hashes io.ReadSeeker readers, e.g. files, before rewinding and sending them.
+//     Other readers are hashed while they are streamed, and the digests are sent as HTTP trailers,
+//     which the Fiber adapter does not support.
+//
+// Other responses, e.g. JSON, are left unchanged. It panics if the configuration is invalid;
+// use TryResponseDigestMiddleware to handle the error instead.
+//
+// Example usage:
+//
+//	s.GET("/downloads/:name", middleware.ResponseDigestMiddleware(nil), func(c core.Context) {
+//		c.File(filepath.Join(downloadDir, filepath.Base(c.Param("name"))))
+//	})
+func ResponseDigestMiddleware(config *ResponseDigestConfig) core.HandlerFunc {
+	handler, err := TryResponseDigestMiddleware(config)
+	if err != nil {
+		panic(err)
+	}
+	return handler
+}
+
+// TryResponseDigestMiddleware is like ResponseDigestMiddleware, but returns a *ConfigError if the configuration is invalid.
+func TryResponseDigestMiddleware(config *ResponseDigestConfig) (core.HandlerFunc, error) {
+	if config == nil {
+		config = DefaultResponseDigestConfig()
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	digest := &responseDigest{
+		algorithms: append([]DigestAlgorithm(nil), config.Algorithms...),
+		files:      make(map[string]fileDigest),
+	}
+	return func(c core.Context) {
+		c.Set(responseDigestKey, digest)
+		c.Next()
+	}, nil
+}
+
+// responseDigestOf returns the response digest configuration of the request, or nil if the
+// response digest middleware is not registered.
+func responseDigestOf(c core.Context) *responseDigest {
+	value, ok := c.Get(responseDigestKey)
+	if !ok {
+		return nil
+	}
+	digest, _ := value.(*responseDigest)
+	return digest
+}
+
+// newHashes returns a hash for every algorithm.
+func (d *responseDigest) newHashes() []hash.Hash {
+	hashes := make([]hash.Hash, len(d.algorithms))
+	for i, algorithm := range d.algorithms {
+		hashes[i] = algorithm.newHash()
+	}
+	return hashes
+}
+
+// sums returns the sums of hashes.
+func sums(hashes []hash.Hash) [][]byte {
+	result := make([][]byte, len(hashes))
+	for i, h := range hashes {
+		result[i] = h.Sum(nil)
+	}
+	return result
+}
+
+// setHeaders sets the Content-MD5, Digest and Content-Digest headers of sums, one per algorithm.
+func (d *responseDigest) setHeaders(header http.Header, sums [][]byte) {
+	var digests, contentDigests []string
+	for i, algorithm := range d.algorithms {
+		encoded := base64.StdEncoding.EncodeToString(sums[i])
+		switch algorithm {
+		case DigestMD5:
+			header.Set("Content-MD5", encoded)
+			digests = append(digests, "MD5="+encoded)
+		default:
+			digests = append(digests, strings.ToUpper(string(algorithm))+"="+encoded)
+			contentDigests = append(contentDigests, string(algorithm)+"=:"+encoded+":")
+		}
+	}
+	header.Set("Digest", strings.Join(digests, ","))
+	if len(contentDigests) > 0 {
+		header.Set("Content-Digest", strings.Join(contentDigests, ", "))
+	}
+}
+
+// hashReader reads r to the end through new hashes and returns their sums.
+func (d *responseDigest) hashReader(r io.Reader) ([][]byte, error) {
+	hashes := d.newHashes()
+	writers := make([]io.Writer, len(hashes))
+	for i, h := range hashes {
+		writers[i] = h
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), r); err != nil {
+		return nil, err
+	}
+	return sums(hashes), nil
+}
+
+// fileSums returns the sums of the file at path, from the cache if the file has not changed.
+func (d *responseDigest) fileSums(path string) ([][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	d.mu.Lock()
+	cached, ok := d.files[path]
+	d.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.sums, nil
+	}
+
+	fileSums, err := d.hashReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	if len(d.files) >= maxCachedFileDigests {
+		// Start over rather than tracking usage, as downloads rarely come from that many files
+		d.files = make(map[string]fileDigest)
+	}
+	d.files[path] = fileDigest{size: info.Size(), modTime: info.ModTime(), sums: fileSums}
+	d.mu.Unlock()
+	return fileSums, nil
+}
+
+// SetFileDigestHeaders sets the digest headers of the file at path if the response digest middleware
+// is registered for the request and the whole file is requested. It is called by the File
+// implementations of the adapters before serving the file. Files that cannot be read are left
+// to the file server, which responds with the appropriate error.
+func SetFileDigestHeaders(c core.Context, path string) {
+	digest := responseDigestOf(c)
+	if digest == nil || c.GetHeader("Range") != "" {
+		return
+	}
+	fileSums, err := digest.fileSums(path)
+	if err != nil {
+		return
+	}
+	digest.setHeaders(c.Writer().Header(), fileSums)
+}
+
+// Data writes data as the response body with status code and content type, with digest headers if
+// the response digest middleware is registered for the request.
+func Data(c core.Context, code int, contentType string, data []byte) {
+	header := c.Writer().Header()
+	if digest := responseDigestOf(c); digest != nil {
+		hashes := digest.newHashes()
+		for _, h := range hashes {
+			h.Write(data)
+		}
+		digest.setHeaders(header, sums(hashes))
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Length", strconv.Itoa(len(data)))
+	c.SetStatus(code)
+	_, _ = c.Writer().Write(data)
+}
+
+// DataFromReader streams r as the response body with status code and content type. contentLength is
+// the size of the body, or -1 if it is unknown, in which case the response is chunked.
+// If the response digest middleware is registered for the request, digests are sent as headers for
+// io.ReadSeeker readers, which are read twice, and as trailers of a chunked response for other readers.
+// It is used by Context.DataFromReader implementations. It returns the error of reading r or writing
+// the response; the status may already have been sent.
+func DataFromReader(c core.Context, code int, contentLength int64, contentType string, r io.Reader) error {
+	header := c.Writer().Header()
+	header.Set("Content-Type", contentType)
+	if contentLength >= 0 {
+		header.Set("Content-Length", strconv.FormatInt(contentLength, 10))
+	}
+
+	digest := responseDigestOf(c)
+	if digest == nil {
+		c.SetStatus(code)
+		_, err := io.Copy(c.Writer(), r)
+		return err
+	}
+
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		readerSums, err := digest.hashReader(seeker)
+		if err != nil {
+			return err
+		}
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		digest.setHeaders(header, readerSums)
+		c.SetStatus(code)
+		_, err = io.Copy(c.Writer(), seeker)
+		return err
+	}
+
+	// Announce the trailers before the header is sent, and set them once the body is written.
+	// Trailers are only sent with chunked responses, so the length is dropped.
+	trailers := []string{"Digest"}
+	for _, algorithm := range digest.algorithms {
+		if algorithm == DigestMD5 {
+			trailers = append(trailers, "Content-MD5")
+		} else if !slices.Contains(trailers, "Content-Digest") {
+			trailers = append(trailers, "Content-Digest")
+		}
+	}
+	header.Del("Content-Length")
+	header.Set("Trailer", strings.Join(trailers, ", "))
+	c.SetStatus(code)
+
+	hashes := digest.newHashes()
+	writers := []io.Writer{c.Writer()}
+	for _, h := range hashes {
+		writers = append(writers, h)
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), r); err != nil {
+		return err
+	}
+	digest.setHeaders(header, sums(hashes))
+	return nil
+}
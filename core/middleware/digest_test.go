@@ -0,0 +1,139 @@
+package middleware_test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestResponseDigestMiddleware(t *testing.T) {
+	content := []byte("release-1.2.3 binary contents")
+	md5Sum := md5.Sum(content)
+	sha256Sum := sha256.Sum256(content)
+	contentMD5 := base64.StdEncoding.EncodeToString(md5Sum[:])
+	sha256Digest := base64.StdEncoding.EncodeToString(sha256Sum[:])
+
+	file := filepath.Join(t.TempDir(), "release.bin")
+	if err := os.WriteFile(file, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			digests := middleware.ResponseDigestMiddleware(&middleware.ResponseDigestConfig{
+				Algorithms: []middleware.DigestAlgorithm{middleware.DigestSHA256, middleware.DigestMD5},
+			})
+			s.GET("/file", digests, func(c core.Context) {
+				c.File(file)
+			})
+			s.GET("/data", digests, func(c core.Context) {
+				middleware.Data(c, http.StatusOK, "application/octet-stream", content)
+			})
+			s.GET("/seeker", digests, func(c core.Context) {
+				_ = middleware.DataFromReader(c, http.StatusOK, int64(len(content)), "application/octet-stream", bytes.NewReader(content))
+			})
+			s.GET("/stream", digests, func(c core.Context) {
+				_ = middleware.DataFromReader(c, http.StatusOK, -1, "application/octet-stream", io.MultiReader(bytes.NewReader(content)))
+			})
+			s.GET("/plain", func(c core.Context) {
+				c.File(file)
+			})
+			client := servertest.NewClient(s)
+
+			for _, path := range []string{"/file", "/data", "/seeker"} {
+				client.GET(path).Expect(t).
+					Status(http.StatusOK).
+					Body(string(content)).
+					Header("Content-MD5", contentMD5).
+					Header("Digest", "SHA-256="+sha256Digest+",MD5="+contentMD5).
+					Header("Content-Digest", "sha-256=:"+sha256Digest+":")
+			}
+
+			// Served from the cache
+			client.GET("/file").Expect(t).Header("Content-Digest", "sha-256=:"+sha256Digest+":")
+
+			// Part of the file
+			client.GET("/file").WithHeader("Range", "bytes=0-6").Expect(t).
+				Status(http.StatusPartialContent).
+				Body("release").
+				Header("Digest", "")
+
+			// Streams send the digests as trailers
+			result := client.GET("/stream").Expect(t).Status(http.StatusOK).Body(string(content)).Recorder().Result()
+			if got := result.Trailer.Get("Content-Digest"); got != "sha-256=:"+sha256Digest+":" {
+				t.Errorf("Content-Digest trailer = %q, want %q", got, "sha-256=:"+sha256Digest+":")
+			}
+			if got := result.Trailer.Get("Content-MD5"); got != contentMD5 {
+				t.Errorf("Content-MD5 trailer = %q, want %q", got, contentMD5)
+			}
+
+			client.GET("/plain").Expect(t).
+				Status(http.StatusOK).
+				Header("Digest", "").
+				Header("Content-MD5", "")
+		})
+	}
+}
+
+func TestResponseDigestStreamTrailers(t *testing.T) {
+	content := []byte("export contents")
+	s := std.NewServer("8080", false)
+	s.GET("/stream", middleware.ResponseDigestMiddleware(&middleware.ResponseDigestConfig{
+		Algorithms: []middleware.DigestAlgorithm{middleware.DigestMD5, middleware.DigestSHA256, middleware.DigestSHA512},
+	}), func(c core.Context) {
+		_ = middleware.DataFromReader(c, http.StatusOK, -1, "text/csv", io.MultiReader(bytes.NewReader(content)))
+	})
+
+	// Content-Digest carries both SHA digests, so it is announced once
+	servertest.NewClient(s).GET("/stream").Expect(t).
+		Status(http.StatusOK).
+		Body(string(content)).
+		Header("Trailer", "Digest, Content-MD5, Content-Digest")
+}
+
+func TestResponseDigestFileChange(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(file, []byte("a,b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := std.NewServer("8080", false)
+	s.GET("/report", middleware.ResponseDigestMiddleware(nil), func(c core.Context) {
+		c.File(file)
+	})
+	client := servertest.NewClient(s)
+	client.GET("/report").Expect(t).Status(http.StatusOK)
+
+	// A new size invalidates the cached digest
+	updated := []byte("a,b\n1,2\n")
+	if err := os.WriteFile(file, updated, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(updated)
+	client.GET("/report").Expect(t).
+		Body(string(updated)).
+		Header("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+}
+
+func TestResponseDigestValidation(t *testing.T) {
+	_, err := middleware.TryResponseDigestMiddleware(&middleware.ResponseDigestConfig{
+		Algorithms: []middleware.DigestAlgorithm{"crc32"},
+	})
+	var configErr *middleware.ConfigError
+	if !errors.As(err, &configErr) || configErr.Field != "Algorithms" {
+		t.Fatalf("TryResponseDigestMiddleware() error = %v, want a *ConfigError for Algorithms", err)
+	}
+}
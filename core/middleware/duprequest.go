@@ -71,6 +71,8 @@ func NewDefaultDuplicateRequestMiddleware() core.HandlerFunc {
 // DuplicateRequestMiddleware returns a middleware function that prevents duplicate requests
 // It generates a request ID using the provided generator, checks if it exists in the storage,
 // and if it does, returns a 409 Conflict response. Otherwise, it saves the ID and continues.
+// Rejected requests, duplicates as well as requests whose ID could not be generated, checked or
+// saved, abort the chain, so the handler does not run for them.
 func DuplicateRequestMiddleware(config *DuplicateRequestConfig) core.HandlerFunc {
 	if config == nil {
 		config = DefaultDuplicateRequestConfig()
@@ -94,6 +96,7 @@ func DuplicateRequestMiddleware(config *DuplicateRequestConfig) core.HandlerFunc
 		if err != nil {
 			// If we can't generate a request ID, return an internal server error
 			c.JSON(http.StatusInternalServerError, errors.NewInternalServerErrorResponse("Failed to generate request ID"))
+			c.Abort()
 			return
 		}
 
@@ -102,12 +105,14 @@ func DuplicateRequestMiddleware(config *DuplicateRequestConfig) core.HandlerFunc
 		if err != nil {
 			// If we can't check the request ID, return an internal server error
 			c.JSON(http.StatusInternalServerError, errors.NewInternalServerErrorResponse("Failed to check request ID"))
+			c.Abort()
 			return
 		}
 
 		// If the request ID exists, return a conflict error
 		if exists {
 			c.JSON(http.StatusConflict, errors.NewConflictResponse(config.ConflictMessage))
+			c.Abort()
 			return
 		}
 
@@ -115,6 +120,7 @@ func DuplicateRequestMiddleware(config *DuplicateRequestConfig) core.HandlerFunc
 		if err := config.RequestIDStorage.SaveRequestID(requestID); err != nil {
 			// If we can't save the request ID, return an internal server error
 			c.JSON(http.StatusInternalServerError, errors.NewInternalServerErrorResponse("Failed to save request ID"))
+			c.Abort()
 			return
 		}
 
@@ -0,0 +1,130 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// DefaultRequestIDTTL is how long InMemoryRequestIDStorage remembers request IDs by default.
+const DefaultRequestIDTTL = 10 * time.Minute
+
+// DefaultRequestIDMaxSize is the maximum number of request IDs InMemoryRequestIDStorage keeps by default.
+const DefaultRequestIDMaxSize = 100000
+
+// InMemoryRequestIDStorage is a RequestIDStorage keeping request IDs in memory for a fixed time.
+// Expired IDs are removed as the storage is used, without a background goroutine, and once the
+// storage is full the oldest IDs are evicted, so memory use stays bounded. It only detects
+// duplicates within one process; use a shared store, such as Redis, across multiple instances.
+type InMemoryRequestIDStorage struct {
+	ttl     time.Duration
+	maxSize int
+	clock   core.Clock
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // Entries from the oldest to the newest, which is also their expiry order
+}
+
+// requestIDEntry is a request ID and the time it expires.
+type requestIDEntry struct {
+	id      string
+	expires time.Time
+}
+
+// NewInMemoryRequestIDStorage returns an empty storage remembering request IDs for ttl and
+// keeping at most maxSize of them. If ttl or maxSize is zero or less, DefaultRequestIDTTL or
+// DefaultRequestIDMaxSize is used. If clock is nil, core.SystemClock is used.
+//
+// Example usage:
+//
+//	s.Use(middleware.DuplicateRequestMiddleware(&middleware.DuplicateRequestConfig{
+//		RequestIDGenerator: myRequestIDGenerator,
+//		RequestIDStorage:   middleware.NewInMemoryRequestIDStorage(5*time.Minute, 0, nil),
+//	}))
+func NewInMemoryRequestIDStorage(ttl time.Duration, maxSize int, clock core.Clock) *InMemoryRequestIDStorage {
+	if ttl <= 0 {
+		ttl = DefaultRequestIDTTL
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultRequestIDMaxSize
+	}
+	if clock == nil {
+		clock = core.SystemClock
+	}
+	return &InMemoryRequestIDStorage{
+		ttl:     ttl,
+		maxSize: maxSize,
+		clock:   clock,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// CheckRequestID implements RequestIDStorage.CheckRequestID. Expired IDs do not exist.
+// An ID that does not exist is saved right away, so that of two identical requests checked at the
+// same time, only one is let through.
+func (s *InMemoryRequestIDStorage) CheckRequestID(requestID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	s.expire(now)
+
+	if _, ok := s.entries[requestID]; ok {
+		return true, nil
+	}
+	s.add(requestID, now)
+	return false, nil
+}
+
+// SaveRequestID implements RequestIDStorage.SaveRequestID. Saving an ID again restarts its TTL.
+func (s *InMemoryRequestIDStorage) SaveRequestID(requestID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	s.expire(now)
+
+	if element, ok := s.entries[requestID]; ok {
+		element.Value.(*requestIDEntry).expires = now.Add(s.ttl)
+		s.order.MoveToBack(element)
+		return nil
+	}
+	s.add(requestID, now)
+	return nil
+}
+
+// add saves requestID as of now, evicting the oldest IDs once the storage is full.
+// The caller must hold s.mu.
+func (s *InMemoryRequestIDStorage) add(requestID string, now time.Time) {
+	s.entries[requestID] = s.order.PushBack(&requestIDEntry{id: requestID, expires: now.Add(s.ttl)})
+	for s.order.Len() > s.maxSize {
+		s.remove(s.order.Front())
+	}
+}
+
+// Len returns the number of request IDs that have not expired.
+func (s *InMemoryRequestIDStorage) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(s.clock.Now())
+	return s.order.Len()
+}
+
+// expire removes the IDs that have expired by now. The caller must hold s.mu.
+func (s *InMemoryRequestIDStorage) expire(now time.Time) {
+	for element := s.order.Front(); element != nil; element = s.order.Front() {
+		if now.Before(element.Value.(*requestIDEntry).expires) {
+			return
+		}
+		s.remove(element)
+	}
+}
+
+// remove removes the entry of element. The caller must hold s.mu.
+func (s *InMemoryRequestIDStorage) remove(element *list.Element) {
+	delete(s.entries, element.Value.(*requestIDEntry).id)
+	s.order.Remove(element)
+}
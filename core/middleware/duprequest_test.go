@@ -0,0 +1,115 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+// fixedRequestIDGenerator gives every request the same ID.
+type fixedRequestIDGenerator string
+
+func (g fixedRequestIDGenerator) GenerateRequestID(context.Context) (string, error) {
+	return string(g), nil
+}
+
+func TestDuplicateRequestMiddlewareWithInMemoryStorage(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			s.Use(middleware.DuplicateRequestMiddleware(&middleware.DuplicateRequestConfig{
+				RequestIDGenerator: fixedRequestIDGenerator("order-1"),
+				RequestIDStorage:   middleware.NewInMemoryRequestIDStorage(time.Minute, 0, clock),
+				ConflictMessage:    "Duplicate order",
+			}))
+			created := 0
+			s.POST("/orders", func(c core.Context) {
+				created++
+				c.String(http.StatusCreated, "created")
+			})
+			client := servertest.NewClient(s)
+
+			client.POST("/orders").Expect(t).Status(http.StatusCreated)
+			client.POST("/orders").Expect(t).Status(http.StatusConflict).JSONPath("$.error.message", "Duplicate order")
+			// The duplicate never reaches the handler
+			if created != 1 {
+				t.Errorf("handler ran %d times, want 1", created)
+			}
+
+			clock.Advance(time.Minute)
+			client.POST("/orders").Expect(t).Status(http.StatusCreated)
+		})
+	}
+}
+
+func TestInMemoryRequestIDStorage(t *testing.T) {
+	clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := middleware.NewInMemoryRequestIDStorage(time.Minute, 2, clock)
+
+	mustCheck := func(id string, want bool) {
+		t.Helper()
+		exists, err := storage.CheckRequestID(id)
+		if err != nil {
+			t.Fatalf("CheckRequestID(%q) returned %v", id, err)
+		}
+		if exists != want {
+			t.Errorf("CheckRequestID(%q) = %v, want %v", id, exists, want)
+		}
+	}
+
+	// Checking an ID claims it
+	mustCheck("a", false)
+	mustCheck("a", true)
+
+	// IDs expire after the TTL
+	clock.Advance(30 * time.Second)
+	mustCheck("b", false)
+	clock.Advance(30 * time.Second)
+	mustCheck("a", false)
+	mustCheck("b", true)
+
+	// Saving an ID again restarts its TTL
+	storage.SaveRequestID("b")
+	clock.Advance(45 * time.Second)
+	mustCheck("b", true)
+
+	// The oldest IDs are evicted once the storage is full
+	storage.SaveRequestID("c")
+	storage.SaveRequestID("d")
+	if storage.Len() != 2 {
+		t.Errorf("storage has %d IDs, want 2", storage.Len())
+	}
+	mustCheck("c", true)
+	mustCheck("d", true)
+	mustCheck("b", false)
+}
+
+func TestInMemoryRequestIDStorageConcurrentChecks(t *testing.T) {
+	storage := middleware.NewInMemoryRequestIDStorage(time.Minute, 0, nil)
+
+	var wg sync.WaitGroup
+	var passed atomic.Int32
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if exists, err := storage.CheckRequestID("order-1"); err == nil && !exists {
+				passed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := passed.Load(); n != 1 {
+		t.Errorf("%d concurrent checks found no duplicate, want 1", n)
+	}
+}
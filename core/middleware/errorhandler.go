@@ -8,10 +8,14 @@ package middleware
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/mythofleader/go-http-server/core"
 	tErrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+	"github.com/mythofleader/go-http-server/core/telemetry"
 )
 
 // DefaultErrorHandlerConfig returns a default error handler configuration.
@@ -19,6 +23,7 @@ func DefaultErrorHandlerConfig() *core.ErrorHandlerConfig {
 	return &core.ErrorHandlerConfig{
 		DefaultErrorMessage: "Internal Server Error",
 		DefaultStatusCode:   http.StatusInternalServerError,
+		IncludeRequestID:    true,
 	}
 }
 
@@ -41,12 +46,151 @@ func (c *errorHandlerContext) Errors() []error {
 
 // handleError processes an error and returns an appropriate HTTP response.
 func handleError(c core.Context, err error, config *core.ErrorHandlerConfig) {
+	WriteErrorResponse(c, []error{err}, config)
+}
+
+// PanicError returns the error of a value recovered from a panic in a handler. HTTPErrors keep
+// their status, so handlers can panic(errors.NewConflictHttpError(err)); other values become
+// InternalServerHttpErrors. It is used by the error handler middleware implementations.
+func PanicError(recovered interface{}) error {
+	switch e := recovered.(type) {
+	case tErrors.HTTPError:
+		return e
+	case string:
+		return tErrors.NewInternalServerHttpError(fmt.Errorf("%s", e))
+	case error:
+		return tErrors.NewInternalServerHttpError(e)
+	default:
+		return tErrors.NewInternalServerHttpError(fmt.Errorf("unknown error: %v", e))
+	}
+}
+
+// WriteErrorResponse writes the error response for the errors of a request. Errors that are not
+// HTTPErrors get the status mapped with errors.RegisterMapping, if any. If config.AggregateErrors
+// is set and there are several errors, the response has the highest-severity status (5xx over 4xx,
+// then the higher code), the message of that error, and every error in error.details; otherwise
+// only the first error is used. Other errors use the default status and message.
+// With core.ErrorFormatProblemJSON, the response is written as RFC 7807 problem details.
+// It is used by the error handler middleware implementations.
+func WriteErrorResponse(c core.Context, errs []error, config *core.ErrorHandlerConfig) {
+	if len(errs) == 0 {
+		return
+	}
+	if config == nil {
+		config = DefaultErrorHandlerConfig()
+	}
+
+	statusCode, message := errorStatus(errs[0], config)
+	if !config.AggregateErrors || len(errs) == 1 {
+		response := NewRequestErrorResponse(c, statusCode, message, config)
+		response.Error.Fields = validationFields(errs[0])
+		setRetryAfter(c, response, errs[0])
+		writeErrorResponse(c, statusCode, response, config)
+		return
+	}
+
+	details := make([]tErrors.ErrorDetail, 0, len(errs))
+	primary := errs[0]
+	for _, err := range errs {
+		code, msg := errorStatus(err, config)
+		details = append(details, tErrors.ErrorDetail{Code: code, Message: msg, Fields: validationFields(err)})
+		if code/100 > statusCode/100 || (code/100 == statusCode/100 && code > statusCode) {
+			statusCode, message, primary = code, msg, err
+		}
+	}
+	response := NewRequestErrorResponse(c, statusCode, message, config)
+	response.Error.Details = details
+	setRetryAfter(c, response, primary)
+	writeErrorResponse(c, statusCode, response, config)
+}
+
+// writeErrorResponse writes response in the format of config. Problem details get the request
+// path as their instance.
+func writeErrorResponse(c core.Context, statusCode int, response *tErrors.ErrorResponse, config *core.ErrorHandlerConfig) {
+	if config.Format != core.ErrorFormatProblemJSON {
+		c.JSON(statusCode, response)
+		return
+	}
+
+	problem := response.Problem()
+	if config.ProblemTypeBaseURI != "" {
+		problem.Type = strings.TrimSuffix(config.ProblemTypeBaseURI, "/") + "/" + strconv.Itoa(statusCode)
+	}
+	problem.Instance = c.Request().URL.Path
+	data, err := core.MarshalJSON(problem)
+	if err != nil {
+		c.JSON(statusCode, response)
+		return
+	}
+	c.SetHeader("Content-Type", tErrors.ProblemJSONContentType)
+	c.SetStatus(statusCode)
+	_ = core.WriteResponseBody(c.Request().Context(), c.Writer(), data)
+}
+
+// setRetryAfter sets the Retry-After header and error.retry_after of the response if err is a
+// TooManyRequestsHttpError with a retry delay.
+func setRetryAfter(c core.Context, response *tErrors.ErrorResponse, err error) {
+	var tooMany *tErrors.TooManyRequestsHttpError
+	if !errors.As(err, &tooMany) || tooMany.RetryAfter <= 0 {
+		return
+	}
+	response.Error.RetryAfter = tErrors.RetryAfterSeconds(tooMany.RetryAfter)
+	c.SetHeader("Retry-After", strconv.Itoa(response.Error.RetryAfter))
+}
+
+// errorStatus returns the status code and message of the response for err.
+// Errors mapped with errors.RegisterMapping get the mapped status; their message is only
+// exposed for client errors, since server error messages may reveal internals.
+func errorStatus(err error, config *core.ErrorHandlerConfig) (int, string) {
 	var httpErr tErrors.HTTPError
 	if errors.As(err, &httpErr) {
-		c.JSON(httpErr.StatusCode(), tErrors.NewErrorResponse(httpErr.StatusCode(), httpErr.Error()))
-		return
+		return httpErr.StatusCode(), httpErr.Error()
+	}
+	if status, ok := tErrors.StatusForError(err); ok {
+		if status >= http.StatusInternalServerError {
+			return status, http.StatusText(status)
+		}
+		return status, err.Error()
+	}
+	return config.DefaultStatusCode, config.DefaultErrorMessage
+}
+
+// validationFields returns the failed fields of a ValidationError, or nil for other errors.
+func validationFields(err error) []tErrors.FieldError {
+	var validationErr *tErrors.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Fields
+	}
+	return nil
+}
+
+// NewRequestErrorResponse creates an ErrorResponse for the request of c, adding the request ID
+// and trace ID as enabled by config. The request ID is the one set by the logging middleware,
+// falling back to the X-Request-ID response and request headers.
+// It is used by the error handler middleware implementations.
+func NewRequestErrorResponse(c core.Context, statusCode int, message string, config *core.ErrorHandlerConfig) *tErrors.ErrorResponse {
+	response := tErrors.NewErrorResponse(statusCode, message)
+	if config == nil {
+		return response
+	}
+
+	if config.IncludeRequestID {
+		if value, ok := c.Get(core.ContextKeyRequestID); ok {
+			response.Error.RequestID, _ = value.(string)
+		}
+		if response.Error.RequestID == "" {
+			response.Error.RequestID = c.Writer().Header().Get("X-Request-ID")
+		}
+		if response.Error.RequestID == "" {
+			response.Error.RequestID = c.GetHeader("X-Request-ID")
+		}
+	}
+	if config.IncludeTraceID {
+		if spanContext := telemetry.SpanContextFromContext(c.Request().Context()); spanContext.IsValid() {
+			response.Error.TraceID = spanContext.TraceID.String()
+		}
 	}
-	c.JSON(config.DefaultStatusCode, tErrors.NewErrorResponse(config.DefaultStatusCode, config.DefaultErrorMessage))
+	return response
 }
 
 // IErrorHandlerMiddleware is an interface for error handler middleware implementations.
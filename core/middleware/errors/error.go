@@ -1,7 +1,10 @@
 // Package errors provides error classes for HTTP status codes.
 package errors
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 type HTTPError interface {
 	error
@@ -133,3 +136,231 @@ func NewMethodNotAllowedHttpError(err error) *MethodNotAllowedHttpError {
 		Message: err.Error(),
 	}
 }
+
+type PaymentRequiredHttpError struct {
+	Message string
+}
+
+func (e *PaymentRequiredHttpError) Error() string {
+	return e.Message
+}
+
+func (e *PaymentRequiredHttpError) StatusCode() int {
+	return http.StatusPaymentRequired
+}
+
+func NewPaymentRequiredHttpError(err error) *PaymentRequiredHttpError {
+	return &PaymentRequiredHttpError{
+		Message: err.Error(),
+	}
+}
+
+type NotAcceptableHttpError struct {
+	Message string
+}
+
+func (e *NotAcceptableHttpError) Error() string {
+	return e.Message
+}
+
+func (e *NotAcceptableHttpError) StatusCode() int {
+	return http.StatusNotAcceptable
+}
+
+func NewNotAcceptableHttpError(err error) *NotAcceptableHttpError {
+	return &NotAcceptableHttpError{
+		Message: err.Error(),
+	}
+}
+
+type RequestTimeoutHttpError struct {
+	Message string
+}
+
+func (e *RequestTimeoutHttpError) Error() string {
+	return e.Message
+}
+
+func (e *RequestTimeoutHttpError) StatusCode() int {
+	return http.StatusRequestTimeout
+}
+
+func NewRequestTimeoutHttpError(err error) *RequestTimeoutHttpError {
+	return &RequestTimeoutHttpError{
+		Message: err.Error(),
+	}
+}
+
+type ConflictHttpError struct {
+	Message string
+}
+
+func (e *ConflictHttpError) Error() string {
+	return e.Message
+}
+
+func (e *ConflictHttpError) StatusCode() int {
+	return http.StatusConflict
+}
+
+func NewConflictHttpError(err error) *ConflictHttpError {
+	return &ConflictHttpError{
+		Message: err.Error(),
+	}
+}
+
+type GoneHttpError struct {
+	Message string
+}
+
+func (e *GoneHttpError) Error() string {
+	return e.Message
+}
+
+func (e *GoneHttpError) StatusCode() int {
+	return http.StatusGone
+}
+
+func NewGoneHttpError(err error) *GoneHttpError {
+	return &GoneHttpError{
+		Message: err.Error(),
+	}
+}
+
+type PreconditionFailedHttpError struct {
+	Message string
+}
+
+func (e *PreconditionFailedHttpError) Error() string {
+	return e.Message
+}
+
+func (e *PreconditionFailedHttpError) StatusCode() int {
+	return http.StatusPreconditionFailed
+}
+
+func NewPreconditionFailedHttpError(err error) *PreconditionFailedHttpError {
+	return &PreconditionFailedHttpError{
+		Message: err.Error(),
+	}
+}
+
+type UnsupportedMediaTypeHttpError struct {
+	Message string
+}
+
+func (e *UnsupportedMediaTypeHttpError) Error() string {
+	return e.Message
+}
+
+func (e *UnsupportedMediaTypeHttpError) StatusCode() int {
+	return http.StatusUnsupportedMediaType
+}
+
+func NewUnsupportedMediaTypeHttpError(err error) *UnsupportedMediaTypeHttpError {
+	return &UnsupportedMediaTypeHttpError{
+		Message: err.Error(),
+	}
+}
+
+type UnprocessableEntityHttpError struct {
+	Message string
+}
+
+func (e *UnprocessableEntityHttpError) Error() string {
+	return e.Message
+}
+
+func (e *UnprocessableEntityHttpError) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
+
+func NewUnprocessableEntityHttpError(err error) *UnprocessableEntityHttpError {
+	return &UnprocessableEntityHttpError{
+		Message: err.Error(),
+	}
+}
+
+// TooManyRequestsHttpError is returned when a client is over a rate limit, quota or concurrency limit.
+// The error handler sends RetryAfter, rounded up to whole seconds, in the Retry-After header
+// and in error.retry_after.
+type TooManyRequestsHttpError struct {
+	Message string
+	// RetryAfter is how long the client should wait before retrying, zero if unknown
+	RetryAfter time.Duration
+}
+
+func (e *TooManyRequestsHttpError) Error() string {
+	return e.Message
+}
+
+func (e *TooManyRequestsHttpError) StatusCode() int {
+	return http.StatusTooManyRequests
+}
+
+func NewTooManyRequestsHttpError(err error, retryAfter time.Duration) *TooManyRequestsHttpError {
+	return &TooManyRequestsHttpError{
+		Message:    err.Error(),
+		RetryAfter: retryAfter,
+	}
+}
+
+// RetryAfterSeconds returns the value of a Retry-After header for d, in whole seconds rounded up
+// so that clients do not retry too early.
+func RetryAfterSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+type NotImplementedHttpError struct {
+	Message string
+}
+
+func (e *NotImplementedHttpError) Error() string {
+	return e.Message
+}
+
+func (e *NotImplementedHttpError) StatusCode() int {
+	return http.StatusNotImplemented
+}
+
+func NewNotImplementedHttpError(err error) *NotImplementedHttpError {
+	return &NotImplementedHttpError{
+		Message: err.Error(),
+	}
+}
+
+type BadGatewayHttpError struct {
+	Message string
+}
+
+func (e *BadGatewayHttpError) Error() string {
+	return e.Message
+}
+
+func (e *BadGatewayHttpError) StatusCode() int {
+	return http.StatusBadGateway
+}
+
+func NewBadGatewayHttpError(err error) *BadGatewayHttpError {
+	return &BadGatewayHttpError{
+		Message: err.Error(),
+	}
+}
+
+type GatewayTimeoutHttpError struct {
+	Message string
+}
+
+func (e *GatewayTimeoutHttpError) Error() string {
+	return e.Message
+}
+
+func (e *GatewayTimeoutHttpError) StatusCode() int {
+	return http.StatusGatewayTimeout
+}
+
+func NewGatewayTimeoutHttpError(err error) *GatewayTimeoutHttpError {
+	return &GatewayTimeoutHttpError{
+		Message: err.Error(),
+	}
+}
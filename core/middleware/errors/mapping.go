@@ -0,0 +1,86 @@
+package errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// statusClientClosedRequest is the status of requests cancelled by the client (core.StatusClientClosedRequest).
+const statusClientClosedRequest = 499
+
+// errorMapping maps errors matched by match to an HTTP status code.
+type errorMapping struct {
+	match  func(err error) bool
+	status int
+}
+
+var (
+	mappingsMu sync.RWMutex
+	mappings   = []errorMapping{
+		{match: isError(context.DeadlineExceeded), status: http.StatusGatewayTimeout},
+		{match: isError(context.Canceled), status: statusClientClosedRequest},
+		{match: isError(sql.ErrNoRows), status: http.StatusNotFound},
+		{match: isError(io.EOF), status: http.StatusBadRequest}, // Empty request body on bind
+		{match: isValidationError, status: http.StatusUnprocessableEntity},
+		{match: isMaxBytesError, status: http.StatusRequestEntityTooLarge},
+	}
+)
+
+// isError returns a matcher for errors wrapping target.
+func isError(target error) func(err error) bool {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
+
+// isValidationError reports whether err contains validator errors, as returned by binding.
+func isValidationError(err error) bool {
+	var validationErrs validator.ValidationErrors
+	return errors.As(err, &validationErrs)
+}
+
+// isMaxBytesError reports whether err is a request body size limit violation.
+func isMaxBytesError(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// RegisterMapping maps errors wrapping target (see errors.Is) to an HTTP status code, so handlers
+// can return library errors as they are and the error handler still responds with the right status.
+// Mappings registered later take precedence, including over the defaults:
+// context.DeadlineExceeded → 504, context.Canceled → 499, sql.ErrNoRows → 404, io.EOF (empty body on bind) → 400
+// validator.ValidationErrors → 422 and *http.MaxBytesError → 413.
+//
+// Example usage:
+//
+//	errors.RegisterMapping(redis.Nil, http.StatusNotFound)
+func RegisterMapping(target error, status int) {
+	RegisterMappingFunc(isError(target), status)
+}
+
+// RegisterMappingFunc maps errors for which match returns true to an HTTP status code,
+// e.g. for error types that are matched with errors.As.
+func RegisterMappingFunc(match func(err error) bool, status int) {
+	mappingsMu.Lock()
+	defer mappingsMu.Unlock()
+	mappings = append(mappings, errorMapping{match: match, status: status})
+}
+
+// StatusForError returns the HTTP status code mapped to err and whether there is one.
+// It is used by the error handler for errors that are not HTTPErrors.
+func StatusForError(err error) (int, bool) {
+	mappingsMu.RLock()
+	defer mappingsMu.RUnlock()
+	for i := len(mappings) - 1; i >= 0; i-- {
+		if mappings[i].match(err) {
+			return mappings[i].status, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,58 @@
+package errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestStatusForError(t *testing.T) {
+	type input struct {
+		Name string `validate:"required"`
+	}
+	validationErr := validator.New().Struct(input{})
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		ok     bool
+	}{
+		{"deadline exceeded", context.DeadlineExceeded, http.StatusGatewayTimeout, true},
+		{"wrapped no rows", fmt.Errorf("find user: %w", sql.ErrNoRows), http.StatusNotFound, true},
+		{"empty body", io.EOF, http.StatusBadRequest, true},
+		{"validation", validationErr, http.StatusUnprocessableEntity, true},
+		{"body too large", fmt.Errorf("file: %w", &http.MaxBytesError{Limit: 10}), http.StatusRequestEntityTooLarge, true},
+		{"unmapped", errors.New("boom"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, ok := StatusForError(tt.err)
+			if status != tt.status || ok != tt.ok {
+				t.Errorf("StatusForError() = %d, %v, want %d, %v", status, ok, tt.status, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRegisterMapping(t *testing.T) {
+	errQuota := errors.New("quota exceeded")
+	RegisterMapping(errQuota, http.StatusTooManyRequests)
+	if status, ok := StatusForError(fmt.Errorf("upload: %w", errQuota)); !ok || status != http.StatusTooManyRequests {
+		t.Errorf("StatusForError() = %d, %v, want 429", status, ok)
+	}
+
+	// Later mappings take precedence over the defaults
+	RegisterMappingFunc(func(err error) bool {
+		return errors.Is(err, sql.ErrNoRows)
+	}, http.StatusGone)
+	if status, _ := StatusForError(sql.ErrNoRows); status != http.StatusGone {
+		t.Errorf("StatusForError() = %d, want 410", status)
+	}
+}
@@ -0,0 +1,48 @@
+package errors
+
+import "net/http"
+
+// ProblemJSONContentType is the content type of RFC 7807 problem details responses.
+const ProblemJSONContentType = "application/problem+json"
+
+// ProblemDetails represents an RFC 7807 problem details response. Besides the standard members,
+// it carries the same extension members as ErrorDetail.
+type ProblemDetails struct {
+	// Type is a URI identifying the problem type, "about:blank" if the problem has no further semantics
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	RequestID string `json:"request_id,omitempty"`
+	TraceID   string `json:"trace_id,omitempty"`
+	// Errors lists every error of the request when the error handler aggregates errors
+	Errors []ErrorDetail `json:"errors,omitempty"`
+	// Fields lists the fields that failed validation, for ValidationErrors
+	Fields []FieldError `json:"fields,omitempty"`
+	// RetryAfter is the number of seconds the client should wait before retrying, for 429 Too Many Requests
+	RetryAfter int `json:"retry_after,omitempty"`
+}
+
+// NewProblemDetails creates a new ProblemDetails of type "about:blank" with the given status code and detail.
+// The title is the status text of the code.
+func NewProblemDetails(statusCode int, detail string) *ProblemDetails {
+	return &ProblemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: detail,
+	}
+}
+
+// Problem returns the problem details equivalent of the response, with the message as the detail.
+func (r *ErrorResponse) Problem() *ProblemDetails {
+	problem := NewProblemDetails(r.Error.Code, r.Error.Message)
+	problem.RequestID = r.Error.RequestID
+	problem.TraceID = r.Error.TraceID
+	problem.Errors = r.Error.Details
+	problem.Fields = r.Error.Fields
+	problem.RetryAfter = r.Error.RetryAfter
+	return problem
+}
@@ -3,12 +3,21 @@ package errors
 
 import (
 	"net/http"
+	"time"
 )
 
 // ErrorDetail represents the structure of an error detail in the response.
 type ErrorDetail struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	TraceID   string `json:"trace_id,omitempty"`
+	// Details lists every error of the request when the error handler aggregates errors
+	Details []ErrorDetail `json:"details,omitempty"`
+	// Fields lists the fields that failed validation, for ValidationErrors
+	Fields []FieldError `json:"fields,omitempty"`
+	// RetryAfter is the number of seconds the client should wait before retrying, for 429 Too Many Requests
+	RetryAfter int `json:"retry_after,omitempty"`
 }
 
 // ErrorResponse represents the structure of an error response.
@@ -42,6 +51,14 @@ func NewUnauthorizedResponse(message string) *ErrorResponse {
 	return NewErrorResponse(http.StatusUnauthorized, message)
 }
 
+// NewPaymentRequiredResponse creates a new ErrorResponse for a 402 Payment Required error.
+func NewPaymentRequiredResponse(message string) *ErrorResponse {
+	if message == "" {
+		message = "Payment Required"
+	}
+	return NewErrorResponse(http.StatusPaymentRequired, message)
+}
+
 // NewForbiddenResponse creates a new ErrorResponse for a 403 Forbidden error.
 func NewForbiddenResponse(message string) *ErrorResponse {
 	if message == "" {
@@ -58,6 +75,22 @@ func NewNotFoundResponse(message string) *ErrorResponse {
 	return NewErrorResponse(http.StatusNotFound, message)
 }
 
+// NewNotAcceptableResponse creates a new ErrorResponse for a 406 Not Acceptable error.
+func NewNotAcceptableResponse(message string) *ErrorResponse {
+	if message == "" {
+		message = "Not Acceptable"
+	}
+	return NewErrorResponse(http.StatusNotAcceptable, message)
+}
+
+// NewRequestTimeoutResponse creates a new ErrorResponse for a 408 Request Timeout error.
+func NewRequestTimeoutResponse(message string) *ErrorResponse {
+	if message == "" {
+		message = "Request Timeout"
+	}
+	return NewErrorResponse(http.StatusRequestTimeout, message)
+}
+
 // NewConflictResponse creates a new ErrorResponse for a 409 Conflict error.
 func NewConflictResponse(message string) *ErrorResponse {
 	if message == "" {
@@ -66,6 +99,50 @@ func NewConflictResponse(message string) *ErrorResponse {
 	return NewErrorResponse(http.StatusConflict, message)
 }
 
+// NewGoneResponse creates a new ErrorResponse for a 410 Gone error.
+func NewGoneResponse(message string) *ErrorResponse {
+	if message == "" {
+		message = "Gone"
+	}
+	return NewErrorResponse(http.StatusGone, message)
+}
+
+// NewPreconditionFailedResponse creates a new ErrorResponse for a 412 Precondition Failed error.
+func NewPreconditionFailedResponse(message string) *ErrorResponse {
+	if message == "" {
+		message = "Precondition Failed"
+	}
+	return NewErrorResponse(http.StatusPreconditionFailed, message)
+}
+
+// NewUnsupportedMediaTypeResponse creates a new ErrorResponse for a 415 Unsupported Media Type error.
+func NewUnsupportedMediaTypeResponse(message string) *ErrorResponse {
+	if message == "" {
+		message = "Unsupported Media Type"
+	}
+	return NewErrorResponse(http.StatusUnsupportedMediaType, message)
+}
+
+// NewUnprocessableEntityResponse creates a new ErrorResponse for a 422 Unprocessable Entity error.
+func NewUnprocessableEntityResponse(message string) *ErrorResponse {
+	if message == "" {
+		message = "Unprocessable Entity"
+	}
+	return NewErrorResponse(http.StatusUnprocessableEntity, message)
+}
+
+// NewTooManyRequestsResponse creates a new ErrorResponse for a 429 Too Many Requests error.
+// retryAfter is reported in error.retry_after, rounded up to whole seconds; the Retry-After
+// header must be set separately, as the error handler does for TooManyRequestsHttpErrors.
+func NewTooManyRequestsResponse(message string, retryAfter time.Duration) *ErrorResponse {
+	if message == "" {
+		message = "Too Many Requests"
+	}
+	response := NewErrorResponse(http.StatusTooManyRequests, message)
+	response.Error.RetryAfter = RetryAfterSeconds(retryAfter)
+	return response
+}
+
 // NewInternalServerErrorResponse creates a new ErrorResponse for a 500 Internal Server Error.
 func NewInternalServerErrorResponse(message string) *ErrorResponse {
 	if message == "" {
@@ -74,6 +151,22 @@ func NewInternalServerErrorResponse(message string) *ErrorResponse {
 	return NewErrorResponse(http.StatusInternalServerError, message)
 }
 
+// NewNotImplementedResponse creates a new ErrorResponse for a 501 Not Implemented error.
+func NewNotImplementedResponse(message string) *ErrorResponse {
+	if message == "" {
+		message = "Not Implemented"
+	}
+	return NewErrorResponse(http.StatusNotImplemented, message)
+}
+
+// NewBadGatewayResponse creates a new ErrorResponse for a 502 Bad Gateway error.
+func NewBadGatewayResponse(message string) *ErrorResponse {
+	if message == "" {
+		message = "Bad Gateway"
+	}
+	return NewErrorResponse(http.StatusBadGateway, message)
+}
+
 // NewServiceUnavailableResponse creates a new ErrorResponse for a 503 Service Unavailable error.
 func NewServiceUnavailableResponse(message string) *ErrorResponse {
 	if message == "" {
@@ -81,3 +174,11 @@ func NewServiceUnavailableResponse(message string) *ErrorResponse {
 	}
 	return NewErrorResponse(http.StatusServiceUnavailable, message)
 }
+
+// NewGatewayTimeoutResponse creates a new ErrorResponse for a 504 Gateway Timeout error.
+func NewGatewayTimeoutResponse(message string) *ErrorResponse {
+	if message == "" {
+		message = "Gateway Timeout"
+	}
+	return NewErrorResponse(http.StatusGatewayTimeout, message)
+}
@@ -0,0 +1,107 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes a field of a request that failed validation.
+type FieldError struct {
+	// Field is the path of the field, using JSON names where the struct has json tags, e.g. "address.zip"
+	Field string `json:"field"`
+	// Rule is the validation tag that failed, e.g. "required" or "email"
+	Rule string `json:"rule"`
+	// Param is the parameter of the rule, e.g. "8" for "min=8"
+	Param string `json:"param,omitempty"`
+	// Message describes the failure for the client
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by binding when the request fails struct validation.
+// It lists every failed field, and the error handler renders it as a 400 Bad Request
+// with the fields in error.fields.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + " " + field.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// NewValidationError converts the errors of the validator to a ValidationError.
+// Field paths start below the validated struct, e.g. "address.zip" for "CreateUserRequest.address.zip".
+func NewValidationError(errs validator.ValidationErrors) *ValidationError {
+	fields := make([]FieldError, len(errs))
+	for i, fe := range errs {
+		field := fe.Namespace()
+		if _, path, ok := strings.Cut(field, "."); ok {
+			field = path
+		}
+		fields[i] = FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fieldMessage(fe),
+		}
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// AsValidationError returns err as a ValidationError if it is one or wraps validator errors.
+func AsValidationError(err error) (*ValidationError, bool) {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr, true
+	}
+	var validatorErrs validator.ValidationErrors
+	if errors.As(err, &validatorErrs) {
+		return NewValidationError(validatorErrs), true
+	}
+	return nil, false
+}
+
+// fieldMessage returns the message of a failed field for the common rules.
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "min":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "len":
+		return fmt.Sprintf("must have length %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s", fe.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fe.Param())
+	case "lte":
+		return fmt.Sprintf("must be less than or equal to %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(fe.Param()), ", "))
+	}
+	if fe.Param() != "" {
+		return fmt.Sprintf("failed the %s=%s rule", fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("failed the %s rule", fe.Tag())
+}
@@ -0,0 +1,159 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// IPConcurrencyConfig holds configuration for the per-IP concurrency guard middleware.
+type IPConcurrencyConfig struct {
+	// MaxConcurrentRequests is the maximum number of requests a single client IP may have in flight.
+	MaxConcurrentRequests int
+
+	// Allowlist contains IPs ("10.0.0.5") and CIDR ranges ("10.0.0.0/8") that are never limited,
+	// e.g. internal load balancers or health checkers that multiplex many clients.
+	Allowlist []string
+
+	// ClientIP returns the client IP of a request. If not set, Context.ClientIP is used, which
+	// honors the X-Forwarded-For and X-Real-IP headers only from the trusted proxies of the server.
+	ClientIP func(req *http.Request) string
+
+	// Optional: custom error message
+	TooManyRequestsMessage string
+}
+
+// DefaultIPConcurrencyConfig returns a default per-IP concurrency guard configuration.
+func DefaultIPConcurrencyConfig() *IPConcurrencyConfig {
+	return &IPConcurrencyConfig{
+		MaxConcurrentRequests:  10,
+		Allowlist:              []string{},
+		TooManyRequestsMessage: "Too many concurrent requests from this client",
+	}
+}
+
+// ipGuard tracks the number of in-flight requests per client IP.
+type ipGuard struct {
+	max       int
+	allowlist []*net.IPNet
+
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+// acquire reserves a slot for ip. It returns false if ip already has max requests in flight.
+func (g *ipGuard) acquire(ip string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.inFlight[ip] >= g.max {
+		return false
+	}
+	g.inFlight[ip]++
+	return true
+}
+
+// release frees a slot of ip, forgetting the IP once it has no requests in flight.
+func (g *ipGuard) release(ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.inFlight[ip]--; g.inFlight[ip] <= 0 {
+		delete(g.inFlight, ip)
+	}
+}
+
+// allowed reports whether ip is in the allowlist.
+func (g *ipGuard) allowed(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range g.allowlist {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// IPConcurrencyMiddleware returns a middleware function that limits the number of simultaneous
+// requests per client IP. Unlike rate limiting, it bounds how many requests a client can keep
+// open at once, which blunts simple DoS patterns such as many slow requests from one host.
+// Requests over the limit are rejected with a 429 Too Many Requests response.
+// Example usage:
+//
+//	config := middleware.DefaultIPConcurrencyConfig()
+//	config.MaxConcurrentRequests = 20
+//	config.Allowlist = []string{"10.0.0.0/8"} // Internal load balancers
+//	s.Use(middleware.IPConcurrencyMiddleware(config))
+func IPConcurrencyMiddleware(config *IPConcurrencyConfig) core.HandlerFunc {
+	if config == nil {
+		config = DefaultIPConcurrencyConfig()
+	}
+	if config.MaxConcurrentRequests <= 0 {
+		panic("IPConcurrencyMiddleware requires a positive MaxConcurrentRequests")
+	}
+
+	guard := &ipGuard{
+		max:      config.MaxConcurrentRequests,
+		inFlight: make(map[string]int),
+	}
+	for _, entry := range config.Allowlist {
+		network, err := parseIPOrCIDR(entry)
+		if err != nil {
+			panic(fmt.Sprintf("IPConcurrencyMiddleware requires valid allowlist entries: %v", err))
+		}
+		guard.allowlist = append(guard.allowlist, network)
+	}
+
+	clientIP := config.ClientIP
+	message := config.TooManyRequestsMessage
+	if message == "" {
+		message = DefaultIPConcurrencyConfig().TooManyRequestsMessage
+	}
+
+	return func(c core.Context) {
+		ip := c.ClientIP()
+		if clientIP != nil {
+			ip = clientIP(c.Request())
+		}
+		if guard.allowed(ip) {
+			c.Next()
+			return
+		}
+
+		if !guard.acquire(ip) {
+			rejectTooManyRequests(c, message, 0)
+			return
+		}
+		defer guard.release(ip)
+
+		// Continue with the next middleware/handler in the chain
+		c.Next()
+	}
+}
+
+// parseIPOrCIDR parses an IP address or CIDR range into a network.
+func parseIPOrCIDR(entry string) (*net.IPNet, error) {
+	entry = strings.TrimSpace(entry)
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		return network, err
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", entry)
+	}
+	bits := 8 * net.IPv4len
+	if ip.To4() == nil {
+		bits = 8 * net.IPv6len
+	} else {
+		ip = ip.To4()
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
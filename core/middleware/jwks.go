@@ -0,0 +1,371 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// DefaultJWKSRefreshInterval is how long the keys of a JWKS are cached by default.
+const DefaultJWKSRefreshInterval = time.Hour
+
+// jwksMinRefreshInterval limits refetching the JWKS for tokens signed with an unknown key,
+// so that tokens with made-up key IDs cannot flood the identity provider.
+const jwksMinRefreshInterval = 10 * time.Second
+
+// jwtAlgorithm describes an asymmetric JWT signing algorithm.
+type jwtAlgorithm struct {
+	hash  crypto.Hash
+	curve elliptic.Curve // nil for RSA
+}
+
+// jwtAlgorithms are the supported asymmetric signing algorithms.
+var jwtAlgorithms = map[string]jwtAlgorithm{
+	"RS256": {hash: crypto.SHA256},
+	"RS384": {hash: crypto.SHA384},
+	"RS512": {hash: crypto.SHA512},
+	"ES256": {hash: crypto.SHA256, curve: elliptic.P256()},
+	"ES384": {hash: crypto.SHA384, curve: elliptic.P384()},
+	"ES512": {hash: crypto.SHA512, curve: elliptic.P521()},
+}
+
+// jwtVerifier verifies the signatures and registered claims of JWT tokens.
+type jwtVerifier struct {
+	secret    string
+	publicKey crypto.PublicKey
+	jwks      *jwks
+	issuer    string
+	audience  []string
+}
+
+// newJWTVerifier returns the verifier of a validated JWT configuration.
+func newJWTVerifier(config *AuthConfig) *jwtVerifier {
+	v := &jwtVerifier{
+		secret:    config.JWTSecret,
+		publicKey: config.JWTPublicKey,
+		issuer:    config.JWTIssuer,
+		audience:  config.JWTAudience,
+	}
+	if config.JWKSURL != "" {
+		v.jwks = newJWKS(config.JWKSURL, config.JWKSClient, config.JWKSRefreshInterval)
+	}
+	return v
+}
+
+// verify checks the signature of signingString, signed with alg by the key kid.
+// The key type must match the algorithm, so that a public key is never used as an HMAC secret.
+func (v *jwtVerifier) verify(ctx context.Context, alg, kid, signingString string, signature []byte) error {
+	if alg == "HS256" {
+		if v.secret == "" || !hmac.Equal(signature, createHmacSignature(signingString, v.secret)) {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	}
+
+	algorithm, ok := jwtAlgorithms[alg]
+	if !ok || (v.publicKey == nil && v.jwks == nil) {
+		return errors.New("unsupported signing method")
+	}
+	key := v.publicKey
+	if v.jwks != nil {
+		k, err := v.jwks.key(ctx, kid)
+		if err != nil {
+			return err
+		}
+		if k.alg != "" && k.alg != alg {
+			return errors.New("token algorithm does not match the signing key")
+		}
+		key = k.key
+	}
+
+	hasher := algorithm.hash.New()
+	hasher.Write([]byte(signingString))
+	digest := hasher.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if algorithm.curve == nil && rsa.VerifyPKCS1v15(key, algorithm.hash, digest, signature) == nil {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		if algorithm.curve == nil || key.Curve != algorithm.curve {
+			break
+		}
+		// ECDSA signatures are the big-endian R and S, each as long as the curve size
+		size := (algorithm.curve.Params().BitSize + 7) / 8
+		if len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if ecdsa.Verify(key, digest, r, s) {
+				return nil
+			}
+		}
+	}
+	return errors.New("invalid token signature")
+}
+
+// verifyClaims checks the issuer and audience of the claims, if configured.
+func (v *jwtVerifier) verifyClaims(claims MapClaims) error {
+	if v.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.issuer {
+			return errors.New("invalid token issuer")
+		}
+	}
+	if len(v.audience) > 0 {
+		// The audience is a string or an array of strings
+		var audience []string
+		switch aud := claims["aud"].(type) {
+		case string:
+			audience = []string{aud}
+		case []interface{}:
+			for _, a := range aud {
+				if s, ok := a.(string); ok {
+					audience = append(audience, s)
+				}
+			}
+		}
+		for _, want := range v.audience {
+			for _, got := range audience {
+				if got == want {
+					return nil
+				}
+			}
+		}
+		return errors.New("invalid token audience")
+	}
+	return nil
+}
+
+// jwk is a public key of a JWKS.
+type jwk struct {
+	alg string // Algorithm the key is restricted to, empty if not restricted
+	key crypto.PublicKey
+}
+
+// jwks fetches and caches the keys of a JSON Web Key Set. The keys are refetched when the
+// refresh interval has passed, and when a token is signed with an unknown key, so that
+// rotated keys are picked up without waiting for the interval.
+type jwks struct {
+	url      string
+	client   *http.Client
+	interval time.Duration
+
+	mu         sync.Mutex
+	keys       map[string]jwk // Keys by key ID
+	checkedAt  time.Time      // Time of the last fetch, successful or not
+	refreshing *jwksRefresh   // Fetch in progress, nil if none
+}
+
+// jwksRefresh is a fetch of the keys, shared by the requests that need its result.
+type jwksRefresh struct {
+	done chan struct{} // Closed once the fetch completes
+	err  error         // Error of the fetch, set before done is closed
+}
+
+func newJWKS(url string, client *http.Client, interval time.Duration) *jwks {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	if interval <= 0 {
+		interval = DefaultJWKSRefreshInterval
+	}
+	return &jwks{url: url, client: client, interval: interval}
+}
+
+// key returns the key kid. Tokens without a key ID may be verified if the set has a single key.
+// The keys are fetched without holding the lock: while one request refetches them, requests
+// whose key is cached go on with it, and those whose key is unknown wait for the fetch.
+func (s *jwks) key(ctx context.Context, kid string) (jwk, error) {
+	now := core.ClockFromContext(ctx).Now()
+	s.mu.Lock()
+	k, ok := s.lookup(kid)
+	elapsed := now.Sub(s.checkedAt)
+	refresh := s.refreshing
+	if refresh == nil && (s.checkedAt.IsZero() || elapsed >= s.interval || (!ok && elapsed >= jwksMinRefreshInterval)) {
+		s.checkedAt = now
+		refresh = &jwksRefresh{done: make(chan struct{})}
+		s.refreshing = refresh
+		s.mu.Unlock()
+		s.refresh(ctx, refresh)
+	} else {
+		s.mu.Unlock()
+		if ok {
+			return k, nil
+		}
+		if refresh == nil {
+			return jwk{}, errors.New("unknown token signing key")
+		}
+		select {
+		case <-refresh.done:
+		case <-ctx.Done():
+			return jwk{}, ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Keep using the cached keys while the identity provider is unavailable
+	if s.keys == nil && refresh.err != nil {
+		return jwk{}, fmt.Errorf("failed to fetch JWKS: %w", refresh.err)
+	}
+	if k, ok = s.lookup(kid); !ok {
+		return jwk{}, errors.New("unknown token signing key")
+	}
+	return k, nil
+}
+
+// refresh fetches the keys and replaces the cached ones, unless the fetch fails. The fetch is not
+// canceled with ctx, as other requests may be waiting for it; the client timeout bounds it.
+func (s *jwks) refresh(ctx context.Context, refresh *jwksRefresh) {
+	keys, err := s.fetch(context.WithoutCancel(ctx))
+	s.mu.Lock()
+	if err == nil {
+		s.keys = keys
+	}
+	refresh.err = err
+	s.refreshing = nil
+	s.mu.Unlock()
+	close(refresh.done)
+}
+
+// lookup returns the cached key kid.
+func (s *jwks) lookup(kid string) (jwk, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, k := range s.keys {
+			return k, true
+		}
+	}
+	k, ok := s.keys[kid]
+	return k, ok
+}
+
+// fetch fetches the keys of the set. Keys of unsupported types and encryption keys are ignored,
+// as are malformed keys, so that one bad key does not make the others unusable.
+func (s *jwks) fetch(ctx context.Context) (map[string]jwk, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("JWKS returned status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]jwk, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key crypto.PublicKey
+		switch k.Kty {
+		case "RSA":
+			key, err = parseRSAJWK(k.N, k.E)
+		case "EC":
+			key, err = parseECJWK(k.Crv, k.X, k.Y)
+		default:
+			continue
+		}
+		if err != nil {
+			log.Printf("[WARNING] ignoring invalid JWKS key %q: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = jwk{alg: k.Alg, key: key}
+	}
+	return keys, nil
+}
+
+// parseRSAJWK returns the RSA public key of the base64url-encoded modulus and exponent.
+func parseRSAJWK(n, e string) (*rsa.PublicKey, error) {
+	modulus, err := base64URLDecode(n)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := base64URLDecode(e)
+	if err != nil {
+		return nil, err
+	}
+	if len(modulus) == 0 || len(exponent) == 0 || len(exponent) > 4 {
+		return nil, errors.New("invalid RSA key")
+	}
+	var exp int
+	for _, b := range exponent {
+		exp = exp<<8 | int(b)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: exp}, nil
+}
+
+// parseECJWK returns the ECDSA public key of the curve and base64url-encoded coordinates.
+func parseECJWK(crv, x, y string) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	var ecdhCurve ecdh.Curve
+	switch crv {
+	case "P-256":
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+	xBytes, err := base64URLDecode(x)
+	if err != nil {
+		return nil, err
+	}
+	yBytes, err := base64URLDecode(y)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject points that are not on the curve
+	size := (curve.Params().BitSize + 7) / 8
+	if len(xBytes) > size || len(yBytes) > size {
+		return nil, errors.New("invalid EC key")
+	}
+	point := make([]byte, 1+2*size)
+	point[0] = 4 // Uncompressed
+	copy(point[1+size-len(xBytes):1+size], xBytes)
+	copy(point[1+2*size-len(yBytes):], yBytes)
+	if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+		return nil, errors.New("invalid EC key")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(xBytes), Y: new(big.Int).SetBytes(yBytes)}, nil
+}
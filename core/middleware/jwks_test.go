@@ -0,0 +1,283 @@
+package middleware_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+// signTestJWT signs claims with an RS256 or ES256 key, naming kid in the header if set.
+func signTestJWT(t *testing.T, key crypto.Signer, kid string, claims middleware.MapClaims) string {
+	t.Helper()
+	header := map[string]string{"typ": "JWT", "alg": "RS256"}
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		header["alg"] = "ES256"
+	}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	headerJSON, _ := json.Marshal(header)
+	payloadJSON, _ := json.Marshal(claims)
+	signingString := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(payloadJSON)
+
+	digest := sha256.Sum256([]byte(signingString))
+	var signature []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signingString + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// testJWK returns the public JWK of key.
+func testJWK(kid string, key crypto.Signer) map[string]string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+	case *ecdsa.PrivateKey:
+		x, y := make([]byte, 32), make([]byte, 32)
+		key.X.FillBytes(x)
+		key.Y.FillBytes(y)
+		return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(x), "y": b64(y)}
+	}
+	return nil
+}
+
+// newJWTServer returns a server authenticating requests to /me with config, using clock.
+func newJWTServer(config *middleware.AuthConfig, clock core.Clock) core.Server {
+	config.AuthType = middleware.AuthTypeJWT
+	config.JWTLookup = testJWTLookup{}
+	s := std.NewServer("8080", false)
+	s.Use(core.ClockMiddleware(clock, nil))
+	s.Use(middleware.AuthMiddleware(config))
+	s.GET("/me", func(c core.Context) {
+		user, _ := middleware.GetUserFromContext(c.Request().Context())
+		c.String(http.StatusOK, "%v", user)
+	})
+	return s
+}
+
+func TestAuthMiddlewareWithPublicKey(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	claims := middleware.MapClaims{"sub": "john", "exp": float64(clock.Now().Add(time.Hour).Unix())}
+
+	for _, key := range []crypto.Signer{rsaKey, ecKey} {
+		client := servertest.NewClient(newJWTServer(&middleware.AuthConfig{JWTPublicKey: key.Public()}, clock))
+		client.GET("/me").WithHeader("Authorization", "Bearer "+signTestJWT(t, key, "", claims)).Expect(t).
+			Status(http.StatusOK).
+			Body("john")
+
+		// HS256 tokens are rejected without a secret, so that the public key is never used as one
+		hs256, _ := middleware.SignJWT(claims, "")
+		client.GET("/me").WithHeader("Authorization", "Bearer "+hs256).Expect(t).
+			Status(http.StatusUnauthorized)
+	}
+
+	// A token signed by another key is rejected
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	client := servertest.NewClient(newJWTServer(&middleware.AuthConfig{JWTPublicKey: ecKey.Public()}, clock))
+	client.GET("/me").WithHeader("Authorization", "Bearer "+signTestJWT(t, otherKey, "", claims)).Expect(t).
+		Status(http.StatusUnauthorized)
+	client.GET("/me").WithHeader("Authorization", "Bearer "+signTestJWT(t, rsaKey, "", claims)).Expect(t).
+		Status(http.StatusUnauthorized)
+}
+
+func TestAuthMiddlewareWithJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rotatedKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	var mu sync.Mutex
+	keys := []map[string]string{testJWK("rsa-1", rsaKey), testJWK("ec-1", ecKey)}
+	var fetches atomic.Int32
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		mu.Lock()
+		defer mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	defer jwksServer.Close()
+
+	clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	claims := middleware.MapClaims{"sub": "john"}
+	client := servertest.NewClient(newJWTServer(&middleware.AuthConfig{JWKSURL: jwksServer.URL}, clock))
+
+	client.GET("/me").WithHeader("Authorization", "Bearer "+signTestJWT(t, rsaKey, "rsa-1", claims)).Expect(t).
+		Status(http.StatusOK)
+	client.GET("/me").WithHeader("Authorization", "Bearer "+signTestJWT(t, ecKey, "ec-1", claims)).Expect(t).
+		Status(http.StatusOK)
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", n)
+	}
+
+	// The identity provider rotates its keys; unknown keys are refetched at most every 10 seconds
+	mu.Lock()
+	keys = append(keys, testJWK("ec-2", rotatedKey))
+	mu.Unlock()
+	rotated := "Bearer " + signTestJWT(t, rotatedKey, "ec-2", claims)
+	client.GET("/me").WithHeader("Authorization", rotated).Expect(t).
+		Status(http.StatusUnauthorized)
+	clock.Advance(10 * time.Second)
+	client.GET("/me").WithHeader("Authorization", rotated).Expect(t).
+		Status(http.StatusOK)
+	client.GET("/me").WithHeader("Authorization", "Bearer "+signTestJWT(t, rotatedKey, "unknown", claims)).Expect(t).
+		Status(http.StatusUnauthorized)
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", n)
+	}
+
+	// Cached keys are used while the JWKS is unavailable
+	jwksServer.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	clock.Advance(middleware.DefaultJWKSRefreshInterval)
+	client.GET("/me").WithHeader("Authorization", rotated).Expect(t).
+		Status(http.StatusOK)
+	if n := fetches.Load(); n != 3 {
+		t.Errorf("JWKS fetched %d times, want 3", n)
+	}
+}
+
+func TestAuthMiddlewareWithJWKSInvalidKey(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "broken", "n": "not base64!", "e": "AQAB"},
+			{"kty": "EC", "kid": "off-curve", "crv": "P-256", "x": "AQ", "y": "AQ"},
+			testJWK("ec-1", ecKey),
+		}})
+	}))
+	defer jwksServer.Close()
+
+	clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := servertest.NewClient(newJWTServer(&middleware.AuthConfig{JWKSURL: jwksServer.URL}, clock))
+
+	// Malformed keys are skipped; the valid keys of the set are still used
+	client.GET("/me").WithHeader("Authorization", "Bearer "+signTestJWT(t, ecKey, "ec-1", middleware.MapClaims{"sub": "john"})).Expect(t).
+		Status(http.StatusOK)
+}
+
+func TestAuthMiddlewareWithJWKSRefreshDoesNotBlock(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var fetches atomic.Int32
+	fetching, release := make(chan struct{}), make(chan struct{})
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) == 2 {
+			close(fetching)
+			<-release
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{testJWK("ec-1", ecKey)}})
+	}))
+	defer jwksServer.Close()
+
+	clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := servertest.NewClient(newJWTServer(&middleware.AuthConfig{JWKSURL: jwksServer.URL}, clock))
+	token := "Bearer " + signTestJWT(t, ecKey, "ec-1", middleware.MapClaims{"sub": "john"})
+	client.GET("/me").WithHeader("Authorization", token).Expect(t).Status(http.StatusOK)
+
+	// Once the refresh interval has passed, one request refetches the keys...
+	clock.Advance(middleware.DefaultJWKSRefreshInterval)
+	refreshed := make(chan int, 1)
+	go func() {
+		rec, err := client.GET("/me").WithHeader("Authorization", token).Do()
+		if err != nil {
+			refreshed <- 0
+			return
+		}
+		refreshed <- rec.Code
+	}()
+	<-fetching
+
+	// ...while the others go on with the cached keys
+	client.GET("/me").WithHeader("Authorization", token).Expect(t).Status(http.StatusOK)
+	close(release)
+	if code := <-refreshed; code != http.StatusOK {
+		t.Errorf("refreshing request returned %d, want 200", code)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", n)
+	}
+}
+
+func TestAuthMiddlewareIssuerAndAudience(t *testing.T) {
+	clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := servertest.NewClient(newJWTServer(&middleware.AuthConfig{
+		JWTSecret:   "secret",
+		JWTIssuer:   "https://auth.example.com/",
+		JWTAudience: []string{"orders-api"},
+	}, clock)).WithJWTSecret("secret")
+
+	tests := []struct {
+		name   string
+		claims middleware.MapClaims
+		status int
+	}{
+		{"valid", middleware.MapClaims{"iss": "https://auth.example.com/", "aud": "orders-api"}, http.StatusOK},
+		{"audience array", middleware.MapClaims{"iss": "https://auth.example.com/", "aud": []string{"billing-api", "orders-api"}}, http.StatusOK},
+		{"wrong issuer", middleware.MapClaims{"iss": "https://evil.example.com/", "aud": "orders-api"}, http.StatusUnauthorized},
+		{"wrong audience", middleware.MapClaims{"iss": "https://auth.example.com/", "aud": "billing-api"}, http.StatusUnauthorized},
+		{"missing audience", middleware.MapClaims{"iss": "https://auth.example.com/"}, http.StatusUnauthorized},
+		{"not valid yet", middleware.MapClaims{"iss": "https://auth.example.com/", "aud": "orders-api", "nbf": float64(clock.Now().Add(time.Minute).Unix())}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.claims["sub"] = "john"
+			client.GET("/me").WithJWT(tt.claims).Expect(t).Status(tt.status)
+		})
+	}
+}
+
+func TestAuthConfigValidateKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		config middleware.AuthConfig
+		field  string
+	}{
+		{"unsupported public key", middleware.AuthConfig{JWTPublicKey: []byte("key")}, "JWTPublicKey"},
+		{"relative JWKS URL", middleware.AuthConfig{JWKSURL: "/jwks.json"}, "JWKSURL"},
+		{"JWKS URL without HTTP", middleware.AuthConfig{JWKSURL: "ftp://example.com/jwks.json"}, "JWKSURL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.AuthType = middleware.AuthTypeJWT
+			tt.config.JWTLookup = testJWTLookup{}
+			var configErr *middleware.ConfigError
+			if err := tt.config.Validate(); !errors.As(err, &configErr) || configErr.Field != tt.field {
+				t.Errorf("Validate() returned %v, want a ConfigError for %s", err, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// KeyCase is a JSON object key naming convention.
+type KeyCase string
+
+const (
+	// KeyCaseUnchanged leaves keys as they are.
+	KeyCaseUnchanged KeyCase = ""
+	// KeyCaseCamel converts keys to camelCase, e.g. "userId".
+	KeyCaseCamel KeyCase = "camel"
+	// KeyCaseSnake converts keys to snake_case, e.g. "user_id".
+	KeyCaseSnake KeyCase = "snake"
+)
+
+// JSONKeyCaseConfig holds configuration for the JSON key case conversion middleware.
+type JSONKeyCaseConfig struct {
+	// Response is the key case JSON responses are converted to, for the clients
+	Response KeyCase
+	// Request is the key case JSON request bodies are converted to before binding,
+	// i.e. the convention of the Go struct tags. Clients may then send either form.
+	Request KeyCase
+}
+
+// JSONKeyCaseMiddleware returns a middleware function that converts JSON object keys,
+// at any depth, between snake_case and camelCase. Register it on the server or on a router group.
+// Request bodies are converted before any later middleware or handler reads them, so register it
+// before middleware that reads the body (e.g. duplicate request detection).
+//
+// Example usage (Go structs use snake_case tags, clients use camelCase):
+//
+//	s.Use(middleware.JSONKeyCaseMiddleware(&middleware.JSONKeyCaseConfig{
+//		Response: middleware.KeyCaseCamel,
+//		Request:  middleware.KeyCaseSnake,
+//	}))
+func JSONKeyCaseMiddleware(config *JSONKeyCaseConfig) core.HandlerFunc {
+	if config == nil || (config.Response == KeyCaseUnchanged && config.Request == KeyCaseUnchanged) {
+		panic("JSONKeyCaseMiddleware requires a Response or Request key case")
+	}
+	requestRename := keyCaseFunc(config.Request)
+	responseRename := keyCaseFunc(config.Response)
+
+	next := func(c core.Context) { c.Next() }
+	if responseRename != nil {
+		next = ResponseTransformMiddleware(TransformKeys(responseRename))
+	}
+
+	return func(c core.Context) {
+		if requestRename != nil {
+			if err := convertRequestKeys(c.Request(), requestRename); err != nil {
+				c.JSON(http.StatusBadRequest, errors.NewBadRequestResponse("Invalid JSON request body"))
+				c.Abort()
+				return
+			}
+		}
+		next(c)
+	}
+}
+
+// keyCaseFunc returns the conversion function for the key case, or nil for KeyCaseUnchanged.
+func keyCaseFunc(keyCase KeyCase) func(string) string {
+	switch keyCase {
+	case KeyCaseUnchanged:
+		return nil
+	case KeyCaseCamel:
+		return ToCamelCase
+	case KeyCaseSnake:
+		return ToSnakeCase
+	default:
+		panic("JSONKeyCaseMiddleware: unknown key case " + string(keyCase))
+	}
+}
+
+// convertRequestKeys rewrites the keys of a JSON request body in place.
+// Requests without a JSON body are left unchanged.
+func convertRequestKeys(req *http.Request, rename func(string) string) error {
+	if req.Body == nil || req.Body == http.NoBody || !strings.Contains(req.Header.Get("Content-Type"), "json") {
+		return nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	_ = req.Body.Close()
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var payload interface{}
+	if err := decoder.Decode(&payload); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(transformKeys(payload, rename))
+	if err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	return nil
+}
+
+// ToCamelCase converts a snake_case or kebab-case key to camelCase, e.g. "user_id" to "userId".
+// Keys without separators are returned with their first letter lowercased.
+func ToCamelCase(key string) string {
+	var sb strings.Builder
+	upperNext := false
+	for i, r := range key {
+		switch {
+		case r == '_' || r == '-':
+			upperNext = sb.Len() > 0
+		case upperNext:
+			sb.WriteRune(unicode.ToUpper(r))
+			upperNext = false
+		case i == 0:
+			sb.WriteRune(unicode.ToLower(r))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// ToSnakeCase converts a camelCase or PascalCase key to snake_case, e.g. "userId" to "user_id".
+// Runs of capitals are treated as one word, e.g. "userID" to "user_id" and "HTTPServer" to "http_server".
+func ToSnakeCase(key string) string {
+	runes := []rune(key)
+	var sb strings.Builder
+	for i, r := range runes {
+		if r == '-' {
+			r = '_'
+		}
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+			if (prevLower || nextLower) && !strings.HasSuffix(sb.String(), "_") {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
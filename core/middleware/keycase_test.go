@@ -0,0 +1,68 @@
+package middleware_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestKeyCaseConversion(t *testing.T) {
+	tests := []struct {
+		key   string
+		camel string
+		snake string
+	}{
+		{"user_id", "userId", "user_id"},
+		{"userId", "userId", "user_id"},
+		{"userID", "userID", "user_id"},
+		{"HTTPServer", "hTTPServer", "http_server"},
+		{"created-at", "createdAt", "created_at"},
+		{"address2Line", "address2Line", "address2_line"},
+		{"id", "id", "id"},
+	}
+
+	for _, tt := range tests {
+		if got := middleware.ToCamelCase(tt.key); got != tt.camel {
+			t.Errorf("ToCamelCase(%q) = %q, want %q", tt.key, got, tt.camel)
+		}
+		if got := middleware.ToSnakeCase(tt.key); got != tt.snake {
+			t.Errorf("ToSnakeCase(%q) = %q, want %q", tt.key, got, tt.snake)
+		}
+	}
+}
+
+func TestJSONKeyCaseMiddleware(t *testing.T) {
+	type user struct {
+		UserID    int    `json:"user_id"`
+		FirstName string `json:"first_name"`
+	}
+
+	s := std.NewServer("8080", false)
+	s.Use(middleware.JSONKeyCaseMiddleware(&middleware.JSONKeyCaseConfig{
+		Response: middleware.KeyCaseCamel,
+		Request:  middleware.KeyCaseSnake,
+	}))
+	s.POST("/users", func(c core.Context) {
+		var u user
+		if err := c.ShouldBindJSON(&u); err != nil {
+			c.String(http.StatusBadRequest, "%v", err)
+			return
+		}
+		c.JSON(http.StatusOK, u)
+	})
+
+	client := servertest.NewClient(s)
+	for _, body := range []map[string]interface{}{
+		{"userId": 7, "firstName": "john"},
+		{"user_id": 7, "first_name": "john"},
+	} {
+		client.POST("/users").WithJSON(body).Expect(t).
+			Status(http.StatusOK).
+			JSONPath("$.userId", 7).
+			JSONPath("$.firstName", "john")
+	}
+}
@@ -0,0 +1,187 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// DefaultMaxHeaderCount is the maximum number of request header fields allowed by the hardened defaults.
+const DefaultMaxHeaderCount = 100
+
+// DefaultMaxBodySize is the maximum request body size, in bytes, allowed by the hardened defaults.
+const DefaultMaxBodySize = 10 << 20 // 10 MB
+
+// QueryLimitConfig holds the limits of the query limit middleware. Zero values disable a limit.
+type QueryLimitConfig struct {
+	// MaxLength is the maximum length of the raw query string, in bytes.
+	// Longer query strings are rejected with 414 URI Too Long.
+	MaxLength int
+
+	// MaxParams is the maximum number of query parameters, counting repeated keys.
+	MaxParams int
+
+	// MaxValuesPerKey is the maximum number of values of a single parameter, counting its array
+	// forms together, e.g. "ids=1&ids=2", "ids[]=1" and "ids[2]=3" are three values of "ids".
+	MaxValuesPerKey int
+
+	// MaxArrayIndex is the highest index allowed in brackets, e.g. 100 rejects "ids[101]=1", so that
+	// parsers allocating arrays up to the index can't be made to allocate huge arrays.
+	MaxArrayIndex int
+
+	// MaxDepth is the maximum number of bracketed segments of a key, e.g. 2 allows "a[b][c]" but not "a[b][c][d]".
+	MaxDepth int
+}
+
+// DefaultQueryLimitConfig returns the query limits applied by the hardened defaults.
+func DefaultQueryLimitConfig() *QueryLimitConfig {
+	return &QueryLimitConfig{
+		MaxLength:       4096,
+		MaxParams:       100,
+		MaxValuesPerKey: 50,
+		MaxArrayIndex:   100,
+		MaxDepth:        5,
+	}
+}
+
+// check returns the status code and message of the response rejecting rawQuery, or 0 if it is within the limits.
+// The query is scanned once, and the scan stops at the first limit exceeded.
+func (config *QueryLimitConfig) check(rawQuery string) (int, string) {
+	if config.MaxLength > 0 && len(rawQuery) > config.MaxLength {
+		return http.StatusRequestURITooLong, "Query string too long"
+	}
+
+	params := 0
+	values := make(map[string]int)
+	for rest := rawQuery; rest != ""; {
+		var param string
+		param, rest, _ = strings.Cut(rest, "&")
+		if param == "" {
+			continue
+		}
+		if params++; config.MaxParams > 0 && params > config.MaxParams {
+			return http.StatusBadRequest, "Too many query parameters"
+		}
+
+		key, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		name, brackets, _ := strings.Cut(key, "[")
+		if config.MaxValuesPerKey > 0 {
+			if values[name]++; values[name] > config.MaxValuesPerKey {
+				return http.StatusBadRequest, fmt.Sprintf("Too many values for query parameter %q", name)
+			}
+		}
+		if brackets == "" {
+			continue
+		}
+
+		// brackets holds the segments after the first "[", e.g. "0][name]" for "a[0][name]"
+		depth := 0
+		for segments := "[" + brackets; strings.HasPrefix(segments, "["); {
+			segment, after, found := strings.Cut(segments[1:], "]")
+			if !found {
+				break
+			}
+			if depth++; config.MaxDepth > 0 && depth > config.MaxDepth {
+				return http.StatusBadRequest, fmt.Sprintf("Query parameter %q is nested too deeply", name)
+			}
+			if config.MaxArrayIndex > 0 && segment != "" && strings.Trim(segment, "0123456789") == "" {
+				// Atoi fails only for indexes out of the int range
+				if index, err := strconv.Atoi(segment); err != nil || index > config.MaxArrayIndex {
+					return http.StatusBadRequest, fmt.Sprintf("Query parameter %q has an array index above %d", name, config.MaxArrayIndex)
+				}
+			}
+			segments = after
+		}
+	}
+	return 0, ""
+}
+
+// QueryLimitMiddleware returns a middleware function that rejects requests whose query string
+// exceeds the limits of config, before handlers or downstream parsers see it: too long query strings
+// with 414 URI Too Long, and too many parameters or values, too deep nesting or too high array indexes
+// with 400 Bad Request, in the standard error format.
+// If config is nil, DefaultQueryLimitConfig is used.
+//
+// Example usage:
+//
+//	config := middleware.DefaultQueryLimitConfig()
+//	config.MaxParams = 20
+//	s.Use(middleware.QueryLimitMiddleware(config))
+func QueryLimitMiddleware(config *QueryLimitConfig) core.HandlerFunc {
+	if config == nil {
+		config = DefaultQueryLimitConfig()
+	}
+
+	return func(c core.Context) {
+		if status, message := config.check(c.Request().URL.RawQuery); status != 0 {
+			c.JSON(status, errors.NewErrorResponse(status, message))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// HeaderLimitMiddleware returns a middleware function that rejects requests with more than
+// maxHeaders header field values with a 431 Request Header Fields Too Large response.
+// The total header size is limited separately by HTTPServerConfig.MaxHeaderBytes.
+//
+// Example usage:
+//
+//	s.Use(middleware.HeaderLimitMiddleware(100))
+func HeaderLimitMiddleware(maxHeaders int) core.HandlerFunc {
+	if maxHeaders <= 0 {
+		panic("HeaderLimitMiddleware requires a positive maxHeaders")
+	}
+
+	return func(c core.Context) {
+		count := 0
+		for _, values := range c.Request().Header {
+			count += len(values)
+		}
+		if count > maxHeaders {
+			c.JSON(http.StatusRequestHeaderFieldsTooLarge, errors.NewErrorResponse(http.StatusRequestHeaderFieldsTooLarge, "Too many request headers"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// BodyLimitMiddleware returns a middleware function that limits request bodies to maxSize bytes.
+// Requests whose Content-Length exceeds the limit are rejected with a 413 Request Entity Too Large
+// response; for other requests, reading past the limit fails with an *http.MaxBytesError.
+//
+// Example usage:
+//
+//	s.Use(middleware.BodyLimitMiddleware(1 << 20)) // 1 MB
+func BodyLimitMiddleware(maxSize int64) core.HandlerFunc {
+	if maxSize <= 0 {
+		panic("BodyLimitMiddleware requires a positive maxSize")
+	}
+
+	return func(c core.Context) {
+		req := c.Request()
+		if req.ContentLength > maxSize {
+			c.JSON(http.StatusRequestEntityTooLarge, errors.NewErrorResponse(http.StatusRequestEntityTooLarge, "Request body too large"))
+			c.Abort()
+			return
+		}
+		if req.Body != nil && req.Body != http.NoBody {
+			req.Body = http.MaxBytesReader(c.Writer(), req.Body, maxSize)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,197 @@
+package middleware_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestHeaderLimitMiddleware(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			s.Use(middleware.HeaderLimitMiddleware(5))
+			s.GET("/", func(c core.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+			client := servertest.NewClient(s)
+
+			client.GET("/").WithHeader("X-One", "1").Expect(t).Status(http.StatusOK)
+
+			req := client.GET("/")
+			for i := 0; i < 6; i++ {
+				req = req.WithHeader(fmt.Sprintf("X-Header-%d", i), "value")
+			}
+			req.Expect(t).Status(http.StatusRequestHeaderFieldsTooLarge)
+		})
+	}
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			s.Use(middleware.BodyLimitMiddleware(10))
+			s.POST("/upload", func(c core.Context) {
+				body, err := io.ReadAll(c.Request().Body)
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					c.String(http.StatusRequestEntityTooLarge, "too large")
+					return
+				}
+				c.String(http.StatusOK, string(body))
+			})
+			client := servertest.NewClient(s)
+
+			client.POST("/upload").WithBody("text/plain", []byte("small")).Expect(t).
+				Status(http.StatusOK).
+				Body("small")
+			client.POST("/upload").WithBody("text/plain", bytes.Repeat([]byte("x"), 11)).Expect(t).
+				Status(http.StatusRequestEntityTooLarge).
+				BodyContains("Request body too large")
+
+			// Bodies without a Content-Length are cut off while reading
+			req, err := client.POST("/upload").Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			req.Body = io.NopCloser(strings.NewReader(strings.Repeat("x", 20)))
+			req.ContentLength = -1
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+			if rec.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+			}
+		})
+	}
+}
+
+func TestResponseLimitMiddleware(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			var writeErr error
+			s.Use(func(c core.Context) {
+				c.SetHeader("X-Request-ID", "req-1")
+				c.Next()
+			})
+			s.Use(middleware.ResponseLimitMiddleware(16))
+			s.GET("/small", func(c core.Context) {
+				c.SetHeader("X-Handler", "small")
+				c.String(http.StatusCreated, "small")
+			})
+			s.GET("/large", func(c core.Context) {
+				c.SetHeader("X-Handler", "large")
+				c.Writer().Write([]byte("0123456789"))
+				_, writeErr = c.Writer().Write([]byte("0123456789"))
+			})
+			s.GET("/declared", func(c core.Context) {
+				c.SetHeader("Content-Length", "100")
+				c.SetStatus(http.StatusOK)
+				_, writeErr = c.Writer().Write([]byte("x"))
+			})
+			s.GET("/stream", func(c core.Context) {
+				c.Writer().Write([]byte("0123456789"))
+				c.Writer().Flush()
+				_, writeErr = c.Writer().Write([]byte("0123456789"))
+			})
+			client := servertest.NewClient(s)
+
+			client.GET("/small").Expect(t).
+				Status(http.StatusCreated).
+				Header("X-Request-ID", "req-1").
+				Header("X-Handler", "small").
+				Body("small")
+
+			// The oversized response is discarded, with the headers the handler set
+			writeErr = nil
+			rec := client.GET("/large").Expect(t).
+				Status(http.StatusInternalServerError).
+				Header("X-Request-ID", "req-1").
+				JSONPath("$.error.message", "Response too large").
+				Recorder()
+			if rec.Header().Get("X-Handler") != "" {
+				t.Error("the headers of the discarded response were sent")
+			}
+			if !errors.Is(writeErr, middleware.ErrResponseTooLarge) {
+				t.Errorf("write returned %v, want ErrResponseTooLarge", writeErr)
+			}
+
+			writeErr = nil
+			client.GET("/declared").Expect(t).
+				Status(http.StatusInternalServerError)
+			if !errors.Is(writeErr, middleware.ErrResponseTooLarge) {
+				t.Errorf("write returned %v, want ErrResponseTooLarge", writeErr)
+			}
+
+			// A streamed response is cut off at the limit
+			writeErr = nil
+			client.GET("/stream").Expect(t).
+				Status(http.StatusOK).
+				Body("0123456789012345")
+			if !errors.Is(writeErr, middleware.ErrResponseTooLarge) {
+				t.Errorf("write returned %v, want ErrResponseTooLarge", writeErr)
+			}
+		})
+	}
+}
+
+func TestResponseLimitMiddlewarePassesOnStatus(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			s.Use(middleware.ResponseLimitMiddleware(16))
+			s.DELETE("/items/:id", func(c core.Context) {
+				c.SetStatus(http.StatusNoContent)
+			})
+			servertest.NewClient(s).DELETE("/items/1").Expect(t).
+				Status(http.StatusNoContent).
+				Body("")
+		})
+	}
+}
+
+func TestQueryLimitMiddleware(t *testing.T) {
+	s := std.NewServer("8080", false)
+	s.Use(middleware.QueryLimitMiddleware(&middleware.QueryLimitConfig{
+		MaxLength:       64,
+		MaxParams:       4,
+		MaxValuesPerKey: 2,
+		MaxArrayIndex:   10,
+		MaxDepth:        2,
+	}))
+	s.GET("/search", func(c core.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	client := servertest.NewClient(s)
+
+	tests := []struct {
+		name, query string
+		status      int
+		message     string
+	}{
+		{"within limits", "q=go&ids[]=1&ids[10]=2&filter[a][b]=c", http.StatusOK, ""},
+		{"too long", "q=" + strings.Repeat("x", 63), http.StatusRequestURITooLong, "Query string too long"},
+		{"too many parameters", "a=1&b=2&c=3&d=4&e=5", http.StatusBadRequest, "Too many query parameters"},
+		{"too many values", "ids=1&ids[]=2&ids[3]=3", http.StatusBadRequest, `Too many values for query parameter "ids"`},
+		{"escaped key", "ids=1&ids%5B%5D=2&ids%5B%5D=3", http.StatusBadRequest, `Too many values for query parameter "ids"`},
+		{"array index", "ids[11]=1", http.StatusBadRequest, `Query parameter "ids" has an array index above 10`},
+		{"huge array index", "ids[99999999999999999999]=1", http.StatusBadRequest, `Query parameter "ids" has an array index above 10`},
+		{"too deep", "filter[a][b][c]=1", http.StatusBadRequest, `Query parameter "filter" is nested too deeply`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect := client.GET("/search?" + tt.query).Expect(t).Status(tt.status)
+			if tt.message != "" {
+				expect.JSONPath("$.error.message", tt.message)
+			}
+		})
+	}
+}
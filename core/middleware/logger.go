@@ -0,0 +1,168 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// slogLogger writes request logs to a *slog.Logger.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a core.Logger writing to logger. If logger is nil, slog.Default() is used.
+//
+// Example usage:
+//
+//	config := middleware.DefaultLoggingConfig()
+//	config.Logger = middleware.NewSlogLogger(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+func NewSlogLogger(logger *slog.Logger) core.Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slogLogger{logger: logger}
+}
+
+// Log implements core.Logger.Log.
+func (l *slogLogger) Log(level core.LogLevel, msg string, keysAndValues ...interface{}) {
+	slogLevel := slog.LevelInfo
+	switch level {
+	case core.LogLevelWarn:
+		slogLevel = slog.LevelWarn
+	case core.LogLevelError:
+		slogLevel = slog.LevelError
+	}
+	l.logger.Log(context.Background(), slogLevel, msg, keysAndValues...)
+}
+
+// ZapSugaredLogger is the subset of *zap.SugaredLogger (go.uber.org/zap) used by NewZapLogger.
+// The library does not depend on zap; a *zap.SugaredLogger implements this interface as it is.
+type ZapSugaredLogger interface {
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// zapLogger writes request logs to a zap SugaredLogger.
+type zapLogger struct {
+	logger ZapSugaredLogger
+}
+
+// NewZapLogger returns a core.Logger writing to a zap SugaredLogger.
+//
+// Example usage:
+//
+//	config.Logger = middleware.NewZapLogger(zapLogger.Sugar())
+func NewZapLogger(logger ZapSugaredLogger) core.Logger {
+	return &zapLogger{logger: logger}
+}
+
+// Log implements core.Logger.Log.
+func (l *zapLogger) Log(level core.LogLevel, msg string, keysAndValues ...interface{}) {
+	switch level {
+	case core.LogLevelWarn:
+		l.logger.Warnw(msg, keysAndValues...)
+	case core.LogLevelError:
+		l.logger.Errorw(msg, keysAndValues...)
+	default:
+		l.logger.Infow(msg, keysAndValues...)
+	}
+}
+
+// LogrusEntry is the subset of *logrus.Entry (github.com/sirupsen/logrus) used by NewLogrusLogger.
+type LogrusEntry interface {
+	Info(args ...interface{})
+	Warn(args ...interface{})
+	Error(args ...interface{})
+}
+
+// logrusLogger writes request logs to logrus.
+type logrusLogger[F ~map[string]interface{}, E LogrusEntry] struct {
+	withFields func(F) E
+}
+
+// NewLogrusLogger returns a core.Logger writing to logrus through the WithFields method of a
+// *logrus.Logger or *logrus.Entry. The library does not depend on logrus; pass the method value.
+//
+// Example usage:
+//
+//	config.Logger = middleware.NewLogrusLogger(logrus.StandardLogger().WithFields)
+func NewLogrusLogger[F ~map[string]interface{}, E LogrusEntry](withFields func(F) E) core.Logger {
+	return &logrusLogger[F, E]{withFields: withFields}
+}
+
+// Log implements core.Logger.Log.
+func (l *logrusLogger[F, E]) Log(level core.LogLevel, msg string, keysAndValues ...interface{}) {
+	fields := make(F, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		if key, ok := keysAndValues[i].(string); ok {
+			fields[key] = keysAndValues[i+1]
+		}
+	}
+	entry := l.withFields(fields)
+	switch level {
+	case core.LogLevelWarn:
+		entry.Warn(msg)
+	case core.LogLevelError:
+		entry.Error(msg)
+	default:
+		entry.Info(msg)
+	}
+}
+
+// logToLogger writes the log entry to logger, at a level derived from its status code.
+func logToLogger(logger core.Logger, logEntry *ApiLog) {
+	level := core.LogLevelInfo
+	switch {
+	case logEntry.StatusCode >= 500:
+		level = core.LogLevelError
+	case logEntry.StatusCode >= 400:
+		level = core.LogLevelWarn
+	}
+	logger.Log(level, logEntry.Method+" "+logEntry.Path, logFields(logEntry)...)
+}
+
+// logFields returns the fields of the log entry as alternating keys and values, using the
+// JSON names of ApiLog. The timestamp is left to the logger; empty optional fields are omitted.
+func logFields(logEntry *ApiLog) []interface{} {
+	fields := []interface{}{
+		"client_ip", logEntry.ClientIp,
+		"method", logEntry.Method,
+		"path", logEntry.Path,
+		"protocol", logEntry.Protocol,
+		"status_code", logEntry.StatusCode,
+		"latency", logEntry.Latency,
+		"user_agent", logEntry.UserAgent,
+		"request_id", logEntry.RequestId,
+	}
+	if logEntry.Error != "" && logEntry.Error != "none" {
+		fields = append(fields, "error", logEntry.Error)
+	}
+	if logEntry.Authorization != "" {
+		fields = append(fields, "authorization", logEntry.Authorization)
+	}
+	if logEntry.TraceId != "" {
+		fields = append(fields, "trace_id", logEntry.TraceId, "span_id", logEntry.SpanId)
+	}
+	if logEntry.Phase != "" {
+		fields = append(fields, "phase", logEntry.Phase)
+	}
+	if logEntry.BytesSent > 0 {
+		fields = append(fields, "bytes_sent", logEntry.BytesSent)
+	}
+
+	// Custom fields are flattened in key order, so that the output is stable
+	keys := make([]string, 0, len(logEntry.CustomFields))
+	for key := range logEntry.CustomFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fields = append(fields, key, logEntry.CustomFields[key])
+	}
+	return fields
+}
@@ -0,0 +1,101 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	config := &core.LoggingConfig{
+		LoggingToConsole: true,
+		CustomFields:     map[string]string{"service": "users"},
+		Logger:           middleware.NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+	}
+	logging := &middleware.BaseLoggingMiddleware{}
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	logging.ProcessLog(logging.CreateLogEntry(req, "192.0.2.1", http.StatusNotFound, 12, "req-1", config), config)
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode slog output %q: %v", buf.String(), err)
+	}
+	want := map[string]interface{}{
+		"level":         "WARN",
+		"msg":           "GET /users",
+		"status_code":   float64(http.StatusNotFound),
+		"latency":       float64(12),
+		"request_id":    "req-1",
+		"client_ip":     "192.0.2.1",
+		"service":       "users",
+		"authorization": "Bearer [MASKED]",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %v, want %v", key, got[key], value)
+		}
+	}
+}
+
+type fakeZapLogger struct {
+	level  string
+	msg    string
+	fields []interface{}
+}
+
+func (l *fakeZapLogger) Infow(msg string, kv ...interface{}) {
+	l.level, l.msg, l.fields = "info", msg, kv
+}
+func (l *fakeZapLogger) Warnw(msg string, kv ...interface{}) {
+	l.level, l.msg, l.fields = "warn", msg, kv
+}
+func (l *fakeZapLogger) Errorw(msg string, kv ...interface{}) {
+	l.level, l.msg, l.fields = "error", msg, kv
+}
+
+func TestZapLogger(t *testing.T) {
+	zap := &fakeZapLogger{}
+	middleware.NewZapLogger(zap).Log(core.LogLevelError, "GET /users", "status_code", 500)
+
+	if zap.level != "error" || zap.msg != "GET /users" || len(zap.fields) != 2 || zap.fields[1] != 500 {
+		t.Errorf("got %s %q %v, want error \"GET /users\" [status_code 500]", zap.level, zap.msg, zap.fields)
+	}
+}
+
+// logrusFields and logrusEntry mirror logrus.Fields and *logrus.Entry.
+type logrusFields map[string]interface{}
+
+type logrusEntry struct {
+	fields logrusFields
+	level  string
+	msg    interface{}
+}
+
+func (e *logrusEntry) Info(args ...interface{})  { e.level, e.msg = "info", args[0] }
+func (e *logrusEntry) Warn(args ...interface{})  { e.level, e.msg = "warn", args[0] }
+func (e *logrusEntry) Error(args ...interface{}) { e.level, e.msg = "error", args[0] }
+
+func TestLogrusLogger(t *testing.T) {
+	entry := &logrusEntry{}
+	withFields := func(fields logrusFields) *logrusEntry {
+		entry.fields = fields
+		return entry
+	}
+	middleware.NewLogrusLogger(withFields).Log(core.LogLevelInfo, "GET /users", "status_code", 200, "request_id", "req-1")
+
+	if entry.level != "info" || entry.msg != "GET /users" {
+		t.Errorf("got %s %v, want info \"GET /users\"", entry.level, entry.msg)
+	}
+	if entry.fields["status_code"] != 200 || entry.fields["request_id"] != "req-1" {
+		t.Errorf("fields = %v, want status_code 200 and request_id req-1", entry.fields)
+	}
+}
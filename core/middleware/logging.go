@@ -7,16 +7,16 @@
 package middleware
 
 import (
-	"bufio"
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
-	"net"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/telemetry"
 )
 
 // ApiLog represents the structure of a log entry for API requests.
@@ -33,6 +33,12 @@ type ApiLog struct {
 	RequestId     string            `json:"request_id"`
 	Authorization string            `json:"authorization"`
 	CustomFields  map[string]string `json:"custom_fields,omitempty"`
+	TraceId       string            `json:"trace_id,omitempty"`
+	SpanId        string            `json:"span_id,omitempty"`
+	// Phase is LogPhaseProgress or LogPhaseSummary for entries of long-lived requests, empty otherwise
+	Phase string `json:"phase,omitempty"`
+	// BytesSent is the number of response body bytes sent, set when LoggingConfig.ProgressInterval is set
+	BytesSent int64 `json:"bytes_sent,omitempty"`
 }
 
 // DefaultLoggingConfig returns a default logging configuration.
@@ -73,9 +79,33 @@ func NewDefaultConsoleLogging(skipPaths []string, customFields map[string]string
 // It provides methods for creating and processing log entries that are used by all implementations.
 type BaseLoggingMiddleware struct{}
 
+// LogRequest runs the rest of the chain of c and logs the request with the status code recorded by
+// the writer, or 499 if the client went away. errorMessage returns the error of the log entry for
+// the status code, e.g. from the errors recorded by the framework; if nil, no error is set.
+func (m *BaseLoggingMiddleware) LogRequest(c core.Context, config *core.LoggingConfig, errorMessage func(statusCode int) string) {
+	start := c.StartTime()
+	req := c.Request()
+	requestID := EnsureRequestID(c)
+
+	// Log progress entries while long-lived requests are open
+	progress := m.StartProgress(c, requestID, start, config)
+	c.Next()
+	progress.Stop()
+
+	latency := c.Elapsed().Milliseconds()
+	statusCode := core.EffectiveStatus(req.Context(), c.Writer().Status())
+	logEntry := m.CreateLogEntry(req, c.ClientIP(), statusCode, latency, requestID, config)
+	progress.Summarize(logEntry)
+	if errorMessage != nil {
+		logEntry.Error = errorMessage(statusCode)
+	}
+
+	m.ProcessLog(logEntry, config)
+}
+
 // CreateLogEntry creates a log entry from the request details.
-func (m *BaseLoggingMiddleware) CreateLogEntry(req *http.Request, statusCode int, latency int64, requestID string, config *core.LoggingConfig) *ApiLog {
-	clientIP := getClientIP(req)
+// clientIP is the client IP resolved by Context.ClientIP.
+func (m *BaseLoggingMiddleware) CreateLogEntry(req *http.Request, clientIP string, statusCode int, latency int64, requestID string, config *core.LoggingConfig) *ApiLog {
 	method := req.Method
 	path := req.URL.Path
 	protocol := req.Proto
@@ -83,12 +113,12 @@ func (m *BaseLoggingMiddleware) CreateLogEntry(req *http.Request, statusCode int
 	authorization := req.Header.Get("Authorization")
 
 	// Determine whether to mask authorization based on LoggingToConsole
-	// If logging to console, we don't mask for easier debugging
-	maskAuth := !config.LoggingToConsole
+	// If logging to console, we don't mask for easier debugging; entries sent to a Logger are masked
+	maskAuth := !config.LoggingToConsole || config.Logger != nil
 
-	return &ApiLog{
+	logEntry := &ApiLog{
 		ClientIp:      clientIP,
-		Timestamp:     time.Now().Format(time.RFC3339),
+		Timestamp:     core.ClockFromContext(req.Context()).Now().Format(time.RFC3339),
 		Method:        method,
 		Path:          path,
 		Protocol:      protocol,
@@ -100,103 +130,35 @@ func (m *BaseLoggingMiddleware) CreateLogEntry(req *http.Request, statusCode int
 		Authorization: maskAuthorizationBool(authorization, maskAuth),
 		CustomFields:  config.CustomFields,
 	}
+
+	// Correlate the entry with the server span, if tracing is active
+	if sc := telemetry.SpanContextFromContext(req.Context()); sc.IsValid() {
+		logEntry.TraceId = sc.TraceID.String()
+		logEntry.SpanId = sc.SpanID.String()
+	}
+	return logEntry
 }
 
-// ProcessLog logs the entry to the console and sends it to the remote URL if configured.
+// ProcessLog logs the entry to the console, or to config.Logger if set, and sends it to the remote URL if configured.
 func (m *BaseLoggingMiddleware) ProcessLog(logEntry *ApiLog, config *core.LoggingConfig) {
 	// Log to console if LoggingToConsole is true
 	if config.LoggingToConsole {
-		logToConsole(logEntry)
+		if config.Logger != nil {
+			logToLogger(config.Logger, logEntry)
+		} else {
+			logToConsole(logEntry)
+		}
 	}
 
 	// Send to remote URL if LoggingToRemote is true and RemoteURL is configured
 	if config.LoggingToRemote && config.RemoteURL != "" {
-		go sendLogToRemote(config.RemoteURL, logEntry)
-	}
-}
-
-// ResponseWriterWrapper is a wrapper for http.ResponseWriter that captures the status code.
-type ResponseWriterWrapper struct {
-	http.ResponseWriter
-	statusCode int
-	written    bool
-}
-
-// WriteHeader captures the status code and calls the underlying ResponseWriter's WriteHeader.
-func (w *ResponseWriterWrapper) WriteHeader(code int) {
-	w.statusCode = code
-	w.written = true
-	w.ResponseWriter.WriteHeader(code)
-}
-
-// Write captures the status code (if not already set) and calls the underlying ResponseWriter's Write.
-func (w *ResponseWriterWrapper) Write(b []byte) (int, error) {
-	if !w.written {
-		w.statusCode = http.StatusOK
-		w.written = true
-	}
-	return w.ResponseWriter.Write(b)
-}
-
-// Status returns the captured status code.
-func (w *ResponseWriterWrapper) Status() int {
-	if !w.written {
-		return http.StatusOK
-	}
-	return w.statusCode
-}
-
-// Hijack implements the http.Hijacker interface to pass through to the underlying ResponseWriter.
-func (w *ResponseWriterWrapper) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
-		return hijacker.Hijack()
-	}
-	return nil, nil, fmt.Errorf("underlying ResponseWriter does not implement http.Hijacker")
-}
-
-// Flush implements the http.Flusher interface to pass through to the underlying ResponseWriter.
-func (w *ResponseWriterWrapper) Flush() {
-	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
-		flusher.Flush()
-	}
-}
-
-// CloseNotify implements the http.CloseNotifier interface to pass through to the underlying ResponseWriter.
-func (w *ResponseWriterWrapper) CloseNotify() <-chan bool {
-	if closeNotifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
-		return closeNotifier.CloseNotify()
-	}
-	return nil
-}
-
-// Push implements the http.Pusher interface to pass through to the underlying ResponseWriter.
-func (w *ResponseWriterWrapper) Push(target string, opts *http.PushOptions) error {
-	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
-		return pusher.Push(target, opts)
-	}
-	return fmt.Errorf("underlying ResponseWriter does not implement http.Pusher")
-}
-
-// getClientIP extracts the client IP address from the request.
-func getClientIP(req *http.Request) string {
-	// Try X-Forwarded-For header first
-	if xff := req.Header.Get("X-Forwarded-For"); xff != "" {
-		ips := strings.Split(xff, ",")
-		return strings.TrimSpace(ips[0])
-	}
-
-	// Try X-Real-IP header
-	if xrip := req.Header.Get("X-Real-IP"); xrip != "" {
-		return xrip
+		go sendLogToRemote(config, logEntry)
 	}
 
-	// Fall back to RemoteAddr
-	ip := req.RemoteAddr
-	// Remove port if present
-	if idx := strings.LastIndex(ip, ":"); idx != -1 {
-		ip = ip[:idx]
+	// Deliver to additional sinks
+	if len(config.Sinks) > 0 {
+		writeLogToSinks(config.Sinks, logEntry)
 	}
-	return ip
 }
 
 // maskAuthorizationBool masks the authorization token for security.
@@ -230,22 +192,92 @@ func logToConsole(logEntry *ApiLog) {
 	fmt.Println(string(jsonData))
 }
 
-// sendLogToRemote sends the log entry to a remote URL.
-func sendLogToRemote(url string, logEntry *ApiLog) {
+// writeLogToSinks encodes the log entry once and passes it to every sink.
+func writeLogToSinks(sinks []core.LogSink, logEntry *ApiLog) {
 	jsonData, err := json.Marshal(logEntry)
 	if err != nil {
 		fmt.Printf("Error marshaling log entry: %v\n", err)
 		return
 	}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	record := core.LogRecord{
+		RequestID:    logEntry.RequestId,
+		CustomFields: logEntry.CustomFields,
+		TraceID:      logEntry.TraceId,
+		SpanID:       logEntry.SpanId,
+		Data:         jsonData,
+	}
+	for _, sink := range sinks {
+		sink.WriteLog(record)
+	}
+}
+
+// defaultRemoteRequestTimeout is the timeout of requests to the remote logging endpoint
+// when LoggingConfig.RemoteRequestTimeout is not set.
+const defaultRemoteRequestTimeout = 5 * time.Second
+
+// sendLogToRemote sends the log entry to the remote URL of the config.
+func sendLogToRemote(config *core.LoggingConfig, logEntry *ApiLog) {
+	jsonData, err := json.Marshal(logEntry)
 	if err != nil {
-		fmt.Printf("Error sending log to remote URL: %v\n", err)
+		fmt.Printf("Error marshaling log entry: %v\n", err)
 		return
 	}
+
+	if err := postRemoteLog(config, jsonData); err != nil {
+		fmt.Printf("Error sending log to remote URL: %v\n", err)
+	}
+}
+
+// postRemoteLog posts a JSON payload to the remote logging endpoint, applying the
+// compression and authentication options of the config.
+func postRemoteLog(config *core.LoggingConfig, payload []byte) error {
+	body := payload
+	if config.RemoteGzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(payload); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		body = buf.Bytes()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, config.RemoteURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if config.RemoteGzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if config.RemoteBasicAuthUser != "" {
+		req.SetBasicAuth(config.RemoteBasicAuthUser, config.RemoteBasicAuthPass)
+	}
+	if config.RemoteAPIKey != "" {
+		header := config.RemoteAPIKeyHeader
+		if header == "" {
+			header = "x-api-key"
+		}
+		req.Header.Set(header, config.RemoteAPIKey)
+	}
+
+	timeout := config.RemoteRequestTimeout
+	if timeout <= 0 {
+		timeout = defaultRemoteRequestTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		fmt.Printf("Remote logging server returned error status: %d\n", resp.StatusCode)
+		return fmt.Errorf("remote logging server returned error status: %d", resp.StatusCode)
 	}
+	return nil
 }
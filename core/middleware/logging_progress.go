@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+const (
+	// LogPhaseProgress marks the entries logged every LoggingConfig.ProgressInterval while a request is open.
+	LogPhaseProgress = "progress"
+	// LogPhaseSummary marks the final entry of a request that logged progress entries.
+	LogPhaseSummary = "summary"
+)
+
+// RequestProgress logs progress entries of a long-lived request, such as a WebSocket connection,
+// a server-sent event stream or a streamed download, so that dashboards see the traffic while it is
+// active instead of only once it ends. It is returned by BaseLoggingMiddleware.StartProgress.
+type RequestProgress struct {
+	c        core.Context
+	original core.ResponseWriter
+	writer   *progressWriter
+	emitted  atomic.Int64
+	stop     chan struct{}
+	done     sync.WaitGroup
+}
+
+// StartProgress logs a progress entry for the request of c every config.ProgressInterval until Stop
+// is called, with the status and number of bytes sent so far. It replaces the writer of c to count
+// the bytes, including those written to hijacked connections. It returns nil, on which Stop and
+// Summarize do nothing, if config.ProgressInterval is not set.
+func (m *BaseLoggingMiddleware) StartProgress(c core.Context, requestID string, start time.Time, config *core.LoggingConfig) *RequestProgress {
+	if config.ProgressInterval <= 0 {
+		return nil
+	}
+
+	p := &RequestProgress{
+		c:        c,
+		original: c.Writer(),
+		writer:   &progressWriter{ResponseWriter: c.Writer()},
+		stop:     make(chan struct{}),
+	}
+	c.SetWriter(p.writer)
+
+	// The request is read once here, as the handlers may change it while progress entries are logged
+	req := c.Request()
+	clock := core.ClockFromContext(req.Context())
+	base := m.CreateLogEntry(req, c.ClientIP(), 0, 0, requestID, config)
+
+	p.done.Add(1)
+	go func() {
+		defer p.done.Done()
+		ticker := time.NewTicker(config.ProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-req.Context().Done():
+				// The client went away, or a panic ended the request before Stop was called
+				return
+			case <-ticker.C:
+				now := clock.Now()
+				entry := *base
+				entry.Timestamp = now.Format(time.RFC3339)
+				entry.StatusCode = int(p.writer.status.Load())
+				entry.Latency = now.Sub(start).Milliseconds()
+				entry.Phase = LogPhaseProgress
+				entry.BytesSent = p.writer.bytes.Load()
+				m.ProcessLog(&entry, config)
+				p.emitted.Add(1)
+			}
+		}
+	}()
+	return p
+}
+
+// Stop stops the progress entries and restores the writer of the request.
+// Call it once the handlers have returned, before reading the response status for the final entry.
+func (p *RequestProgress) Stop() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	p.done.Wait()
+	p.c.SetWriter(p.original)
+}
+
+// Summarize sets the number of bytes sent on the final entry of the request and, if progress
+// entries were logged, marks it as their summary.
+func (p *RequestProgress) Summarize(logEntry *ApiLog) {
+	if p == nil {
+		return
+	}
+	logEntry.BytesSent = p.writer.bytes.Load()
+	if p.emitted.Load() > 0 {
+		logEntry.Phase = LogPhaseSummary
+	}
+}
+
+// progressWriter counts the bytes of a response for progress entries, which are logged from another goroutine.
+type progressWriter struct {
+	http.ResponseWriter
+	status atomic.Int64 // Status code written, 0 until the header is written
+	bytes  atomic.Int64 // Body bytes sent, including those written to a hijacked connection
+}
+
+// WriteHeader records the status code and forwards it.
+func (w *progressWriter) WriteHeader(code int) {
+	w.status.CompareAndSwap(0, int64(code))
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write counts the bytes written.
+func (w *progressWriter) Write(data []byte) (int, error) {
+	w.status.CompareAndSwap(0, http.StatusOK)
+	n, err := w.ResponseWriter.Write(data)
+	w.bytes.Add(int64(n))
+	return n, err
+}
+
+// Flush sends any buffered data to the client.
+func (w *progressWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack returns the connection of the request, counting the bytes written to it.
+func (w *progressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	counted := &countingConn{Conn: conn, bytes: &w.bytes}
+	// Anything buffered before the hijack is sent as it is
+	if err := rw.Writer.Flush(); err != nil {
+		return nil, nil, err
+	}
+	return counted, bufio.NewReadWriter(rw.Reader, bufio.NewWriterSize(counted, rw.Writer.Size())), nil
+}
+
+// Unwrap returns the underlying ResponseWriter so that http.ResponseController can reach it.
+func (w *progressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// countingConn is a hijacked connection counting the bytes written to it.
+type countingConn struct {
+	net.Conn
+	bytes *atomic.Int64
+}
+
+// Write counts the bytes written.
+func (c *countingConn) Write(data []byte) (int, error) {
+	n, err := c.Conn.Write(data)
+	c.bytes.Add(int64(n))
+	return n, err
+}
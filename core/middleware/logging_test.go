@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+)
+
+func TestRemoteLoggingGzipAndAuth(t *testing.T) {
+	type received struct {
+		entry    middleware.ApiLog
+		user     string
+		password string
+		apiKey   string
+		err      error
+	}
+	ch := make(chan received, 1)
+
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got received
+		got.user, got.password, _ = r.BasicAuth()
+		got.apiKey = r.Header.Get("X-Collector-Key")
+		if r.Header.Get("Content-Encoding") != "gzip" {
+			got.err = http.ErrNotSupported
+		} else if zr, err := gzip.NewReader(r.Body); err != nil {
+			got.err = err
+		} else {
+			got.err = json.NewDecoder(zr).Decode(&got.entry)
+		}
+		ch <- got
+	}))
+	defer remote.Close()
+
+	config := &core.LoggingConfig{
+		RemoteURL:           remote.URL,
+		LoggingToRemote:     true,
+		RemoteGzip:          true,
+		RemoteBasicAuthUser: "collector",
+		RemoteBasicAuthPass: "secret",
+		RemoteAPIKey:        "key",
+		RemoteAPIKeyHeader:  "X-Collector-Key",
+	}
+	logging := &middleware.BaseLoggingMiddleware{}
+	logging.ProcessLog(&middleware.ApiLog{Path: "/users", StatusCode: http.StatusOK}, config)
+
+	select {
+	case got := <-ch:
+		if got.err != nil {
+			t.Fatalf("failed to read remote log payload: %v", got.err)
+		}
+		if got.entry.Path != "/users" || got.entry.StatusCode != http.StatusOK {
+			t.Errorf("remote log entry = %+v, want path /users and status 200", got.entry)
+		}
+		if got.user != "collector" || got.password != "secret" {
+			t.Errorf("basic auth = %q:%q, want collector:secret", got.user, got.password)
+		}
+		if got.apiKey != "key" {
+			t.Errorf("API key header = %q, want key", got.apiKey)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("remote log was not received")
+	}
+}
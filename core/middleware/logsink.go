@@ -0,0 +1,180 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// BatchSinkConfig holds configuration for a BatchSink.
+type BatchSinkConfig struct {
+	// BatchSize is the maximum number of records delivered at once.
+	// If not set, it defaults to 100.
+	BatchSize int
+	// FlushInterval is the maximum time a record waits before its batch is delivered.
+	// If not set, it defaults to 1 second.
+	FlushInterval time.Duration
+	// QueueSize is the number of records buffered while batches are being delivered.
+	// When the queue is full, new records are dropped. If not set, it defaults to 10000.
+	QueueSize int
+	// Timeout bounds the delivery of a single batch. If not set, it defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// DefaultBatchSinkConfig returns a default batch sink configuration.
+func DefaultBatchSinkConfig() *BatchSinkConfig {
+	return &BatchSinkConfig{
+		BatchSize:     100,
+		FlushInterval: time.Second,
+		QueueSize:     10000,
+		Timeout:       10 * time.Second,
+	}
+}
+
+// BatchFunc delivers a batch of log records.
+type BatchFunc func(ctx context.Context, records []core.LogRecord) error
+
+// BatchSink is a core.LogSink that queues records and delivers them asynchronously in batches.
+// It is the building block of the Kafka and NATS sinks and can wrap any other destination.
+// Close must be called on shutdown to deliver the remaining records, e.g. from Server.OnStop.
+type BatchSink struct {
+	deliver BatchFunc
+	config  BatchSinkConfig
+	queue   chan core.LogRecord
+	done    chan struct{}
+	dropped atomic.Int64
+
+	// mu guards closed and the queue: WriteLog sends under the read lock, Close closes the queue
+	// under the write lock, so that no record is sent on the closed queue
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewBatchSink returns a BatchSink that delivers batches with the given function
+// and starts its delivery goroutine.
+func NewBatchSink(deliver BatchFunc, config *BatchSinkConfig) *BatchSink {
+	if deliver == nil {
+		panic("NewBatchSink requires a BatchFunc")
+	}
+
+	defaults := DefaultBatchSinkConfig()
+	if config == nil {
+		config = defaults
+	}
+	cfg := *config
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaults.FlushInterval
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaults.QueueSize
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+
+	s := &BatchSink{
+		deliver: deliver,
+		config:  cfg,
+		queue:   make(chan core.LogRecord, cfg.QueueSize),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// WriteLog implements core.LogSink.WriteLog.
+// It never blocks; records are dropped if the queue is full or the sink is closed.
+func (s *BatchSink) WriteLog(record core.LogRecord) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.dropped.Add(1)
+		return
+	}
+	select {
+	case s.queue <- record:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of records dropped because the queue was full or the sink was closed.
+func (s *BatchSink) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close stops accepting records and delivers the queued ones.
+// It returns ctx.Err() if ctx is done before delivery completes.
+// Its signature matches core.LifecycleHook, so it can be registered with Server.OnStop.
+func (s *BatchSink) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run collects records into batches and delivers them until the queue is closed.
+func (s *BatchSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]core.LogRecord, 0, s.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+		if err := s.deliver(ctx, batch); err != nil {
+			fmt.Printf("Error delivering %d log records: %v\n", len(batch), err)
+		}
+		cancel()
+		batch = make([]core.LogRecord, 0, s.config.BatchSize)
+	}
+
+	for {
+		select {
+		case record, ok := <-s.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) >= s.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// RequestIDKey returns the request ID of a record. It is the default partition key of the Kafka sink.
+func RequestIDKey(record core.LogRecord) string {
+	return record.RequestID
+}
+
+// CustomFieldKey returns a key function that reads the given custom field of a record,
+// e.g. CustomFieldKey("tenant") to partition logs by tenant.
+func CustomFieldKey(field string) func(record core.LogRecord) string {
+	return func(record core.LogRecord) string {
+		return record.CustomFields[field]
+	}
+}
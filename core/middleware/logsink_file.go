@@ -0,0 +1,261 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// backupTimeFormat is the timestamp format used in the names of rotated log files.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// FileSinkConfig holds configuration for the file log sink.
+type FileSinkConfig struct {
+	// Path is the log file path. Rotated files are kept next to it as
+	// <name>-<timestamp><ext>, e.g. api-2024-01-02T15-04-05.000.log.
+	Path string
+	// MaxSize is the size in bytes at which the file is rotated.
+	// If not set, it defaults to 100 MB.
+	MaxSize int64
+	// RotateInterval rotates the file when it is older than the interval, e.g. 24h for daily files.
+	// Time-based rotation is disabled if not set.
+	RotateInterval time.Duration
+	// MaxBackups is the number of rotated files to keep. All are kept if not set.
+	MaxBackups int
+	// MaxAge is the age after which rotated files are deleted. They are kept forever if not set.
+	MaxAge time.Duration
+	// Batch configures batching. If nil, DefaultBatchSinkConfig is used.
+	Batch *BatchSinkConfig
+}
+
+// FileSink is a log sink that writes log entries to a file as newline-delimited JSON,
+// rotating the file by size and age and deleting old files according to the retention settings.
+// Writes are batched and asynchronous; Close must be called on shutdown, e.g. from Server.OnStop.
+type FileSink struct {
+	*BatchSink
+	file *RotatingFile
+}
+
+// NewFileSink opens the log file and returns a FileSink writing to it.
+//
+// Example usage:
+//
+//	sink, err := middleware.NewFileSink(&middleware.FileSinkConfig{
+//		Path:       "/var/log/app/api.log",
+//		MaxSize:    50 << 20,
+//		MaxBackups: 7,
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	s.OnStop(sink.Close)
+//	config.Sinks = append(config.Sinks, sink)
+func NewFileSink(config *FileSinkConfig) (*FileSink, error) {
+	if config == nil {
+		return nil, errors.New("NewFileSink requires a configuration")
+	}
+	file, err := OpenRotatingFile(config)
+	if err != nil {
+		return nil, err
+	}
+
+	sink := &FileSink{file: file}
+	sink.BatchSink = NewBatchSink(func(ctx context.Context, records []core.LogRecord) error {
+		var errs []error
+		for _, record := range records {
+			line := make([]byte, 0, len(record.Data)+1)
+			line = append(append(line, record.Data...), '\n')
+			if _, err := file.Write(line); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}, config.Batch)
+	return sink, nil
+}
+
+// Close delivers the queued records and closes the log file.
+func (s *FileSink) Close(ctx context.Context) error {
+	if err := s.BatchSink.Close(ctx); err != nil {
+		return err
+	}
+	return s.file.Close()
+}
+
+// RotatingFile is an io.WriteCloser that writes to a file and rotates it by size and age.
+// It is safe for concurrent use.
+type RotatingFile struct {
+	config FileSinkConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// OpenRotatingFile opens (appending to) or creates the file at config.Path.
+func OpenRotatingFile(config *FileSinkConfig) (*RotatingFile, error) {
+	if config.Path == "" {
+		return nil, errors.New("rotating log file requires a Path")
+	}
+	cfg := *config
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 100 << 20 // 100 MB
+	}
+
+	f := &RotatingFile{config: cfg, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes p to the file, rotating it first if the write would exceed MaxSize
+// or the file is older than RotateInterval.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	expired := f.config.RotateInterval > 0 && f.now().Sub(f.openedAt) >= f.config.RotateInterval
+	if f.size > 0 && (f.size+int64(len(p)) > f.config.MaxSize || expired) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it with a timestamp and opens a new file.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rotate()
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// open opens the log file for appending.
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.config.Path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+	return nil
+}
+
+// rotate renames the current file and opens a new one. The caller must hold f.mu.
+func (f *RotatingFile) rotate() error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+
+	if err := os.Rename(f.config.Path, f.backupName(f.now())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	if err := f.removeOldBackups(); err != nil {
+		fmt.Printf("Error removing old log files: %v\n", err)
+	}
+	return nil
+}
+
+// backupName returns the name of the file rotated at t.
+func (f *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(f.config.Path)
+	prefix := strings.TrimSuffix(f.config.Path, ext)
+	return prefix + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// removeOldBackups deletes rotated files beyond MaxBackups or older than MaxAge.
+func (f *RotatingFile) removeOldBackups() error {
+	if f.config.MaxBackups <= 0 && f.config.MaxAge <= 0 {
+		return nil
+	}
+
+	ext := filepath.Ext(f.config.Path)
+	prefix := filepath.Base(strings.TrimSuffix(f.config.Path, ext)) + "-"
+	dir := filepath.Dir(f.config.Path)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	type backup struct {
+		path string
+		time time.Time
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext), time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), time: t})
+	}
+
+	// Newest first
+	sort.Slice(backups, func(i, j int) bool { return backups[i].time.After(backups[j].time) })
+
+	var errs []error
+	for i, b := range backups {
+		tooMany := f.config.MaxBackups > 0 && i >= f.config.MaxBackups
+		tooOld := f.config.MaxAge > 0 && f.now().Sub(b.time) > f.config.MaxAge
+		if tooMany || tooOld {
+			if err := os.Remove(b.path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
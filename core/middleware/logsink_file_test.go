@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	f, err := middleware.OpenRotatingFile(&middleware.FileSinkConfig{
+		Path:       filepath.Join(dir, "api.log"),
+		MaxSize:    15,
+		MaxBackups: 2,
+	})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile() returned error: %v", err)
+	}
+	defer f.Close()
+
+	for i := 0; i < 5; i++ {
+		if _, err := f.Write([]byte("0123456789")); err != nil {
+			t.Fatalf("Write() returned error: %v", err)
+		}
+		// Rotated files are named by millisecond timestamp
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backups int
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "api-") && strings.HasSuffix(entry.Name(), ".log") {
+			backups++
+		}
+	}
+	if backups != 2 {
+		t.Errorf("found %d rotated files, want 2 (MaxBackups)", backups)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "api.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "0123456789" {
+		t.Errorf("current file = %q, want the last write only", data)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "api.log")
+	sink, err := middleware.NewFileSink(&middleware.FileSinkConfig{Path: path})
+	if err != nil {
+		t.Fatalf("NewFileSink() returned error: %v", err)
+	}
+
+	sink.WriteLog(core.LogRecord{Data: []byte(`{"path":"/a"}`)})
+	sink.WriteLog(core.LogRecord{Data: []byte(`{"path":"/b"}`)})
+	if err := sink.Close(context.Background()); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"path\":\"/a\"}\n{\"path\":\"/b\"}\n"; string(data) != want {
+		t.Errorf("log file = %q, want %q", data, want)
+	}
+}
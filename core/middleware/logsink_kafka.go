@@ -0,0 +1,81 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"context"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// KafkaMessage is a message produced to a Kafka topic.
+type KafkaMessage struct {
+	// Key selects the partition of the message
+	Key []byte
+	// Value is the JSON-encoded log entry
+	Value []byte
+}
+
+// KafkaProducer produces messages to Kafka.
+// The library does not depend on a Kafka client; implement this interface with a small
+// adapter around the client used by the application, e.g. for github.com/segmentio/kafka-go:
+//
+//	type kafkaProducer struct{ w *kafka.Writer }
+//
+//	func (p kafkaProducer) Produce(ctx context.Context, topic string, messages []middleware.KafkaMessage) error {
+//		msgs := make([]kafka.Message, len(messages))
+//		for i, m := range messages {
+//			msgs[i] = kafka.Message{Topic: topic, Key: m.Key, Value: m.Value}
+//		}
+//		return p.w.WriteMessages(ctx, msgs...)
+//	}
+type KafkaProducer interface {
+	// Produce writes the messages to the topic
+	Produce(ctx context.Context, topic string, messages []KafkaMessage) error
+}
+
+// KafkaSinkConfig holds configuration for the Kafka log sink.
+type KafkaSinkConfig struct {
+	// Producer is the implementation of KafkaProducer
+	Producer KafkaProducer
+	// Topic is the topic log entries are produced to
+	Topic string
+	// PartitionKey returns the message key of a record.
+	// If not set, it defaults to RequestIDKey; use CustomFieldKey("tenant") to partition by tenant.
+	PartitionKey func(record core.LogRecord) string
+	// Batch configures batching. If nil, DefaultBatchSinkConfig is used.
+	Batch *BatchSinkConfig
+}
+
+// NewKafkaSink returns a log sink that produces log entries to a Kafka topic asynchronously, in batches.
+// Add it to LoggingConfig.Sinks and register its Close method with Server.OnStop.
+//
+// Example usage:
+//
+//	sink := middleware.NewKafkaSink(&middleware.KafkaSinkConfig{
+//		Producer: kafkaProducer{w: writer},
+//		Topic:    "api-logs",
+//	})
+//	s.OnStop(sink.Close)
+//	config.Sinks = append(config.Sinks, sink)
+func NewKafkaSink(config *KafkaSinkConfig) *BatchSink {
+	if config == nil || config.Producer == nil {
+		panic("NewKafkaSink requires a KafkaProducer implementation")
+	}
+	if config.Topic == "" {
+		panic("NewKafkaSink requires a Topic")
+	}
+
+	partitionKey := config.PartitionKey
+	if partitionKey == nil {
+		partitionKey = RequestIDKey
+	}
+	producer, topic := config.Producer, config.Topic
+
+	return NewBatchSink(func(ctx context.Context, records []core.LogRecord) error {
+		messages := make([]KafkaMessage, len(records))
+		for i, record := range records {
+			messages[i] = KafkaMessage{Key: []byte(partitionKey(record)), Value: record.Data}
+		}
+		return producer.Produce(ctx, topic, messages)
+	}, config.Batch)
+}
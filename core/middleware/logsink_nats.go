@@ -0,0 +1,68 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// JetStreamPublisher publishes messages to NATS JetStream.
+// The library does not depend on a NATS client; implement this interface with a small
+// adapter around the client used by the application, e.g. for github.com/nats-io/nats.go/jetstream:
+//
+//	type jetStreamPublisher struct{ js jetstream.JetStream }
+//
+//	func (p jetStreamPublisher) Publish(ctx context.Context, subject string, data []byte) error {
+//		_, err := p.js.Publish(ctx, subject, data)
+//		return err
+//	}
+type JetStreamPublisher interface {
+	// Publish publishes data to the subject and waits for the acknowledgement
+	Publish(ctx context.Context, subject string, data []byte) error
+}
+
+// NATSSinkConfig holds configuration for the NATS JetStream log sink.
+type NATSSinkConfig struct {
+	// Publisher is the implementation of JetStreamPublisher
+	Publisher JetStreamPublisher
+	// Subject is the subject log entries are published to, e.g. "logs.api"
+	Subject string
+	// SubjectKey optionally returns a token appended to Subject for each record,
+	// e.g. CustomFieldKey("tenant") publishes to "logs.api.<tenant>". Empty tokens are not appended.
+	SubjectKey func(record core.LogRecord) string
+	// Batch configures batching. If nil, DefaultBatchSinkConfig is used.
+	Batch *BatchSinkConfig
+}
+
+// NewNATSSink returns a log sink that publishes log entries to NATS JetStream asynchronously, in batches.
+// Add it to LoggingConfig.Sinks and register its Close method with Server.OnStop.
+func NewNATSSink(config *NATSSinkConfig) *BatchSink {
+	if config == nil || config.Publisher == nil {
+		panic("NewNATSSink requires a JetStreamPublisher implementation")
+	}
+	if config.Subject == "" {
+		panic("NewNATSSink requires a Subject")
+	}
+
+	publisher, subject, subjectKey := config.Publisher, config.Subject, config.SubjectKey
+
+	return NewBatchSink(func(ctx context.Context, records []core.LogRecord) error {
+		var errs []error
+		for _, record := range records {
+			target := subject
+			if subjectKey != nil {
+				// Dots and spaces would change the subject hierarchy
+				if token := strings.NewReplacer(".", "_", " ", "_").Replace(subjectKey(record)); token != "" {
+					target += "." + token
+				}
+			}
+			if err := publisher.Publish(ctx, target, record.Data); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}, config.Batch)
+}
@@ -0,0 +1,130 @@
+package middleware_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+)
+
+type fakeKafkaProducer struct {
+	mu       sync.Mutex
+	topic    string
+	batches  [][]middleware.KafkaMessage
+	messages int
+}
+
+func (p *fakeKafkaProducer) Produce(ctx context.Context, topic string, messages []middleware.KafkaMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.topic = topic
+	p.batches = append(p.batches, messages)
+	p.messages += len(messages)
+	return nil
+}
+
+type fakeJetStreamPublisher struct {
+	mu       sync.Mutex
+	subjects []string
+}
+
+func (p *fakeJetStreamPublisher) Publish(ctx context.Context, subject string, data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subjects = append(p.subjects, subject)
+	return nil
+}
+
+func TestKafkaSink(t *testing.T) {
+	producer := &fakeKafkaProducer{}
+	sink := middleware.NewKafkaSink(&middleware.KafkaSinkConfig{
+		Producer: producer,
+		Topic:    "api-logs",
+		Batch:    &middleware.BatchSinkConfig{BatchSize: 2, FlushInterval: time.Hour},
+	})
+
+	for _, id := range []string{"a", "b", "c"} {
+		sink.WriteLog(core.LogRecord{RequestID: id, Data: []byte(`{}`)})
+	}
+	if err := sink.Close(context.Background()); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+
+	if producer.topic != "api-logs" {
+		t.Errorf("topic = %q, want api-logs", producer.topic)
+	}
+	if len(producer.batches) != 2 || producer.messages != 3 {
+		t.Fatalf("got %d batches with %d messages, want 2 batches with 3 messages", len(producer.batches), producer.messages)
+	}
+	if key := string(producer.batches[0][0].Key); key != "a" {
+		t.Errorf("partition key = %q, want request ID a", key)
+	}
+
+	// Records written after Close are dropped
+	sink.WriteLog(core.LogRecord{RequestID: "d"})
+	if sink.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", sink.Dropped())
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	publisher := &fakeJetStreamPublisher{}
+	sink := middleware.NewNATSSink(&middleware.NATSSinkConfig{
+		Publisher:  publisher,
+		Subject:    "logs.api",
+		SubjectKey: middleware.CustomFieldKey("tenant"),
+		Batch:      &middleware.BatchSinkConfig{FlushInterval: 10 * time.Millisecond},
+	})
+
+	sink.WriteLog(core.LogRecord{CustomFields: map[string]string{"tenant": "acme.eu"}})
+	sink.WriteLog(core.LogRecord{})
+	if err := sink.Close(context.Background()); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+
+	want := []string{"logs.api.acme_eu", "logs.api"}
+	if len(publisher.subjects) != len(want) {
+		t.Fatalf("subjects = %v, want %v", publisher.subjects, want)
+	}
+	for i := range want {
+		if publisher.subjects[i] != want[i] {
+			t.Errorf("subjects[%d] = %q, want %q", i, publisher.subjects[i], want[i])
+		}
+	}
+}
+
+func TestBatchSinkWriteLogDuringClose(t *testing.T) {
+	var mu sync.Mutex
+	delivered := 0
+	sink := middleware.NewBatchSink(func(ctx context.Context, records []core.LogRecord) error {
+		mu.Lock()
+		defer mu.Unlock()
+		delivered += len(records)
+		return nil
+	}, nil)
+
+	// Requests still logging while the sink closes must not panic
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				sink.WriteLog(core.LogRecord{RequestID: "req"})
+			}
+		}()
+	}
+	if err := sink.Close(context.Background()); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if total := int64(delivered) + sink.Dropped(); total != 8*200 {
+		t.Errorf("delivered %d and dropped %d records, want %d in total", delivered, sink.Dropped(), 8*200)
+	}
+}
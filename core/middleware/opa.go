@@ -0,0 +1,289 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/errors"
+	"github.com/mythofleader/go-http-server/core/middleware/util"
+)
+
+// OPAInput is the input document sent to Open Policy Agent for each request.
+type OPAInput struct {
+	// Method is the request method, e.g. "GET"
+	Method string `json:"method"`
+	// Path is the request path, e.g. "/orders/42"
+	Path string `json:"path"`
+	// Segments is the request path split at slashes, e.g. ["orders", "42"]
+	Segments []string `json:"segments"`
+	// User is the user stored by the auth middleware, nil for anonymous requests
+	User interface{} `json:"user"`
+	// Headers holds the request headers with lowercase names, see OPAConfig.Headers
+	Headers map[string]string `json:"headers"`
+	// Body is the parsed JSON request body if OPAConfig.IncludeBody is set
+	Body interface{} `json:"body,omitempty"`
+}
+
+// OPAResult is the decision of a policy.
+type OPAResult struct {
+	// Allow is whether the request may proceed
+	Allow bool `json:"allow"`
+	// Reason explains a denial; it is sent to the client as the error message if set
+	Reason string `json:"reason,omitempty"`
+}
+
+// OPAEvaluator evaluates a policy for an input document.
+// NewOPAClient returns an evaluator querying an OPA server, such as a sidecar. To evaluate an
+// embedded rego policy, wrap a prepared query of github.com/open-policy-agent/opa/rego in an
+// OPAEvaluatorFunc; the library does not depend on OPA:
+//
+//	query, err := rego.New(rego.Query("data.httpapi.authz.allow"), rego.Module("authz.rego", policy)).
+//		PrepareForEval(ctx)
+//	evaluator := middleware.OPAEvaluatorFunc(func(ctx context.Context, input *middleware.OPAInput) (middleware.OPAResult, error) {
+//		results, err := query.Eval(ctx, rego.EvalInput(input))
+//		if err != nil {
+//			return middleware.OPAResult{}, err
+//		}
+//		return middleware.OPAResult{Allow: results.Allowed()}, nil
+//	})
+type OPAEvaluator interface {
+	// Evaluate returns the decision of the policy for input
+	Evaluate(ctx context.Context, input *OPAInput) (OPAResult, error)
+}
+
+// OPAEvaluatorFunc is an adapter to allow the use of ordinary functions as OPA evaluators.
+type OPAEvaluatorFunc func(ctx context.Context, input *OPAInput) (OPAResult, error)
+
+// Evaluate calls f(ctx, input).
+func (f OPAEvaluatorFunc) Evaluate(ctx context.Context, input *OPAInput) (OPAResult, error) {
+	return f(ctx, input)
+}
+
+// opaClient queries the Data API of an OPA server.
+type opaClient struct {
+	url    string
+	client *http.Client
+}
+
+// NewOPAClient returns an evaluator querying the Data API of an OPA server at url, the URL
+// of the policy decision, e.g. "http://localhost:8181/v1/data/httpapi/authz".
+// The decision may be a boolean or an object with "allow" and "reason" fields; an undefined
+// decision denies the request. If client is nil, a client with a 2 second timeout is used.
+func NewOPAClient(url string, client *http.Client) OPAEvaluator {
+	if client == nil {
+		client = &http.Client{Timeout: 2 * time.Second}
+	}
+	return &opaClient{url: url, client: client}
+}
+
+// Evaluate implements OPAEvaluator.Evaluate.
+func (o *opaClient) Evaluate(ctx context.Context, input *OPAInput) (OPAResult, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return OPAResult{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.url, bytes.NewReader(body))
+	if err != nil {
+		return OPAResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return OPAResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return OPAResult{}, fmt.Errorf("OPA returned status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return OPAResult{}, err
+	}
+
+	// An undefined decision has no result
+	var result OPAResult
+	switch {
+	case len(response.Result) == 0 || string(response.Result) == "null":
+	case response.Result[0] == '{':
+		err = json.Unmarshal(response.Result, &result)
+	default:
+		err = json.Unmarshal(response.Result, &result.Allow)
+	}
+	return result, err
+}
+
+// OPAConfig holds configuration for the OPA middleware.
+type OPAConfig struct {
+	// Evaluator evaluates the policy, e.g. NewOPAClient for an OPA sidecar
+	Evaluator OPAEvaluator
+
+	// Headers lists the request headers sent to the policy. If empty, all headers are sent
+	// except Authorization, Cookie and Proxy-Authorization.
+	Headers []string
+
+	// IncludeBody sends the parsed body of JSON requests to the policy. The body stays
+	// readable by the handler.
+	IncludeBody bool
+
+	// FailOpen lets requests through when the policy cannot be evaluated.
+	// By default, they are rejected with 503 Service Unavailable.
+	FailOpen bool
+
+	// DecisionLogger logs every decision with the method, path, result and evaluation time,
+	// at info level for allowed requests, warn for denied requests and error for failed evaluations.
+	DecisionLogger core.Logger
+
+	// SkipPaths is a list of paths that are not evaluated
+	SkipPaths []string
+
+	// Optional: custom error message, used when a denial has no reason
+	ForbiddenMessage string
+}
+
+// DefaultOPAConfig returns a default OPA configuration.
+// The Evaluator is nil by default and must be provided by the user.
+func DefaultOPAConfig() *OPAConfig {
+	return &OPAConfig{
+		ForbiddenMessage: "Forbidden",
+	}
+}
+
+// OPAMiddleware returns a middleware function that sends the attributes of each request to
+// Open Policy Agent and enforces the decision: denied requests are rejected with 403 Forbidden.
+// Register it after the auth middleware, so that the policy can decide on the user.
+// Example usage:
+//
+//	config := middleware.DefaultOPAConfig()
+//	config.Evaluator = middleware.NewOPAClient("http://localhost:8181/v1/data/httpapi/authz", nil)
+//	config.DecisionLogger = middleware.NewSlogLogger(nil)
+//	s.Use(middleware.OPAMiddleware(config))
+func OPAMiddleware(config *OPAConfig) core.HandlerFunc {
+	if config == nil || config.Evaluator == nil {
+		panic("OPAMiddleware requires an OPAEvaluator implementation")
+	}
+	message := config.ForbiddenMessage
+	if message == "" {
+		message = "Forbidden"
+	}
+
+	skipPaths := util.CompilePaths(config.SkipPaths)
+
+	return func(c core.Context) {
+		req := c.Request()
+		if skipPaths.Match(req.URL.Path) {
+			c.Next()
+			return
+		}
+
+		input := opaInput(c, config)
+		clock := core.ClockFromContext(req.Context())
+		start := clock.Now()
+		result, err := config.Evaluator.Evaluate(req.Context(), input)
+		if config.DecisionLogger != nil {
+			logOPADecision(config.DecisionLogger, input, result, err, clock.Now().Sub(start))
+		}
+
+		if err != nil {
+			if config.FailOpen {
+				c.Next()
+				return
+			}
+			c.JSON(http.StatusServiceUnavailable, errors.NewServiceUnavailableResponse("Policy evaluation failed"))
+			c.Abort()
+			return
+		}
+		if !result.Allow {
+			reason := message
+			if result.Reason != "" {
+				reason = result.Reason
+			}
+			c.JSON(http.StatusForbidden, errors.NewForbiddenResponse(reason))
+			c.Abort()
+			return
+		}
+
+		// Continue with the next middleware/handler in the chain
+		c.Next()
+	}
+}
+
+// opaCredentialHeaders are the headers not sent to the policy unless listed in OPAConfig.Headers.
+var opaCredentialHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+}
+
+// opaInput returns the input document of the request.
+func opaInput(c core.Context, config *OPAConfig) *OPAInput {
+	req := c.Request()
+	input := &OPAInput{
+		Method:   req.Method,
+		Path:     req.URL.Path,
+		Segments: strings.FieldsFunc(req.URL.Path, func(r rune) bool { return r == '/' }),
+		Headers:  make(map[string]string),
+	}
+	input.User, _ = GetUserFromContext(req.Context())
+
+	if len(config.Headers) > 0 {
+		for _, name := range config.Headers {
+			if value := req.Header.Get(name); value != "" {
+				input.Headers[strings.ToLower(name)] = value
+			}
+		}
+	} else {
+		for name, values := range req.Header {
+			if !opaCredentialHeaders[name] && len(values) > 0 {
+				input.Headers[strings.ToLower(name)] = values[0]
+			}
+		}
+	}
+
+	if config.IncludeBody {
+		// Bodies that are not JSON, too large or malformed are left out
+		if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType == "application/json" {
+			if data, err := c.GetRawData(); err == nil && len(data) > 0 {
+				var body interface{}
+				if json.Unmarshal(data, &body) == nil {
+					input.Body = body
+				}
+			}
+		}
+	}
+	return input
+}
+
+// logOPADecision logs the decision of a request to logger.
+func logOPADecision(logger core.Logger, input *OPAInput, result OPAResult, err error, elapsed time.Duration) {
+	fields := []interface{}{
+		"method", input.Method,
+		"path", input.Path,
+		"allow", result.Allow,
+		"duration_ms", float64(elapsed.Microseconds()) / 1000,
+	}
+	switch {
+	case err != nil:
+		logger.Log(core.LogLevelError, "policy evaluation failed", append(fields, "error", err.Error())...)
+	case result.Allow:
+		logger.Log(core.LogLevelInfo, "policy decision", fields...)
+	default:
+		if result.Reason != "" {
+			fields = append(fields, "reason", result.Reason)
+		}
+		logger.Log(core.LogLevelWarn, "policy decision", fields...)
+	}
+}
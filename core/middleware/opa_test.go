@@ -0,0 +1,130 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+// recordingLogger is a core.Logger recording the levels and messages it logs.
+type recordingLogger struct {
+	entries []string
+}
+
+func (l *recordingLogger) Log(level core.LogLevel, msg string, keysAndValues ...interface{}) {
+	l.entries = append(l.entries, level.String()+" "+msg)
+}
+
+func newOPAServer(config *middleware.OPAConfig) core.Server {
+	s := std.NewServer("8080", false)
+	s.Use(middleware.OPAMiddleware(config))
+	s.POST("/orders", func(c core.Context) {
+		data, _ := c.GetRawData()
+		c.String(http.StatusOK, "%s", data)
+	})
+	s.GET("/orders/:id", func(c core.Context) {
+		c.String(http.StatusOK, "order")
+	})
+	return s
+}
+
+func TestOPAMiddlewareWithOPAServer(t *testing.T) {
+	// The OPA server allows small orders and reading order 42
+	opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Input middleware.OPAInput `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("OPA received an invalid request: %v", err)
+		}
+		input := request.Input
+		if _, ok := input.Headers["authorization"]; ok {
+			t.Error("OPA received the Authorization header")
+		}
+		switch {
+		case input.Method == http.MethodGet && len(input.Segments) == 2 && input.Segments[1] == "42":
+			w.Write([]byte(`{"result": true}`))
+		case input.Method == http.MethodGet:
+			w.Write([]byte(`{}`))
+		case input.Body.(map[string]interface{})["amount"].(float64) < 100:
+			w.Write([]byte(`{"result": {"allow": true}}`))
+		default:
+			w.Write([]byte(`{"result": {"allow": false, "reason": "amount over limit"}}`))
+		}
+	}))
+	defer opa.Close()
+
+	logger := &recordingLogger{}
+	config := middleware.DefaultOPAConfig()
+	config.Evaluator = middleware.NewOPAClient(opa.URL+"/v1/data/httpapi/authz", nil)
+	config.IncludeBody = true
+	config.DecisionLogger = logger
+	client := servertest.NewClient(newOPAServer(config))
+
+	client.GET("/orders/42").WithHeader("Authorization", "Bearer token").Expect(t).
+		Status(http.StatusOK)
+	// An undefined decision denies the request
+	client.GET("/orders/7").Expect(t).
+		Status(http.StatusForbidden).
+		JSONPath("$.error.message", "Forbidden")
+	// The body is still readable by the handler
+	client.POST("/orders").WithBody("application/json", []byte(`{"amount": 10}`)).Expect(t).
+		Status(http.StatusOK).
+		Body(`{"amount": 10}`)
+	client.POST("/orders").WithBody("application/json", []byte(`{"amount": 500}`)).Expect(t).
+		Status(http.StatusForbidden).
+		JSONPath("$.error.message", "amount over limit")
+
+	want := []string{"info policy decision", "warn policy decision", "info policy decision", "warn policy decision"}
+	if len(logger.entries) != len(want) {
+		t.Fatalf("logged %v, want %v", logger.entries, want)
+	}
+	for i := range want {
+		if logger.entries[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, logger.entries[i], want[i])
+		}
+	}
+}
+
+func TestOPAMiddlewareEvaluationErrors(t *testing.T) {
+	failing := middleware.OPAEvaluatorFunc(func(context.Context, *middleware.OPAInput) (middleware.OPAResult, error) {
+		return middleware.OPAResult{}, errors.New("OPA unavailable")
+	})
+
+	logger := &recordingLogger{}
+	config := &middleware.OPAConfig{Evaluator: failing, DecisionLogger: logger}
+	servertest.NewClient(newOPAServer(config)).
+		GET("/orders/42").Expect(t).Status(http.StatusServiceUnavailable)
+	if len(logger.entries) != 1 || logger.entries[0] != "error policy evaluation failed" {
+		t.Errorf("logged %v, want the failed evaluation", logger.entries)
+	}
+
+	config.FailOpen = true
+	servertest.NewClient(newOPAServer(config)).
+		GET("/orders/42").Expect(t).Status(http.StatusOK)
+}
+
+func TestOPAMiddlewareHeaders(t *testing.T) {
+	var headers map[string]string
+	config := &middleware.OPAConfig{
+		Evaluator: middleware.OPAEvaluatorFunc(func(ctx context.Context, input *middleware.OPAInput) (middleware.OPAResult, error) {
+			headers = input.Headers
+			return middleware.OPAResult{Allow: true}, nil
+		}),
+		Headers: []string{"X-Tenant"},
+	}
+	servertest.NewClient(newOPAServer(config)).
+		GET("/orders/42").WithHeader("X-Tenant", "acme").WithHeader("X-Other", "1").Expect(t).
+		Status(http.StatusOK)
+	if len(headers) != 1 || headers["x-tenant"] != "acme" {
+		t.Errorf("OPA received headers %v, want only x-tenant", headers)
+	}
+}
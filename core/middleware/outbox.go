@@ -0,0 +1,246 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// ErrNoOutbox is returned by StageEvents and CommitJSON if the outbox middleware is not registered
+// for the request, in which case staged events would never be published.
+var ErrNoOutbox = errors.New("outbox middleware is not registered for this request")
+
+// outboxKey is the context key of the events staged for a request.
+const outboxKey = "outbox:events"
+
+// OutboxEvent is a domain event staged by a handler, published once its response has been written.
+type OutboxEvent struct {
+	// Topic names the kind of the event or its destination, e.g. "order.created".
+	Topic string
+
+	// Key identifies the entity of the event, e.g. for partitioning. Optional.
+	Key string
+
+	// Payload is the data of the event.
+	Payload interface{}
+}
+
+// OutboxPublisher publishes the domain events of a request, e.g. to a message broker.
+type OutboxPublisher interface {
+	// Publish publishes the events of a request, in the order they were staged
+	Publish(ctx context.Context, events []OutboxEvent) error
+}
+
+// OutboxPublisherFunc is an adapter to allow the use of ordinary functions as outbox publishers.
+type OutboxPublisherFunc func(ctx context.Context, events []OutboxEvent) error
+
+// Publish calls f(ctx, events).
+func (f OutboxPublisherFunc) Publish(ctx context.Context, events []OutboxEvent) error {
+	return f(ctx, events)
+}
+
+// OutboxConfig holds configuration for the outbox.
+type OutboxConfig struct {
+	// Publisher publishes the events of successful requests.
+	Publisher OutboxPublisher
+
+	// Timeout limits the time a publication may take.
+	Timeout time.Duration
+
+	// OnError is called when the events of a request could not be published, with the context passed
+	// to the publisher, which carries the request ID. If nil, the failure is logged as a warning.
+	OnError func(ctx context.Context, events []OutboxEvent, err error)
+}
+
+// DefaultOutboxConfig returns a default outbox configuration.
+func DefaultOutboxConfig() *OutboxConfig {
+	return &OutboxConfig{
+		Publisher: nil, // Must be provided
+		Timeout:   10 * time.Second,
+	}
+}
+
+// Validate checks that the configuration has a publisher and a usable timeout, returning a *ConfigError if not.
+func (config *OutboxConfig) Validate() error {
+	if config.Publisher == nil {
+		return &ConfigError{
+			Middleware: "Outbox",
+			Field:      "Publisher",
+			Problem:    "must not be nil",
+			Remedy:     "set Publisher to the publisher of your message broker, or wrap a function with OutboxPublisherFunc",
+		}
+	}
+	if config.Timeout < 0 {
+		return &ConfigError{
+			Middleware: "Outbox",
+			Field:      "Timeout",
+			Problem:    "must not be negative",
+		}
+	}
+	return nil
+}
+
+// Outbox publishes the domain events staged by handlers only once their response has been written
+// successfully, so that clients never see an error for a change whose events went out, and events
+// are never published for a request that failed. Events are published in the background, after
+// the handlers return, so publishing never delays the response; failures are passed to OnError.
+//
+// Example usage:
+//
+//	outbox, err := middleware.NewOutbox(&middleware.OutboxConfig{Publisher: publisher})
+//	s.Use(outbox.Middleware())
+//	s.OnStop(outbox.Wait)
+//
+//	s.POST("/orders", func(c core.Context) {
+//		order := createOrder(c)
+//		_ = middleware.CommitJSON(c, http.StatusCreated, order,
+//			middleware.OutboxEvent{Topic: "order.created", Key: order.ID, Payload: order})
+//	})
+type Outbox struct {
+	config  *OutboxConfig
+	pending sync.WaitGroup
+}
+
+// NewOutbox returns an outbox with config, or a *ConfigError if the configuration is invalid.
+// A zero timeout is replaced with that of DefaultOutboxConfig.
+func NewOutbox(config *OutboxConfig) (*Outbox, error) {
+	if config == nil {
+		config = DefaultOutboxConfig()
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	c := *config
+	if c.Timeout == 0 {
+		c.Timeout = DefaultOutboxConfig().Timeout
+	}
+	if c.OnError == nil {
+		c.OnError = logOutboxError
+	}
+	return &Outbox{config: &c}, nil
+}
+
+// logOutboxError logs the events of a request that could not be published.
+func logOutboxError(ctx context.Context, events []OutboxEvent, err error) {
+	log.Printf("[WARNING] outbox: failed to publish %d events of request %q: %v", len(events), core.RequestIDFromContext(ctx), err)
+}
+
+// stagedEvents are the events staged by the handlers of a request.
+type stagedEvents struct {
+	mu     sync.Mutex
+	events []OutboxEvent
+}
+
+// outboxWriter records whether writing the response failed, e.g. because the client went away.
+type outboxWriter struct {
+	http.ResponseWriter
+	err error
+}
+
+// Write records the first write error.
+func (w *outboxWriter) Write(data []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(data)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// Flush sends any buffered data to the client.
+func (w *outboxWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter so that http.ResponseController can reach it.
+func (w *outboxWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Middleware returns a middleware function that collects the events staged by the handlers of a
+// request and publishes them once the handlers have returned, if the response has a status below
+// 400, was written without error, and no error was added with c.Error. Otherwise they are discarded.
+func (o *Outbox) Middleware() core.HandlerFunc {
+	return func(c core.Context) {
+		staged := &stagedEvents{}
+		c.Set(outboxKey, staged)
+
+		originalWriter := c.Writer()
+		writer := &outboxWriter{ResponseWriter: originalWriter}
+		c.SetWriter(writer)
+		c.Next()
+		c.SetWriter(originalWriter)
+
+		staged.mu.Lock()
+		events := staged.events
+		staged.events = nil
+		staged.mu.Unlock()
+		if len(events) == 0 {
+			return
+		}
+
+		req := c.Request()
+		status := core.EffectiveStatus(req.Context(), c.Writer().Status())
+		if status >= http.StatusBadRequest || writer.err != nil || len(c.Errors()) > 0 {
+			return
+		}
+
+		// The request context is canceled once the response is sent, unlike the detached context
+		ctx := c.DetachedContext()
+		o.pending.Add(1)
+		go func() {
+			defer o.pending.Done()
+			ctx, cancel := context.WithTimeout(ctx, o.config.Timeout)
+			defer cancel()
+			if err := o.config.Publisher.Publish(ctx, events); err != nil {
+				o.config.OnError(ctx, events, err)
+			}
+		}()
+	}
+}
+
+// Wait waits until the events of all requests have been published or ctx is done, e.g. when the
+// server shuts down. It has the signature of a lifecycle hook, so it can be passed to OnStop.
+func (o *Outbox) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		o.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// StageEvents stages events to be published by the outbox once the response of the request has been
+// written successfully. It returns ErrNoOutbox if the outbox middleware is not registered.
+func StageEvents(c core.Context, events ...OutboxEvent) error {
+	value, ok := c.Get(outboxKey)
+	if !ok {
+		return ErrNoOutbox
+	}
+	staged := value.(*stagedEvents)
+	staged.mu.Lock()
+	defer staged.mu.Unlock()
+	staged.events = append(staged.events, events...)
+	return nil
+}
+
+// CommitJSON stages events and writes obj as the JSON response with status code in one step, so the
+// events are published if and only if the response is sent. Like StageEvents, it returns ErrNoOutbox,
+// without writing the response, if the outbox middleware is not registered.
+func CommitJSON(c core.Context, code int, obj interface{}, events ...OutboxEvent) error {
+	if err := StageEvents(c, events...); err != nil {
+		return err
+	}
+	c.JSON(code, obj)
+	return nil
+}
@@ -0,0 +1,129 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+// recordingPublisher records the topics of the events it publishes.
+type recordingPublisher struct {
+	mu     sync.Mutex
+	topics []string
+	err    error
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, events []middleware.OutboxEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, event := range events {
+		p.topics = append(p.topics, event.Topic)
+	}
+	return p.err
+}
+
+func (p *recordingPublisher) published() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.topics...)
+}
+
+func TestOutbox(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			publisher := &recordingPublisher{}
+			outbox, err := middleware.NewOutbox(&middleware.OutboxConfig{Publisher: publisher})
+			if err != nil {
+				t.Fatalf("NewOutbox() returned error: %v", err)
+			}
+			s.Use(outbox.Middleware())
+			s.POST("/orders", func(c core.Context) {
+				_ = middleware.StageEvents(c, middleware.OutboxEvent{Topic: "order.created"})
+				_ = middleware.CommitJSON(c, http.StatusCreated, map[string]string{"id": "1"},
+					middleware.OutboxEvent{Topic: "stock.reserved"})
+			})
+			s.POST("/failed", func(c core.Context) {
+				_ = middleware.StageEvents(c, middleware.OutboxEvent{Topic: "failed.status"})
+				c.JSON(http.StatusConflict, map[string]string{"error": "conflict"})
+			})
+			s.POST("/error", func(c core.Context) {
+				_ = middleware.StageEvents(c, middleware.OutboxEvent{Topic: "failed.error"})
+				_ = c.Error(errors.New("storage failed"))
+			})
+			client := servertest.NewClient(s)
+
+			client.POST("/orders").Expect(t).Status(http.StatusCreated)
+			client.POST("/failed").Expect(t).Status(http.StatusConflict)
+			client.POST("/error").Expect(t)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := outbox.Wait(ctx); err != nil {
+				t.Fatalf("Wait() returned error: %v", err)
+			}
+			got := publisher.published()
+			if len(got) != 2 || got[0] != "order.created" || got[1] != "stock.reserved" {
+				t.Errorf("published %v, want [order.created stock.reserved]", got)
+			}
+		})
+	}
+}
+
+func TestOutboxPublishError(t *testing.T) {
+	failures := make(chan int, 1)
+	outbox, err := middleware.NewOutbox(&middleware.OutboxConfig{
+		Publisher: &recordingPublisher{err: errors.New("broker unavailable")},
+		OnError: func(_ context.Context, events []middleware.OutboxEvent, _ error) {
+			failures <- len(events)
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewOutbox() returned error: %v", err)
+	}
+	s := std.NewServer("8080", false)
+	s.Use(outbox.Middleware())
+	s.POST("/orders", func(c core.Context) {
+		_ = middleware.CommitJSON(c, http.StatusCreated, "ok",
+			middleware.OutboxEvent{Topic: "order.created"}, middleware.OutboxEvent{Topic: "stock.reserved"})
+	})
+
+	// The response doesn't depend on the publication
+	servertest.NewClient(s).POST("/orders").Expect(t).Status(http.StatusCreated)
+	select {
+	case n := <-failures:
+		if n != 2 {
+			t.Errorf("OnError() got %d events, want 2", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnError() was not called")
+	}
+}
+
+func TestStageEventsWithoutOutbox(t *testing.T) {
+	s := std.NewServer("8080", false)
+	s.POST("/orders", func(c core.Context) {
+		if err := middleware.CommitJSON(c, http.StatusCreated, "ok", middleware.OutboxEvent{Topic: "order.created"}); !errors.Is(err, middleware.ErrNoOutbox) {
+			t.Errorf("CommitJSON() error = %v, want ErrNoOutbox", err)
+		}
+		c.String(http.StatusInternalServerError, "no outbox")
+	})
+
+	servertest.NewClient(s).POST("/orders").Expect(t).Status(http.StatusInternalServerError)
+}
+
+func TestNewOutboxValidation(t *testing.T) {
+	_, err := middleware.NewOutbox(middleware.DefaultOutboxConfig())
+	var configErr *middleware.ConfigError
+	if !errors.As(err, &configErr) || configErr.Field != "Publisher" {
+		t.Fatalf("NewOutbox() error = %v, want a *ConfigError for Publisher", err)
+	}
+}
@@ -0,0 +1,117 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/errors"
+	"github.com/mythofleader/go-http-server/core/middleware/util"
+)
+
+// Obligation is an action a policy requires before the request may proceed, such as setting
+// a response header or recording an audit entry. If it returns an error, the request is denied.
+type Obligation func(c core.Context) error
+
+// Decision is the result of a policy evaluation. The zero Decision denies the request,
+// so that a policy that fails to decide fails closed.
+type Decision struct {
+	// Allow is whether the request may proceed
+	Allow bool
+	// Reason explains a denial; it is sent to the client as the error message if set
+	Reason string
+	// Obligations run in order before the handler when the request is allowed
+	Obligations []Obligation
+}
+
+// PolicyAllow returns a Decision allowing the request once the obligations are fulfilled.
+func PolicyAllow(obligations ...Obligation) Decision {
+	return Decision{Allow: true, Obligations: obligations}
+}
+
+// PolicyDeny returns a Decision denying the request for reason.
+func PolicyDeny(reason string) Decision {
+	return Decision{Reason: reason}
+}
+
+// PolicyFunc decides whether a request may proceed. user is the user stored by the auth
+// middleware, nil for anonymous requests, and route is the method and path of the request
+// in the format of route templates, e.g. "GET /orders/42".
+type PolicyFunc func(c core.Context, user interface{}, route string) Decision
+
+// PolicyConfig holds configuration for the policy middleware.
+type PolicyConfig struct {
+	// Policy evaluates every request that is not skipped
+	Policy PolicyFunc
+
+	// SkipPaths is a list of paths that are not evaluated
+	SkipPaths []string
+
+	// Optional: custom error message, used when a denial has no reason
+	ForbiddenMessage string
+}
+
+// DefaultPolicyConfig returns a default policy configuration.
+// The Policy is nil by default and must be provided by the user.
+func DefaultPolicyConfig() *PolicyConfig {
+	return &PolicyConfig{
+		ForbiddenMessage: "Forbidden",
+	}
+}
+
+// PolicyMiddleware returns a middleware function that evaluates an attribute-based access control
+// policy after authentication, so that custom policy engines can decide on the user, the route and
+// any other attribute of the request before the handler runs. Denied requests, and allowed requests
+// whose obligations fail, are rejected with 403 Forbidden. Register it after the auth middleware.
+// Example usage:
+//
+//	s.Use(middleware.PolicyMiddleware(&middleware.PolicyConfig{
+//		Policy: func(c core.Context, user interface{}, route string) middleware.Decision {
+//			if u, ok := user.(User); ok && u.Tenant == c.GetHeader("X-Tenant") {
+//				return middleware.PolicyAllow()
+//			}
+//			return middleware.PolicyDeny("tenant mismatch")
+//		},
+//	}))
+func PolicyMiddleware(config *PolicyConfig) core.HandlerFunc {
+	if config == nil || config.Policy == nil {
+		panic("PolicyMiddleware requires a Policy")
+	}
+	message := config.ForbiddenMessage
+	if message == "" {
+		message = "Forbidden"
+	}
+
+	skipPaths := util.CompilePaths(config.SkipPaths)
+
+	return func(c core.Context) {
+		req := c.Request()
+		if skipPaths.Match(req.URL.Path) {
+			c.Next()
+			return
+		}
+
+		user, _ := GetUserFromContext(req.Context())
+		decision := config.Policy(c, user, req.Method+" "+req.URL.Path)
+		if decision.Allow {
+			for _, obligation := range decision.Obligations {
+				if err := obligation(c); err != nil {
+					decision = Decision{}
+					break
+				}
+			}
+		}
+		if !decision.Allow {
+			reason := message
+			if decision.Reason != "" {
+				reason = decision.Reason
+			}
+			c.JSON(http.StatusForbidden, errors.NewForbiddenResponse(reason))
+			c.Abort()
+			return
+		}
+
+		// Continue with the next middleware/handler in the chain
+		c.Next()
+	}
+}
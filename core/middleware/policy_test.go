@@ -0,0 +1,73 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestPolicyMiddleware(t *testing.T) {
+	var routes []string
+	s := std.NewServer("8080", false)
+	// Store the user like the auth middleware does
+	s.Use(func(c core.Context) {
+		if user := c.GetHeader("X-User"); user != "" {
+			c.SetRequest(c.Request().WithContext(context.WithValue(c.Request().Context(), middleware.UserContextKey, user)))
+		}
+		c.Next()
+	})
+	s.Use(middleware.PolicyMiddleware(&middleware.PolicyConfig{
+		Policy: func(c core.Context, user interface{}, route string) middleware.Decision {
+			routes = append(routes, route)
+			switch user {
+			case "admin":
+				return middleware.PolicyAllow(func(c core.Context) error {
+					c.SetHeader("X-Audited", "true")
+					return nil
+				})
+			case "auditor":
+				return middleware.PolicyAllow(func(c core.Context) error {
+					return errors.New("audit log unavailable")
+				})
+			case nil:
+				return middleware.Decision{}
+			}
+			return middleware.PolicyDeny("orders are restricted to admins")
+		},
+		SkipPaths: []string{"/health"},
+	}))
+	s.GET("/orders/:id", func(c core.Context) {
+		c.String(http.StatusOK, "order")
+	})
+	s.GET("/health", func(c core.Context) {
+		c.String(http.StatusOK, "healthy")
+	})
+	client := servertest.NewClient(s)
+
+	client.GET("/orders/42").WithHeader("X-User", "admin").Expect(t).
+		Status(http.StatusOK).
+		Header("X-Audited", "true").
+		Body("order")
+	client.GET("/orders/42").WithHeader("X-User", "john").Expect(t).
+		Status(http.StatusForbidden).
+		JSONPath("$.error.message", "orders are restricted to admins")
+
+	// Unfulfilled obligations and undecided policies deny with the default message
+	client.GET("/orders/42").WithHeader("X-User", "auditor").Expect(t).
+		Status(http.StatusForbidden).
+		JSONPath("$.error.message", "Forbidden")
+	client.GET("/orders/42").Expect(t).
+		Status(http.StatusForbidden).
+		JSONPath("$.error.message", "Forbidden")
+
+	client.GET("/health").Expect(t).Status(http.StatusOK)
+	if len(routes) != 4 || routes[0] != "GET /orders/42" {
+		t.Errorf("policy evaluated routes %v, want 4 evaluations of GET /orders/42", routes)
+	}
+}
@@ -0,0 +1,265 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/errors"
+	"github.com/mythofleader/go-http-server/core/middleware/util"
+)
+
+// RateLimit is a token bucket limit: Requests tokens are added every Per, up to Burst tokens.
+type RateLimit struct {
+	// Requests is the number of requests allowed per Per on average
+	Requests int
+	// Per is the period of Requests, e.g. time.Minute
+	Per time.Duration
+	// Burst is the size of the bucket, the number of requests allowed at once.
+	// If zero, it defaults to Requests.
+	Burst int
+}
+
+// rate returns the number of tokens added per second.
+func (l RateLimit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// burst returns the size of the bucket.
+func (l RateLimit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// RateLimitResult is the outcome of taking a token from a bucket.
+type RateLimitResult struct {
+	// Allowed is whether a token was available
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// RetryAfter is how long until the next token is available, zero if Allowed
+	RetryAfter time.Duration
+}
+
+// RateLimitStore stores the token buckets of the rate limit middleware.
+// The in-memory store returned by NewMemoryRateLimitStore only limits requests within one
+// process; implement this interface with a shared store, such as Redis, to limit requests
+// across all instances behind a load balancer.
+type RateLimitStore interface {
+	// Take takes a token from the bucket of key, creating a full bucket if none exists.
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// RateLimitConfig holds configuration for the rate limit middleware.
+type RateLimitConfig struct {
+	// Limit is the token bucket limit applied to every key
+	Limit RateLimit
+
+	// KeyFunc returns the key requests are counted by, e.g. RateLimitByIP or RateLimitByAPIKey.
+	// Requests with an empty key are not limited. If nil, RateLimitByIP is used.
+	KeyFunc func(c core.Context) string
+
+	// Store holds the token buckets. If nil, an in-memory store is used.
+	Store RateLimitStore
+
+	// FailClosed rejects requests with 503 Service Unavailable when the store fails.
+	// By default, requests are let through, so that an unavailable store does not take the API down.
+	FailClosed bool
+
+	// SkipPaths is a list of paths that are not rate limited
+	SkipPaths []string
+
+	// Optional: custom error message
+	TooManyRequestsMessage string
+}
+
+// DefaultRateLimitConfig returns a default rate limit configuration:
+// 100 requests per minute per client IP, kept in memory.
+func DefaultRateLimitConfig() *RateLimitConfig {
+	return &RateLimitConfig{
+		Limit:                  RateLimit{Requests: 100, Per: time.Minute},
+		KeyFunc:                RateLimitByIP,
+		TooManyRequestsMessage: "Too many requests",
+	}
+}
+
+// RateLimitByIP returns the client IP of the request, resolved by Context.ClientIP, for RateLimitConfig.KeyFunc.
+func RateLimitByIP(c core.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimitByAPIKey returns the x-api-key header of the request, for RateLimitConfig.KeyFunc.
+// Requests without an API key are limited by client IP.
+func RateLimitByAPIKey(c core.Context) string {
+	if apiKey := c.GetHeader("x-api-key"); apiKey != "" {
+		return "key:" + apiKey
+	}
+	return RateLimitByIP(c)
+}
+
+// RateLimitMiddleware returns a middleware function that limits the request rate per key with
+// token buckets. Responses carry the X-RateLimit-Limit and X-RateLimit-Remaining headers; requests
+// over the limit are rejected with 429 Too Many Requests and a Retry-After header.
+// Example usage:
+//
+//	config := middleware.DefaultRateLimitConfig()
+//	config.Limit = middleware.RateLimit{Requests: 10, Per: time.Second, Burst: 20}
+//	config.KeyFunc = middleware.RateLimitByAPIKey
+//	s.Use(middleware.RateLimitMiddleware(config))
+func RateLimitMiddleware(config *RateLimitConfig) core.HandlerFunc {
+	if config == nil {
+		config = DefaultRateLimitConfig()
+	}
+	if config.Limit.Requests <= 0 || config.Limit.Per <= 0 {
+		panic("RateLimitMiddleware requires a positive Limit.Requests and Limit.Per")
+	}
+
+	keyFunc := config.KeyFunc
+	if keyFunc == nil {
+		keyFunc = RateLimitByIP
+	}
+	store := config.Store
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+	message := config.TooManyRequestsMessage
+	if message == "" {
+		message = "Too many requests"
+	}
+
+	skipPaths := util.CompilePaths(config.SkipPaths)
+
+	return func(c core.Context) {
+		if skipPaths.Match(c.Request().URL.Path) {
+			c.Next()
+			return
+		}
+		key := keyFunc(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		result, err := store.Take(c.Request().Context(), key, config.Limit)
+		serveRateLimited(c, config.Limit, result, err, config.FailClosed, message)
+	}
+}
+
+// serveRateLimited sets the rate limit headers of result and runs the rest of the chain, or
+// rejects the request if it is over the limit. If the store failed with err, the request is
+// let through, or rejected with 503 Service Unavailable if failClosed is set.
+func serveRateLimited(c core.Context, limit RateLimit, result RateLimitResult, err error, failClosed bool, message string) {
+	if err != nil {
+		if failClosed {
+			c.JSON(http.StatusServiceUnavailable, errors.NewErrorResponse(http.StatusServiceUnavailable, "Rate limit unavailable"))
+			c.Abort()
+			return
+		}
+		c.Next()
+		return
+	}
+
+	c.SetHeader("X-RateLimit-Limit", strconv.Itoa(limit.burst()))
+	c.SetHeader("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	if !result.Allowed {
+		rejectTooManyRequests(c, message, result.RetryAfter)
+		return
+	}
+
+	// Continue with the next middleware/handler in the chain
+	c.Next()
+}
+
+// rejectTooManyRequests aborts the request with 429 Too Many Requests. If retryAfter is positive,
+// it is sent in the Retry-After header and in error.retry_after, in whole seconds rounded up so that
+// clients do not retry too early. It is shared by the rate limiting and concurrency limiting middleware.
+func rejectTooManyRequests(c core.Context, message string, retryAfter time.Duration) {
+	if retryAfter > 0 {
+		c.SetHeader("Retry-After", strconv.Itoa(errors.RetryAfterSeconds(retryAfter)))
+	}
+	c.JSON(http.StatusTooManyRequests, errors.NewTooManyRequestsResponse(message, retryAfter))
+	c.Abort()
+}
+
+// rateLimitSweepInterval is how often the in-memory store removes buckets that have refilled.
+const rateLimitSweepInterval = time.Minute
+
+// tokenBucket is a token bucket of the in-memory store.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // When the bucket is full again, after which it can be removed
+}
+
+// MemoryRateLimitStore is a RateLimitStore keeping token buckets in memory.
+// Buckets that have refilled completely are removed periodically, so memory use is bounded
+// by the number of keys active within one refill period.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore returns an empty in-memory rate limit store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*tokenBucket)}
+}
+
+// Take implements RateLimitStore.Take. The current time is read from the clock of ctx.
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	now := core.ClockFromContext(ctx).Now()
+	rate, burst := limit.rate(), float64(limit.burst())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, last: now}
+		s.buckets[key] = bucket
+	}
+	if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+		bucket.tokens = math.Min(burst, bucket.tokens+elapsed*rate)
+		bucket.last = now
+	}
+
+	result := RateLimitResult{}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+	}
+	result.Remaining = int(bucket.tokens)
+	bucket.full = now.Add(time.Duration((burst - bucket.tokens) / rate * float64(time.Second)))
+	return result, nil
+}
+
+// sweep removes the buckets that are full again, at most once per rateLimitSweepInterval.
+// A removed bucket is recreated full, so removing it does not change any result.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// Len returns the number of buckets in the store.
+func (s *MemoryRateLimitStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
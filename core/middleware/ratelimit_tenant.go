@@ -0,0 +1,215 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/util"
+)
+
+// DefaultTenantLimitCacheTTL is how long the limits loaded from a TenantLimitStore are cached by default.
+const DefaultTenantLimitCacheTTL = time.Minute
+
+// TenantLimitStore loads the rate limits of tenants, e.g. from the plans of their subscriptions.
+type TenantLimitStore interface {
+	// TenantLimit returns the limit of tenant, and false if the tenant has no limit of its own
+	TenantLimit(ctx context.Context, tenant string) (RateLimit, bool, error)
+}
+
+// TenantLimits is a TenantLimitStore with fixed limits by tenant.
+type TenantLimits map[string]RateLimit
+
+// TenantLimit implements TenantLimitStore.TenantLimit.
+func (l TenantLimits) TenantLimit(_ context.Context, tenant string) (RateLimit, bool, error) {
+	limit, ok := l[tenant]
+	return limit, ok, nil
+}
+
+// TenantRateLimitConfig holds configuration for the tenant rate limit middleware.
+type TenantRateLimitConfig struct {
+	// TenantFunc returns the tenant of a request, e.g. TenantFromHeader("X-Tenant-ID").
+	// Requests without a tenant are not limited.
+	TenantFunc func(c core.Context) string
+
+	// Limit is the token bucket limit of tenants without a limit of their own
+	Limit RateLimit
+
+	// Limits loads the limits of individual tenants. If nil, every tenant gets Limit.
+	Limits TenantLimitStore
+
+	// LimitCacheTTL is how long the limits loaded from Limits are cached.
+	// If zero, DefaultTenantLimitCacheTTL is used.
+	LimitCacheTTL time.Duration
+
+	// PartitionFunc splits the quota of a tenant into partitions with their own buckets, each
+	// with the limit of the tenant, e.g. by API key or route group. If nil, each tenant has one bucket.
+	PartitionFunc func(c core.Context) string
+
+	// Store holds the token buckets. If nil, an in-memory store is used.
+	Store RateLimitStore
+
+	// FailClosed rejects requests with 503 Service Unavailable when the limit or token bucket
+	// store fails. By default, requests are let through.
+	FailClosed bool
+
+	// SkipPaths is a list of paths that are not rate limited
+	SkipPaths []string
+
+	// Optional: custom error message
+	TooManyRequestsMessage string
+}
+
+// DefaultTenantRateLimitConfig returns a default tenant rate limit configuration: 1000 requests
+// per minute per tenant, identified by the X-Tenant-ID header, kept in memory.
+func DefaultTenantRateLimitConfig() *TenantRateLimitConfig {
+	return &TenantRateLimitConfig{
+		TenantFunc:             TenantFromHeader("X-Tenant-ID"),
+		Limit:                  RateLimit{Requests: 1000, Per: time.Minute},
+		LimitCacheTTL:          DefaultTenantLimitCacheTTL,
+		TooManyRequestsMessage: "Too many requests",
+	}
+}
+
+// TenantFromHeader returns a TenantFunc reading the tenant from the header name.
+func TenantFromHeader(name string) func(c core.Context) string {
+	return func(c core.Context) string {
+		return c.GetHeader(name)
+	}
+}
+
+// TenantFromUser returns a TenantFunc reading the tenant from the user stored by the
+// authorization middleware, which must run first. Requests without a user have no tenant.
+func TenantFromUser(tenant func(user interface{}) string) func(c core.Context) string {
+	return func(c core.Context) string {
+		user, ok := GetUserFromContext(c.Request().Context())
+		if !ok {
+			return ""
+		}
+		return tenant(user)
+	}
+}
+
+// TenantRateLimitMiddleware returns a middleware function that limits the request rate per tenant
+// with token buckets, with the limits of individual tenants loaded from a store. Responses carry
+// the X-RateLimit-Limit and X-RateLimit-Remaining headers of the tenant; requests over its limit
+// are rejected with 429 Too Many Requests and a Retry-After header.
+// Example usage:
+//
+//	config := middleware.DefaultTenantRateLimitConfig()
+//	config.TenantFunc = middleware.TenantFromUser(func(user interface{}) string {
+//		return user.(*User).TenantID
+//	})
+//	config.Limits = middleware.TenantLimits{
+//		"acme": {Requests: 10000, Per: time.Minute},
+//	}
+//	s.Use(middleware.TenantRateLimitMiddleware(config))
+func TenantRateLimitMiddleware(config *TenantRateLimitConfig) core.HandlerFunc {
+	if config == nil {
+		config = DefaultTenantRateLimitConfig()
+	}
+	if config.TenantFunc == nil {
+		panic("TenantRateLimitMiddleware requires a TenantFunc")
+	}
+	if config.Limit.Requests <= 0 || config.Limit.Per <= 0 {
+		panic("TenantRateLimitMiddleware requires a positive Limit.Requests and Limit.Per")
+	}
+
+	store := config.Store
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+	message := config.TooManyRequestsMessage
+	if message == "" {
+		message = "Too many requests"
+	}
+	var limits *tenantLimitCache
+	if config.Limits != nil {
+		limits = newTenantLimitCache(config.Limits, config.LimitCacheTTL)
+	}
+
+	skipPaths := util.CompilePaths(config.SkipPaths)
+
+	return func(c core.Context) {
+		if skipPaths.Match(c.Request().URL.Path) {
+			c.Next()
+			return
+		}
+		tenant := config.TenantFunc(c)
+		if tenant == "" {
+			c.Next()
+			return
+		}
+		ctx := c.Request().Context()
+
+		limit := config.Limit
+		if limits != nil {
+			tenantLimit, ok, err := limits.get(ctx, tenant)
+			if err != nil {
+				serveRateLimited(c, limit, RateLimitResult{}, err, config.FailClosed, message)
+				return
+			}
+			if ok && tenantLimit.Requests > 0 && tenantLimit.Per > 0 {
+				limit = tenantLimit
+			}
+		}
+
+		key := "tenant:" + tenant
+		if config.PartitionFunc != nil {
+			if partition := config.PartitionFunc(c); partition != "" {
+				key += ":" + partition
+			}
+		}
+		result, err := store.Take(ctx, key, limit)
+		serveRateLimited(c, limit, result, err, config.FailClosed, message)
+	}
+}
+
+// tenantLimit is a cached limit of a tenant.
+type tenantLimit struct {
+	limit   RateLimit
+	ok      bool
+	expires time.Time
+}
+
+// tenantLimitCache caches the limits of a TenantLimitStore. If loading a limit fails,
+// the expired limit is used until the store is available again.
+type tenantLimitCache struct {
+	store TenantLimitStore
+	ttl   time.Duration
+
+	mu     sync.Mutex
+	limits map[string]tenantLimit
+}
+
+func newTenantLimitCache(store TenantLimitStore, ttl time.Duration) *tenantLimitCache {
+	if ttl <= 0 {
+		ttl = DefaultTenantLimitCacheTTL
+	}
+	return &tenantLimitCache{store: store, ttl: ttl, limits: make(map[string]tenantLimit)}
+}
+
+// get returns the limit of tenant, loading it if it is not cached or has expired.
+func (l *tenantLimitCache) get(ctx context.Context, tenant string) (RateLimit, bool, error) {
+	now := core.ClockFromContext(ctx).Now()
+	l.mu.Lock()
+	cached, found := l.limits[tenant]
+	l.mu.Unlock()
+	if found && now.Before(cached.expires) {
+		return cached.limit, cached.ok, nil
+	}
+
+	limit, ok, err := l.store.TenantLimit(ctx, tenant)
+	if err != nil {
+		if found {
+			return cached.limit, cached.ok, nil
+		}
+		return RateLimit{}, false, err
+	}
+	l.mu.Lock()
+	l.limits[tenant] = tenantLimit{limit: limit, ok: ok, expires: now.Add(l.ttl)}
+	l.mu.Unlock()
+	return limit, ok, nil
+}
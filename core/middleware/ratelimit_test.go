@@ -0,0 +1,203 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+// newRateLimitedServer returns a server limited by config, reading the time from clock.
+func newRateLimitedServer(clock core.Clock, config *middleware.RateLimitConfig) core.Server {
+	s := std.NewServer("8080", false)
+	// Requests of the test client come from 192.0.2.1; trust it to forward the client IP
+	if err := s.SetTrustedProxies([]string{"192.0.2.1"}); err != nil {
+		panic(err)
+	}
+	s.Use(core.ClockMiddleware(clock, nil))
+	s.Use(middleware.RateLimitMiddleware(config))
+	s.GET("/items", func(c core.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	s.GET("/health", func(c core.Context) {
+		c.String(http.StatusOK, "healthy")
+	})
+	return s
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	config := middleware.DefaultRateLimitConfig()
+	config.Limit = middleware.RateLimit{Requests: 2, Per: 10 * time.Second}
+	config.SkipPaths = []string{"/health"}
+	client := servertest.NewClient(newRateLimitedServer(clock, config))
+
+	client.GET("/items").Expect(t).
+		Status(http.StatusOK).
+		Header("X-RateLimit-Limit", "2").
+		Header("X-RateLimit-Remaining", "1")
+	client.GET("/items").Expect(t).
+		Status(http.StatusOK).
+		Header("X-RateLimit-Remaining", "0")
+	client.GET("/items").Expect(t).
+		Status(http.StatusTooManyRequests).
+		Header("Retry-After", "5").
+		JSONPath("$.error.code", float64(http.StatusTooManyRequests)).
+		JSONPath("$.error.message", "Too many requests").
+		JSONPath("$.error.retry_after", float64(5))
+
+	// Skipped paths are not counted
+	client.GET("/health").Expect(t).Status(http.StatusOK)
+
+	// One token is added every 5 seconds
+	clock.Advance(5 * time.Second)
+	client.GET("/items").Expect(t).Status(http.StatusOK)
+	client.GET("/items").Expect(t).Status(http.StatusTooManyRequests)
+}
+
+func TestRateLimitMiddlewareKeys(t *testing.T) {
+	clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	config := middleware.DefaultRateLimitConfig()
+	config.Limit = middleware.RateLimit{Requests: 1, Per: time.Minute}
+	config.KeyFunc = middleware.RateLimitByAPIKey
+	client := servertest.NewClient(newRateLimitedServer(clock, config))
+
+	client.GET("/items").WithAPIKey("key-a").Expect(t).Status(http.StatusOK)
+	client.GET("/items").WithAPIKey("key-a").Expect(t).Status(http.StatusTooManyRequests)
+	client.GET("/items").WithAPIKey("key-b").Expect(t).Status(http.StatusOK)
+
+	// Requests without an API key are limited by client IP
+	client.GET("/items").WithHeader("X-Real-IP", "10.0.0.1").Expect(t).Status(http.StatusOK)
+	client.GET("/items").WithHeader("X-Real-IP", "10.0.0.1").Expect(t).Status(http.StatusTooManyRequests)
+	client.GET("/items").WithHeader("X-Real-IP", "10.0.0.2").Expect(t).Status(http.StatusOK)
+}
+
+// failingRateLimitStore is a RateLimitStore that is always unavailable.
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, middleware.RateLimit) (middleware.RateLimitResult, error) {
+	return middleware.RateLimitResult{}, errors.New("store unavailable")
+}
+
+func TestRateLimitMiddlewareStoreErrors(t *testing.T) {
+	config := middleware.DefaultRateLimitConfig()
+	config.Store = failingRateLimitStore{}
+	servertest.NewClient(newRateLimitedServer(nil, config)).
+		GET("/items").Expect(t).Status(http.StatusOK)
+
+	config.FailClosed = true
+	servertest.NewClient(newRateLimitedServer(nil, config)).
+		GET("/items").Expect(t).Status(http.StatusServiceUnavailable)
+}
+
+func TestMemoryRateLimitStoreSweep(t *testing.T) {
+	clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx := core.ContextWithClock(context.Background(), clock)
+	limit := middleware.RateLimit{Requests: 10, Per: time.Minute}
+	store := middleware.NewMemoryRateLimitStore()
+
+	for _, key := range []string{"a", "b"} {
+		if _, err := store.Take(ctx, key, limit); err != nil {
+			t.Fatalf("Take(%q) returned %v", key, err)
+		}
+	}
+	if store.Len() != 2 {
+		t.Fatalf("store has %d buckets, want 2", store.Len())
+	}
+
+	// Both buckets have refilled after 6 seconds and are removed by the next sweep
+	clock.Advance(2 * time.Minute)
+	if _, err := store.Take(ctx, "c", limit); err != nil {
+		t.Fatalf("Take(c) returned %v", err)
+	}
+	if store.Len() != 1 {
+		t.Errorf("store has %d buckets after the sweep, want 1", store.Len())
+	}
+}
+
+func TestRateLimitMiddlewarePanicsWithoutLimit(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("RateLimitMiddleware did not panic without a limit")
+		}
+	}()
+	middleware.RateLimitMiddleware(&middleware.RateLimitConfig{})
+}
+
+// countingTenantLimits is a TenantLimitStore counting its loads, failing once err is set.
+type countingTenantLimits struct {
+	limits middleware.TenantLimits
+	loads  int
+	err    error
+}
+
+func (l *countingTenantLimits) TenantLimit(ctx context.Context, tenant string) (middleware.RateLimit, bool, error) {
+	l.loads++
+	if l.err != nil {
+		return middleware.RateLimit{}, false, l.err
+	}
+	return l.limits.TenantLimit(ctx, tenant)
+}
+
+func TestTenantRateLimitMiddleware(t *testing.T) {
+	clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	limits := &countingTenantLimits{limits: middleware.TenantLimits{
+		"acme": {Requests: 3, Per: time.Minute},
+	}}
+	config := middleware.DefaultTenantRateLimitConfig()
+	config.Limit = middleware.RateLimit{Requests: 1, Per: time.Minute}
+	config.Limits = limits
+	config.PartitionFunc = func(c core.Context) string { return c.Query("partition") }
+
+	s := std.NewServer("8080", false)
+	s.Use(core.ClockMiddleware(clock, nil))
+	s.Use(middleware.TenantRateLimitMiddleware(config))
+	s.GET("/items", func(c core.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	client := servertest.NewClient(s)
+	get := func(tenant string) *servertest.Request {
+		return client.GET("/items").WithHeader("X-Tenant-ID", tenant)
+	}
+
+	// Tenants get their own limit, or the default one
+	get("acme").Expect(t).Status(http.StatusOK).
+		Header("X-RateLimit-Limit", "3").
+		Header("X-RateLimit-Remaining", "2")
+	get("globex").Expect(t).Status(http.StatusOK).
+		Header("X-RateLimit-Limit", "1").
+		Header("X-RateLimit-Remaining", "0")
+	get("globex").Expect(t).Status(http.StatusTooManyRequests).Header("Retry-After", "60")
+	get("acme").Expect(t).Status(http.StatusOK)
+	get("acme").Expect(t).Status(http.StatusOK)
+	get("acme").Expect(t).Status(http.StatusTooManyRequests)
+
+	// Partitions have buckets of their own
+	client.GET("/items?partition=exports").WithHeader("X-Tenant-ID", "acme").Expect(t).
+		Status(http.StatusOK).
+		Header("X-RateLimit-Remaining", "2")
+
+	// Requests without a tenant are not limited
+	client.GET("/items").Expect(t).Status(http.StatusOK)
+	client.GET("/items").Expect(t).Status(http.StatusOK)
+
+	// Limits are cached, and the cached limit is used while the store fails
+	if limits.loads != 2 {
+		t.Errorf("limits were loaded %d times, want 2", limits.loads)
+	}
+	limits.err = errors.New("store unavailable")
+	clock.Advance(time.Minute)
+	get("acme").Expect(t).Status(http.StatusOK).Header("X-RateLimit-Limit", "3")
+	if limits.loads != 3 {
+		t.Errorf("limits were loaded %d times, want 3", limits.loads)
+	}
+
+	// Tenants whose limit never loaded are let through
+	get("initech").Expect(t).Status(http.StatusOK)
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// RawDataLimitMiddleware returns a middleware function that sets the maximum request body size,
+// in bytes, that Context.GetRawData caches for the requests it handles.
+// Without it, core.DefaultMaxRawDataSize is used.
+// Register it before any middleware that calls GetRawData.
+//
+// Example usage:
+//
+//	s.Use(middleware.RawDataLimitMiddleware(1 << 20)) // 1 MB
+func RawDataLimitMiddleware(maxSize int64) core.HandlerFunc {
+	if maxSize <= 0 {
+		panic("RawDataLimitMiddleware requires a positive maxSize")
+	}
+
+	return func(c core.Context) {
+		c.Set(core.ContextKeyMaxRawDataSize, maxSize)
+		c.Next()
+	}
+}
@@ -0,0 +1,75 @@
+package middleware_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestGetRawData(t *testing.T) {
+	servers := map[string]core.Server{
+		"std": std.NewServer("8080", false),
+		"gin": gin.NewServer("8080", false),
+	}
+
+	for name, s := range servers {
+		t.Run(name, func(t *testing.T) {
+			var first, second []byte
+			s.Use(func(c core.Context) {
+				first, _ = c.GetRawData()
+				c.Next()
+			})
+			s.Use(func(c core.Context) {
+				second, _ = c.GetRawData()
+				c.Next()
+			})
+			s.POST("/items", func(c core.Context) {
+				var body struct {
+					Name string `json:"name"`
+				}
+				if err := c.ShouldBindJSON(&body); err != nil {
+					c.String(http.StatusBadRequest, "%v", err)
+					return
+				}
+				c.String(http.StatusOK, "%s", body.Name)
+			})
+
+			servertest.NewClient(s).POST("/items").
+				WithJSON(map[string]string{"name": "john"}).
+				Expect(t).
+				Status(http.StatusOK).
+				Body("john")
+
+			if string(first) != `{"name":"john"}` || string(second) != string(first) {
+				t.Errorf("raw data = %q and %q, want both %q", first, second, `{"name":"john"}`)
+			}
+		})
+	}
+}
+
+func TestGetRawDataTooLarge(t *testing.T) {
+	s := std.NewServer("8080", false)
+	s.Use(middleware.RawDataLimitMiddleware(4))
+	s.POST("/upload", func(c core.Context) {
+		if _, err := c.GetRawData(); !errors.Is(err, core.ErrRawDataTooLarge) {
+			c.String(http.StatusInternalServerError, "err = %v", err)
+			return
+		}
+		// The body must still be readable in full
+		data, _ := io.ReadAll(c.Request().Body)
+		c.String(http.StatusOK, "%s", data)
+	})
+
+	servertest.NewClient(s).POST("/upload").
+		WithBody("text/plain", []byte("0123456789")).
+		Expect(t).
+		Status(http.StatusOK).
+		Body("0123456789")
+}
@@ -0,0 +1,131 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// DefaultRequestIDHeader is the header carrying request IDs by default.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// RequestIDConfig holds configuration for the request ID middleware.
+type RequestIDConfig struct {
+	// Header is the request and response header carrying the request ID.
+	Header string
+
+	// Generator generates the IDs of requests without a usable one, e.g. core.NewUUIDGenerator(),
+	// core.NewULIDGenerator(nil) or a core.IDGeneratorFunc. If nil, the ID generator of the request
+	// context is used, which is set with ServerBuilder.WithIDGenerator.
+	Generator core.IDGenerator
+
+	// TrustIncoming keeps the ID sent in Header by the client or an upstream proxy,
+	// so that a request can be followed across services. Otherwise a new ID is always generated.
+	TrustIncoming bool
+
+	// MaxLength is the maximum length of incoming IDs; longer ones are replaced with a new ID.
+	// Incoming IDs with characters other than printable ASCII are always replaced. Zero means no limit.
+	MaxLength int
+}
+
+// DefaultRequestIDConfig returns a default request ID configuration.
+func DefaultRequestIDConfig() *RequestIDConfig {
+	return &RequestIDConfig{
+		Header:        DefaultRequestIDHeader,
+		Generator:     nil, // Generator of the request context
+		TrustIncoming: true,
+		MaxLength:     128,
+	}
+}
+
+// Validate checks that the configuration is usable, returning a *ConfigError if not.
+func (config *RequestIDConfig) Validate() error {
+	if config.MaxLength < 0 {
+		return &ConfigError{
+			Middleware: "RequestIDMiddleware",
+			Field:      "MaxLength",
+			Problem:    "must not be negative",
+			Remedy:     "set MaxLength to 0 to accept incoming IDs of any length",
+		}
+	}
+	return nil
+}
+
+// usable reports whether id, received from the client, can be kept as the request ID.
+func (config *RequestIDConfig) usable(id string) bool {
+	if id == "" || (config.MaxLength > 0 && len(id) > config.MaxLength) {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// assign sets the request ID of c: it keeps the incoming ID if allowed, or generates one,
+// then stores it under core.ContextKeyRequestID and sets the response header.
+func (config *RequestIDConfig) assign(c core.Context) string {
+	req := c.Request()
+	id := req.Header.Get(config.Header)
+	if !config.TrustIncoming || !config.usable(id) {
+		generator := config.Generator
+		if generator == nil {
+			generator = core.IDGeneratorFromContext(req.Context())
+		}
+		id = generator.NewID()
+	}
+	c.Set(core.ContextKeyRequestID, id)
+	c.SetHeader(config.Header, id)
+	return id
+}
+
+// RequestIDMiddleware returns a middleware function that gives each request an ID, so that its
+// handlers, logs and error responses can be correlated. The ID is stored in the context under
+// core.ContextKeyRequestID, where handlers read it with c.Get or core.RequestIDFromContext on
+// c.DetachedContext(), and is sent back in the response header. The logging middleware and the
+// error handler use it instead of handling the X-Request-ID header themselves, so register it
+// before them.
+// It panics with a *ConfigError if the configuration is invalid; use TryRequestIDMiddleware to get the error instead.
+// Example usage:
+//
+//	config := middleware.DefaultRequestIDConfig()
+//	config.Generator = core.NewUUIDGenerator()
+//	s.Use(middleware.RequestIDMiddleware(config))
+func RequestIDMiddleware(config *RequestIDConfig) core.HandlerFunc {
+	handler, err := TryRequestIDMiddleware(config)
+	if err != nil {
+		panic(err)
+	}
+	return handler
+}
+
+// TryRequestIDMiddleware is like RequestIDMiddleware, but returns a *ConfigError instead of panicking
+// if the configuration is invalid.
+func TryRequestIDMiddleware(config *RequestIDConfig) (core.HandlerFunc, error) {
+	if config == nil {
+		config = DefaultRequestIDConfig()
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Header == "" {
+		config.Header = DefaultRequestIDHeader
+	}
+
+	return func(c core.Context) {
+		config.assign(c)
+	}, nil
+}
+
+// EnsureRequestID returns the request ID of c stored by RequestIDMiddleware. If there is none,
+// as on servers that only enable logging, it assigns one with DefaultRequestIDConfig, taking it
+// from the X-Request-ID header or generating it. It is used by the logging middleware.
+func EnsureRequestID(c core.Context) string {
+	if value, ok := c.Get(core.ContextKeyRequestID); ok {
+		if id, _ := value.(string); id != "" {
+			return id
+		}
+	}
+	return DefaultRequestIDConfig().assign(c)
+}
@@ -0,0 +1,70 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			config := middleware.DefaultRequestIDConfig()
+			config.Header = "X-Correlation-ID"
+			config.Generator = servertest.SequentialIDs("id")
+			config.MaxLength = 16
+			s.Use(middleware.RequestIDMiddleware(config))
+			s.GET("/id", func(c core.Context) {
+				value, _ := c.Get(core.ContextKeyRequestID)
+				c.String(http.StatusOK, value.(string)+" "+core.RequestIDFromContext(c.DetachedContext()))
+			})
+			client := servertest.NewClient(s)
+
+			client.GET("/id").Expect(t).
+				Header("X-Correlation-ID", "id-1").
+				Body("id-1 id-1")
+			client.GET("/id").WithHeader("X-Correlation-ID", "upstream").Expect(t).
+				Header("X-Correlation-ID", "upstream").
+				Body("upstream upstream")
+
+			// Unusable incoming IDs are replaced
+			client.GET("/id").WithHeader("X-Correlation-ID", strings.Repeat("x", 17)).Expect(t).
+				Header("X-Correlation-ID", "id-2")
+			client.GET("/id").WithHeader("X-Correlation-ID", "a b").Expect(t).
+				Header("X-Correlation-ID", "id-3")
+		})
+	}
+}
+
+func TestRequestIDMiddlewareIgnoresIncoming(t *testing.T) {
+	s := std.NewServer("8080", false)
+	config := middleware.DefaultRequestIDConfig()
+	config.Generator = core.NewUUIDGenerator()
+	config.TrustIncoming = false
+	s.Use(middleware.RequestIDMiddleware(config))
+	s.GET("/", func(c core.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	rec := servertest.NewClient(s).GET("/").WithHeader("X-Request-ID", "spoofed").Expect(t).
+		Status(http.StatusOK).
+		Recorder()
+	if id := rec.Header().Get("X-Request-ID"); len(id) != 36 {
+		t.Errorf("X-Request-ID = %q, want a generated UUID", id)
+	}
+}
+
+func TestTryRequestIDMiddleware(t *testing.T) {
+	_, err := middleware.TryRequestIDMiddleware(&middleware.RequestIDConfig{MaxLength: -1})
+	var configErr *middleware.ConfigError
+	if !errors.As(err, &configErr) || configErr.Field != "MaxLength" {
+		t.Fatalf("TryRequestIDMiddleware() error = %v, want a *ConfigError for MaxLength", err)
+	}
+}
@@ -0,0 +1,244 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/mythofleader/go-http-server/core"
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// ErrResponseTooLarge is returned by writes that would make a response exceed the limit
+// of the response limit middleware.
+var ErrResponseTooLarge = errors.New("response exceeds the maximum size")
+
+// ResponseLimitMiddleware returns a middleware function that limits response bodies to maxSize bytes,
+// so that a runaway handler, e.g. one serializing an unbounded query, can't exhaust memory or flood
+// clients. The response is buffered until the handler returns or flushes it. If it grows past the limit
+// before then, it is discarded and a 500 Internal Server Error response is sent instead. A flushed,
+// streaming response is cut off at the limit. Writes past the limit fail with ErrResponseTooLarge,
+// and every oversized response is logged.
+//
+// Example usage:
+//
+//	s.Use(middleware.ResponseLimitMiddleware(50 << 20)) // 50 MB
+func ResponseLimitMiddleware(maxSize int64) core.HandlerFunc {
+	if maxSize <= 0 {
+		panic("ResponseLimitMiddleware requires a positive maxSize")
+	}
+
+	return func(c core.Context) {
+		req := c.Request()
+		originalWriter := c.Writer()
+		writer := &limitWriter{writer: originalWriter, header: originalWriter.Header().Clone(), maxSize: maxSize}
+		c.SetWriter(writer)
+
+		c.Next()
+
+		// gin defers the status set with SetStatus until the first write; pass it on
+		status := 0
+		if w := c.Writer(); !w.Written() && w.Status() != http.StatusOK {
+			status = w.Status()
+		}
+
+		if writer.exceeded() {
+			log.Printf("[WARNING] %s %s: response exceeds %d bytes; %s", req.Method, req.URL.Path, maxSize, writer.outcome())
+		}
+		if writer.state == limitExceeded {
+			c.SetWriter(originalWriter)
+			c.JSON(http.StatusInternalServerError, httperrors.NewInternalServerErrorResponse("Response too large"))
+			c.Abort()
+			return
+		}
+
+		writer.commit()
+		c.SetWriter(originalWriter)
+		if status != 0 {
+			c.SetStatus(status)
+		}
+	}
+}
+
+// Response states of a limitWriter.
+const (
+	limitBuffering = iota // The handler is running; its response is buffered
+	limitCommitted        // The handler flushed; its response goes to the client
+	limitExceeded         // The buffered response exceeded the limit and was discarded
+	limitTruncated        // The committed response reached the limit and was cut off
+)
+
+// limitWriter buffers a response until it is committed, and rejects writes past maxSize.
+type limitWriter struct {
+	writer  core.ResponseWriter
+	maxSize int64
+
+	state  int
+	header http.Header
+	status int
+	body   bytes.Buffer
+	size   int64 // Body bytes written through after committing, including the buffered ones
+}
+
+// exceeded returns whether the response reached the limit.
+func (w *limitWriter) exceeded() bool {
+	return w.state == limitExceeded || w.state == limitTruncated
+}
+
+// outcome describes what happened to an oversized response, for the log.
+func (w *limitWriter) outcome() string {
+	if w.state == limitTruncated {
+		return "the streamed response was cut off"
+	}
+	return "it was replaced with a 500 response"
+}
+
+// Header returns the buffered header map, or the header map of the underlying writer once committed.
+func (w *limitWriter) Header() http.Header {
+	if w.state == limitCommitted || w.state == limitTruncated {
+		return w.writer.Header()
+	}
+	return w.header
+}
+
+// WriteHeader records the status code, or sends it once committed. A Content-Length above the
+// limit exceeds it right away.
+func (w *limitWriter) WriteHeader(code int) {
+	switch w.state {
+	case limitCommitted, limitTruncated:
+		w.writer.WriteHeader(code)
+	case limitBuffering:
+		if w.status != 0 {
+			return
+		}
+		w.status = code
+		if length, err := strconv.ParseInt(w.header.Get("Content-Length"), 10, 64); err == nil && length > w.maxSize {
+			w.exceed()
+		}
+	}
+}
+
+// Write buffers data, or writes it once committed, up to the limit.
+func (w *limitWriter) Write(data []byte) (int, error) {
+	switch w.state {
+	case limitExceeded, limitTruncated:
+		return 0, ErrResponseTooLarge
+	case limitCommitted:
+		if w.size+int64(len(data)) > w.maxSize {
+			// Send what fits, so that the client gets exactly maxSize bytes
+			n, _ := w.writer.Write(data[:w.maxSize-w.size])
+			w.size += int64(n)
+			w.state = limitTruncated
+			return n, ErrResponseTooLarge
+		}
+		n, err := w.writer.Write(data)
+		w.size += int64(n)
+		return n, err
+	}
+
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+		if w.state == limitExceeded {
+			return 0, ErrResponseTooLarge
+		}
+	}
+	if int64(w.body.Len()+len(data)) > w.maxSize {
+		w.exceed()
+		return 0, ErrResponseTooLarge
+	}
+	return w.body.Write(data)
+}
+
+// Flush commits the buffered response and flushes it, so that streaming handlers keep working.
+func (w *limitWriter) Flush() {
+	if w.state == limitExceeded {
+		return
+	}
+	w.commit()
+	w.writer.Flush()
+}
+
+// Hijack commits the buffered response and hijacks the connection of the underlying writer.
+func (w *limitWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.state == limitExceeded {
+		return nil, nil, ErrResponseTooLarge
+	}
+	w.commit()
+	return http.NewResponseController(w.writer).Hijack()
+}
+
+// Status returns the response status code, http.StatusOK if none has been set.
+func (w *limitWriter) Status() int {
+	if w.state != limitBuffering && w.state != limitExceeded {
+		return w.writer.Status()
+	}
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Size returns the number of body bytes written, -1 if the status code has not been written.
+func (w *limitWriter) Size() int {
+	if w.state != limitBuffering && w.state != limitExceeded {
+		return w.writer.Size()
+	}
+	if w.status == 0 {
+		return -1
+	}
+	return w.body.Len()
+}
+
+// Written returns whether the status code has been written.
+func (w *limitWriter) Written() bool {
+	return w.Size() != -1
+}
+
+// Pusher returns the http.Pusher of the underlying writer, if any.
+func (w *limitWriter) Pusher() http.Pusher {
+	return w.writer.Pusher()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *limitWriter) Unwrap() http.ResponseWriter {
+	return w.writer
+}
+
+// exceed discards the buffered response.
+func (w *limitWriter) exceed() {
+	w.state = limitExceeded
+	w.body = bytes.Buffer{}
+}
+
+// commit sends the buffered headers, status and body and switches to writing through.
+func (w *limitWriter) commit() {
+	if w.state != limitBuffering {
+		return
+	}
+	w.state = limitCommitted
+
+	// The handler may have removed headers set by earlier middleware as well as added its own
+	header := w.writer.Header()
+	for key := range header {
+		if _, ok := w.header[key]; !ok {
+			delete(header, key)
+		}
+	}
+	for key, values := range w.header {
+		header[key] = values
+	}
+
+	if w.status != 0 {
+		w.writer.WriteHeader(w.status)
+	}
+	if w.body.Len() > 0 {
+		n, _ := w.writer.Write(w.body.Bytes())
+		w.size = int64(n)
+		w.body = bytes.Buffer{}
+	}
+}
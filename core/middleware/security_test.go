@@ -0,0 +1,81 @@
+package middleware_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			s.Use(middleware.NewDefaultSecurityHeadersMiddleware())
+			s.GET("/", func(c core.Context) {
+				c.String(http.StatusOK, "%s", c.CSPNonce())
+			})
+			client := servertest.NewClient(s)
+
+			rec := client.GET("/").Expect(t).
+				Status(http.StatusOK).
+				Header("X-Content-Type-Options", "nosniff").
+				Header("X-Frame-Options", "DENY").
+				Header("Referrer-Policy", "strict-origin-when-cross-origin").
+				Header("Strict-Transport-Security", "").
+				Recorder()
+
+			nonce := rec.Body.String()
+			if len(nonce) != 24 {
+				t.Fatalf("nonce = %q, want 24 base64 characters", nonce)
+			}
+			want := "default-src 'self'; script-src 'self' 'nonce-" + nonce + "'; style-src 'self' 'nonce-" + nonce + "'; object-src 'none'; base-uri 'self'; frame-ancestors 'none'"
+			if got := rec.Header().Get("Content-Security-Policy"); got != want {
+				t.Errorf("Content-Security-Policy = %q, want %q", got, want)
+			}
+
+			// Every request gets a fresh nonce
+			other := client.GET("/").Expect(t).Recorder().Body.String()
+			if other == nonce {
+				t.Error("nonce reused across requests")
+			}
+
+			client.GET("/").WithHeader("X-Forwarded-Proto", "https").Expect(t).
+				Header("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		})
+	}
+}
+
+func TestSecurityHeadersMiddlewareCustomPolicy(t *testing.T) {
+	s := std.NewServer("8080", false)
+	config := middleware.DefaultSecurityHeadersConfig()
+	config.ContentSecurityPolicy = "default-src 'self'; script-src https://cdn.example.com"
+	config.NonceDirectives = []string{"script-src"}
+	config.FrameOptions = ""
+	s.Use(middleware.SecurityHeadersMiddleware(config))
+	s.GET("/", func(c core.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	policy := rec.Header().Get("Content-Security-Policy")
+	if !strings.HasPrefix(policy, "default-src 'self'; script-src https://cdn.example.com 'nonce-") {
+		t.Errorf("Content-Security-Policy = %q, want nonce on script-src only", policy)
+	}
+	if rec.Header().Get("X-Frame-Options") != "" {
+		t.Error("X-Frame-Options sent although disabled")
+	}
+	if rec.Header().Get("Strict-Transport-Security") == "" {
+		t.Error("Strict-Transport-Security not sent over TLS")
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/util"
+)
+
+// SkipRoutesMiddleware returns middleware that runs handler except on the given routes, where the
+// rest of the chain runs directly. Routes are templates such as "/events" or "GET /events/:id";
+// templates without a method match every method.
+func SkipRoutesMiddleware(handler core.HandlerFunc, routes ...string) core.HandlerFunc {
+	if handler == nil {
+		panic("SkipRoutesMiddleware requires a handler")
+	}
+	if len(routes) == 0 {
+		return handler
+	}
+
+	// The templates are compiled into one matcher per method, "" matching every method
+	paths := make(map[string][]string)
+	for _, template := range routes {
+		method, path := parseRouteTemplate(template)
+		paths[method] = append(paths[method], path)
+	}
+	matchers := make(map[string]*util.PathMatcher, len(paths))
+	for method, patterns := range paths {
+		matchers[method] = util.CompilePaths(patterns)
+	}
+	anyMethod := matchers[""]
+
+	return func(c core.Context) {
+		req := c.Request()
+		method := matchers[req.Method]
+		if (anyMethod != nil && anyMethod.Match(req.URL.Path)) || (method != nil && method.Match(req.URL.Path)) {
+			c.Next()
+			return
+		}
+		handler(c)
+	}
+}
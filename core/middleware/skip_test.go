@@ -0,0 +1,37 @@
+package middleware_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestSkipRoutesMiddleware(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			tag := func(c core.Context) {
+				c.Writer().Header().Set("X-Tagged", "true")
+				c.Next()
+			}
+			s.Use(middleware.SkipRoutesMiddleware(tag, "/events", "POST /items", "GET /files"))
+			handler := func(c core.Context) {
+				c.String(http.StatusOK, "ok")
+			}
+			s.GET("/events", handler)
+			s.POST("/items", handler)
+			s.DELETE("/files", handler)
+			s.GET("/other", handler)
+			client := servertest.NewClient(s)
+
+			client.GET("/events").Expect(t).Status(http.StatusOK).Header("X-Tagged", "")
+			client.POST("/items").Expect(t).Status(http.StatusOK).Header("X-Tagged", "")
+			client.Request(http.MethodDelete, "/files").Expect(t).Status(http.StatusOK).Header("X-Tagged", "true")
+			client.GET("/other").Expect(t).Status(http.StatusOK).Header("X-Tagged", "true")
+		})
+	}
+}
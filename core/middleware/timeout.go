@@ -2,9 +2,16 @@
 package middleware
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/mythofleader/go-http-server/core"
@@ -15,6 +22,17 @@ type TimeoutConfig struct {
 	// Timeout is the maximum duration to wait for a response.
 	// If not set, it defaults to 2 seconds.
 	Timeout time.Duration
+
+	// SoftTimeout is the duration after which a handler that is still running is reported, without
+	// failing its request, to give early signal on creeping latency. It must be less than Timeout.
+	// If not set, handlers are not reported before they time out.
+	SoftTimeout time.Duration
+
+	// OnSoftTimeout is called when a handler is still running at SoftTimeout, e.g. to count slow
+	// requests in a metric. It runs on its own goroutine while the handler keeps running, so it
+	// receives the request rather than the context; it should return quickly, as the writes of the
+	// handler wait for it. If nil, a warning is logged.
+	OnSoftTimeout func(req *http.Request)
 }
 
 // DefaultTimeoutConfig returns a default timeout configuration.
@@ -24,6 +42,26 @@ func DefaultTimeoutConfig() *TimeoutConfig {
 	}
 }
 
+// Validate checks that the soft timeout comes before the timeout, returning a *ConfigError if not.
+func (config *TimeoutConfig) Validate() error {
+	if config.SoftTimeout < 0 {
+		return &ConfigError{
+			Middleware: "TimeoutMiddleware",
+			Field:      "SoftTimeout",
+			Problem:    "must not be negative",
+		}
+	}
+	if config.SoftTimeout > 0 && config.SoftTimeout >= config.Timeout {
+		return &ConfigError{
+			Middleware: "TimeoutMiddleware",
+			Field:      "SoftTimeout",
+			Problem:    fmt.Sprintf("(%v) must be less than Timeout (%v)", config.SoftTimeout, config.Timeout),
+			Remedy:     "lower SoftTimeout, or leave it unset to disable the warning",
+		}
+	}
+	return nil
+}
+
 // NewDefaultTimeoutMiddleware returns a middleware function with default configuration.
 // This function uses the DefaultTimeoutConfig which sets a default timeout of 2 seconds.
 // Example usage:
@@ -40,58 +78,343 @@ func NewDefaultTimeoutMiddleware() core.HandlerFunc {
 }
 
 // TimeoutMiddleware returns a middleware function that times out requests after a specified duration.
-// If the handler doesn't respond within the timeout period, it returns a 503 Service Unavailable response.
+// The request context is canceled at the timeout, so that handlers and the calls they make can stop
+// early. The response of the handler is buffered until it returns: if it does not return within the
+// timeout period, a 504 Gateway Timeout response is sent instead, and its writes are rejected
+// with core.ErrResponseSent. A handler that flushes its response, e.g. to stream it, commits it and
+// is no longer timed out.
+//
+// With a SoftTimeout, a handler still running at the soft timeout is reported with a warning, or to
+// OnSoftTimeout, and keeps running until the timeout. It panics with a *ConfigError if the
+// configuration is invalid; use TryTimeoutMiddleware to handle the error instead.
+//
+// Example usage:
+//
+//	s.Use(middleware.TimeoutMiddleware(&middleware.TimeoutConfig{
+//		Timeout:     5 * time.Second,
+//		SoftTimeout: 2 * time.Second, // Warn about requests getting close to the timeout
+//	}))
 func TimeoutMiddleware(config *TimeoutConfig) core.HandlerFunc {
+	handler, err := TryTimeoutMiddleware(config)
+	if err != nil {
+		panic(err)
+	}
+	return handler
+}
+
+// TryTimeoutMiddleware is like TimeoutMiddleware, but returns a *ConfigError instead of panicking
+// if the configuration is invalid.
+func TryTimeoutMiddleware(config *TimeoutConfig) (core.HandlerFunc, error) {
 	if config == nil {
 		config = DefaultTimeoutConfig()
 	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 
 	// Log middleware configuration
 	log.Printf("[MIDDLEWARE] Timeout middleware configured:")
 	log.Printf("[MIDDLEWARE]   - Timeout: %v", config.Timeout)
+	if config.SoftTimeout > 0 {
+		log.Printf("[MIDDLEWARE]   - Soft timeout: %v", config.SoftTimeout)
+	}
 
-	return func(c core.Context) {
-		// Create a channel to track if the response has been written
-		responseSent := make(chan bool, 1)
+	onSoftTimeout := config.OnSoftTimeout
+	if onSoftTimeout == nil {
+		timeout, softTimeout := config.Timeout, config.SoftTimeout
+		onSoftTimeout = func(req *http.Request) {
+			log.Printf("[WARNING] %s %s (request %q) is still running after %v; it times out after %v",
+				req.Method, req.URL.Path, core.RequestIDFromContext(req.Context()), softTimeout, timeout)
+		}
+	}
 
-		// Create a timeout channel
-		timeoutCh := time.After(config.Timeout)
+	message := []byte(fmt.Sprintf("Request timed out after %v", config.Timeout))
 
-		// Get the original response writer
+	return func(c core.Context) {
+		originalReq := c.Request()
 		originalWriter := c.Writer()
 
-		// Create a goroutine to handle the timeout
-		go func() {
-			// Wait for the timeout
-			<-timeoutCh
-
-			// Check if a response has already been sent
-			select {
-			case <-responseSent:
-				// Response already sent, do nothing
-				return
-			default:
-				// No response sent yet, send timeout response
-				originalWriter.WriteHeader(http.StatusServiceUnavailable)
-				originalWriter.Write([]byte(fmt.Sprintf("Request timed out after %v", config.Timeout)))
-				responseSent <- true
-			}
-		}()
-
-		// Signal when the response is sent
-		defer func() {
-			select {
-			case <-responseSent:
-				// Response already sent by timeout handler
-				return
-			default:
-				// Response sent by normal handler
-				responseSent <- true
+		// Cancel the request context at the timeout
+		ctx, cancel := context.WithTimeout(originalReq.Context(), config.Timeout)
+		defer cancel()
+		c.SetRequest(originalReq.WithContext(ctx))
+
+		// Buffer the response, so that the handler and the timeout response can't both be sent
+		writer := newTimeoutWriter(originalWriter)
+		c.SetWriter(writer)
+
+		// Respond with a timeout unless the handler has returned or committed its response by then.
+		// A context canceled because the client went away does not need a response.
+		stop := context.AfterFunc(ctx, func() {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				writer.timeout(message)
 			}
-		}()
+		})
+
+		// Report the handler if it is still running at the soft timeout. Committed responses, such
+		// as streams, are no longer timed out and are not reported either.
+		var soft *time.Timer
+		if config.SoftTimeout > 0 {
+			req := c.Request()
+			soft = time.AfterFunc(config.SoftTimeout, func() {
+				writer.reportRunning(func() { onSoftTimeout(req) })
+			})
+		}
 
 		// Continue with the next middleware/handler in the chain
 		// This will execute the actual request handler
 		c.Next()
+		stop()
+		if soft != nil {
+			soft.Stop()
+			// A report already under way completes before the handler is marked as returned
+			writer.markReturned()
+		}
+
+		// A handler returning after the deadline has lost, even if it returned before the
+		// timeout response could be sent
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			writer.timeout(message)
+		}
+
+		// gin defers the status set with SetStatus until the first write; pass it on
+		status := 0
+		if w := c.Writer(); !w.Written() && w.Status() != http.StatusOK {
+			status = w.Status()
+		}
+
+		// Send the buffered response, unless the timeout response has been sent
+		if writer.finish() {
+			c.SetRequest(originalReq)
+			c.SetWriter(originalWriter)
+			if status != 0 {
+				c.SetStatus(status)
+			}
+			return
+		}
+
+		// The timeout response has been sent: keep the finished writer in place so that the
+		// middleware before this one can't write a second response, and writes from goroutines
+		// the handler left behind are rejected. Installing it again drops the status the
+		// handler may have left in gin's writer.
+		c.SetRequest(originalReq)
+		c.SetWriter(writer)
+		c.Abort()
+	}, nil
+}
+
+// Response states of a timeoutWriter.
+const (
+	timeoutBuffering = iota // The handler is running; its response is buffered
+	timeoutCommitted        // The handler flushed or finished; its response goes to the client
+	timeoutTimedOut         // The timeout response has been sent; the handler's writes are rejected
+)
+
+// timeoutWriter buffers the response of a handler until the handler finishes or flushes, or
+// discards it if the timeout response is sent first. The mutex makes sure exactly one of them wins.
+type timeoutWriter struct {
+	writer core.ResponseWriter
+
+	mu     sync.Mutex
+	state  int
+	header http.Header
+	status int
+	body   bytes.Buffer
+	warned bool
+
+	// returned is set once the handler has returned, after which it is no longer reported at the soft timeout
+	returned bool
+}
+
+// newTimeoutWriter returns a timeoutWriter for w. The header map starts as a copy of the headers
+// set by earlier middleware, so that the handler sees them.
+func newTimeoutWriter(w core.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{writer: w, header: w.Header().Clone()}
+}
+
+// Header returns the buffered header map, or the header map of the underlying writer once committed.
+func (w *timeoutWriter) Header() http.Header {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.state == timeoutCommitted {
+		return w.writer.Header()
+	}
+	return w.header
+}
+
+// WriteHeader records the status code, or sends it once committed. It is ignored after a
+// timeout, when gin sends the pending status of the handler.
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch w.state {
+	case timeoutCommitted:
+		w.writer.WriteHeader(code)
+	case timeoutBuffering:
+		if w.status == 0 {
+			w.status = code
+		}
+	}
+}
+
+// Write buffers data, or writes it once committed. It returns core.ErrResponseSent after a timeout.
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch w.state {
+	case timeoutTimedOut:
+		w.warn("ignoring write of %d bytes after the request timed out", len(data))
+		return 0, core.ErrResponseSent
+	case timeoutCommitted:
+		return w.writer.Write(data)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(data)
+}
+
+// Flush commits the buffered response and flushes it, so that streaming handlers keep working.
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.state == timeoutTimedOut {
+		return
+	}
+	w.commit()
+	w.writer.Flush()
+}
+
+// Hijack commits the buffered response and hijacks the connection of the underlying writer.
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.state == timeoutTimedOut {
+		return nil, nil, core.ErrResponseSent
+	}
+	w.commit()
+	return http.NewResponseController(w.writer).Hijack()
+}
+
+// Status returns the response status code, http.StatusOK if none has been set.
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.state != timeoutBuffering {
+		return w.writer.Status()
+	}
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Size returns the number of body bytes written, -1 if the status code has not been written.
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.state != timeoutBuffering {
+		return w.writer.Size()
+	}
+	if w.status == 0 {
+		return -1
+	}
+	return w.body.Len()
+}
+
+// Written returns whether the status code has been written.
+func (w *timeoutWriter) Written() bool {
+	return w.Size() != -1
+}
+
+// Pusher returns the http.Pusher of the underlying writer, if any.
+func (w *timeoutWriter) Pusher() http.Pusher {
+	return w.writer.Pusher()
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.writer
+}
+
+// reportRunning calls report if the handler is still running and has neither committed its response
+// nor timed out. report runs under the lock, so that it never runs once markReturned has returned.
+func (w *timeoutWriter) reportRunning(report func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.state == timeoutBuffering && !w.returned {
+		report()
+	}
+}
+
+// markReturned records that the handler has returned, waiting for a report under way.
+func (w *timeoutWriter) markReturned() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.returned = true
+}
+
+// timeout sends the timeout response with message, unless the handler's response has been committed.
+func (w *timeoutWriter) timeout(message []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.state != timeoutBuffering {
+		return
+	}
+	w.state = timeoutTimedOut
+	header := w.writer.Header()
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	header.Set("Content-Length", strconv.Itoa(len(message)))
+	w.writer.WriteHeader(http.StatusGatewayTimeout)
+	w.writer.Write(message)
+	w.writer.Flush()
+}
+
+// finish sends the buffered response once the handler has returned. It returns false if the
+// timeout response has been sent instead.
+func (w *timeoutWriter) finish() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.state == timeoutTimedOut {
+		return false
+	}
+	w.commit()
+	return true
+}
+
+// commit sends the buffered headers, status and body and switches to writing through.
+// The caller must hold w.mu.
+func (w *timeoutWriter) commit() {
+	if w.state == timeoutCommitted {
+		return
+	}
+	w.state = timeoutCommitted
+
+	// The handler may have removed headers set by earlier middleware as well as added its own
+	header := w.writer.Header()
+	for key := range header {
+		if _, ok := w.header[key]; !ok {
+			delete(header, key)
+		}
+	}
+	for key, values := range w.header {
+		header[key] = values
+	}
+
+	if w.status != 0 {
+		w.writer.WriteHeader(w.status)
+	}
+	if w.body.Len() > 0 {
+		w.writer.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+}
+
+// warn logs a rejected write, once per writer. The caller must hold w.mu.
+func (w *timeoutWriter) warn(format string, args ...interface{}) {
+	if w.warned {
+		return
 	}
+	w.warned = true
+	log.Printf("[WARNING] "+format+"; the handler is still writing after its response was replaced", args...)
 }
@@ -0,0 +1,135 @@
+package middleware_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestTimeoutMiddlewareRejectsLateWrites(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			lateErr := make(chan error, 1)
+			s.Use(middleware.TimeoutMiddleware(&middleware.TimeoutConfig{Timeout: 20 * time.Millisecond}))
+			s.GET("/slow", func(c core.Context) {
+				time.Sleep(60 * time.Millisecond)
+				_, err := c.Writer().Write([]byte("too late"))
+				lateErr <- err
+			})
+			s.GET("/fast", func(c core.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+			client := servertest.NewClient(s)
+
+			client.GET("/slow").Expect(t).
+				Status(http.StatusGatewayTimeout).
+				Body("Request timed out after 20ms")
+			if err := <-lateErr; !errors.Is(err, core.ErrResponseSent) {
+				t.Errorf("late write returned %v, want ErrResponseSent", err)
+			}
+
+			client.GET("/fast").Expect(t).
+				Status(http.StatusOK).
+				Body("ok")
+		})
+	}
+}
+
+func TestTimeoutMiddlewareCommitsFlushedResponses(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			s.Use(middleware.TimeoutMiddleware(&middleware.TimeoutConfig{Timeout: 20 * time.Millisecond}))
+			s.GET("/stream", func(c core.Context) {
+				c.SetHeader("Content-Type", "text/event-stream")
+				c.Writer().Write([]byte("data: 1\n\n"))
+				c.Writer().Flush()
+				time.Sleep(40 * time.Millisecond)
+				c.Writer().Write([]byte("data: 2\n\n"))
+			})
+
+			servertest.NewClient(s).GET("/stream").Expect(t).
+				Status(http.StatusOK).
+				Header("Content-Type", "text/event-stream").
+				Body("data: 1\n\ndata: 2\n\n")
+		})
+	}
+}
+
+func TestTimeoutMiddlewareSoftTimeout(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			reported := make(chan string, 2)
+			s.Use(middleware.TimeoutMiddleware(&middleware.TimeoutConfig{
+				Timeout:     200 * time.Millisecond,
+				SoftTimeout: 20 * time.Millisecond,
+				OnSoftTimeout: func(req *http.Request) {
+					reported <- req.URL.Path
+				},
+			}))
+			s.GET("/slow", func(c core.Context) {
+				time.Sleep(60 * time.Millisecond)
+				c.String(http.StatusOK, "done")
+			})
+			s.GET("/fast", func(c core.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+			client := servertest.NewClient(s)
+
+			// The slow handler is reported but still completes its response
+			client.GET("/slow").Expect(t).Status(http.StatusOK).Body("done")
+			client.GET("/fast").Expect(t).Status(http.StatusOK).Body("ok")
+
+			close(reported)
+			var paths []string
+			for path := range reported {
+				paths = append(paths, path)
+			}
+			if len(paths) != 1 || paths[0] != "/slow" {
+				t.Errorf("reported %v, want only /slow", paths)
+			}
+		})
+	}
+}
+
+func TestTimeoutMiddlewareSoftTimeoutLogsWarning(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	s := std.NewServer("8080", false)
+	s.Use(middleware.TimeoutMiddleware(&middleware.TimeoutConfig{
+		Timeout:     200 * time.Millisecond,
+		SoftTimeout: 20 * time.Millisecond,
+	}))
+	s.GET("/slow", func(c core.Context) {
+		time.Sleep(60 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+
+	servertest.NewClient(s).GET("/slow").Expect(t).Status(http.StatusOK).Body("done")
+
+	// The warning is logged before the handler returns, so it is in the buffer by now
+	want := "[WARNING] GET /slow (request \"\") is still running after 20ms; it times out after 200ms"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("log = %q, want it to contain %q", buf.String(), want)
+	}
+}
+
+func TestTimeoutMiddlewareInvalidSoftTimeout(t *testing.T) {
+	_, err := middleware.TryTimeoutMiddleware(&middleware.TimeoutConfig{Timeout: time.Second, SoftTimeout: 2 * time.Second})
+	var configErr *middleware.ConfigError
+	if !errors.As(err, &configErr) || configErr.Field != "SoftTimeout" {
+		t.Errorf("TryTimeoutMiddleware() error = %v, want a SoftTimeout ConfigError", err)
+	}
+}
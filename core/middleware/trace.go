@@ -0,0 +1,365 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// DefaultDebugTraceHeader is the default request header carrying the debug trace secret.
+const DefaultDebugTraceHeader = "X-Debug-Trace"
+
+// DebugTraceIDHeader is the response header carrying the ID of the debug trace of a request.
+const DebugTraceIDHeader = "X-Debug-Trace-Id"
+
+// DefaultDebugTracePath is the default path of the debug trace endpoint.
+const DefaultDebugTracePath = "/debug/traces"
+
+// DefaultDebugTraceCapacity is the default number of recent traces kept by DebugTracer.
+const DefaultDebugTraceCapacity = 50
+
+// DebugTraceHandlerStep is the name of the step recorded for the route handlers.
+const DebugTraceHandlerStep = "Handler"
+
+// Outcomes of a DebugTraceStep.
+const (
+	// DebugTraceSkipped means the middleware was skipped for the route and the chain continued.
+	DebugTraceSkipped = "skipped"
+	// DebugTracePassed means the middleware ran and the chain continued to the next step.
+	DebugTracePassed = "passed"
+	// DebugTraceStopped means the middleware ran and no later step ran, e.g. because it aborted.
+	DebugTraceStopped = "stopped"
+	// DebugTraceHandled means the route handlers ran.
+	DebugTraceHandled = "handled"
+)
+
+// debugTraceKey is the context key of the trace of a request.
+const debugTraceKey = "debugTrace"
+
+// DebugTraceConfig holds configuration for the debug tracer.
+type DebugTraceConfig struct {
+	// Secret is the value of Header that enables tracing for a request. It is required.
+	Secret string
+
+	// Header is the request header carrying the secret. Default: DefaultDebugTraceHeader
+	Header string
+
+	// Capacity is the number of recent traces kept. Default: DefaultDebugTraceCapacity
+	Capacity int
+}
+
+// DebugTraceStep describes what a middleware did with a traced request.
+type DebugTraceStep struct {
+	Name string `json:"name"`
+	// Outcome is DebugTraceSkipped, DebugTracePassed, DebugTraceStopped or DebugTraceHandled
+	Outcome string `json:"outcome"`
+	// Duration is the time spent in the middleware, including the steps it passed the request to
+	Duration time.Duration `json:"duration"`
+	// StatusBefore and StatusAfter are the response status when the step started and returned
+	StatusBefore int `json:"status_before"`
+	StatusAfter  int `json:"status_after"`
+	// Wrote is set if the middleware itself wrote the response header
+	Wrote bool `json:"wrote,omitempty"`
+	// Errors are the errors the middleware itself added with Context.Error
+	Errors []string `json:"errors,omitempty"`
+}
+
+// DebugTrace is the trace of a request.
+type DebugTrace struct {
+	ID       string           `json:"id"`
+	Time     time.Time        `json:"time"`
+	Method   string           `json:"method"`
+	Path     string           `json:"path"`
+	Status   int              `json:"status"`
+	Duration time.Duration    `json:"duration"`
+	Steps    []DebugTraceStep `json:"steps"`
+}
+
+// DebugTracer records, for requests carrying a secret header, the decisions of each middleware:
+// whether it was skipped, passed the request on or stopped the chain, how long it took, and how
+// it changed the response status. The ID of a trace is returned in the DebugTraceIDHeader response
+// header and the trace is served by Handler, to find out e.g. why a request got a 401.
+//
+// Register Middleware first, wrap the traced middleware with Step and serve Handler:
+//
+//	tracer := middleware.NewDebugTracer(&middleware.DebugTraceConfig{Secret: os.Getenv("DEBUG_TRACE_SECRET")})
+//	s.Use(tracer.Middleware())
+//	s.Use(tracer.Step("Auth", middleware.AuthMiddleware(authConfig)))
+//	s.GET(middleware.DefaultDebugTracePath, tracer.Handler())
+type DebugTracer struct {
+	header string
+	secret []byte
+
+	mu     sync.Mutex
+	recent []DebugTrace // Ring buffer of recent traces
+	next   int          // Index of the next trace to write in recent
+	full   bool         // Whether recent has wrapped around
+}
+
+// NewDebugTracer returns a DebugTracer. It panics with a *ConfigError if config has no secret.
+func NewDebugTracer(config *DebugTraceConfig) *DebugTracer {
+	if config == nil || config.Secret == "" {
+		panic(&ConfigError{
+			Middleware: "NewDebugTracer",
+			Field:      "Secret",
+			Problem:    "is empty",
+			Remedy:     "set the value of the header that enables tracing",
+		})
+	}
+	header := config.Header
+	if header == "" {
+		header = DefaultDebugTraceHeader
+	}
+	capacity := config.Capacity
+	if capacity <= 0 {
+		capacity = DefaultDebugTraceCapacity
+	}
+	return &DebugTracer{
+		header: header,
+		secret: []byte(config.Secret),
+		recent: make([]DebugTrace, capacity),
+	}
+}
+
+// activeTrace is the trace of a request being served. Steps are nested: a middleware calling
+// Next is on the stack while the steps after it run. As the chain, it is only used by the
+// goroutine serving the request.
+type activeTrace struct {
+	c     core.Context
+	clock core.Clock
+	steps []DebugTraceStep
+	stack []*openStep
+}
+
+// openStep is a step that has started and not returned yet.
+type openStep struct {
+	index   int
+	start   time.Time
+	ran     bool
+	written bool // Whether the header was written when the step last resumed
+	errors  int  // Number of context errors when the step last resumed
+}
+
+// Middleware returns a middleware function that starts a trace for requests carrying the secret
+// header and stores it once the chain returns. It must be registered before the traced middleware.
+func (t *DebugTracer) Middleware() core.HandlerFunc {
+	return func(c core.Context) {
+		secret := c.GetHeader(t.header)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(secret), t.secret) != 1 {
+			c.Next()
+			return
+		}
+
+		req := c.Request()
+		clock := core.ClockFromContext(req.Context())
+		trace := DebugTrace{
+			ID:     core.IDGeneratorFromContext(req.Context()).NewID(),
+			Time:   clock.Now(),
+			Method: req.Method,
+			Path:   req.URL.Path,
+		}
+		active := &activeTrace{c: c, clock: clock}
+		c.Set(debugTraceKey, active)
+		c.SetHeader(DebugTraceIDHeader, trace.ID)
+
+		c.Next()
+
+		trace.Duration = clock.Now().Sub(trace.Time)
+		trace.Status = c.Writer().Status()
+		trace.Steps = active.finish()
+		t.record(trace)
+	}
+}
+
+// Step returns handler wrapped to record a step of the traces. handler runs unchanged for
+// requests that are not traced. As with SkipRoutesMiddleware, handler is skipped on skipRoutes,
+// which traces report as DebugTraceSkipped.
+func (t *DebugTracer) Step(name string, handler core.HandlerFunc, skipRoutes ...string) core.HandlerFunc {
+	if len(skipRoutes) > 0 {
+		run := handler
+		handler = SkipRoutesMiddleware(func(c core.Context) {
+			if active := debugTraceOf(c); active != nil {
+				active.markRan()
+			}
+			run(c)
+		}, skipRoutes...)
+	}
+	return func(c core.Context) {
+		active := debugTraceOf(c)
+		if active == nil {
+			handler(c)
+			return
+		}
+		step := active.begin(name, len(skipRoutes) == 0)
+		handler(c)
+		active.end(step)
+	}
+}
+
+// HandlerStep returns a handler recording the DebugTraceHandlerStep step. Put it in front of the
+// handlers of a route so that traces show whether the request reached them.
+func (t *DebugTracer) HandlerStep() core.HandlerFunc {
+	return t.Step(DebugTraceHandlerStep, func(c core.Context) {
+		c.Next()
+	})
+}
+
+// Traces returns the recent traces, newest first.
+func (t *DebugTracer) Traces() []DebugTrace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count := t.next
+	if t.full {
+		count = len(t.recent)
+	}
+	traces := make([]DebugTrace, 0, count)
+	for i := 1; i <= count; i++ {
+		traces = append(traces, t.recent[(t.next-i+len(t.recent))%len(t.recent)])
+	}
+	return traces
+}
+
+// Trace returns the recent trace with the given ID.
+func (t *DebugTracer) Trace(id string) (DebugTrace, bool) {
+	for _, trace := range t.Traces() {
+		if trace.ID == id {
+			return trace, true
+		}
+	}
+	return DebugTrace{}, false
+}
+
+// Handler returns a handler serving the recent traces as JSON, or the trace whose ID is given in
+// the id query parameter. Requests must carry the secret header, as traces reveal the paths and
+// errors of recent requests.
+func (t *DebugTracer) Handler() core.HandlerFunc {
+	return func(c core.Context) {
+		secret := c.GetHeader(t.header)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(secret), t.secret) != 1 {
+			c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		id, ok := c.GetQuery("id")
+		if !ok {
+			c.JSON(http.StatusOK, t.Traces())
+			return
+		}
+		trace, ok := t.Trace(id)
+		if !ok {
+			c.JSON(http.StatusNotFound, map[string]string{"error": fmt.Sprintf("trace %s not found", id)})
+			return
+		}
+		c.JSON(http.StatusOK, trace)
+	}
+}
+
+// record adds a finished trace to the recent traces.
+func (t *DebugTracer) record(trace DebugTrace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.recent[t.next] = trace
+	t.next = (t.next + 1) % len(t.recent)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// debugTraceOf returns the trace of the request, or nil if it is not traced.
+func debugTraceOf(c core.Context) *activeTrace {
+	value, ok := c.Get(debugTraceKey)
+	if !ok {
+		return nil
+	}
+	active, _ := value.(*activeTrace)
+	return active
+}
+
+// begin records the start of a step. Steps that have not run are marked with markRan when they do.
+func (a *activeTrace) begin(name string, ran bool) *openStep {
+	// The step running the chain pauses while this one runs
+	if len(a.stack) > 0 {
+		a.pause(a.stack[len(a.stack)-1])
+	}
+	step := &openStep{
+		index:   len(a.steps),
+		start:   a.clock.Now(),
+		ran:     ran,
+		written: a.c.Writer().Written(),
+		errors:  len(a.c.Errors()),
+	}
+	a.steps = append(a.steps, DebugTraceStep{Name: name, StatusBefore: a.c.Writer().Status()})
+	a.stack = append(a.stack, step)
+	return step
+}
+
+// end records the return of a step.
+func (a *activeTrace) end(step *openStep) {
+	a.pause(step)
+	recorded := &a.steps[step.index]
+	recorded.Duration = a.clock.Now().Sub(step.start)
+	recorded.StatusAfter = a.c.Writer().Status()
+	if !step.ran {
+		recorded.Outcome = DebugTraceSkipped
+	}
+
+	for i := len(a.stack) - 1; i >= 0; i-- {
+		if a.stack[i] == step {
+			a.stack = append(a.stack[:i], a.stack[i+1:]...)
+			break
+		}
+	}
+	// The step running the chain resumes
+	if len(a.stack) > 0 {
+		a.resume(a.stack[len(a.stack)-1])
+	}
+}
+
+// markRan marks the innermost open step as run.
+func (a *activeTrace) markRan() {
+	if len(a.stack) > 0 {
+		a.stack[len(a.stack)-1].ran = true
+	}
+}
+
+// pause attributes the header write and errors since the step last resumed to the step.
+func (a *activeTrace) pause(step *openStep) {
+	recorded := &a.steps[step.index]
+	if !step.written && a.c.Writer().Written() {
+		recorded.Wrote = true
+	}
+	errs := a.c.Errors()
+	for _, err := range errs[min(step.errors, len(errs)):] {
+		recorded.Errors = append(recorded.Errors, err.Error())
+	}
+}
+
+// resume starts attributing the header write and errors to the step again.
+func (a *activeTrace) resume(step *openStep) {
+	step.written = a.c.Writer().Written()
+	step.errors = len(a.c.Errors())
+}
+
+// finish returns the recorded steps with their outcomes.
+func (a *activeTrace) finish() []DebugTraceStep {
+	steps := make([]DebugTraceStep, len(a.steps))
+	copy(steps, a.steps)
+	for i := range steps {
+		switch {
+		case steps[i].Outcome != "":
+		case steps[i].Name == DebugTraceHandlerStep:
+			steps[i].Outcome = DebugTraceHandled
+		case i < len(steps)-1:
+			steps[i].Outcome = DebugTracePassed
+		default:
+			steps[i].Outcome = DebugTraceStopped
+		}
+	}
+	return steps
+}
@@ -0,0 +1,110 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestDebugTracer(t *testing.T) {
+	tracer := middleware.NewDebugTracer(&middleware.DebugTraceConfig{Secret: "s3cret"})
+	s := std.NewServer("8080", false)
+	s.Use(tracer.Middleware())
+	s.Use(tracer.Step("ErrorHandler", std.NewErrorHandlerMiddleware().Middleware(&core.ErrorHandlerConfig{AggregateErrors: true})))
+	s.Use(tracer.Step("Timeout", func(c core.Context) { c.Next() }, "/orders/:id"))
+	s.Use(tracer.Step("Auth", func(c core.Context) {
+		if c.GetHeader("Authorization") == "" {
+			_ = c.Error(httperrors.NewUnauthorizedHttpError(errors.New("missing token")))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}))
+	s.GET("/orders/:id", tracer.HandlerStep(), func(c core.Context) {
+		c.String(http.StatusOK, "order")
+	})
+	s.GET(middleware.DefaultDebugTracePath, tracer.Handler())
+	client := servertest.NewClient(s)
+
+	// Requests without the secret are not traced
+	rec := client.GET("/orders/42").Expect(t).Status(http.StatusUnauthorized).Recorder()
+	if id := rec.Header().Get(middleware.DebugTraceIDHeader); id != "" {
+		t.Fatalf("untraced request got trace ID %q", id)
+	}
+	if traces := tracer.Traces(); len(traces) != 0 {
+		t.Fatalf("Traces() = %d traces, want 0", len(traces))
+	}
+
+	rec = client.GET("/orders/42").WithHeader(middleware.DefaultDebugTraceHeader, "s3cret").Expect(t).
+		Status(http.StatusUnauthorized).Recorder()
+	trace, ok := tracer.Trace(rec.Header().Get(middleware.DebugTraceIDHeader))
+	if !ok {
+		t.Fatal("trace of the request was not recorded")
+	}
+	if trace.Status != http.StatusUnauthorized || trace.Path != "/orders/42" || len(trace.Steps) != 3 {
+		t.Fatalf("trace = %+v, want 401 for /orders/42 with 3 steps", trace)
+	}
+	errorHandler, timeout, auth := trace.Steps[0], trace.Steps[1], trace.Steps[2]
+	if errorHandler.Outcome != middleware.DebugTracePassed || !errorHandler.Wrote || errorHandler.StatusAfter != http.StatusUnauthorized {
+		t.Errorf("ErrorHandler step = %+v, want passed and wrote 401", errorHandler)
+	}
+	if timeout.Outcome != middleware.DebugTraceSkipped {
+		t.Errorf("Timeout step outcome = %q, want %q", timeout.Outcome, middleware.DebugTraceSkipped)
+	}
+	if auth.Outcome != middleware.DebugTraceStopped || auth.Wrote || len(auth.Errors) != 1 {
+		t.Errorf("Auth step = %+v, want stopped with one error", auth)
+	}
+
+	rec = client.GET("/orders/42").
+		WithHeader(middleware.DefaultDebugTraceHeader, "s3cret").
+		WithHeader("Authorization", "Bearer token").
+		Expect(t).Status(http.StatusOK).Recorder()
+	trace, _ = tracer.Trace(rec.Header().Get(middleware.DebugTraceIDHeader))
+	if len(trace.Steps) != 4 || trace.Steps[2].Outcome != middleware.DebugTracePassed ||
+		trace.Steps[3].Outcome != middleware.DebugTraceHandled || !trace.Steps[3].Wrote {
+		t.Errorf("steps = %+v, want auth passed and handler handled", trace.Steps)
+	}
+
+	// The endpoint requires the secret
+	client.GET(middleware.DefaultDebugTracePath).WithHeader("Authorization", "Bearer token").Expect(t).
+		Status(http.StatusNotFound)
+	client.GET(middleware.DefaultDebugTracePath).
+		WithHeader(middleware.DefaultDebugTraceHeader, "s3cret").
+		WithHeader("Authorization", "Bearer token").
+		WithQuery("id", trace.ID).
+		Expect(t).
+		Status(http.StatusOK).
+		JSONPath("$.steps[3].outcome", middleware.DebugTraceHandled)
+}
+
+func TestDebugTracerClock(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := servertest.NewFakeClock(now)
+	tracer := middleware.NewDebugTracer(&middleware.DebugTraceConfig{Secret: "s3cret"})
+	s := std.NewServer("8080", false)
+	s.Use(core.ClockMiddleware(clock, core.IDGeneratorFunc(func() string { return "trace-1" })))
+	s.Use(tracer.Middleware())
+	s.GET("/slow", tracer.HandlerStep(), func(c core.Context) {
+		clock.Advance(3 * time.Second)
+		c.String(http.StatusOK, "ok")
+	})
+	client := servertest.NewClient(s)
+
+	client.GET("/slow").WithHeader(middleware.DefaultDebugTraceHeader, "s3cret").Expect(t).
+		Status(http.StatusOK).
+		Header(middleware.DebugTraceIDHeader, "trace-1")
+	trace, ok := tracer.Trace("trace-1")
+	if !ok {
+		t.Fatal("trace of the request was not recorded")
+	}
+	if !trace.Time.Equal(now) || trace.Duration != 3*time.Second || trace.Steps[0].Duration != 3*time.Second {
+		t.Errorf("trace = %+v, want started at %v and lasting 3s", trace, now)
+	}
+}
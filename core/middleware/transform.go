@@ -0,0 +1,136 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// ResponseTransformer rewrites a decoded JSON response payload.
+// The payload is the result of decoding the response body with json.Decoder.UseNumber:
+// objects are map[string]interface{}, arrays are []interface{} and numbers are json.Number.
+// It returns the payload to send, which may be the same value modified in place.
+type ResponseTransformer func(c core.Context, status int, payload interface{}) (interface{}, error)
+
+// ResponseTransformMiddleware returns a middleware function that buffers JSON responses and
+// passes them through the given transformers, in order, before they are sent.
+// Register it on a router group to transform only the responses of that group, e.g. to rename
+// fields for an API version. Non-JSON and empty responses are sent unchanged.
+// Because responses are buffered, streamed responses (JSONStream, NDJSON, CSV) are only sent
+// once the handler returns.
+// If a transformer returns an error, a 500 Internal Server Error response is sent instead.
+//
+// Example usage:
+//
+//	v1 := s.Group("/v1")
+//	v1.Use(middleware.ResponseTransformMiddleware(
+//		middleware.RenameFields(map[string]string{"fullName": "name"}),
+//		middleware.WrapEnvelope("data"),
+//	))
+func ResponseTransformMiddleware(transformers ...ResponseTransformer) core.HandlerFunc {
+	if len(transformers) == 0 {
+		panic("ResponseTransformMiddleware requires at least one transformer")
+	}
+
+	return func(c core.Context) {
+		original := c.Writer()
+		buffer := core.NewResponseBuffer(original)
+		c.SetWriter(buffer)
+		c.Next()
+		c.SetWriter(original)
+
+		if err := transformResponse(c, buffer, transformers); err != nil {
+			log.Printf("[MIDDLEWARE] Response transformation failed: %v", err)
+			buffer.Header().Set("Content-Type", "application/json")
+			buffer = core.NewResponseBuffer(original)
+			buffer.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(buffer).Encode(errors.NewInternalServerErrorResponse("Failed to transform response"))
+		}
+		_ = buffer.Commit()
+	}
+}
+
+// transformResponse applies the transformers to the buffered response if it is JSON.
+func transformResponse(c core.Context, buffer *core.ResponseBuffer, transformers []ResponseTransformer) error {
+	body := bytes.TrimSpace(buffer.Body())
+	if len(body) == 0 || !strings.Contains(buffer.Header().Get("Content-Type"), "json") {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var payload interface{}
+	if err := decoder.Decode(&payload); err != nil {
+		// Not a single JSON document; leave it alone
+		return nil
+	}
+
+	var err error
+	for _, transform := range transformers {
+		if payload, err = transform(c, buffer.Status(), payload); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	buffer.SetBody(data)
+	return nil
+}
+
+// TransformKeys returns a ResponseTransformer that renames the keys of all objects in the payload,
+// at any depth, with the given function.
+func TransformKeys(rename func(key string) string) ResponseTransformer {
+	return func(c core.Context, status int, payload interface{}) (interface{}, error) {
+		return transformKeys(payload, rename), nil
+	}
+}
+
+// transformKeys renames the object keys of value recursively.
+func transformKeys(value interface{}, rename func(key string) string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			result[rename(key)] = transformKeys(child, rename)
+		}
+		return result
+	case []interface{}:
+		for i, child := range v {
+			v[i] = transformKeys(child, rename)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// RenameFields returns a ResponseTransformer that renames object keys, at any depth,
+// according to the given old name to new name mapping. Keys not in the mapping are kept.
+func RenameFields(fields map[string]string) ResponseTransformer {
+	return TransformKeys(func(key string) string {
+		if renamed, ok := fields[key]; ok {
+			return renamed
+		}
+		return key
+	})
+}
+
+// WrapEnvelope returns a ResponseTransformer that wraps successful (2xx) payloads in an object
+// under the given key, e.g. {"data": payload}. Error responses are left unchanged.
+func WrapEnvelope(key string) ResponseTransformer {
+	return func(c core.Context, status int, payload interface{}) (interface{}, error) {
+		if status < 200 || status >= 300 {
+			return payload, nil
+		}
+		return map[string]interface{}{key: payload}, nil
+	}
+}
@@ -0,0 +1,71 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestResponseTransformMiddleware(t *testing.T) {
+	servers := map[string]core.Server{
+		"std": std.NewServer("8080", false),
+		"gin": gin.NewServer("8080", false),
+	}
+
+	for name, s := range servers {
+		t.Run(name, func(t *testing.T) {
+			v1 := s.Group("/v1")
+			v1.Use(middleware.ResponseTransformMiddleware(
+				middleware.RenameFields(map[string]string{"fullName": "name"}),
+				middleware.WrapEnvelope("data"),
+			))
+			v1.GET("/users", func(c core.Context) {
+				c.JSON(http.StatusOK, []map[string]interface{}{{"id": 1, "fullName": "john"}})
+			})
+			v1.GET("/missing", func(c core.Context) {
+				c.JSON(http.StatusNotFound, map[string]string{"fullName": "none"})
+			})
+			v1.GET("/text", func(c core.Context) {
+				c.String(http.StatusOK, "fullName")
+			})
+			s.GET("/users", func(c core.Context) {
+				c.JSON(http.StatusOK, map[string]string{"fullName": "john"})
+			})
+
+			client := servertest.NewClient(s)
+			client.GET("/v1/users").Expect(t).
+				Status(http.StatusOK).
+				JSONPath("$.data[0].id", 1).
+				JSONPath("$.data[0].name", "john")
+			client.GET("/v1/missing").Expect(t).
+				Status(http.StatusNotFound).
+				JSONPath("$.name", "none")
+			client.GET("/v1/text").Expect(t).
+				Status(http.StatusOK).
+				Body("fullName")
+			client.GET("/users").Expect(t).
+				Status(http.StatusOK).
+				JSONPath("$.fullName", "john")
+		})
+	}
+}
+
+func TestResponseTransformMiddlewareError(t *testing.T) {
+	s := std.NewServer("8080", false)
+	s.Use(middleware.ResponseTransformMiddleware(func(c core.Context, status int, payload interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("boom")
+	}))
+	s.GET("/users", func(c core.Context) {
+		c.JSON(http.StatusOK, map[string]string{"name": "john"})
+	})
+
+	servertest.NewClient(s).GET("/users").Expect(t).
+		Status(http.StatusInternalServerError).
+		JSONPath("$.error.code", http.StatusInternalServerError)
+}
@@ -3,6 +3,7 @@ package util
 import (
 	"path"
 	"strings"
+	"sync"
 )
 
 func isWildcardMatch(pattern, pathStr string) bool {
@@ -27,6 +28,8 @@ func isParamPatternMatch(pattern, pathStr string) bool {
 	return true
 }
 
+// IsSkipPaths reports whether pathStr equals, or matches as a path.Match glob or a ':param' pattern,
+// any of skipPaths. It scans the list on every call; middleware use CompilePaths instead.
 func IsSkipPaths(pathStr string, skipPaths []string) bool {
 	for _, ignorePath := range skipPaths {
 		if pathStr == ignorePath || isWildcardMatch(ignorePath, pathStr) || isParamPatternMatch(ignorePath, pathStr) {
@@ -36,3 +39,141 @@ func IsSkipPaths(pathStr string, skipPaths []string) bool {
 
 	return false
 }
+
+// PathMatcher matches paths against a list of skip patterns as IsSkipPaths does, but compiles
+// them into a segment tree once, so that the cost of a match depends on the depth of the path
+// rather than on the number of patterns. It is safe for concurrent use.
+type PathMatcher struct {
+	root     *pathNode
+	fallback []string // Patterns combining ':' params with globs, or using '[' or '\', matched with IsSkipPaths
+	empty    bool
+}
+
+// pathNode is a node of the segment tree of a PathMatcher.
+type pathNode struct {
+	literals map[string]*pathNode // Children by segment
+	param    *pathNode            // Child of ':name' segments, matching any segment
+	globs    []globChild          // Children of segments with '*' or '?'
+	end      bool                 // Whether a pattern ends here
+}
+
+// globChild is a child of a pathNode for a segment pattern matched with path.Match.
+type globChild struct {
+	pattern string
+	node    *pathNode
+}
+
+// NewPathMatcher compiles patterns into a PathMatcher.
+func NewPathMatcher(patterns []string) *PathMatcher {
+	m := &PathMatcher{root: &pathNode{}, empty: len(patterns) == 0}
+	for _, pattern := range patterns {
+		hasParam, hasGlob := false, false
+		segments := strings.Split(pattern, "/")
+		for _, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				hasParam = true
+			}
+			if strings.ContainsAny(segment, "*?") {
+				hasGlob = true
+			}
+		}
+		// A pattern with both is matched by either semantics as a whole, which the tree can't express
+		if strings.ContainsAny(pattern, `[\`) || (hasParam && hasGlob) {
+			m.fallback = append(m.fallback, pattern)
+			continue
+		}
+		m.root.insert(segments)
+	}
+	return m
+}
+
+// insert adds the pattern with segments below n.
+func (n *pathNode) insert(segments []string) {
+	for _, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			if n.param == nil {
+				n.param = &pathNode{}
+			}
+			n = n.param
+		case strings.ContainsAny(segment, "*?"):
+			var child *pathNode
+			for _, glob := range n.globs {
+				if glob.pattern == segment {
+					child = glob.node
+					break
+				}
+			}
+			if child == nil {
+				child = &pathNode{}
+				n.globs = append(n.globs, globChild{pattern: segment, node: child})
+			}
+			n = child
+		default:
+			if n.literals == nil {
+				n.literals = make(map[string]*pathNode)
+			}
+			child, ok := n.literals[segment]
+			if !ok {
+				child = &pathNode{}
+				n.literals[segment] = child
+			}
+			n = child
+		}
+	}
+	n.end = true
+}
+
+// match reports whether a pattern below n matches the remaining path, whose next segment starts at
+// offset i or which is exhausted if i is beyond its end.
+func (n *pathNode) match(pathStr string, i int) bool {
+	if i > len(pathStr) {
+		return n.end
+	}
+	j := strings.IndexByte(pathStr[i:], '/')
+	next := len(pathStr) + 1
+	if j >= 0 {
+		next = i + j + 1
+		j += i
+	} else {
+		j = len(pathStr)
+	}
+	segment := pathStr[i:j]
+
+	if child, ok := n.literals[segment]; ok && child.match(pathStr, next) {
+		return true
+	}
+	if n.param != nil && n.param.match(pathStr, next) {
+		return true
+	}
+	for _, glob := range n.globs {
+		// '*' and '?' don't match '/', so a glob pattern matches segment by segment
+		if ok, _ := path.Match(glob.pattern, segment); ok && glob.node.match(pathStr, next) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether pathStr matches any of the patterns, with the same result as IsSkipPaths.
+func (m *PathMatcher) Match(pathStr string) bool {
+	if m == nil || m.empty {
+		return false
+	}
+	return m.root.match(pathStr, 0) || IsSkipPaths(pathStr, m.fallback)
+}
+
+// compiledPaths caches the matchers returned by CompilePaths by their patterns.
+var compiledPaths sync.Map
+
+// CompilePaths returns a PathMatcher for patterns, sharing it with other middleware that compiled
+// the same list, e.g. the skip paths generated from the controllers for logging and authorization.
+// Middleware call it when they are created, not for every request.
+func CompilePaths(patterns []string) *PathMatcher {
+	key := strings.Join(patterns, "\x00")
+	if m, ok := compiledPaths.Load(key); ok {
+		return m.(*PathMatcher)
+	}
+	m, _ := compiledPaths.LoadOrStore(key, NewPathMatcher(patterns))
+	return m.(*PathMatcher)
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -58,3 +59,92 @@ func TestIsParamPatternMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestPathMatcher(t *testing.T) {
+	patterns := []string{
+		"/health",
+		"/users/:id",
+		"/users/:id/orders/:order",
+		"/static/*",
+		"/static/*.css",
+		"/api/v?/status",
+		"/files/:name/*",
+		"/docs/[a-c]*",
+		"",
+		"/trailing/",
+	}
+	paths := []string{
+		"", "/", "/health", "/health/", "/healthz",
+		"/users", "/users/", "/users/1", "/users/1/orders", "/users/1/orders/2", "/users/1/orders/2/items",
+		"/static", "/static/", "/static/app.js", "/static/css/app.css", "/static/app.css",
+		"/api/v1/status", "/api/v10/status", "/api/v/status",
+		"/files/a/b", "/files/:name/*", "/files/a/*",
+		"/docs/alpha", "/docs/delta",
+		"/trailing", "/trailing/",
+	}
+
+	m := NewPathMatcher(patterns)
+	for _, p := range paths {
+		if got, want := m.Match(p), IsSkipPaths(p, patterns); got != want {
+			t.Errorf("Match(%q) = %v, IsSkipPaths = %v", p, got, want)
+		}
+	}
+
+	if NewPathMatcher(nil).Match("/health") {
+		t.Error("Match() of an empty matcher returned true")
+	}
+	var nilMatcher *PathMatcher
+	if nilMatcher.Match("/health") {
+		t.Error("Match() of a nil matcher returned true")
+	}
+}
+
+func TestCompilePaths(t *testing.T) {
+	a := CompilePaths([]string{"/health", "/users/:id"})
+	b := CompilePaths([]string{"/health", "/users/:id"})
+	if a != b {
+		t.Error("CompilePaths() did not share the matcher of an identical list")
+	}
+	if c := CompilePaths([]string{"/health"}); c == a {
+		t.Error("CompilePaths() shared the matcher of a different list")
+	}
+}
+
+// generatedPatterns returns n patterns like those generated from the routes of controllers.
+func generatedPatterns(n int) []string {
+	patterns := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		switch i % 3 {
+		case 0:
+			patterns = append(patterns, fmt.Sprintf("/api/v1/resource%d", i))
+		case 1:
+			patterns = append(patterns, fmt.Sprintf("/api/v1/resource%d/:id", i))
+		default:
+			patterns = append(patterns, fmt.Sprintf("/static/bundle%d/*", i))
+		}
+	}
+	return patterns
+}
+
+// BenchmarkSkipPaths compares IsSkipPaths and a compiled PathMatcher for a path matching no pattern,
+// the common case of a request that is not skipped.
+// Run it with: go test ./core/middleware/util -run '^$' -bench BenchmarkSkipPaths -benchmem
+func BenchmarkSkipPaths(b *testing.B) {
+	for _, n := range []int{10, 100, 500} {
+		patterns := generatedPatterns(n)
+		const pathStr = "/api/v1/orders/42"
+
+		b.Run(fmt.Sprintf("IsSkipPaths/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				IsSkipPaths(pathStr, patterns)
+			}
+		})
+		b.Run(fmt.Sprintf("PathMatcher/%d", n), func(b *testing.B) {
+			m := NewPathMatcher(patterns)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.Match(pathStr)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+// Package middleware provides common middleware functionality for HTTP servers.
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware/errors"
+	"github.com/mythofleader/go-http-server/core/middleware/util"
+)
+
+// WatchdogConfig holds configuration for the watchdog.
+type WatchdogConfig struct {
+	// MaxHeapBytes is the heap size, in bytes of allocated objects, above which the process is
+	// overloaded and best-effort routes are rejected. If zero, the heap is not checked.
+	MaxHeapBytes uint64
+
+	// MaxGoroutines is the number of goroutines above which the process is overloaded and
+	// best-effort routes are rejected. If zero, goroutines are not checked.
+	MaxGoroutines int
+
+	// SevereHeapBytes and SevereGoroutines are the thresholds above which normal routes are
+	// rejected as well. If zero, normal routes are not rejected. Critical routes never are.
+	SevereHeapBytes  uint64
+	SevereGoroutines int
+
+	// Interval is how often the heap and goroutines are sampled.
+	Interval time.Duration
+
+	// Priorities maps route templates, with the syntax of ConcurrencyLimitConfig.Limits, e.g.
+	// "/analytics/*" or "POST /payments", to their priority class. Routes that match no template
+	// are normal; if a route matches several, the template with the longest path is used.
+	Priorities map[string]core.Priority
+
+	// LowPriorityRoutes are templates of best-effort routes, a shorthand for Priorities entries.
+	LowPriorityRoutes []string
+
+	// RetryAfter is sent in the Retry-After header of rejected requests. If zero, it is omitted.
+	RetryAfter time.Duration
+
+	// ProfileDir is the directory heap profiles are written to when the process becomes
+	// overloaded, for diagnosing what is using the memory. If empty, no profiles are written.
+	ProfileDir string
+
+	// ProfileInterval is the minimum time between two heap profiles, so that a process
+	// flapping around a threshold does not fill the disk.
+	ProfileInterval time.Duration
+
+	// Optional: custom error message
+	ServiceUnavailableMessage string
+}
+
+// DefaultWatchdogConfig returns a default watchdog configuration. No thresholds are set.
+func DefaultWatchdogConfig() *WatchdogConfig {
+	return &WatchdogConfig{
+		Interval:                  time.Second,
+		RetryAfter:                5 * time.Second,
+		ProfileInterval:           5 * time.Minute,
+		ServiceUnavailableMessage: "Server is overloaded",
+	}
+}
+
+// Shed levels of a watchdog, from the least to the most overloaded.
+const (
+	shedNone       = iota // No routes are rejected
+	shedBestEffort        // Best-effort routes are rejected
+	shedNormal            // Normal and best-effort routes are rejected
+)
+
+// WatchdogStats is a sample of the resource usage of the process.
+type WatchdogStats struct {
+	HeapBytes  uint64 // Bytes of allocated heap objects
+	Goroutines int    // Number of goroutines
+}
+
+// Watchdog monitors the heap size and goroutine count of the process and, while they are above
+// their thresholds, rejects routes by priority class with 503 Service Unavailable, so that the
+// process stays alive and keeps serving its critical routes under overload: best-effort routes
+// are shed first, normal routes under severe overload, and critical routes never. Changes of the
+// overload are logged, and a heap profile is captured when the overload gets worse.
+//
+// Example usage:
+//
+//	config := middleware.DefaultWatchdogConfig()
+//	config.MaxHeapBytes = 1 << 30 // 1 GB
+//	config.MaxGoroutines = 10000
+//	config.SevereGoroutines = 20000
+//	config.Priorities = map[string]core.Priority{
+//		"/health":       core.PriorityCritical,
+//		"POST /payments": core.PriorityCritical,
+//		"/analytics/*":  core.PriorityBestEffort,
+//	}
+//	config.ProfileDir = "/var/tmp/profiles"
+//	watchdog := middleware.NewWatchdog(config)
+//	go watchdog.Run(ctx)
+//	s.Use(watchdog.Middleware())
+type Watchdog struct {
+	config *WatchdogConfig
+	routes []watchdogRoute // Routes with a priority class, the most specific first
+
+	mu         sync.Mutex
+	stats      WatchdogStats
+	level      int // Shed level of the last sample
+	profiledAt time.Time
+	profileSeq int
+}
+
+// NewWatchdog returns a watchdog with config. It samples the process when Check or Run is called.
+// Zero intervals and an empty message are replaced with those of DefaultWatchdogConfig.
+func NewWatchdog(config *WatchdogConfig) *Watchdog {
+	defaults := DefaultWatchdogConfig()
+	if config == nil {
+		config = defaults
+	}
+	c := *config
+	if c.Interval <= 0 {
+		c.Interval = defaults.Interval
+	}
+	if c.ProfileInterval <= 0 {
+		c.ProfileInterval = defaults.ProfileInterval
+	}
+	if c.ServiceUnavailableMessage == "" {
+		c.ServiceUnavailableMessage = defaults.ServiceUnavailableMessage
+	}
+	config = &c
+
+	routes := make([]watchdogRoute, 0, len(config.Priorities)+len(config.LowPriorityRoutes))
+	for template, priority := range config.Priorities {
+		routes = append(routes, newWatchdogRoute(template, priority))
+	}
+	for _, template := range config.LowPriorityRoutes {
+		routes = append(routes, newWatchdogRoute(template, core.PriorityBestEffort))
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if len(routes[i].path) != len(routes[j].path) {
+			return len(routes[i].path) > len(routes[j].path)
+		}
+		return routes[i].template < routes[j].template
+	})
+	return &Watchdog{config: config, routes: routes}
+}
+
+// watchdogRoute is the priority class of a route template.
+type watchdogRoute struct {
+	template string
+	method   string // Empty for any method
+	path     string
+	matcher  *util.PathMatcher
+	priority core.Priority
+}
+
+func newWatchdogRoute(template string, priority core.Priority) watchdogRoute {
+	method, path := parseRouteTemplate(template)
+	return watchdogRoute{
+		template: template,
+		method:   method,
+		path:     path,
+		matcher:  util.CompilePaths([]string{path}),
+		priority: priority,
+	}
+}
+
+// Run checks the process immediately and then every interval until ctx is done.
+func (w *Watchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+	for {
+		w.Check()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check samples the heap size and goroutine count, updates which routes are rejected
+// and returns the sample.
+func (w *Watchdog) Check() WatchdogStats {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	stats := WatchdogStats{HeapBytes: memStats.HeapAlloc, Goroutines: runtime.NumGoroutine()}
+
+	level := shedNone
+	reasons := w.exceeded(stats, w.config.SevereHeapBytes, w.config.SevereGoroutines)
+	if len(reasons) > 0 {
+		level = shedNormal
+	} else if reasons = w.exceeded(stats, w.config.MaxHeapBytes, w.config.MaxGoroutines); len(reasons) > 0 {
+		level = shedBestEffort
+	}
+
+	w.mu.Lock()
+	previous := w.level
+	w.stats = stats
+	w.level = level
+	w.mu.Unlock()
+
+	switch {
+	case level > previous:
+		log.Printf("[WARNING] watchdog: process is overloaded (%s); %s", strings.Join(reasons, ", "), shedDescription(level))
+		w.captureProfile()
+	case level < previous && level != shedNone:
+		log.Printf("[INFO] watchdog: overload eased (%s); %s", strings.Join(reasons, ", "), shedDescription(level))
+	case level < previous:
+		log.Printf("[INFO] watchdog: process recovered (heap %d bytes, %d goroutines)", stats.HeapBytes, stats.Goroutines)
+	}
+	return stats
+}
+
+// Overloaded returns whether the last sample was above a threshold.
+func (w *Watchdog) Overloaded() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.level != shedNone
+}
+
+// Sheds returns whether routes of priority are currently rejected.
+func (w *Watchdog) Sheds(priority core.Priority) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch priority {
+	case core.PriorityBestEffort:
+		return w.level >= shedBestEffort
+	case core.PriorityNormal:
+		return w.level >= shedNormal
+	default:
+		return false
+	}
+}
+
+// Priority returns the priority class of the route of req.
+func (w *Watchdog) Priority(req *http.Request) core.Priority {
+	for _, route := range w.routes {
+		if route.method != "" && route.method != req.Method {
+			continue
+		}
+		if route.matcher.Match(req.URL.Path) {
+			return route.priority
+		}
+	}
+	return core.PriorityNormal
+}
+
+// Stats returns the last sample.
+func (w *Watchdog) Stats() WatchdogStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stats
+}
+
+// Middleware returns a middleware function that rejects requests to the routes shed at the
+// current overload with 503 Service Unavailable.
+func (w *Watchdog) Middleware() core.HandlerFunc {
+	return func(c core.Context) {
+		if !w.Overloaded() || !w.Sheds(w.Priority(c.Request())) {
+			c.Next()
+			return
+		}
+		if w.config.RetryAfter > 0 {
+			c.SetHeader("Retry-After", strconv.Itoa(errors.RetryAfterSeconds(w.config.RetryAfter)))
+		}
+		c.JSON(http.StatusServiceUnavailable, errors.NewErrorResponse(http.StatusServiceUnavailable, w.config.ServiceUnavailableMessage))
+		c.Abort()
+	}
+}
+
+// exceeded describes the thresholds stats are above. Zero thresholds are not checked.
+func (w *Watchdog) exceeded(stats WatchdogStats, maxHeapBytes uint64, maxGoroutines int) []string {
+	var reasons []string
+	if maxHeapBytes > 0 && stats.HeapBytes > maxHeapBytes {
+		reasons = append(reasons, fmt.Sprintf("heap %d bytes > %d", stats.HeapBytes, maxHeapBytes))
+	}
+	if maxGoroutines > 0 && stats.Goroutines > maxGoroutines {
+		reasons = append(reasons, fmt.Sprintf("%d goroutines > %d", stats.Goroutines, maxGoroutines))
+	}
+	return reasons
+}
+
+// shedDescription describes which routes are rejected at level, for the log.
+func shedDescription(level int) string {
+	if level == shedNormal {
+		return "rejecting normal and best-effort routes"
+	}
+	return "rejecting best-effort routes"
+}
+
+// captureProfile writes a heap profile to the profile directory, at most once per profile interval.
+func (w *Watchdog) captureProfile() {
+	if w.config.ProfileDir == "" {
+		return
+	}
+
+	now := time.Now()
+	w.mu.Lock()
+	if !w.profiledAt.IsZero() && now.Sub(w.profiledAt) < w.config.ProfileInterval {
+		w.mu.Unlock()
+		return
+	}
+	w.profiledAt = now
+	w.profileSeq++
+	name := fmt.Sprintf("heap-%s-%d.pprof", now.UTC().Format("20060102T150405Z"), w.profileSeq)
+	w.mu.Unlock()
+
+	path := filepath.Join(w.config.ProfileDir, name)
+	file, err := os.Create(path)
+	if err != nil {
+		log.Printf("[WARNING] watchdog: failed to write heap profile: %v", err)
+		return
+	}
+	defer file.Close()
+	if err := pprof.Lookup("heap").WriteTo(file, 0); err != nil {
+		log.Printf("[WARNING] watchdog: failed to write heap profile: %v", err)
+		return
+	}
+	log.Printf("[INFO] watchdog: wrote heap profile to %s", path)
+}
@@ -0,0 +1,120 @@
+package middleware_test
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestWatchdogMiddleware(t *testing.T) {
+	for name, s := range map[string]core.Server{"gin": gin.NewServer("8080", false), "std": std.NewServer("8080", false)} {
+		t.Run(name, func(t *testing.T) {
+			config := middleware.DefaultWatchdogConfig()
+			config.MaxGoroutines = 1 // Always exceeded
+			config.LowPriorityRoutes = []string{"/analytics/*", "POST /reports"}
+			config.ProfileDir = t.TempDir()
+			watchdog := middleware.NewWatchdog(config)
+
+			s.Use(watchdog.Middleware())
+			for _, path := range []string{"/analytics/events", "/payments", "/reports"} {
+				s.GET(path, func(c core.Context) { c.String(http.StatusOK, "ok") })
+			}
+			s.POST("/reports", func(c core.Context) { c.String(http.StatusOK, "ok") })
+			client := servertest.NewClient(s)
+
+			// Nothing is rejected until the process has been checked
+			client.GET("/analytics/events").Expect(t).Status(http.StatusOK)
+
+			stats := watchdog.Check()
+			if !watchdog.Overloaded() || stats.Goroutines <= 1 {
+				t.Fatalf("watchdog is not overloaded with %d goroutines", stats.Goroutines)
+			}
+			client.GET("/analytics/events").Expect(t).
+				Status(http.StatusServiceUnavailable).
+				Header("Retry-After", "5").
+				JSONPath("$.error.message", "Server is overloaded")
+			client.POST("/reports").Expect(t).Status(http.StatusServiceUnavailable)
+			client.GET("/reports").Expect(t).Status(http.StatusOK)
+			client.GET("/payments").Expect(t).Status(http.StatusOK)
+
+			// A heap profile is captured once when the process becomes overloaded
+			watchdog.Check()
+			entries, err := os.ReadDir(config.ProfileDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("watchdog wrote %d heap profiles, want 1", len(entries))
+			}
+		})
+	}
+}
+
+func TestWatchdogWithinThresholds(t *testing.T) {
+	config := middleware.DefaultWatchdogConfig()
+	config.MaxGoroutines = 1 << 20
+	config.MaxHeapBytes = 1 << 40
+	config.LowPriorityRoutes = []string{"/analytics/*"}
+	watchdog := middleware.NewWatchdog(config)
+
+	s := std.NewServer("8080", false)
+	s.Use(watchdog.Middleware())
+	s.GET("/analytics/events", func(c core.Context) { c.String(http.StatusOK, "ok") })
+
+	if stats := watchdog.Check(); watchdog.Overloaded() || stats.HeapBytes == 0 {
+		t.Fatalf("watchdog is overloaded with %+v", stats)
+	}
+	servertest.NewClient(s).GET("/analytics/events").Expect(t).Status(http.StatusOK)
+}
+
+func TestWatchdogPriorities(t *testing.T) {
+	newServer := func(config *middleware.WatchdogConfig) (*middleware.Watchdog, *servertest.Client) {
+		watchdog := middleware.NewWatchdog(config)
+		s := std.NewServer("8080", false)
+		s.Use(watchdog.Middleware())
+		for _, path := range []string{"/health", "/payments", "/orders", "/analytics/events"} {
+			s.GET(path, func(c core.Context) { c.String(http.StatusOK, "ok") })
+		}
+		return watchdog, servertest.NewClient(s)
+	}
+	priorities := map[string]core.Priority{
+		"/*":           core.PriorityBestEffort, // Less specific than the routes below
+		"/analytics/*": core.PriorityBestEffort,
+		"/health":      core.PriorityCritical,
+		"/payments":    core.PriorityCritical,
+		"/orders":      core.PriorityNormal,
+	}
+
+	// Best-effort routes are shed first
+	config := middleware.DefaultWatchdogConfig()
+	config.MaxGoroutines = 1
+	config.Priorities = priorities
+	watchdog, client := newServer(config)
+	watchdog.Check()
+	if !watchdog.Sheds(core.PriorityBestEffort) || watchdog.Sheds(core.PriorityNormal) {
+		t.Fatal("watchdog does not shed only best-effort routes")
+	}
+	client.GET("/analytics/events").Expect(t).Status(http.StatusServiceUnavailable)
+	client.GET("/orders").Expect(t).Status(http.StatusOK)
+	client.GET("/health").Expect(t).Status(http.StatusOK)
+
+	// Normal routes are shed under severe overload; critical routes never are
+	config = middleware.DefaultWatchdogConfig()
+	config.SevereGoroutines = 1
+	config.Priorities = priorities
+	watchdog, client = newServer(config)
+	watchdog.Check()
+	client.GET("/analytics/events").Expect(t).Status(http.StatusServiceUnavailable)
+	client.GET("/orders").Expect(t).Status(http.StatusServiceUnavailable)
+	client.GET("/health").Expect(t).Status(http.StatusOK)
+	client.GET("/payments").Expect(t).Status(http.StatusOK)
+	if watchdog.Sheds(core.PriorityCritical) {
+		t.Error("watchdog sheds critical routes")
+	}
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// AllRoutes is the MiddlewareDescription.AppliesTo value of middleware running on every route.
+const AllRoutes = "/*"
+
+// MiddlewareDescription describes a middleware of the request pipeline.
+type MiddlewareDescription struct {
+	// Name is the name of the middleware, e.g. MiddlewareTimeout, or its function name if it was
+	// registered without a name
+	Name string `json:"name"`
+	// Config summarizes the configuration of the middleware, e.g. "2s", empty if unknown
+	Config string `json:"config,omitempty"`
+	// AppliesTo is the path pattern of the routes the middleware runs on: AllRoutes, or the
+	// prefix of the router group it was added to, e.g. "/api/*"
+	AppliesTo string `json:"appliesTo"`
+	// Except lists the routes skipping the middleware, e.g. "GET /events"
+	Except []string `json:"except,omitempty"`
+}
+
+// MiddlewareChain describes the middleware of a server in the order they run.
+type MiddlewareChain []MiddlewareDescription
+
+// String returns a numbered list of the middleware, one per line.
+func (c MiddlewareChain) String() string {
+	var b strings.Builder
+	for i, m := range c {
+		fmt.Fprintf(&b, "%d. %s", i+1, m.Name)
+		if m.Config != "" {
+			fmt.Fprintf(&b, " (%s)", m.Config)
+		}
+		fmt.Fprintf(&b, " on %s", m.AppliesTo)
+		if len(m.Except) > 0 {
+			fmt.Fprintf(&b, " except %s", strings.Join(m.Except, ", "))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Mermaid returns the chain as a Mermaid flowchart, e.g. for a Markdown document.
+func (c MiddlewareChain) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	b.WriteString("    request([Request])\n")
+	previous := "request"
+	for i, m := range c {
+		id := fmt.Sprintf("m%d", i+1)
+		label := strings.ReplaceAll(strings.Join(m.labelLines(), "<br/>"), `"`, "#quot;")
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", id, label)
+		fmt.Fprintf(&b, "    %s --> %s\n", previous, id)
+		previous = id
+	}
+	b.WriteString("    handler([Handler])\n")
+	fmt.Fprintf(&b, "    %s --> handler\n", previous)
+	return b.String()
+}
+
+// DOT returns the chain as a Graphviz digraph.
+func (c MiddlewareChain) DOT() string {
+	quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	var b strings.Builder
+	b.WriteString("digraph middleware {\n")
+	b.WriteString("    rankdir=TB;\n")
+	b.WriteString("    node [shape=box];\n")
+	b.WriteString("    request [label=\"Request\", shape=oval];\n")
+	previous := "request"
+	for i, m := range c {
+		id := fmt.Sprintf("m%d", i+1)
+		lines := m.labelLines()
+		for j, line := range lines {
+			lines[j] = quote.Replace(line)
+		}
+		fmt.Fprintf(&b, "    %s [label=\"%s\"];\n", id, strings.Join(lines, `\n`))
+		fmt.Fprintf(&b, "    %s -> %s;\n", previous, id)
+		previous = id
+	}
+	b.WriteString("    handler [label=\"Handler\", shape=oval];\n")
+	fmt.Fprintf(&b, "    %s -> handler;\n}\n", previous)
+	return b.String()
+}
+
+// labelLines returns the lines of the diagram node of the middleware.
+func (m MiddlewareDescription) labelLines() []string {
+	lines := []string{m.Name}
+	if m.Config != "" {
+		lines = append(lines, m.Config)
+	}
+	if m.AppliesTo != AllRoutes {
+		lines = append(lines, "on "+m.AppliesTo)
+	}
+	if len(m.Except) > 0 {
+		lines = append(lines, "except "+strings.Join(m.Except, ", "))
+	}
+	return lines
+}
+
+// MiddlewareLog records the middleware added to a server and its router groups.
+// Framework-specific Server implementations use it to implement DescribeMiddleware.
+type MiddlewareLog struct {
+	mu      sync.Mutex
+	entries []middlewareLogEntry
+}
+
+// middlewareLogEntry is a recorded middleware; the name of unnamed middleware is resolved lazily.
+type middlewareLogEntry struct {
+	description MiddlewareDescription
+	handler     HandlerFunc
+}
+
+// Add records a middleware. An empty AppliesTo is recorded as AllRoutes.
+func (l *MiddlewareLog) Add(description MiddlewareDescription, handler HandlerFunc) {
+	if description.AppliesTo == "" {
+		description.AppliesTo = AllRoutes
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, middlewareLogEntry{description: description, handler: handler})
+}
+
+// Chain returns the recorded middleware in the order they run: the middleware of the server
+// in registration order, followed by the middleware of router groups.
+func (l *MiddlewareLog) Chain() MiddlewareChain {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	chain := make(MiddlewareChain, 0, len(l.entries))
+	for _, server := range []bool{true, false} {
+		for _, entry := range l.entries {
+			if (entry.description.AppliesTo == AllRoutes) != server {
+				continue
+			}
+			description := entry.description
+			description.Name = NamedHandler{Name: description.Name, Handler: entry.handler}.DisplayName()
+			description.Except = append([]string(nil), description.Except...)
+			chain = append(chain, description)
+		}
+	}
+	return chain
+}
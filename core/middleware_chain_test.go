@@ -0,0 +1,42 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareLogChain(t *testing.T) {
+	var l MiddlewareLog
+	group := func(c Context) {}
+	l.Add(MiddlewareDescription{Name: MiddlewareTimeout, Config: "2s", Except: []string{"GET /events"}}, nil)
+	l.Add(MiddlewareDescription{AppliesTo: "/api/*"}, group)
+	l.Add(MiddlewareDescription{Name: MiddlewareLogging}, nil)
+
+	chain := l.Chain()
+	if len(chain) != 3 {
+		t.Fatalf("Chain() returned %d middleware, want 3", len(chain))
+	}
+	// Server middleware runs before the middleware of router groups
+	if chain[0].Name != MiddlewareTimeout || chain[1].Name != MiddlewareLogging {
+		t.Errorf("Chain() order = %q, %q, want timeout then logging", chain[0].Name, chain[1].Name)
+	}
+	if chain[1].AppliesTo != AllRoutes {
+		t.Errorf("AppliesTo = %q, want %q", chain[1].AppliesTo, AllRoutes)
+	}
+	if !strings.Contains(chain[2].Name, "TestMiddlewareLogChain") || chain[2].AppliesTo != "/api/*" {
+		t.Errorf("group middleware = %+v, want its function name on /api/*", chain[2])
+	}
+
+	want := "1. Timeout (2s) on /* except GET /events\n"
+	if got := chain.String(); !strings.HasPrefix(got, want) {
+		t.Errorf("String() = %q, want prefix %q", got, want)
+	}
+	if got := chain.Mermaid(); !strings.Contains(got, "m1[\"Timeout<br/>2s<br/>except GET /events\"]") ||
+		!strings.Contains(got, "m3 --> handler") {
+		t.Errorf("Mermaid() = %q", got)
+	}
+	if got := chain.DOT(); !strings.Contains(got, `m1 [label="Timeout\n2s\nexcept GET /events"];`) ||
+		!strings.Contains(got, "request -> m1;") {
+		t.Errorf("DOT() = %q", got)
+	}
+}
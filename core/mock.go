@@ -0,0 +1,125 @@
+package core
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// Environment variables read by MockConfigFromEnv.
+const (
+	// MockModeEnv enables mock mode when set to a true value ("1", "true", ...).
+	MockModeEnv = "HTTP_SERVER_MOCK_MODE"
+	// MockLatencyEnv sets the artificial latency of mocked responses, e.g. "200ms".
+	MockLatencyEnv = "HTTP_SERVER_MOCK_LATENCY"
+)
+
+// MockExampleHeader is the request header used to select a mocked response by example name.
+// Without it, the first example of the route is served.
+const MockExampleHeader = "X-Mock-Example"
+
+// MockConfig holds configuration for mock mode.
+// In mock mode, mocked routes serve the example responses of their controller
+// (see ExampleProvider) instead of running the controller's handlers.
+type MockConfig struct {
+	// Enabled turns mock mode on
+	Enabled bool
+	// Routes lists the routes to mock as "METHOD /path" (e.g. "POST /users").
+	// If empty, every controller that implements ExampleProvider is mocked.
+	// Controllers implementing MockedController are mocked when Mocked returns true, regardless of Routes.
+	Routes []string
+	// Latency is an artificial delay added before every mocked response
+	Latency time.Duration
+}
+
+// MockedController is an optional interface for controllers that mark their own route as mocked,
+// e.g. while backend logic does not exist yet. It only has an effect when mock mode is enabled.
+type MockedController interface {
+	// Mocked returns whether the route serves example responses in mock mode
+	Mocked() bool
+}
+
+// MockConfigFromEnv returns a mock configuration read from MockModeEnv and MockLatencyEnv.
+// Invalid values are treated as unset.
+func MockConfigFromEnv() *MockConfig {
+	config := &MockConfig{}
+	config.Enabled, _ = strconv.ParseBool(os.Getenv(MockModeEnv))
+	if latency, err := time.ParseDuration(os.Getenv(MockLatencyEnv)); err == nil {
+		config.Latency = latency
+	}
+	return config
+}
+
+// IsMocked returns whether the route of controller is mocked under this configuration.
+func (config *MockConfig) IsMocked(controller Controller) bool {
+	if config == nil || !config.Enabled {
+		return false
+	}
+	if mocked, ok := controller.(MockedController); ok && mocked.Mocked() {
+		return true
+	}
+	if _, ok := controller.(ExampleProvider); !ok {
+		return false
+	}
+	if len(config.Routes) == 0 {
+		return true
+	}
+	route := string(controller.GetHttpMethod()) + " " + controller.GetPath()
+	for _, r := range config.Routes {
+		if r == route {
+			return true
+		}
+	}
+	return false
+}
+
+// MockHandler returns a handler that serves the given examples after the given latency.
+// The example is selected by name with the MockExampleHeader request header, defaulting to the first one.
+// If there are no examples, or the requested one does not exist, it responds with 501 Not Implemented.
+func MockHandler(examples []Example, latency time.Duration) HandlerFunc {
+	return func(c Context) {
+		if latency > 0 {
+			select {
+			case <-time.After(latency):
+			case <-c.Request().Context().Done():
+				return
+			}
+		}
+
+		example, ok := selectExample(examples, c.GetHeader(MockExampleHeader))
+		if !ok {
+			c.JSON(http.StatusNotImplemented, httperrors.NewErrorResponse(http.StatusNotImplemented, "No mock example available for this route"))
+			return
+		}
+
+		statusCode := example.StatusCode
+		if statusCode == 0 {
+			statusCode = http.StatusOK
+		}
+		c.SetHeader("X-Mock", "true")
+		if example.Response == nil {
+			c.SetStatus(statusCode)
+			return
+		}
+		c.JSON(statusCode, example.Response)
+	}
+}
+
+// selectExample returns the example with the given name, or the first example if name is empty.
+func selectExample(examples []Example, name string) (Example, bool) {
+	if name == "" {
+		if len(examples) == 0 {
+			return Example{}, false
+		}
+		return examples[0], true
+	}
+	for _, example := range examples {
+		if example.Name == name {
+			return example, true
+		}
+	}
+	return Example{}, false
+}
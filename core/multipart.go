@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// multipartMemoryKey is the context key of the multipart memory of a request.
+type multipartMemoryKey struct{}
+
+// ContextWithMultipartMemory returns a copy of ctx carrying the memory used to parse multipart forms.
+func ContextWithMultipartMemory(ctx context.Context, maxMemory int64) context.Context {
+	return context.WithValue(ctx, multipartMemoryKey{}, maxMemory)
+}
+
+// MultipartMemoryFromContext returns the multipart memory carried by ctx, or defaultMemory if there is none.
+func MultipartMemoryFromContext(ctx context.Context, defaultMemory int64) int64 {
+	if maxMemory, ok := ctx.Value(multipartMemoryKey{}).(int64); ok && maxMemory > 0 {
+		return maxMemory
+	}
+	return defaultMemory
+}
+
+// MultipartMemory returns a middleware function that sets the memory used to parse the multipart
+// forms of the requests it handles, overriding EngineOptions.MaxMultipartMemory, e.g. to keep the
+// files of an upload route on disk. It applies to FormFile, MultipartForm, PostForm and Bind,
+// except for the Bind and PostForm of the Gin adapter, which use the engine setting.
+//
+// Example usage:
+//
+//	s.POST("/videos", core.MultipartMemory(1<<20), uploadVideoHandler)
+func MultipartMemory(maxMemory int64) HandlerFunc {
+	return func(c Context) {
+		c.SetRequest(c.Request().WithContext(ContextWithMultipartMemory(c.Request().Context(), maxMemory)))
+		c.Next()
+	}
+}
+
+// MultipartForm parses the multipart/form-data body of r on first use, keeping up to maxMemory bytes
+// of files in memory and storing the rest in temporary files, and returns the form.
+// It is used by Context.MultipartForm implementations. Requests that are not multipart return
+// http.ErrNotMultipart.
+func MultipartForm(r *http.Request, maxMemory int64) (*multipart.Form, error) {
+	if r.MultipartForm == nil {
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			return nil, err
+		}
+	}
+	return r.MultipartForm, nil
+}
+
+// FormFile returns the first file of the multipart form field name of r, parsing the form like
+// MultipartForm. It is used by Context.FormFile implementations. It returns http.ErrMissingFile
+// if the form has no file for name.
+func FormFile(r *http.Request, maxMemory int64, name string) (*multipart.FileHeader, error) {
+	form, err := MultipartForm(r, maxMemory)
+	if err != nil {
+		return nil, err
+	}
+	if files := form.File[name]; len(files) > 0 {
+		return files[0], nil
+	}
+	return nil, http.ErrMissingFile
+}
+
+// SaveUploadedFile copies the uploaded file to dst, creating its directory if needed.
+// The file is written to a temporary file next to dst and renamed once complete, so dst never holds
+// a partial upload. dst is used as is: never build it from file.Filename without sanitizing it,
+// e.g. with filepath.Base, as the client chooses the name.
+func SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dir := filepath.Dir(dst)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	// Temporary files are created readable by their owner only; let the group read the saved file
+	if err := tmp.Chmod(0o640); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveUploadedFile(t *testing.T) {
+	r := newUploadRequest(t, nil, map[string]string{"report.csv": "a,b\n1,2\n"})
+	file, err := FormFile(r, 0, "file")
+	if err != nil {
+		t.Fatalf("FormFile() returned error: %v", err)
+	}
+	if _, err := FormFile(r, 0, "other"); !errors.Is(err, http.ErrMissingFile) {
+		t.Errorf("FormFile(other) error = %v, want http.ErrMissingFile", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "reports", "2024")
+	dst := filepath.Join(dir, "report.csv")
+	if err := SaveUploadedFile(file, dst); err != nil {
+		t.Fatalf("SaveUploadedFile() returned error: %v", err)
+	}
+	if data, err := os.ReadFile(dst); err != nil || string(data) != "a,b\n1,2\n" {
+		t.Errorf("saved file = %q, %v", data, err)
+	}
+	// No temporary file is left behind
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory has %d entries, want 1", len(entries))
+	}
+}
+
+func TestMultipartMemoryFromContext(t *testing.T) {
+	ctx := context.Background()
+	if got := MultipartMemoryFromContext(ctx, DefaultMaxMultipartMemory); got != DefaultMaxMultipartMemory {
+		t.Errorf("MultipartMemoryFromContext() = %d, want the default", got)
+	}
+	if got := MultipartMemoryFromContext(ContextWithMultipartMemory(ctx, 1<<20), DefaultMaxMultipartMemory); got != 1<<20 {
+		t.Errorf("MultipartMemoryFromContext() = %d, want %d", got, 1<<20)
+	}
+}
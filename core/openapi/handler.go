@@ -0,0 +1,58 @@
+package openapi
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// Handler returns a handler serving doc as JSON.
+func Handler(doc *Document) core.HandlerFunc {
+	return func(c core.Context) {
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// uiTemplate is the Swagger UI page. The inline script carries the CSP nonce of the request,
+// so that the page works behind a Content-Security-Policy allowing the script origin.
+var uiTemplate = template.Must(template.New("swagger-ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.AssetsURL}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{.AssetsURL}}/swagger-ui-bundle.js"></script>
+<script nonce="{{.Nonce}}">
+window.ui = SwaggerUIBundle({url: {{.SpecPath}}, dom_id: "#swagger-ui"});
+</script>
+</body>
+</html>
+`))
+
+// UIHandler returns a handler serving a Swagger UI page for the document served on specPath.
+// The page loads the swagger-ui-dist scripts and styles from opts.SwaggerUIURL.
+func UIHandler(specPath string, opts *Options) core.HandlerFunc {
+	o := opts.withDefaults()
+	return func(c core.Context) {
+		var page bytes.Buffer
+		err := uiTemplate.Execute(&page, map[string]string{
+			"Title":     o.Title,
+			"AssetsURL": o.SwaggerUIURL,
+			"SpecPath":  specPath,
+			"Nonce":     c.CSPNonce(),
+		})
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.SetHeader("Content-Type", "text/html; charset=utf-8")
+		c.SetStatus(http.StatusOK)
+		c.Writer().Write(page.Bytes())
+	}
+}
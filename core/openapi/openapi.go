@@ -0,0 +1,276 @@
+// Package openapi generates OpenAPI 3 documents from controllers. Every registered route is
+// documented; controllers implementing core.DocumentedController add a summary, tags and the
+// schemas of their request and response bodies, and controllers implementing
+// core.ExampleProvider add their examples.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// Version is the OpenAPI version of generated documents.
+const Version = "3.0.3"
+
+// DefaultUIPath is the path the Swagger UI page is served on by default.
+const DefaultUIPath = "/docs"
+
+// DefaultSwaggerUIURL is the base URL the Swagger UI page loads its scripts and styles from by default.
+const DefaultSwaggerUIURL = "https://unpkg.com/swagger-ui-dist@5"
+
+// Options holds configuration for the generated document and the Swagger UI page.
+type Options struct {
+	// Title is the title of the API. If not set, it defaults to "API".
+	Title string
+	// Version is the version of the API. If not set, it defaults to "1.0.0".
+	Version string
+	// Description describes the API
+	Description string
+	// Servers are the base URLs of the API, e.g. "https://api.example.com"
+	Servers []string
+
+	// UIPath is the path of the Swagger UI page. If not set, DefaultUIPath ("/docs") is used.
+	UIPath string
+	// DisableUI disables the Swagger UI page, so that only the document is served
+	DisableUI bool
+	// SwaggerUIURL is the base URL of the swagger-ui-dist files, to load them from a mirror or
+	// a self-hosted copy. If not set, DefaultSwaggerUIURL is used.
+	SwaggerUIURL string
+}
+
+// withDefaults returns a copy of opts with the defaults set.
+func (opts *Options) withDefaults() Options {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Title == "" {
+		o.Title = "API"
+	}
+	if o.Version == "" {
+		o.Version = "1.0.0"
+	}
+	if o.UIPath == "" {
+		o.UIPath = DefaultUIPath
+	}
+	if o.SwaggerUIURL == "" {
+		o.SwaggerUIURL = DefaultSwaggerUIURL
+	}
+	o.SwaggerUIURL = strings.TrimSuffix(o.SwaggerUIURL, "/")
+	return o
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components *Components         `json:"components,omitempty"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a base URL of the API.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem maps lowercase HTTP methods to the operations of a path.
+type PathItem map[string]*Operation
+
+// Operation documents a route.
+type Operation struct {
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+}
+
+// Parameter is a path parameter of a route.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody documents the request body of a route.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response documents a response of a route.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema and examples of a body.
+type MediaType struct {
+	Schema   *Schema                  `json:"schema,omitempty"`
+	Examples map[string]ExampleObject `json:"examples,omitempty"`
+}
+
+// ExampleObject is an example body.
+type ExampleObject struct {
+	Summary string      `json:"summary,omitempty"`
+	Value   interface{} `json:"value"`
+}
+
+// Components holds the schemas of named types, referenced by "#/components/schemas/<name>".
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Generate returns the OpenAPI document of the routes of controllers.
+// If opts is nil, the default title and version are used.
+func Generate(controllers []core.Controller, opts *Options) *Document {
+	o := opts.withDefaults()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: o.Title, Version: o.Version, Description: o.Description},
+		Paths:   make(map[string]PathItem),
+	}
+	for _, url := range o.Servers {
+		doc.Servers = append(doc.Servers, Server{URL: url})
+	}
+
+	g := newSchemaGenerator()
+	for _, controller := range controllers {
+		path, parameters := convertPath(controller.GetPath())
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		operation := g.operation(controller)
+		operation.Parameters = parameters
+		item[strings.ToLower(string(controller.GetHttpMethod()))] = operation
+	}
+	if len(g.schemas) > 0 {
+		doc.Components = &Components{Schemas: g.schemas}
+	}
+	return doc
+}
+
+// convertPath converts a route path to an OpenAPI path and returns its path parameters,
+// e.g. "/users/:id" to "/users/{id}".
+func convertPath(path string) (string, []Parameter) {
+	var parameters []Parameter
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if len(segment) < 2 || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		parameters = append(parameters, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	return strings.Join(segments, "/"), parameters
+}
+
+// operation returns the operation of the route of controller.
+func (g *schemaGenerator) operation(controller core.Controller) *Operation {
+	var doc core.RouteDoc
+	if documented, ok := controller.(core.DocumentedController); ok {
+		doc = documented.Documentation()
+	}
+	var examples []core.Example
+	if provider, ok := controller.(core.ExampleProvider); ok {
+		examples = provider.Examples()
+	}
+
+	operation := &Operation{
+		Summary:     doc.Summary,
+		Description: doc.Description,
+		OperationID: doc.OperationID,
+		Tags:        doc.Tags,
+		Responses:   make(map[string]*Response),
+		Deprecated:  doc.Deprecated,
+	}
+
+	// Request body
+	request := MediaType{Schema: g.schemaOf(doc.Request)}
+	for _, example := range examples {
+		if example.Request != nil {
+			request.Examples = addExample(request.Examples, example, example.Request)
+		}
+	}
+	if request.Schema != nil || request.Examples != nil {
+		operation.RequestBody = &RequestBody{
+			Required: request.Schema != nil,
+			Content:  map[string]MediaType{"application/json": request},
+		}
+	}
+
+	// Responses, documented or taken from the examples
+	responses := make(map[int]MediaType)
+	for code, body := range doc.Responses {
+		responses[code] = MediaType{Schema: g.schemaOf(body)}
+	}
+	for _, example := range examples {
+		if example.StatusCode == 0 {
+			continue
+		}
+		response := responses[example.StatusCode]
+		if example.Response != nil {
+			response.Examples = addExample(response.Examples, example, example.Response)
+		}
+		responses[example.StatusCode] = response
+	}
+	if len(responses) == 0 {
+		responses[http.StatusOK] = MediaType{}
+	}
+	for code, body := range responses {
+		response := &Response{Description: http.StatusText(code)}
+		if response.Description == "" {
+			response.Description = "Status " + strconv.Itoa(code)
+		}
+		if body.Schema != nil || body.Examples != nil {
+			response.Content = map[string]MediaType{"application/json": body}
+		}
+		operation.Responses[strconv.Itoa(code)] = response
+	}
+	return operation
+}
+
+// addExample adds the value of example to examples, named after the example.
+func addExample(examples map[string]ExampleObject, example core.Example, value interface{}) map[string]ExampleObject {
+	if examples == nil {
+		examples = make(map[string]ExampleObject)
+	}
+	name := example.Name
+	if name == "" {
+		name = "example" + strconv.Itoa(len(examples)+1)
+	}
+	examples[name] = ExampleObject{Summary: example.Description, Value: value}
+	return examples
+}
+
+// schemaOf returns the schema of the type of v, or nil if v is nil.
+func (g *schemaGenerator) schemaOf(v interface{}) *Schema {
+	if v == nil {
+		return nil
+	}
+	return g.schema(reflect.TypeOf(v))
+}
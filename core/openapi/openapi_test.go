@@ -0,0 +1,178 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+type testController struct {
+	method   core.HttpMethod
+	path     string
+	doc      *core.RouteDoc
+	examples []core.Example
+}
+
+func (c *testController) GetHttpMethod() core.HttpMethod { return c.method }
+func (c *testController) GetPath() string                { return c.path }
+func (c *testController) Handler() []core.HandlerFunc    { return nil }
+func (c *testController) SkipLogging() bool              { return false }
+func (c *testController) SkipAuthCheck() bool            { return false }
+
+// documentedController also implements core.DocumentedController and core.ExampleProvider.
+type documentedController struct{ testController }
+
+func (c *documentedController) Documentation() core.RouteDoc { return *c.doc }
+func (c *documentedController) Examples() []core.Example     { return c.examples }
+
+type audit struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Version   int       `json:"version"`
+}
+
+type createUserRequest struct {
+	Name  string   `json:"name" binding:"required"`
+	Email string   `json:"email,omitempty" validate:"required,email"`
+	Tags  []string `json:"tags"`
+	Age   int64    `json:"age,string"`
+	Note  string   `json:"-"`
+	local string
+}
+
+type user struct {
+	audit
+	ID      string            `json:"id"`
+	Version string            `json:"version"` // Shadows audit.Version
+	Manager *user             `json:"manager,omitempty"`
+	Labels  map[string]string `json:"labels"`
+	Avatar  []byte            `json:"avatar"`
+	Extra   interface{}       `json:"extra"`
+}
+
+func TestGenerate(t *testing.T) {
+	doc := Generate([]core.Controller{
+		&documentedController{testController{
+			method: core.POST,
+			path:   "/teams/:team/users",
+			doc: &core.RouteDoc{
+				Summary: "Create a user",
+				Tags:    []string{"users"},
+				Request: createUserRequest{},
+				Responses: map[int]interface{}{
+					http.StatusCreated:  &user{},
+					http.StatusConflict: nil,
+				},
+			},
+			examples: []core.Example{{
+				Name:       "success",
+				Request:    map[string]string{"name": "john"},
+				StatusCode: http.StatusCreated,
+				Response:   map[string]string{"id": "1"},
+			}},
+		}},
+		&testController{method: core.GET, path: "/files/*path"},
+	}, &Options{Title: "Users", Servers: []string{"https://api.example.com"}})
+
+	if doc.OpenAPI != Version || doc.Info.Title != "Users" || doc.Info.Version != "1.0.0" {
+		t.Errorf("header = %s %+v, want defaults and title Users", doc.OpenAPI, doc.Info)
+	}
+	if len(doc.Servers) != 1 || doc.Servers[0].URL != "https://api.example.com" {
+		t.Errorf("Servers = %+v", doc.Servers)
+	}
+
+	create := doc.Paths["/teams/{team}/users"]["post"]
+	if create == nil {
+		t.Fatalf("paths = %v, want /teams/{team}/users", doc.Paths)
+	}
+	if create.Summary != "Create a user" || !reflect.DeepEqual(create.Tags, []string{"users"}) {
+		t.Errorf("operation = %+v", create)
+	}
+	if len(create.Parameters) != 1 || create.Parameters[0].Name != "team" || create.Parameters[0].In != "path" {
+		t.Errorf("Parameters = %+v, want the team path parameter", create.Parameters)
+	}
+
+	request := create.RequestBody.Content["application/json"]
+	if request.Schema.Ref != "#/components/schemas/createUserRequest" || request.Examples["success"].Value == nil {
+		t.Errorf("request body = %+v, want a reference and the example", request)
+	}
+	if response := create.Responses["201"]; response.Description != "Created" ||
+		response.Content["application/json"].Schema.Ref != "#/components/schemas/user" ||
+		response.Content["application/json"].Examples["success"].Value == nil {
+		t.Errorf("201 response = %+v", response)
+	}
+	if response := create.Responses["409"]; response.Description != "Conflict" || response.Content != nil {
+		t.Errorf("409 response = %+v, want no content", response)
+	}
+
+	files := doc.Paths["/files/{path}"]["get"]
+	if files == nil || files.Parameters[0].Name != "path" || files.Responses["200"].Description != "OK" {
+		t.Errorf("undocumented operation = %+v, want the path parameter and a 200 response", files)
+	}
+
+	schemas := doc.Components.Schemas
+	req := schemas["createUserRequest"]
+	if !reflect.DeepEqual(req.Required, []string{"name", "email"}) {
+		t.Errorf("required = %v, want name and email", req.Required)
+	}
+	for name, want := range map[string]Schema{
+		"name":  {Type: "string"},
+		"email": {Type: "string"},
+		"tags":  {Type: "array", Items: &Schema{Type: "string"}},
+		"age":   {Type: "string"},
+	} {
+		if got := req.Properties[name]; got == nil || !reflect.DeepEqual(*got, want) {
+			t.Errorf("property %s = %+v, want %+v", name, got, want)
+		}
+	}
+	if len(req.Properties) != 4 {
+		t.Errorf("properties = %v, want 4 properties", req.Properties)
+	}
+
+	u := schemas["user"]
+	for name, want := range map[string]Schema{
+		"createdAt": {Type: "string", Format: "date-time"},
+		"id":        {Type: "string"},
+		"version":   {Type: "string"},
+		"manager":   {Ref: "#/components/schemas/user"},
+		"labels":    {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+		"avatar":    {Type: "string", Format: "byte"},
+		"extra":     {},
+	} {
+		if got := u.Properties[name]; got == nil || !reflect.DeepEqual(*got, want) {
+			t.Errorf("property %s = %+v, want %+v", name, got, want)
+		}
+	}
+
+	if _, err := json.Marshal(doc); err != nil {
+		t.Errorf("Marshal() returned error: %v", err)
+	}
+}
+
+func TestComponentNames(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+	g := newSchemaGenerator()
+	g.schema(reflect.TypeOf(user{}))
+	g.schema(reflect.TypeOf(struct {
+		Owner user `json:"owner"`
+	}{}))
+	if len(g.schemas) != 1 || g.schemas["user"] == nil {
+		t.Fatalf("schemas = %v, want the local user type once", g.schemas)
+	}
+
+	// Another type of the same name is qualified with its package
+	if name := g.component(reflect.TypeOf(userAlias{})); name != "openapi.user" {
+		t.Errorf("component name = %s, want openapi.user", name)
+	}
+	if name := componentName("Page[github.com/x.User]"); name != "Page_github.com_x.User_" {
+		t.Errorf("componentName() = %s", name)
+	}
+}
+
+// userAlias refers to the package-level user type from tests declaring a local user type.
+type userAlias = user
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// ParamType is the set of types a path parameter can be parsed into with ParseParam.
+type ParamType interface {
+	~string | ~bool |
+		~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// uuidPattern matches a UUID in its canonical 8-4-4-4-12 hexadecimal form.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ParseParam parses the URL param with the given key into T.
+// If the param is missing or cannot be parsed, it returns a *errors.BadRequestHttpError,
+// which the error handler middleware turns into a 400 response.
+//
+// Example usage:
+//
+//	id, err := core.ParseParam[int64](c, "id")
+//	if err != nil {
+//		c.Error(err)
+//		return
+//	}
+func ParseParam[T ParamType](c Context, key string) (T, error) {
+	var zero T
+	value := c.Param(key)
+	if value == "" {
+		return zero, paramError(key, "is required")
+	}
+
+	var result T
+	target := reflect.ValueOf(&result).Elem()
+	var err error
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(value)
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(value)
+		target.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		n, err = strconv.ParseInt(value, 10, target.Type().Bits())
+		target.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		n, err = strconv.ParseUint(value, 10, target.Type().Bits())
+		target.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		var n float64
+		n, err = strconv.ParseFloat(value, target.Type().Bits())
+		target.SetFloat(n)
+	}
+	if err != nil {
+		return zero, paramError(key, fmt.Sprintf("must be a valid %s", target.Kind()))
+	}
+	return result, nil
+}
+
+// ParseParamInt parses the URL param with the given key as an int.
+// It is used by Context.ParamInt implementations.
+func ParseParamInt(c Context, key string) (int, error) {
+	value := c.Param(key)
+	if value == "" {
+		return 0, paramError(key, "is required")
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, paramError(key, "must be an integer")
+	}
+	return n, nil
+}
+
+// ParseParamUUID validates that the URL param with the given key is a UUID and returns it.
+// It is used by Context.ParamUUID implementations.
+func ParseParamUUID(c Context, key string) (string, error) {
+	value := c.Param(key)
+	if value == "" {
+		return "", paramError(key, "is required")
+	}
+	if !uuidPattern.MatchString(value) {
+		return "", paramError(key, "must be a UUID")
+	}
+	return value, nil
+}
+
+// paramError returns the 400 error reported for an invalid path parameter.
+func paramError(key, reason string) error {
+	return &httperrors.BadRequestHttpError{
+		Message: fmt.Sprintf("invalid path parameter %q: %s", key, reason),
+	}
+}
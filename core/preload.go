@@ -0,0 +1,87 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// PreloadingController is an optional interface for controllers whose handlers need data loaded
+// before they run, e.g. the entity referenced by the :id param. The loaders, usually created with
+// Preload, run in order before the handlers of the route, and abort the request if one fails.
+type PreloadingController interface {
+	// Loaders returns the handlers loading the data of the route
+	Loaders() []HandlerFunc
+}
+
+// ControllerHandlers returns the handlers of the route of controller: its loaders, if it is a
+// PreloadingController, followed by its handlers. It is used by the RegisterRouter implementations.
+func ControllerHandlers(controller Controller) []HandlerFunc {
+	preloading, ok := controller.(PreloadingController)
+	if !ok {
+		return controller.Handler()
+	}
+	loaders := preloading.Loaders()
+	return append(loaders[:len(loaders):len(loaders)], controller.Handler()...)
+}
+
+// loadedKey returns the context key under which a loaded value of type T is stored.
+func loadedKey[T any]() string {
+	t := reflect.TypeFor[T]()
+	return "loaded:" + t.PkgPath() + "." + t.String()
+}
+
+// Preload returns a handler that calls load and stores the value for the handlers after it,
+// which get it with Loaded, standardizing the "fetch or 404" pattern:
+//   - If load returns nil and no error, the resource doesn't exist, and the request is aborted with
+//     a 404 Not Found error named after T, e.g. "Order not found".
+//   - If load returns an error, the request is aborted with it, e.g. a 403 Forbidden HTTPError.
+//
+// Errors are added with c.Error, so that the error handler middleware writes the response.
+// Values are stored by type, so a route can preload one value of each type.
+//
+// Example usage:
+//
+//	func (c *OrderController) Loaders() []core.HandlerFunc {
+//		return []core.HandlerFunc{core.Preload(func(ctx core.Context) (*Order, error) {
+//			return c.orders.Find(ctx.Request().Context(), ctx.Param("id"))
+//		})}
+//	}
+//
+//	func (c *OrderController) Handler() []core.HandlerFunc {
+//		return []core.HandlerFunc{func(ctx core.Context) {
+//			order, _ := core.Loaded[Order](ctx)
+//			ctx.JSON(http.StatusOK, order)
+//		}}
+//	}
+func Preload[T any](load func(c Context) (*T, error)) HandlerFunc {
+	key := loadedKey[T]()
+	name := reflect.TypeFor[T]().Name()
+	if name == "" {
+		name = "Resource"
+	}
+
+	return func(c Context) {
+		value, err := load(c)
+		if err == nil && value == nil {
+			err = httperrors.NewNotFoundHttpError(fmt.Errorf("%s not found", name))
+		}
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		c.Set(key, value)
+	}
+}
+
+// Loaded returns the value of type T stored by Preload for this request, and whether there is one.
+func Loaded[T any](c Context) (*T, bool) {
+	value, exists := c.Get(loadedKey[T]())
+	if !exists {
+		return nil, false
+	}
+	loaded, ok := value.(*T)
+	return loaded, ok
+}
@@ -0,0 +1,33 @@
+package core
+
+// Priority is the priority class of a route, deciding which routes are shed first when the
+// server is overloaded. The zero value is PriorityNormal.
+type Priority int
+
+const (
+	// PriorityNormal routes are shed only under severe overload. Routes are normal by default.
+	PriorityNormal Priority = iota
+	// PriorityCritical routes, such as health checks and payments, are never shed.
+	PriorityCritical
+	// PriorityBestEffort routes, such as analytics, are shed first.
+	PriorityBestEffort
+)
+
+// String returns the name of the priority class.
+func (p Priority) String() string {
+	switch p {
+	case PriorityCritical:
+		return "critical"
+	case PriorityBestEffort:
+		return "best-effort"
+	default:
+		return "normal"
+	}
+}
+
+// PrioritizedController is an optional interface for controllers that declare the priority class
+// of their route, used by the server builder's watchdog to decide which routes to shed first.
+type PrioritizedController interface {
+	// Priority returns the priority class of the route
+	Priority() Priority
+}
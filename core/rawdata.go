@@ -0,0 +1,73 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultMaxRawDataSize is the maximum request body size, in bytes, that GetRawData caches
+// when no per-request limit has been set under ContextKeyMaxRawDataSize.
+var DefaultMaxRawDataSize int64 = 10 << 20 // 10 MB
+
+// ContextKeyMaxRawDataSize is the context key holding the int64 maximum body size cached by GetRawData.
+// It is set by middleware.RawDataLimitMiddleware.
+const ContextKeyMaxRawDataSize = "max_raw_data_size"
+
+// contextKeyRawData is the context key under which the cached request body is stored.
+const contextKeyRawData = "raw_data"
+
+// ErrRawDataTooLarge is returned by GetRawData when the request body exceeds the maximum cached size.
+// The request body is left intact so that it can still be streamed.
+var ErrRawDataTooLarge = errors.New("request body exceeds the maximum cached size")
+
+// ReadRawData reads the request body of c once and caches it in the context.
+// Every call, including the first, rewinds the request body, so that later readers
+// (binding, signature verification, other middleware) see the full body again.
+// It is used by Context.GetRawData implementations.
+func ReadRawData(c Context) ([]byte, error) {
+	req := c.Request()
+
+	if cached, ok := c.Get(contextKeyRawData); ok {
+		data := cached.([]byte)
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		return data, nil
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		c.Set(contextKeyRawData, []byte{})
+		return []byte{}, nil
+	}
+
+	maxSize := DefaultMaxRawDataSize
+	if value, ok := c.Get(ContextKeyMaxRawDataSize); ok {
+		if size, ok := value.(int64); ok {
+			maxSize = size
+		}
+	}
+
+	body := req.Body
+	data, err := io.ReadAll(io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxSize {
+		// Put back what was read so the body can still be consumed as a stream
+		req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(data), body), Closer: body}
+		return nil, fmt.Errorf("%w (%d bytes)", ErrRawDataTooLarge, maxSize)
+	}
+
+	_ = body.Close()
+	c.Set(contextKeyRawData, data)
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// readCloser combines a Reader and the Closer of the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
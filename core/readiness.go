@@ -0,0 +1,209 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultReadinessPath is the default path of the readiness endpoint.
+const DefaultReadinessPath = "/ready"
+
+// Default retry settings of dependency checks.
+const (
+	DefaultStartupTimeout      = 30 * time.Second
+	DefaultReadinessBackoff    = 100 * time.Millisecond
+	DefaultReadinessMaxBackoff = 5 * time.Second
+)
+
+// DependencyCheck checks that a dependency of the server is available, e.g. that the database
+// answers a ping, that migrations have been applied or that the cache is reachable.
+type DependencyCheck struct {
+	// Name identifies the dependency in errors and in the readiness endpoint, e.g. "postgres"
+	Name string
+	// Check returns nil once the dependency is available
+	Check LifecycleHook
+}
+
+// ReadinessConfig configures how dependency checks are retried at startup.
+type ReadinessConfig struct {
+	// Timeout is how long the checks may take before startup fails.
+	// If zero, DefaultStartupTimeout (30s) is used.
+	Timeout time.Duration
+	// Backoff is the delay before the first retry of a failed check; it doubles with every retry.
+	// If zero, DefaultReadinessBackoff (100ms) is used.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries. If zero, DefaultReadinessMaxBackoff (5s) is used.
+	MaxBackoff time.Duration
+	// Background starts the server without waiting for the checks; they keep running in the
+	// background and the readiness endpoint reports 503 Service Unavailable until they pass.
+	// If false, the server does not accept requests until the checks pass, and Run returns
+	// the error of the checks if they do not pass within Timeout.
+	Background bool
+}
+
+// DependencyStatus is the state of a dependency check.
+type DependencyStatus struct {
+	Name     string `json:"name"`
+	Ready    bool   `json:"ready"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Readiness runs dependency checks before the server accepts traffic, retrying failed checks
+// with exponential backoff until they pass or the startup timeout expires.
+// Checks run concurrently; a check that has passed is not run again.
+type Readiness struct {
+	config ReadinessConfig
+	checks []DependencyCheck
+
+	mu       sync.Mutex
+	statuses []DependencyStatus
+	ready    bool
+	cancel   context.CancelFunc // Cancels checks running in the background
+}
+
+// NewReadiness returns a Readiness running checks with config. If config is nil, the defaults are used.
+func NewReadiness(config *ReadinessConfig, checks ...DependencyCheck) *Readiness {
+	r := &Readiness{checks: checks, statuses: make([]DependencyStatus, len(checks))}
+	if config != nil {
+		r.config = *config
+	}
+	if r.config.Timeout <= 0 {
+		r.config.Timeout = DefaultStartupTimeout
+	}
+	if r.config.Backoff <= 0 {
+		r.config.Backoff = DefaultReadinessBackoff
+	}
+	if r.config.MaxBackoff <= 0 {
+		r.config.MaxBackoff = DefaultReadinessMaxBackoff
+	}
+	for i, check := range checks {
+		r.statuses[i].Name = check.Name
+	}
+	return r
+}
+
+// Run runs the checks until all of them pass, and returns the errors of the checks that have not
+// passed when ctx is done or the timeout expires. Once all checks have passed, later calls return nil.
+func (r *Readiness) Run(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+	defer cancel()
+
+	errs := make([]error, len(r.checks))
+	var wg sync.WaitGroup
+	for i := range r.checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = r.runCheck(ctx, i)
+		}(i)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.ready = true
+	r.mu.Unlock()
+	return nil
+}
+
+// runCheck runs the check at index i until it passes or ctx is done.
+func (r *Readiness) runCheck(ctx context.Context, i int) error {
+	check := r.checks[i]
+	backoff := r.config.Backoff
+	for {
+		r.mu.Lock()
+		if r.statuses[i].Ready {
+			r.mu.Unlock()
+			return nil
+		}
+		r.mu.Unlock()
+
+		err := check.Check(ctx)
+
+		r.mu.Lock()
+		r.statuses[i].Attempts++
+		r.statuses[i].Ready = err == nil
+		r.statuses[i].Error = ""
+		if err != nil {
+			r.statuses[i].Error = err.Error()
+		}
+		r.mu.Unlock()
+		if err == nil {
+			return nil
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("dependency %q is not ready: %w", check.Name, err)
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, r.config.MaxBackoff)
+	}
+}
+
+// Start runs the checks as a start hook. With ReadinessConfig.Background, it returns immediately
+// and the checks run until they pass, the timeout expires or Stop is called.
+func (r *Readiness) Start(ctx context.Context) error {
+	if !r.config.Background {
+		return r.Run(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	r.mu.Lock()
+	r.cancel = cancel
+	r.mu.Unlock()
+	go func() {
+		defer cancel()
+		_ = r.Run(ctx)
+	}()
+	return nil
+}
+
+// Stop cancels checks running in the background.
+func (r *Readiness) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		r.cancel()
+	}
+	return nil
+}
+
+// Ready returns whether all checks have passed.
+func (r *Readiness) Ready() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ready
+}
+
+// Statuses returns the state of each check, in the order the checks were registered.
+func (r *Readiness) Statuses() []DependencyStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]DependencyStatus(nil), r.statuses...)
+}
+
+// Handler returns a handler that responds with 200 OK once all checks have passed and with
+// 503 Service Unavailable before, listing the state of each check.
+func (r *Readiness) Handler() HandlerFunc {
+	return func(c Context) {
+		status, code := "ready", http.StatusOK
+		if !r.Ready() {
+			status, code = "not ready", http.StatusServiceUnavailable
+		}
+		c.SetHeader("Cache-Control", "no-store")
+		c.JSON(code, map[string]interface{}{
+			"status": status,
+			"checks": r.Statuses(),
+		})
+	}
+}
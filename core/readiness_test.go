@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadinessRetries(t *testing.T) {
+	var attempts atomic.Int32
+	r := NewReadiness(&ReadinessConfig{Timeout: time.Second, Backoff: time.Millisecond},
+		DependencyCheck{Name: "postgres", Check: func(ctx context.Context) error {
+			if attempts.Add(1) < 3 {
+				return errors.New("connection refused")
+			}
+			return nil
+		}},
+		DependencyCheck{Name: "redis", Check: func(ctx context.Context) error { return nil }},
+	)
+
+	if err := r.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !r.Ready() {
+		t.Fatal("Ready() = false after the checks passed")
+	}
+	statuses := r.Statuses()
+	if statuses[0].Attempts != 3 || !statuses[0].Ready || statuses[0].Error != "" {
+		t.Errorf("postgres status = %+v, want ready after 3 attempts", statuses[0])
+	}
+
+	// Passed checks are not run again
+	if err := r.Run(context.Background()); err != nil || attempts.Load() != 3 {
+		t.Errorf("second Run() = %v with %d attempts, want nil with 3", err, attempts.Load())
+	}
+}
+
+func TestReadinessTimeout(t *testing.T) {
+	r := NewReadiness(&ReadinessConfig{Timeout: 20 * time.Millisecond, Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond},
+		DependencyCheck{Name: "migrations", Check: func(ctx context.Context) error {
+			return errors.New("version 41, want 42")
+		}},
+	)
+
+	err := r.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `dependency "migrations" is not ready: version 41, want 42`) {
+		t.Fatalf("Run() error = %v, want the error of the migrations check", err)
+	}
+	if r.Ready() {
+		t.Error("Ready() = true after the timeout")
+	}
+	if status := r.Statuses()[0]; status.Attempts < 2 || status.Error == "" {
+		t.Errorf("status = %+v, want several failed attempts", status)
+	}
+}
+
+func TestReadinessBackground(t *testing.T) {
+	release := make(chan struct{})
+	r := NewReadiness(&ReadinessConfig{Background: true, Backoff: time.Millisecond},
+		DependencyCheck{Name: "cache", Check: func(ctx context.Context) error {
+			select {
+			case <-release:
+				return nil
+			default:
+				return errors.New("unreachable")
+			}
+		}},
+	)
+
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if r.Ready() {
+		t.Fatal("Ready() = true before the check passed")
+	}
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for !r.Ready() {
+		if time.Now().After(deadline) {
+			t.Fatal("checks did not pass in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	_ = r.Stop(context.Background())
+}
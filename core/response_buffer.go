@@ -0,0 +1,90 @@
+package core
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+)
+
+// ResponseBuffer is an http.ResponseWriter that holds the status code and body in memory
+// instead of sending them, so that middleware can inspect or rewrite a response before it is sent.
+// Headers are written directly to the underlying writer's header map.
+//
+// Example usage:
+//
+//	original := c.Writer()
+//	buffer := core.NewResponseBuffer(original)
+//	c.SetWriter(buffer)
+//	c.Next()
+//	c.SetWriter(original)
+//	// inspect or modify buffer.Body() ...
+//	buffer.Commit()
+type ResponseBuffer struct {
+	writer    http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	committed bool
+}
+
+// NewResponseBuffer returns a ResponseBuffer that commits to w.
+func NewResponseBuffer(w http.ResponseWriter) *ResponseBuffer {
+	return &ResponseBuffer{writer: w}
+}
+
+// Header returns the header map of the underlying writer.
+func (b *ResponseBuffer) Header() http.Header {
+	return b.writer.Header()
+}
+
+// WriteHeader records the status code. Only the first call has an effect.
+func (b *ResponseBuffer) WriteHeader(code int) {
+	if b.committed {
+		log.Printf("[WARNING] ignoring WriteHeader(%d) after the buffered response was committed", code)
+		return
+	}
+	if b.status == 0 {
+		b.status = code
+	}
+}
+
+// Write appends data to the buffered body. After Commit, writes are rejected with ErrResponseSent.
+func (b *ResponseBuffer) Write(data []byte) (int, error) {
+	if b.committed {
+		log.Printf("[WARNING] ignoring write of %d bytes after the buffered response was committed", len(data))
+		return 0, ErrResponseSent
+	}
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(data)
+}
+
+// Status returns the buffered status code, or 0 if nothing has been written yet.
+func (b *ResponseBuffer) Status() int {
+	return b.status
+}
+
+// Body returns the buffered body.
+func (b *ResponseBuffer) Body() []byte {
+	return b.body.Bytes()
+}
+
+// SetBody replaces the buffered body.
+func (b *ResponseBuffer) SetBody(body []byte) {
+	b.body.Reset()
+	b.body.Write(body)
+}
+
+// Commit sends the buffered status code and body to the underlying writer.
+// If nothing has been written, nothing is sent. Later writes to the buffer are rejected.
+func (b *ResponseBuffer) Commit() error {
+	b.committed = true
+	if b.status == 0 {
+		return nil
+	}
+	// The body may have been rewritten, so a length set by the handler is no longer valid
+	b.writer.Header().Del("Content-Length")
+	b.writer.WriteHeader(b.status)
+	_, err := b.writer.Write(b.body.Bytes())
+	return err
+}
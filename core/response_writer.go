@@ -0,0 +1,124 @@
+package core
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter is the http.ResponseWriter returned by Context.Writer.
+// It records the response status and size, so that middleware can read them after Next returns
+// instead of wrapping the writer itself. gin.ResponseWriter implements it.
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+
+	// Status returns the response status code, 200 if none has been written yet.
+	Status() int
+	// Size returns the number of body bytes written, or -1 if the header has not been written.
+	Size() int
+	// Written returns whether the header has been written.
+	Written() bool
+	// Pusher returns the http.Pusher of the connection, or nil if HTTP/2 server push is not supported.
+	Pusher() http.Pusher
+}
+
+// NewResponseWriter returns w as a ResponseWriter.
+// If w already implements ResponseWriter, it is returned unchanged; otherwise it is wrapped.
+// The wrapper forwards WriteHeader immediately and ignores superfluous calls.
+func NewResponseWriter(w http.ResponseWriter) ResponseWriter {
+	if rw, ok := w.(ResponseWriter); ok {
+		return rw
+	}
+	sw := &StatusWriter{}
+	sw.Reset(w)
+	return sw
+}
+
+// StatusWriter is the ResponseWriter returned by NewResponseWriter.
+// It records the status and size of a response written to an http.ResponseWriter.
+// Adapters can embed it by value and call Reset to avoid an allocation per request.
+type StatusWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+// Reset makes the writer write to rw, as if nothing had been written yet.
+func (w *StatusWriter) Reset(rw http.ResponseWriter) {
+	w.ResponseWriter = rw
+	w.status = http.StatusOK
+	w.size = -1
+}
+
+// Unwrap returns the underlying ResponseWriter so that http.ResponseController can reach it.
+func (w *StatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WriteHeader records the status code and forwards it if the header has not been written yet.
+func (w *StatusWriter) WriteHeader(code int) {
+	if w.Written() {
+		return
+	}
+	w.status = code
+	// Informational responses do not complete the header
+	if code < 100 || code >= 200 || code == http.StatusSwitchingProtocols {
+		w.size = 0
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes data, sending a 200 OK header first if none has been written.
+func (w *StatusWriter) Write(data []byte) (int, error) {
+	if !w.Written() {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
+}
+
+// Status implements ResponseWriter.Status.
+func (w *StatusWriter) Status() int {
+	return w.status
+}
+
+// Size implements ResponseWriter.Size.
+func (w *StatusWriter) Size() int {
+	return w.size
+}
+
+// Written implements ResponseWriter.Written.
+func (w *StatusWriter) Written() bool {
+	return w.size != -1
+}
+
+// Flush sends the header, if it has not been written, and any buffered data to the client.
+func (w *StatusWriter) Flush() {
+	if !w.Written() {
+		w.WriteHeader(http.StatusOK)
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack lets the handler take over the connection, for WebSocket upgrades.
+func (w *StatusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Pusher implements ResponseWriter.Pusher.
+func (w *StatusWriter) Pusher() http.Pusher {
+	var next http.ResponseWriter = w.ResponseWriter
+	for next != nil {
+		if pusher, ok := next.(http.Pusher); ok {
+			return pusher
+		}
+		u, ok := next.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		next = u.Unwrap()
+	}
+	return nil
+}
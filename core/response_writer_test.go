@@ -0,0 +1,51 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := NewResponseWriter(rec)
+
+	if w.Written() || w.Size() != -1 || w.Status() != http.StatusOK {
+		t.Fatalf("new writer: Written() = %v, Size() = %d, Status() = %d, want false, -1, 200", w.Written(), w.Size(), w.Status())
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusInternalServerError) // Superfluous, ignored
+	if _, err := w.Write([]byte("created")); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	if !w.Written() || w.Size() != len("created") || w.Status() != http.StatusCreated {
+		t.Errorf("Written() = %v, Size() = %d, Status() = %d, want true, %d, 201", w.Written(), w.Size(), w.Status(), len("created"))
+	}
+	if rec.Code != http.StatusCreated || rec.Body.String() != "created" {
+		t.Errorf("recorded %d %q, want 201 %q", rec.Code, rec.Body.String(), "created")
+	}
+
+	if NewResponseWriter(w) != w {
+		t.Error("NewResponseWriter() wrapped a ResponseWriter again")
+	}
+	if w.Pusher() != nil {
+		t.Error("Pusher() returned a pusher for a writer without HTTP/2 push")
+	}
+}
+
+func TestResponseWriterImplicitStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := NewResponseWriter(rec)
+
+	w.Flush()
+	if !w.Written() || w.Size() != 0 || w.Status() != http.StatusOK {
+		t.Errorf("after Flush: Written() = %v, Size() = %d, Status() = %d, want true, 0, 200", w.Written(), w.Size(), w.Status())
+	}
+	if !rec.Flushed {
+		t.Error("Flush() did not flush the underlying writer")
+	}
+	if err := http.NewResponseController(w).Flush(); err != nil {
+		t.Errorf("ResponseController.Flush() returned error: %v", err)
+	}
+}
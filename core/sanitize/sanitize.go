@@ -0,0 +1,264 @@
+// Package sanitize cleans untrusted HTML, such as user-generated content, before it is rendered
+// in HTML responses. A Policy allowlists elements, attributes and URL schemes; everything else
+// is removed, and the text content of removed elements is kept, escaped.
+//
+// Use Policy.Sanitize on strings written directly into responses, and Policy.FuncMap with
+// html/template, which escapes all other values automatically:
+//
+//	policy := sanitize.UGCPolicy()
+//	tmpl := template.Must(template.New("post").Funcs(policy.FuncMap()).Parse(`<h1>{{.Title}}</h1>{{sanitize .Body}}`))
+package sanitize
+
+import (
+	"html/template"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Policy describes which HTML is kept by Sanitize. Configure a policy once, e.g. at startup;
+// after that it is safe for concurrent use.
+type Policy struct {
+	elements    map[string]bool
+	globalAttrs map[string]bool
+	attrs       map[string]map[string]bool
+	urlSchemes  map[string]bool
+	noFollow    bool
+}
+
+// urlAttrs are the attributes holding URLs, whose schemes are checked against the policy.
+var urlAttrs = map[string]bool{
+	"href":       true,
+	"src":        true,
+	"cite":       true,
+	"action":     true,
+	"formaction": true,
+	"poster":     true,
+	"background": true,
+}
+
+// dropContentElements are removed together with their content, since their text is not
+// meant to be displayed.
+var dropContentElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"noscript": true,
+	"template": true,
+	"textarea": true,
+	"title":    true,
+	"svg":      true,
+	"math":     true,
+}
+
+// voidElements have no content and no end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// NewPolicy returns an empty policy, which removes all elements and keeps only text.
+// URLs may use the http, https and mailto schemes unless AllowURLSchemes is called.
+func NewPolicy() *Policy {
+	return &Policy{
+		elements:    make(map[string]bool),
+		globalAttrs: make(map[string]bool),
+		attrs:       make(map[string]map[string]bool),
+		urlSchemes:  map[string]bool{"http": true, "https": true, "mailto": true},
+	}
+}
+
+// StrictPolicy returns a policy that removes all HTML, e.g. for names or titles.
+func StrictPolicy() *Policy {
+	return NewPolicy()
+}
+
+// UGCPolicy returns a policy for user-generated content such as comments and posts:
+// text formatting, lists, quotes, code blocks, links and images. Links get rel="nofollow".
+func UGCPolicy() *Policy {
+	return NewPolicy().
+		AllowElements("p", "br", "hr", "b", "strong", "i", "em", "u", "s", "del", "ins", "sub", "sup", "small", "mark",
+			"h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "li", "dl", "dt", "dd", "blockquote", "q",
+			"code", "pre", "kbd", "abbr", "span", "div", "table", "thead", "tbody", "tr", "th", "td", "caption",
+			"a", "img").
+		AllowAttrs("title").
+		AllowElementAttrs("a", "href").
+		AllowElementAttrs("img", "src", "alt", "width", "height").
+		AllowElementAttrs("blockquote", "cite").
+		AllowElementAttrs("q", "cite").
+		AllowElementAttrs("th", "colspan", "rowspan").
+		AllowElementAttrs("td", "colspan", "rowspan").
+		RequireNoFollowOnLinks()
+}
+
+// AllowElements keeps the given elements. Their attributes are removed unless allowed separately.
+// Elements whose content is never displayed, such as script and style, are always removed.
+func (p *Policy) AllowElements(names ...string) *Policy {
+	for _, name := range names {
+		p.elements[strings.ToLower(name)] = true
+	}
+	return p
+}
+
+// AllowAttrs keeps the given attributes on every allowed element.
+func (p *Policy) AllowAttrs(names ...string) *Policy {
+	for _, name := range names {
+		p.globalAttrs[strings.ToLower(name)] = true
+	}
+	return p
+}
+
+// AllowElementAttrs keeps the given attributes on element.
+func (p *Policy) AllowElementAttrs(element string, names ...string) *Policy {
+	element = strings.ToLower(element)
+	if p.attrs[element] == nil {
+		p.attrs[element] = make(map[string]bool)
+	}
+	for _, name := range names {
+		p.attrs[element][strings.ToLower(name)] = true
+	}
+	return p
+}
+
+// AllowURLSchemes replaces the URL schemes allowed in href, src and other URL attributes.
+// Relative URLs are always allowed; attributes with other schemes, e.g. javascript:, are removed.
+func (p *Policy) AllowURLSchemes(schemes ...string) *Policy {
+	p.urlSchemes = make(map[string]bool, len(schemes))
+	for _, scheme := range schemes {
+		p.urlSchemes[strings.ToLower(scheme)] = true
+	}
+	return p
+}
+
+// RequireNoFollowOnLinks sets rel="nofollow" on links, replacing any rel attribute,
+// so that spam links in user content don't gain search ranking.
+func (p *Policy) RequireNoFollowOnLinks() *Policy {
+	p.noFollow = true
+	return p
+}
+
+// Sanitize returns s with everything not allowed by the policy removed.
+// The result is well-formed: allowed elements left open in s are closed.
+func (p *Policy) Sanitize(s string) string {
+	var out strings.Builder
+	var open []string // Allowed elements written and not yet closed
+	skip := ""        // Element whose content is being dropped
+	skipDepth := 0
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// io.EOF, or a read error, which cannot happen with a strings.Reader
+			break
+		}
+		token := z.Token()
+		name := strings.ToLower(token.Data)
+
+		if skipDepth > 0 {
+			switch {
+			case tt == html.StartTagToken && name == skip:
+				skipDepth++
+			case tt == html.EndTagToken && name == skip:
+				skipDepth--
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			out.WriteString(html.EscapeString(token.Data))
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if dropContentElements[name] {
+				if tt == html.StartTagToken && !voidElements[name] {
+					skip, skipDepth = name, 1
+				}
+				continue
+			}
+			if !p.elements[name] {
+				continue
+			}
+			p.writeStartTag(&out, name, token.Attr)
+			if voidElements[name] {
+				continue
+			}
+			if tt == html.SelfClosingTagToken {
+				out.WriteString("</" + name + ">")
+				continue
+			}
+			open = append(open, name)
+
+		case html.EndTagToken:
+			// Only close elements that are open, closing any left open inside them
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == name {
+					for j := len(open) - 1; j >= i; j-- {
+						out.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+		// Comments and doctypes are dropped
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i] + ">")
+	}
+	return out.String()
+}
+
+// writeStartTag writes the start tag of element name with the allowed attributes of attrs.
+func (p *Policy) writeStartTag(out *strings.Builder, name string, attrs []html.Attribute) {
+	out.WriteString("<" + name)
+	hasHref := false
+	for _, attr := range attrs {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || !(p.globalAttrs[key] || p.attrs[name][key]) {
+			continue
+		}
+		if urlAttrs[key] && !p.allowedURL(attr.Val) {
+			continue
+		}
+		if key == "rel" && name == "a" && p.noFollow {
+			continue
+		}
+		if key == "href" {
+			hasHref = true
+		}
+		out.WriteString(" " + key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	if name == "a" && p.noFollow && hasHref {
+		out.WriteString(` rel="nofollow"`)
+	}
+	out.WriteString(">")
+}
+
+// allowedURL reports whether value is a relative URL or uses an allowed scheme.
+func (p *Policy) allowedURL(value string) bool {
+	value = strings.TrimSpace(value)
+	u, err := url.Parse(value)
+	if err != nil {
+		// Browsers ignore control characters, e.g. in "java\tscript:", so reject what doesn't parse
+		return false
+	}
+	return u.Scheme == "" || p.urlSchemes[strings.ToLower(u.Scheme)]
+}
+
+// HTML returns s sanitized as template.HTML, which html/template inserts without escaping it again.
+func (p *Policy) HTML(s string) template.HTML {
+	return template.HTML(p.Sanitize(s))
+}
+
+// FuncMap returns template functions using the policy: "sanitize" renders user HTML through the policy.
+// Values not passed through sanitize are still escaped by html/template as usual.
+func (p *Policy) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"sanitize": p.HTML,
+	}
+}
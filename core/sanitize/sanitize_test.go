@@ -0,0 +1,80 @@
+package sanitize
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestUGCPolicy(t *testing.T) {
+	policy := UGCPolicy()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain text", "Tom & Jerry", "Tom &amp; Jerry"},
+		{"formatting", "<p>Hello <b>world</b></p>", "<p>Hello <b>world</b></p>"},
+		{"script", `Hi<script>alert("x")</script>!`, "Hi!"},
+		{"nested script", "<div><script><script>x</script>y</script>z</div>", "<div>yz</div>"},
+		{"style", "<style>body{display:none}</style>text", "text"},
+		{"event handler", `<img src="/a.png" onerror="alert(1)">`, `<img src="/a.png">`},
+		{"javascript url", `<a href="javascript:alert(1)">x</a>`, "<a>x</a>"},
+		{"encoded javascript url", `<a href="javascript&#58;alert(1)">x</a>`, "<a>x</a>"},
+		{"control character url", "<a href=\"java\tscript:alert(1)\">x</a>", "<a>x</a>"},
+		{"link nofollow", `<a href="https://example.com" rel="author">x</a>`, `<a href="https://example.com" rel="nofollow">x</a>`},
+		{"relative link", `<a href="/posts/1?a=1&b=2">x</a>`, `<a href="/posts/1?a=1&amp;b=2" rel="nofollow">x</a>`},
+		{"disallowed element keeps text", "<marquee>hi</marquee>", "hi"},
+		{"unclosed elements", "<b><i>bold", "<b><i>bold</i></b>"},
+		{"stray end tag", "a</div>b", "ab"},
+		{"misnested", "<b><i>x</b>y</i>", "<b><i>x</i></b>y"},
+		{"comment", "a<!-- <script>x</script> -->b", "ab"},
+		{"attribute quoting", `<span title='"><script>'>x</span>`, `<span title="&#34;&gt;&lt;script&gt;">x</span>`},
+		{"self-closing", "<br/><hr>", "<br><hr>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Sanitize(tt.input); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrictPolicy(t *testing.T) {
+	got := StrictPolicy().Sanitize(`<a href="/x"><b>Bob</b></a> <3 <script>alert(1)</script>`)
+	if want := "Bob &lt;3 "; got != want {
+		t.Errorf("Sanitize() = %q, want %q", got, want)
+	}
+}
+
+func TestCustomPolicy(t *testing.T) {
+	policy := NewPolicy().
+		AllowElements("a").
+		AllowElementAttrs("a", "href").
+		AllowURLSchemes("https")
+
+	if got := policy.Sanitize(`<a href="mailto:a@example.com">a</a><a href="https://example.com">b</a>`); got != `<a>a</a><a href="https://example.com">b</a>` {
+		t.Errorf("Sanitize() = %q", got)
+	}
+}
+
+func TestFuncMap(t *testing.T) {
+	tmpl := template.Must(template.New("post").
+		Funcs(UGCPolicy().FuncMap()).
+		Parse(`<h1>{{.Title}}</h1>{{sanitize .Body}}`))
+
+	var out strings.Builder
+	err := tmpl.Execute(&out, map[string]string{
+		"Title": "<i>Title</i>",
+		"Body":  `<p>Body<img src=x onerror=alert(1)></p>`,
+	})
+	if err != nil {
+		t.Fatalf("Execute() returned error: %v", err)
+	}
+	if want := `<h1>&lt;i&gt;Title&lt;/i&gt;</h1><p>Body<img src="x"></p>`; out.String() != want {
+		t.Errorf("rendered %q, want %q", out.String(), want)
+	}
+}
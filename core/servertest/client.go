@@ -0,0 +1,212 @@
+// Package servertest provides helpers for testing servers built with this package.
+// Requests are served in-memory through the server's http.Handler, so no port is bound.
+package servertest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+)
+
+// Client sends requests to a server in-memory.
+//
+// Example usage:
+//
+//	client := servertest.NewClient(s).WithJWTSecret("secret")
+//	client.GET("/users").
+//		WithJWT(server.MapClaims{"sub": "1"}).
+//		Expect(t).
+//		Status(http.StatusOK).
+//		JSONPath("$.items[0].id", "1")
+type Client struct {
+	handler   http.Handler
+	jwtSecret string
+	headers   http.Header
+}
+
+// NewClient creates a new Client that serves requests with the given server's handler.
+func NewClient(srv core.Server) *Client {
+	return NewHandlerClient(srv.Handler())
+}
+
+// NewHandlerClient creates a new Client that serves requests with the given handler.
+func NewHandlerClient(handler http.Handler) *Client {
+	return &Client{
+		handler: handler,
+		headers: make(http.Header),
+	}
+}
+
+// WithJWTSecret sets the secret used to sign tokens passed to Request.WithJWT.
+func (c *Client) WithJWTSecret(secret string) *Client {
+	c.jwtSecret = secret
+	return c
+}
+
+// WithHeader sets a header that is sent with every request.
+func (c *Client) WithHeader(key, value string) *Client {
+	c.headers.Set(key, value)
+	return c
+}
+
+// GET creates a new GET request for the given path.
+func (c *Client) GET(path string) *Request {
+	return c.Request(http.MethodGet, path)
+}
+
+// POST creates a new POST request for the given path.
+func (c *Client) POST(path string) *Request {
+	return c.Request(http.MethodPost, path)
+}
+
+// PUT creates a new PUT request for the given path.
+func (c *Client) PUT(path string) *Request {
+	return c.Request(http.MethodPut, path)
+}
+
+// DELETE creates a new DELETE request for the given path.
+func (c *Client) DELETE(path string) *Request {
+	return c.Request(http.MethodDelete, path)
+}
+
+// PATCH creates a new PATCH request for the given path.
+func (c *Client) PATCH(path string) *Request {
+	return c.Request(http.MethodPatch, path)
+}
+
+// Request creates a new request with the given method and path.
+func (c *Client) Request(method, path string) *Request {
+	return &Request{
+		client: c,
+		method: method,
+		path:   path,
+		header: c.headers.Clone(),
+		query:  make(url.Values),
+	}
+}
+
+// Request is a request being built by a Client.
+type Request struct {
+	client *Client
+	method string
+	path   string
+	header http.Header
+	query  url.Values
+	body   []byte
+	err    error // First error that occurred while building the request
+}
+
+// WithHeader sets a request header.
+func (r *Request) WithHeader(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// WithQuery adds a URL query parameter, after those given in the path.
+func (r *Request) WithQuery(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// WithBody sets the raw request body and its content type.
+func (r *Request) WithBody(contentType string, body []byte) *Request {
+	r.header.Set("Content-Type", contentType)
+	r.body = body
+	return r
+}
+
+// WithJSON serializes the given value as the JSON request body.
+func (r *Request) WithJSON(obj interface{}) *Request {
+	body, err := json.Marshal(obj)
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+	return r.WithBody("application/json", body)
+}
+
+// WithJWT signs the given claims with the client's JWT secret and sets them as a Bearer token.
+func (r *Request) WithJWT(claims middleware.MapClaims) *Request {
+	token, err := middleware.SignJWT(claims, r.client.jwtSecret)
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+	return r.WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithBasicAuth sets the Authorization header for HTTP Basic authentication.
+func (r *Request) WithBasicAuth(username, password string) *Request {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return r.WithHeader("Authorization", "Basic "+credentials)
+}
+
+// WithAPIKey sets the x-api-key header checked by the API key middleware.
+func (r *Request) WithAPIKey(apiKey string) *Request {
+	return r.WithHeader("x-api-key", apiKey)
+}
+
+// Build creates the *http.Request without sending it.
+func (r *Request) Build() (*http.Request, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	target := r.path
+	if len(r.query) > 0 {
+		// Parameters of WithQuery are added to those of the path, if any
+		separator := "?"
+		if strings.Contains(target, "?") {
+			separator = "&"
+		}
+		target += separator + r.query.Encode()
+	}
+
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+
+	req := httptest.NewRequest(r.method, target, body)
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	return req, nil
+}
+
+// Do sends the request and returns the recorded response.
+func (r *Request) Do() (*httptest.ResponseRecorder, error) {
+	req, err := r.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	rec := httptest.NewRecorder()
+	r.client.handler.ServeHTTP(rec, req)
+	return rec, nil
+}
+
+// Expect sends the request and returns an Expectation for asserting on the response.
+// Building errors are reported through t and abort the test.
+func (r *Request) Expect(t testing.TB) *Expectation {
+	t.Helper()
+
+	rec, err := r.Do()
+	if err != nil {
+		t.Fatalf("servertest: failed to build %s %s: %v", r.method, r.path, err)
+	}
+
+	return &Expectation{
+		t:      t,
+		method: r.method,
+		path:   r.path,
+		rec:    rec,
+	}
+}
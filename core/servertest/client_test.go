@@ -0,0 +1,90 @@
+package servertest
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+type testJWTLookup struct{}
+
+func (l *testJWTLookup) LookupUserByJWT(claims middleware.MapClaims) (interface{}, error) {
+	return claims["sub"], nil
+}
+
+func TestClientJSONPath(t *testing.T) {
+	s := std.NewServer("8080", false)
+	s.GET("/users", func(c core.Context) {
+		c.JSON(http.StatusOK, map[string]interface{}{
+			"items": []map[string]interface{}{{"id": 1, "name": "john"}},
+			"query": c.Query("q"),
+		})
+	})
+
+	NewClient(s).GET("/users").
+		WithQuery("q", "jo").
+		Expect(t).
+		Status(http.StatusOK).
+		Header("Content-Type", "application/json").
+		JSONPath("$.items[0].id", 1).
+		JSONPath("$.items[0].name", "john").
+		JSONPath("$.query", "jo")
+}
+
+func TestClientWithQueryInPath(t *testing.T) {
+	req, err := NewClient(std.NewServer("8080", false)).GET("/users?page=2").WithQuery("q", "jo").Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	if got := req.URL.RawQuery; got != "page=2&q=jo" {
+		t.Errorf("query = %q, want %q", got, "page=2&q=jo")
+	}
+}
+
+func TestClientWithJWT(t *testing.T) {
+	s := std.NewServer("8080", false)
+	s.Use(middleware.NewDefaultJWTAuthMiddleware(&testJWTLookup{}, "secret"))
+	s.GET("/me", func(c core.Context) {
+		user, _ := middleware.GetUserFromContext(c.Request().Context())
+		c.String(http.StatusOK, "%v", user)
+	})
+
+	client := NewClient(s).WithJWTSecret("secret")
+	client.GET("/me").WithJWT(middleware.MapClaims{"sub": "42"}).Expect(t).Status(http.StatusOK).Body("42")
+	client.GET("/me").Expect(t).Status(http.StatusUnauthorized)
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	doc := map[string]interface{}{
+		"a": map[string]interface{}{"b": []interface{}{"x", "y"}},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected interface{}
+		wantErr  bool
+	}{
+		{"root", "$", doc, false},
+		{"nested index", "$.a.b[1]", "y", false},
+		{"missing key", "$.c", nil, true},
+		{"out of range", "$.a.b[2]", nil, true},
+		{"no root", "a.b", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lookupJSONPath(doc, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("lookupJSONPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("lookupJSONPath(%q) = %v, want %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package servertest
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// FakeClock is a core.Clock whose time only changes when it is set or advanced.
+// It is safe for concurrent use.
+//
+// Example usage:
+//
+//	clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	s, _ := server.NewServerBuilder(server.FrameworkGin, "8080").
+//		WithClock(clock).
+//		Build()
+//	clock.Advance(2 * time.Hour) // e.g. to expire a JWT
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set sets the current time of the clock.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// SequentialIDs returns an ID generator producing prefix-1, prefix-2, and so on.
+func SequentialIDs(prefix string) core.IDGenerator {
+	var n atomic.Int64
+	return core.IDGeneratorFunc(func() string {
+		return fmt.Sprintf("%s-%d", prefix, n.Add(1))
+	})
+}
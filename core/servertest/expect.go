@@ -0,0 +1,124 @@
+package servertest
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Expectation asserts on a recorded response.
+// Failed assertions are reported with t.Errorf, so all assertions in a chain are evaluated.
+type Expectation struct {
+	t      testing.TB
+	method string
+	path   string
+	rec    *httptest.ResponseRecorder
+
+	decoded   bool
+	jsonBody  interface{}
+	decodeErr error
+}
+
+// Status asserts that the response has the given status code.
+func (e *Expectation) Status(code int) *Expectation {
+	e.t.Helper()
+	if e.rec.Code != code {
+		e.t.Errorf("%s %s: status = %d, want %d (body: %s)", e.method, e.path, e.rec.Code, code, e.rec.Body.String())
+	}
+	return e
+}
+
+// Header asserts that the response header has the given value.
+func (e *Expectation) Header(key, value string) *Expectation {
+	e.t.Helper()
+	if got := e.rec.Header().Get(key); got != value {
+		e.t.Errorf("%s %s: header %s = %q, want %q", e.method, e.path, key, got, value)
+	}
+	return e
+}
+
+// Body asserts that the response body equals the given string.
+func (e *Expectation) Body(body string) *Expectation {
+	e.t.Helper()
+	if got := e.rec.Body.String(); got != body {
+		e.t.Errorf("%s %s: body = %q, want %q", e.method, e.path, got, body)
+	}
+	return e
+}
+
+// BodyContains asserts that the response body contains the given substring.
+func (e *Expectation) BodyContains(substr string) *Expectation {
+	e.t.Helper()
+	if got := e.rec.Body.String(); !strings.Contains(got, substr) {
+		e.t.Errorf("%s %s: body %q does not contain %q", e.method, e.path, got, substr)
+	}
+	return e
+}
+
+// JSONPath asserts that the value at the given path in the JSON response body equals expected.
+// Paths use a dotted notation with optional indexes, e.g. "$.items[0].id".
+// The expected value is compared after a JSON round trip, so numbers may be given as any numeric type.
+func (e *Expectation) JSONPath(path string, expected interface{}) *Expectation {
+	e.t.Helper()
+
+	doc, err := e.decode()
+	if err != nil {
+		e.t.Errorf("%s %s: response body is not valid JSON: %v", e.method, e.path, err)
+		return e
+	}
+
+	got, err := lookupJSONPath(doc, path)
+	if err != nil {
+		e.t.Errorf("%s %s: %v", e.method, e.path, err)
+		return e
+	}
+
+	want, err := normalizeJSON(expected)
+	if err != nil {
+		e.t.Errorf("%s %s: expected value for %s cannot be encoded as JSON: %v", e.method, e.path, path, err)
+		return e
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		e.t.Errorf("%s %s: %s = %v, want %v", e.method, e.path, path, got, want)
+	}
+	return e
+}
+
+// DecodeJSON decodes the JSON response body into obj.
+func (e *Expectation) DecodeJSON(obj interface{}) *Expectation {
+	e.t.Helper()
+	if err := json.Unmarshal(e.rec.Body.Bytes(), obj); err != nil {
+		e.t.Errorf("%s %s: failed to decode response body: %v", e.method, e.path, err)
+	}
+	return e
+}
+
+// Recorder returns the underlying response recorder.
+func (e *Expectation) Recorder() *httptest.ResponseRecorder {
+	return e.rec
+}
+
+// decode parses the response body as JSON once and caches the result.
+func (e *Expectation) decode() (interface{}, error) {
+	if !e.decoded {
+		e.decodeErr = json.Unmarshal(e.rec.Body.Bytes(), &e.jsonBody)
+		e.decoded = true
+	}
+	return e.jsonBody, e.decodeErr
+}
+
+// normalizeJSON converts a Go value to the representation produced by json.Unmarshal.
+func normalizeJSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
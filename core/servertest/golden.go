@@ -0,0 +1,142 @@
+package servertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// UpdateGoldenEnv is the environment variable that, when set to a non-empty value,
+// makes Golden rewrite golden files instead of comparing against them.
+//
+//	UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+// GoldenDir is the directory golden files are read from and written to,
+// relative to the package under test.
+var GoldenDir = filepath.Join("testdata", "golden")
+
+// Normalizer rewrites volatile parts of a response snapshot (timestamps, IDs, ...)
+// so that snapshots are stable between test runs.
+type Normalizer func(snapshot string) string
+
+// ReplacePattern returns a Normalizer that replaces all matches of the regular expression with replacement.
+func ReplacePattern(pattern, replacement string) Normalizer {
+	re := regexp.MustCompile(pattern)
+	return func(snapshot string) string {
+		return re.ReplaceAllString(snapshot, replacement)
+	}
+}
+
+// DefaultNormalizers returns the normalizers applied by Golden in addition to any custom ones.
+// They replace RFC 3339 timestamps, UUIDs and request ID header values.
+func DefaultNormalizers() []Normalizer {
+	return []Normalizer{
+		ReplacePattern(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`, "<timestamp>"),
+		ReplacePattern(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`, "<uuid>"),
+		ReplacePattern(`(?m)^(X-Request-Id: ).*$`, "${1}<request-id>"),
+	}
+}
+
+// ignoredGoldenHeaders are response headers that are never part of a snapshot.
+var ignoredGoldenHeaders = map[string]bool{
+	"Date":           true,
+	"Content-Length": true,
+}
+
+// Golden compares the response against the golden file with the given name.
+// The snapshot contains the status code, the response headers and the body
+// (indented if it is JSON). If UpdateGoldenEnv is set, the golden file is written instead.
+func (e *Expectation) Golden(name string, normalizers ...Normalizer) *Expectation {
+	e.t.Helper()
+
+	snapshot := e.snapshot()
+	for _, normalize := range append(DefaultNormalizers(), normalizers...) {
+		snapshot = normalize(snapshot)
+	}
+
+	path := filepath.Join(GoldenDir, name+".golden")
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			e.t.Fatalf("servertest: failed to create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(snapshot), 0o644); err != nil {
+			e.t.Fatalf("servertest: failed to write golden file %s: %v", path, err)
+		}
+		return e
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		e.t.Errorf("servertest: failed to read golden file %s (run with %s=1 to create it): %v", path, UpdateGoldenEnv, err)
+		return e
+	}
+
+	if diff := diffLines(string(want), snapshot); diff != "" {
+		e.t.Errorf("%s %s: response does not match golden file %s:\n%s", e.method, e.path, path, diff)
+	}
+	return e
+}
+
+// snapshot renders the recorded response as text.
+func (e *Expectation) snapshot() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d\n", e.rec.Code)
+
+	header := e.rec.Header()
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		if !ignoredGoldenHeaders[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			fmt.Fprintf(&sb, "%s: %s\n", key, value)
+		}
+	}
+	sb.WriteString("\n")
+
+	body := e.rec.Body.Bytes()
+	var indented bytes.Buffer
+	if json.Valid(body) && json.Indent(&indented, body, "", "  ") == nil {
+		body = indented.Bytes()
+	}
+	sb.Write(bytes.TrimRight(body, "\n"))
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// diffLines returns a line-by-line description of the differences between want and got,
+// or an empty string if they are equal.
+func diffLines(want, got string) string {
+	if want == got {
+		return ""
+	}
+
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	var sb strings.Builder
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			fmt.Fprintf(&sb, "line %d:\n  - %s\n  + %s\n", i+1, w, g)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,37 @@
+package servertest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+func TestGolden(t *testing.T) {
+	original := GoldenDir
+	GoldenDir = t.TempDir()
+	defer func() { GoldenDir = original }()
+
+	s := std.NewServer("8080", false)
+	s.GET("/now", func(c core.Context) {
+		c.JSON(http.StatusOK, map[string]string{"now": time.Now().Format(time.RFC3339Nano)})
+	})
+	client := NewClient(s)
+
+	t.Setenv(UpdateGoldenEnv, "1")
+	client.GET("/now").Expect(t).Golden("now")
+
+	t.Setenv(UpdateGoldenEnv, "")
+	client.GET("/now").Expect(t).Status(http.StatusOK).Golden("now")
+}
+
+func TestDiffLines(t *testing.T) {
+	if diff := diffLines("a\nb\n", "a\nb\n"); diff != "" {
+		t.Errorf("diffLines() on equal input = %q, want empty", diff)
+	}
+	if diff := diffLines("a\nb\n", "a\nc\n"); diff != "line 2:\n  - b\n  + c\n" {
+		t.Errorf("diffLines() = %q", diff)
+	}
+}
@@ -0,0 +1,66 @@
+package servertest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lookupJSONPath returns the value at the given path in a decoded JSON document.
+// Supported syntax is a subset of JSONPath: "$", ".key" and "[index]" segments.
+func lookupJSONPath(doc interface{}, path string) (interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid JSON path %q: must start with $", path)
+	}
+
+	current := doc
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			rest = rest[end:]
+			if key == "" {
+				return nil, fmt.Errorf("invalid JSON path %q: empty key", path)
+			}
+
+			obj, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("JSON path %q: cannot read key %q from %T", path, key, current)
+			}
+			value, exists := obj[key]
+			if !exists {
+				return nil, fmt.Errorf("JSON path %q: key %q not found", path, key)
+			}
+			current = value
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid JSON path %q: missing ]", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSON path %q: bad index %q", path, rest[1:end])
+			}
+			rest = rest[end+1:]
+
+			arr, ok := current.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("JSON path %q: cannot index %T", path, current)
+			}
+			if index < 0 || index >= len(arr) {
+				return nil, fmt.Errorf("JSON path %q: index %d out of range (len %d)", path, index, len(arr))
+			}
+			current = arr[index]
+		default:
+			return nil, fmt.Errorf("invalid JSON path %q: unexpected %q", path, rest[0])
+		}
+	}
+
+	return current, nil
+}
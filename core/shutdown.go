@@ -0,0 +1,54 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ShutdownSignals are the signals that make RunWithGracefulShutdown shut the server down.
+var ShutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// RunWithGracefulShutdown calls run and waits until ctx is done or the process receives one of
+// the ShutdownSignals. It then calls shutdown, which stops accepting new connections and waits
+// up to timeout for in-flight requests; a timeout of zero or less waits without limit.
+// A second signal during the shutdown terminates the process.
+// It returns nil after a graceful shutdown, the error of run if the server stopped by itself,
+// and the error of shutdown if in-flight requests did not finish in time.
+// It is used by the RunWithGracefulShutdown implementations.
+func RunWithGracefulShutdown(ctx context.Context, timeout time.Duration, run func() error, shutdown func(context.Context) error) error {
+	ctx, stop := signal.NotifyContext(ctx, ShutdownSignals...)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- run()
+	}()
+
+	select {
+	case err := <-errc:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+	// Restore the default signal handling, so that a second signal terminates the process
+	stop()
+
+	shutdownCtx := context.WithoutCancel(ctx)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, timeout)
+		defer cancel()
+	}
+	err := shutdown(shutdownCtx)
+	if runErr := <-errc; err == nil && !errors.Is(runErr, http.ErrServerClosed) {
+		err = runErr
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRunWithGracefulShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	closed := make(chan struct{})
+	var deadline bool
+	run := func() error {
+		cancel()
+		<-closed
+		return http.ErrServerClosed
+	}
+	shutdown := func(ctx context.Context) error {
+		_, deadline = ctx.Deadline()
+		close(closed)
+		return nil
+	}
+
+	if err := RunWithGracefulShutdown(ctx, time.Second, run, shutdown); err != nil {
+		t.Fatalf("RunWithGracefulShutdown() returned error: %v", err)
+	}
+	if !deadline {
+		t.Error("shutdown context has no deadline")
+	}
+}
+
+func TestRunWithGracefulShutdownRunError(t *testing.T) {
+	errListen := errors.New("address already in use")
+	shutdown := func(ctx context.Context) error {
+		t.Error("shutdown called after run failed")
+		return nil
+	}
+
+	err := RunWithGracefulShutdown(context.Background(), time.Second, func() error { return errListen }, shutdown)
+	if !errors.Is(err, errListen) {
+		t.Errorf("RunWithGracefulShutdown() error = %v, want %v", err, errListen)
+	}
+}
+
+func TestRunWithGracefulShutdownTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	closed := make(chan struct{})
+	run := func() error {
+		<-closed
+		return http.ErrServerClosed
+	}
+	shutdown := func(ctx context.Context) error {
+		defer close(closed)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	err := RunWithGracefulShutdown(ctx, 10*time.Millisecond, run, shutdown)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RunWithGracefulShutdown() error = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,32 @@
+package core
+
+// Names of the default middleware registered by the server builder, as passed to Server.UseNamed.
+const (
+	MiddlewareDebugTrace     = "DebugTrace"
+	MiddlewareClock          = "Clock"
+	MiddlewareOpenTelemetry  = "OpenTelemetry"
+	MiddlewareRequestID      = "RequestID"
+	MiddlewareTemplates      = "Templates"
+	MiddlewareErrorHandler   = "ErrorHandler"
+	MiddlewareWatchdog       = "Watchdog"
+	MiddlewareHeaderLimit    = "HeaderLimit"
+	MiddlewareBodyLimit      = "BodyLimit"
+	MiddlewareQueryLimit     = "QueryLimit"
+	MiddlewareResponseLimit  = "ResponseLimit"
+	MiddlewareTimeout        = "Timeout"
+	MiddlewareCORS           = "CORS"
+	MiddlewareCachePolicy    = "CachePolicy"
+	MiddlewareResponseDigest = "ResponseDigest"
+	MiddlewareLogging        = "Logging"
+	MiddlewareAuth           = "Auth"
+	MiddlewarePolicy         = "Policy"
+	MiddlewareOutbox         = "Outbox"
+)
+
+// MiddlewareSkipper is an optional interface for controllers whose route opts out of individual
+// middleware registered by the server builder, e.g. a streaming endpoint that must not time out.
+// Custom middleware added with a name can be skipped the same way.
+type MiddlewareSkipper interface {
+	// SkipMiddleware returns the names of the middleware to skip for the route, e.g. MiddlewareTimeout
+	SkipMiddleware() []string
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// StaticFilepathParam is the name of the wildcard parameter of static file routes,
+// e.g. "/assets/*filepath" for Static("/assets", dir).
+const StaticFilepathParam = "filepath"
+
+// NewStaticFileSystem returns the http.FileSystem served by Static and StaticFS.
+// Directories are served only through their index.html; opening a directory without one fails
+// with fs.ErrNotExist, so directory listings are never served.
+// embed.FS values include their top directory, e.g. "dist/index.html"; use fs.Sub to serve its contents.
+func NewStaticFileSystem(fsys fs.FS) http.FileSystem {
+	return staticFileSystem{fs: http.FS(fsys)}
+}
+
+// staticFileSystem is an http.FileSystem that hides directories without an index.html.
+type staticFileSystem struct {
+	fs http.FileSystem
+}
+
+// Open opens name, or fails with fs.ErrNotExist if it is a directory without an index.html.
+func (s staticFileSystem) Open(name string) (http.File, error) {
+	f, err := s.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		index, err := s.fs.Open(path.Join(name, "index.html"))
+		if err != nil {
+			f.Close()
+			return nil, fs.ErrNotExist
+		}
+		index.Close()
+	}
+	return f, nil
+}
+
+// StaticRoute returns the route path serving the files below prefix, e.g. "/assets/*filepath"
+// for "/assets" and "/*filepath" for "/".
+func StaticRoute(prefix string) string {
+	return strings.TrimSuffix(prefix, "/") + "/*" + StaticFilepathParam
+}
@@ -0,0 +1,67 @@
+package std
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// SetEngineOptions implements core.Server.SetEngineOptions for Server.
+func (s *Server) SetEngineOptions(options *core.EngineOptions) {
+	if options == nil {
+		options = &core.EngineOptions{}
+	}
+	s.config.Options = *options
+}
+
+// routePath returns the path of r used for routing, and whether parameter values matched
+// against it must be unescaped.
+func (s *Server) routePath(r *http.Request) (string, bool) {
+	p, unescape := r.URL.Path, false
+	if s.config.Options.UseRawPath && r.URL.RawPath != "" {
+		p, unescape = r.URL.RawPath, true
+	}
+	if s.config.Options.RemoveExtraSlash {
+		p = cleanPath(p)
+	}
+	return p, unescape
+}
+
+// cleanPath removes repeated slashes and "." and ".." segments from p, keeping a trailing slash.
+// Paths that are already clean are returned without allocating.
+func cleanPath(p string) string {
+	if !strings.Contains(p, "//") && !strings.Contains(p, "/.") {
+		return p
+	}
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// unescapeParams unescapes parameter values matched against an escaped path.
+// Values that are not valid escapes are kept as they are.
+func unescapeParams(params []routeParam) {
+	for i := range params {
+		if !strings.Contains(params[i].Value, "%") {
+			continue
+		}
+		if value, err := url.PathUnescape(params[i].Value); err == nil {
+			params[i].Value = value
+		}
+	}
+}
+
+// SetTrustedProxies implements core.Server.SetTrustedProxies for Server.
+func (s *Server) SetTrustedProxies(proxies []string) error {
+	trusted, err := core.ParseTrustedProxies(proxies...)
+	if err != nil {
+		return err
+	}
+	s.config.TrustedProxies = trusted
+	return nil
+}
@@ -0,0 +1,117 @@
+package std
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// routeOverlap describes how two route paths relate.
+type routeOverlap int
+
+const (
+	// overlapNone means no request path can match both routes
+	overlapNone routeOverlap = iota
+	// overlapStatic means both routes can match a request path; the one with static segments takes precedence
+	overlapStatic
+	// overlapSame means both routes match exactly the same request paths
+	overlapSame
+)
+
+// isWildcardSegment reports whether segment is a ":name" parameter or "*name" wildcard.
+func isWildcardSegment(segment string) bool {
+	return strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*")
+}
+
+// compareRoutes returns how the paths a and b overlap. If both have a parameter at the same position
+// under different names, it returns the two segments, which makes Param ambiguous.
+func compareRoutes(a, b string) (overlap routeOverlap, nameA, nameB string) {
+	segmentsA := strings.Split(a, "/")
+	segmentsB := strings.Split(b, "/")
+	overlap = overlapSame
+	for i := 0; i < len(segmentsA) && i < len(segmentsB); i++ {
+		sa, sb := segmentsA[i], segmentsB[i]
+		wildA, wildB := isWildcardSegment(sa), isWildcardSegment(sb)
+		switch {
+		case !wildA && !wildB:
+			if sa != sb {
+				return overlapNone, "", ""
+			}
+		case wildA && wildB:
+			if sa != sb {
+				return overlap, sa, sb
+			}
+		default:
+			overlap = overlapStatic
+		}
+		// A trailing wildcard matches the rest of the path
+		if strings.HasPrefix(sa, "*") || strings.HasPrefix(sb, "*") {
+			if len(segmentsA) != len(segmentsB) || sa != sb {
+				overlap = overlapStatic
+			}
+			return overlap, "", ""
+		}
+	}
+	if len(segmentsA) != len(segmentsB) {
+		return overlapNone, "", ""
+	}
+	return overlap, "", ""
+}
+
+// checkRoute returns an error wrapping core.ErrRouteConflict if method and path conflict with
+// a registered route. Routes that overlap with a static route are reported as a warning.
+func (s *Server) checkRoute(method, path string) error {
+	existing := make([]string, 0, len(s.routes[method]))
+	for registered := range s.routes[method] {
+		existing = append(existing, registered)
+	}
+	sort.Strings(existing)
+
+	for _, registered := range existing {
+		if registered == path {
+			return fmt.Errorf("%w: %s %s is already registered", core.ErrRouteConflict, method, path)
+		}
+		overlap, nameA, nameB := compareRoutes(path, registered)
+		if nameA != "" {
+			return fmt.Errorf("%w: parameter %s in %s %s conflicts with %s in %s %s; use the same name at the same position",
+				core.ErrRouteConflict, nameA, method, path, nameB, method, registered)
+		}
+		switch overlap {
+		case overlapSame:
+			return fmt.Errorf("%w: %s %s matches the same requests as %s %s", core.ErrRouteConflict, method, path, method, registered)
+		case overlapStatic:
+			log.Printf("[WARNING] route %s %s overlaps %s %s; requests matching both are served by the route with more static segments", method, path, method, registered)
+		}
+	}
+	return nil
+}
+
+// handle registers handlers for method and path in the route tree of method.
+func (s *Server) handle(method, path string, handlers []core.HandlerFunc) {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	s.checkNotFrozen(method + " " + path)
+	if err := s.checkRoute(method, path); err != nil {
+		panic(err)
+	}
+
+	if s.routes == nil {
+		s.routes = make(map[string]map[string][]core.HandlerFunc)
+	}
+	if s.routes[method] == nil {
+		s.routes[method] = make(map[string][]core.HandlerFunc)
+	}
+	s.routes[method][path] = handlers
+
+	if s.trees[method] == nil {
+		s.trees[method] = &node{}
+	}
+	// The guard counts the in-flight requests of the route for DisableRoute
+	guarded := make([]core.HandlerFunc, 0, len(handlers)+1)
+	guarded = append(guarded, s.drainer.Guard(method, path))
+	s.trees[method].insert(path, append(guarded, handlers...))
+}
@@ -3,217 +3,49 @@ package std
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"reflect"
-	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/bench"
+	"github.com/mythofleader/go-http-server/core/dynamic"
+	"github.com/mythofleader/go-http-server/core/internal/nethttp"
 	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
 )
 
 // Context is an implementation of core.Context using the standard net/http package.
-type Context struct {
-	req        *http.Request
-	writer     http.ResponseWriter
-	params     map[string]string
-	queryCache map[string]string
-	errs       []error                // Errors that occurred during request processing
-	keys       map[string]interface{} // Key-value store for context data
-	mu         sync.RWMutex           // Mutex to protect concurrent access to keys
-
-	// Fields for middleware flow control
-	handlers     []core.HandlerFunc // All handlers (middleware + route handlers)
-	index        int                // Current handler index
-	handlerCount int                // Total number of handlers
-}
-
-// Request implements core.Context.Request
-func (c *Context) Request() *http.Request {
-	return c.req
-}
-
-// Writer implements core.Context.Writer
-func (c *Context) Writer() http.ResponseWriter {
-	return c.writer
-}
-
-// Param implements core.Context.Param
-func (c *Context) Param(key string) string {
-	return c.params[key]
-}
-
-// Query implements core.Context.Query
-func (c *Context) Query(key string) string {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if c.queryCache == nil {
-		c.queryCache = make(map[string]string)
-	}
-	if val, ok := c.queryCache[key]; ok {
-		return val
-	}
-	val := c.req.URL.Query().Get(key)
-	c.queryCache[key] = val
-	return val
-}
-
-// DefaultQuery implements core.Context.DefaultQuery
-func (c *Context) DefaultQuery(key, defaultValue string) string {
-	val := c.Query(key)
-	if val == "" {
-		return defaultValue
-	}
-	return val
-}
-
-// GetHeader implements core.Context.GetHeader
-func (c *Context) GetHeader(key string) string {
-	return c.req.Header.Get(key)
-}
-
-// SetHeader implements core.Context.SetHeader
-func (c *Context) SetHeader(key, value string) {
-	c.writer.Header().Set(key, value)
-}
-
-// SetStatus implements core.Context.SetStatus
-func (c *Context) SetStatus(code int) {
-	c.writer.WriteHeader(code)
-}
-
-// JSON implements core.Context.JSON
-func (c *Context) JSON(code int, obj interface{}) {
-	c.SetHeader("Content-Type", "application/json")
-	c.SetStatus(code)
-	// Use a JSON encoder to write the response
-	if err := json.NewEncoder(c.writer).Encode(obj); err != nil {
-		http.Error(c.writer, err.Error(), http.StatusInternalServerError)
-	}
-}
-
-// String implements core.Context.String
-func (c *Context) String(code int, format string, values ...interface{}) {
-	c.SetHeader("Content-Type", "text/plain")
-	c.SetStatus(code)
-	fmt.Fprintf(c.writer, format, values...)
-}
-
-// Bind implements core.Context.Bind
-func (c *Context) Bind(obj interface{}) error {
-	// This is a simplified implementation
-	contentType := c.GetHeader("Content-Type")
-	if contentType == "application/json" {
-		return c.BindJSON(obj)
-	}
-	return errors.New("unsupported content type")
-}
-
-// BindJSON implements core.Context.BindJSON
-func (c *Context) BindJSON(obj interface{}) error {
-	return json.NewDecoder(c.req.Body).Decode(obj)
-}
-
-// ShouldBindJSON implements core.Context.ShouldBindJSON
-func (c *Context) ShouldBindJSON(obj interface{}) error {
-	return json.NewDecoder(c.req.Body).Decode(obj)
-}
-
-// File implements core.Context.File
-func (c *Context) File(filepath string) {
-	http.ServeFile(c.writer, c.req, filepath)
-}
-
-// Redirect implements core.Context.Redirect
-func (c *Context) Redirect(code int, location string) {
-	http.Redirect(c.writer, c.req, location, code)
-}
-
-// Error implements core.Context.Error
-// Since the standard HTTP package doesn't have a built-in error handling mechanism,
-// this implementation stores the error in the context and returns it.
-func (c *Context) Error(err error) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	// Initialize the errs slice if it's nil
-	if c.errs == nil {
-		c.errs = make([]error, 0)
-	}
-
-	// Add the error to the errs slice
-	c.errs = append(c.errs, err)
-
-	// Return the error
-	return err
-}
-
-// Errors implements core.Context.Errors
-// It returns all errors added to the context.
-func (c *Context) Errors() []error {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	return c.errs
-}
-
-// Next implements core.Context.Next
-// It calls the next handler in the chain.
-func (c *Context) Next() {
-	c.index++
-	for c.index < c.handlerCount {
-		c.handlers[c.index](c)
-		c.index++
-	}
-}
-
-// Abort implements core.Context.Abort
-// It prevents pending handlers in the chain from being called.
-func (c *Context) Abort() {
-	c.index = c.handlerCount
-}
-
-// Get implements core.Context.Get
-// It returns the value for the given key and a boolean indicating whether the key exists.
-func (c *Context) Get(key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	if c.keys == nil {
-		return nil, false
-	}
-	value, exists := c.keys[key]
-	return value, exists
-}
-
-// Set implements core.Context.Set
-// It stores a value in the context for the given key.
-func (c *Context) Set(key string, value interface{}) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if c.keys == nil {
-		c.keys = make(map[string]interface{})
-	}
-	c.keys[key] = value
-}
+// It is shared with the chi and fiber adapters.
+type Context = nethttp.Context
 
 // Server is an implementation of core.Server using the standard net/http package.
 type Server struct {
-	mux              *http.ServeMux
+	trees            map[string]*node // method -> route tree
 	server           *http.Server
+	serverMu         sync.Mutex                               // Guards server and closed
+	closed           bool                                     // Set by Stop and Shutdown; Run and RunTLS then return http.ErrServerClosed
 	routes           map[string]map[string][]core.HandlerFunc // method -> path -> handlers
 	middleware       []core.HandlerFunc
 	port             string
-	middlewareLog    []string           // Track middleware names for logging
-	noRouteHandlers  []core.HandlerFunc // Handlers for 404 Not Found errors
-	noMethodHandlers []core.HandlerFunc // Handlers for 405 Method Not Allowed errors
-	showLogs         bool               // Controls whether framework logs are shown
+	middlewareLog    []core.NamedHandler    // Track middleware for logging
+	middlewareChain  core.MiddlewareLog     // Describes the middleware of the server and its groups
+	noRouteHandlers  []core.HandlerFunc     // Handlers for 404 Not Found errors
+	noMethodHandlers []core.HandlerFunc     // Handlers for 405 Method Not Allowed errors
+	showLogs         bool                   // Controls whether framework logs are shown
+	frozen           atomic.Bool            // Set once the route table is sealed
+	freezeOnce       sync.Once              // Builds the handler chains when the server is frozen
+	dynamic          core.DynamicRouter     // Dynamic router, nil unless dynamic routing is enabled
+	lifecycle        core.Lifecycle         // Start and stop hooks
+	httpConfig       *core.HTTPServerConfig // Settings of the http.Server, nil for the defaults
+	fastPath         *core.FastPathConfig   // Health check paths answered before the middleware chain
+	config           nethttp.Config         // Router and request parsing settings, and trusted proxies
+	drainer          core.RouteDrainer      // In-flight requests of each route, for DisableRoute
+	lambdaConfig     core.LambdaConfig      // Event type of StartLambda
 }
 
 // GetLoggingMiddleware returns a standard HTTP-specific logging middleware.
@@ -228,62 +60,27 @@ func (s *Server) GetErrorHandlerMiddleware() core.IErrorHandlerMiddleware {
 
 // GET implements core.Server.GET for Server
 func (s *Server) GET(path string, handlers ...core.HandlerFunc) {
-	if s.routes == nil {
-		s.routes = make(map[string]map[string][]core.HandlerFunc)
-	}
-	if s.routes["GET"] == nil {
-		s.routes["GET"] = make(map[string][]core.HandlerFunc)
-	}
-	s.routes["GET"][path] = handlers
-	s.mux.HandleFunc(path, s.handleHTTP("GET", path))
+	s.handle("GET", path, handlers)
 }
 
 // POST implements core.Server.POST for Server
 func (s *Server) POST(path string, handlers ...core.HandlerFunc) {
-	if s.routes == nil {
-		s.routes = make(map[string]map[string][]core.HandlerFunc)
-	}
-	if s.routes["POST"] == nil {
-		s.routes["POST"] = make(map[string][]core.HandlerFunc)
-	}
-	s.routes["POST"][path] = handlers
-	s.mux.HandleFunc(path, s.handleHTTP("POST", path))
+	s.handle("POST", path, handlers)
 }
 
 // PUT implements core.Server.PUT for Server
 func (s *Server) PUT(path string, handlers ...core.HandlerFunc) {
-	if s.routes == nil {
-		s.routes = make(map[string]map[string][]core.HandlerFunc)
-	}
-	if s.routes["PUT"] == nil {
-		s.routes["PUT"] = make(map[string][]core.HandlerFunc)
-	}
-	s.routes["PUT"][path] = handlers
-	s.mux.HandleFunc(path, s.handleHTTP("PUT", path))
+	s.handle("PUT", path, handlers)
 }
 
 // DELETE implements core.Server.DELETE for Server
 func (s *Server) DELETE(path string, handlers ...core.HandlerFunc) {
-	if s.routes == nil {
-		s.routes = make(map[string]map[string][]core.HandlerFunc)
-	}
-	if s.routes["DELETE"] == nil {
-		s.routes["DELETE"] = make(map[string][]core.HandlerFunc)
-	}
-	s.routes["DELETE"][path] = handlers
-	s.mux.HandleFunc(path, s.handleHTTP("DELETE", path))
+	s.handle("DELETE", path, handlers)
 }
 
 // PATCH implements core.Server.PATCH for Server
 func (s *Server) PATCH(path string, handlers ...core.HandlerFunc) {
-	if s.routes == nil {
-		s.routes = make(map[string]map[string][]core.HandlerFunc)
-	}
-	if s.routes["PATCH"] == nil {
-		s.routes["PATCH"] = make(map[string][]core.HandlerFunc)
-	}
-	s.routes["PATCH"][path] = handlers
-	s.mux.HandleFunc(path, s.handleHTTP("PATCH", path))
+	s.handle("PATCH", path, handlers)
 }
 
 // Group implements core.Server.Group for Server
@@ -297,18 +94,34 @@ func (s *Server) Group(path string) core.RouterGroup {
 // Use implements core.Server.Use for Server
 func (s *Server) Use(middleware ...core.HandlerFunc) {
 	for _, m := range middleware {
-		// Get the function name for logging
-		funcValue := reflect.ValueOf(m)
-		middlewareName := runtime.FuncForPC(funcValue.Pointer()).Name()
-		s.middlewareLog = append(s.middlewareLog, middlewareName)
+		s.UseNamed("", m)
+	}
+}
 
-		// Log middleware addition if showLogs is true
-		if s.showLogs {
-			log.Printf("[STD] Adding middleware: %s", middlewareName)
-		}
+// UseNamed implements core.Server.UseNamed for Server
+// If name is empty, the function name is resolved only when framework logs are shown.
+func (s *Server) UseNamed(name string, middleware core.HandlerFunc) {
+	s.UseDescribed(core.MiddlewareDescription{Name: name}, middleware)
+}
+
+// UseDescribed implements core.Server.UseDescribed for Server
+func (s *Server) UseDescribed(description core.MiddlewareDescription, middleware core.HandlerFunc) {
+	s.checkNotFrozen("middleware")
+	named := core.NamedHandler{Name: description.Name, Handler: middleware}
+	s.middlewareLog = append(s.middlewareLog, named)
+	s.middlewareChain.Add(description, middleware)
+
+	// Log middleware addition if showLogs is true
+	if s.showLogs {
+		log.Printf("[STD] Adding middleware: %s", named.DisplayName())
 	}
 
-	s.middleware = append(s.middleware, middleware...)
+	s.middleware = append(s.middleware, middleware)
+}
+
+// DescribeMiddleware implements core.Server.DescribeMiddleware for Server
+func (s *Server) DescribeMiddleware() core.MiddlewareChain {
+	return s.middlewareChain.Chain()
 }
 
 // RegisterRouter implements core.Server.RegisterRouter
@@ -317,7 +130,7 @@ func (s *Server) RegisterRouter(controllers ...core.Controller) {
 		// Get HTTP method, path, and handlers from the controller
 		method := controller.GetHttpMethod()
 		path := controller.GetPath()
-		handlers := controller.Handler()
+		handlers := core.ControllerHandlers(controller)
 
 		// Register the route based on the HTTP method
 		switch method {
@@ -341,8 +154,21 @@ func (s *Server) RegisterRouter(controllers ...core.Controller) {
 	}
 }
 
+// DisableRoute implements core.Server.DisableRoute for Server
+func (s *Server) DisableRoute(method core.HttpMethod, path string, status ...int) (*core.RouteDrain, error) {
+	code := 0
+	if len(status) > 0 {
+		code = status[0]
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return s.drainer.Disable(string(method), path, code)
+}
+
 // NoRoute implements core.Server.NoRoute
 func (s *Server) NoRoute(handlers ...core.HandlerFunc) {
+	s.checkNotFrozen("NoRoute handlers")
 	// If no handlers are provided, use default handler
 	if len(handlers) == 0 {
 		// Default handler returns a 404 Not Found error
@@ -366,6 +192,7 @@ func (s *Server) NoRoute(handlers ...core.HandlerFunc) {
 
 // NoMethod implements core.Server.NoMethod
 func (s *Server) NoMethod(handlers ...core.HandlerFunc) {
+	s.checkNotFrozen("NoMethod handlers")
 	// If no handlers are provided, use default handler
 	if len(handlers) == 0 {
 		// Default handler returns a 405 Method Not Allowed error
@@ -390,6 +217,11 @@ func (s *Server) NoMethod(handlers ...core.HandlerFunc) {
 
 // Run implements core.Server.Run for Server
 func (s *Server) Run() error {
+	s.Freeze()
+	if err := s.lifecycle.Start(context.Background()); err != nil {
+		return err
+	}
+
 	addr := ":" + s.port
 
 	// Log server information if showLogs is true
@@ -401,7 +233,7 @@ func (s *Server) Run() error {
 		if len(s.middlewareLog) > 0 {
 			log.Println("[STD] Middleware registered:")
 			for i, middleware := range s.middlewareLog {
-				log.Printf("[STD]   %d. %s", i+1, middleware)
+				log.Printf("[STD]   %d. %s", i+1, middleware.DisplayName())
 			}
 		} else {
 			log.Println("[STD] No middleware registered")
@@ -429,37 +261,74 @@ func (s *Server) Run() error {
 		log.Printf("[STD] Server is ready to handle requests")
 	}
 
-	s.server = &http.Server{
-		Addr:    addr,
-		Handler: s.mux,
+	srv, err := s.newHTTPServer(addr)
+	if err != nil {
+		return err
 	}
-
-	return s.server.ListenAndServe()
+	return srv.ListenAndServe()
 }
 
 // RunTLS implements core.Server.RunTLS for Server
 func (s *Server) RunTLS(addr, certFile, keyFile string) error {
-	s.server = &http.Server{
-		Addr:    addr,
-		Handler: s.mux,
+	s.Freeze()
+	if err := s.lifecycle.Start(context.Background()); err != nil {
+		return err
+	}
+
+	srv, err := s.newHTTPServer(addr)
+	if err != nil {
+		return err
 	}
-	return s.server.ListenAndServeTLS(certFile, keyFile)
+	return srv.ListenAndServeTLS(certFile, keyFile)
+}
+
+// newHTTPServer creates the http.Server of Run and RunTLS. It returns http.ErrServerClosed
+// if the server has been stopped before it started, e.g. by a shutdown signal during start hooks.
+func (s *Server) newHTTPServer(addr string) (*http.Server, error) {
+	s.serverMu.Lock()
+	defer s.serverMu.Unlock()
+	if s.closed {
+		return nil, http.ErrServerClosed
+	}
+	s.server = core.NewHTTPServer(addr, s.Handler(), s.httpConfig)
+	return s.server, nil
+}
+
+// closeHTTPServer marks the server as stopped and returns its http.Server, nil if it has not started.
+func (s *Server) closeHTTPServer() *http.Server {
+	s.serverMu.Lock()
+	defer s.serverMu.Unlock()
+	s.closed = true
+	return s.server
 }
 
 // Stop implements core.Server.Stop for Server
 func (s *Server) Stop() error {
-	if s.server == nil {
-		return nil
+	var err error
+	if srv := s.closeHTTPServer(); srv != nil {
+		err = srv.Close()
 	}
-	return s.server.Close()
+	if hookErr := s.lifecycle.Stop(context.Background()); err == nil {
+		err = hookErr
+	}
+	return err
+}
+
+// RunWithGracefulShutdown implements core.Server.RunWithGracefulShutdown for Server
+func (s *Server) RunWithGracefulShutdown(ctx context.Context, timeout time.Duration) error {
+	return core.RunWithGracefulShutdown(ctx, timeout, s.Run, s.Shutdown)
 }
 
 // Shutdown implements core.Server.Shutdown for Server
 func (s *Server) Shutdown(ctx context.Context) error {
-	if s.server == nil {
-		return nil
+	var err error
+	if srv := s.closeHTTPServer(); srv != nil {
+		err = srv.Shutdown(ctx)
+	}
+	if hookErr := s.lifecycle.Stop(ctx); err == nil {
+		err = hookErr
 	}
-	return s.server.Shutdown(ctx)
+	return err
 }
 
 // GetPort implements core.Server.GetPort for Server
@@ -467,9 +336,146 @@ func (s *Server) GetPort() string {
 	return s.port
 }
 
+// Freeze implements core.Server.Freeze for Server
+// Since the middleware and routes no longer change, the handler chain of every route is built once here.
+func (s *Server) Freeze() {
+	s.freezeOnce.Do(func() {
+		s.frozen.Store(true)
+		for _, root := range s.trees {
+			root.compile(s.middleware)
+		}
+	})
+}
+
+// Frozen implements core.Server.Frozen for Server
+func (s *Server) Frozen() bool {
+	return s.frozen.Load()
+}
+
+// OnStart implements core.Server.OnStart for Server
+func (s *Server) OnStart(hook core.LifecycleHook) {
+	s.lifecycle.OnStart(hook)
+}
+
+// OnStop implements core.Server.OnStop for Server
+func (s *Server) OnStop(hook core.LifecycleHook) {
+	s.lifecycle.OnStop(hook)
+}
+
+// SetHTTPServerConfig implements core.Server.SetHTTPServerConfig for Server
+func (s *Server) SetHTTPServerConfig(config *core.HTTPServerConfig) {
+	s.httpConfig = config
+}
+
+// SetFastPath implements core.Server.SetFastPath for Server
+func (s *Server) SetFastPath(config *core.FastPathConfig) {
+	s.fastPath = config
+}
+
+// checkNotFrozen panics with an error wrapping core.ErrServerFrozen if the server has been frozen.
+func (s *Server) checkNotFrozen(what string) {
+	if s.frozen.Load() {
+		panic(fmt.Errorf("%w: cannot register %s", core.ErrServerFrozen, what))
+	}
+}
+
+// Handler implements core.Server.Handler for Server
+func (s *Server) Handler() http.Handler {
+	return core.FastPathHandler(s, s.fastPath)
+}
+
+// ServeHTTP implements http.Handler for Server
+// Requests are matched against the route tree of their method. If the path matches a route of
+// another method, the NoMethod handlers run; if it matches no route, dynamic routes, if enabled,
+// and then the NoRoute handlers are tried.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := &Context{}
+	ctx.Init(&s.config, w, r)
+	path, unescape := s.routePath(r)
+
+	if root := s.trees[r.Method]; root != nil {
+		if n, params := root.lookup(path, ctx.ParamBuffer()); n != nil {
+			if unescape {
+				unescapeParams(params)
+			}
+			ctx.SetParams(params)
+			s.serveRoute(ctx, n)
+			return
+		}
+	}
+
+	for method, root := range s.trees {
+		if method == r.Method {
+			continue
+		}
+		if n, params := root.lookup(path, ctx.ParamBuffer()); n != nil {
+			ctx.SetParams(params)
+			s.serveMethodNotAllowed(ctx, n.route)
+			return
+		}
+	}
+
+	s.serveNotFound(w, r)
+}
+
+// serveNotFound runs the middleware chain for a request matching no route, followed by the matching
+// dynamic route if dynamic routing is enabled, and otherwise the NoRoute handlers, or a plain
+// 404 Not Found if there are none.
+func (s *Server) serveNotFound(w http.ResponseWriter, r *http.Request) {
+	allHandlers := make([]core.HandlerFunc, 0, len(s.middleware)+len(s.noRouteHandlers)+1)
+	allHandlers = append(allHandlers, s.middleware...)
+	if s.dynamic != nil {
+		allHandlers = append(allHandlers, s.serveDynamic)
+	}
+	if len(s.noRouteHandlers) > 0 {
+		allHandlers = append(allHandlers, s.noRouteHandlers...)
+	} else {
+		allHandlers = append(allHandlers, func(c core.Context) {
+			http.NotFound(c.Writer(), c.Request())
+		})
+	}
+
+	ctx := &Context{}
+	ctx.Init(&s.config, w, r)
+
+	// Start the middleware chain
+	ctx.Serve(allHandlers)
+}
+
+// serveDynamic serves the request with a dynamic route and skips the NoRoute handlers if one matched.
+func (s *Server) serveDynamic(c core.Context) {
+	if s.dynamic.Serve(c) {
+		c.Abort()
+	}
+}
+
+// Dynamic implements core.Server.Dynamic for Server
+func (s *Server) Dynamic() core.DynamicRouter {
+	if s.dynamic == nil {
+		s.checkNotFrozen("dynamic router")
+		s.dynamic = dynamic.NewRouter()
+		if s.showLogs {
+			log.Printf("[STD] Dynamic routing enabled")
+		}
+	}
+	return s.dynamic
+}
+
+// SelfBench implements core.Server.SelfBench for Server
+func (s *Server) SelfBench(routes []bench.Route, concurrency int, duration time.Duration) (*bench.Report, error) {
+	return bench.Run(s.Handler(), routes, concurrency, duration)
+}
+
+// Unwrap implements core.Server.Unwrap. The std adapter has no engine besides its own router,
+// so it returns the server itself.
+func (s *Server) Unwrap() interface{} {
+	return s
+}
+
 // StartLambda starts the server in AWS Lambda mode.
 // This method should be called instead of Run or RunTLS when running in AWS Lambda.
-// This method uses the httpadapter library to convert the standard HTTP handler to a Lambda handler.
+// This method uses the httpadapter library to convert ALB, API Gateway and function URL events
+// for the server's router; see SetLambdaConfig.
 //
 // Example usage:
 //
@@ -485,120 +491,78 @@ func (s *Server) GetPort() string {
 //	    }
 //	}
 func (s *Server) StartLambda() error {
-	// Lambda is only supported with the Gin framework
-	return errors.New("Lambda is only supported with the Gin framework")
-}
-
-// handleHTTP creates an http.HandlerFunc that handles the request based on the method and path
-func (s *Server) handleHTTP(method, path string) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Special handling for OPTIONS requests to support CORS preflight
-		if r.Method == "OPTIONS" {
-			// Run middleware only for OPTIONS requests
-			allHandlers := make([]core.HandlerFunc, len(s.middleware))
-			copy(allHandlers, s.middleware)
-
-			ctx := &Context{
-				req:          r,
-				writer:       w,
-				params:       make(map[string]string),
-				keys:         make(map[string]interface{}),
-				handlers:     allHandlers,
-				index:        -1,
-				handlerCount: len(allHandlers),
-			}
-
-			// Start the middleware chain
-			ctx.Next()
-			return
-		}
-
-		if r.Method != method {
-			// Method not allowed
-			if len(s.noMethodHandlers) > 0 {
-				// Use custom NoMethod handlers
-				allHandlers := make([]core.HandlerFunc, 0, len(s.middleware)+len(s.noMethodHandlers))
-				allHandlers = append(allHandlers, s.middleware...)
-				allHandlers = append(allHandlers, s.noMethodHandlers...)
-
-				ctx := &Context{
-					req:          r,
-					writer:       w,
-					params:       make(map[string]string),
-					keys:         make(map[string]interface{}),
-					handlers:     allHandlers,
-					index:        -1,
-					handlerCount: len(allHandlers),
-				}
-
-				// Add a MethodNotAllowedHttpError to the context
-				ctx.Error(fmt.Errorf("Method %s not allowed for path %s", r.Method, path))
-
-				// Start the middleware chain
-				ctx.Next()
-			} else {
-				// Use default error response
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-			return
-		}
+	s.Freeze()
+	if err := s.lifecycle.Start(context.Background()); err != nil {
+		return err
+	}
 
-		handlers, ok := s.routes[method][path]
-		if !ok {
-			// Route not found
-			if len(s.noRouteHandlers) > 0 {
-				// Use custom NoRoute handlers
-				allHandlers := make([]core.HandlerFunc, 0, len(s.middleware)+len(s.noRouteHandlers))
-				allHandlers = append(allHandlers, s.middleware...)
-				allHandlers = append(allHandlers, s.noRouteHandlers...)
-
-				ctx := &Context{
-					req:          r,
-					writer:       w,
-					params:       make(map[string]string),
-					keys:         make(map[string]interface{}),
-					handlers:     allHandlers,
-					index:        -1,
-					handlerCount: len(allHandlers),
-				}
+	// Start the Lambda handler
+	lambda.Start(core.NewLambdaHandler(s, s.lambdaConfig.EventType))
 
-				// Add a NotFoundHttpError to the context
-				ctx.Error(fmt.Errorf("Route %s not found", path))
+	// This line is never reached because lambda.Start() doesn't return
+	return nil
+}
 
-				// Start the middleware chain
-				ctx.Next()
-			} else {
-				// Use default error response
-				http.NotFound(w, r)
-			}
-			return
-		}
+// SetLambdaConfig implements core.Server.SetLambdaConfig
+func (s *Server) SetLambdaConfig(config *core.LambdaConfig) {
+	if config == nil {
+		config = &core.LambdaConfig{}
+	}
+	s.lambdaConfig = *config
+}
 
+// serveRoute runs the middleware chain followed by the handlers of the matched route.
+func (s *Server) serveRoute(ctx *Context, n *node) {
+	route := n.route
+	allHandlers := n.chain
+	if allHandlers == nil {
 		// Combine middleware and route handlers into a single slice
-		allHandlers := make([]core.HandlerFunc, 0, len(s.middleware)+len(handlers))
-		allHandlers = append(allHandlers, s.middleware...)
-		allHandlers = append(allHandlers, handlers...)
-
-		ctx := &Context{
-			req:          r,
-			writer:       w,
-			params:       make(map[string]string),
-			keys:         make(map[string]interface{}),
-			handlers:     allHandlers,
-			index:        -1,
-			handlerCount: len(allHandlers),
-		}
+		allHandlers = combineHandlers(s.middleware, n.handlers)
+	}
 
-		// Log middleware execution
+	// Log middleware execution if showLogs is true
+	if s.showLogs {
 		for i := range s.middleware {
 			if i < len(s.middlewareLog) {
-				log.Printf("[STD] Middleware registered: %s for %s %s", s.middlewareLog[i], method, path)
+				log.Printf("[STD] Middleware registered: %s for %s %s", s.middlewareLog[i].DisplayName(), ctx.Request().Method, route)
 			}
 		}
+	}
+
+	// Start the middleware chain
+	ctx.Serve(allHandlers)
+}
+
+// serveMethodNotAllowed runs the NoMethod handlers for a request whose path matches route
+// with a different method.
+func (s *Server) serveMethodNotAllowed(ctx *Context, route string) {
+	// Special handling for OPTIONS requests to support CORS preflight
+	if ctx.Request().Method == "OPTIONS" {
+		// Run middleware only for OPTIONS requests
+		allHandlers := make([]core.HandlerFunc, len(s.middleware))
+		copy(allHandlers, s.middleware)
 
 		// Start the middleware chain
-		ctx.Next()
+		ctx.Serve(allHandlers)
+		return
 	}
+
+	if len(s.noMethodHandlers) == 0 {
+		// Use default error response
+		http.Error(ctx.Writer(), "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Use custom NoMethod handlers
+	allHandlers := make([]core.HandlerFunc, 0, len(s.middleware)+len(s.noMethodHandlers))
+	allHandlers = append(allHandlers, s.middleware...)
+	allHandlers = append(allHandlers, s.noMethodHandlers...)
+
+	// Add a MethodNotAllowedHttpError to the context
+	ctx.Error(fmt.Errorf("Method %s not allowed for path %s", ctx.Request().Method, route))
+
+	// Start the middleware chain
+	ctx.Serve(allHandlers)
 }
 
 // RouterGroup is an implementation of core.RouterGroup using the standard net/http package.
@@ -610,52 +574,27 @@ type RouterGroup struct {
 
 // GET implements core.RouterGroup.GET for RouterGroup
 func (g *RouterGroup) GET(path string, handlers ...core.HandlerFunc) {
-	fullPath := g.prefix + path
-	wrappedHandlers := make([]core.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		wrappedHandlers[i] = g.wrapHandler(handler)
-	}
-	g.server.GET(fullPath, wrappedHandlers...)
+	g.server.GET(g.prefix+path, g.withMiddleware(handlers)...)
 }
 
 // POST implements core.RouterGroup.POST for RouterGroup
 func (g *RouterGroup) POST(path string, handlers ...core.HandlerFunc) {
-	fullPath := g.prefix + path
-	wrappedHandlers := make([]core.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		wrappedHandlers[i] = g.wrapHandler(handler)
-	}
-	g.server.POST(fullPath, wrappedHandlers...)
+	g.server.POST(g.prefix+path, g.withMiddleware(handlers)...)
 }
 
 // PUT implements core.RouterGroup.PUT for RouterGroup
 func (g *RouterGroup) PUT(path string, handlers ...core.HandlerFunc) {
-	fullPath := g.prefix + path
-	wrappedHandlers := make([]core.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		wrappedHandlers[i] = g.wrapHandler(handler)
-	}
-	g.server.PUT(fullPath, wrappedHandlers...)
+	g.server.PUT(g.prefix+path, g.withMiddleware(handlers)...)
 }
 
 // DELETE implements core.RouterGroup.DELETE for RouterGroup
 func (g *RouterGroup) DELETE(path string, handlers ...core.HandlerFunc) {
-	fullPath := g.prefix + path
-	wrappedHandlers := make([]core.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		wrappedHandlers[i] = g.wrapHandler(handler)
-	}
-	g.server.DELETE(fullPath, wrappedHandlers...)
+	g.server.DELETE(g.prefix+path, g.withMiddleware(handlers)...)
 }
 
 // PATCH implements core.RouterGroup.PATCH for RouterGroup
 func (g *RouterGroup) PATCH(path string, handlers ...core.HandlerFunc) {
-	fullPath := g.prefix + path
-	wrappedHandlers := make([]core.HandlerFunc, len(handlers))
-	for i, handler := range handlers {
-		wrappedHandlers[i] = g.wrapHandler(handler)
-	}
-	g.server.PATCH(fullPath, wrappedHandlers...)
+	g.server.PATCH(g.prefix+path, g.withMiddleware(handlers)...)
 }
 
 // Group implements core.RouterGroup.Group for RouterGroup
@@ -669,6 +608,10 @@ func (g *RouterGroup) Group(path string) core.RouterGroup {
 
 // Use implements core.RouterGroup.Use for RouterGroup
 func (g *RouterGroup) Use(middleware ...core.HandlerFunc) {
+	g.server.checkNotFrozen("group middleware")
+	for _, m := range middleware {
+		g.server.middlewareChain.Add(core.MiddlewareDescription{AppliesTo: g.prefix + "/*"}, m)
+	}
 	g.middleware = append(g.middleware, middleware...)
 }
 
@@ -678,7 +621,7 @@ func (g *RouterGroup) RegisterRouter(controllers ...core.Controller) {
 		// Get HTTP method, path, and handlers from the controller
 		method := controller.GetHttpMethod()
 		path := controller.GetPath()
-		handlers := controller.Handler()
+		handlers := core.ControllerHandlers(controller)
 
 		// Register the route based on the HTTP method
 		switch method {
@@ -700,15 +643,14 @@ func (g *RouterGroup) RegisterRouter(controllers ...core.Controller) {
 	}
 }
 
-// wrapHandler wraps a core.HandlerFunc to apply middleware
-func (g *RouterGroup) wrapHandler(handler core.HandlerFunc) core.HandlerFunc {
-	return func(c core.Context) {
-		// Apply middleware
-		for _, m := range g.middleware {
-			m(c)
-		}
-		handler(c)
-	}
+// withMiddleware returns the route handlers prefixed with the group middleware
+func (g *RouterGroup) withMiddleware(handlers []core.HandlerFunc) []core.HandlerFunc {
+	// Group middleware runs as part of the request's handler chain, so Next and Abort
+	// behave as they do for server middleware. As in Gin, middleware added with Use
+	// only applies to routes registered afterwards.
+	chain := make([]core.HandlerFunc, 0, len(g.middleware)+len(handlers))
+	chain = append(chain, g.middleware...)
+	return append(chain, handlers...)
 }
 
 // NewServer creates a new Server instance using the standard HTTP package.
@@ -721,9 +663,9 @@ func NewServer(port string, showLogs bool) *Server {
 	}
 
 	return &Server{
-		mux:              http.NewServeMux(),
+		trees:            make(map[string]*node),
 		port:             port,
-		middlewareLog:    make([]string, 0),
+		middlewareLog:    make([]core.NamedHandler, 0),
 		noRouteHandlers:  make([]core.HandlerFunc, 0),
 		noMethodHandlers: make([]core.HandlerFunc, 0),
 		showLogs:         showLogs,
@@ -0,0 +1,155 @@
+package std
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/internal/nethttp"
+)
+
+// routeParam is a path parameter matched by the route tree. The value is a substring
+// of the request path, so matching does not allocate.
+type routeParam = nethttp.Param
+
+// node is a node of the route tree. Each node holds one path segment; a route is matched
+// segment by segment, preferring static segments over ":name" parameters over a "*name" wildcard,
+// and backtracking when a more specific branch does not lead to a route.
+type node struct {
+	segment  string
+	indices  string  // First bytes of the static children's segments, for a quick scan
+	static   []*node // Static children, in the same order as indices
+	param    *node   // ":name" child, if any
+	wildcard *node   // "*name" child, if any
+	route    string  // Registered route path, empty if no route ends at this node
+	handlers []core.HandlerFunc
+	chain    []core.HandlerFunc // Middleware followed by handlers, built when the server is frozen
+}
+
+// insert adds a route with the given path and handlers below n, the root of a method's tree.
+// Paths are split at "/", so "/" is the empty segment below the root.
+// Conflicting routes are rejected by checkRoute before they are inserted.
+func (n *node) insert(path string, handlers []core.HandlerFunc) {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	current := n
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, "*"):
+			if i != len(segments)-1 {
+				panic(fmt.Errorf("invalid route %s: wildcard %s must be the last segment", path, segment))
+			}
+			if current.wildcard == nil {
+				current.wildcard = &node{segment: segment}
+			}
+			current = current.wildcard
+		case strings.HasPrefix(segment, ":"):
+			if current.param == nil {
+				current.param = &node{segment: segment}
+			}
+			current = current.param
+		default:
+			current = current.staticChild(segment)
+		}
+	}
+	current.route = path
+	current.handlers = handlers
+}
+
+// staticChild returns the static child of n for segment, adding it if it does not exist.
+func (n *node) staticChild(segment string) *node {
+	for i, child := range n.static {
+		if child.segment == segment {
+			return n.static[i]
+		}
+	}
+	child := &node{segment: segment}
+	first := byte(0)
+	if segment != "" {
+		first = segment[0]
+	}
+	n.indices += string(first)
+	n.static = append(n.static, child)
+	return child
+}
+
+// compile sets the handler chain of every route below n to middleware followed by its handlers.
+func (n *node) compile(middleware []core.HandlerFunc) {
+	if n.route != "" {
+		n.chain = combineHandlers(middleware, n.handlers)
+	}
+	for _, child := range n.static {
+		child.compile(middleware)
+	}
+	if n.param != nil {
+		n.param.compile(middleware)
+	}
+	if n.wildcard != nil {
+		n.wildcard.compile(middleware)
+	}
+}
+
+// combineHandlers returns a new slice with middleware followed by handlers.
+func combineHandlers(middleware, handlers []core.HandlerFunc) []core.HandlerFunc {
+	combined := make([]core.HandlerFunc, 0, len(middleware)+len(handlers))
+	combined = append(combined, middleware...)
+	return append(combined, handlers...)
+}
+
+// lookup returns the node of the route matching path and the matched parameters appended to params.
+// It returns nil if no route matches.
+func (n *node) lookup(path string, params []routeParam) (*node, []routeParam) {
+	if len(path) == 0 || path[0] != '/' {
+		return nil, params
+	}
+	return n.match(path, 1, params)
+}
+
+// match matches the segment of path starting at start, and the segments after it, against
+// the children of n.
+func (n *node) match(path string, start int, params []routeParam) (*node, []routeParam) {
+	end := strings.IndexByte(path[start:], '/')
+	last := end < 0
+	if last {
+		end = len(path)
+	} else {
+		end += start
+	}
+	segment := path[start:end]
+
+	first := byte(0)
+	if segment != "" {
+		first = segment[0]
+	}
+	for i := 0; i < len(n.indices); i++ {
+		if n.indices[i] != first || n.static[i].segment != segment {
+			continue
+		}
+		if found, matched := n.static[i].next(path, end, last, params); found != nil {
+			return found, matched
+		}
+	}
+
+	if n.param != nil && segment != "" {
+		withParam := append(params, routeParam{Key: n.param.segment[1:], Value: segment})
+		if found, matched := n.param.next(path, end, last, withParam); found != nil {
+			return found, matched
+		}
+	}
+
+	if n.wildcard != nil {
+		// The wildcard value includes the leading slash, as in Gin
+		return n.wildcard, append(params, routeParam{Key: n.wildcard.segment[1:], Value: path[start-1:]})
+	}
+	return nil, params
+}
+
+// next continues matching below n after the segment ending at end.
+func (n *node) next(path string, end int, last bool, params []routeParam) (*node, []routeParam) {
+	if last {
+		if n.route != "" {
+			return n, params
+		}
+		return nil, params
+	}
+	return n.match(path, end+1, params)
+}
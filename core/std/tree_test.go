@@ -0,0 +1,101 @@
+package std
+
+import (
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// benchRoutes are common route shapes of REST APIs.
+var benchRoutes = []string{
+	"/",
+	"/health",
+	"/users",
+	"/users/new",
+	"/users/:id",
+	"/users/:id/posts",
+	"/users/:id/posts/:postID",
+	"/orgs/:org/repos/:repo/issues/:number/comments",
+	"/static/*filepath",
+}
+
+func newTestTree(routes ...string) *node {
+	root := &node{}
+	for _, route := range routes {
+		root.insert(route, []core.HandlerFunc{func(c core.Context) {}})
+	}
+	return root
+}
+
+func TestTreeLookup(t *testing.T) {
+	root := newTestTree(append(benchRoutes, "/users/new/settings", "/files/:name/raw")...)
+
+	tests := []struct {
+		path   string
+		route  string
+		params map[string]string
+	}{
+		{"/", "/", nil},
+		{"/health", "/health", nil},
+		{"/users/new", "/users/new", nil},
+		{"/users/42", "/users/:id", map[string]string{"id": "42"}},
+		{"/users/42/posts/7", "/users/:id/posts/:postID", map[string]string{"id": "42", "postID": "7"}},
+		// Static segments are preferred, but matching backtracks to parameters
+		{"/users/new/posts", "/users/:id/posts", map[string]string{"id": "new"}},
+		{"/orgs/acme/repos/api/issues/12/comments", "/orgs/:org/repos/:repo/issues/:number/comments",
+			map[string]string{"org": "acme", "repo": "api", "number": "12"}},
+		{"/static/css/site.css", "/static/*filepath", map[string]string{"filepath": "/css/site.css"}},
+		{"/static/", "/static/*filepath", map[string]string{"filepath": "/"}},
+		{"/users/", "", nil},
+		{"/users/42/comments", "", nil},
+		{"/files//raw", "", nil},
+		{"/missing", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			n, params := root.lookup(tt.path, nil)
+			if tt.route == "" {
+				if n != nil {
+					t.Fatalf("lookup(%q) matched %s, want no match", tt.path, n.route)
+				}
+				return
+			}
+			if n == nil || n.route != tt.route {
+				t.Fatalf("lookup(%q) = %v, want %s", tt.path, n, tt.route)
+			}
+			if len(params) != len(tt.params) {
+				t.Fatalf("lookup(%q) params = %v, want %v", tt.path, params, tt.params)
+			}
+			for _, param := range params {
+				if tt.params[param.Key] != param.Value {
+					t.Errorf("param %s = %q, want %q", param.Key, param.Value, tt.params[param.Key])
+				}
+			}
+		})
+	}
+}
+
+func TestTreeLookupDoesNotAllocate(t *testing.T) {
+	root := newTestTree(benchRoutes...)
+	var buf [4]routeParam
+	allocs := testing.AllocsPerRun(100, func() {
+		root.lookup("/orgs/acme/repos/api/issues/12/comments", buf[:0])
+		root.lookup("/static/css/site.css", buf[:0])
+	})
+	if allocs != 0 {
+		t.Errorf("lookup allocated %v times per run, want 0", allocs)
+	}
+}
+
+func BenchmarkTreeLookup(b *testing.B) {
+	root := newTestTree(benchRoutes...)
+	for _, path := range []string{"/health", "/users/42", "/orgs/acme/repos/api/issues/12/comments", "/static/css/site.css"} {
+		b.Run(path, func(b *testing.B) {
+			var buf [4]routeParam
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				root.lookup(path, buf[:0])
+			}
+		})
+	}
+}
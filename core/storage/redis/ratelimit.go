@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+)
+
+// tokenBucketScript takes a token from the bucket in the hash KEYS[1], with the fields tokens and
+// last, refilling it with ARGV[2] tokens per millisecond up to ARGV[3] tokens since the last take.
+// ARGV[1] is the current time in milliseconds. The bucket expires once it is full again.
+// It returns whether a token was taken, the whole tokens left and the milliseconds until the
+// next token.
+const tokenBucketScript = `
+local now = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(bucket[1])
+local last = tonumber(bucket[2])
+if tokens == nil or last == nil then
+	tokens = burst
+	last = now
+end
+if now > last then
+	tokens = math.min(burst, tokens + (now - last) * rate)
+	last = now
+end
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'last', tostring(last))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate) + 1000)
+return {allowed, math.floor(tokens), retry}
+`
+
+// RateLimitStore is a middleware.RateLimitStore keeping token buckets in Redis, so that the rate
+// limit applies to all instances together. Buckets are updated atomically by a Lua script and
+// expire once they are full again. The current time is read from the clock of the request
+// context, so the clocks of the instances should be synchronized.
+type RateLimitStore struct {
+	client Client
+	prefix string
+}
+
+// NewRateLimitStore returns a store keeping token buckets in keys starting with prefix.
+// If prefix is empty, DefaultKeyPrefix+"ratelimit:" is used.
+//
+// Example usage:
+//
+//	config := middleware.DefaultRateLimitConfig()
+//	config.Store = redis.NewRateLimitStore(redisClient{c}, "")
+//	s.Use(middleware.RateLimitMiddleware(config))
+func NewRateLimitStore(client Client, prefix string) *RateLimitStore {
+	if client == nil {
+		panic("NewRateLimitStore requires a Client")
+	}
+	if prefix == "" {
+		prefix = DefaultKeyPrefix + "ratelimit:"
+	}
+	return &RateLimitStore{client: client, prefix: prefix}
+}
+
+// Take implements middleware.RateLimitStore.Take.
+func (s *RateLimitStore) Take(ctx context.Context, key string, limit middleware.RateLimit) (middleware.RateLimitResult, error) {
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = limit.Requests
+	}
+	perMillisecond := float64(limit.Requests) / (float64(limit.Per) / float64(time.Millisecond))
+	now := core.ClockFromContext(ctx).Now().UnixMilli()
+
+	reply, err := s.client.Eval(ctx, tokenBucketScript, []string{s.prefix + key},
+		strconv.FormatInt(now, 10), strconv.FormatFloat(perMillisecond, 'g', -1, 64), strconv.Itoa(burst))
+	if err != nil {
+		return middleware.RateLimitResult{}, err
+	}
+	values, err := int64s(reply, 3)
+	if err != nil {
+		return middleware.RateLimitResult{}, err
+	}
+	return middleware.RateLimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,50 @@
+// Package redis provides Redis implementations of the stores of the middleware package, so that
+// duplicate request prevention and rate limits work across all instances behind a load balancer.
+//
+// The package does not depend on a Redis client; implement Client with a small adapter around
+// the client used by the application, e.g. for github.com/redis/go-redis/v9:
+//
+//	type redisClient struct{ c *goredis.Client }
+//
+//	func (r redisClient) SetNX(ctx context.Context, key, value string, expiration time.Duration) (bool, error) {
+//		return r.c.SetNX(ctx, key, value, expiration).Result()
+//	}
+//
+//	func (r redisClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+//		return r.c.Eval(ctx, script, keys, args...).Result()
+//	}
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultKeyPrefix is the default prefix of the keys written by the stores.
+const DefaultKeyPrefix = "go-http-server:"
+
+// Client runs the Redis commands used by the stores.
+type Client interface {
+	// SetNX sets key to value with the expiration if key does not exist, and returns whether it was set
+	SetNX(ctx context.Context, key, value string, expiration time.Duration) (bool, error)
+	// Eval runs a Lua script and returns its result as int64, string, []interface{} or nil values
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// int64s converts the array reply of a script to integers.
+func int64s(reply interface{}, n int) ([]int64, error) {
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != n {
+		return nil, fmt.Errorf("unexpected Redis reply %v", reply)
+	}
+	ints := make([]int64, n)
+	for i, value := range values {
+		v, ok := value.(int64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected Redis reply %v", reply)
+		}
+		ints[i] = v
+	}
+	return ints, nil
+}
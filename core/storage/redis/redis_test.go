@@ -0,0 +1,169 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/middleware"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+// fakeClient is an in-memory Client. Eval runs a Go port of tokenBucketScript, so that the
+// arguments and replies of the stores are tested; the script itself needs a Redis server.
+type fakeClient struct {
+	clock core.Clock
+
+	mu      sync.Mutex
+	values  map[string]string
+	hashes  map[string]map[string]string
+	expires map[string]time.Time
+}
+
+func newFakeClient(clock core.Clock) *fakeClient {
+	return &fakeClient{
+		clock:   clock,
+		values:  make(map[string]string),
+		hashes:  make(map[string]map[string]string),
+		expires: make(map[string]time.Time),
+	}
+}
+
+// expire removes key if it has expired. The caller must hold c.mu.
+func (c *fakeClient) expire(key string) {
+	if expires, ok := c.expires[key]; ok && !c.clock.Now().Before(expires) {
+		delete(c.values, key)
+		delete(c.hashes, key)
+		delete(c.expires, key)
+	}
+}
+
+func (c *fakeClient) SetNX(_ context.Context, key, value string, expiration time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire(key)
+	if _, ok := c.values[key]; ok {
+		return false, nil
+	}
+	c.values[key] = value
+	c.expires[key] = c.clock.Now().Add(expiration)
+	return true, nil
+}
+
+func (c *fakeClient) Eval(_ context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	if script != tokenBucketScript {
+		return nil, fmt.Errorf("unknown script")
+	}
+	argv := make([]float64, len(args))
+	for i, arg := range args {
+		v, err := strconv.ParseFloat(arg.(string), 64)
+		if err != nil {
+			return nil, err
+		}
+		argv[i] = v
+	}
+	now, rate, burst := argv[0], argv[1], argv[2]
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire(keys[0])
+	bucket, ok := c.hashes[keys[0]]
+	tokens, last := burst, now
+	if ok {
+		tokens, _ = strconv.ParseFloat(bucket["tokens"], 64)
+		last, _ = strconv.ParseFloat(bucket["last"], 64)
+	}
+	if now > last {
+		tokens = math.Min(burst, tokens+(now-last)*rate)
+		last = now
+	}
+	var allowed, retry int64
+	if tokens >= 1 {
+		tokens--
+		allowed = 1
+	} else {
+		retry = int64(math.Ceil((1 - tokens) / rate))
+	}
+	c.hashes[keys[0]] = map[string]string{
+		"tokens": strconv.FormatFloat(tokens, 'g', -1, 64),
+		"last":   strconv.FormatFloat(last, 'g', -1, 64),
+	}
+	c.expires[keys[0]] = c.clock.Now().Add(time.Duration(math.Ceil((burst-tokens)/rate)+1000) * time.Millisecond)
+	return []interface{}{allowed, int64(math.Floor(tokens)), retry}, nil
+}
+
+func TestRequestIDStorage(t *testing.T) {
+	clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	storage := NewRequestIDStorage(newFakeClient(clock), "", time.Minute)
+
+	for _, want := range []bool{false, true} {
+		exists, err := storage.CheckRequestID("order-1")
+		if err != nil {
+			t.Fatalf("CheckRequestID() returned %v", err)
+		}
+		if exists != want {
+			t.Errorf("CheckRequestID() = %v, want %v", exists, want)
+		}
+	}
+
+	clock.Advance(time.Minute)
+	if exists, _ := storage.CheckRequestID("order-1"); exists {
+		t.Error("CheckRequestID() found an expired ID")
+	}
+}
+
+func TestRequestIDStorageAcrossInstances(t *testing.T) {
+	client := newFakeClient(core.SystemClock)
+	newInstance := func() *servertest.Client {
+		s := std.NewServer("8080", false)
+		s.Use(middleware.DuplicateRequestMiddleware(&middleware.DuplicateRequestConfig{
+			RequestIDGenerator: fixedRequestIDGenerator("order-1"),
+			RequestIDStorage:   NewRequestIDStorage(client, "", time.Minute),
+			ConflictMessage:    "Duplicate order",
+		}))
+		s.POST("/orders", func(c core.Context) { c.String(http.StatusCreated, "created") })
+		return servertest.NewClient(s)
+	}
+
+	newInstance().POST("/orders").Expect(t).Status(http.StatusCreated)
+	newInstance().POST("/orders").Expect(t).Status(http.StatusConflict)
+}
+
+// fixedRequestIDGenerator gives every request the same ID.
+type fixedRequestIDGenerator string
+
+func (g fixedRequestIDGenerator) GenerateRequestID(context.Context) (string, error) {
+	return string(g), nil
+}
+
+func TestRateLimitStore(t *testing.T) {
+	clock := servertest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx := core.ContextWithClock(context.Background(), clock)
+	store := NewRateLimitStore(newFakeClient(clock), "")
+	limit := middleware.RateLimit{Requests: 2, Per: time.Minute}
+
+	take := func(want middleware.RateLimitResult) {
+		t.Helper()
+		result, err := store.Take(ctx, "ip:10.0.0.1", limit)
+		if err != nil {
+			t.Fatalf("Take() returned %v", err)
+		}
+		if result != want {
+			t.Errorf("Take() = %+v, want %+v", result, want)
+		}
+	}
+
+	take(middleware.RateLimitResult{Allowed: true, Remaining: 1})
+	take(middleware.RateLimitResult{Allowed: true, Remaining: 0})
+	take(middleware.RateLimitResult{RetryAfter: 30 * time.Second})
+
+	clock.Advance(30 * time.Second)
+	take(middleware.RateLimitResult{Allowed: true, Remaining: 0})
+}
@@ -0,0 +1,60 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core/middleware"
+)
+
+// DefaultRequestIDTTL is how long request IDs are remembered by default.
+const DefaultRequestIDTTL = middleware.DefaultRequestIDTTL
+
+// RequestIDStorage is a middleware.RequestIDStorage keeping request IDs in Redis with a TTL.
+// CheckRequestID claims an ID with SETNX, so that of two identical requests arriving at
+// different instances at the same time, only one is let through.
+type RequestIDStorage struct {
+	client Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRequestIDStorage returns a storage remembering request IDs for ttl in keys starting with
+// prefix. If ttl is zero or less, DefaultRequestIDTTL is used; if prefix is empty,
+// DefaultKeyPrefix+"requestid:" is used.
+//
+// Example usage:
+//
+//	s.Use(middleware.DuplicateRequestMiddleware(&middleware.DuplicateRequestConfig{
+//		RequestIDGenerator: myRequestIDGenerator,
+//		RequestIDStorage:   redis.NewRequestIDStorage(redisClient{c}, "", 5*time.Minute),
+//	}))
+func NewRequestIDStorage(client Client, prefix string, ttl time.Duration) *RequestIDStorage {
+	if client == nil {
+		panic("NewRequestIDStorage requires a Client")
+	}
+	if prefix == "" {
+		prefix = DefaultKeyPrefix + "requestid:"
+	}
+	if ttl <= 0 {
+		ttl = DefaultRequestIDTTL
+	}
+	return &RequestIDStorage{client: client, prefix: prefix, ttl: ttl}
+}
+
+// CheckRequestID implements middleware.RequestIDStorage.CheckRequestID. An ID that does not
+// exist is saved right away, so that a concurrent check of the same ID finds it.
+func (s *RequestIDStorage) CheckRequestID(requestID string) (bool, error) {
+	set, err := s.client.SetNX(context.Background(), s.prefix+requestID, "1", s.ttl)
+	if err != nil {
+		return false, err
+	}
+	return !set, nil
+}
+
+// SaveRequestID implements middleware.RequestIDStorage.SaveRequestID. IDs claimed by
+// CheckRequestID are already saved; their TTL is not restarted.
+func (s *RequestIDStorage) SaveRequestID(requestID string) error {
+	_, err := s.client.SetNX(context.Background(), s.prefix+requestID, "1", s.ttl)
+	return err
+}
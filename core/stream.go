@@ -0,0 +1,193 @@
+package core
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+)
+
+// SeqFromChan returns an iterator over the values received from ch, suitable for Context.JSONStream.
+// The iterator ends when ch is closed.
+func SeqFromChan[T any](ch <-chan T) iter.Seq[interface{}] {
+	return func(yield func(interface{}) bool) {
+		for value := range ch {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// WriteJSONStream writes the values produced by seq as a JSON array, encoding and flushing
+// one element at a time so that the full response is never held in memory.
+// It is used by Context.JSONStream implementations.
+// Once the status code is written, errors can no longer be reported to the client;
+// the returned error should be logged or recorded with Context.Error.
+// Writing stops with ErrClientAborted when ctx is done, e.g. because the client disconnected.
+func WriteJSONStream(ctx context.Context, w http.ResponseWriter, code int, seq iter.Seq[interface{}]) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	rc := http.NewResponseController(w)
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	var err error
+	first := true
+	for value := range seq {
+		if err = CheckAborted(ctx); err != nil {
+			break
+		}
+		var data []byte
+		if data, err = MarshalJSON(value); err != nil {
+			break
+		}
+		if !first {
+			if _, err = io.WriteString(w, ","); err != nil {
+				break
+			}
+		}
+		first = false
+		if _, err = w.Write(data); err != nil {
+			break
+		}
+		// Flushing is best effort; not every writer supports it
+		_ = rc.Flush()
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]")
+	return err
+}
+
+// WriteNDJSON writes the values produced by seq as newline-delimited JSON
+// (application/x-ndjson), flushing after every line.
+// It is used by Context.NDJSON implementations. Writing stops with ErrClientAborted when ctx is done.
+func WriteNDJSON(ctx context.Context, w http.ResponseWriter, code int, seq iter.Seq[interface{}]) error {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(code)
+
+	rc := http.NewResponseController(w)
+	for value := range seq {
+		if err := CheckAborted(ctx); err != nil {
+			return err
+		}
+		data, err := MarshalJSON(value)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return err
+		}
+		_ = rc.Flush()
+	}
+	return nil
+}
+
+// WriteCSV writes headers followed by the rows produced by rows as CSV (text/csv),
+// flushing after every row. If headers is empty, no header row is written.
+// It is used by Context.CSV implementations. Writing stops with ErrClientAborted when ctx is done.
+func WriteCSV(ctx context.Context, w http.ResponseWriter, code int, headers []string, rows iter.Seq[[]string]) error {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(code)
+
+	rc := http.NewResponseController(w)
+	cw := csv.NewWriter(w)
+	if len(headers) > 0 {
+		if err := cw.Write(headers); err != nil {
+			return err
+		}
+	}
+	for row := range rows {
+		if err := CheckAborted(ctx); err != nil {
+			return err
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		_ = rc.Flush()
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteStream calls step with w until it returns false, flushing after every call, so that a
+// response produced piece by piece, e.g. proxied from another service, is sent as it is produced
+// without holding the full body in memory. The status set with SetStatus, 200 OK by default, and
+// the headers are sent with the first write or flush.
+// It is used by Context.Stream implementations. Writing stops with the first write error, or with
+// ErrClientAborted when ctx is done.
+func WriteStream(ctx context.Context, w http.ResponseWriter, step func(w io.Writer) bool) error {
+	rc := http.NewResponseController(w)
+	sw := &streamWriter{w: w}
+	for {
+		if err := CheckAborted(ctx); err != nil {
+			return err
+		}
+		more := step(sw)
+		if sw.err != nil {
+			return sw.err
+		}
+		_ = rc.Flush()
+		if !more {
+			return nil
+		}
+	}
+}
+
+// streamWriter records the first error writing to w, so that WriteStream stops even if step ignores it.
+type streamWriter struct {
+	w   io.Writer
+	err error
+}
+
+// Write writes p to the underlying writer, failing with the first error once one has occurred.
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	if sw.err != nil {
+		return 0, sw.err
+	}
+	n, err := sw.w.Write(p)
+	sw.err = err
+	return n, err
+}
+
+// DecodeJSONStream reads a JSON array from r and calls fn with each element,
+// decoding one element at a time so that the full body is never held in memory.
+// It stops at the first error returned by fn.
+// It is used by Context.BindJSONStream implementations.
+func DecodeJSONStream(r io.Reader, fn func(element json.RawMessage) error) error {
+	dec := json.NewDecoder(r)
+
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected JSON array, got %v", token)
+	}
+
+	for dec.More() {
+		var element json.RawMessage
+		if err := dec.Decode(&element); err != nil {
+			return err
+		}
+		if err := fn(element); err != nil {
+			return err
+		}
+	}
+
+	// Consume the closing bracket so that truncated arrays are reported
+	_, err = dec.Token()
+	return err
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSONStream(t *testing.T) {
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	close(ch)
+
+	rec := httptest.NewRecorder()
+	if err := WriteJSONStream(context.Background(), rec, http.StatusOK, SeqFromChan(ch)); err != nil {
+		t.Fatalf("WriteJSONStream() error = %v", err)
+	}
+
+	if got := rec.Body.String(); got != "[1,2,3]" {
+		t.Errorf("body = %q, want %q", got, "[1,2,3]")
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if !rec.Flushed {
+		t.Error("expected the response to be flushed")
+	}
+}
+
+func TestWriteJSONStreamEmpty(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := WriteJSONStream(context.Background(), rec, http.StatusOK, func(yield func(interface{}) bool) {}); err != nil {
+		t.Fatalf("WriteJSONStream() error = %v", err)
+	}
+	if got := rec.Body.String(); got != "[]" {
+		t.Errorf("body = %q, want %q", got, "[]")
+	}
+}
+
+func TestDecodeJSONStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []string
+		wantErr bool
+	}{
+		{"array", `[{"id":1}, {"id":2}]`, []string{`{"id":1}`, `{"id":2}`}, false},
+		{"empty", `[]`, nil, false},
+		{"not an array", `{"id":1}`, nil, true},
+		{"truncated", `[{"id":1}`, []string{`{"id":1}`}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := DecodeJSONStream(strings.NewReader(tt.body), func(element json.RawMessage) error {
+				got = append(got, string(element))
+				return nil
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeJSONStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("elements = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONStreamStopsOnError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := DecodeJSONStream(strings.NewReader(`[1,2,3]`), func(element json.RawMessage) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("DecodeJSONStream() error = %v, want %v", err, stop)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times, want 1", calls)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	seq := func(yield func(interface{}) bool) {
+		_ = yield(map[string]int{"id": 1}) && yield(map[string]int{"id": 2})
+	}
+	if err := WriteNDJSON(context.Background(), rec, http.StatusOK, seq); err != nil {
+		t.Fatalf("WriteNDJSON() error = %v", err)
+	}
+
+	if got, want := rec.Body.String(), "{\"id\":1}\n{\"id\":2}\n"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", got)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rows := func(yield func([]string) bool) {
+		_ = yield([]string{"1", "john"}) && yield([]string{"2", "doe, jane"})
+	}
+	if err := WriteCSV(context.Background(), rec, http.StatusOK, []string{"id", "name"}, rows); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+
+	if got, want := rec.Body.String(), "id,name\n1,john\n2,\"doe, jane\"\n"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want text/csv; charset=utf-8", got)
+	}
+	if !rec.Flushed {
+		t.Error("expected the response to be flushed")
+	}
+}
+
+func TestWriteStream(t *testing.T) {
+	rec := httptest.NewRecorder()
+	chunks := []string{"first\n", "second\n", "third\n"}
+	err := WriteStream(context.Background(), rec, func(w io.Writer) bool {
+		_, _ = io.WriteString(w, chunks[0])
+		chunks = chunks[1:]
+		return len(chunks) > 0
+	})
+	if err != nil {
+		t.Fatalf("WriteStream() error = %v", err)
+	}
+	if got := rec.Body.String(); got != "first\nsecond\nthird\n" {
+		t.Errorf("body = %q", got)
+	}
+	if !rec.Flushed {
+		t.Error("expected the response to be flushed")
+	}
+}
+
+// failingWriter is a response writer whose writes fail, as when the client has gone away.
+type failingWriter struct {
+	http.ResponseWriter
+}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestWriteStreamStops(t *testing.T) {
+	// A write error stops the stream even if step ignores it
+	calls := 0
+	err := WriteStream(context.Background(), failingWriter{httptest.NewRecorder()}, func(w io.Writer) bool {
+		calls++
+		_, _ = io.WriteString(w, "chunk")
+		return true
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("WriteStream() = %v after %d calls, want the write error after 1 call", err, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = WriteStream(ctx, httptest.NewRecorder(), func(w io.Writer) bool {
+		t.Error("step called after the client went away")
+		return false
+	})
+	if !errors.Is(err, ErrClientAborted) {
+		t.Errorf("WriteStream() = %v, want ErrClientAborted", err)
+	}
+}
@@ -0,0 +1,281 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+const (
+	// BaggageHeader is the W3C Baggage request header carrying application-defined key-value pairs
+	// across services, alongside traceparent.
+	BaggageHeader = "baggage"
+
+	// maxBaggageMembers and maxBaggageBytes are the limits of W3C Baggage; members beyond them are dropped.
+	maxBaggageMembers = 64
+	maxBaggageBytes   = 8192
+)
+
+// ErrInvalidBaggageKey is returned by Baggage.With for keys that are not HTTP tokens.
+var ErrInvalidBaggageKey = errors.New("telemetry: baggage key must be a non-empty HTTP token")
+
+// BaggageMember is an entry of a Baggage.
+type BaggageMember struct {
+	// Key is the name of the entry, e.g. "tenant".
+	Key string
+	// Value is the decoded value of the entry.
+	Value string
+	// Properties are the metadata of the entry as sent, e.g. "ttl=60", which are propagated unchanged.
+	Properties []string
+}
+
+// Baggage is an immutable set of W3C Baggage entries, e.g. feature flags or debugging annotations
+// that flow from service to service with a request. The zero value is an empty baggage.
+type Baggage struct {
+	members []BaggageMember
+}
+
+// ParseBaggage parses the values of baggage headers. Malformed members are dropped, as are
+// members beyond the limits of W3C Baggage (64 members, 8192 bytes); if a key is repeated,
+// the last value is kept.
+func ParseBaggage(values ...string) Baggage {
+	var b Baggage
+	size := 0
+	for _, value := range values {
+		for _, raw := range strings.Split(value, ",") {
+			raw = strings.TrimSpace(raw)
+			if raw == "" {
+				continue
+			}
+			if size += len(raw); size > maxBaggageBytes {
+				return b
+			}
+			member, ok := parseBaggageMember(raw)
+			if !ok {
+				continue
+			}
+			if i := b.index(member.Key); i >= 0 {
+				b.members[i] = member
+			} else if len(b.members) < maxBaggageMembers {
+				b.members = append(b.members, member)
+			}
+		}
+	}
+	return b
+}
+
+// parseBaggageMember parses a list member: key=value followed by ;-separated properties.
+func parseBaggageMember(raw string) (BaggageMember, bool) {
+	parts := strings.Split(raw, ";")
+	key, value, ok := strings.Cut(parts[0], "=")
+	if !ok {
+		return BaggageMember{}, false
+	}
+	key = strings.TrimSpace(key)
+	if !isToken(key) {
+		return BaggageMember{}, false
+	}
+	value, err := url.PathUnescape(strings.TrimSpace(value))
+	if err != nil {
+		return BaggageMember{}, false
+	}
+
+	member := BaggageMember{Key: key, Value: value}
+	for _, property := range parts[1:] {
+		if property = strings.TrimSpace(property); property != "" {
+			member.Properties = append(member.Properties, property)
+		}
+	}
+	return member, true
+}
+
+// index returns the index of the member with key, or -1.
+func (b Baggage) index(key string) int {
+	for i := range b.members {
+		if b.members[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns the value of the entry with key, and whether there is one.
+func (b Baggage) Get(key string) (string, bool) {
+	if i := b.index(key); i >= 0 {
+		return b.members[i].Value, true
+	}
+	return "", false
+}
+
+// Len returns the number of entries.
+func (b Baggage) Len() int {
+	return len(b.members)
+}
+
+// Members returns a copy of the entries, in the order they were received or added.
+func (b Baggage) Members() []BaggageMember {
+	members := make([]BaggageMember, len(b.members))
+	copy(members, b.members)
+	return members
+}
+
+// With returns a copy of b with the entry key set to value, replacing the entry and its
+// properties if there is one. It returns ErrInvalidBaggageKey if key is not an HTTP token.
+func (b Baggage) With(key, value string) (Baggage, error) {
+	if !isToken(key) {
+		return b, ErrInvalidBaggageKey
+	}
+	members := b.Members()
+	if i := b.index(key); i >= 0 {
+		members[i] = BaggageMember{Key: key, Value: value}
+	} else {
+		members = append(members, BaggageMember{Key: key, Value: value})
+	}
+	return Baggage{members: members}, nil
+}
+
+// Without returns a copy of b without the entry key.
+func (b Baggage) Without(key string) Baggage {
+	i := b.index(key)
+	if i < 0 {
+		return b
+	}
+	members := make([]BaggageMember, 0, len(b.members)-1)
+	members = append(members, b.members[:i]...)
+	members = append(members, b.members[i+1:]...)
+	return Baggage{members: members}
+}
+
+// String returns the baggage header value of b, with the values percent-encoded.
+// Entries beyond the limits of W3C Baggage are left out.
+func (b Baggage) String() string {
+	var sb strings.Builder
+	for i, member := range b.members {
+		if i == maxBaggageMembers {
+			break
+		}
+		encoded := member.Key + "=" + escapeBaggageValue(member.Value)
+		for _, property := range member.Properties {
+			encoded += ";" + property
+		}
+		if sb.Len()+len(encoded)+1 > maxBaggageBytes {
+			break
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(encoded)
+	}
+	return sb.String()
+}
+
+// escapeBaggageValue percent-encodes the characters of value that are not baggage octets.
+func escapeBaggageValue(value string) string {
+	const hex = "0123456789ABCDEF"
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c <= 0x20 || c >= 0x7f || c == '"' || c == ',' || c == ';' || c == '\\' || c == '%' {
+			sb.WriteByte('%')
+			sb.WriteByte(hex[c>>4])
+			sb.WriteByte(hex[c&0x0f])
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// isToken reports whether s is a non-empty HTTP token (RFC 9110).
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// baggageContextKey is the context key of the baggage of a request.
+type baggageContextKey struct{}
+
+// ContextWithBaggage returns a copy of ctx carrying the baggage, which Inject propagates.
+func ContextWithBaggage(ctx context.Context, b Baggage) context.Context {
+	return context.WithValue(ctx, baggageContextKey{}, b)
+}
+
+// BaggageFromContext returns the baggage of ctx, which is empty if none has been set.
+func BaggageFromContext(ctx context.Context) Baggage {
+	b, _ := ctx.Value(baggageContextKey{}).(Baggage)
+	return b
+}
+
+// BaggageFromRequest returns the baggage stored in the context of req by the middleware, or the
+// baggage parsed from its baggage headers if there is none, e.g. if no middleware stores it.
+func BaggageFromRequest(req *http.Request) Baggage {
+	if b, ok := req.Context().Value(baggageContextKey{}).(Baggage); ok {
+		return b
+	}
+	return ParseBaggage(req.Header.Values(BaggageHeader)...)
+}
+
+// BaggageMiddleware returns a middleware function that stores the baggage of incoming baggage
+// headers in the request context, for servers without OpenTelemetry export, whose Middleware
+// does the same. Handlers read it with BaggageFromContext, and Inject or Transport propagate it.
+func BaggageMiddleware() core.HandlerFunc {
+	return func(c core.Context) {
+		req := c.Request()
+		c.SetRequest(req.WithContext(ContextWithBaggage(req.Context(), ParseBaggage(req.Header.Values(BaggageHeader)...))))
+	}
+}
+
+// Inject sets the traceparent header of the current span of ctx and the baggage header of its
+// baggage on the headers of an outgoing request, so that the next service continues the trace
+// and receives the baggage. Headers are left alone if there is no span or baggage.
+func Inject(ctx context.Context, header http.Header) {
+	if sc := SpanContextFromContext(ctx); sc.IsValid() {
+		header.Set(TraceparentHeader, sc.Traceparent())
+	}
+	if b := BaggageFromContext(ctx); b.Len() > 0 {
+		header.Set(BaggageHeader, b.String())
+	}
+}
+
+// Transport returns an http.RoundTripper that calls Inject with the context of each request before
+// sending it with base, or http.DefaultTransport if base is nil.
+//
+// Example usage:
+//
+//	client := &http.Client{Transport: telemetry.Transport(nil)}
+//	req, _ := http.NewRequestWithContext(c.Request().Context(), http.MethodGet, url, nil)
+//	resp, err := client.Do(req)
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		// A RoundTripper must not modify the request
+		req = req.Clone(req.Context())
+		Inject(req.Context(), req.Header)
+		return base.RoundTrip(req)
+	})
+}
+
+// roundTripperFunc is an adapter to allow the use of ordinary functions as http.RoundTrippers.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,146 @@
+package telemetry_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+	"github.com/mythofleader/go-http-server/core/telemetry"
+)
+
+func TestParseBaggage(t *testing.T) {
+	b := telemetry.ParseBaggage(
+		"tenant=acme, flag = on;ttl=60 , bad key=1, noequals",
+		"note=hello%20world%2C%20bye,tenant=globex",
+	)
+
+	if b.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3; members = %+v", b.Len(), b.Members())
+	}
+	for key, want := range map[string]string{"tenant": "globex", "flag": "on", "note": "hello world, bye"} {
+		if got, ok := b.Get(key); !ok || got != want {
+			t.Errorf("Get(%q) = %q, %v, want %q", key, got, ok, want)
+		}
+	}
+	if members := b.Members(); len(members[1].Properties) != 1 || members[1].Properties[0] != "ttl=60" {
+		t.Errorf("Properties of flag = %v, want [ttl=60]", members[1].Properties)
+	}
+	if _, ok := b.Get("bad key"); ok {
+		t.Error("Get() found a member with an invalid key")
+	}
+}
+
+func TestParseBaggageLimits(t *testing.T) {
+	var members []string
+	for i := 0; i < 100; i++ {
+		members = append(members, "k"+strconv.Itoa(i)+"=v")
+	}
+	if b := telemetry.ParseBaggage(strings.Join(members, ",")); b.Len() != 64 {
+		t.Errorf("Len() = %d, want 64", b.Len())
+	}
+
+	big := telemetry.ParseBaggage("a="+strings.Repeat("x", 8000), "b="+strings.Repeat("y", 500))
+	if _, ok := big.Get("b"); ok || big.Len() != 1 {
+		t.Errorf("Len() = %d, want the member beyond 8192 bytes dropped", big.Len())
+	}
+}
+
+func TestBaggageWith(t *testing.T) {
+	b := telemetry.ParseBaggage("tenant=acme;p=1")
+	updated, err := b.With("tenant", "globex")
+	if err != nil {
+		t.Fatalf("With() returned error: %v", err)
+	}
+	updated, _ = updated.With("note", "a,b;c d%")
+
+	if got, _ := b.Get("tenant"); got != "acme" {
+		t.Errorf("With() modified the original baggage: tenant = %q", got)
+	}
+	if got, want := updated.String(), "tenant=globex,note=a%2Cb%3Bc%20d%25"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, _ := telemetry.ParseBaggage(updated.String()).Get("note"); got != "a,b;c d%" {
+		t.Errorf("round trip of note = %q", got)
+	}
+	if got := updated.Without("tenant").String(); got != "note=a%2Cb%3Bc%20d%25" {
+		t.Errorf("Without() = %q", got)
+	}
+	if got := b.String(); got != "tenant=acme;p=1" {
+		t.Errorf("String() = %q, want the properties kept", got)
+	}
+
+	if _, err := b.With("bad key", "1"); !errors.Is(err, telemetry.ErrInvalidBaggageKey) {
+		t.Errorf("With() error = %v, want ErrInvalidBaggageKey", err)
+	}
+}
+
+func TestBaggagePropagation(t *testing.T) {
+	var received http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer downstream.Close()
+	client := &http.Client{Transport: telemetry.Transport(nil)}
+
+	s := std.NewServer("8080", false)
+	s.Use(telemetry.BaggageMiddleware())
+	s.GET("/", func(c core.Context) {
+		ctx := c.Request().Context()
+		b, _ := telemetry.BaggageFromContext(ctx).With("hop", "api")
+		req, _ := http.NewRequestWithContext(telemetry.ContextWithBaggage(ctx, b), http.MethodGet, downstream.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("Do() returned error: %v", err)
+			return
+		}
+		resp.Body.Close()
+		if req.Header.Get(telemetry.BaggageHeader) != "" {
+			t.Error("Transport modified the outgoing request")
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	servertest.NewClient(s).GET("/").WithHeader("Baggage", "tenant=acme").Expect(t).
+		Status(http.StatusOK)
+	if got := received.Get(telemetry.BaggageHeader); got != "tenant=acme,hop=api" {
+		t.Errorf("propagated baggage = %q, want tenant=acme,hop=api", got)
+	}
+	if received.Get(telemetry.TraceparentHeader) != "" {
+		t.Error("traceparent propagated without a span")
+	}
+}
+
+func TestInject(t *testing.T) {
+	header := make(http.Header)
+	telemetry.Inject(context.Background(), header)
+	if len(header) != 0 {
+		t.Errorf("Inject() without span or baggage set %v", header)
+	}
+
+	_, srv := newFakeCollector(t)
+	exporter, err := telemetry.New(srv.URL, &telemetry.Options{ExportInterval: time.Hour, MetricInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	defer exporter.Shutdown(context.Background())
+
+	ctx, span := exporter.StartSpan(context.Background(), "call")
+	defer span.End()
+	ctx = telemetry.ContextWithBaggage(ctx, telemetry.ParseBaggage("tenant=acme"))
+	telemetry.Inject(ctx, header)
+
+	if got, want := header.Get(telemetry.TraceparentHeader), span.SpanContext().Traceparent(); got != want {
+		t.Errorf("traceparent = %q, want %q", got, want)
+	}
+	if got := header.Get(telemetry.BaggageHeader); got != "tenant=acme" {
+		t.Errorf("baggage = %q, want tenant=acme", got)
+	}
+}
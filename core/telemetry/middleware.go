@@ -0,0 +1,81 @@
+package telemetry
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// Middleware returns a middleware function that starts a server span for every request,
+// continuing the trace of an incoming W3C traceparent header, returns the trace and span IDs
+// in the traceresponse header, and records the http.server.request.duration metric.
+// The span and the baggage of incoming W3C baggage headers are stored in the request context,
+// so handlers can get them with SpanFromContext(c.Request().Context()) and BaggageFromContext,
+// start child spans with StartSpan and propagate both with Inject.
+// Register it first, so that the span covers all other middleware.
+func (t *Telemetry) Middleware() core.HandlerFunc {
+	return func(c core.Context) {
+		req := c.Request()
+		// Measure from the start time of the request, shared with the logging middleware
+		c.StartTime()
+
+		parent, _ := ParseTraceparent(req.Header.Get(TraceparentHeader))
+		span := t.startSpan(parent, req.Method, SpanKindServer)
+		span.SetAttribute("http.request.method", req.Method)
+		span.SetAttribute("url.path", req.URL.Path)
+		span.SetAttribute("user_agent.original", req.UserAgent())
+		defer span.End()
+		ctx := ContextWithSpan(req.Context(), span)
+		ctx = ContextWithBaggage(ctx, ParseBaggage(req.Header.Values(BaggageHeader)...))
+		c.SetRequest(req.WithContext(ctx))
+		c.SetHeader(TraceresponseHeader, span.SpanContext().Traceparent())
+
+		c.Next()
+
+		// Client cancellations are measured as 499, apart from server errors
+		status := core.EffectiveStatus(req.Context(), c.Writer().Status())
+		span.SetAttribute("http.response.status_code", status)
+		if status >= http.StatusInternalServerError {
+			span.SetError(http.StatusText(status))
+		}
+		t.recordDuration(req.Method, status, c.Elapsed().Seconds())
+	}
+}
+
+// LogSink returns a log sink that exports access log entries as OTLP log records,
+// with the same resource as traces and metrics. Add it to LoggingConfig.Sinks.
+func (t *Telemetry) LogSink() core.LogSink {
+	return logSink{t}
+}
+
+// logSink is a core.LogSink that queues access log entries for OTLP export.
+type logSink struct {
+	telemetry *Telemetry
+}
+
+// WriteLog implements core.LogSink.WriteLog
+func (s logSink) WriteLog(record core.LogRecord) {
+	if s.telemetry.options.DisableLogs {
+		return
+	}
+
+	attrs := make(map[string]interface{}, len(record.CustomFields)+1)
+	for key, value := range record.CustomFields {
+		attrs[key] = value
+	}
+	if record.RequestID != "" {
+		attrs["request_id"] = record.RequestID
+	}
+
+	body := string(record.Data)
+	s.telemetry.queueLog(otlpLogRecord{
+		TimeUnixNano:   unixNano(time.Now()),
+		SeverityNumber: 9, // Info
+		SeverityText:   "INFO",
+		Body:           otlpValue{StringValue: &body},
+		Attributes:     encodeAttributes(attrs),
+		TraceID:        record.TraceID,
+		SpanID:         record.SpanID,
+	})
+}
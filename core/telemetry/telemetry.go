@@ -0,0 +1,568 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// instrumentationName is the instrumentation scope of all exported telemetry.
+const instrumentationName = "github.com/mythofleader/go-http-server"
+
+// requestDurationBounds are the histogram bucket boundaries, in seconds, recommended by the
+// OpenTelemetry semantic conventions for http.server.request.duration.
+var requestDurationBounds = []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+
+// Options holds configuration for the telemetry exporters.
+type Options struct {
+	// ServiceName is the service.name resource attribute. If not set, it defaults to "http-server".
+	ServiceName string
+	// ServiceVersion is the service.version resource attribute
+	ServiceVersion string
+	// Environment is the deployment.environment resource attribute
+	Environment string
+	// ResourceAttributes are additional resource attributes shared by traces, metrics and logs
+	ResourceAttributes map[string]string
+
+	// Headers are sent with every export request, e.g. for collector authentication
+	Headers map[string]string
+	// Insecure uses plain HTTP for endpoints given without a scheme. HTTPS is used by default.
+	Insecure bool
+	// Timeout is the timeout of each export request. If not set, it defaults to 10 seconds.
+	Timeout time.Duration
+
+	// DisableTraces, DisableMetrics and DisableLogs turn off the corresponding signal
+	DisableTraces  bool
+	DisableMetrics bool
+	DisableLogs    bool
+
+	// SampleRatio is the fraction of new traces that are sampled, between 0 and 1.
+	// The sampling decision of an incoming traceparent is respected. If not set, every trace is sampled.
+	SampleRatio float64
+	// ExportInterval is the interval between span and log exports. If not set, it defaults to 5 seconds.
+	ExportInterval time.Duration
+	// MetricInterval is the interval between metric exports. If not set, it defaults to 60 seconds.
+	MetricInterval time.Duration
+	// QueueSize is the maximum number of spans and of log records buffered between exports.
+	// Further items are dropped. If not set, it defaults to 2048.
+	QueueSize int
+}
+
+// DefaultOptions returns default telemetry options.
+func DefaultOptions() *Options {
+	return &Options{
+		ServiceName:    "http-server",
+		Timeout:        10 * time.Second,
+		SampleRatio:    1,
+		ExportInterval: 5 * time.Second,
+		MetricInterval: 60 * time.Second,
+		QueueSize:      2048,
+	}
+}
+
+// Telemetry exports traces, metrics and logs to an OTLP/HTTP endpoint
+// (/v1/traces, /v1/metrics and /v1/logs) with a shared resource.
+// Spans and log records are buffered and exported in the background;
+// Shutdown must be called on exit to export what is left.
+type Telemetry struct {
+	options  Options
+	endpoint string
+	resource otlpResource
+	client   *http.Client
+
+	mu        sync.Mutex
+	spans     []*Span
+	logs      []otlpLogRecord
+	durations map[string]*histogram
+	startTime time.Time
+	dropped   int64
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// New returns a Telemetry exporting to endpoint and starts its background export loop.
+// endpoint is the base URL of the collector, e.g. "https://collector:4318", or a host and port,
+// e.g. "collector:4318", in which case HTTPS is used unless opts.Insecure is set.
+func New(endpoint string, opts *Options) (*Telemetry, error) {
+	if endpoint == "" {
+		return nil, errors.New("telemetry: endpoint is required")
+	}
+	o := mergeOptions(opts)
+
+	if !strings.Contains(endpoint, "://") {
+		if o.Insecure {
+			endpoint = "http://" + endpoint
+		} else {
+			endpoint = "https://" + endpoint
+		}
+	}
+
+	t := &Telemetry{
+		options:   o,
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		resource:  newResource(o),
+		client:    &http.Client{Timeout: o.Timeout},
+		durations: make(map[string]*histogram),
+		startTime: time.Now(),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go t.run()
+	return t, nil
+}
+
+// StartSpan starts an internal span as a child of the current span of ctx and returns
+// a context carrying the new span. The caller must call End on the span.
+func (t *Telemetry) StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	span := t.startSpan(SpanContextFromContext(ctx), name, SpanKindInternal)
+	return ContextWithSpan(ctx, span), span
+}
+
+// Dropped returns the number of spans and log records dropped because the queue was full.
+func (t *Telemetry) Dropped() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.dropped
+}
+
+// Shutdown stops the export loop and exports the remaining telemetry.
+// Its signature matches core.LifecycleHook, so it can be registered with Server.OnStop.
+func (t *Telemetry) Shutdown(ctx context.Context) error {
+	t.stopOnce.Do(func() { close(t.stop) })
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return errors.Join(t.exportSpans(ctx), t.exportLogs(ctx), t.exportMetrics(ctx))
+}
+
+// startSpan starts a span. If parent is valid, the span joins its trace and inherits
+// its sampling decision; otherwise a new trace is started and sampled by SampleRatio.
+func (t *Telemetry) startSpan(parent SpanContext, name string, kind SpanKind) *Span {
+	span := &Span{exporter: t, name: name, kind: kind, start: time.Now()}
+	if parent.IsValid() {
+		span.context = SpanContext{TraceID: parent.TraceID, Sampled: parent.Sampled}
+		span.parent = parent.SpanID
+	} else {
+		span.context = SpanContext{TraceID: newTraceID()}
+		span.context.Sampled = t.shouldSample(span.context.TraceID)
+	}
+	span.context.SpanID = newSpanID()
+	if t.options.DisableTraces {
+		span.exporter = nil
+	}
+	return span
+}
+
+// shouldSample decides from the trace ID whether a new trace is sampled, so that
+// the decision is consistent for the same trace ID.
+func (t *Telemetry) shouldSample(id TraceID) bool {
+	if t.options.SampleRatio >= 1 {
+		return true
+	}
+	bound := uint64(t.options.SampleRatio * (1 << 63))
+	return binary.BigEndian.Uint64(id[8:])>>1 < bound
+}
+
+// queueSpan buffers an ended span for export.
+func (t *Telemetry) queueSpan(span *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.spans) >= t.options.QueueSize {
+		t.dropped++
+		return
+	}
+	t.spans = append(t.spans, span)
+}
+
+// queueLog buffers a log record for export.
+func (t *Telemetry) queueLog(record otlpLogRecord) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.logs) >= t.options.QueueSize {
+		t.dropped++
+		return
+	}
+	t.logs = append(t.logs, record)
+}
+
+// recordDuration adds a request duration, in seconds, to the histogram for the method and status.
+func (t *Telemetry) recordDuration(method string, status int, seconds float64) {
+	if t.options.DisableMetrics {
+		return
+	}
+	key := method + " " + strconv.Itoa(status)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h, ok := t.durations[key]
+	if !ok {
+		h = &histogram{method: method, status: status, counts: make([]uint64, len(requestDurationBounds)+1)}
+		t.durations[key] = h
+	}
+	h.record(seconds)
+}
+
+// run exports spans and logs every ExportInterval and metrics every MetricInterval until Shutdown.
+func (t *Telemetry) run() {
+	defer close(t.done)
+
+	exportTicker := time.NewTicker(t.options.ExportInterval)
+	defer exportTicker.Stop()
+	metricTicker := time.NewTicker(t.options.MetricInterval)
+	defer metricTicker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-exportTicker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), t.options.Timeout)
+			if err := errors.Join(t.exportSpans(ctx), t.exportLogs(ctx)); err != nil {
+				fmt.Printf("Error exporting telemetry: %v\n", err)
+			}
+			cancel()
+		case <-metricTicker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), t.options.Timeout)
+			if err := t.exportMetrics(ctx); err != nil {
+				fmt.Printf("Error exporting telemetry: %v\n", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// exportSpans sends the buffered spans to /v1/traces.
+func (t *Telemetry) exportSpans(ctx context.Context) error {
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+
+	encoded := make([]otlpSpan, len(spans))
+	for i, span := range spans {
+		encoded[i] = span.encode()
+	}
+	return t.post(ctx, "/v1/traces", map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": t.resource,
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": otlpScope{Name: instrumentationName},
+				"spans": encoded,
+			}},
+		}},
+	})
+}
+
+// exportLogs sends the buffered log records to /v1/logs.
+func (t *Telemetry) exportLogs(ctx context.Context) error {
+	t.mu.Lock()
+	logs := t.logs
+	t.logs = nil
+	t.mu.Unlock()
+	if len(logs) == 0 {
+		return nil
+	}
+
+	return t.post(ctx, "/v1/logs", map[string]interface{}{
+		"resourceLogs": []interface{}{map[string]interface{}{
+			"resource": t.resource,
+			"scopeLogs": []interface{}{map[string]interface{}{
+				"scope":      otlpScope{Name: instrumentationName},
+				"logRecords": logs,
+			}},
+		}},
+	})
+}
+
+// exportMetrics sends the cumulative request duration histograms to /v1/metrics.
+func (t *Telemetry) exportMetrics(ctx context.Context) error {
+	now := time.Now()
+
+	t.mu.Lock()
+	points := make([]otlpHistogramPoint, 0, len(t.durations))
+	for _, h := range t.durations {
+		points = append(points, h.encode(t.startTime, now))
+	}
+	t.mu.Unlock()
+	if len(points) == 0 {
+		return nil
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].sortKey < points[j].sortKey })
+
+	return t.post(ctx, "/v1/metrics", map[string]interface{}{
+		"resourceMetrics": []interface{}{map[string]interface{}{
+			"resource": t.resource,
+			"scopeMetrics": []interface{}{map[string]interface{}{
+				"scope": otlpScope{Name: instrumentationName},
+				"metrics": []interface{}{map[string]interface{}{
+					"name":        "http.server.request.duration",
+					"description": "Duration of HTTP server requests.",
+					"unit":        "s",
+					"histogram": map[string]interface{}{
+						"aggregationTemporality": 2, // Cumulative
+						"dataPoints":             points,
+					},
+				}},
+			}},
+		}},
+	})
+}
+
+// post sends an OTLP/JSON payload to the endpoint path.
+func (t *Telemetry) post(ctx context.Context, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.options.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("telemetry collector returned error status for %s: %d", path, resp.StatusCode)
+	}
+	return nil
+}
+
+// mergeOptions fills unset options with their defaults.
+func mergeOptions(opts *Options) Options {
+	defaults := DefaultOptions()
+	if opts == nil {
+		return *defaults
+	}
+	o := *opts
+	if o.ServiceName == "" {
+		o.ServiceName = defaults.ServiceName
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaults.Timeout
+	}
+	if o.SampleRatio <= 0 {
+		o.SampleRatio = defaults.SampleRatio
+	}
+	if o.ExportInterval <= 0 {
+		o.ExportInterval = defaults.ExportInterval
+	}
+	if o.MetricInterval <= 0 {
+		o.MetricInterval = defaults.MetricInterval
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = defaults.QueueSize
+	}
+	return o
+}
+
+// newResource returns the resource shared by all signals.
+func newResource(o Options) otlpResource {
+	attrs := map[string]interface{}{
+		"service.name":           o.ServiceName,
+		"telemetry.sdk.name":     instrumentationName,
+		"telemetry.sdk.language": "go",
+	}
+	if o.ServiceVersion != "" {
+		attrs["service.version"] = o.ServiceVersion
+	}
+	if o.Environment != "" {
+		attrs["deployment.environment"] = o.Environment
+	}
+	for key, value := range o.ResourceAttributes {
+		attrs[key] = value
+	}
+	return otlpResource{Attributes: encodeAttributes(attrs)}
+}
+
+// histogram is a cumulative histogram of request durations for one method and status.
+type histogram struct {
+	method string
+	status int
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// record adds a value to the histogram.
+func (h *histogram) record(value float64) {
+	bucket := sort.SearchFloat64s(requestDurationBounds, value)
+	h.counts[bucket]++
+	h.count++
+	h.sum += value
+}
+
+// encode returns the OTLP data point of the histogram.
+func (h *histogram) encode(start, now time.Time) otlpHistogramPoint {
+	counts := make([]string, len(h.counts))
+	for i, c := range h.counts {
+		counts[i] = strconv.FormatUint(c, 10)
+	}
+	return otlpHistogramPoint{
+		Attributes: encodeAttributes(map[string]interface{}{
+			"http.request.method":       h.method,
+			"http.response.status_code": h.status,
+		}),
+		StartTimeUnixNano: unixNano(start),
+		TimeUnixNano:      unixNano(now),
+		Count:             strconv.FormatUint(h.count, 10),
+		Sum:               h.sum,
+		BucketCounts:      counts,
+		ExplicitBounds:    requestDurationBounds,
+		sortKey:           h.method + " " + strconv.Itoa(h.status),
+	}
+}
+
+// encode returns the OTLP representation of the span.
+func (s *Span) encode() otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	encoded := otlpSpan{
+		TraceID:           s.context.TraceID.String(),
+		SpanID:            s.context.SpanID.String(),
+		Name:              s.name,
+		Kind:              int(s.kind),
+		StartTimeUnixNano: unixNano(s.start),
+		EndTimeUnixNano:   unixNano(s.end),
+		Attributes:        encodeAttributes(s.attributes),
+	}
+	if s.parent.IsValid() {
+		encoded.ParentSpanID = s.parent.String()
+	}
+	if s.failed {
+		encoded.Status = &otlpStatus{Code: 2, Message: s.errMessage} // Error
+	}
+	return encoded
+}
+
+// OTLP/JSON message types. 64-bit integers are encoded as strings and IDs as hex, as required by the protocol.
+type (
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+
+	otlpKeyValue struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    string   `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            *otlpStatus    `json:"status,omitempty"`
+	}
+
+	otlpLogRecord struct {
+		TimeUnixNano   string         `json:"timeUnixNano"`
+		SeverityNumber int            `json:"severityNumber"`
+		SeverityText   string         `json:"severityText"`
+		Body           otlpValue      `json:"body"`
+		Attributes     []otlpKeyValue `json:"attributes,omitempty"`
+		TraceID        string         `json:"traceId,omitempty"`
+		SpanID         string         `json:"spanId,omitempty"`
+	}
+
+	otlpHistogramPoint struct {
+		Attributes        []otlpKeyValue `json:"attributes"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		TimeUnixNano      string         `json:"timeUnixNano"`
+		Count             string         `json:"count"`
+		Sum               float64        `json:"sum"`
+		BucketCounts      []string       `json:"bucketCounts"`
+		ExplicitBounds    []float64      `json:"explicitBounds"`
+
+		sortKey string
+	}
+)
+
+// encodeAttributes converts attributes to OTLP key-values, sorted by key.
+func encodeAttributes(attrs map[string]interface{}) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	encoded := make([]otlpKeyValue, len(keys))
+	for i, key := range keys {
+		encoded[i] = otlpKeyValue{Key: key, Value: encodeValue(attrs[key])}
+	}
+	return encoded
+}
+
+// encodeValue converts a value to an OTLP any-value.
+func encodeValue(value interface{}) otlpValue {
+	switch v := value.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int:
+		return otlpValue{IntValue: strconv.Itoa(v)}
+	case int64:
+		return otlpValue{IntValue: strconv.FormatInt(v, 10)}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	case fmt.Stringer:
+		s := v.String()
+		return otlpValue{StringValue: &s}
+	default:
+		s := fmt.Sprintf("%v", v)
+		return otlpValue{StringValue: &s}
+	}
+}
+
+// unixNano returns t as a decimal string of nanoseconds since the Unix epoch.
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,275 @@
+package telemetry_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+	"github.com/mythofleader/go-http-server/core/telemetry"
+)
+
+// fakeCollector records OTLP/JSON export requests by path.
+type fakeCollector struct {
+	mu       sync.Mutex
+	requests map[string][]map[string]interface{}
+	headers  http.Header
+}
+
+func newFakeCollector(t *testing.T) (*fakeCollector, *httptest.Server) {
+	c := &fakeCollector{requests: make(map[string][]map[string]interface{})}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]interface{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("invalid OTLP/JSON payload for %s: %v", r.URL.Path, err)
+		}
+		c.mu.Lock()
+		c.requests[r.URL.Path] = append(c.requests[r.URL.Path], payload)
+		c.headers = r.Header.Clone()
+		c.mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+	return c, srv
+}
+
+// records returns the spans, log records or data points of the exports to path.
+func (c *fakeCollector) records(path, resourceKey, scopeKey, recordKey string) []map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var records []map[string]interface{}
+	for _, payload := range c.requests[path] {
+		for _, resource := range payload[resourceKey].([]interface{}) {
+			for _, scope := range resource.(map[string]interface{})[scopeKey].([]interface{}) {
+				for _, record := range scope.(map[string]interface{})[recordKey].([]interface{}) {
+					records = append(records, record.(map[string]interface{}))
+				}
+			}
+		}
+	}
+	return records
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		valid   bool
+		sampled bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"invalid version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"malformed", "00-4bf92f35-00f067aa0ba902b7-01", false, false},
+		{"empty", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := telemetry.ParseTraceparent(tt.value)
+			if ok != tt.valid {
+				t.Fatalf("ParseTraceparent(%q) ok = %v, want %v", tt.value, ok, tt.valid)
+			}
+			if !ok {
+				return
+			}
+			if sc.Sampled != tt.sampled {
+				t.Errorf("Sampled = %v, want %v", sc.Sampled, tt.sampled)
+			}
+			if got := sc.Traceparent(); got != tt.value {
+				t.Errorf("Traceparent() = %q, want %q", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	servers := map[string]func() core.Server{
+		"gin": func() core.Server { return gin.NewServer("8080", false) },
+		"std": func() core.Server { return std.NewServer("8080", false) },
+	}
+
+	for name, newServer := range servers {
+		t.Run(name, func(t *testing.T) {
+			collector, srv := newFakeCollector(t)
+			exporter, err := telemetry.New(srv.URL, &telemetry.Options{
+				ServiceName:    "orders",
+				Environment:    "test",
+				Headers:        map[string]string{"Authorization": "Bearer token"},
+				ExportInterval: time.Hour,
+				MetricInterval: time.Hour,
+			})
+			if err != nil {
+				t.Fatalf("New() returned error: %v", err)
+			}
+
+			s := newServer()
+			s.Use(exporter.Middleware())
+			s.GET("/orders", func(c core.Context) {
+				sc := telemetry.SpanContextFromContext(c.Request().Context())
+				_, child := exporter.StartSpan(c.Request().Context(), "load orders")
+				child.End()
+				c.String(http.StatusOK, sc.TraceID.String())
+			})
+			s.GET("/fail", func(c core.Context) {
+				c.String(http.StatusInternalServerError, "fail")
+			})
+
+			client := servertest.NewClient(s)
+			rec := client.GET("/orders").WithHeader("traceparent", traceparent).Expect(t).
+				Status(http.StatusOK).
+				Body("4bf92f3577b34da6a3ce929d0e0e4736").
+				Recorder()
+			client.GET("/fail").Expect(t).Status(http.StatusInternalServerError)
+
+			exporter.LogSink().WriteLog(core.LogRecord{RequestID: "req-1", Data: []byte(`{"status":200}`)})
+			if err := exporter.Shutdown(context.Background()); err != nil {
+				t.Fatalf("Shutdown() returned error: %v", err)
+			}
+
+			if got := collector.headers.Get("Authorization"); got != "Bearer token" {
+				t.Errorf("Authorization header = %q, want Bearer token", got)
+			}
+
+			spans := collector.records("/v1/traces", "resourceSpans", "scopeSpans", "spans")
+			if len(spans) != 3 {
+				t.Fatalf("exported %d spans, want 3", len(spans))
+			}
+			child, server := spans[0], spans[1]
+			if want := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + server["spanId"].(string) + "-01"; rec.Header().Get("traceresponse") != want {
+				t.Errorf("traceresponse = %q, want %q", rec.Header().Get("traceresponse"), want)
+			}
+			if server["traceId"] != "4bf92f3577b34da6a3ce929d0e0e4736" || server["parentSpanId"] != "00f067aa0ba902b7" {
+				t.Errorf("server span did not continue the incoming trace: %v", server)
+			}
+			if child["parentSpanId"] != server["spanId"] {
+				t.Errorf("child span parent = %v, want %v", child["parentSpanId"], server["spanId"])
+			}
+			if status, _ := spans[2]["status"].(map[string]interface{}); status == nil || status["code"] != float64(2) {
+				t.Errorf("5xx span status = %v, want error", spans[2]["status"])
+			}
+
+			points := collector.records("/v1/metrics", "resourceMetrics", "scopeMetrics", "metrics")
+			if len(points) != 1 || points[0]["name"] != "http.server.request.duration" {
+				t.Fatalf("exported metrics = %v, want http.server.request.duration", points)
+			}
+			dataPoints := points[0]["histogram"].(map[string]interface{})["dataPoints"].([]interface{})
+			if len(dataPoints) != 2 {
+				t.Errorf("exported %d data points, want one per method and status", len(dataPoints))
+			}
+
+			logs := collector.records("/v1/logs", "resourceLogs", "scopeLogs", "logRecords")
+			if len(logs) != 1 || logs[0]["body"].(map[string]interface{})["stringValue"] != `{"status":200}` {
+				t.Errorf("exported logs = %v, want the access log entry", logs)
+			}
+
+			// All signals share the same resource
+			collector.mu.Lock()
+			defer collector.mu.Unlock()
+			for path, key := range map[string]string{"/v1/traces": "resourceSpans", "/v1/metrics": "resourceMetrics", "/v1/logs": "resourceLogs"} {
+				resource := collector.requests[path][0][key].([]interface{})[0].(map[string]interface{})["resource"]
+				attrs := map[string]interface{}{}
+				for _, kv := range resource.(map[string]interface{})["attributes"].([]interface{}) {
+					kv := kv.(map[string]interface{})
+					attrs[kv["key"].(string)] = kv["value"].(map[string]interface{})["stringValue"]
+				}
+				if attrs["service.name"] != "orders" || attrs["deployment.environment"] != "test" {
+					t.Errorf("%s resource attributes = %v", path, attrs)
+				}
+			}
+		})
+	}
+}
+
+// captureSink records the log records written by the logging middleware.
+type captureSink struct {
+	mu      sync.Mutex
+	records []core.LogRecord
+}
+
+func (s *captureSink) WriteLog(record core.LogRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+}
+
+func TestLogCorrelation(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	for _, s := range []core.Server{gin.NewServer("8080", false), std.NewServer("8080", false)} {
+		_, srv := newFakeCollector(t)
+		exporter, err := telemetry.New(srv.URL, &telemetry.Options{ExportInterval: time.Hour, MetricInterval: time.Hour})
+		if err != nil {
+			t.Fatalf("New() returned error: %v", err)
+		}
+		defer exporter.Shutdown(context.Background())
+
+		sink := &captureSink{}
+		s.Use(exporter.Middleware())
+		s.Use(s.GetLoggingMiddleware().Middleware(&core.LoggingConfig{Sinks: []core.LogSink{sink}}))
+		s.GET("/orders", func(c core.Context) {
+			c.String(http.StatusOK, "ok")
+		})
+
+		rec := servertest.NewClient(s).GET("/orders").WithHeader("traceparent", traceparent).Expect(t).
+			Status(http.StatusOK).
+			Recorder()
+		sc, ok := telemetry.ParseTraceparent(rec.Header().Get("traceresponse"))
+		if !ok {
+			t.Fatalf("invalid traceresponse header %q", rec.Header().Get("traceresponse"))
+		}
+
+		sink.mu.Lock()
+		if len(sink.records) != 1 {
+			t.Fatalf("logged %d records, want 1", len(sink.records))
+		}
+		record := sink.records[0]
+		sink.mu.Unlock()
+
+		if record.TraceID != sc.TraceID.String() || record.SpanID != sc.SpanID.String() {
+			t.Errorf("log record trace = %s/%s, want %s/%s", record.TraceID, record.SpanID, sc.TraceID, sc.SpanID)
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal(record.Data, &entry); err != nil {
+			t.Fatalf("invalid log entry: %v", err)
+		}
+		if entry["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || entry["span_id"] != sc.SpanID.String() {
+			t.Errorf("log entry trace_id/span_id = %v/%v", entry["trace_id"], entry["span_id"])
+		}
+	}
+}
+
+func TestSampleRatio(t *testing.T) {
+	collector, srv := newFakeCollector(t)
+	exporter, err := telemetry.New(srv.URL, &telemetry.Options{
+		SampleRatio:    0.000001,
+		ExportInterval: time.Hour,
+		MetricInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		_, span := exporter.StartSpan(context.Background(), "work")
+		span.End()
+	}
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() returned error: %v", err)
+	}
+
+	if spans := collector.records("/v1/traces", "resourceSpans", "scopeSpans", "spans"); len(spans) != 0 {
+		t.Errorf("exported %d unsampled spans, want 0", len(spans))
+	}
+}
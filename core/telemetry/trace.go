@@ -0,0 +1,207 @@
+// Package telemetry exports traces, metrics and logs to an OpenTelemetry collector
+// using the OTLP/HTTP protocol with JSON encoding, without depending on the OpenTelemetry SDK.
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// TraceparentHeader is the W3C Trace Context request header carrying the trace and parent span IDs.
+	TraceparentHeader = "traceparent"
+	// TraceresponseHeader is the W3C Trace Context response header carrying the trace ID
+	// and the ID of the server span, so clients can find the trace of their request.
+	TraceresponseHeader = "traceresponse"
+)
+
+// SpanKind is the OTLP span kind.
+type SpanKind int
+
+const (
+	// SpanKindInternal is an internal operation of the service
+	SpanKindInternal SpanKind = 1
+	// SpanKindServer is the handling of an incoming request
+	SpanKindServer SpanKind = 2
+	// SpanKindClient is an outgoing request
+	SpanKindClient SpanKind = 3
+)
+
+// TraceID is a 16-byte trace identifier.
+type TraceID [16]byte
+
+// String returns the lowercase hex encoding of the ID.
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the ID is not all zeros.
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// SpanID is an 8-byte span identifier.
+type SpanID [8]byte
+
+// String returns the lowercase hex encoding of the ID.
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the ID is not all zeros.
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether both IDs are set.
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// Traceparent returns the W3C traceparent header value for the span context.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses a W3C traceparent header value.
+// It returns false if the value is malformed or carries all-zero IDs.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+	// Version 00 has exactly four fields; later versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&0x01 == 1
+
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	return sc, true
+}
+
+// Span is a timed operation within a trace. It is safe for concurrent use.
+type Span struct {
+	exporter *Telemetry
+	name     string
+	kind     SpanKind
+	context  SpanContext
+	parent   SpanID
+	start    time.Time
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes map[string]interface{}
+	errMessage string
+	failed     bool
+	ended      bool
+}
+
+// SpanContext returns the identifiers of the span.
+func (s *Span) SpanContext() SpanContext {
+	return s.context
+}
+
+// SetAttribute sets an attribute on the span. Supported value types are
+// string, bool, int, int64, float64 and fmt.Stringer; other values are formatted with %v.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]interface{})
+	}
+	s.attributes[key] = value
+}
+
+// SetError marks the span as failed with the given message.
+func (s *Span) SetError(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = true
+	s.errMessage = message
+}
+
+// End ends the span and queues it for export if it is sampled.
+// Calls after the first have no effect.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if s.exporter != nil && s.context.Sampled {
+		s.exporter.queueSpan(s)
+	}
+}
+
+// spanContextKey is the context key of the current span.
+type spanContextKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying the span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the current span of ctx, or nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// SpanContextFromContext returns the span context of the current span of ctx.
+// The result is invalid if there is no current span.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.context
+	}
+	return SpanContext{}
+}
+
+// newTraceID returns a random trace ID.
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		if _, err := rand.Read(id[:]); err != nil {
+			panic(fmt.Sprintf("telemetry: failed to generate trace ID: %v", err))
+		}
+	}
+	return id
+}
+
+// newSpanID returns a random span ID.
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		if _, err := rand.Read(id[:]); err != nil {
+			panic(fmt.Sprintf("telemetry: failed to generate span ID: %v", err))
+		}
+	}
+	return id
+}
@@ -0,0 +1,232 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNoTemplateEngine is returned when rendering HTML for a request without a template engine,
+// e.g. because TemplateMiddleware is not registered.
+var ErrNoTemplateEngine = errors.New("no template engine is registered for this request")
+
+// ErrTemplateNotFound is returned when rendering a template that the engine does not have.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// TemplateEngine renders named templates for Context.HTML.
+// The default implementation is HTMLTemplates, based on html/template.
+type TemplateEngine interface {
+	// Render writes the template name executed with data to w
+	Render(w io.Writer, name string, data interface{}) error
+}
+
+// templateEngineKey is the context key of the template engine of a request.
+type templateEngineKey struct{}
+
+// ContextWithTemplateEngine returns a copy of ctx carrying engine.
+func ContextWithTemplateEngine(ctx context.Context, engine TemplateEngine) context.Context {
+	return context.WithValue(ctx, templateEngineKey{}, engine)
+}
+
+// TemplateEngineFromContext returns the template engine carried by ctx, or nil if there is none.
+func TemplateEngineFromContext(ctx context.Context) TemplateEngine {
+	engine, _ := ctx.Value(templateEngineKey{}).(TemplateEngine)
+	return engine
+}
+
+// TemplateMiddleware returns a middleware function that stores engine in the request context,
+// where Context.HTML reads it.
+func TemplateMiddleware(engine TemplateEngine) HandlerFunc {
+	return func(c Context) {
+		c.SetRequest(c.Request().WithContext(ContextWithTemplateEngine(c.Request().Context(), engine)))
+		c.Next()
+	}
+}
+
+// WriteHTML renders the template name of the template engine of ctx with data and writes it as a
+// text/html response with status code. It is used by Context.HTML implementations.
+// The template is rendered before anything is written, so if rendering fails, nothing is written
+// and the error is returned to be added with Context.Error.
+func WriteHTML(ctx context.Context, w http.ResponseWriter, code int, name string, data interface{}) error {
+	// Skip rendering altogether if the client is already gone
+	if err := CheckAborted(ctx); err != nil {
+		return nil
+	}
+
+	engine := TemplateEngineFromContext(ctx)
+	if engine == nil {
+		return ErrNoTemplateEngine
+	}
+	var buf bytes.Buffer
+	if err := engine.Render(&buf, name, data); err != nil {
+		return fmt.Errorf("render template %q: %w", name, err)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	return WriteResponseBody(ctx, w, buf.Bytes())
+}
+
+// HTMLTemplateConfig holds configuration for HTMLTemplates.
+type HTMLTemplateConfig struct {
+	// FS is the file system the templates are loaded from, e.g. an embed.FS.
+	// If nil, they are loaded from the OS file system, relative to the working directory.
+	FS fs.FS
+
+	// Patterns are the glob patterns of the page templates, e.g. "templates/pages/*.html".
+	// Pages are named after their file name, e.g. "index.html", which must be unique.
+	Patterns []string
+
+	// Layouts are the glob patterns of the templates shared by all pages, such as layouts and
+	// partials, e.g. "templates/layouts/*.html". Pages can redefine their blocks.
+	Layouts []string
+
+	// Layout is the name of the template executed to render every page, e.g. "base.html", which
+	// includes the blocks the pages define, e.g. {{block "content" .}}{{end}}.
+	// If empty, pages are executed directly.
+	Layout string
+
+	// Funcs are the functions available in all templates.
+	Funcs template.FuncMap
+
+	// Reload parses the templates again on every render, so changes show without a restart.
+	// It is meant for development, as parsing is much slower than rendering.
+	Reload bool
+}
+
+// HTMLTemplates is a TemplateEngine rendering html/template templates loaded from glob patterns,
+// optionally within a shared layout. Every page is parsed together with the layouts, so pages
+// can define the same blocks without conflicting.
+//
+// Example usage:
+//
+//	templates, err := core.NewHTMLTemplates(core.HTMLTemplateConfig{
+//		Patterns: []string{"templates/pages/*.html"},
+//		Layouts:  []string{"templates/layouts/*.html"},
+//		Layout:   "base.html",
+//		Reload:   os.Getenv("APP_ENV") == "dev",
+//	})
+//	s.Use(core.TemplateMiddleware(templates))
+//
+//	s.GET("/", func(c core.Context) {
+//		c.HTML(http.StatusOK, "index.html", map[string]interface{}{"Title": "Home"})
+//	})
+type HTMLTemplates struct {
+	config HTMLTemplateConfig
+
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+// NewHTMLTemplates loads the templates of config. It returns an error if a pattern is malformed,
+// no page matches, two pages have the same name or a template cannot be parsed.
+func NewHTMLTemplates(config HTMLTemplateConfig) (*HTMLTemplates, error) {
+	t := &HTMLTemplates{config: config}
+	pages, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+	t.pages = pages
+	return t, nil
+}
+
+// glob returns the files matching patterns.
+func (t *HTMLTemplates) glob(patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		var matches []string
+		var err error
+		if t.config.FS != nil {
+			matches, err = fs.Glob(t.config.FS, pattern)
+		} else {
+			matches, err = filepath.Glob(pattern)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("template pattern %q: %w", pattern, err)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// parse parses files into t.
+func (t *HTMLTemplates) parse(tmpl *template.Template, files ...string) (*template.Template, error) {
+	if t.config.FS != nil {
+		return tmpl.ParseFS(t.config.FS, files...)
+	}
+	return tmpl.ParseFiles(files...)
+}
+
+// load parses every page with the layouts and returns the pages by name.
+func (t *HTMLTemplates) load() (map[string]*template.Template, error) {
+	pageFiles, err := t.glob(t.config.Patterns)
+	if err != nil {
+		return nil, err
+	}
+	if len(pageFiles) == 0 {
+		return nil, fmt.Errorf("no templates match %q", t.config.Patterns)
+	}
+	layoutFiles, err := t.glob(t.config.Layouts)
+	if err != nil {
+		return nil, err
+	}
+
+	base := template.New("").Funcs(t.config.Funcs)
+	if len(layoutFiles) > 0 {
+		if base, err = t.parse(base, layoutFiles...); err != nil {
+			return nil, err
+		}
+	}
+
+	pages := make(map[string]*template.Template, len(pageFiles))
+	for _, file := range pageFiles {
+		name := path.Base(filepath.ToSlash(file))
+		if _, exists := pages[name]; exists {
+			return nil, fmt.Errorf("template %q: %s has the same name as another page", name, file)
+		}
+		page, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if page, err = t.parse(page, file); err != nil {
+			return nil, err
+		}
+		pages[name] = page
+	}
+	return pages, nil
+}
+
+// Render implements TemplateEngine.Render
+func (t *HTMLTemplates) Render(w io.Writer, name string, data interface{}) error {
+	t.mu.RLock()
+	pages := t.pages
+	t.mu.RUnlock()
+
+	if t.config.Reload {
+		reloaded, err := t.load()
+		if err != nil {
+			return err
+		}
+		t.mu.Lock()
+		t.pages = reloaded
+		t.mu.Unlock()
+		pages = reloaded
+	}
+
+	page, ok := pages[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	if t.config.Layout != "" {
+		return page.ExecuteTemplate(w, t.config.Layout, data)
+	}
+	return page.ExecuteTemplate(w, name, data)
+}
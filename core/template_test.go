@@ -0,0 +1,106 @@
+package core
+
+import (
+	"errors"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestHTMLTemplatesLayout(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html":  {Data: []byte(`<title>{{block "title" .}}Shop{{end}}</title><main>{{block "content" .}}{{end}}</main>`)},
+		"layouts/nav.html":   {Data: []byte(`{{define "nav"}}<nav>{{upper .User}}</nav>{{end}}`)},
+		"pages/index.html":   {Data: []byte(`{{define "content"}}{{template "nav" .}}Hello {{.User}}{{end}}`)},
+		"pages/product.html": {Data: []byte(`{{define "title"}}{{.Name}}{{end}}{{define "content"}}{{.Name}}{{end}}`)},
+	}
+	templates, err := NewHTMLTemplates(HTMLTemplateConfig{
+		FS:       fsys,
+		Patterns: []string{"pages/*.html"},
+		Layouts:  []string{"layouts/*.html"},
+		Layout:   "base.html",
+		Funcs:    template.FuncMap{"upper": strings.ToUpper},
+	})
+	if err != nil {
+		t.Fatalf("NewHTMLTemplates() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{"index.html", map[string]string{"User": "<b>kim</b>"}, `<title>Shop</title><main><nav>&lt;B&gt;KIM&lt;/B&gt;</nav>Hello &lt;b&gt;kim&lt;/b&gt;</main>`},
+		{"product.html", map[string]string{"Name": "Lamp"}, `<title>Lamp</title><main>Lamp</main>`},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		if err := templates.Render(&out, tt.name, tt.data); err != nil {
+			t.Fatalf("Render(%q) returned error: %v", tt.name, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("Render(%q) = %q, want %q", tt.name, out.String(), tt.want)
+		}
+	}
+
+	if err := templates.Render(&strings.Builder{}, "missing.html", nil); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("Render(missing.html) error = %v, want ErrTemplateNotFound", err)
+	}
+}
+
+func TestHTMLTemplatesErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/page.html": {Data: []byte(`a`)},
+		"b/page.html": {Data: []byte(`b`)},
+		"broken.html": {Data: []byte(`{{if}}`)},
+	}
+	for name, config := range map[string]HTMLTemplateConfig{
+		"no match":       {FS: fsys, Patterns: []string{"pages/*.html"}},
+		"bad pattern":    {FS: fsys, Patterns: []string{"[*.html"}},
+		"duplicate name": {FS: fsys, Patterns: []string{"a/*.html", "b/*.html"}},
+		"parse error":    {FS: fsys, Patterns: []string{"broken.html"}},
+	} {
+		if _, err := NewHTMLTemplates(config); err == nil {
+			t.Errorf("%s: NewHTMLTemplates() returned no error", name)
+		}
+	}
+}
+
+func TestHTMLTemplatesReload(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(page, []byte(`v1 {{.}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, reload := range []bool{false, true} {
+		if err := os.WriteFile(page, []byte(`v1 {{.}}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		templates, err := NewHTMLTemplates(HTMLTemplateConfig{
+			Patterns: []string{filepath.Join(dir, "*.html")},
+			Reload:   reload,
+		})
+		if err != nil {
+			t.Fatalf("NewHTMLTemplates() returned error: %v", err)
+		}
+		if err := os.WriteFile(page, []byte(`v2 {{.}}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		var out strings.Builder
+		if err := templates.Render(&out, "index.html", "home"); err != nil {
+			t.Fatalf("Render() returned error: %v", err)
+		}
+		want := "v1 home"
+		if reload {
+			want = "v2 home"
+		}
+		if out.String() != want {
+			t.Errorf("Reload %v: Render() = %q, want %q", reload, out.String(), want)
+		}
+	}
+}
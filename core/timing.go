@@ -0,0 +1,27 @@
+package core
+
+import "time"
+
+// ContextKeyStartTime is the context key under which the start time of the request is stored.
+const ContextKeyStartTime = "start_time"
+
+// RequestStartTime returns the start time of the request, reading the clock of the request on
+// first use, so that the first middleware asking for it, e.g. the clock or logging middleware,
+// sets it for all others. The logging, metrics and other middleware measuring the request thus
+// share one clock reading. It is used by Context.StartTime implementations.
+func RequestStartTime(c Context) time.Time {
+	if start, ok := c.Get(ContextKeyStartTime); ok {
+		return start.(time.Time)
+	}
+
+	start := ClockFromContext(c.Request().Context()).Now()
+	c.Set(ContextKeyStartTime, start)
+	return start
+}
+
+// RequestElapsed returns the time elapsed since the start time of the request, measured with the
+// clock of the request. It is used by Context.Elapsed implementations.
+func RequestElapsed(c Context) time.Duration {
+	start := RequestStartTime(c)
+	return ClockFromContext(c.Request().Context()).Now().Sub(start)
+}
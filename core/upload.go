@@ -0,0 +1,222 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// UploadPart describes a file part of a multipart upload.
+type UploadPart struct {
+	// FieldName is the name of the form field
+	FieldName string
+	// FileName is the file name sent by the client; it must not be trusted as a path
+	FileName string
+	// ContentType is the Content-Type of the part, if sent
+	ContentType string
+	// Header holds all headers of the part
+	Header textproto.MIMEHeader
+}
+
+// UploadedFile describes a file stored by StreamMultipart.
+type UploadedFile struct {
+	FieldName   string `json:"field_name"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+}
+
+// UploadResult is the result of StreamMultipart.
+type UploadResult struct {
+	// Files lists the stored files in the order they were received
+	Files []UploadedFile
+	// Fields holds the values of the non-file form fields
+	Fields url.Values
+}
+
+// UploadProgress reports the progress of a streamed upload.
+type UploadProgress struct {
+	// Part is the file part being stored
+	Part UploadPart
+	// Bytes is the number of bytes of the current file read so far
+	Bytes int64
+	// TotalBytes is the number of file bytes of the whole request read so far
+	TotalBytes int64
+}
+
+// Uploader stores the files of a streamed multipart upload, e.g. in S3.
+type Uploader interface {
+	// Upload stores the file part, reading r until io.EOF or an error.
+	// Errors returned by r, such as size limit violations, must be returned as they are.
+	Upload(ctx context.Context, part UploadPart, r io.Reader) error
+}
+
+// UploaderFunc adapts a function to the Uploader interface.
+// For S3, wrap the multipart upload manager of the AWS SDK, which uploads r part by part:
+//
+//	core.UploaderFunc(func(ctx context.Context, part core.UploadPart, r io.Reader) error {
+//		_, err := s3Uploader.Upload(ctx, &s3.PutObjectInput{Bucket: &bucket, Key: aws.String(uuid.NewString()), Body: r})
+//		return err
+//	})
+type UploaderFunc func(ctx context.Context, part UploadPart, r io.Reader) error
+
+// Upload calls f(ctx, part, r).
+func (f UploaderFunc) Upload(ctx context.Context, part UploadPart, r io.Reader) error {
+	return f(ctx, part, r)
+}
+
+// WriterUploader returns an Uploader that copies every file into the writer returned by open.
+// If the writer is an io.Closer, it is closed after the copy.
+func WriterUploader(open func(part UploadPart) (io.Writer, error)) Uploader {
+	return UploaderFunc(func(ctx context.Context, part UploadPart, r io.Reader) error {
+		w, err := open(part)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, r)
+		if closer, ok := w.(io.Closer); ok {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	})
+}
+
+// UploadConfig holds the limits and callbacks of StreamMultipart.
+type UploadConfig struct {
+	// MaxFileSize is the maximum size of a single file in bytes; 0 means no limit
+	MaxFileSize int64
+	// MaxTotalSize is the maximum size of all files together in bytes; 0 means no limit
+	MaxTotalSize int64
+	// MaxFiles is the maximum number of files; 0 means no limit
+	MaxFiles int
+	// MaxFieldSize is the maximum size of a non-file field value in bytes.
+	// If not set, it defaults to 1 MB.
+	MaxFieldSize int64
+	// OnProgress is called after every read of file data, e.g. to report progress to a job store
+	OnProgress func(progress UploadProgress)
+}
+
+// defaultMaxFieldSize is the default maximum size of a non-file field value.
+const defaultMaxFieldSize = 1 << 20
+
+// StreamMultipart reads the multipart/form-data body of r and passes each file part to uploader
+// as it arrives, so that files are never held in memory or on disk as a whole.
+// Non-file fields are collected in the result.
+//
+// Size limit violations return an *http.MaxBytesError, which the error handler maps to
+// 413 Request Entity Too Large, and more than MaxFiles files return a 400 HTTPError.
+// Requests that are not multipart return http.ErrNotMultipart.
+func StreamMultipart(r *http.Request, uploader Uploader, config *UploadConfig) (*UploadResult, error) {
+	if config == nil {
+		config = &UploadConfig{}
+	}
+	maxFieldSize := config.MaxFieldSize
+	if maxFieldSize <= 0 {
+		maxFieldSize = defaultMaxFieldSize
+	}
+
+	// Reject uploads announced as too large before reading anything
+	if config.MaxTotalSize > 0 && r.ContentLength > config.MaxTotalSize+maxFieldSize {
+		return nil, &http.MaxBytesError{Limit: config.MaxTotalSize}
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := r.Context()
+	result := &UploadResult{Fields: make(url.Values)}
+	var total int64
+	for {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+
+		if p.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(p, maxFieldSize+1))
+			p.Close()
+			if err != nil {
+				return result, err
+			}
+			if int64(len(value)) > maxFieldSize {
+				return result, fmt.Errorf("field %q: %w", p.FormName(), &http.MaxBytesError{Limit: maxFieldSize})
+			}
+			result.Fields.Add(p.FormName(), string(value))
+			continue
+		}
+
+		if config.MaxFiles > 0 && len(result.Files) >= config.MaxFiles {
+			p.Close()
+			return result, httperrors.NewBadRequestHttpError(fmt.Errorf("upload has more than %d files", config.MaxFiles))
+		}
+
+		part := UploadPart{
+			FieldName:   p.FormName(),
+			FileName:    p.FileName(),
+			ContentType: p.Header.Get("Content-Type"),
+			Header:      p.Header,
+		}
+		body := &uploadReader{r: p, part: part, total: &total, config: config}
+		err = uploader.Upload(ctx, part, body)
+		if err == nil {
+			// Uploaders may stop reading early; the limits apply to the whole part
+			_, err = io.Copy(io.Discard, body)
+		}
+		p.Close()
+		if err != nil {
+			return result, fmt.Errorf("file %q: %w", part.FileName, err)
+		}
+		result.Files = append(result.Files, UploadedFile{
+			FieldName:   part.FieldName,
+			FileName:    part.FileName,
+			ContentType: part.ContentType,
+			Size:        body.read,
+		})
+	}
+}
+
+// uploadReader enforces the size limits of a file part and reports progress while it is read.
+type uploadReader struct {
+	r      io.Reader
+	part   UploadPart
+	read   int64
+	total  *int64
+	config *UploadConfig
+	err    error
+}
+
+// Read implements io.Reader.
+func (u *uploadReader) Read(b []byte) (int, error) {
+	if u.err != nil {
+		return 0, u.err
+	}
+	n, err := u.r.Read(b)
+	u.read += int64(n)
+	*u.total += int64(n)
+
+	if u.config.MaxFileSize > 0 && u.read > u.config.MaxFileSize {
+		u.err = &http.MaxBytesError{Limit: u.config.MaxFileSize}
+	} else if u.config.MaxTotalSize > 0 && *u.total > u.config.MaxTotalSize {
+		u.err = &http.MaxBytesError{Limit: u.config.MaxTotalSize}
+	}
+	if u.err != nil {
+		return 0, u.err
+	}
+
+	if n > 0 && u.config.OnProgress != nil {
+		u.config.OnProgress(UploadProgress{Part: u.part, Bytes: u.read, TotalBytes: *u.total})
+	}
+	return n, err
+}
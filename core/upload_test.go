@@ -0,0 +1,121 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// newUploadRequest returns a multipart request with the given fields and files.
+func newUploadRequest(t *testing.T, fields map[string]string, files map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := w.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		part, err := w.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(part, content)
+	}
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestStreamMultipart(t *testing.T) {
+	req := newUploadRequest(t, map[string]string{"album": "holiday"}, map[string]string{"a.txt": "hello", "b.txt": "world!"})
+
+	stored := make(map[string]*bytes.Buffer)
+	var progress []UploadProgress
+	uploader := WriterUploader(func(part UploadPart) (io.Writer, error) {
+		stored[part.FileName] = &bytes.Buffer{}
+		return stored[part.FileName], nil
+	})
+
+	result, err := StreamMultipart(req, uploader, &UploadConfig{
+		OnProgress: func(p UploadProgress) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatalf("StreamMultipart() error = %v", err)
+	}
+
+	if got := result.Fields.Get("album"); got != "holiday" {
+		t.Errorf("field album = %q, want holiday", got)
+	}
+	if len(result.Files) != 2 {
+		t.Fatalf("got %d files, want 2", len(result.Files))
+	}
+	for _, file := range result.Files {
+		if file.FieldName != "file" || int64(stored[file.FileName].Len()) != file.Size {
+			t.Errorf("file %+v does not match stored content %q", file, stored[file.FileName])
+		}
+	}
+	if stored["a.txt"].String() != "hello" || stored["b.txt"].String() != "world!" {
+		t.Errorf("stored = %q, %q", stored["a.txt"], stored["b.txt"])
+	}
+	if len(progress) == 0 || progress[len(progress)-1].TotalBytes != 11 {
+		t.Errorf("progress = %+v, want a final total of 11 bytes", progress)
+	}
+}
+
+func TestStreamMultipartLimits(t *testing.T) {
+	discard := UploaderFunc(func(ctx context.Context, part UploadPart, r io.Reader) error {
+		_, err := io.Copy(io.Discard, r)
+		return err
+	})
+	// An uploader that stops reading early must not bypass the limits
+	peek := UploaderFunc(func(ctx context.Context, part UploadPart, r io.Reader) error {
+		_, err := r.Read(make([]byte, 1))
+		return err
+	})
+
+	tests := []struct {
+		name     string
+		uploader Uploader
+		config   *UploadConfig
+		files    map[string]string
+		tooLarge bool
+	}{
+		{"file size", discard, &UploadConfig{MaxFileSize: 5}, map[string]string{"a.txt": "too long"}, true},
+		{"file size with early return", peek, &UploadConfig{MaxFileSize: 5}, map[string]string{"a.txt": "too long"}, true},
+		{"total size", discard, &UploadConfig{MaxTotalSize: 8}, map[string]string{"a.txt": "12345", "b.txt": "67890"}, true},
+		{"file count", discard, &UploadConfig{MaxFiles: 1}, map[string]string{"a.txt": "1", "b.txt": "2"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := StreamMultipart(newUploadRequest(t, nil, tt.files), tt.uploader, tt.config)
+			var maxBytesErr *http.MaxBytesError
+			if tt.tooLarge != errors.As(err, &maxBytesErr) {
+				t.Errorf("StreamMultipart() error = %v, want MaxBytesError: %v", err, tt.tooLarge)
+			}
+			var httpErr httperrors.HTTPError
+			if !tt.tooLarge && !errors.As(err, &httpErr) {
+				t.Errorf("StreamMultipart() error = %v, want an HTTPError", err)
+			}
+		})
+	}
+}
+
+func TestStreamMultipartNotMultipart(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := StreamMultipart(req, WriterUploader(nil), nil); !errors.Is(err, http.ErrNotMultipart) {
+		t.Errorf("StreamMultipart() error = %v, want http.ErrNotMultipart", err)
+	}
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// validate checks the validate tags of bound structs. Failed fields are reported with their JSON names.
+var validate = newValidator()
+
+// newValidator returns a validator reporting fields by their JSON names.
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// RegisterValidation adds a custom rule for validate tags, e.g. RegisterValidation("sku", isSKU)
+// for `validate:"sku"`. It must be called before requests are served.
+func RegisterValidation(tag string, fn validator.Func) error {
+	return validate.RegisterValidation(tag, fn)
+}
+
+// Validate checks the validate tags of obj, e.g. `validate:"required,email"`, and returns a
+// *errors.ValidationError listing every failed field. Values that are not structs or pointers
+// to structs are not validated. BindJSON and ShouldBindJSON call it after decoding the body.
+func Validate(obj interface{}) error {
+	value := reflect.ValueOf(obj)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	err := validate.Struct(value.Interface())
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return httperrors.NewValidationError(validationErrs)
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+func TestValidate(t *testing.T) {
+	type signup struct {
+		Email    string `json:"email" validate:"required,email"`
+		Password string `json:"password,omitempty" validate:"min=8"`
+		Plan     string `validate:"oneof=free pro"`
+		Internal string `json:"-" validate:"required"`
+	}
+
+	if err := Validate(&signup{Email: "john@example.com", Password: "12345678", Plan: "pro", Internal: "x"}); err != nil {
+		t.Fatalf("Validate(valid) = %v, want nil", err)
+	}
+	if err := Validate(map[string]string{}); err != nil {
+		t.Errorf("Validate(map) = %v, want nil", err)
+	}
+
+	err := Validate(signup{Password: "short", Plan: "enterprise", Internal: "x"})
+	var validationErr *httperrors.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Validate(invalid) = %v, want a *ValidationError", err)
+	}
+	want := []httperrors.FieldError{
+		{Field: "email", Rule: "required", Message: "is required"},
+		{Field: "password", Rule: "min", Param: "8", Message: "must be at least 8"},
+		{Field: "Plan", Rule: "oneof", Param: "free pro", Message: "must be one of free, pro"},
+	}
+	if len(validationErr.Fields) != len(want) {
+		t.Fatalf("Fields = %+v, want %+v", validationErr.Fields, want)
+	}
+	for i := range want {
+		if validationErr.Fields[i] != want[i] {
+			t.Errorf("Fields[%d] = %+v, want %+v", i, validationErr.Fields[i], want[i])
+		}
+	}
+	if validationErr.StatusCode() != 400 {
+		t.Errorf("StatusCode() = %d, want 400", validationErr.StatusCode())
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	type item struct {
+		SKU string `json:"sku" validate:"sku"`
+	}
+	if err := RegisterValidation("sku", func(fl validator.FieldLevel) bool {
+		return len(fl.Field().String()) == 6
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Validate(item{SKU: "ABC123"}); err != nil {
+		t.Errorf("Validate(valid SKU) = %v, want nil", err)
+	}
+	if err := Validate(item{SKU: "ABC"}); err == nil || err.Error() != "validation failed: sku failed the sku rule" {
+		t.Errorf("Validate(invalid SKU) = %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	httperrors "github.com/mythofleader/go-http-server/core/middleware/errors"
+)
+
+// DefaultWarmupPath is the default path of the warmup endpoint.
+const DefaultWarmupPath = "/warmup"
+
+// Warmup runs warmup hooks, e.g. to fill caches or prime connection pools, until they succeed once.
+// It is run as a start hook before the server accepts requests, and can also be triggered through
+// an endpoint on platforms that send warmup requests to new instances, such as App Engine.
+type Warmup struct {
+	mu    sync.Mutex
+	hooks []LifecycleHook
+	done  bool
+}
+
+// NewWarmup returns a Warmup running hooks in order.
+func NewWarmup(hooks ...LifecycleHook) *Warmup {
+	return &Warmup{hooks: hooks}
+}
+
+// Run runs the hooks in order and stops at the first error. Once all hooks have succeeded,
+// later calls return nil without running them again; after an error, the next call retries.
+// Concurrent calls wait for the running warmup to finish.
+func (w *Warmup) Run(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return nil
+	}
+	for _, hook := range w.hooks {
+		if err := hook(ctx); err != nil {
+			return err
+		}
+	}
+	w.done = true
+	return nil
+}
+
+// Done returns whether the warmup has completed.
+func (w *Warmup) Done() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.done
+}
+
+// Handler returns a handler that runs the warmup and responds with 200 OK once it has completed,
+// or with 503 Service Unavailable if a hook fails.
+func (w *Warmup) Handler() HandlerFunc {
+	return func(c Context) {
+		if err := w.Run(c.Request().Context()); err != nil {
+			c.JSON(http.StatusServiceUnavailable, httperrors.NewErrorResponse(http.StatusServiceUnavailable, "Warmup failed: "+err.Error()))
+			return
+		}
+		c.JSON(http.StatusOK, map[string]string{"status": "warm"})
+	}
+}
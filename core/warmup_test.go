@@ -0,0 +1,34 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWarmup(t *testing.T) {
+	calls := 0
+	fail := true
+	w := NewWarmup(func(ctx context.Context) error {
+		calls++
+		if fail {
+			return errors.New("database unavailable")
+		}
+		return nil
+	})
+
+	if err := w.Run(context.Background()); err == nil || w.Done() {
+		t.Fatalf("Run() = %v, Done() = %v, want an error", err, w.Done())
+	}
+
+	// Failed warmups are retried, successful ones are not repeated
+	fail = false
+	for i := 0; i < 2; i++ {
+		if err := w.Run(context.Background()); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+	if !w.Done() || calls != 2 {
+		t.Errorf("Done() = %v, calls = %d, want true, 2", w.Done(), calls)
+	}
+}
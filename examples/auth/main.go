@@ -146,12 +146,6 @@ func (s *LegacyUserService) LookupUserByJWT(claims server.MapClaims) (interface{
 }
 
 func main() {
-	// Create a new server
-	srv, err := server.NewServer(server.FrameworkStdHTTP, "8080", false)
-	if err != nil {
-		log.Fatalf("Failed to create server: %v", err)
-	}
-
 	// Create a user store
 	userStore := NewUserStore()
 
@@ -160,8 +154,7 @@ func main() {
 	jwtService := NewJWTService(userStore)
 
 	// Create other services for demonstration purposes
-	// These are commented out in the examples below
-	// Uncomment the appropriate section to use a different authentication method
+	// They are used in the commented-out alternatives below
 	var basicAuthService = NewBasicAuthService(userStore) // For BasicAuth example
 	var legacyService = NewLegacyUserService(userStore)   // For legacy example
 
@@ -169,38 +162,37 @@ func main() {
 	_ = basicAuthService
 	_ = legacyService
 
-	// Choose which authentication method to use
-	// For this example, we'll use the specific JWTUserLookup interface
-	authConfig := &server.AuthConfig{
-		JWTLookup: jwtService, // Only implement JWTUserLookup
-		AuthType:  server.AuthTypeJWT,
-		JWTSecret: "your-secret-key",
-		// Skip authentication for specific paths
-		SkipPaths: []string{
+	// Create the server with JWT authentication, using the specific JWTUserLookup interface.
+	// The auth middleware is added in its canonical position, after logging.
+	srv, err := server.NewServerBuilder(server.FrameworkStdHTTP, "8080").
+		WithFrameworkLogs(false).
+		WithJWTAuth(jwtService, "your-secret-key",
+			// Skip authentication for specific paths
+			"/",                      // Exact path match
 			"/public",                // Exact path match
 			"/api/docs/*",            // Wildcard pattern - matches all paths starting with /api/docs/
 			"/api/users/:id/profile", // Parameter pattern - matches paths like /api/users/123/profile
-		},
+		).
+		Build()
+	if err != nil {
+		log.Fatalf("Failed to create server: %v", err)
 	}
 
 	// Alternatively, you could use the specific BasicAuthUserLookup interface
-	// Uncomment this section and comment out the JWT section above
-	// authConfig := &server.AuthConfig{
-	//     BasicAuthLookup: basicAuthService,
-	//     AuthType:        server.AuthTypeBasic,
-	// }
+	// Replace WithJWTAuth above with:
+	//     WithBasicAuth(basicAuthService, "/", "/public", "/api/docs/*", "/api/users/:id/profile").
 
 	// Or you could use the legacy UserLookupInterface (for backward compatibility)
-	// Uncomment this section and comment out the JWT section above
-	// authConfig := &server.AuthConfig{
-	//     UserLookup: legacyService,
-	//     AuthType:   server.AuthTypeJWT,
-	//     JWTSecret:  "your-secret-key",
-	// }
-
-	// Create a protected route group
+	// Replace WithJWTAuth above with:
+	//     WithAuth(server.AuthConfig{
+	//         UserLookup: legacyService,
+	//         AuthType:   server.AuthTypeJWT,
+	//         JWTSecret:  "your-secret-key",
+	//         SkipPaths:  []string{"/", "/public", "/api/docs/*", "/api/users/:id/profile"},
+	//     }).
+
+	// Create a route group for the protected API
 	protected := srv.Group("/api")
-	protected.Use(server.AuthMiddleware(authConfig))
 
 	// Add a protected route
 	protected.GET("/profile", func(c server.Context) {
@@ -269,7 +261,7 @@ Available endpoints:
 - GET /api/profile            - User profile (authenticated)
 
 The auth middleware is configured to skip authentication for:
-1. Exact path match: "/public"
+1. Exact path matches: "/" and "/public"
 2. Wildcard pattern: "/api/docs/*" (all paths starting with /api/docs/)
 3. Parameter pattern: "/api/users/:id/profile" (paths like /api/users/123/profile)
 
@@ -10,7 +10,6 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"sync"
 	"time"
 
 	server "github.com/mythofleader/go-http-server"
@@ -53,69 +52,6 @@ func (g *SimpleRequestIDGenerator) GenerateRequestID(ctx context.Context) (strin
 // requestKey is used to store and retrieve the request from the context
 type requestKey struct{}
 
-// InMemoryRequestIDStorage is a simple in-memory implementation of the RequestIDStorage interface
-type InMemoryRequestIDStorage struct {
-	requestIDs map[string]bool
-	mutex      sync.RWMutex
-	expiry     time.Duration
-	cleanup    *time.Ticker
-}
-
-// NewInMemoryRequestIDStorage creates a new InMemoryRequestIDStorage
-func NewInMemoryRequestIDStorage(expiry time.Duration) *InMemoryRequestIDStorage {
-	storage := &InMemoryRequestIDStorage{
-		requestIDs: make(map[string]bool),
-		mutex:      sync.RWMutex{},
-		expiry:     expiry,
-		cleanup:    time.NewTicker(expiry / 2), // Run cleanup at half the expiry time
-	}
-
-	// Start a goroutine to clean up expired request IDs
-	go func() {
-		for range storage.cleanup.C {
-			storage.cleanupExpiredIDs()
-		}
-	}()
-
-	return storage
-}
-
-// CheckRequestID checks if a request ID exists in the storage
-func (s *InMemoryRequestIDStorage) CheckRequestID(requestID string) (bool, error) {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-
-	_, exists := s.requestIDs[requestID]
-	return exists, nil
-}
-
-// SaveRequestID saves a request ID to the storage
-func (s *InMemoryRequestIDStorage) SaveRequestID(requestID string) error {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	s.requestIDs[requestID] = true
-	return nil
-}
-
-// cleanupExpiredIDs removes expired request IDs from the storage
-// This is a simplified implementation that just clears all IDs
-// In a real application, you would want to track when each ID was added
-func (s *InMemoryRequestIDStorage) cleanupExpiredIDs() {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
-	// In a real application, you would only remove expired IDs
-	// For simplicity, we're just clearing all IDs in this example
-	s.requestIDs = make(map[string]bool)
-	log.Println("Cleaned up expired request IDs")
-}
-
-// Close stops the cleanup ticker
-func (s *InMemoryRequestIDStorage) Close() {
-	s.cleanup.Stop()
-}
-
 // requestMiddleware is a middleware that stores the request in the context
 func requestMiddleware() server.HandlerFunc {
 	return func(c server.Context) {
@@ -142,8 +78,7 @@ func main() {
 
 	// Create the request ID generator and storage
 	idGenerator := &SimpleRequestIDGenerator{}
-	idStorage := NewInMemoryRequestIDStorage(5 * time.Minute) // IDs expire after 5 minutes
-	defer idStorage.Close()
+	idStorage := server.NewInMemoryRequestIDStorage(5*time.Minute, 0, nil) // IDs expire after 5 minutes
 
 	// Configure the duplicate request middleware
 	dupReqConfig := &server.DuplicateRequestConfig{
@@ -14,7 +14,7 @@ import (
 
 func main() {
 	// Parse command line flags
-	framework := flag.String("framework", "gin", "HTTP framework to use (gin, std)")
+	framework := flag.String("framework", "gin", "HTTP framework to use (gin, std, chi, fiber)")
 	lambdaMode := flag.Bool("lambda", false, "Run in AWS Lambda mode")
 	port := flag.String("port", "8080", "Port to run the server on")
 	env := flag.String("env", "dev", "Environment (dev, prod)")
@@ -29,6 +29,10 @@ func main() {
 		s, err = server.NewServer(server.FrameworkGin, *port, false)
 	case "std":
 		s, err = server.NewServer(server.FrameworkStdHTTP, *port, false)
+	case "chi":
+		s, err = server.NewServer(server.FrameworkChi, *port, false)
+	case "fiber":
+		s, err = server.NewServer(server.FrameworkFiber, *port, false)
 	default:
 		// Default to Gin
 		s, err = server.NewServer(server.FrameworkGin, *port, false)
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/chi"
+	"github.com/mythofleader/go-http-server/core/fiber"
+	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+// FrameworkFactory creates the Server of a framework for NewServer and ServerBuilder.Build.
+// port is never empty, and showLogs reports whether logs about the framework, middleware and
+// routes should be printed to the console.
+type FrameworkFactory func(port string, showLogs bool) core.Server
+
+var (
+	frameworksMu sync.RWMutex
+	frameworks   = map[core.FrameworkType]FrameworkFactory{
+		core.FrameworkGin:     func(port string, showLogs bool) core.Server { return gin.NewServer(port, showLogs) },
+		core.FrameworkStdHTTP: func(port string, showLogs bool) core.Server { return std.NewServer(port, showLogs) },
+		core.FrameworkChi:     func(port string, showLogs bool) core.Server { return chi.NewServer(port, showLogs) },
+		core.FrameworkFiber:   func(port string, showLogs bool) core.Server { return fiber.NewServer(port, showLogs) },
+	}
+)
+
+// RegisterFramework makes a framework available to NewServer and NewServerBuilder under name, so
+// that third-party backends can be used without changing this package. It is typically called
+// from the init function of the package implementing the backend.
+// It panics if name is empty, if factory is nil or if a framework is already registered under
+// name, including the built-in ones.
+//
+// Example usage:
+//
+//	func init() {
+//		server.RegisterFramework("echo", func(port string, showLogs bool) server.Server {
+//			return echoadapter.NewServer(port, showLogs)
+//		})
+//	}
+func RegisterFramework(name core.FrameworkType, factory FrameworkFactory) {
+	if name == "" {
+		panic("server: RegisterFramework called with an empty framework name")
+	}
+	if factory == nil {
+		panic(fmt.Sprintf("server: RegisterFramework called with a nil factory for %q", name))
+	}
+
+	frameworksMu.Lock()
+	defer frameworksMu.Unlock()
+	if _, ok := frameworks[name]; ok {
+		panic(fmt.Sprintf("server: framework %q is already registered", name))
+	}
+	frameworks[name] = factory
+}
+
+// lookupFramework returns the factory registered under name.
+func lookupFramework(name core.FrameworkType) (FrameworkFactory, bool) {
+	frameworksMu.RLock()
+	defer frameworksMu.RUnlock()
+	factory, ok := frameworks[name]
+	return factory, ok
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/servertest"
+	"github.com/mythofleader/go-http-server/core/std"
+)
+
+// customServer stands for a third-party backend, built on the std adapter.
+type customServer struct {
+	*std.Server
+	port string
+}
+
+func TestRegisterFramework(t *testing.T) {
+	const name core.FrameworkType = "custom"
+	// Frameworks stay registered, e.g. when the test is run with -count
+	if _, ok := lookupFramework(name); !ok {
+		RegisterFramework(name, func(port string, showLogs bool) core.Server {
+			return &customServer{Server: std.NewServer(port, showLogs), port: port}
+		})
+	}
+
+	s, err := NewServer(name, "", false)
+	if err != nil {
+		t.Fatalf("NewServer(%q) returned error: %v", name, err)
+	}
+	if custom, ok := s.(*customServer); !ok || custom.port != "8080" {
+		t.Fatalf("NewServer(%q) = %#v, want the custom server on the default port", name, s)
+	}
+
+	built, err := NewServerBuilder(name, "9090").
+		WithFrameworkLogs(false).
+		WithDefaultLogging(false).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	built.GET("/", func(c core.Context) { c.String(http.StatusOK, "ok") })
+	servertest.NewClient(built).GET("/").Expect(t).Status(http.StatusOK).Body("ok")
+
+	if _, err := NewServer("unknown", "8080", false); err == nil {
+		t.Error(`NewServer("unknown") returned no error`)
+	}
+}
+
+func TestRegisterFrameworkPanics(t *testing.T) {
+	factory := func(port string, showLogs bool) core.Server { return std.NewServer(port, showLogs) }
+	tests := []struct {
+		name    string
+		fw      core.FrameworkType
+		factory FrameworkFactory
+	}{
+		{"empty name", "", factory},
+		{"nil factory", "nil-factory", nil},
+		{"built-in", core.FrameworkGin, factory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterFramework(%q) did not panic", tt.fw)
+				}
+			}()
+			RegisterFramework(tt.fw, tt.factory)
+		})
+	}
+}
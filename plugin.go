@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"fmt"
+)
+
+// Plugin packages routes, middleware and lifecycle hooks as a single installable unit.
+// Cross-cutting packages such as metrics, tracing or auth providers implement this
+// interface and are installed with ServerBuilder.UsePlugin.
+type Plugin interface {
+	// Name returns the unique name of the plugin.
+	Name() string
+	// Register adds the plugin's controllers, middleware and configuration to the builder.
+	// It is called by ServerBuilder.Build before the server is assembled.
+	Register(builder *ServerBuilder) error
+	// Start is called before the server starts accepting requests.
+	Start(ctx context.Context) error
+	// Stop is called when the server is stopped or shut down.
+	Stop(ctx context.Context) error
+}
+
+// UsePlugin installs a plugin.
+// Plugins are registered in the order they are added, and their Start hooks run in the same order.
+// A plugin may install the plugins it depends on from its Register method; they are registered
+// after the plugins installed before them.
+func (b *ServerBuilder) UsePlugin(plugin Plugin) *ServerBuilder {
+	b.plugins = append(b.plugins, plugin)
+	return b
+}
+
+// registerPlugins calls Register on every installed plugin, including those installed by Register.
+func (b *ServerBuilder) registerPlugins() error {
+	names := make(map[string]bool, len(b.plugins))
+	for i := 0; i < len(b.plugins); i++ {
+		plugin := b.plugins[i]
+		name := plugin.Name()
+		if names[name] {
+			return fmt.Errorf("plugin %q is installed more than once", name)
+		}
+		names[name] = true
+
+		if err := plugin.Register(b); err != nil {
+			return fmt.Errorf("failed to register plugin %q: %w", name, err)
+		}
+	}
+	return nil
+}
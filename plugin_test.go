@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/servertest"
+)
+
+type testPlugin struct {
+	name   string
+	events []string
+}
+
+func (p *testPlugin) Name() string { return p.name }
+
+func (p *testPlugin) Register(b *ServerBuilder) error {
+	b.AddNamedMiddleware(p.name, func(c core.Context) {
+		c.SetHeader("X-Plugin", p.name)
+	})
+	return nil
+}
+
+func (p *testPlugin) Start(ctx context.Context) error {
+	p.events = append(p.events, "start")
+	return nil
+}
+
+func (p *testPlugin) Stop(ctx context.Context) error {
+	p.events = append(p.events, "stop")
+	return nil
+}
+
+func TestUsePlugin(t *testing.T) {
+	plugin := &testPlugin{name: "metrics"}
+	s, err := NewServerBuilder(core.FrameworkStdHTTP, "8080").
+		WithFrameworkLogs(false).
+		UsePlugin(plugin).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	s.GET("/", func(c core.Context) { c.String(http.StatusOK, "ok") })
+
+	servertest.NewClient(s).GET("/").Expect(t).Header("X-Plugin", "metrics")
+
+	// Shutdown without Run must not call Stop
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() returned error: %v", err)
+	}
+	if len(plugin.events) != 0 {
+		t.Errorf("plugin events = %v, want none before Run", plugin.events)
+	}
+}
+
+func TestUsePluginDuplicate(t *testing.T) {
+	_, err := NewServerBuilder(core.FrameworkStdHTTP, "8080").
+		UsePlugin(&testPlugin{name: "a"}).
+		UsePlugin(&testPlugin{name: "a"}).
+		Build()
+	if err == nil {
+		t.Error("Build() with duplicate plugins did not return error")
+	}
+}
+
+// dependentPlugin installs the plugin it depends on from Register.
+type dependentPlugin struct {
+	testPlugin
+	dependency Plugin
+}
+
+func (p *dependentPlugin) Register(b *ServerBuilder) error {
+	b.UsePlugin(p.dependency)
+	return p.testPlugin.Register(b)
+}
+
+func TestUsePluginFromRegister(t *testing.T) {
+	s, err := NewServerBuilder(core.FrameworkStdHTTP, "8080").
+		WithFrameworkLogs(false).
+		UsePlugin(&dependentPlugin{testPlugin: testPlugin{name: "auth"}, dependency: &testPlugin{name: "sessions"}}).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	s.GET("/", func(c core.Context) { c.String(http.StatusOK, "ok") })
+
+	// The middleware of the dependency, registered last, runs last
+	servertest.NewClient(s).GET("/").Expect(t).Header("X-Plugin", "sessions")
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mythofleader/go-http-server/core"
+)
+
+// discardWriter is a ResponseWriter that discards the response, so benchmarks measure routing.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(statusCode int)  {}
+
+// BenchmarkRouter compares routing in the Gin and standard net/http adapters for common route shapes.
+// Run it with: go test -run '^$' -bench BenchmarkRouter -benchmem
+func BenchmarkRouter(b *testing.B) {
+	paths := map[string]string{
+		"static":     "/health",
+		"param":      "/users/42",
+		"deep":       "/orgs/acme/repos/api/issues/12/comments",
+		"wildcard":   "/static/css/site.css",
+		"not found":  "/missing/path",
+		"many roots": "/v1/accounts/7/settings",
+	}
+
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		s, err := NewServer(frameworkType, "8080", false)
+		if err != nil {
+			b.Fatalf("NewServer(%s) returned error: %v", frameworkType, err)
+		}
+		handler := func(c core.Context) {}
+		s.GET("/health", handler)
+		s.GET("/users", handler)
+		s.GET("/users/:id", handler)
+		s.GET("/users/:id/posts/:postID", handler)
+		s.GET("/orgs/:org/repos/:repo/issues/:number/comments", handler)
+		s.GET("/static/*filepath", handler)
+		for _, version := range []string{"/v1", "/v2", "/v3"} {
+			for _, resource := range []string{"/accounts", "/orders", "/products", "/invoices"} {
+				s.GET(version+resource+"/:id", handler)
+				s.GET(version+resource+"/:id/settings", handler)
+			}
+		}
+		// Run freezes the server, which lets adapters precompute handler chains
+		s.Freeze()
+		h := s.Handler()
+
+		for name, path := range paths {
+			b.Run(string(frameworkType)+"/"+name, func(b *testing.B) {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				w := &discardWriter{header: make(http.Header)}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					h.ServeHTTP(w, req)
+				}
+			})
+		}
+	}
+}
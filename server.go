@@ -6,10 +6,14 @@ import (
 	"fmt"
 
 	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/bench"
 	"github.com/mythofleader/go-http-server/core/gin"
 	"github.com/mythofleader/go-http-server/core/middleware"
 	"github.com/mythofleader/go-http-server/core/middleware/errors"
+	"github.com/mythofleader/go-http-server/core/openapi"
+	"github.com/mythofleader/go-http-server/core/sanitize"
 	"github.com/mythofleader/go-http-server/core/std"
+	"github.com/mythofleader/go-http-server/core/telemetry"
 )
 
 // Re-export types from core package
@@ -22,14 +26,128 @@ type (
 	FrameworkType = core.FrameworkType
 	// HandlerFunc is a function that handles an HTTP request.
 	HandlerFunc = core.HandlerFunc
+	// ResponseWriter is the response writer returned by Context.Writer, recording status and size.
+	ResponseWriter = core.ResponseWriter
+	// StatusWriter wraps an http.ResponseWriter as a ResponseWriter.
+	StatusWriter = core.StatusWriter
 	// RouterGroup is a group of routes.
 	RouterGroup = core.RouterGroup
 	// LoggingConfig holds configuration for the logging middleware.
 	LoggingConfig = core.LoggingConfig
 	// ErrorHandlerConfig holds configuration for the error handler middleware.
 	ErrorHandlerConfig = core.ErrorHandlerConfig
+	// ErrorFormat is the body format of the responses written by the error handler middleware.
+	ErrorFormat = core.ErrorFormat
 	// HttpMethod represents an HTTP method.
 	HttpMethod = core.HttpMethod
+	// LifecycleHook is a function called when a server starts or stops.
+	LifecycleHook = core.LifecycleHook
+	// DynamicRouter holds routes that can be added and removed while the server is serving.
+	DynamicRouter = core.DynamicRouter
+	// Example is an example request and response for a route.
+	Example = core.Example
+	// ExampleProvider is an optional interface for controllers that provide example requests and responses.
+	ExampleProvider = core.ExampleProvider
+	// DocumentedController is an optional interface for controllers that document their route in the OpenAPI document.
+	DocumentedController = core.DocumentedController
+	// RouteDoc documents a route for the generated OpenAPI document.
+	RouteDoc = core.RouteDoc
+	// RouteExamples holds the examples of a single route.
+	RouteExamples = core.RouteExamples
+	// MockConfig holds configuration for mock mode.
+	MockConfig = core.MockConfig
+	// HTTPServerConfig holds timeouts, header limits and TLS settings of the http.Server.
+	HTTPServerConfig = core.HTTPServerConfig
+	// FastPathConfig configures health check paths answered before the middleware chain.
+	FastPathConfig = core.FastPathConfig
+	// JSONCodec encodes the bodies of JSON responses.
+	JSONCodec = core.JSONCodec
+	// JSONPolicy holds serialization policies applied to every JSON response.
+	JSONPolicy = core.JSONPolicy
+	// JSONTimeFormat is the encoding of time.Time values under a JSONPolicy.
+	JSONTimeFormat = core.JSONTimeFormat
+	// EngineOptions holds router and request parsing settings mapped to each framework's native settings.
+	EngineOptions = core.EngineOptions
+	// TrustedProxies is the set of proxies trusted to forward the client IP.
+	TrustedProxies = core.TrustedProxies
+	// LambdaConfig holds the settings of StartLambda.
+	LambdaConfig = core.LambdaConfig
+	// LambdaEventType is the type of the events a Lambda function receives from its trigger.
+	LambdaEventType = core.LambdaEventType
+	// Warmup runs warmup hooks until they succeed once.
+	Warmup = core.Warmup
+	// DependencyCheck checks that a dependency is available before the server accepts traffic.
+	DependencyCheck = core.DependencyCheck
+	// ReadinessConfig configures the startup timeout and backoff of dependency checks.
+	ReadinessConfig = core.ReadinessConfig
+	// DependencyStatus is the state of a dependency check.
+	DependencyStatus = core.DependencyStatus
+	// RouteDrain reports when the in-flight requests of a route disabled with DisableRoute have finished.
+	RouteDrain = core.RouteDrain
+	// Readiness runs dependency checks with retries before the server accepts traffic.
+	Readiness = core.Readiness
+	// Clock provides the current time to the middleware.
+	Clock = core.Clock
+	// IDGenerator generates unique identifiers such as request IDs.
+	IDGenerator = core.IDGenerator
+	// MockedController is an optional interface for controllers that mark their own route as mocked.
+	MockedController = core.MockedController
+	// Deps gives controller factories access to the dependencies provided to the server builder.
+	Deps = core.Deps
+	// ControllerFactory constructs a controller from the dependencies of the server.
+	ControllerFactory = core.ControllerFactory
+	// Container holds the dependencies passed to controller factories.
+	Container = core.Container
+	// MiddlewareSkipper is an optional interface for controllers that opt out of individual middleware.
+	MiddlewareSkipper = core.MiddlewareSkipper
+	// MiddlewareDescription describes a middleware of the request pipeline, as returned by Server.DescribeMiddleware.
+	MiddlewareDescription = core.MiddlewareDescription
+	// MiddlewareChain describes the middleware of a server in the order they run.
+	MiddlewareChain = core.MiddlewareChain
+	// PrioritizedController is an optional interface for controllers that declare the priority class of their route.
+	PrioritizedController = core.PrioritizedController
+	// PreloadingController is an optional interface for controllers that load data before their handlers run.
+	PreloadingController = core.PreloadingController
+	// TemplateEngine renders the named templates of Context.HTML.
+	TemplateEngine = core.TemplateEngine
+	// HTMLTemplateConfig holds configuration for HTMLTemplates.
+	HTMLTemplateConfig = core.HTMLTemplateConfig
+	// HTMLTemplates is a TemplateEngine rendering html/template templates loaded from glob patterns.
+	HTMLTemplates = core.HTMLTemplates
+	// Priority is the priority class of a route, deciding which routes are shed first under overload.
+	Priority = core.Priority
+	// Uploader stores the files of a streamed multipart upload, e.g. in S3.
+	Uploader = core.Uploader
+	// UploaderFunc adapts a function to the Uploader interface.
+	UploaderFunc = core.UploaderFunc
+	// UploadPart describes a file part of a multipart upload.
+	UploadPart = core.UploadPart
+	// UploadConfig holds the limits and callbacks of StreamMultipart.
+	UploadConfig = core.UploadConfig
+	// UploadProgress reports the progress of a streamed upload.
+	UploadProgress = core.UploadProgress
+	// UploadResult is the result of StreamMultipart.
+	UploadResult = core.UploadResult
+	// UploadedFile describes a file stored by StreamMultipart.
+	UploadedFile = core.UploadedFile
+	// LogSink receives log entries from the logging middleware.
+	LogSink = core.LogSink
+	// Logger writes request logs to a structured logging library.
+	Logger = core.Logger
+	// LogLevel is the level of a request log written to a Logger.
+	LogLevel = core.LogLevel
+	// LogRecord is a log entry delivered to a LogSink.
+	LogRecord = core.LogRecord
+	// ParamType is the set of types a path parameter can be parsed into with Param.
+	ParamType = core.ParamType
+)
+
+// Re-export types from bench package
+type (
+	// BenchRoute describes a request sent during Server.SelfBench.
+	BenchRoute = bench.Route
+	// BenchReport holds the results of Server.SelfBench.
+	BenchReport = bench.Report
 )
 
 // Re-export types from middleware package
@@ -40,14 +158,52 @@ type (
 	AuthConfig = middleware.AuthConfig
 	// APIKeyConfig holds configuration for the API key middleware.
 	APIKeyConfig = middleware.APIKeyConfig
+	// ConfigError reports an invalid middleware configuration.
+	ConfigError = middleware.ConfigError
+	// PolicyConfig holds configuration for the policy middleware.
+	PolicyConfig = middleware.PolicyConfig
+	// PolicyFunc decides whether a request may proceed, given the authenticated user and the route.
+	PolicyFunc = middleware.PolicyFunc
+	// Decision is the result of a policy evaluation.
+	Decision = middleware.Decision
+	// Obligation is an action a policy requires before the request may proceed.
+	Obligation = middleware.Obligation
+	// OPAConfig holds configuration for the OPA middleware.
+	OPAConfig = middleware.OPAConfig
+	// OPAInput is the input document sent to Open Policy Agent for each request.
+	OPAInput = middleware.OPAInput
+	// OPAResult is the decision of an OPA policy.
+	OPAResult = middleware.OPAResult
+	// OPAEvaluator evaluates an OPA policy, e.g. through an OPA sidecar or an embedded rego query.
+	OPAEvaluator = middleware.OPAEvaluator
+	// OPAEvaluatorFunc is an adapter to allow the use of ordinary functions as OPA evaluators.
+	OPAEvaluatorFunc = middleware.OPAEvaluatorFunc
 	// CORSConfig holds configuration for the CORS middleware.
 	CORSConfig = middleware.CORSConfig
+	// CORSStats records the decisions of a CORS middleware for troubleshooting.
+	CORSStats = middleware.CORSStats
+	// CORSDecision describes how the CORS middleware handled a cross-origin request.
+	CORSDecision = middleware.CORSDecision
+	// DebugTraceConfig holds configuration for the debug tracer.
+	DebugTraceConfig = middleware.DebugTraceConfig
+	// DebugTracer records what each middleware did with requests carrying a secret header.
+	DebugTracer = middleware.DebugTracer
+	// DebugTrace is the trace of a request.
+	DebugTrace = middleware.DebugTrace
+	// DebugTraceStep describes what a middleware did with a traced request.
+	DebugTraceStep = middleware.DebugTraceStep
+	// SecurityHeadersConfig holds configuration for the security headers middleware.
+	SecurityHeadersConfig = middleware.SecurityHeadersConfig
+	// ChecksumConfig holds configuration for the checksum middleware.
+	ChecksumConfig = middleware.ChecksumConfig
 	// DuplicateRequestConfig holds configuration for the duplicate request prevention middleware.
 	DuplicateRequestConfig = middleware.DuplicateRequestConfig
 	// RequestIDGenerator defines the interface for generating request IDs.
 	RequestIDGenerator = middleware.RequestIDGenerator
 	// RequestIDStorage defines the interface for checking and storing request IDs.
 	RequestIDStorage = middleware.RequestIDStorage
+	// InMemoryRequestIDStorage is a RequestIDStorage keeping request IDs in memory for a fixed time.
+	InMemoryRequestIDStorage = middleware.InMemoryRequestIDStorage
 	// BasicAuthUserLookup defines the interface for looking up users based on Basic Auth credentials.
 	BasicAuthUserLookup = middleware.BasicAuthUserLookup
 	// JWTUserLookup defines the interface for looking up users based on JWT claims.
@@ -56,6 +212,70 @@ type (
 	MapClaims = middleware.MapClaims
 	// AuthType represents the type of authentication to use.
 	AuthType = middleware.AuthType
+	// ConcurrencyLimitConfig holds configuration for the concurrency limit middleware.
+	ConcurrencyLimitConfig = middleware.ConcurrencyLimitConfig
+	// WatchdogConfig holds configuration for the watchdog.
+	WatchdogConfig = middleware.WatchdogConfig
+	// WatchdogStats is a sample of the resource usage of the process.
+	WatchdogStats = middleware.WatchdogStats
+	// Watchdog rejects low-priority routes while the heap or goroutine count is above its threshold.
+	Watchdog = middleware.Watchdog
+	// IPConcurrencyConfig holds configuration for the per-IP concurrency guard middleware.
+	IPConcurrencyConfig = middleware.IPConcurrencyConfig
+	// RequestIDConfig holds configuration for the request ID middleware.
+	RequestIDConfig = middleware.RequestIDConfig
+	// OutboxConfig holds configuration for the outbox.
+	OutboxConfig = middleware.OutboxConfig
+	// OutboxEvent is a domain event staged by a handler, published once its response has been written.
+	OutboxEvent = middleware.OutboxEvent
+	// OutboxPublisher publishes the domain events of a request, e.g. to a message broker.
+	OutboxPublisher = middleware.OutboxPublisher
+	// OutboxPublisherFunc is an adapter to allow the use of ordinary functions as outbox publishers.
+	OutboxPublisherFunc = middleware.OutboxPublisherFunc
+	// Outbox publishes the domain events staged by handlers once their response has been written.
+	Outbox = middleware.Outbox
+	// QueryLimitConfig holds the limits of the query limit middleware.
+	QueryLimitConfig = middleware.QueryLimitConfig
+	// CachePolicy sets the caching headers of the responses of a route.
+	CachePolicy = middleware.CachePolicy
+	// DigestAlgorithm is a hash algorithm of the response digest middleware.
+	DigestAlgorithm = middleware.DigestAlgorithm
+	// ResponseDigestConfig holds configuration for the response digest middleware.
+	ResponseDigestConfig = middleware.ResponseDigestConfig
+	// AffinityConfig holds configuration for the deployment affinity middleware.
+	AffinityConfig = middleware.AffinityConfig
+	// RateLimitConfig holds configuration for the rate limit middleware.
+	RateLimitConfig = middleware.RateLimitConfig
+	// RateLimit is a token bucket limit of the rate limit middleware.
+	RateLimit = middleware.RateLimit
+	// RateLimitStore stores the token buckets of the rate limit middleware, e.g. in Redis.
+	RateLimitStore = middleware.RateLimitStore
+	// TenantRateLimitConfig holds configuration for the tenant rate limit middleware.
+	TenantRateLimitConfig = middleware.TenantRateLimitConfig
+	// TenantLimitStore loads the rate limits of tenants, e.g. from the plans of their subscriptions.
+	TenantLimitStore = middleware.TenantLimitStore
+	// TenantLimits is a TenantLimitStore with fixed limits by tenant.
+	TenantLimits = middleware.TenantLimits
+	// BatchSinkConfig holds configuration for batching log sinks.
+	BatchSinkConfig = middleware.BatchSinkConfig
+	// KafkaSinkConfig holds configuration for the Kafka log sink.
+	KafkaSinkConfig = middleware.KafkaSinkConfig
+	// FileSinkConfig holds configuration for the rotating file log sink.
+	FileSinkConfig = middleware.FileSinkConfig
+	// NATSSinkConfig holds configuration for the NATS JetStream log sink.
+	NATSSinkConfig = middleware.NATSSinkConfig
+	// TelemetryOptions holds configuration for the OpenTelemetry exporters used by WithOpenTelemetry.
+	TelemetryOptions = telemetry.Options
+	// OpenAPIOptions holds configuration for the OpenAPI document and Swagger UI page served by WithOpenAPI.
+	OpenAPIOptions = openapi.Options
+	// SanitizePolicy describes which HTML is kept when sanitizing user-generated content.
+	SanitizePolicy = sanitize.Policy
+	// ResponseTransformer rewrites a decoded JSON response payload.
+	ResponseTransformer = middleware.ResponseTransformer
+	// KeyCase is a JSON object key naming convention.
+	KeyCase = middleware.KeyCase
+	// JSONKeyCaseConfig holds configuration for the JSON key case conversion middleware.
+	JSONKeyCaseConfig = middleware.JSONKeyCaseConfig
 )
 
 // Re-export types from middleware/errors package
@@ -64,6 +284,8 @@ type (
 	ErrorDetail = errors.ErrorDetail
 	// ErrorResponse represents the structure of an error response.
 	ErrorResponse = errors.ErrorResponse
+	// ProblemDetails represents an RFC 7807 problem details response.
+	ProblemDetails = errors.ProblemDetails
 
 	// Error structs that embed the error interface
 	// BadRequestHttpError represents a 400 Bad Request error.
@@ -80,6 +302,34 @@ type (
 	InternalServerHttpError = errors.InternalServerHttpError
 	// ServiceUnavailableHttpError represents a 503 Service Unavailable error.
 	ServiceUnavailableHttpError = errors.ServiceUnavailableHttpError
+	// PaymentRequiredHttpError represents a 402 Payment Required error.
+	PaymentRequiredHttpError = errors.PaymentRequiredHttpError
+	// NotAcceptableHttpError represents a 406 Not Acceptable error.
+	NotAcceptableHttpError = errors.NotAcceptableHttpError
+	// RequestTimeoutHttpError represents a 408 Request Timeout error.
+	RequestTimeoutHttpError = errors.RequestTimeoutHttpError
+	// ConflictHttpError represents a 409 Conflict error.
+	ConflictHttpError = errors.ConflictHttpError
+	// GoneHttpError represents a 410 Gone error.
+	GoneHttpError = errors.GoneHttpError
+	// PreconditionFailedHttpError represents a 412 Precondition Failed error.
+	PreconditionFailedHttpError = errors.PreconditionFailedHttpError
+	// UnsupportedMediaTypeHttpError represents a 415 Unsupported Media Type error.
+	UnsupportedMediaTypeHttpError = errors.UnsupportedMediaTypeHttpError
+	// UnprocessableEntityHttpError represents a 422 Unprocessable Entity error.
+	UnprocessableEntityHttpError = errors.UnprocessableEntityHttpError
+	// TooManyRequestsHttpError represents a 429 Too Many Requests error.
+	TooManyRequestsHttpError = errors.TooManyRequestsHttpError
+	// NotImplementedHttpError represents a 501 Not Implemented error.
+	NotImplementedHttpError = errors.NotImplementedHttpError
+	// BadGatewayHttpError represents a 502 Bad Gateway error.
+	BadGatewayHttpError = errors.BadGatewayHttpError
+	// GatewayTimeoutHttpError represents a 504 Gateway Timeout error.
+	GatewayTimeoutHttpError = errors.GatewayTimeoutHttpError
+	// ValidationError is returned by binding when the request fails struct validation.
+	ValidationError = errors.ValidationError
+	// FieldError describes a field of a request that failed validation.
+	FieldError = errors.FieldError
 )
 
 // Re-export constants from core package
@@ -88,6 +338,60 @@ const (
 	FrameworkGin = core.FrameworkGin
 	// FrameworkStdHTTP represents the standard net/http package.
 	FrameworkStdHTTP = core.FrameworkStdHTTP
+	// FrameworkChi represents the chi router.
+	FrameworkChi = core.FrameworkChi
+	// FrameworkFiber represents the fiber framework, which serves requests with fasthttp.
+	FrameworkFiber = core.FrameworkFiber
+
+	// DefaultWarmupPath is the default path of the warmup endpoint.
+	DefaultWarmupPath = core.DefaultWarmupPath
+	// DefaultReadinessPath is the default path of the readiness endpoint.
+	DefaultReadinessPath = core.DefaultReadinessPath
+	// DefaultOpenAPIUIPath is the default path of the Swagger UI page served by WithOpenAPI.
+	DefaultOpenAPIUIPath = openapi.DefaultUIPath
+
+	// ErrorFormatDefault writes {"error": {"code": ..., "message": ...}} error responses.
+	ErrorFormatDefault = core.ErrorFormatDefault
+	// ErrorFormatProblemJSON writes RFC 7807 application/problem+json error responses.
+	ErrorFormatProblemJSON = core.ErrorFormatProblemJSON
+	// ProblemJSONContentType is the content type of RFC 7807 problem details responses.
+	ProblemJSONContentType = errors.ProblemJSONContentType
+
+	// JSONTimeRFC3339 encodes times as RFC 3339 strings with second precision.
+	JSONTimeRFC3339 = core.JSONTimeRFC3339
+	// JSONTimeRFC3339Nano encodes times as RFC 3339 strings with nanosecond precision.
+	JSONTimeRFC3339Nano = core.JSONTimeRFC3339Nano
+	// JSONTimeUnix encodes times as Unix timestamps in seconds.
+	JSONTimeUnix = core.JSONTimeUnix
+	// JSONTimeUnixMilli encodes times as Unix timestamps in milliseconds.
+	JSONTimeUnixMilli = core.JSONTimeUnixMilli
+
+	// LogLevelInfo is used for successful requests.
+	LogLevelInfo = core.LogLevelInfo
+	// LogLevelWarn is used for requests answered with a 4xx status.
+	LogLevelWarn = core.LogLevelWarn
+	// LogLevelError is used for requests answered with a 5xx status.
+	LogLevelError = core.LogLevelError
+
+	// PlatformGoogleAppEngine is the client IP header of Google App Engine.
+	PlatformGoogleAppEngine = core.PlatformGoogleAppEngine
+	// PlatformCloudflare is the client IP header of Cloudflare's CDN.
+	PlatformCloudflare = core.PlatformCloudflare
+	// PlatformFlyIO is the client IP header of Fly.io.
+	PlatformFlyIO = core.PlatformFlyIO
+
+	// LambdaEventAuto detects the type of each Lambda event from its payload.
+	LambdaEventAuto = core.LambdaEventAuto
+	// LambdaEventALB is an Application Load Balancer target group event.
+	LambdaEventALB = core.LambdaEventALB
+	// LambdaEventAPIGatewayV1 is an API Gateway REST API event (payload format 1.0).
+	LambdaEventAPIGatewayV1 = core.LambdaEventAPIGatewayV1
+	// LambdaEventAPIGatewayV2 is an API Gateway HTTP API event (payload format 2.0).
+	LambdaEventAPIGatewayV2 = core.LambdaEventAPIGatewayV2
+	// LambdaEventFunctionURL is a Lambda function URL event.
+	LambdaEventFunctionURL = core.LambdaEventFunctionURL
+	// DefaultMaxMultipartMemory is the default memory used to parse multipart forms.
+	DefaultMaxMultipartMemory = core.DefaultMaxMultipartMemory
 
 	// HTTP methods
 	// GET represents the HTTP GET method.
@@ -100,6 +404,56 @@ const (
 	DELETE = core.DELETE
 	// PATCH represents the HTTP PATCH method.
 	PATCH = core.PATCH
+
+	// Names of the default middleware, for use with MiddlewareSkipper
+	// MiddlewareDebugTrace is the name of the debug trace middleware.
+	MiddlewareDebugTrace = core.MiddlewareDebugTrace
+	// MiddlewareClock is the name of the clock middleware.
+	MiddlewareClock = core.MiddlewareClock
+	// MiddlewareOpenTelemetry is the name of the OpenTelemetry middleware.
+	MiddlewareOpenTelemetry = core.MiddlewareOpenTelemetry
+	// MiddlewareRequestID is the name of the request ID middleware.
+	MiddlewareRequestID = core.MiddlewareRequestID
+	// MiddlewareTemplates is the name of the template engine middleware.
+	MiddlewareTemplates = core.MiddlewareTemplates
+	// MiddlewareOutbox is the name of the outbox middleware.
+	MiddlewareOutbox = core.MiddlewareOutbox
+	// MiddlewareErrorHandler is the name of the error handler middleware.
+	MiddlewareErrorHandler = core.MiddlewareErrorHandler
+	// MiddlewareHeaderLimit is the name of the header limit middleware.
+	MiddlewareHeaderLimit = core.MiddlewareHeaderLimit
+	// MiddlewareBodyLimit is the name of the body limit middleware.
+	MiddlewareBodyLimit = core.MiddlewareBodyLimit
+	// MiddlewareQueryLimit is the name of the query limit middleware.
+	MiddlewareQueryLimit = core.MiddlewareQueryLimit
+	// MiddlewareResponseLimit is the name of the response limit middleware.
+	MiddlewareResponseLimit = core.MiddlewareResponseLimit
+	// MiddlewareCachePolicy is the name of the cache policy middleware.
+	MiddlewareCachePolicy = core.MiddlewareCachePolicy
+	// MiddlewareResponseDigest is the name of the response digest middleware.
+	MiddlewareResponseDigest = core.MiddlewareResponseDigest
+	// MiddlewareWatchdog is the name of the watchdog middleware.
+	MiddlewareWatchdog = core.MiddlewareWatchdog
+
+	// Priority classes of routes, for use with PrioritizedController
+	// PriorityCritical routes, such as health checks and payments, are never shed.
+	PriorityCritical = core.PriorityCritical
+	// PriorityNormal routes are shed only under severe overload. Routes are normal by default.
+	PriorityNormal = core.PriorityNormal
+	// PriorityBestEffort routes, such as analytics, are shed first.
+	PriorityBestEffort = core.PriorityBestEffort
+	// MiddlewareTimeout is the name of the timeout middleware.
+	MiddlewareTimeout = core.MiddlewareTimeout
+	// MiddlewareCORS is the name of the CORS middleware.
+	MiddlewareCORS = core.MiddlewareCORS
+	// MiddlewareLogging is the name of the logging middleware.
+	MiddlewareLogging = core.MiddlewareLogging
+	// MiddlewareAuth is the name of the authorization middleware.
+	MiddlewareAuth = core.MiddlewareAuth
+	// MiddlewarePolicy is the name of the policy middleware.
+	MiddlewarePolicy = core.MiddlewarePolicy
+	// AllRoutes is the MiddlewareDescription.AppliesTo value of middleware running on every route.
+	AllRoutes = core.AllRoutes
 )
 
 // Re-export constants from middleware package
@@ -108,6 +462,43 @@ const (
 	AuthTypeBasic = middleware.AuthTypeBasic
 	// AuthTypeJWT represents JWT Bearer token authentication.
 	AuthTypeJWT = middleware.AuthTypeJWT
+	// DefaultJWKSRefreshInterval is how long the keys of a JWKS are cached by default.
+	DefaultJWKSRefreshInterval = middleware.DefaultJWKSRefreshInterval
+	// DefaultRequestIDTTL is how long InMemoryRequestIDStorage remembers request IDs by default.
+	DefaultRequestIDTTL = middleware.DefaultRequestIDTTL
+	// DefaultRequestIDMaxSize is the maximum number of request IDs InMemoryRequestIDStorage keeps by default.
+	DefaultRequestIDMaxSize = middleware.DefaultRequestIDMaxSize
+	// DefaultRequestIDHeader is the header carrying request IDs by default.
+	DefaultRequestIDHeader = middleware.DefaultRequestIDHeader
+	// DefaultTenantLimitCacheTTL is how long the limits loaded from a TenantLimitStore are cached by default.
+	DefaultTenantLimitCacheTTL = middleware.DefaultTenantLimitCacheTTL
+	// AffinityHeader marks the requests proxied to a peer deployment by the affinity middleware.
+	AffinityHeader = middleware.AffinityHeader
+
+	// DigestMD5 sends the MD5 of file and byte responses in the Content-MD5 and Digest headers.
+	DigestMD5 = middleware.DigestMD5
+	// DigestSHA256 sends the SHA-256 of file and byte responses in the Digest and Content-Digest headers.
+	DigestSHA256 = middleware.DigestSHA256
+	// DigestSHA512 sends the SHA-512 of file and byte responses in the Digest and Content-Digest headers.
+	DigestSHA512 = middleware.DigestSHA512
+
+	// KeyCaseCamel converts JSON keys to camelCase.
+	KeyCaseCamel = middleware.KeyCaseCamel
+	// KeyCaseSnake converts JSON keys to snake_case.
+	KeyCaseSnake = middleware.KeyCaseSnake
+
+	// DefaultMaxHeaderCount is the maximum number of request headers allowed by WithHardenedDefaults.
+	DefaultMaxHeaderCount = middleware.DefaultMaxHeaderCount
+	// DefaultMaxBodySize is the maximum request body size allowed by WithHardenedDefaults.
+	DefaultMaxBodySize = middleware.DefaultMaxBodySize
+	// DefaultCORSDebugPath is the default path of the CORS debug endpoint.
+	DefaultCORSDebugPath = middleware.DefaultCORSDebugPath
+	// DefaultDebugTraceHeader is the default request header carrying the debug trace secret.
+	DefaultDebugTraceHeader = middleware.DefaultDebugTraceHeader
+	// DebugTraceIDHeader is the response header carrying the ID of the debug trace of a request.
+	DebugTraceIDHeader = middleware.DebugTraceIDHeader
+	// DefaultDebugTracePath is the default path of the debug trace endpoint.
+	DefaultDebugTracePath = middleware.DefaultDebugTracePath
 )
 
 // Re-export types from gin package
@@ -130,12 +521,134 @@ var (
 	AuthMiddleware = middleware.AuthMiddleware
 	// APIKeyMiddleware returns a middleware function that checks for a valid API key.
 	APIKeyMiddleware = middleware.APIKeyMiddleware
+	// TryAuthMiddleware is like AuthMiddleware, but returns a *ConfigError instead of panicking.
+	TryAuthMiddleware = middleware.TryAuthMiddleware
+	// TryAPIKeyMiddleware is like APIKeyMiddleware, but returns a *ConfigError instead of panicking.
+	TryAPIKeyMiddleware = middleware.TryAPIKeyMiddleware
+	// PolicyMiddleware returns a middleware function that evaluates an access control policy before the handler runs.
+	PolicyMiddleware = middleware.PolicyMiddleware
+	// DefaultPolicyConfig returns a default policy configuration.
+	DefaultPolicyConfig = middleware.DefaultPolicyConfig
+	// PolicyAllow returns a Decision allowing the request once the obligations are fulfilled.
+	PolicyAllow = middleware.PolicyAllow
+	// PolicyDeny returns a Decision denying the request for a reason.
+	PolicyDeny = middleware.PolicyDeny
+	// OPAMiddleware returns a middleware function that enforces the decisions of Open Policy Agent.
+	OPAMiddleware = middleware.OPAMiddleware
+	// DefaultOPAConfig returns a default OPA configuration.
+	DefaultOPAConfig = middleware.DefaultOPAConfig
+	// NewOPAClient returns an OPA evaluator querying the Data API of an OPA server.
+	NewOPAClient = middleware.NewOPAClient
 	// CORSMiddleware returns a middleware function that handles CORS (Cross-Origin Resource Sharing).
 	CORSMiddleware = middleware.CORSMiddleware
+	// NewCORSStats returns a CORSStats keeping the given number of recent decisions.
+	NewCORSStats = middleware.NewCORSStats
+	// NewDebugTracer returns a DebugTracer.
+	NewDebugTracer = middleware.NewDebugTracer
+	// DefaultCORSConfig returns a default CORS configuration.
+	DefaultCORSConfig = middleware.DefaultCORSConfig
+	// DefaultTimeoutConfig returns a default timeout configuration.
+	DefaultTimeoutConfig = middleware.DefaultTimeoutConfig
+	// DefaultAuthConfig returns a default auth configuration.
+	DefaultAuthConfig = middleware.DefaultAuthConfig
+	// DefaultAPIKeyConfig returns a default API key configuration.
+	DefaultAPIKeyConfig = middleware.DefaultAPIKeyConfig
+	// SecurityHeadersMiddleware returns a middleware function that sets security headers, including a CSP with a per-request nonce.
+	SecurityHeadersMiddleware = middleware.SecurityHeadersMiddleware
+	// ChecksumMiddleware returns a middleware function that verifies request bodies against their checksum headers.
+	ChecksumMiddleware = middleware.ChecksumMiddleware
 	// DuplicateRequestMiddleware returns a middleware function that prevents duplicate requests.
 	DuplicateRequestMiddleware = middleware.DuplicateRequestMiddleware
+	// NewInMemoryRequestIDStorage returns an in-memory RequestIDStorage with a TTL and a maximum size.
+	NewInMemoryRequestIDStorage = middleware.NewInMemoryRequestIDStorage
+	// ConcurrencyLimitMiddleware returns a middleware function that limits concurrent executions per route template.
+	ConcurrencyLimitMiddleware = middleware.ConcurrencyLimitMiddleware
+	// NewConcurrencyLimit returns a middleware function that limits concurrent executions of a single route.
+	NewConcurrencyLimit = middleware.NewConcurrencyLimit
+	// IPConcurrencyMiddleware returns a middleware function that limits simultaneous requests per client IP.
+	IPConcurrencyMiddleware = middleware.IPConcurrencyMiddleware
+	// AffinityMiddleware returns a middleware function that pins clients to a blue/green deployment with a cookie.
+	AffinityMiddleware = middleware.AffinityMiddleware
+	// TryAffinityMiddleware is like AffinityMiddleware, but returns a *ConfigError instead of panicking.
+	TryAffinityMiddleware = middleware.TryAffinityMiddleware
+	// DefaultAffinityConfig returns a default deployment affinity configuration.
+	DefaultAffinityConfig = middleware.DefaultAffinityConfig
+	// RequestIDMiddleware returns a middleware function that gives each request an ID.
+	RequestIDMiddleware = middleware.RequestIDMiddleware
+	// TryRequestIDMiddleware is like RequestIDMiddleware, but returns a *ConfigError for an invalid configuration.
+	TryRequestIDMiddleware = middleware.TryRequestIDMiddleware
+	// DefaultRequestIDConfig returns a default request ID configuration.
+	DefaultRequestIDConfig = middleware.DefaultRequestIDConfig
+	// NewOutbox returns an outbox, or a *ConfigError if the configuration is invalid.
+	NewOutbox = middleware.NewOutbox
+	// DefaultOutboxConfig returns a default outbox configuration.
+	DefaultOutboxConfig = middleware.DefaultOutboxConfig
+	// StageEvents stages domain events to be published once the response has been written successfully.
+	StageEvents = middleware.StageEvents
+	// CommitJSON stages domain events and writes the JSON response in one step.
+	CommitJSON = middleware.CommitJSON
+	// ErrNoOutbox is returned by StageEvents and CommitJSON if the outbox middleware is not registered.
+	ErrNoOutbox = middleware.ErrNoOutbox
+	// RateLimitMiddleware returns a middleware function that limits the request rate per client IP, API key or custom key.
+	RateLimitMiddleware = middleware.RateLimitMiddleware
+	// TenantRateLimitMiddleware returns a middleware function that limits the request rate per tenant.
+	TenantRateLimitMiddleware = middleware.TenantRateLimitMiddleware
+	// DefaultTenantRateLimitConfig returns a default tenant rate limit configuration.
+	DefaultTenantRateLimitConfig = middleware.DefaultTenantRateLimitConfig
+	// TenantFromHeader returns a TenantFunc reading the tenant from a request header.
+	TenantFromHeader = middleware.TenantFromHeader
+	// TenantFromUser returns a TenantFunc reading the tenant from the authenticated user.
+	TenantFromUser = middleware.TenantFromUser
+	// HeaderLimitMiddleware returns a middleware function that limits the number of request headers.
+	HeaderLimitMiddleware = middleware.HeaderLimitMiddleware
+	// BodyLimitMiddleware returns a middleware function that limits the request body size.
+	BodyLimitMiddleware = middleware.BodyLimitMiddleware
+	// QueryLimitMiddleware returns a middleware function that limits the length and complexity of query strings.
+	QueryLimitMiddleware = middleware.QueryLimitMiddleware
+	// DefaultQueryLimitConfig returns the query limits applied by WithHardenedDefaults.
+	DefaultQueryLimitConfig = middleware.DefaultQueryLimitConfig
+	// CachePolicyMiddleware returns a middleware function that sets the caching headers of responses by route and status.
+	CachePolicyMiddleware = middleware.CachePolicyMiddleware
+	// ResponseDigestMiddleware returns a middleware function that adds digests of file and byte responses.
+	ResponseDigestMiddleware = middleware.ResponseDigestMiddleware
+	// TryResponseDigestMiddleware is like ResponseDigestMiddleware, but returns a *ConfigError for an invalid configuration.
+	TryResponseDigestMiddleware = middleware.TryResponseDigestMiddleware
+	// TryTimeoutMiddleware is like TimeoutMiddleware, but returns a *ConfigError for an invalid configuration.
+	TryTimeoutMiddleware = middleware.TryTimeoutMiddleware
+	// DefaultResponseDigestConfig returns a default response digest configuration, which sends SHA-256 digests.
+	DefaultResponseDigestConfig = middleware.DefaultResponseDigestConfig
+	// Data writes bytes as the response body, with digest headers if the response digest middleware is registered.
+	Data = middleware.Data
+	// DataFromReader streams a reader as the response body, with digests if the response digest middleware is registered.
+	DataFromReader = middleware.DataFromReader
+	// ResponseLimitMiddleware returns a middleware function that limits the response body size.
+	ResponseLimitMiddleware = middleware.ResponseLimitMiddleware
+	// NewWatchdog returns a watchdog monitoring the heap size and goroutine count of the process.
+	NewWatchdog = middleware.NewWatchdog
+	// DefaultWatchdogConfig returns a default watchdog configuration.
+	DefaultWatchdogConfig = middleware.DefaultWatchdogConfig
+	// SkipRoutesMiddleware returns a middleware function that runs a middleware except on the given routes.
+	SkipRoutesMiddleware = middleware.SkipRoutesMiddleware
+	// RawDataLimitMiddleware returns a middleware function that sets the maximum body size cached by GetRawData.
+	RawDataLimitMiddleware = middleware.RawDataLimitMiddleware
+	// ResponseTransformMiddleware returns a middleware function that rewrites JSON responses with transformers.
+	ResponseTransformMiddleware = middleware.ResponseTransformMiddleware
+	// JSONKeyCaseMiddleware returns a middleware function that converts JSON keys between snake_case and camelCase.
+	JSONKeyCaseMiddleware = middleware.JSONKeyCaseMiddleware
+	// NewKafkaSink returns a log sink that produces log entries to a Kafka topic.
+	NewKafkaSink = middleware.NewKafkaSink
+	// NewFileSink returns a log sink that writes log entries to a rotating file.
+	NewFileSink = middleware.NewFileSink
+	// NewNATSSink returns a log sink that publishes log entries to NATS JetStream.
+	NewNATSSink = middleware.NewNATSSink
+	// NewSlogLogger returns a Logger writing to a *slog.Logger.
+	NewSlogLogger = middleware.NewSlogLogger
+	// NewZapLogger returns a Logger writing to a zap SugaredLogger.
+	NewZapLogger = middleware.NewZapLogger
 	// GetUserFromContext retrieves the authenticated user from the context.
 	GetUserFromContext = middleware.GetUserFromContext
+	// SignJWT creates an HS256 signed JWT token from the given claims.
+	SignJWT = middleware.SignJWT
 
 	// NewDefaultAPIKeyMiddleware returns a middleware function with default configuration and the specified API key.
 	NewDefaultAPIKeyMiddleware = middleware.NewDefaultAPIKeyMiddleware
@@ -145,6 +658,8 @@ var (
 	NewDefaultBasicAuthMiddleware = middleware.NewDefaultBasicAuthMiddleware
 	// NewDefaultCORSMiddleware returns a middleware function with default configuration.
 	NewDefaultCORSMiddleware = middleware.NewDefaultCORSMiddleware
+	// NewDefaultSecurityHeadersMiddleware returns a security headers middleware function with default configuration.
+	NewDefaultSecurityHeadersMiddleware = middleware.NewDefaultSecurityHeadersMiddleware
 	// NewDefaultDuplicateRequestMiddleware returns a middleware function with default configuration.
 	NewDefaultDuplicateRequestMiddleware = middleware.NewDefaultDuplicateRequestMiddleware
 	// NewDefaultConsoleLogging returns a logging configuration for console-only logging with the specified ignore path list and custom fields.
@@ -157,6 +672,8 @@ var (
 var (
 	// NewErrorResponse creates a new ErrorResponse with the given status code and message.
 	NewErrorResponse = errors.NewErrorResponse
+	// NewProblemDetails creates a new ProblemDetails of type "about:blank" with the given status code and detail.
+	NewProblemDetails = errors.NewProblemDetails
 	// NewBadRequestResponse creates a new ErrorResponse for a 400 Bad Request error.
 	NewBadRequestResponse = errors.NewBadRequestResponse
 	// NewUnauthorizedResponse creates a new ErrorResponse for a 401 Unauthorized error.
@@ -169,8 +686,30 @@ var (
 	NewConflictResponse = errors.NewConflictResponse
 	// NewInternalServerErrorResponse creates a new ErrorResponse for a 500 Internal Server Error.
 	NewInternalServerErrorResponse = errors.NewInternalServerErrorResponse
+	// NewTooManyRequestsResponse creates a new ErrorResponse for a 429 Too Many Requests error with a retry delay.
+	NewTooManyRequestsResponse = errors.NewTooManyRequestsResponse
 	// NewServiceUnavailableResponse creates a new ErrorResponse for a 503 Service Unavailable error.
 	NewServiceUnavailableResponse = errors.NewServiceUnavailableResponse
+	// NewPaymentRequiredResponse creates a new ErrorResponse for a 402 Payment Required error.
+	NewPaymentRequiredResponse = errors.NewPaymentRequiredResponse
+	// NewNotAcceptableResponse creates a new ErrorResponse for a 406 Not Acceptable error.
+	NewNotAcceptableResponse = errors.NewNotAcceptableResponse
+	// NewRequestTimeoutResponse creates a new ErrorResponse for a 408 Request Timeout error.
+	NewRequestTimeoutResponse = errors.NewRequestTimeoutResponse
+	// NewGoneResponse creates a new ErrorResponse for a 410 Gone error.
+	NewGoneResponse = errors.NewGoneResponse
+	// NewPreconditionFailedResponse creates a new ErrorResponse for a 412 Precondition Failed error.
+	NewPreconditionFailedResponse = errors.NewPreconditionFailedResponse
+	// NewUnsupportedMediaTypeResponse creates a new ErrorResponse for a 415 Unsupported Media Type error.
+	NewUnsupportedMediaTypeResponse = errors.NewUnsupportedMediaTypeResponse
+	// NewUnprocessableEntityResponse creates a new ErrorResponse for a 422 Unprocessable Entity error.
+	NewUnprocessableEntityResponse = errors.NewUnprocessableEntityResponse
+	// NewNotImplementedResponse creates a new ErrorResponse for a 501 Not Implemented error.
+	NewNotImplementedResponse = errors.NewNotImplementedResponse
+	// NewBadGatewayResponse creates a new ErrorResponse for a 502 Bad Gateway error.
+	NewBadGatewayResponse = errors.NewBadGatewayResponse
+	// NewGatewayTimeoutResponse creates a new ErrorResponse for a 504 Gateway Timeout error.
+	NewGatewayTimeoutResponse = errors.NewGatewayTimeoutResponse
 
 	// Constructor functions for the error structs
 	// NewBadRequestHttpError creates a new BadRequestHttpError.
@@ -187,8 +726,208 @@ var (
 	NewInternalServerHttpError = errors.NewInternalServerHttpError
 	// NewServiceUnavailableHttpError creates a new ServiceUnavailableHttpError.
 	NewServiceUnavailableHttpError = errors.NewServiceUnavailableHttpError
+	// NewPaymentRequiredHttpError creates a new PaymentRequiredHttpError.
+	NewPaymentRequiredHttpError = errors.NewPaymentRequiredHttpError
+	// NewNotAcceptableHttpError creates a new NotAcceptableHttpError.
+	NewNotAcceptableHttpError = errors.NewNotAcceptableHttpError
+	// NewRequestTimeoutHttpError creates a new RequestTimeoutHttpError.
+	NewRequestTimeoutHttpError = errors.NewRequestTimeoutHttpError
+	// NewConflictHttpError creates a new ConflictHttpError.
+	NewConflictHttpError = errors.NewConflictHttpError
+	// NewGoneHttpError creates a new GoneHttpError.
+	NewGoneHttpError = errors.NewGoneHttpError
+	// NewPreconditionFailedHttpError creates a new PreconditionFailedHttpError.
+	NewPreconditionFailedHttpError = errors.NewPreconditionFailedHttpError
+	// NewUnsupportedMediaTypeHttpError creates a new UnsupportedMediaTypeHttpError.
+	NewUnsupportedMediaTypeHttpError = errors.NewUnsupportedMediaTypeHttpError
+	// NewUnprocessableEntityHttpError creates a new UnprocessableEntityHttpError.
+	NewUnprocessableEntityHttpError = errors.NewUnprocessableEntityHttpError
+	// NewTooManyRequestsHttpError creates a new TooManyRequestsHttpError.
+	NewTooManyRequestsHttpError = errors.NewTooManyRequestsHttpError
+	// NewNotImplementedHttpError creates a new NotImplementedHttpError.
+	NewNotImplementedHttpError = errors.NewNotImplementedHttpError
+	// NewBadGatewayHttpError creates a new BadGatewayHttpError.
+	NewBadGatewayHttpError = errors.NewBadGatewayHttpError
+	// NewGatewayTimeoutHttpError creates a new GatewayTimeoutHttpError.
+	NewGatewayTimeoutHttpError = errors.NewGatewayTimeoutHttpError
+
+	// Error status mappings
+	// RegisterErrorMapping maps errors wrapping the given error to an HTTP status code in the error handler.
+	RegisterErrorMapping = errors.RegisterMapping
+	// RegisterErrorMappingFunc maps errors matched by a function to an HTTP status code in the error handler.
+	RegisterErrorMappingFunc = errors.RegisterMappingFunc
 )
 
+// Param parses the URL param with the given key into T.
+// If the param is missing or cannot be parsed, it returns a 400 Bad Request error
+// that the error handler middleware turns into a standard error response.
+//
+// Example usage:
+//
+//	id, err := server.Param[int64](c, "id")
+//	if err != nil {
+//		c.Error(err)
+//		return
+//	}
+func Param[T ParamType](c core.Context, key string) (T, error) {
+	return core.ParseParam[T](c, key)
+}
+
+// BindOnce binds the JSON request body into a new T the first time it is called for a request,
+// and returns the cached value on later calls instead of decoding the body again.
+func BindOnce[T any](c core.Context) (*T, error) {
+	return core.BindOnce[T](c)
+}
+
+// Bound returns the value of type T previously bound by BindOnce for this request,
+// and whether there is one.
+func Bound[T any](c core.Context) (*T, bool) {
+	return core.Bound[T](c)
+}
+
+// Preload returns a handler that loads a value with load before the handlers of a route, for
+// Loaded, aborting with 404 Not Found if load returns nil or with the error it returns.
+func Preload[T any](load func(c core.Context) (*T, error)) core.HandlerFunc {
+	return core.Preload[T](load)
+}
+
+// Loaded returns the value of type T stored by Preload for this request, and whether there is one.
+func Loaded[T any](c core.Context) (*T, bool) {
+	return core.Loaded[T](c)
+}
+
+// Baggage returns the W3C baggage of the request of c, e.g. feature flags set by an upstream service:
+// the baggage stored by the OpenTelemetry middleware or telemetry.BaggageMiddleware, or the baggage
+// of its baggage headers if neither is registered. Propagate it with telemetry.Inject or telemetry.Transport.
+//
+// Example usage:
+//
+//	if flag, _ := server.Baggage(c).Get("feature.checkout-v2"); flag == "on" {
+//		// ...
+//	}
+func Baggage(c core.Context) telemetry.Baggage {
+	return telemetry.BaggageFromRequest(c.Request())
+}
+
+// Validate checks the validate tags of a struct and returns a *ValidationError listing every failed field.
+var Validate = core.Validate
+
+// RegisterValidation adds a custom rule for validate tags.
+var RegisterValidation = core.RegisterValidation
+
+// BindForm sets the fields of a struct from form values and uploaded files, matching fields by their form tags.
+var BindForm = core.BindForm
+
+// Resolve returns the dependency of type T, or an error wrapping ErrDependencyNotFound.
+func Resolve[T any](deps core.Deps) (T, error) {
+	return core.Resolve[T](deps)
+}
+
+// MustResolve is like Resolve but panics if there is no dependency of type T.
+// Build reports the panic of a controller factory as an error.
+func MustResolve[T any](deps core.Deps) T {
+	return core.MustResolve[T](deps)
+}
+
+// MockConfigFromEnv returns a mock mode configuration read from environment variables.
+var MockConfigFromEnv = core.MockConfigFromEnv
+
+// HardenedHTTPServerConfig returns conservative http.Server timeouts, header limits and TLS settings.
+var HardenedHTTPServerConfig = core.HardenedHTTPServerConfig
+
+// NewWarmup returns a Warmup running the given hooks in order.
+var NewWarmup = core.NewWarmup
+
+// NewReadiness returns a Readiness running the given dependency checks.
+var NewReadiness = core.NewReadiness
+
+// NewJSONCodec returns a JSONCodec applying the given policy on top of encoding/json.
+var NewJSONCodec = core.NewJSONCodec
+
+// SetJSONCodec sets the codec used for all JSON responses of the process.
+var SetJSONCodec = core.SetJSONCodec
+
+// ShutdownSignals are the signals that make RunWithGracefulShutdown shut the server down.
+var ShutdownSignals = core.ShutdownSignals
+
+// NewResponseWriter returns an http.ResponseWriter as a ResponseWriter, wrapping it if needed.
+var NewResponseWriter = core.NewResponseWriter
+
+// DefaultFastPathPaths are the health check paths answered by the fast path by default.
+var DefaultFastPathPaths = core.DefaultFastPathPaths
+
+// NewULIDGenerator returns an ID generator producing ULIDs.
+var NewULIDGenerator = core.NewULIDGenerator
+
+// NewUUIDGenerator returns an ID generator producing random (version 4) UUIDs.
+var NewUUIDGenerator = core.NewUUIDGenerator
+
+// MultipartMemory returns a middleware function that sets the memory used to parse the multipart forms of a route.
+var MultipartMemory = core.MultipartMemory
+
+// ParseTrustedProxies parses the IPs and CIDR ranges of trusted proxies.
+var ParseTrustedProxies = core.ParseTrustedProxies
+
+// ResolveClientIP returns the client IP of a request, honoring forwarding headers only from trusted proxies.
+var ResolveClientIP = core.ResolveClientIP
+
+// NewHTMLTemplates loads html/template templates matching glob patterns, with optional layouts.
+var NewHTMLTemplates = core.NewHTMLTemplates
+
+// TemplateMiddleware returns a middleware function that stores the template engine of Context.HTML in the request context.
+var TemplateMiddleware = core.TemplateMiddleware
+
+// ErrNoTemplateEngine is returned when rendering HTML for a request without a template engine.
+var ErrNoTemplateEngine = core.ErrNoTemplateEngine
+
+// ErrTemplateNotFound is returned when rendering a template that the engine does not have.
+var ErrTemplateNotFound = core.ErrTemplateNotFound
+
+// StreamMultipart passes the files of a multipart request to an Uploader as they arrive.
+var StreamMultipart = core.StreamMultipart
+
+// WriterUploader returns an Uploader that copies every file into the writer returned by open.
+var WriterUploader = core.WriterUploader
+
+// UGCSanitizePolicy returns an HTML sanitization policy for user-generated content such as comments and posts.
+var UGCSanitizePolicy = sanitize.UGCPolicy
+
+// StrictSanitizePolicy returns an HTML sanitization policy that removes all HTML.
+var StrictSanitizePolicy = sanitize.StrictPolicy
+
+// ClientAborts returns the number of responses abandoned because the client disconnected.
+var ClientAborts = core.ClientAborts
+
+// ErrClientAborted is returned by the streaming response methods when the client disconnected.
+var ErrClientAborted = core.ErrClientAborted
+
+// ErrRouteConflict is the panic value (wrapped) raised when a route conflicts with an existing route.
+var ErrRouteConflict = core.ErrRouteConflict
+
+// ErrDependencyNotFound is wrapped by the errors of Resolve and MustResolve when a dependency is missing.
+var ErrDependencyNotFound = core.ErrDependencyNotFound
+
+// ErrRouteNotFound is wrapped by the errors of DisableRoute when the route is not registered.
+var ErrRouteNotFound = core.ErrRouteNotFound
+
+// ErrUnknownLambdaEvent is returned by the Lambda handler of StartLambda for events that are not HTTP requests.
+var ErrUnknownLambdaEvent = core.ErrUnknownLambdaEvent
+
+// NewContainer returns a Container holding the given dependencies.
+var NewContainer = core.NewContainer
+
+// ErrResponseSent is returned by writes made after the response has been sent, e.g. after a timeout.
+var ErrResponseSent = core.ErrResponseSent
+
+// ErrChecksumMismatch is returned when reading a request body that does not match its checksum header.
+var ErrChecksumMismatch = middleware.ErrChecksumMismatch
+
+// ErrResponseTooLarge is returned by writes that would make a response exceed the limit set with WithMaxResponseSize.
+var ErrResponseTooLarge = middleware.ErrResponseTooLarge
+
+// ErrInvalidConfig is wrapped by the *ConfigError returned for invalid middleware configurations.
+var ErrInvalidConfig = middleware.ErrInvalidConfig
+
 // NewServer creates a new Server instance.
 // By default, it uses the Gin framework if no framework type is specified.
 // If port is not provided, it defaults to "8080".
@@ -200,13 +939,10 @@ func NewServer(frameworkType core.FrameworkType, port string, showFrameworkLogs
 		port = "8080"
 	}
 
-	// Use the specified framework
-	switch frameworkType {
-	case core.FrameworkGin:
-		return gin.NewServer(port, showFrameworkLogs), nil
-	case core.FrameworkStdHTTP:
-		return std.NewServer(port, showFrameworkLogs), nil
-	default:
+	// Use the specified framework, built-in or registered with RegisterFramework
+	factory, ok := lookupFramework(frameworkType)
+	if !ok {
 		return nil, fmt.Errorf("unsupported framework type: %s", frameworkType)
 	}
+	return factory(port, showFrameworkLogs), nil
 }
@@ -3,13 +3,20 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"math/rand"
 	"net"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/openapi"
+	"github.com/mythofleader/go-http-server/core/telemetry"
 )
 
 // ServerBuilder is a builder for creating a server with controllers and middleware.
@@ -18,13 +25,69 @@ type ServerBuilder struct {
 	port             string
 	portSet          bool // Flag to track whether a port has been set
 	controllers      []core.Controller
-	middleware       []core.HandlerFunc
+	factories        []core.ControllerFactory // Constructors of controllers, called by Build
+	deps             *core.Container          // Dependencies passed to the controller factories
+	middleware       []core.NamedHandler
 	loggingConfig    *core.LoggingConfig
 	timeoutConfig    *TimeoutConfig
 	corsConfig       *CORSConfig
 	errorConfig      *core.ErrorHandlerConfig
-	noRouteHandlers  []core.HandlerFunc // Handlers for 404 Not Found errors
-	noMethodHandlers []core.HandlerFunc // Handlers for 405 Method Not Allowed errors
+	authConfig       *AuthConfig
+	policyConfig     *PolicyConfig
+	noRouteHandlers  []core.HandlerFunc   // Handlers for 404 Not Found errors
+	noMethodHandlers []core.HandlerFunc   // Handlers for 405 Method Not Allowed errors
+	plugins          []Plugin             // Plugins installed with UsePlugin
+	examplesPath     string               // Path of the examples endpoint, empty if disabled
+	openAPIPath      string               // Path of the OpenAPI document, empty if disabled
+	openAPIOptions   *openapi.Options     // OpenAPI document and Swagger UI settings
+	corsDebugPath    string               // Path of the CORS debug endpoint, empty if disabled
+	debugTrace       *DebugTraceConfig    // Debug request tracing, nil if disabled
+	debugTracePath   string               // Path of the debug trace endpoint
+	fastPath         *core.FastPathConfig // Health check paths answered before the middleware chain
+	warmupHooks      []core.LifecycleHook // Hooks run before the server accepts requests
+	warmupPath       string               // Path of the warmup endpoint, empty if disabled
+	mockConfig       *core.MockConfig     // Mock mode configuration, nil if disabled
+	engineOptions    *core.EngineOptions  // Router and request parsing settings, nil for the defaults
+	trustedProxies   []string             // IPs and CIDRs of the proxies trusted to forward the client IP
+	lambdaConfig     *core.LambdaConfig   // Event type of StartLambda, nil to detect it
+	jsonCodec        core.JSONCodec       // Codec of JSON responses, nil to keep the current codec
+
+	// Dependency checks that must pass before the server accepts traffic, their retry settings
+	// (nil for the defaults) and the path of the readiness endpoint (empty if disabled)
+	dependencyChecks []core.DependencyCheck
+	readinessConfig  *core.ReadinessConfig
+	readinessPath    string
+
+	// Settings of the http.Server created by Run and RunTLS, nil for the net/http defaults
+	httpServerConfig *core.HTTPServerConfig
+	// Request header count and body size limits, zero if disabled
+	maxHeaderCount int
+	maxBodySize    int64
+	// Query string limits, nil if disabled
+	queryLimits *QueryLimitConfig
+	// Response body size limit, zero if disabled
+	maxResponseSize int64
+	// Caching header rules, checked in order
+	cachePolicies []CachePolicy
+	// Algorithms of the digests of file and byte responses, empty if disabled
+	responseDigests []DigestAlgorithm
+	// Heap and goroutine watchdog, nil if disabled
+	watchdogConfig *WatchdogConfig
+
+	// Clock and ID generator injected into requests, nil for the defaults
+	clock       core.Clock
+	idGenerator core.IDGenerator
+	// Request ID settings, nil if only the logging middleware assigns request IDs
+	requestIDConfig *RequestIDConfig
+	// Publisher of the domain events staged by handlers, nil if disabled
+	outboxConfig *OutboxConfig
+	// Template engine of Context.HTML, loaded from htmlTemplates at build time if set
+	templateEngine core.TemplateEngine
+	htmlTemplates  *core.HTMLTemplateConfig
+
+	// OpenTelemetry export, disabled if telemetryEndpoint is empty
+	telemetryEndpoint string
+	telemetryOptions  *telemetry.Options
 
 	// Flags for default middleware
 	useDefaultLogging      bool
@@ -40,7 +103,8 @@ func NewServerBuilder(frameworkType core.FrameworkType, port ...string) *ServerB
 	builder := &ServerBuilder{
 		frameworkType:     frameworkType,
 		controllers:       make([]core.Controller, 0),
-		middleware:        make([]core.HandlerFunc, 0),
+		deps:              core.NewContainer(),
+		middleware:        make([]core.NamedHandler, 0),
 		noRouteHandlers:   make([]core.HandlerFunc, 0),
 		noMethodHandlers:  make([]core.HandlerFunc, 0),
 		showFrameworkLogs: true, // Default to showing framework logs
@@ -129,15 +193,43 @@ func (b *ServerBuilder) AddControllers(controllers ...core.Controller) *ServerBu
 	return b
 }
 
+// AddControllerFactory adds a controller constructed by factory when Build is called.
+// The factory receives the dependencies provided with WithDependencies, e.g.
+//
+//	builder.WithDependencies(db, mailer).
+//		AddControllerFactory(func(deps server.Deps) core.Controller {
+//			return NewUserController(server.MustResolve[*sql.DB](deps), server.MustResolve[Mailer](deps))
+//		})
+//
+// If a dependency is missing, Build returns an error wrapping ErrDependencyNotFound.
+func (b *ServerBuilder) AddControllerFactory(factory core.ControllerFactory) *ServerBuilder {
+	b.factories = append(b.factories, factory)
+	return b
+}
+
+// WithDependencies provides dependencies, such as database handles and API clients, to the
+// controller factories. Factories look them up by type with Resolve or MustResolve.
+func (b *ServerBuilder) WithDependencies(values ...interface{}) *ServerBuilder {
+	b.deps.Provide(values...)
+	return b
+}
+
 // AddMiddleware adds a middleware to the builder.
 func (b *ServerBuilder) AddMiddleware(middleware core.HandlerFunc) *ServerBuilder {
-	b.middleware = append(b.middleware, middleware)
+	return b.AddNamedMiddleware("", middleware)
+}
+
+// AddNamedMiddleware adds a middleware to the builder with the name shown in framework logs.
+func (b *ServerBuilder) AddNamedMiddleware(name string, middleware core.HandlerFunc) *ServerBuilder {
+	b.middleware = append(b.middleware, core.NamedHandler{Name: name, Handler: middleware})
 	return b
 }
 
 // AddMiddlewares adds multiple middleware to the builder.
 func (b *ServerBuilder) AddMiddlewares(middleware ...core.HandlerFunc) *ServerBuilder {
-	b.middleware = append(b.middleware, middleware...)
+	for _, m := range middleware {
+		b.AddNamedMiddleware("", m)
+	}
 	return b
 }
 
@@ -165,7 +257,17 @@ func (b *ServerBuilder) WithRemoteLogging(remoteURL string, customFields map[str
 	return b
 }
 
-// WithTimeout configures the timeout middleware with the specified timeout.
+// WithLoggingConfig configures the logging middleware with the specified configuration.
+// Use it for options not covered by WithLogging and WithRemoteLogging, such as
+// gzip compression and authentication for the remote logging endpoint.
+func (b *ServerBuilder) WithLoggingConfig(loggingConfig core.LoggingConfig) *ServerBuilder {
+	b.loggingConfig = &loggingConfig
+	return b
+}
+
+// WithTimeout configures the timeout middleware with the specified timeout, and optionally a soft
+// timeout at which handlers still running are reported. Build returns a *ConfigError if the soft
+// timeout is not less than the timeout.
 func (b *ServerBuilder) WithTimeout(timeout TimeoutConfig) *ServerBuilder {
 	b.timeoutConfig = &timeout
 	return b
@@ -177,6 +279,45 @@ func (b *ServerBuilder) WithCORS(cors CORSConfig) *ServerBuilder {
 	return b
 }
 
+// WithAuth configures the authorization middleware with the specified configuration.
+// Routes of controllers whose SkipAuthCheck returns true are added to its SkipPaths.
+// An invalid configuration is reported by Build as a *ConfigError.
+func (b *ServerBuilder) WithAuth(auth AuthConfig) *ServerBuilder {
+	b.authConfig = &auth
+	return b
+}
+
+// WithJWTAuth configures the authorization middleware for JWT Bearer tokens signed with secret,
+// skipping the paths matching skipPatterns. Patterns are matched like AuthConfig.SkipPaths:
+// exact paths, wildcards such as "/docs/*" and parameters such as "/users/:id/profile".
+func (b *ServerBuilder) WithJWTAuth(lookup JWTUserLookup, secret string, skipPatterns ...string) *ServerBuilder {
+	config := DefaultAuthConfig()
+	config.AuthType = AuthTypeJWT
+	config.JWTLookup = lookup
+	config.JWTSecret = secret
+	config.SkipPaths = skipPatterns
+	return b.WithAuth(*config)
+}
+
+// WithBasicAuth configures the authorization middleware for HTTP Basic authentication,
+// skipping the paths matching skipPatterns as WithJWTAuth does.
+func (b *ServerBuilder) WithBasicAuth(lookup BasicAuthUserLookup, skipPatterns ...string) *ServerBuilder {
+	config := DefaultAuthConfig()
+	config.AuthType = AuthTypeBasic
+	config.BasicAuthLookup = lookup
+	config.SkipPaths = skipPatterns
+	return b.WithAuth(*config)
+}
+
+// WithPolicy configures the policy middleware, which evaluates policy after the authorization
+// middleware and before the handlers, e.g. to query a policy engine with the authenticated user.
+func (b *ServerBuilder) WithPolicy(policy PolicyFunc) *ServerBuilder {
+	config := DefaultPolicyConfig()
+	config.Policy = policy
+	b.policyConfig = config
+	return b
+}
+
 // WithErrorHandler configures the error handler middleware with the specified configuration.
 func (b *ServerBuilder) WithErrorHandler(errorConfig core.ErrorHandlerConfig) *ServerBuilder {
 	b.errorConfig = &errorConfig
@@ -247,6 +388,289 @@ func (b *ServerBuilder) WithNoMethod(handlers ...core.HandlerFunc) *ServerBuilde
 	return b
 }
 
+// WithExamplesEndpoint serves the examples of all controllers implementing core.ExampleProvider
+// as JSON on a GET endpoint, for developer portals.
+// If path is not provided, core.DefaultExamplesPath ("/docs/examples") is used.
+func (b *ServerBuilder) WithExamplesEndpoint(path ...string) *ServerBuilder {
+	b.examplesPath = core.DefaultExamplesPath
+	if len(path) > 0 && path[0] != "" {
+		b.examplesPath = path[0]
+	}
+	return b
+}
+
+// WithOpenAPI serves an OpenAPI 3 document of the routes of all controllers as JSON on path,
+// e.g. "/openapi.json", and a Swagger UI page for it on DefaultOpenAPIUIPath ("/docs").
+// Controllers implementing core.DocumentedController add summaries, tags and body schemas,
+// and controllers implementing core.ExampleProvider add their examples.
+// opts sets the title, version and UI settings of the first OpenAPIOptions, if provided.
+func (b *ServerBuilder) WithOpenAPI(path string, opts ...OpenAPIOptions) *ServerBuilder {
+	b.openAPIPath = path
+	b.openAPIOptions = nil
+	if len(opts) > 0 {
+		b.openAPIOptions = &opts[0]
+	}
+	return b
+}
+
+// WithCORSDebugEndpoint records the decisions of the CORS middleware and serves recent decisions
+// and counters as JSON on a GET endpoint, to troubleshoot blocked origins.
+// If path is not provided, DefaultCORSDebugPath ("/debug/cors") is used.
+// The endpoint reveals recent origins and paths, so only enable it where it is not publicly reachable.
+func (b *ServerBuilder) WithCORSDebugEndpoint(path ...string) *ServerBuilder {
+	b.corsDebugPath = DefaultCORSDebugPath
+	if len(path) > 0 && path[0] != "" {
+		b.corsDebugPath = path[0]
+	}
+	return b
+}
+
+// WithDebugTrace records, for requests whose DefaultDebugTraceHeader ("X-Debug-Trace") header
+// carries secret, what each middleware did with the request, and serves the traces as JSON on a
+// GET endpoint. The trace ID is returned in the X-Debug-Trace-Id response header, and the endpoint
+// also requires the secret. If path is not provided, DefaultDebugTracePath ("/debug/traces") is used.
+func (b *ServerBuilder) WithDebugTrace(secret string, path ...string) *ServerBuilder {
+	b.debugTrace = &DebugTraceConfig{Secret: secret}
+	b.debugTracePath = DefaultDebugTracePath
+	if len(path) > 0 && path[0] != "" {
+		b.debugTracePath = path[0]
+	}
+	return b
+}
+
+// WithFastPath answers GET and HEAD requests for the given health check paths with 200 OK before
+// the middleware chain runs, so that frequent load balancer probes skip logging, authentication
+// and body handling. If no paths are provided, DefaultFastPathPaths ("/health" and "/ping") are used.
+// Use WithFastPathConfig to answer with a custom handler.
+func (b *ServerBuilder) WithFastPath(paths ...string) *ServerBuilder {
+	b.fastPath = &core.FastPathConfig{Paths: paths}
+	return b
+}
+
+// WithFastPathConfig configures the health check paths answered before the middleware chain.
+func (b *ServerBuilder) WithFastPathConfig(config FastPathConfig) *ServerBuilder {
+	b.fastPath = &config
+	return b
+}
+
+// WithEngineOptions sets router and request parsing settings, such as the trusted platform
+// header and the multipart memory limit, which each framework maps to its native settings.
+func (b *ServerBuilder) WithEngineOptions(options EngineOptions) *ServerBuilder {
+	b.engineOptions = &options
+	return b
+}
+
+// WithTrustedProxies sets the IPs and CIDR ranges, e.g. "10.0.0.0/8", of the proxies trusted to
+// forward the client IP in X-Forwarded-For and X-Real-IP. Without it, no proxy is trusted and
+// Context.ClientIP returns the remote address.
+func (b *ServerBuilder) WithTrustedProxies(proxies ...string) *ServerBuilder {
+	b.trustedProxies = append(b.trustedProxies, proxies...)
+	return b
+}
+
+// WithLambdaConfig sets the type of the events StartLambda expects, e.g. LambdaEventFunctionURL.
+// Without it, the type of each event is detected.
+func (b *ServerBuilder) WithLambdaConfig(config LambdaConfig) *ServerBuilder {
+	b.lambdaConfig = &config
+	return b
+}
+
+// WithJSONPolicy applies serialization policies, such as the time format and decimals as strings,
+// to every JSON response. The codec is process-wide, so all servers of the process share it.
+func (b *ServerBuilder) WithJSONPolicy(policy JSONPolicy) *ServerBuilder {
+	b.jsonCodec = core.NewJSONCodec(policy)
+	return b
+}
+
+// WithJSONCodec sets the codec used to encode JSON responses. Like WithJSONPolicy, it is process-wide.
+func (b *ServerBuilder) WithJSONCodec(codec JSONCodec) *ServerBuilder {
+	b.jsonCodec = codec
+	return b
+}
+
+// WithWarmup adds a hook that is run before the server accepts requests, e.g. to fill caches
+// or prime connection pools. Hooks run in the order they are added; if one fails, the server
+// does not start and Run returns the error.
+func (b *ServerBuilder) WithWarmup(hook core.LifecycleHook) *ServerBuilder {
+	b.warmupHooks = append(b.warmupHooks, hook)
+	return b
+}
+
+// WithWarmupEndpoint serves a GET endpoint that runs the warmup hooks if they have not completed yet,
+// for platforms that send warmup requests to new instances. It responds with 200 OK once the warmup
+// has completed and with 503 Service Unavailable if a hook fails.
+// If path is not provided, DefaultWarmupPath ("/warmup") is used; App Engine uses "/_ah/warmup".
+func (b *ServerBuilder) WithWarmupEndpoint(path ...string) *ServerBuilder {
+	b.warmupPath = DefaultWarmupPath
+	if len(path) > 0 && path[0] != "" {
+		b.warmupPath = path[0]
+	}
+	return b
+}
+
+// WithDependencyCheck adds a check that must pass before the server accepts traffic, e.g. a
+// database ping or a check that migrations have been applied. Failed checks are retried with
+// exponential backoff until they pass or the startup timeout expires, in which case Run returns
+// the error. Checks run concurrently, before the warmup hooks.
+func (b *ServerBuilder) WithDependencyCheck(name string, check core.LifecycleHook) *ServerBuilder {
+	b.dependencyChecks = append(b.dependencyChecks, core.DependencyCheck{Name: name, Check: check})
+	return b
+}
+
+// WithReadinessConfig sets the startup timeout and backoff of the dependency checks. With
+// ReadinessConfig.Background, the server starts without waiting for the checks and only the
+// readiness endpoint reports whether they have passed.
+func (b *ServerBuilder) WithReadinessConfig(config ReadinessConfig) *ServerBuilder {
+	b.readinessConfig = &config
+	return b
+}
+
+// WithReadinessEndpoint serves a GET endpoint that responds with 200 OK once all dependency checks
+// have passed and with 503 Service Unavailable before, listing the state of each check.
+// If path is not provided, DefaultReadinessPath ("/ready") is used.
+func (b *ServerBuilder) WithReadinessEndpoint(path ...string) *ServerBuilder {
+	b.readinessPath = DefaultReadinessPath
+	if len(path) > 0 && path[0] != "" {
+		b.readinessPath = path[0]
+	}
+	return b
+}
+
+// WithMockMode configures mock mode. When enabled, mocked routes serve the example responses
+// of their controller (see core.ExampleProvider) instead of running its handlers,
+// so clients can be developed against the real API shape before the backend logic exists.
+// Use core.MockConfigFromEnv to toggle mock mode with environment variables.
+func (b *ServerBuilder) WithMockMode(config *core.MockConfig) *ServerBuilder {
+	b.mockConfig = config
+	return b
+}
+
+// WithHTTPServerConfig sets the timeouts, header limits and TLS settings of the http.Server.
+func (b *ServerBuilder) WithHTTPServerConfig(config *core.HTTPServerConfig) *ServerBuilder {
+	b.httpServerConfig = config
+	return b
+}
+
+// WithHardenedDefaults applies conservative protections in one call, for users who don't want
+// to tune each setting: the timeouts, 64 KB header size limit and TLS 1.2 minimum of
+// core.HardenedHTTPServerConfig (slowloris protection), at most 100 request headers,
+// a 10 MB request body limit and the query limits of DefaultQueryLimitConfig.
+// Call WithHTTPServerConfig or WithQueryLimits afterwards to override these settings.
+func (b *ServerBuilder) WithHardenedDefaults() *ServerBuilder {
+	b.httpServerConfig = core.HardenedHTTPServerConfig()
+	b.maxHeaderCount = DefaultMaxHeaderCount
+	b.maxBodySize = DefaultMaxBodySize
+	b.queryLimits = DefaultQueryLimitConfig()
+	return b
+}
+
+// WithQueryLimits rejects requests whose query string is too long (414 URI Too Long) or has too
+// many parameters, values per key, nesting levels or too high array indexes (400 Bad Request),
+// before any handler parses it. See QueryLimitMiddleware.
+func (b *ServerBuilder) WithQueryLimits(config QueryLimitConfig) *ServerBuilder {
+	b.queryLimits = &config
+	return b
+}
+
+// WithMaxResponseSize limits response bodies to maxSize bytes, so that a runaway handler can't
+// exhaust memory or flood clients: responses are buffered until the handler returns or flushes,
+// and an oversized response is replaced with a 500 Internal Server Error, or cut off at the limit
+// if it is streamed. See ResponseLimitMiddleware.
+func (b *ServerBuilder) WithMaxResponseSize(maxSize int64) *ServerBuilder {
+	b.maxResponseSize = maxSize
+	return b
+}
+
+// WithCachePolicy sets the Cache-Control and Expires headers of the successful responses of route,
+// e.g. "GET /products/:id", so that handlers don't have to: responses may be cached for maxAge,
+// only by the client if private, or not at all if noStore. Error responses of the route are
+// marked "no-store", and handlers can still set Cache-Control themselves. If several policies
+// match a request, the one added first applies. See CachePolicyMiddleware.
+func (b *ServerBuilder) WithCachePolicy(route string, maxAge time.Duration, private, noStore bool) *ServerBuilder {
+	b.cachePolicies = append(b.cachePolicies, CachePolicy{Route: route, MaxAge: maxAge, Private: private, NoStore: noStore})
+	return b
+}
+
+// WithResponseDigest adds digests of file and byte responses, served with c.File, Data or
+// DataFromReader, so clients can verify the integrity of downloads: Content-MD5 for DigestMD5,
+// and Digest and Content-Digest for the SHA algorithms. If no algorithm is given, DigestSHA256
+// is used. See ResponseDigestMiddleware.
+func (b *ServerBuilder) WithResponseDigest(algorithms ...DigestAlgorithm) *ServerBuilder {
+	if len(algorithms) == 0 {
+		algorithms = DefaultResponseDigestConfig().Algorithms
+	}
+	b.responseDigests = algorithms
+	return b
+}
+
+// WithWatchdog monitors the heap size and goroutine count of the process while the server runs and,
+// while they are above the thresholds of watchdog, rejects routes by priority class with 503 Service
+// Unavailable and captures heap profiles. Controllers declare the priority class of their route by
+// implementing PrioritizedController. See Watchdog.
+func (b *ServerBuilder) WithWatchdog(watchdog WatchdogConfig) *ServerBuilder {
+	b.watchdogConfig = &watchdog
+	return b
+}
+
+// WithClock sets the clock used by the middleware for timestamps, latencies and expiry checks,
+// e.g. a servertest.FakeClock to test logging or JWT expiry deterministically.
+func (b *ServerBuilder) WithClock(clock core.Clock) *ServerBuilder {
+	b.clock = clock
+	return b
+}
+
+// WithIDGenerator sets the generator of request IDs, e.g. core.NewULIDGenerator(nil)
+// for ULIDs or servertest.SequentialIDs in tests.
+func (b *ServerBuilder) WithIDGenerator(ids core.IDGenerator) *ServerBuilder {
+	b.idGenerator = ids
+	return b
+}
+
+// WithRequestID gives each request an ID with RequestIDMiddleware, e.g. with config.Generator set to
+// core.NewUUIDGenerator(), and stores it in the context under core.ContextKeyRequestID, so that
+// handlers and all middleware, not only the logging middleware, can read it.
+func (b *ServerBuilder) WithRequestID(config RequestIDConfig) *ServerBuilder {
+	b.requestIDConfig = &config
+	return b
+}
+
+// WithOutbox publishes the domain events that handlers stage with StageEvents or CommitJSON once
+// their response has been written successfully, and discards them if the request fails.
+// Events still being published when the server shuts down are waited for. See Outbox.
+func (b *ServerBuilder) WithOutbox(config OutboxConfig) *ServerBuilder {
+	b.outboxConfig = &config
+	return b
+}
+
+// WithHTMLTemplates loads html/template templates matching the glob patterns of config, with
+// optional layouts, and renders them with Context.HTML. Set config.Reload in development to pick
+// up template changes without a restart. Build returns an error if the templates cannot be loaded.
+// See HTMLTemplates.
+func (b *ServerBuilder) WithHTMLTemplates(config HTMLTemplateConfig) *ServerBuilder {
+	b.htmlTemplates = &config
+	b.templateEngine = nil
+	return b
+}
+
+// WithTemplateEngine renders Context.HTML with engine, e.g. an adapter for another template language.
+func (b *ServerBuilder) WithTemplateEngine(engine TemplateEngine) *ServerBuilder {
+	b.templateEngine = engine
+	b.htmlTemplates = nil
+	return b
+}
+
+// WithOpenTelemetry exports traces, metrics and access logs to an OpenTelemetry collector
+// over OTLP/HTTP, with the same resource attributes on all signals.
+// endpoint is the base URL of the collector, e.g. "https://otel-collector:4318".
+// A server span is started for every request, continuing incoming W3C traceparent headers;
+// access logs are exported when logging is enabled. Telemetry is flushed when the server stops.
+// If opts is nil, telemetry.DefaultOptions is used.
+func (b *ServerBuilder) WithOpenTelemetry(endpoint string, opts *telemetry.Options) *ServerBuilder {
+	b.telemetryEndpoint = endpoint
+	b.telemetryOptions = opts
+	return b
+}
+
 // Build creates a server with the configured controllers and middleware.
 func (b *ServerBuilder) Build() (core.Server, error) {
 	// Check if a port has been set
@@ -254,15 +678,134 @@ func (b *ServerBuilder) Build() (core.Server, error) {
 		return nil, fmt.Errorf("port not set: use NewServerBuilder with a port parameter or call WithDefaultPort")
 	}
 
+	// Reject invalid middleware configurations before anything is started
+	if b.authConfig != nil {
+		if err := b.authConfig.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if b.timeoutConfig != nil {
+		if err := b.timeoutConfig.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if b.debugTrace != nil && b.debugTrace.Secret == "" {
+		return nil, &ConfigError{
+			Middleware: "WithDebugTrace",
+			Field:      "secret",
+			Problem:    "is empty",
+			Remedy:     "pass the value of the header that enables tracing",
+		}
+	}
+
+	// Let plugins add their controllers and middleware before the server is assembled
+	if err := b.registerPlugins(); err != nil {
+		return nil, err
+	}
+
+	// Construct the controllers of the factories with the provided dependencies
+	for i, factory := range b.factories {
+		controller, err := constructController(factory, b.deps)
+		if err != nil {
+			return nil, fmt.Errorf("controller factory %d: %w", i, err)
+		}
+		b.controllers = append(b.controllers, controller)
+	}
+	b.factories = nil
+
 	// Create a new server
 	server, err := NewServer(b.frameworkType, b.port, b.showFrameworkLogs)
 	if err != nil {
 		return nil, err
 	}
 
-	// Collect controllers that should be skipped for logging and auth checks
+	if b.httpServerConfig != nil {
+		server.SetHTTPServerConfig(b.httpServerConfig)
+	}
+	if b.fastPath != nil {
+		server.SetFastPath(b.fastPath)
+	}
+	if b.engineOptions != nil {
+		server.SetEngineOptions(b.engineOptions)
+	}
+	if len(b.trustedProxies) > 0 {
+		if err := server.SetTrustedProxies(b.trustedProxies); err != nil {
+			return nil, err
+		}
+	}
+	if b.lambdaConfig != nil {
+		server.SetLambdaConfig(b.lambdaConfig)
+	}
+	if b.jsonCodec != nil {
+		core.SetJSONCodec(b.jsonCodec)
+	}
+
+	// Attach plugin lifecycle hooks
+	for _, plugin := range b.plugins {
+		server.OnStart(plugin.Start)
+		server.OnStop(plugin.Stop)
+	}
+
+	// Wait for dependencies before serving; the endpoint reports whether the checks have passed
+	var readiness *core.Readiness
+	if len(b.dependencyChecks) > 0 || b.readinessPath != "" {
+		readiness = core.NewReadiness(b.readinessConfig, b.dependencyChecks...)
+		server.OnStart(readiness.Start)
+		server.OnStop(readiness.Stop)
+	}
+
+	// Warm up before serving; the endpoint only runs the hooks if they have not completed yet
+	var warmup *core.Warmup
+	if len(b.warmupHooks) > 0 || b.warmupPath != "" {
+		warmup = core.NewWarmup(b.warmupHooks...)
+		if len(b.warmupHooks) > 0 {
+			server.OnStart(warmup.Run)
+		}
+	}
+
+	// Set up OpenTelemetry export; the exporters are flushed when the server stops
+	var otel *telemetry.Telemetry
+	if b.telemetryEndpoint != "" {
+		otel, err = telemetry.New(b.telemetryEndpoint, b.telemetryOptions)
+		if err != nil {
+			return nil, err
+		}
+		server.OnStop(otel.Shutdown)
+	}
+
+	// Monitor the process while the server runs, shedding routes by the priority class of their
+	// controllers; priorities set in the watchdog configuration take precedence
+	var watchdog *Watchdog
+	if b.watchdogConfig != nil {
+		watchdogConfig := *b.watchdogConfig
+		watchdogConfig.Priorities = make(map[string]core.Priority)
+		for _, controller := range b.controllers {
+			if prioritized, ok := controller.(core.PrioritizedController); ok && controller.GetPath() != "" {
+				route := string(controller.GetHttpMethod()) + " " + controller.GetPath()
+				watchdogConfig.Priorities[route] = prioritized.Priority()
+			}
+		}
+		for route, priority := range b.watchdogConfig.Priorities {
+			watchdogConfig.Priorities[route] = priority
+		}
+		watchdog = NewWatchdog(&watchdogConfig)
+		var stopWatchdog context.CancelFunc
+		server.OnStart(func(context.Context) error {
+			var ctx context.Context
+			ctx, stopWatchdog = context.WithCancel(context.Background())
+			go watchdog.Run(ctx)
+			return nil
+		})
+		server.OnStop(func(context.Context) error {
+			stopWatchdog()
+			return nil
+		})
+	}
+
+	// Collect controllers that should be skipped for logging, auth checks and other middleware
 	var skipLogPaths []string
 	var skipAuthCheckPaths []string
+	skipMiddlewareRoutes := make(map[string][]string)
 	for _, controller := range b.controllers {
 		if controller.SkipLogging() {
 			path := controller.GetPath()
@@ -276,12 +819,44 @@ func (b *ServerBuilder) Build() (core.Server, error) {
 				skipAuthCheckPaths = append(skipAuthCheckPaths, path)
 			}
 		}
+		if skipper, ok := controller.(core.MiddlewareSkipper); ok && controller.GetPath() != "" {
+			route := string(controller.GetHttpMethod()) + " " + controller.GetPath()
+			for _, name := range skipper.SkipMiddleware() {
+				skipMiddlewareRoutes[name] = append(skipMiddlewareRoutes[name], route)
+			}
+		}
+	}
+
+	// Trace requests carrying the debug secret through all other middleware
+	var tracer *DebugTracer
+	if b.debugTrace != nil {
+		tracer = NewDebugTracer(b.debugTrace)
+		server.UseDescribed(core.MiddlewareDescription{Name: core.MiddlewareDebugTrace}, tracer.Middleware())
+	}
+
+	// use registers named middleware, skipping it on the routes of controllers that opt out of it.
+	// config summarizes the configuration of the middleware for DescribeMiddleware.
+	use := func(name, config string, handler core.HandlerFunc) {
+		routes := skipMiddlewareRoutes[name]
+		if tracer != nil {
+			stepName := core.NamedHandler{Name: name, Handler: handler}.DisplayName()
+			handler = tracer.Step(stepName, handler, routes...)
+		} else if len(routes) > 0 {
+			handler = SkipRoutesMiddleware(handler, routes...)
+		}
+		server.UseDescribed(core.MiddlewareDescription{Name: name, Config: config, Except: routes}, handler)
 	}
 
 	// Add middleware in the correct order
 	// The order of middleware registration is important:
 	//
-	// 1. Error handler middleware (must be first)
+	// 0. Debug trace, clock, OpenTelemetry and request ID middleware
+	//    - The debug trace must start before the middleware it traces
+	//    - The clock and ID generator must be in place before any middleware reads them
+	//    - The server span must cover all other middleware, including the error handler
+	//    - The request ID must be assigned before the error handler and logging read it
+	//
+	// 1. Error handler middleware (must be first among the default middleware)
 	//    - This middleware catches errors and panics from all subsequent middleware
 	//    - It must be registered first to properly handle errors in other middleware
 	//
@@ -295,41 +870,115 @@ func (b *ServerBuilder) Build() (core.Server, error) {
 	//    - This middleware logs request details including status codes and errors
 	//    - It must be registered after the error handler to properly capture errors
 	//
-	// 5. Custom middleware
+	// 5. Authorization and policy middleware (must be after logging)
+	//    - Rejected requests are logged with their 401 or 403 status
+	//    - The policy is evaluated with the user stored by the authorization middleware
+	//
+	// 6. Custom middleware
 	//    - Any additional middleware provided by the application
 
-	// 1. Error handler middleware (must be first)
+	// 0. Clock, OpenTelemetry and request ID middleware
+	if b.clock != nil || b.idGenerator != nil {
+		use(core.MiddlewareClock, "", core.ClockMiddleware(b.clock, b.idGenerator))
+	}
+	if otel != nil {
+		use(core.MiddlewareOpenTelemetry, b.telemetryEndpoint, otel.Middleware())
+	}
+	if b.requestIDConfig != nil {
+		requestID, err := TryRequestIDMiddleware(b.requestIDConfig)
+		if err != nil {
+			return nil, err
+		}
+		use(core.MiddlewareRequestID, b.requestIDConfig.Header, requestID)
+	}
+	templateEngine := b.templateEngine
+	if b.htmlTemplates != nil {
+		templates, err := core.NewHTMLTemplates(*b.htmlTemplates)
+		if err != nil {
+			return nil, err
+		}
+		templateEngine = templates
+	}
+	if templateEngine != nil {
+		use(core.MiddlewareTemplates, "", core.TemplateMiddleware(templateEngine))
+	}
+
+	// 1. Error handler middleware (must be first among the default middleware)
 	if b.errorConfig != nil {
 		// Use framework-specific error handler middleware
 		errorHandler := server.GetErrorHandlerMiddleware()
-		server.Use(errorHandler.Middleware(b.errorConfig))
+		use(core.MiddlewareErrorHandler, "", errorHandler.Middleware(b.errorConfig))
 	} else if b.useDefaultErrorHandler {
 		// Use framework-specific error handler middleware with default config
 		errorHandler := server.GetErrorHandlerMiddleware()
-		server.Use(errorHandler.Middleware(nil))
+		use(core.MiddlewareErrorHandler, "default", errorHandler.Middleware(nil))
+	}
+
+	// The watchdog sheds low-priority requests before any work is done
+	if watchdog != nil {
+		use(core.MiddlewareWatchdog, "", watchdog.Middleware())
+	}
+
+	// Request limits reject oversized requests before any work is done
+	if b.maxHeaderCount > 0 {
+		use(core.MiddlewareHeaderLimit, fmt.Sprintf("max %d headers", b.maxHeaderCount), HeaderLimitMiddleware(b.maxHeaderCount))
+	}
+	if b.maxBodySize > 0 {
+		use(core.MiddlewareBodyLimit, fmt.Sprintf("max %d bytes", b.maxBodySize), BodyLimitMiddleware(b.maxBodySize))
+	}
+	if b.queryLimits != nil {
+		use(core.MiddlewareQueryLimit, fmt.Sprintf("max %d params", b.queryLimits.MaxParams), QueryLimitMiddleware(b.queryLimits))
+	}
+	if b.maxResponseSize > 0 {
+		use(core.MiddlewareResponseLimit, fmt.Sprintf("max %d bytes", b.maxResponseSize), ResponseLimitMiddleware(b.maxResponseSize))
 	}
 
 	// 2. Timeout middleware
 	if b.timeoutConfig != nil {
-		server.Use(TimeoutMiddleware(b.timeoutConfig))
+		use(core.MiddlewareTimeout, b.timeoutConfig.Timeout.String(), TimeoutMiddleware(b.timeoutConfig))
 	} else if b.useDefaultTimeout {
-		server.Use(NewDefaultTimeoutMiddleware())
+		use(core.MiddlewareTimeout, DefaultTimeoutConfig().Timeout.String(), NewDefaultTimeoutMiddleware())
 	}
 
 	// 3. CORS middleware
+	var corsStats *CORSStats
+	if b.corsDebugPath != "" {
+		corsStats = NewCORSStats(0)
+	}
 	if b.corsConfig != nil {
-		server.Use(CORSMiddleware(b.corsConfig))
+		corsConfig := *b.corsConfig
+		if corsStats != nil {
+			corsConfig.Stats = corsStats
+		}
+		use(core.MiddlewareCORS, describeCORS(&corsConfig), CORSMiddleware(&corsConfig))
 	} else if b.useDefaultCORS {
-		server.Use(NewDefaultCORSMiddleware())
+		corsConfig := DefaultCORSConfig()
+		corsConfig.Stats = corsStats
+		use(core.MiddlewareCORS, describeCORS(corsConfig), CORSMiddleware(corsConfig))
+	}
+
+	// Caching headers are set from the status of the response
+	if len(b.cachePolicies) > 0 {
+		use(core.MiddlewareCachePolicy, fmt.Sprintf("%d rules", len(b.cachePolicies)), CachePolicyMiddleware(b.cachePolicies...))
+	}
+	if len(b.responseDigests) > 0 {
+		responseDigest, err := TryResponseDigestMiddleware(&ResponseDigestConfig{Algorithms: b.responseDigests})
+		if err != nil {
+			return nil, err
+		}
+		use(core.MiddlewareResponseDigest, fmt.Sprint(b.responseDigests), responseDigest)
 	}
 
 	// 4. Logging middleware (must be after error handler)
 	if b.loggingConfig != nil {
 		// Add skip paths from controllers
 		b.loggingConfig.SkipPaths = append(b.loggingConfig.SkipPaths, skipLogPaths...)
+		if otel != nil {
+			b.loggingConfig.Sinks = append(b.loggingConfig.Sinks, otel.LogSink())
+		}
 		// Use framework-specific logging middleware
 		loggingMiddleware := server.GetLoggingMiddleware()
-		server.Use(loggingMiddleware.Middleware(b.loggingConfig))
+		use(core.MiddlewareLogging, describeLogging(b.loggingConfig), loggingMiddleware.Middleware(b.loggingConfig))
 	} else if b.useDefaultLogging {
 		// Create a default logging config with skip paths from controllers
 		loggingConfig := &core.LoggingConfig{
@@ -339,19 +988,86 @@ func (b *ServerBuilder) Build() (core.Server, error) {
 			LoggingToRemote:  false,
 			SkipPaths:        skipLogPaths,
 		}
+		if otel != nil {
+			loggingConfig.Sinks = append(loggingConfig.Sinks, otel.LogSink())
+		}
 		// Use framework-specific logging middleware with default config
 		loggingMiddleware := server.GetLoggingMiddleware()
-		server.Use(loggingMiddleware.Middleware(loggingConfig))
+		use(core.MiddlewareLogging, describeLogging(loggingConfig), loggingMiddleware.Middleware(loggingConfig))
+	}
+
+	// 5. Authorization and policy middleware (must be after logging)
+	if b.authConfig != nil {
+		authConfig := *b.authConfig
+		authConfig.SkipPaths = append(append([]string{}, authConfig.SkipPaths...), skipAuthCheckPaths...)
+		use(core.MiddlewareAuth, string(authConfig.AuthType), AuthMiddleware(&authConfig))
+	}
+	if b.policyConfig != nil {
+		use(core.MiddlewarePolicy, "", PolicyMiddleware(b.policyConfig))
+	}
+
+	// Staged events are published once the handlers have responded
+	if b.outboxConfig != nil {
+		outbox, err := NewOutbox(b.outboxConfig)
+		if err != nil {
+			return nil, err
+		}
+		server.OnStop(outbox.Wait)
+		use(core.MiddlewareOutbox, "", outbox.Middleware())
 	}
 
-	// 5. Custom middleware
+	// 6. Custom middleware
 	for _, middleware := range b.middleware {
-		server.Use(middleware)
+		use(middleware.Name, "", middleware.Handler)
 	}
 
 	// Register controllers
 	if len(b.controllers) > 0 {
-		server.RegisterRouter(b.controllers...)
+		controllers := b.mockControllers()
+		if tracer != nil {
+			controllers = tracedControllers(controllers, tracer)
+		}
+		server.RegisterRouter(controllers...)
+	}
+
+	// Serve controller examples for developer portals
+	if b.examplesPath != "" {
+		examples := core.CollectExamples(b.controllers)
+		server.GET(b.examplesPath, func(c core.Context) {
+			c.JSON(http.StatusOK, examples)
+		})
+	}
+
+	// Serve the OpenAPI document and the Swagger UI page
+	if b.openAPIPath != "" {
+		server.GET(b.openAPIPath, openapi.Handler(openapi.Generate(b.controllers, b.openAPIOptions)))
+		if b.openAPIOptions == nil || !b.openAPIOptions.DisableUI {
+			uiPath := openapi.DefaultUIPath
+			if b.openAPIOptions != nil && b.openAPIOptions.UIPath != "" {
+				uiPath = b.openAPIOptions.UIPath
+			}
+			server.GET(uiPath, openapi.UIHandler(b.openAPIPath, b.openAPIOptions))
+		}
+	}
+
+	// Serve the readiness endpoint
+	if b.readinessPath != "" {
+		server.GET(b.readinessPath, readiness.Handler())
+	}
+
+	// Serve the warmup endpoint
+	if b.warmupPath != "" {
+		server.GET(b.warmupPath, warmup.Handler())
+	}
+
+	// Serve CORS decisions for troubleshooting
+	if corsStats != nil {
+		server.GET(b.corsDebugPath, corsStats.Handler())
+	}
+
+	// Serve debug traces
+	if tracer != nil {
+		server.GET(b.debugTracePath, tracer.Handler())
 	}
 
 	// Set NoRoute handlers if provided, otherwise use default handlers
@@ -362,3 +1078,106 @@ func (b *ServerBuilder) Build() (core.Server, error) {
 
 	return server, nil
 }
+
+// constructController calls factory, returning the errors of MustResolve instead of panicking.
+func constructController(factory core.ControllerFactory, deps core.Deps) (controller core.Controller, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			resolveErr, ok := r.(error)
+			if !ok || !errors.Is(resolveErr, core.ErrDependencyNotFound) {
+				panic(r)
+			}
+			err = resolveErr
+		}
+	}()
+
+	controller = factory(deps)
+	if controller == nil {
+		return nil, fmt.Errorf("returned a nil controller")
+	}
+	return controller, nil
+}
+
+// describeCORS summarizes a CORS configuration for DescribeMiddleware.
+func describeCORS(config *CORSConfig) string {
+	if len(config.AllowedDomains) == 0 {
+		return "all origins"
+	}
+	return "origins " + strings.Join(config.AllowedDomains, ", ")
+}
+
+// describeLogging summarizes a logging configuration for DescribeMiddleware.
+func describeLogging(config *core.LoggingConfig) string {
+	var targets []string
+	if config.LoggingToConsole {
+		targets = append(targets, "console")
+	}
+	if config.LoggingToRemote {
+		targets = append(targets, config.RemoteURL)
+	}
+	if len(config.Sinks) > 0 {
+		targets = append(targets, fmt.Sprintf("%d sinks", len(config.Sinks)))
+	}
+	return strings.Join(targets, ", ")
+}
+
+// mockControllers returns the controllers to register, replacing the handlers of
+// mocked controllers with handlers that serve their examples.
+func (b *ServerBuilder) mockControllers() []core.Controller {
+	if b.mockConfig == nil || !b.mockConfig.Enabled {
+		return b.controllers
+	}
+
+	controllers := make([]core.Controller, len(b.controllers))
+	for i, controller := range b.controllers {
+		controllers[i] = controller
+		if !b.mockConfig.IsMocked(controller) {
+			continue
+		}
+
+		var examples []core.Example
+		if provider, ok := controller.(core.ExampleProvider); ok {
+			examples = provider.Examples()
+		}
+		if b.showFrameworkLogs {
+			log.Printf("[MOCK] Serving %d example(s) for %s %s", len(examples), controller.GetHttpMethod(), controller.GetPath())
+		}
+		controllers[i] = &mockedController{
+			Controller: controller,
+			handler:    core.MockHandler(examples, b.mockConfig.Latency),
+		}
+	}
+	return controllers
+}
+
+// mockedController wraps a controller, replacing its handlers with a mock handler.
+type mockedController struct {
+	core.Controller
+	handler core.HandlerFunc
+}
+
+// Handler returns the mock handler. The loaders of the controller don't run, as the mock doesn't need their data.
+func (c *mockedController) Handler() []core.HandlerFunc {
+	return []core.HandlerFunc{c.handler}
+}
+
+// tracedControllers returns the controllers with a debug trace step in front of their handlers,
+// so that traces show whether requests reached them.
+func tracedControllers(controllers []core.Controller, tracer *DebugTracer) []core.Controller {
+	traced := make([]core.Controller, len(controllers))
+	for i, controller := range controllers {
+		traced[i] = &tracedController{Controller: controller, step: tracer.HandlerStep()}
+	}
+	return traced
+}
+
+// tracedController wraps a controller, adding a debug trace step in front of its handlers.
+type tracedController struct {
+	core.Controller
+	step core.HandlerFunc
+}
+
+// Handler returns the loaders and handlers of the controller after the trace step.
+func (c *tracedController) Handler() []core.HandlerFunc {
+	return append([]core.HandlerFunc{c.step}, core.ControllerHandlers(c.Controller)...)
+}
@@ -1,10 +1,34 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	gingonic "github.com/gin-gonic/gin"
+	chirouter "github.com/go-chi/chi/v5"
+	fiberapp "github.com/gofiber/fiber/v2"
 	"github.com/mythofleader/go-http-server/core"
+	"github.com/mythofleader/go-http-server/core/dynamic"
 	"github.com/mythofleader/go-http-server/core/gin"
+	"github.com/mythofleader/go-http-server/core/servertest"
 	"github.com/mythofleader/go-http-server/core/std"
 )
 
@@ -72,3 +96,2576 @@ func TestStdServerRoutes(t *testing.T) {
 	// Skip this test for now as we need to refactor it to work with the new structure
 	t.Skip("Skipping test as it needs to be refactored to work with the new structure")
 }
+
+func TestFreeze(t *testing.T) {
+	for _, framework := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(framework), func(t *testing.T) {
+			s, err := NewServer(framework, "8080", false)
+			if err != nil {
+				t.Fatalf("NewServer(%s) returned error: %v", framework, err)
+			}
+			s.GET("/before", func(c core.Context) {})
+			s.Freeze()
+			if !s.Frozen() {
+				t.Fatal("Frozen() = false after Freeze()")
+			}
+
+			defer func() {
+				r := recover()
+				err, ok := r.(error)
+				if !ok || !errors.Is(err, core.ErrServerFrozen) {
+					t.Errorf("registering a route after Freeze() panicked with %v, want ErrServerFrozen", r)
+				}
+			}()
+			s.Group("/api").GET("/after", func(c core.Context) {})
+		})
+	}
+}
+
+func TestStdRouteConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		first    string
+		second   string
+		conflict bool
+	}{
+		{"same method and path", "GET /users", "GET /users", true},
+		{"different methods", "GET /users", "POST /users", false},
+		{"renamed parameter", "GET /users/:id", "GET /users/:uid", true},
+		{"parameter names under a prefix", "GET /users/:id", "GET /users/:name/posts", true},
+		{"parameter names across methods", "GET /users/:id", "DELETE /users/:uid", false},
+		{"static and parameter", "GET /users/:id", "GET /users/new", false},
+		{"different static segments", "GET /users/list", "GET /orders/list", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := std.NewServer("8080", false)
+			register := func(route string) {
+				method, path, _ := strings.Cut(route, " ")
+				handler := func(c core.Context) { c.String(http.StatusOK, route) }
+				switch method {
+				case "GET":
+					s.GET(path, handler)
+				case "POST":
+					s.POST(path, handler)
+				case "DELETE":
+					s.DELETE(path, handler)
+				}
+			}
+			register(tt.first)
+
+			defer func() {
+				r := recover()
+				err, _ := r.(error)
+				if conflict := errors.Is(err, core.ErrRouteConflict); conflict != tt.conflict {
+					t.Errorf("registering %s after %s panicked with %v, want conflict: %v", tt.second, tt.first, r, tt.conflict)
+				}
+			}()
+			register(tt.second)
+		})
+	}
+}
+
+func TestStdMethodsOnSamePath(t *testing.T) {
+	s := std.NewServer("8080", false)
+	s.GET("/users", func(c core.Context) { c.String(http.StatusOK, "list") })
+	s.POST("/users", func(c core.Context) { c.String(http.StatusCreated, "create") })
+
+	client := servertest.NewClient(s)
+	client.GET("/users").Expect(t).Status(http.StatusOK).Body("list")
+	client.POST("/users").Expect(t).Status(http.StatusCreated).Body("create")
+	client.Request(http.MethodDelete, "/users").Expect(t).Status(http.StatusMethodNotAllowed)
+}
+
+func TestRouteParams(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServer(frameworkType, "8080", false)
+			if err != nil {
+				t.Fatalf("NewServer(%s) returned error: %v", frameworkType, err)
+			}
+			s.GET("/users/new", func(c core.Context) { c.String(http.StatusOK, "new") })
+			s.GET("/users/:id", func(c core.Context) { c.String(http.StatusOK, "user "+c.Param("id")) })
+			s.Group("/users/:id").GET("/posts/:postID", func(c core.Context) {
+				c.String(http.StatusOK, c.Param("id")+"/"+c.Param("postID"))
+			})
+			s.GET("/static/*filepath", func(c core.Context) { c.String(http.StatusOK, c.Param("filepath")) })
+
+			client := servertest.NewClient(s)
+			client.GET("/users/new").Expect(t).Body("new")
+			client.GET("/users/42").Expect(t).Body("user 42")
+			client.GET("/users/42/posts/7").Expect(t).Body("42/7")
+			client.GET("/static/css/site.css").Expect(t).Body("/css/site.css")
+			client.GET("/users/42/comments").Expect(t).Status(http.StatusNotFound)
+		})
+	}
+}
+
+func TestDynamicRoutes(t *testing.T) {
+	for _, framework := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(framework), func(t *testing.T) {
+			s, err := NewServer(framework, "8080", false)
+			if err != nil {
+				t.Fatalf("NewServer(%s) returned error: %v", framework, err)
+			}
+			s.GET("/static", func(c core.Context) { c.String(http.StatusOK, "static") })
+			router := s.Dynamic()
+			s.Freeze()
+
+			err = router.Add(core.GET, "/plugins/:name", func(c core.Context) {
+				c.String(http.StatusOK, "plugin %s", dynamic.Param(c, "name"))
+			})
+			if err != nil {
+				t.Fatalf("Add() returned error: %v", err)
+			}
+
+			client := servertest.NewClient(s)
+			client.GET("/static").Expect(t).Status(http.StatusOK).Body("static")
+			client.GET("/plugins/foo").Expect(t).Status(http.StatusOK).Body("plugin foo")
+
+			router.Remove(core.GET, "/plugins/:name")
+			client.GET("/plugins/foo").Expect(t).Status(http.StatusNotFound)
+		})
+	}
+}
+
+func TestDynamicRoutesChain(t *testing.T) {
+	for _, framework := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(framework), func(t *testing.T) {
+			s, err := NewServer(framework, "8080", false)
+			if err != nil {
+				t.Fatalf("NewServer(%s) returned error: %v", framework, err)
+			}
+			s.NoRoute(func(c core.Context) { c.String(http.StatusNotFound, "no route") })
+			router := s.Dynamic()
+			s.Freeze()
+
+			auth := func(c core.Context) {
+				if c.GetHeader("Authorization") == "" {
+					c.String(http.StatusUnauthorized, "denied")
+					c.Abort()
+					return
+				}
+				c.Set("user", "alice")
+				c.Next()
+			}
+			err = router.Add(core.GET, "/plugins/:name/*rest", auth, func(c core.Context) {
+				user, _ := c.Get("user")
+				c.String(http.StatusOK, "%s %s %s", user, c.Param("name"), c.Param("rest"))
+			})
+			if err != nil {
+				t.Fatalf("Add() returned error: %v", err)
+			}
+
+			client := servertest.NewClient(s)
+			client.GET("/plugins/reports/2024/01").Expect(t).Status(http.StatusUnauthorized).Body("denied")
+			client.GET("/plugins/reports/2024/01").WithHeader("Authorization", "Bearer token").Expect(t).
+				Status(http.StatusOK).
+				Body("alice reports /2024/01")
+			client.GET("/missing").Expect(t).Status(http.StatusNotFound).Body("no route")
+		})
+	}
+}
+
+func TestQueryAndFormHelpers(t *testing.T) {
+	for _, framework := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(framework), func(t *testing.T) {
+			s, err := NewServer(framework, "8080", false)
+			if err != nil {
+				t.Fatalf("NewServer(%s) returned error: %v", framework, err)
+			}
+			s.POST("/search", func(c core.Context) {
+				q, ok := c.GetQuery("q")
+				_, missing := c.GetQuery("missing")
+				c.JSON(http.StatusOK, map[string]interface{}{
+					"q":       q,
+					"ok":      ok,
+					"missing": missing,
+					"tags":    c.QueryArray("tag"),
+					"filter":  c.QueryMap("filter"),
+					"name":    c.PostForm("name"),
+					"page":    c.DefaultPostForm("page", "1"),
+				})
+			})
+
+			servertest.NewClient(s).POST("/search").
+				WithQuery("q", "").
+				WithQuery("tag", "a").
+				WithQuery("tag", "b").
+				WithQuery("filter[status]", "open").
+				WithBody("application/x-www-form-urlencoded", []byte("name=john")).
+				Expect(t).
+				Status(http.StatusOK).
+				JSONPath("$.q", "").
+				JSONPath("$.ok", true).
+				JSONPath("$.missing", false).
+				JSONPath("$.tags", []string{"a", "b"}).
+				JSONPath("$.filter", map[string]string{"status": "open"}).
+				JSONPath("$.name", "john").
+				JSONPath("$.page", "1")
+		})
+	}
+}
+
+func TestTypedParams(t *testing.T) {
+	s, err := NewServer(core.FrameworkGin, "8080", false)
+	if err != nil {
+		t.Fatalf("NewServer() returned error: %v", err)
+	}
+	s.GET("/users/:id/:token", func(c core.Context) {
+		id, err := c.ParamInt("id")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, NewBadRequestResponse(err.Error()))
+			return
+		}
+		token, err := c.ParamUUID("token")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, NewBadRequestResponse(err.Error()))
+			return
+		}
+		c.String(http.StatusOK, "%d %s", id, token)
+	})
+	s.GET("/ratio/:value", func(c core.Context) {
+		value, err := Param[float64](c, "value")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, NewBadRequestResponse(err.Error()))
+			return
+		}
+		c.String(http.StatusOK, "%.2f", value)
+	})
+
+	client := servertest.NewClient(s)
+	client.GET("/users/42/123e4567-e89b-12d3-a456-426614174000").Expect(t).
+		Status(http.StatusOK).
+		Body("42 123e4567-e89b-12d3-a456-426614174000")
+	client.GET("/users/abc/123e4567-e89b-12d3-a456-426614174000").Expect(t).
+		Status(http.StatusBadRequest).
+		JSONPath("$.error.message", `invalid path parameter "id": must be an integer`)
+	client.GET("/users/42/not-a-uuid").Expect(t).
+		Status(http.StatusBadRequest).
+		JSONPath("$.error.message", `invalid path parameter "token": must be a UUID`)
+	client.GET("/ratio/0.5").Expect(t).Status(http.StatusOK).Body("0.50")
+	client.GET("/ratio/half").Expect(t).
+		Status(http.StatusBadRequest).
+		JSONPath("$.error.message", `invalid path parameter "value": must be a valid float64`)
+}
+
+func TestBindOnce(t *testing.T) {
+	type createUserRequest struct {
+		Name string `json:"name"`
+	}
+
+	for _, framework := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(framework), func(t *testing.T) {
+			s, err := NewServer(framework, "8080", false)
+			if err != nil {
+				t.Fatalf("NewServer(%s) returned error: %v", framework, err)
+			}
+			s.Use(func(c core.Context) {
+				req, err := BindOnce[createUserRequest](c)
+				if err != nil || req.Name == "" {
+					c.JSON(http.StatusBadRequest, NewBadRequestResponse("name is required"))
+					c.Abort()
+					return
+				}
+				c.Next()
+			})
+			s.POST("/users", func(c core.Context) {
+				// The body has already been consumed; the handler must get the cached value
+				req, ok := Bound[createUserRequest](c)
+				again, err := BindOnce[createUserRequest](c)
+				if !ok || err != nil || again != req {
+					c.String(http.StatusInternalServerError, "not bound")
+					return
+				}
+				c.String(http.StatusOK, "%s", req.Name)
+			})
+
+			client := servertest.NewClient(s)
+			client.POST("/users").WithJSON(map[string]string{"name": "john"}).Expect(t).
+				Status(http.StatusOK).
+				Body("john")
+			client.POST("/users").WithJSON(map[string]string{}).Expect(t).
+				Status(http.StatusBadRequest)
+		})
+	}
+}
+
+func TestBindJSONValidation(t *testing.T) {
+	type address struct {
+		Zip string `json:"zip" validate:"required,len=5"`
+	}
+	type createUserRequest struct {
+		Email   string  `json:"email" validate:"required,email"`
+		Age     int     `json:"age" validate:"gte=18"`
+		Address address `json:"address"`
+	}
+
+	for _, framework := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(framework), func(t *testing.T) {
+			s, err := NewServerBuilder(framework, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultErrorHandling().
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.POST("/users", func(c core.Context) {
+				var req createUserRequest
+				if err := c.BindJSON(&req); err != nil {
+					_ = c.Error(err)
+					return
+				}
+				c.String(http.StatusCreated, "%s", req.Email)
+			})
+
+			client := servertest.NewClient(s)
+			client.POST("/users").
+				WithJSON(map[string]interface{}{"email": "john@example.com", "age": 30, "address": map[string]string{"zip": "12345"}}).
+				Expect(t).
+				Status(http.StatusCreated).
+				Body("john@example.com")
+			client.POST("/users").
+				WithJSON(map[string]interface{}{"email": "john", "age": 12}).
+				Expect(t).
+				Status(http.StatusBadRequest).
+				JSONPath("$.error.fields[0].field", "email").
+				JSONPath("$.error.fields[0].rule", "email").
+				JSONPath("$.error.fields[1].field", "age").
+				JSONPath("$.error.fields[1].message", "must be greater than or equal to 18").
+				JSONPath("$.error.fields[2].field", "address.zip").
+				JSONPath("$.error.fields[2].rule", "required")
+		})
+	}
+}
+
+type exampleController struct {
+	method   core.HttpMethod
+	path     string
+	examples []core.Example
+}
+
+func (c *exampleController) GetHttpMethod() core.HttpMethod { return c.method }
+func (c *exampleController) GetPath() string                { return c.path }
+func (c *exampleController) SkipLogging() bool              { return false }
+func (c *exampleController) SkipAuthCheck() bool            { return false }
+func (c *exampleController) Examples() []core.Example       { return c.examples }
+
+func (c *exampleController) Handler() []core.HandlerFunc {
+	return []core.HandlerFunc{func(ctx core.Context) {
+		ctx.JSON(http.StatusOK, map[string]string{"name": "john"})
+	}}
+}
+
+func TestExamplesEndpoint(t *testing.T) {
+	controller := &exampleController{
+		method: core.POST,
+		path:   "/users",
+		examples: []core.Example{{
+			Name:       "success",
+			Request:    map[string]string{"name": "john"},
+			StatusCode: http.StatusOK,
+			Response:   map[string]string{"name": "john"},
+		}},
+	}
+
+	s, err := NewServerBuilder(core.FrameworkStdHTTP, "8080").
+		WithFrameworkLogs(false).
+		AddController(controller).
+		WithExamplesEndpoint().
+		Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	servertest.NewClient(s).GET(core.DefaultExamplesPath).Expect(t).
+		Status(http.StatusOK).
+		JSONPath("$[0].method", "POST").
+		JSONPath("$[0].path", "/users").
+		JSONPath("$[0].examples[0].name", "success").
+		JSONPath("$[0].examples[0].request.name", "john").
+		JSONPath("$[0].examples[0].statusCode", http.StatusOK)
+}
+
+type documentedController struct {
+	exampleController
+}
+
+type userDoc struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func (c *documentedController) Documentation() core.RouteDoc {
+	return core.RouteDoc{
+		Summary:   "Create a user",
+		Tags:      []string{"users"},
+		Request:   userDoc{},
+		Responses: map[int]interface{}{http.StatusOK: userDoc{}},
+	}
+}
+
+func TestWithOpenAPI(t *testing.T) {
+	for _, ft := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(ft), func(t *testing.T) {
+			s, err := NewServerBuilder(ft, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				AddController(&documentedController{exampleController{method: core.POST, path: "/users"}}).
+				AddController(&exampleController{method: core.GET, path: "/users/:id"}).
+				WithOpenAPI("/openapi.json", OpenAPIOptions{Title: "Users"}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+
+			client := servertest.NewClient(s)
+			rec := client.GET("/openapi.json").Expect(t).
+				Status(http.StatusOK).
+				JSONPath("$.openapi", "3.0.3").
+				JSONPath("$.info.title", "Users").
+				Recorder()
+
+			var doc struct {
+				Paths map[string]map[string]struct {
+					Summary    string `json:"summary"`
+					Parameters []struct {
+						Name string `json:"name"`
+					} `json:"parameters"`
+				} `json:"paths"`
+				Components struct {
+					Schemas map[string]interface{} `json:"schemas"`
+				} `json:"components"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+				t.Fatalf("failed to decode document: %v", err)
+			}
+			if summary := doc.Paths["/users"]["post"].Summary; summary != "Create a user" {
+				t.Errorf("summary = %q, want %q", summary, "Create a user")
+			}
+			if params := doc.Paths["/users/{id}"]["get"].Parameters; len(params) != 1 || params[0].Name != "id" {
+				t.Errorf("parameters = %v, want the id path parameter", params)
+			}
+			if _, ok := doc.Components.Schemas["userDoc"]; !ok {
+				t.Errorf("schemas = %v, want userDoc", doc.Components.Schemas)
+			}
+
+			client.GET(DefaultOpenAPIUIPath).Expect(t).
+				Status(http.StatusOK).
+				Header("Content-Type", "text/html; charset=utf-8").
+				BodyContains("swagger-ui-bundle.js").
+				BodyContains(`url: "/openapi.json"`)
+		})
+	}
+}
+
+func TestMockMode(t *testing.T) {
+	mocked := &exampleController{
+		method: core.GET,
+		path:   "/orders",
+		examples: []core.Example{
+			{Name: "list", StatusCode: http.StatusOK, Response: []string{"order-1"}},
+			{Name: "empty", StatusCode: http.StatusOK, Response: []string{}},
+		},
+	}
+	live := &exampleController{method: core.GET, path: "/users"}
+
+	t.Setenv(core.MockModeEnv, "true")
+	t.Setenv(core.MockLatencyEnv, "1ms")
+	config := core.MockConfigFromEnv()
+	if !config.Enabled || config.Latency != time.Millisecond {
+		t.Fatalf("MockConfigFromEnv() = %+v, want enabled with 1ms latency", config)
+	}
+	config.Routes = []string{"GET /orders"}
+
+	s, err := NewServerBuilder(core.FrameworkGin, "8080").
+		WithFrameworkLogs(false).
+		AddControllers(mocked, live).
+		WithMockMode(config).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+
+	client := servertest.NewClient(s)
+	client.GET("/orders").Expect(t).
+		Status(http.StatusOK).
+		Header("X-Mock", "true").
+		JSONPath("$[0]", "order-1")
+	client.GET("/orders").WithHeader(core.MockExampleHeader, "empty").Expect(t).
+		Status(http.StatusOK).
+		Body("[]")
+	client.GET("/orders").WithHeader(core.MockExampleHeader, "missing").Expect(t).
+		Status(http.StatusNotImplemented)
+	client.GET("/users").Expect(t).
+		Status(http.StatusOK).
+		Header("X-Mock", "").
+		JSONPath("$.name", "john")
+}
+
+type clockJWTLookup struct{}
+
+func (clockJWTLookup) LookupUserByJWT(claims MapClaims) (interface{}, error) {
+	return claims["sub"], nil
+}
+
+type clockLogSink struct {
+	records []core.LogRecord
+}
+
+func (s *clockLogSink) WriteLog(record core.LogRecord) {
+	s.records = append(s.records, record)
+}
+
+func TestClockAndIDGenerator(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			clock := servertest.NewFakeClock(now)
+			sink := &clockLogSink{}
+
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithClock(clock).
+				WithIDGenerator(servertest.SequentialIDs("req")).
+				WithLoggingConfig(core.LoggingConfig{Sinks: []core.LogSink{sink}}).
+				AddMiddleware(NewDefaultJWTAuthMiddleware(clockJWTLookup{}, "secret")).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/me", func(c core.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+
+			client := servertest.NewClient(s).WithJWTSecret("secret")
+			claims := MapClaims{"sub": "1", "exp": float64(now.Add(time.Hour).Unix())}
+
+			client.GET("/me").WithJWT(claims).Expect(t).
+				Status(http.StatusOK).
+				Header("X-Request-ID", "req-1")
+
+			// The token expires once the clock passes its exp claim
+			clock.Advance(2 * time.Hour)
+			client.GET("/me").WithJWT(claims).Expect(t).
+				Status(http.StatusUnauthorized).
+				Header("X-Request-ID", "req-2")
+
+			if len(sink.records) != 2 {
+				t.Fatalf("logged %d records, want 2", len(sink.records))
+			}
+			if sink.records[0].RequestID != "req-1" {
+				t.Errorf("RequestID = %q, want req-1", sink.records[0].RequestID)
+			}
+			if !strings.Contains(string(sink.records[0].Data), `"timestamp":"2024-01-02T03:04:05Z"`) {
+				t.Errorf("log entry %s does not use the fake clock", sink.records[0].Data)
+			}
+		})
+	}
+}
+
+func TestStartTime(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			clock := servertest.NewFakeClock(now)
+			sink := &clockLogSink{}
+
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithClock(clock).
+				WithLoggingConfig(core.LoggingConfig{Sinks: []core.LogSink{sink}}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			var elapsed time.Duration
+			s.Use(func(c core.Context) {
+				// The clock has moved on since the clock middleware recorded the start time
+				clock.Advance(50 * time.Millisecond)
+				c.Next()
+				elapsed = c.Elapsed()
+			})
+			s.GET("/report", func(c core.Context) {
+				if !c.StartTime().Equal(now) {
+					t.Errorf("StartTime() = %v, want %v", c.StartTime(), now)
+				}
+				clock.Advance(200 * time.Millisecond)
+				c.String(http.StatusOK, "ok")
+			})
+
+			servertest.NewClient(s).GET("/report").Expect(t).Status(http.StatusOK)
+
+			if elapsed != 250*time.Millisecond {
+				t.Errorf("Elapsed() = %v, want 250ms", elapsed)
+			}
+			if len(sink.records) != 1 || !strings.Contains(string(sink.records[0].Data), `"latency":250`) {
+				t.Errorf("log records %v do not share the start time", sink.records)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			newServer := func(proxies ...string) core.Server {
+				s, err := NewServerBuilder(frameworkType, "8080").
+					WithFrameworkLogs(false).
+					WithTrustedProxies(proxies...).
+					Build()
+				if err != nil {
+					t.Fatalf("Build() returned error: %v", err)
+				}
+				s.GET("/ip", func(c core.Context) {
+					c.String(http.StatusOK, c.ClientIP())
+				})
+				return s
+			}
+			request := func(s core.Server) string {
+				req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+				req.RemoteAddr = "192.0.2.1:1234"
+				req.Header.Set("X-Forwarded-For", "198.51.100.7, 203.0.113.5, 10.0.0.2")
+				rec := httptest.NewRecorder()
+				s.Handler().ServeHTTP(rec, req)
+				return rec.Body.String()
+			}
+
+			// Without trusted proxies, the forwarding headers can be forged by any client
+			if ip := request(newServer()); ip != "192.0.2.1" {
+				t.Errorf("ClientIP() = %q, want the remote address", ip)
+			}
+			if ip := request(newServer("192.0.2.1", "10.0.0.0/8")); ip != "203.0.113.5" {
+				t.Errorf("ClientIP() = %q, want the first untrusted hop", ip)
+			}
+
+			if _, err := NewServerBuilder(frameworkType, "8080").WithTrustedProxies("10.0.0.0/99").Build(); err == nil {
+				t.Error("Build() with an invalid proxy returned no error")
+			}
+		})
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			sink := &clockLogSink{}
+			config := DefaultRequestIDConfig()
+			config.Generator = servertest.SequentialIDs("req")
+
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultErrorHandling().
+				WithRequestID(*config).
+				WithLoggingConfig(core.LoggingConfig{Sinks: []core.LogSink{sink}}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/id", func(c core.Context) {
+				c.String(http.StatusOK, core.RequestIDFromContext(c.DetachedContext()))
+			})
+			s.GET("/fail", func(c core.Context) {
+				_ = c.Error(NewInternalServerHttpError(errors.New("failed")))
+			})
+
+			client := servertest.NewClient(s)
+			client.GET("/id").Expect(t).
+				Status(http.StatusOK).
+				Header("X-Request-ID", "req-1").
+				Body("req-1")
+			client.GET("/fail").Expect(t).
+				Status(http.StatusInternalServerError).
+				Header("X-Request-ID", "req-2").
+				JSONPath("$.error.request_id", "req-2")
+
+			if len(sink.records) != 2 {
+				t.Fatalf("logged %d records, want 2", len(sink.records))
+			}
+			for i, record := range sink.records {
+				if want := "req-" + strconv.Itoa(i+1); record.RequestID != want {
+					t.Errorf("RequestID of record %d = %q, want %q", i, record.RequestID, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBaggage(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServer(frameworkType, "8080", false)
+			if err != nil {
+				t.Fatalf("NewServer() returned error: %v", err)
+			}
+			s.GET("/flags", func(c core.Context) {
+				flag, _ := Baggage(c).Get("feature.checkout")
+				c.String(http.StatusOK, flag)
+			})
+
+			servertest.NewClient(s).GET("/flags").WithHeader("Baggage", "tenant=acme,feature.checkout=v2").Expect(t).
+				Status(http.StatusOK).
+				Body("v2")
+		})
+	}
+}
+
+// quietController is a controller whose requests are not logged.
+type quietController struct{}
+
+func (quietController) GetHttpMethod() core.HttpMethod { return core.GET }
+func (quietController) GetPath() string                { return "/metrics/:name" }
+func (quietController) SkipLogging() bool              { return true }
+func (quietController) SkipAuthCheck() bool            { return false }
+
+func (quietController) Handler() []core.HandlerFunc {
+	return []core.HandlerFunc{func(c core.Context) {
+		c.String(http.StatusOK, "metric")
+	}}
+}
+
+func TestLoggingSkipPaths(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			sink := &clockLogSink{}
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithLoggingConfig(core.LoggingConfig{Sinks: []core.LogSink{sink}, SkipPaths: []string{"/static/*"}}).
+				AddController(quietController{}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/static/:file", func(c core.Context) {
+				c.String(http.StatusOK, "file")
+			})
+			s.GET("/orders", func(c core.Context) {
+				c.String(http.StatusOK, "orders")
+			})
+
+			client := servertest.NewClient(s)
+			client.GET("/metrics/cpu").Expect(t).Status(http.StatusOK)
+			client.GET("/static/app.js").Expect(t).Status(http.StatusOK)
+			client.GET("/orders").Expect(t).Status(http.StatusOK)
+
+			if len(sink.records) != 1 {
+				t.Fatalf("logged %d records, want only the one of /orders", len(sink.records))
+			}
+			if !strings.Contains(string(sink.records[0].Data), "/orders") {
+				t.Errorf("logged %s, want the entry of /orders", sink.records[0].Data)
+			}
+		})
+	}
+}
+
+type preloadOrder struct {
+	ID    string `json:"id"`
+	Owner string `json:"owner"`
+}
+
+// orderController preloads the order of its route before its handler runs.
+type orderController struct {
+	orders  map[string]*preloadOrder
+	handled int
+}
+
+func (c *orderController) GetHttpMethod() core.HttpMethod { return core.GET }
+func (c *orderController) GetPath() string                { return "/orders/:id" }
+func (c *orderController) SkipLogging() bool              { return false }
+func (c *orderController) SkipAuthCheck() bool            { return false }
+
+func (c *orderController) Loaders() []core.HandlerFunc {
+	return []core.HandlerFunc{Preload(func(ctx core.Context) (*preloadOrder, error) {
+		order := c.orders[ctx.Param("id")]
+		if order != nil && order.Owner != ctx.GetHeader("X-User") {
+			return nil, NewForbiddenHttpError(errors.New("not your order"))
+		}
+		return order, nil
+	})}
+}
+
+func (c *orderController) Handler() []core.HandlerFunc {
+	return []core.HandlerFunc{func(ctx core.Context) {
+		c.handled++
+		order, _ := Loaded[preloadOrder](ctx)
+		ctx.JSON(http.StatusOK, order)
+	}}
+}
+
+func TestPreload(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			controller := &orderController{orders: map[string]*preloadOrder{"1": {ID: "1", Owner: "alice"}}}
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultErrorHandling().
+				AddController(controller).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+
+			client := servertest.NewClient(s)
+			client.GET("/orders/1").WithHeader("X-User", "alice").Expect(t).
+				Status(http.StatusOK).
+				JSONPath("$.id", "1")
+			client.GET("/orders/2").WithHeader("X-User", "alice").Expect(t).
+				Status(http.StatusNotFound).
+				JSONPath("$.error.message", "preloadOrder not found")
+			client.GET("/orders/1").WithHeader("X-User", "bob").Expect(t).
+				Status(http.StatusForbidden).
+				JSONPath("$.error.message", "not your order")
+
+			if controller.handled != 1 {
+				t.Errorf("handler ran %d times, want only for the loaded order", controller.handled)
+			}
+		})
+	}
+}
+
+func TestJSONClientAbort(t *testing.T) {
+	for _, s := range []core.Server{gin.NewServer("8080", false), std.NewServer("8080", false)} {
+		s.GET("/report", func(c core.Context) {
+			c.JSON(http.StatusOK, map[string]string{"report": "large"})
+		})
+
+		req, err := servertest.NewClient(s).GET("/report").Build()
+		if err != nil {
+			t.Fatalf("Build() returned error: %v", err)
+		}
+		ctx, cancel := context.WithCancel(req.Context())
+		cancel()
+
+		before := core.ClientAborts()
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req.WithContext(ctx))
+
+		if rec.Body.Len() != 0 {
+			t.Errorf("wrote %q for a disconnected client, want nothing", rec.Body.String())
+		}
+		if core.ClientAborts() != before+1 {
+			t.Errorf("ClientAborts() = %d, want %d", core.ClientAborts(), before+1)
+		}
+	}
+}
+
+func TestHardenedDefaults(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithHardenedDefaults().
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.POST("/upload", func(c core.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+
+			client := servertest.NewClient(s)
+			client.POST("/upload").WithBody("text/plain", []byte("data")).Expect(t).
+				Status(http.StatusOK)
+
+			req := client.POST("/upload")
+			for i := 0; i <= DefaultMaxHeaderCount; i++ {
+				req = req.WithHeader("X-Header-"+strconv.Itoa(i), "value")
+			}
+			req.Expect(t).Status(http.StatusRequestHeaderFieldsTooLarge)
+
+			big, err := client.POST("/upload").Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			big.ContentLength = DefaultMaxBodySize + 1
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, big)
+			if rec.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+			}
+
+			client.POST("/upload?ids[1000]=1").Expect(t).
+				Status(http.StatusBadRequest).
+				JSONPath("$.error.code", 400)
+			client.POST("/upload?q=" + strings.Repeat("x", 4096)).Expect(t).
+				Status(http.StatusRequestURITooLong)
+		})
+	}
+}
+
+func TestWithCachePolicy(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithDefaultErrorHandling().
+				WithClock(servertest.NewFakeClock(now)).
+				WithCachePolicy("GET /products/:id", 5*time.Minute, false, false).
+				WithCachePolicy("/account", time.Minute, true, false).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/products/:id", func(c core.Context) {
+				if c.Param("id") == "0" {
+					c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed"})
+					return
+				}
+				c.JSON(http.StatusOK, map[string]string{"id": c.Param("id")})
+			})
+			s.GET("/account", func(c core.Context) {
+				c.String(http.StatusOK, "account")
+			})
+
+			client := servertest.NewClient(s)
+			client.GET("/products/1").Expect(t).
+				Status(http.StatusOK).
+				Header("Cache-Control", "public, max-age=300").
+				Header("Expires", "Tue, 02 Jan 2024 03:09:05 GMT")
+			client.GET("/products/0").Expect(t).
+				Status(http.StatusInternalServerError).
+				Header("Cache-Control", "no-store").
+				Header("Expires", "")
+			client.GET("/account").Expect(t).
+				Header("Cache-Control", "private, max-age=60")
+		})
+	}
+}
+
+func TestWithOutbox(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			published := make(chan string, 2)
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithDefaultErrorHandling().
+				WithOutbox(OutboxConfig{Publisher: OutboxPublisherFunc(func(ctx context.Context, events []OutboxEvent) error {
+					for _, event := range events {
+						published <- event.Topic
+					}
+					return nil
+				})}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.POST("/orders/:id", func(c core.Context) {
+				if c.Param("id") == "0" {
+					_ = StageEvents(c, OutboxEvent{Topic: "order.rejected"})
+					_ = c.Error(NewForbiddenHttpError(errors.New("rejected")))
+					return
+				}
+				_ = CommitJSON(c, http.StatusCreated, map[string]string{"id": c.Param("id")},
+					OutboxEvent{Topic: "order.created", Key: c.Param("id")})
+			})
+
+			client := servertest.NewClient(s)
+			client.POST("/orders/0").Expect(t).Status(http.StatusForbidden)
+			client.POST("/orders/1").Expect(t).Status(http.StatusCreated).JSONPath("$.id", "1")
+
+			select {
+			case topic := <-published:
+				if topic != "order.created" {
+					t.Errorf("published %q, want order.created", topic)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("no event was published")
+			}
+		})
+	}
+}
+
+func TestWithResponseDigest(t *testing.T) {
+	content := []byte("invoice 2024-001")
+	sum := sha256.Sum256(content)
+	contentDigest := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+	file := filepath.Join(t.TempDir(), "invoice.txt")
+	if err := os.WriteFile(file, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithResponseDigest().
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/invoice", func(c core.Context) {
+				c.File(file)
+			})
+			s.GET("/invoice/data", func(c core.Context) {
+				Data(c, http.StatusOK, "text/plain", content)
+			})
+			s.GET("/status", func(c core.Context) {
+				c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+			})
+
+			client := servertest.NewClient(s)
+			client.GET("/invoice").Expect(t).Status(http.StatusOK).Header("Content-Digest", contentDigest)
+			client.GET("/invoice/data").Expect(t).Status(http.StatusOK).Header("Content-Digest", contentDigest)
+			client.GET("/status").Expect(t).Status(http.StatusOK).Header("Content-Digest", "")
+		})
+	}
+}
+
+func TestWithHTMLTemplates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/base.html":         {Data: []byte(`<html><body>{{block "content" .}}{{end}}</body></html>`)},
+		"templates/pages/home.html":   {Data: []byte(`{{define "content"}}<h1>{{.Title}}</h1>{{end}}`)},
+		"templates/pages/broken.html": {Data: []byte(`{{define "content"}}{{.Missing}}{{end}}`)},
+	}
+
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithDefaultErrorHandling().
+				WithHTMLTemplates(HTMLTemplateConfig{
+					FS:       fsys,
+					Patterns: []string{"templates/pages/*.html"},
+					Layouts:  []string{"templates/base.html"},
+					Layout:   "base.html",
+				}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/", func(c core.Context) {
+				c.HTML(http.StatusOK, "home.html", map[string]string{"Title": "<Welcome>"})
+			})
+			s.GET("/broken", func(c core.Context) {
+				c.HTML(http.StatusOK, "broken.html", "not a struct")
+			})
+			s.GET("/missing", func(c core.Context) {
+				c.HTML(http.StatusOK, "missing.html", nil)
+			})
+
+			client := servertest.NewClient(s)
+			client.GET("/").Expect(t).
+				Status(http.StatusOK).
+				Header("Content-Type", "text/html; charset=utf-8").
+				Body("<html><body><h1>&lt;Welcome&gt;</h1></body></html>")
+			client.GET("/broken").Expect(t).Status(http.StatusInternalServerError)
+			client.GET("/missing").Expect(t).Status(http.StatusInternalServerError)
+		})
+	}
+
+	_, err := NewServerBuilder(core.FrameworkStdHTTP, "8080").
+		WithHTMLTemplates(HTMLTemplateConfig{FS: fsys, Patterns: []string{"views/*.html"}}).
+		Build()
+	if err == nil {
+		t.Error("Build() returned no error for templates that don't exist")
+	}
+}
+
+func TestWithMaxResponseSize(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithDefaultTimeout().
+				WithMaxResponseSize(1024).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/items", func(c core.Context) {
+				n, _ := strconv.Atoi(c.Query("n"))
+				c.JSON(http.StatusOK, make([]int, n))
+			})
+
+			client := servertest.NewClient(s)
+			client.GET("/items?n=10").Expect(t).
+				Status(http.StatusOK).
+				JSONPath("$[9]", 0)
+			client.GET("/items?n=1000").Expect(t).
+				Status(http.StatusInternalServerError).
+				JSONPath("$.error.message", "Response too large")
+		})
+	}
+}
+
+func TestCORSDebugEndpoint(t *testing.T) {
+	s, err := NewServerBuilder(FrameworkStdHTTP, "8080").
+		WithFrameworkLogs(false).
+		WithCORS(CORSConfig{AllowedDomains: []string{"https://app.example.com"}}).
+		WithCORSDebugEndpoint().
+		Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	s.GET("/items", func(c core.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	client := servertest.NewClient(s)
+	client.GET("/items").WithHeader("Origin", "https://other.example.com").Expect(t).Status(http.StatusOK)
+
+	client.GET(DefaultCORSDebugPath).Expect(t).
+		Status(http.StatusOK).
+		JSONPath("$.blocked", 1).
+		JSONPath("$.recent[0].origin", "https://other.example.com")
+}
+
+func TestErrorResponseRequestID(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer collector.Close()
+
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithErrorHandler(core.ErrorHandlerConfig{
+					DefaultErrorMessage: "Internal Server Error",
+					DefaultStatusCode:   http.StatusInternalServerError,
+					IncludeRequestID:    true,
+					IncludeTraceID:      true,
+				}).
+				WithIDGenerator(servertest.SequentialIDs("req")).
+				WithOpenTelemetry(collector.URL, nil).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/fail", func(c core.Context) {
+				panic("database unavailable")
+			})
+
+			servertest.NewClient(s).GET("/fail").
+				WithHeader("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01").
+				Expect(t).
+				Status(http.StatusInternalServerError).
+				Header("X-Request-ID", "req-1").
+				JSONPath("$.error.message", "database unavailable").
+				JSONPath("$.error.request_id", "req-1").
+				JSONPath("$.error.trace_id", "4bf92f3577b34da6a3ce929d0e0e4736")
+		})
+	}
+}
+
+func TestProblemJSONErrors(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithErrorHandler(core.ErrorHandlerConfig{
+					DefaultErrorMessage: "Internal Server Error",
+					DefaultStatusCode:   http.StatusInternalServerError,
+					Format:              ErrorFormatProblemJSON,
+					ProblemTypeBaseURI:  "https://example.com/problems/",
+				}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/items/:id", func(c core.Context) {
+				_ = c.Error(NewNotFoundHttpError(errors.New("item not found")))
+			})
+			s.GET("/search", func(c core.Context) {
+				_ = c.Error(NewTooManyRequestsHttpError(errors.New("slow down"), 2*time.Second))
+			})
+
+			servertest.NewClient(s).GET("/items/42").Expect(t).
+				Status(http.StatusNotFound).
+				Header("Content-Type", ProblemJSONContentType).
+				JSONPath("$.type", "https://example.com/problems/404").
+				JSONPath("$.title", "Not Found").
+				JSONPath("$.status", 404).
+				JSONPath("$.detail", "item not found").
+				JSONPath("$.instance", "/items/42")
+
+			servertest.NewClient(s).GET("/search").Expect(t).
+				Status(http.StatusTooManyRequests).
+				Header("Retry-After", "2").
+				JSONPath("$.retry_after", 2)
+		})
+	}
+
+	// Without a base URI, problems have no further semantics than their status
+	problem := NewErrorResponse(http.StatusConflict, "version mismatch").Problem()
+	if problem.Type != "about:blank" || problem.Title != "Conflict" || problem.Detail != "version mismatch" {
+		t.Errorf("Problem() = %+v", problem)
+	}
+}
+
+func TestAggregateErrors(t *testing.T) {
+	failing := func(c core.Context) {
+		_ = c.Error(NewBadRequestHttpError(errors.New("invalid name")))
+		_ = c.Error(NewServiceUnavailableHttpError(errors.New("search is down")))
+		_ = c.Error(errors.New("cache miss"))
+	}
+
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithErrorHandler(core.ErrorHandlerConfig{
+					DefaultErrorMessage: "Internal Server Error",
+					DefaultStatusCode:   http.StatusInternalServerError,
+					AggregateErrors:     true,
+				}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/items", failing)
+
+			servertest.NewClient(s).GET("/items").Expect(t).
+				Status(http.StatusServiceUnavailable).
+				JSONPath("$.error.message", "search is down").
+				JSONPath("$.error.details[0].code", 400).
+				JSONPath("$.error.details[0].message", "invalid name").
+				JSONPath("$.error.details[1].code", 503).
+				JSONPath("$.error.details[2].code", 500).
+				JSONPath("$.error.details[2].message", "Internal Server Error")
+		})
+	}
+
+	// Without aggregation only the first error is used
+	s, err := NewServerBuilder(FrameworkGin, "8080").
+		WithFrameworkLogs(false).
+		WithDefaultErrorHandling().
+		Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	s.GET("/items", failing)
+	rec := servertest.NewClient(s).GET("/items").Expect(t).
+		Status(http.StatusBadRequest).
+		JSONPath("$.error.message", "invalid name").
+		Recorder()
+	if strings.Contains(rec.Body.String(), "details") {
+		t.Errorf("body %s contains details without aggregation", rec.Body.String())
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithErrorHandler(core.ErrorHandlerConfig{
+					DefaultErrorMessage: "Internal Server Error",
+					DefaultStatusCode:   http.StatusInternalServerError,
+					AggregateErrors:     true,
+				}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/timeout", func(c core.Context) {
+				_ = c.Error(fmt.Errorf("query: %w", context.DeadlineExceeded))
+			})
+			s.GET("/bind", func(c core.Context) {
+				var body struct{ Name string }
+				_ = c.Error(c.ShouldBindJSON(&body))
+			})
+			client := servertest.NewClient(s)
+
+			client.GET("/timeout").Expect(t).
+				Status(http.StatusGatewayTimeout).
+				JSONPath("$.error.message", "Gateway Timeout")
+			client.GET("/bind").Expect(t).
+				Status(http.StatusBadRequest).
+				JSONPath("$.error.message", "EOF")
+		})
+	}
+}
+
+func TestClientClosedRequestLogging(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			sink := &clockLogSink{}
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithLoggingConfig(core.LoggingConfig{Sinks: []core.LogSink{sink}}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s.GET("/report", func(c core.Context) {
+				// The client disconnects while the report is generated
+				cancel()
+				c.String(http.StatusInternalServerError, "failed")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/report", nil).WithContext(ctx)
+			s.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+			if len(sink.records) != 1 {
+				t.Fatalf("logged %d records, want 1", len(sink.records))
+			}
+			if data := string(sink.records[0].Data); !strings.Contains(data, `"status_code":499`) {
+				t.Errorf("log entry %s does not have status 499", data)
+			}
+		})
+	}
+}
+
+// progressLogSink is a LogSink that records the entries logged while the request is served.
+type progressLogSink struct {
+	mu      sync.Mutex
+	records []string
+}
+
+func (s *progressLogSink) WriteLog(record core.LogRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, string(record.Data))
+}
+
+func (s *progressLogSink) logged() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.records...)
+}
+
+func TestProgressLogging(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			sink := &progressLogSink{}
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithLoggingConfig(core.LoggingConfig{
+					Sinks:            []core.LogSink{sink},
+					ProgressInterval: 5 * time.Millisecond,
+				}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/events", func(c core.Context) {
+				c.SetHeader("Content-Type", "text/event-stream")
+				_, _ = c.Writer().Write([]byte("data: hello\n\n"))
+				c.Writer().Flush()
+				// The stream stays open until a progress entry is logged
+				deadline := time.Now().Add(2 * time.Second)
+				for len(sink.logged()) == 0 && time.Now().Before(deadline) {
+					time.Sleep(time.Millisecond)
+				}
+			})
+
+			servertest.NewClient(s).GET("/events").Expect(t).
+				Status(http.StatusOK).
+				Body("data: hello\n\n")
+
+			records := sink.logged()
+			if len(records) < 2 {
+				t.Fatalf("logged %d records, want progress entries and a summary", len(records))
+			}
+			for _, data := range records[:len(records)-1] {
+				if !strings.Contains(data, `"phase":"progress"`) {
+					t.Errorf("entry %s before the summary is not a progress entry", data)
+				}
+			}
+			summary := records[len(records)-1]
+			for _, want := range []string{`"status_code":200`, `"phase":"summary"`, `"bytes_sent":13`} {
+				if !strings.Contains(summary, want) {
+					t.Errorf("summary entry %s does not contain %s", summary, want)
+				}
+			}
+		})
+	}
+}
+
+type slowController struct {
+	path string
+	skip []string
+}
+
+func (c *slowController) GetHttpMethod() core.HttpMethod { return core.GET }
+func (c *slowController) GetPath() string                { return c.path }
+func (c *slowController) SkipLogging() bool              { return false }
+func (c *slowController) SkipAuthCheck() bool            { return false }
+func (c *slowController) SkipMiddleware() []string       { return c.skip }
+
+func (c *slowController) Handler() []core.HandlerFunc {
+	return []core.HandlerFunc{func(ctx core.Context) {
+		time.Sleep(50 * time.Millisecond)
+		ctx.String(http.StatusOK, "done")
+	}}
+}
+
+func TestSkipMiddleware(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithTimeout(TimeoutConfig{Timeout: 10 * time.Millisecond}).
+				AddControllers(
+					&slowController{path: "/stream", skip: []string{MiddlewareTimeout}},
+					&slowController{path: "/slow"},
+				).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+
+			client := servertest.NewClient(s)
+			client.GET("/stream").Expect(t).
+				Status(http.StatusOK).
+				Body("done")
+			client.GET("/slow").Expect(t).
+				Status(http.StatusGatewayTimeout)
+		})
+	}
+}
+
+func TestFastPath(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			sink := &clockLogSink{}
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithLoggingConfig(core.LoggingConfig{Sinks: []core.LogSink{sink}}).
+				AddMiddleware(NewDefaultJWTAuthMiddleware(clockJWTLookup{}, "secret")).
+				WithFastPath().
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/private", func(c core.Context) {
+				c.String(http.StatusOK, "secret")
+			})
+
+			client := servertest.NewClient(s)
+			client.GET("/health").Expect(t).
+				Status(http.StatusOK).
+				Body("ok")
+			client.GET("/ping").Expect(t).
+				Status(http.StatusOK)
+			if len(sink.records) != 0 {
+				t.Errorf("fast path requests were logged: %+v", sink.records)
+			}
+
+			// Other requests still run the middleware chain
+			client.GET("/private").Expect(t).
+				Status(http.StatusUnauthorized)
+			if len(sink.records) != 1 {
+				t.Errorf("got %d log records, want 1", len(sink.records))
+			}
+		})
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			warmed := 0
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithWarmup(func(ctx context.Context) error {
+					warmed++
+					return nil
+				}).
+				WithWarmupEndpoint().
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+
+			// The endpoint runs the warmup for platforms that call it before sending traffic
+			client := servertest.NewClient(s)
+			client.GET(DefaultWarmupPath).Expect(t).
+				Status(http.StatusOK).
+				JSONPath("$.status", "warm")
+			client.GET(DefaultWarmupPath).Expect(t).
+				Status(http.StatusOK)
+			if warmed != 1 {
+				t.Errorf("warmup ran %d times, want 1", warmed)
+			}
+		})
+	}
+}
+
+func TestWithDependencyCheck(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithDependencyCheck("postgres", func(ctx context.Context) error {
+					return errors.New("connection refused")
+				}).
+				WithReadinessConfig(ReadinessConfig{Timeout: 20 * time.Millisecond, Backoff: time.Millisecond}).
+				WithReadinessEndpoint().
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+
+			// Readiness reports the state of each check until they pass
+			servertest.NewClient(s).GET(DefaultReadinessPath).Expect(t).
+				Status(http.StatusServiceUnavailable).
+				JSONPath("$.status", "not ready").
+				JSONPath("$.checks[0].name", "postgres")
+
+			// The listener is not bound if the checks do not pass within the startup timeout
+			if err := s.Run(); err == nil || !strings.Contains(err.Error(), `dependency "postgres" is not ready`) {
+				t.Errorf("Run() error = %v, want the error of the postgres check", err)
+			}
+		})
+	}
+}
+
+func TestContextWriterStatus(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+
+			var status, size int
+			s.Use(func(c core.Context) {
+				c.Next()
+				status, size = c.Writer().Status(), c.Writer().Size()
+			})
+			// A middleware buffering the response, as in the ResponseBuffer example
+			s.Use(func(c core.Context) {
+				original := c.Writer()
+				buffer := core.NewResponseBuffer(original)
+				c.SetWriter(buffer)
+				c.Next()
+				if c.Writer().Status() != http.StatusCreated {
+					t.Errorf("buffered Status() = %d, want 201", c.Writer().Status())
+				}
+				c.SetWriter(original)
+				buffer.Commit()
+			})
+			s.POST("/items", func(c core.Context) {
+				if c.Writer().Written() {
+					t.Error("Written() = true before the handler responded")
+				}
+				c.String(http.StatusCreated, "created")
+			})
+
+			servertest.NewClient(s).POST("/items").Expect(t).
+				Status(http.StatusCreated).
+				Body("created")
+			if status != http.StatusCreated || size != len("created") {
+				t.Errorf("Status(), Size() = %d, %d, want 201, %d", status, size, len("created"))
+			}
+		})
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServer(frameworkType, "8080", false)
+			if err != nil {
+				t.Fatalf("NewServer() returned error: %v", err)
+			}
+			s.GET("/headers", func(c core.Context) {
+				c.SetHeader("x-removed", "value")
+				c.SetHeader("X-Removed", "")
+				c.AddHeader("vary", "Origin")
+				c.AddHeader("Vary", "Accept-Encoding")
+				c.JSON(http.StatusOK, map[string]interface{}{
+					"first": c.GetHeader("x-forwarded-for"),
+					"all":   c.GetHeaders("x-forwarded-for"),
+					"none":  c.GetHeaders("X-Missing"),
+				})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/headers", nil)
+			req.Header.Add("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+			req.Header.Add("X-Forwarded-For", "10.0.0.2")
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+
+			want := `{"all":["203.0.113.7, 10.0.0.1","10.0.0.2"],"first":"203.0.113.7, 10.0.0.1","none":null}`
+			if body := strings.TrimSpace(rec.Body.String()); body != want {
+				t.Errorf("body = %s, want %s", body, want)
+			}
+			if vary := rec.Header().Values("Vary"); strings.Join(vary, ",") != "Origin,Accept-Encoding" {
+				t.Errorf("Vary = %q, want Origin and Accept-Encoding", vary)
+			}
+			if _, ok := rec.Header()["X-Removed"]; ok {
+				t.Errorf("X-Removed = %q, want no header", rec.Header().Values("X-Removed"))
+			}
+		})
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServer(frameworkType, "8080", false)
+			if err != nil {
+				t.Fatalf("NewServer() returned error: %v", err)
+			}
+			s.GET("/adapter", func(c core.Context) { c.String(http.StatusOK, "adapter") })
+
+			// Routes added to the native engine are served next to those of the adapter
+			switch engine := s.Unwrap().(type) {
+			case *gingonic.Engine:
+				engine.GET("/native", func(c *gingonic.Context) { c.String(http.StatusOK, "native") })
+			case *chirouter.Mux:
+				engine.Get("/native", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("native")) })
+			case *fiberapp.App:
+				engine.Get("/native", func(c *fiberapp.Ctx) error { return c.SendString("native") })
+			case *std.Server:
+				if engine != s {
+					t.Fatalf("Unwrap() = %p, want the server %p", engine, s)
+				}
+				return
+			default:
+				t.Fatalf("Unwrap() returned %T", engine)
+			}
+
+			client := servertest.NewClient(s)
+			client.GET("/adapter").Expect(t).Status(http.StatusOK).Body("adapter")
+			client.GET("/native").Expect(t).Status(http.StatusOK).Body("native")
+		})
+	}
+}
+
+func TestEngineOptions(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithEngineOptions(EngineOptions{
+					TrustedPlatform:    PlatformCloudflare,
+					MaxMultipartMemory: 1 << 20,
+					RemoveExtraSlash:   true,
+					UseRawPath:         true,
+				}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/users/:id", func(c core.Context) {
+				c.String(http.StatusOK, c.Param("id"))
+			})
+			s.GET("/files/:name/raw", func(c core.Context) {
+				c.String(http.StatusOK, c.Param("name"))
+			})
+
+			client := servertest.NewClient(s)
+			client.GET("/users//42").Expect(t).
+				Status(http.StatusOK).
+				Body("42")
+			client.GET("/users/./42").Expect(t).
+				Status(http.StatusOK).
+				Body("42")
+			// An encoded slash stays within the parameter and is unescaped
+			client.GET("/files/docs%2Freadme.md/raw").Expect(t).
+				Status(http.StatusOK).
+				Body("docs/readme.md")
+		})
+	}
+}
+
+func TestRunWithGracefulShutdown(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "").
+				WithDefaultRandomPort().
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			started, release := make(chan struct{}), make(chan struct{})
+			s.GET("/ready", func(c core.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+			s.GET("/slow", func(c core.Context) {
+				close(started)
+				<-release
+				c.String(http.StatusOK, "done")
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan error, 1)
+			go func() {
+				done <- s.RunWithGracefulShutdown(ctx, 5*time.Second)
+			}()
+
+			// Connections left open by the client would hold up the shutdown
+			client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+			base := "http://127.0.0.1:" + s.GetPort()
+			for i := 0; ; i++ {
+				resp, err := client.Get(base + "/ready")
+				if err == nil {
+					resp.Body.Close()
+					break
+				}
+				if i == 100 {
+					t.Fatalf("server did not start: %v", err)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			// A request in flight when the shutdown starts is completed
+			type result struct {
+				body string
+				err  error
+			}
+			slow := make(chan result, 1)
+			go func() {
+				resp, err := client.Get(base + "/slow")
+				if err != nil {
+					slow <- result{err: err}
+					return
+				}
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				slow <- result{string(body), err}
+			}()
+			<-started
+			cancel()
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+
+			if r := <-slow; r.err != nil || r.body != "done" {
+				t.Errorf("in-flight request got %q, %v, want %q", r.body, r.err, "done")
+			}
+			if err := <-done; err != nil {
+				t.Errorf("RunWithGracefulShutdown() returned error: %v", err)
+			}
+		})
+	}
+}
+
+func TestJSONPolicy(t *testing.T) {
+	t.Cleanup(func() { core.SetJSONCodec(nil) })
+
+	type invoice struct {
+		ID       int        `json:"id"`
+		Total    float64    `json:"total"`
+		IssuedAt time.Time  `json:"issued_at"`
+		PaidAt   *time.Time `json:"paid_at"`
+		Note     string     `json:"note"`
+	}
+	issued := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithJSONPolicy(JSONPolicy{TimeFormat: JSONTimeUnix, DecimalAsString: true, OmitZero: true}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/invoice", func(c core.Context) {
+				c.JSON(http.StatusOK, invoice{ID: 1, Total: 19.99, IssuedAt: issued})
+			})
+
+			servertest.NewClient(s).GET("/invoice").Expect(t).
+				Status(http.StatusOK).
+				BodyContains(`{"id":1,"total":"19.99","issued_at":1714564800}`)
+		})
+	}
+}
+
+type publicController struct{}
+
+func (publicController) GetHttpMethod() core.HttpMethod { return core.GET }
+func (publicController) GetPath() string                { return "/public" }
+func (publicController) SkipLogging() bool              { return false }
+func (publicController) SkipAuthCheck() bool            { return true }
+
+func (publicController) Handler() []core.HandlerFunc {
+	return []core.HandlerFunc{func(c core.Context) {
+		c.String(http.StatusOK, "public")
+	}}
+}
+
+func TestWithAuth(t *testing.T) {
+	_, err := NewServerBuilder(core.FrameworkStdHTTP, "8080").
+		WithFrameworkLogs(false).
+		WithAuth(AuthConfig{AuthType: AuthTypeJWT, JWTLookup: clockJWTLookup{}}).
+		Build()
+	var configErr *ConfigError
+	if !errors.Is(err, ErrInvalidConfig) || !errors.As(err, &configErr) || configErr.Field != "JWTSecret" {
+		t.Fatalf("Build() returned %v, want a ConfigError for JWTSecret", err)
+	}
+
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			config := AuthConfig{AuthType: AuthTypeJWT, JWTLookup: clockJWTLookup{}, JWTSecret: "secret"}
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithAuth(config).
+				AddControllers(publicController{}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/private", func(c core.Context) {
+				c.String(http.StatusOK, "private")
+			})
+			client := servertest.NewClient(s).WithJWTSecret("secret")
+
+			client.GET("/public").Expect(t).Status(http.StatusOK).Body("public")
+			client.GET("/private").Expect(t).Status(http.StatusUnauthorized)
+			client.GET("/private").WithJWT(MapClaims{"sub": "1"}).Expect(t).
+				Status(http.StatusOK).
+				Body("private")
+		})
+	}
+}
+
+type basicAuthLookup struct{}
+
+func (basicAuthLookup) LookupUserByBasicAuth(username, password string) (interface{}, error) {
+	if password != "password" {
+		return nil, errors.New("invalid password")
+	}
+	return username, nil
+}
+
+func TestWithJWTAndBasicAuth(t *testing.T) {
+	skipPatterns := []string{"/public", "/docs/*", "/users/:id/profile"}
+	builders := map[string]func(*ServerBuilder) *ServerBuilder{
+		"jwt": func(b *ServerBuilder) *ServerBuilder {
+			return b.WithJWTAuth(clockJWTLookup{}, "secret", skipPatterns...)
+		},
+		"basic": func(b *ServerBuilder) *ServerBuilder {
+			return b.WithBasicAuth(basicAuthLookup{}, skipPatterns...)
+		},
+	}
+
+	for name, withAuth := range builders {
+		t.Run(name, func(t *testing.T) {
+			s, err := withAuth(NewServerBuilder(core.FrameworkStdHTTP, "8080").WithFrameworkLogs(false)).Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			for _, path := range []string{"/public", "/docs/intro", "/users/:id/profile", "/users/:id/orders"} {
+				s.GET(path, func(c core.Context) {
+					c.String(http.StatusOK, "ok")
+				})
+			}
+			client := servertest.NewClient(s).WithJWTSecret("secret")
+
+			client.GET("/public").Expect(t).Status(http.StatusOK)
+			client.GET("/docs/intro").Expect(t).Status(http.StatusOK)
+			client.GET("/users/42/profile").Expect(t).Status(http.StatusOK)
+			client.GET("/users/42/orders").Expect(t).Status(http.StatusUnauthorized)
+
+			authorized := client.GET("/users/42/orders")
+			if name == "jwt" {
+				authorized.WithJWT(MapClaims{"sub": "1"})
+			} else {
+				authorized.WithBasicAuth("john", "password")
+			}
+			authorized.Expect(t).Status(http.StatusOK)
+		})
+	}
+}
+
+func TestWithPolicy(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithJWTAuth(clockJWTLookup{}, "secret").
+				WithPolicy(func(c core.Context, user interface{}, route string) Decision {
+					if user == "1" && route == "GET /accounts/1" {
+						return PolicyAllow()
+					}
+					return PolicyDeny("not your account")
+				}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/accounts/:id", func(c core.Context) {
+				c.String(http.StatusOK, "account")
+			})
+			client := servertest.NewClient(s).WithJWTSecret("secret")
+
+			client.GET("/accounts/1").WithJWT(MapClaims{"sub": "1"}).Expect(t).
+				Status(http.StatusOK)
+			client.GET("/accounts/2").WithJWT(MapClaims{"sub": "1"}).Expect(t).
+				Status(http.StatusForbidden).
+				BodyContains("not your account")
+			client.GET("/accounts/1").Expect(t).
+				Status(http.StatusUnauthorized)
+		})
+	}
+}
+
+func TestTimeoutCancelsRequestContext(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			var loggedStatus int
+			handlerErr := make(chan error, 1)
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				AddMiddleware(func(c core.Context) {
+					c.SetHeader("X-Request-ID", "req-1")
+					c.Next()
+					loggedStatus = c.Writer().Status()
+				}).
+				AddMiddleware(TimeoutMiddleware(&TimeoutConfig{Timeout: 20 * time.Millisecond})).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/slow", func(c core.Context) {
+				c.SetHeader("X-Partial", "true")
+				<-c.Request().Context().Done()
+				handlerErr <- c.Request().Context().Err()
+				c.String(http.StatusOK, "too late")
+			})
+			s.GET("/created", func(c core.Context) {
+				c.SetHeader("X-Handler", "true")
+				c.JSON(http.StatusCreated, map[string]string{"id": "1"})
+			})
+			client := servertest.NewClient(s)
+
+			rec := client.GET("/slow").Expect(t).
+				Status(http.StatusGatewayTimeout).
+				Header("X-Request-ID", "req-1").
+				Body("Request timed out after 20ms").
+				Recorder()
+			if err := <-handlerErr; !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("handler context error = %v, want DeadlineExceeded", err)
+			}
+			if rec.Header().Get("X-Partial") != "" {
+				t.Error("headers of the timed out handler were sent")
+			}
+			if loggedStatus != http.StatusGatewayTimeout {
+				t.Errorf("outer middleware read status %d, want 504", loggedStatus)
+			}
+
+			client.GET("/created").Expect(t).
+				Status(http.StatusCreated).
+				Header("X-Request-ID", "req-1").
+				Header("X-Handler", "true").
+				JSONPath("$.id", "1")
+			if loggedStatus != http.StatusCreated {
+				t.Errorf("outer middleware read status %d, want 201", loggedStatus)
+			}
+		})
+	}
+}
+
+type prioritizedController struct {
+	exampleController
+	priority core.Priority
+}
+
+func (c *prioritizedController) Priority() core.Priority { return c.priority }
+
+func TestWithWatchdog(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "").
+				WithDefaultRandomPort().
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				AddController(&prioritizedController{exampleController{method: core.GET, path: "/payments"}, PriorityCritical}).
+				AddController(&exampleController{method: core.GET, path: "/orders"}).
+				WithWatchdog(WatchdogConfig{
+					// Always exceeded once the server runs, so that all but critical routes are shed
+					MaxGoroutines:     1,
+					SevereGoroutines:  1,
+					LowPriorityRoutes: []string{"/analytics/*"},
+				}).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.GET("/analytics/events", func(c core.Context) {
+				c.String(http.StatusOK, "ok")
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan error, 1)
+			go func() {
+				done <- s.RunWithGracefulShutdown(ctx, 5*time.Second)
+			}()
+
+			// The watchdog checks the process when the server starts
+			// Connections left open by the client would hold up the shutdown
+			client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+			base := "http://127.0.0.1:" + s.GetPort()
+			for i := 0; ; i++ {
+				resp, err := client.Get(base + "/analytics/events")
+				if err == nil {
+					resp.Body.Close()
+					if resp.StatusCode == http.StatusServiceUnavailable {
+						break
+					}
+				}
+				if i == 100 {
+					t.Fatalf("low-priority route was not rejected: %v", err)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			for path, want := range map[string]int{"/payments": http.StatusOK, "/orders": http.StatusServiceUnavailable} {
+				resp, err := client.Get(base + path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != want {
+					t.Errorf("GET %s returned %d, want %d", path, resp.StatusCode, want)
+				}
+			}
+
+			cancel()
+			if err := <-done; err != nil {
+				t.Errorf("RunWithGracefulShutdown() returned error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDescribeMiddleware(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultErrorHandling().
+				WithTimeout(TimeoutConfig{Timeout: 5 * time.Second}).
+				AddControllers(&slowController{path: "/stream", skip: []string{MiddlewareTimeout}}).
+				AddNamedMiddleware("audit", func(c core.Context) { c.Next() }).
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			s.Group("/api").Use(func(c core.Context) { c.Next() })
+
+			chain := s.DescribeMiddleware()
+			if len(chain) != 4 {
+				t.Fatalf("DescribeMiddleware() returned %d middleware, want 4:\n%s", len(chain), chain)
+			}
+			want := []MiddlewareDescription{
+				{Name: MiddlewareErrorHandler, Config: "default", AppliesTo: AllRoutes},
+				{Name: MiddlewareTimeout, Config: "5s", AppliesTo: AllRoutes, Except: []string{"GET /stream"}},
+				{Name: "audit", AppliesTo: AllRoutes},
+			}
+			for i, w := range want {
+				got := chain[i]
+				if got.Name != w.Name || got.Config != w.Config || got.AppliesTo != w.AppliesTo ||
+					strings.Join(got.Except, ",") != strings.Join(w.Except, ",") {
+					t.Errorf("middleware %d = %+v, want %+v", i, got, w)
+				}
+			}
+			if chain[3].AppliesTo != "/api/*" {
+				t.Errorf("group middleware applies to %q, want /api/*", chain[3].AppliesTo)
+			}
+		})
+	}
+}
+
+func TestWithDebugTrace(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultErrorHandling().
+				WithJWTAuth(clockJWTLookup{}, "secret", DefaultDebugTracePath).
+				AddController(&exampleController{method: core.GET, path: "/orders"}).
+				WithDebugTrace("s3cret").
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			client := servertest.NewClient(s).WithJWTSecret("secret").WithHeader(DefaultDebugTraceHeader, "s3cret")
+
+			rec := client.GET("/orders").Expect(t).Status(http.StatusUnauthorized).Recorder()
+			id := rec.Header().Get(DebugTraceIDHeader)
+			if id == "" {
+				t.Fatal("response has no trace ID")
+			}
+			var trace DebugTrace
+			client.GET(DefaultDebugTracePath).WithQuery("id", id).Expect(t).Status(http.StatusOK).DecodeJSON(&trace)
+			if len(trace.Steps) < 2 || trace.Steps[0].Name != MiddlewareErrorHandler || trace.Steps[1].Name != MiddlewareAuth {
+				t.Fatalf("steps = %+v, want the error handler and auth first", trace.Steps)
+			}
+			if auth := trace.Steps[1]; !auth.Wrote || auth.StatusBefore != http.StatusOK || auth.StatusAfter != http.StatusUnauthorized {
+				t.Errorf("auth step = %+v, want it to write the 401", auth)
+			}
+
+			rec = client.GET("/orders").WithJWT(MapClaims{"sub": "1"}).Expect(t).Status(http.StatusOK).Recorder()
+			client.GET(DefaultDebugTracePath).WithQuery("id", rec.Header().Get(DebugTraceIDHeader)).Expect(t).
+				Status(http.StatusOK).
+				JSONPath("$.steps[2].name", "Handler").
+				JSONPath("$.steps[2].outcome", "handled")
+
+			if chain := s.DescribeMiddleware(); chain[0].Name != MiddlewareDebugTrace {
+				t.Errorf("first middleware = %q, want %q", chain[0].Name, MiddlewareDebugTrace)
+			}
+		})
+	}
+}
+
+type userStore map[string]string
+
+type userController struct {
+	users userStore
+}
+
+func (c *userController) GetHttpMethod() core.HttpMethod { return core.GET }
+func (c *userController) GetPath() string                { return "/users/:id" }
+func (c *userController) SkipLogging() bool              { return false }
+func (c *userController) SkipAuthCheck() bool            { return false }
+
+func (c *userController) Handler() []core.HandlerFunc {
+	return []core.HandlerFunc{func(ctx core.Context) {
+		ctx.String(http.StatusOK, "%s", c.users[ctx.Param("id")])
+	}}
+}
+
+func TestAddControllerFactory(t *testing.T) {
+	factory := func(deps Deps) core.Controller {
+		return &userController{users: MustResolve[userStore](deps)}
+	}
+
+	s, err := NewServerBuilder(core.FrameworkStdHTTP, "8080").
+		WithFrameworkLogs(false).
+		WithDependencies(userStore{"1": "john"}).
+		AddControllerFactory(factory).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() returned error: %v", err)
+	}
+	servertest.NewClient(s).GET("/users/1").Expect(t).Status(http.StatusOK).Body("john")
+
+	_, err = NewServerBuilder(core.FrameworkStdHTTP, "8080").
+		WithFrameworkLogs(false).
+		AddControllerFactory(factory).
+		Build()
+	if !errors.Is(err, ErrDependencyNotFound) {
+		t.Errorf("Build() without the dependency returned %v, want ErrDependencyNotFound", err)
+	}
+}
+
+func TestStaticFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "robots.txt"), []byte("User-agent: *"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	assets := fstest.MapFS{
+		"index.html":    {Data: []byte("<h1>app</h1>")},
+		"js/app.js":     {Data: []byte("console.log(1)")},
+		"img/logo.svg":  {Data: []byte("<svg/>")},
+		"img/README.md": {Data: []byte("unlisted")},
+	}
+
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServer(frameworkType, "8080", false)
+			if err != nil {
+				t.Fatalf("NewServer() returned error: %v", err)
+			}
+			s.StaticFS("/app", assets)
+			s.StaticFile("/robots.txt", filepath.Join(dir, "robots.txt"))
+			s.Group("/v1").Static("/files", dir)
+			client := servertest.NewClient(s)
+
+			client.GET("/app/js/app.js").Expect(t).Status(http.StatusOK).Body("console.log(1)")
+			client.GET("/app/").Expect(t).Status(http.StatusOK).Body("<h1>app</h1>")
+			client.Request(http.MethodHead, "/app/js/app.js").Expect(t).Status(http.StatusOK)
+			client.GET("/robots.txt").Expect(t).Status(http.StatusOK).Body("User-agent: *")
+			client.GET("/v1/files/robots.txt").Expect(t).Status(http.StatusOK).Body("User-agent: *")
+
+			// Missing files and directories without an index.html are not found
+			client.GET("/app/missing.js").Expect(t).Status(http.StatusNotFound)
+			client.GET("/app/img/").Expect(t).Status(http.StatusNotFound)
+		})
+	}
+}
+
+func TestDisableRoute(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServer(frameworkType, "8080", false)
+			if err != nil {
+				t.Fatalf("NewServer() returned error: %v", err)
+			}
+			started, release := make(chan struct{}), make(chan struct{})
+			s.Group("/v1").GET("/reports/:id", func(c core.Context) {
+				close(started)
+				<-release
+				c.String(http.StatusOK, "report")
+			})
+			s.GET("/orders", func(c core.Context) {
+				c.String(http.StatusOK, "orders")
+			})
+			client := servertest.NewClient(s)
+
+			inFlight := make(chan *httptest.ResponseRecorder)
+			go func() {
+				rec, _ := client.GET("/v1/reports/1").Do()
+				inFlight <- rec
+			}()
+			<-started
+
+			drain, err := s.DisableRoute(core.GET, "/v1/reports/:id", http.StatusGone)
+			if err != nil {
+				t.Fatalf("DisableRoute() error = %v", err)
+			}
+			client.GET("/v1/reports/2").Expect(t).Status(http.StatusGone)
+			client.GET("/orders").Expect(t).Status(http.StatusOK)
+			select {
+			case <-drain.Done():
+				t.Fatal("drain completed while a request was in flight")
+			default:
+			}
+
+			// The in-flight request finishes normally and completes the drain
+			close(release)
+			if rec := <-inFlight; rec.Code != http.StatusOK || rec.Body.String() != "report" {
+				t.Errorf("in-flight request = %d %q, want 200 report", rec.Code, rec.Body.String())
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := drain.Wait(ctx); err != nil || drain.InFlight() != 0 {
+				t.Errorf("Wait() = %v with %d in flight, want nil with 0", err, drain.InFlight())
+			}
+
+			if _, err := s.DisableRoute(core.GET, "/missing"); !errors.Is(err, ErrRouteNotFound) {
+				t.Errorf("DisableRoute() of a missing route error = %v, want ErrRouteNotFound", err)
+			}
+		})
+	}
+}
+
+func TestPanicHttpErrors(t *testing.T) {
+	panics := map[string]error{
+		"/payment":  NewPaymentRequiredHttpError(errors.New("subscription expired")),
+		"/conflict": NewConflictHttpError(errors.New("order already exists")),
+		"/gone":     NewGoneHttpError(errors.New("endpoint removed")),
+		"/entity":   NewUnprocessableEntityHttpError(errors.New("invalid state transition")),
+		"/upstream": NewBadGatewayHttpError(errors.New("payment provider failed")),
+		"/plain":    errors.New("boom"),
+	}
+	want := map[string]int{
+		"/payment":  http.StatusPaymentRequired,
+		"/conflict": http.StatusConflict,
+		"/gone":     http.StatusGone,
+		"/entity":   http.StatusUnprocessableEntity,
+		"/upstream": http.StatusBadGateway,
+		"/plain":    http.StatusInternalServerError,
+	}
+
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServerBuilder(frameworkType, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultLogging(false).
+				WithDefaultErrorHandling().
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			for path, panicErr := range panics {
+				s.GET(path, func(c core.Context) {
+					panic(panicErr)
+				})
+			}
+
+			// Panicking with an HTTP error keeps its status; other errors are 500s
+			client := servertest.NewClient(s)
+			for path, status := range want {
+				client.GET(path).Expect(t).
+					Status(status).
+					JSONPath("$.error.code", float64(status))
+			}
+			client.GET("/conflict").Expect(t).JSONPath("$.error.message", "order already exists")
+
+			// Too Many Requests errors carry their retry delay in whole seconds
+			s.GET("/export", func(c core.Context) {
+				_ = c.Error(NewTooManyRequestsHttpError(errors.New("export quota exceeded"), 1500*time.Millisecond))
+			})
+			client.GET("/export").Expect(t).
+				Status(http.StatusTooManyRequests).
+				Header("Retry-After", "2").
+				JSONPath("$.error.retry_after", float64(2))
+		})
+	}
+}
+
+func TestBindForm(t *testing.T) {
+	type searchRequest struct {
+		Query    string    `form:"q" validate:"required"`
+		Page     int       `form:"page,default=1"`
+		Tags     []string  `form:"tag"`
+		Since    time.Time `form:"since" time_format:"2006-01-02" time_utc:"true"`
+		Internal string    `form:"-"`
+	}
+	type uploadRequest struct {
+		Title string                `form:"title"`
+		File  *multipart.FileHeader `form:"file"`
+	}
+
+	for _, framework := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(framework), func(t *testing.T) {
+			s, err := NewServerBuilder(framework, "8080").
+				WithFrameworkLogs(false).
+				WithDefaultErrorHandling().
+				Build()
+			if err != nil {
+				t.Fatalf("Build() returned error: %v", err)
+			}
+			search := func(c core.Context) {
+				var req searchRequest
+				if err := c.Bind(&req); err != nil {
+					_ = c.Error(err)
+					return
+				}
+				c.JSON(http.StatusOK, map[string]interface{}{
+					"q": req.Query, "page": req.Page, "tags": req.Tags,
+					"since": req.Since.Format(time.DateOnly), "internal": req.Internal,
+				})
+			}
+			s.GET("/search", search)
+			s.POST("/search", search)
+			s.POST("/upload", func(c core.Context) {
+				var req uploadRequest
+				if err := c.Bind(&req); err != nil {
+					_ = c.Error(err)
+					return
+				}
+				c.String(http.StatusOK, "%s:%s:%d", req.Title, req.File.Filename, req.File.Size)
+			})
+			client := servertest.NewClient(s)
+
+			form := url.Values{"q": {"go"}, "tag": {"web", "http"}, "since": {"2024-03-01"}, "-": {"x"}}
+			client.POST("/search").
+				WithBody("application/x-www-form-urlencoded; charset=utf-8", []byte(form.Encode())).
+				Expect(t).
+				Status(http.StatusOK).
+				JSONPath("$.q", "go").
+				JSONPath("$.page", float64(1)).
+				JSONPath("$.tags[1]", "http").
+				JSONPath("$.since", "2024-03-01").
+				JSONPath("$.internal", "")
+			client.GET("/search").WithQuery("q", "go").WithQuery("page", "3").Expect(t).
+				Status(http.StatusOK).
+				JSONPath("$.page", float64(3))
+
+			// Invalid values and failed validation are client errors
+			client.GET("/search").WithQuery("q", "go").WithQuery("page", "two").Expect(t).
+				Status(http.StatusBadRequest)
+			client.POST("/search").
+				WithBody("application/x-www-form-urlencoded", []byte("page=2")).
+				Expect(t).
+				Status(http.StatusBadRequest).
+				JSONPath("$.error.fields[0].field", "Query")
+
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			_ = writer.WriteField("title", "report")
+			part, _ := writer.CreateFormFile("file", "report.csv")
+			_, _ = part.Write([]byte("a,b\n1,2\n"))
+			_ = writer.Close()
+			client.POST("/upload").
+				WithBody(writer.FormDataContentType(), body.Bytes()).
+				Expect(t).
+				Status(http.StatusOK).
+				Body("report:report.csv:8")
+		})
+	}
+}
+
+func TestStreamingResponses(t *testing.T) {
+	for _, frameworkType := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(frameworkType), func(t *testing.T) {
+			s, err := NewServer(frameworkType, "8080", false)
+			if err != nil {
+				t.Fatalf("NewServer() returned error: %v", err)
+			}
+			s.GET("/events", func(c core.Context) {
+				c.SetHeader("Content-Type", "text/event-stream")
+				c.SetStatus(http.StatusAccepted)
+				n := 0
+				if err := c.Stream(func(w io.Writer) bool {
+					n++
+					fmt.Fprintf(w, "data: %d\n\n", n)
+					return n < 3
+				}); err != nil {
+					t.Errorf("Stream() returned error: %v", err)
+				}
+			})
+			s.GET("/known", func(c core.Context) {
+				if err := c.DataFromReader(http.StatusOK, 5, "text/plain", strings.NewReader("hello")); err != nil {
+					t.Errorf("DataFromReader() returned error: %v", err)
+				}
+			})
+			s.GET("/unknown", func(c core.Context) {
+				// A reader that is not an io.Seeker, as when proxying another response
+				body := io.MultiReader(strings.NewReader("chunked "), strings.NewReader("body"))
+				if err := c.DataFromReader(http.StatusOK, -1, "application/octet-stream", body); err != nil {
+					t.Errorf("DataFromReader() returned error: %v", err)
+				}
+			})
+			client := servertest.NewClient(s)
+
+			client.GET("/events").Expect(t).
+				Status(http.StatusAccepted).
+				Header("Content-Type", "text/event-stream").
+				Body("data: 1\n\ndata: 2\n\ndata: 3\n\n")
+			client.GET("/known").Expect(t).
+				Status(http.StatusOK).
+				Header("Content-Type", "text/plain").
+				Header("Content-Length", "5").
+				Body("hello")
+			client.GET("/unknown").Expect(t).
+				Status(http.StatusOK).
+				Header("Content-Type", "application/octet-stream").
+				Body("chunked body")
+		})
+	}
+}
+
+func TestMultipartHelpers(t *testing.T) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	_ = writer.WriteField("album", "summer")
+	for _, name := range []string{"a.jpg", "b.jpg"} {
+		part, _ := writer.CreateFormFile("photos", name)
+		_, _ = part.Write([]byte("photo " + name))
+	}
+	_ = writer.Close()
+
+	for _, framework := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		t.Run(string(framework), func(t *testing.T) {
+			dir := t.TempDir()
+			s, err := NewServer(framework, "8080", false)
+			if err != nil {
+				t.Fatalf("NewServer() returned error: %v", err)
+			}
+			s.POST("/photos", MultipartMemory(1), func(c core.Context) {
+				file, err := c.FormFile("photos")
+				if err != nil {
+					t.Errorf("FormFile() returned error: %v", err)
+					return
+				}
+				if err := c.SaveUploadedFile(file, filepath.Join(dir, "albums", filepath.Base(file.Filename))); err != nil {
+					t.Errorf("SaveUploadedFile() returned error: %v", err)
+					return
+				}
+				if _, err := c.FormFile("cover"); !errors.Is(err, http.ErrMissingFile) {
+					t.Errorf("FormFile(cover) error = %v, want http.ErrMissingFile", err)
+				}
+				form, err := c.MultipartForm()
+				if err != nil {
+					t.Errorf("MultipartForm() returned error: %v", err)
+					return
+				}
+				c.String(http.StatusOK, "%s:%d", form.Value["album"][0], len(form.File["photos"]))
+			})
+			s.POST("/form", func(c core.Context) {
+				_, err := c.MultipartForm()
+				c.String(http.StatusBadRequest, "%v", errors.Is(err, http.ErrNotMultipart))
+			})
+
+			client := servertest.NewClient(s)
+			client.POST("/photos").
+				WithBody(writer.FormDataContentType(), body.Bytes()).
+				Expect(t).
+				Status(http.StatusOK).
+				Body("summer:2")
+			saved, err := os.ReadFile(filepath.Join(dir, "albums", "a.jpg"))
+			if err != nil || string(saved) != "photo a.jpg" {
+				t.Errorf("saved file = %q, %v, want %q", saved, err, "photo a.jpg")
+			}
+			client.POST("/form").
+				WithBody("application/x-www-form-urlencoded", []byte("album=summer")).
+				Expect(t).
+				Body("true")
+		})
+	}
+}
+
+func TestBindContentType(t *testing.T) {
+	type item struct {
+		Name string `json:"name" form:"name" validate:"required"`
+	}
+
+	for _, framework := range []core.FrameworkType{core.FrameworkGin, core.FrameworkStdHTTP, core.FrameworkChi, core.FrameworkFiber} {
+		for _, strict := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/strict=%t", framework, strict), func(t *testing.T) {
+				s, err := NewServerBuilder(framework, "8080").
+					WithFrameworkLogs(false).
+					WithDefaultErrorHandling().
+					WithEngineOptions(EngineOptions{StrictContentType: strict}).
+					Build()
+				if err != nil {
+					t.Fatalf("Build() returned error: %v", err)
+				}
+				s.POST("/bind", func(c core.Context) {
+					var req item
+					if err := c.Bind(&req); err != nil {
+						_ = c.Error(err)
+						return
+					}
+					c.String(http.StatusOK, req.Name)
+				})
+				s.POST("/bind-json", func(c core.Context) {
+					var req item
+					if err := c.BindJSON(&req); err != nil {
+						_ = c.Error(err)
+						return
+					}
+					c.String(http.StatusOK, req.Name)
+				})
+				client := servertest.NewClient(s)
+				body := []byte(`{"name":"widget"}`)
+
+				// Content-Type parameters and structured syntax suffixes are understood
+				for _, contentType := range []string{"application/json; charset=utf-8", "Application/JSON", "application/vnd.api+json"} {
+					client.POST("/bind").WithBody(contentType, body).Expect(t).Status(http.StatusOK).Body("widget")
+					client.POST("/bind-json").WithBody(contentType, body).Expect(t).Status(http.StatusOK).Body("widget")
+				}
+
+				// Bodies in other charsets are always rejected
+				client.POST("/bind-json").WithBody("application/json; charset=iso-8859-1", body).Expect(t).
+					Status(http.StatusUnsupportedMediaType)
+
+				// Unexpected content types are only rejected in strict mode
+				wantStatus := http.StatusOK
+				if strict {
+					wantStatus = http.StatusUnsupportedMediaType
+				}
+				client.POST("/bind-json").WithBody("text/plain", body).Expect(t).Status(wantStatus)
+				client.POST("/bind").WithBody("application/x-www-form-urlencoded", []byte("name=widget")).Expect(t).
+					Status(http.StatusOK).
+					Body("widget")
+			})
+		}
+	}
+}